@@ -0,0 +1,267 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+
+	"monad-dashboard/hexutil"
+)
+
+// A minimal address inspector: balance, nonce and code come from the node,
+// recent activity from an in-memory index of the receipts and monadLogs the
+// dashboard has seen since it started.
+
+// Bounds on the activity index
+const (
+	maxIndexedAddresses = 50000
+	maxAddressActivity  = 25
+)
+
+// Activity kinds
+const (
+	activityTxSent          = "tx_sent"
+	activityTxReceived      = "tx_received"
+	activityContractCreated = "contract_created"
+	activityLogEmitted      = "log_emitted"
+	activityTokenSent       = "token_sent"
+	activityTokenReceived   = "token_received"
+)
+
+// AddressActivity is one indexed event involving an address
+type AddressActivity struct {
+	Kind         string `json:"kind"`
+	BlockNumber  int64  `json:"block_number"`
+	TxHash       string `json:"transaction_hash"`
+	Counterparty string `json:"counterparty,omitempty"` // Other side of a transaction or transfer
+	Token        string `json:"token,omitempty"`
+	Value        string `json:"value,omitempty"` // Token amount or id, decimal
+	Event        string `json:"event,omitempty"` // Signature from the emitter's verified ABI
+	Timestamp    int64  `json:"timestamp,omitempty"`
+}
+
+// indexedAddress is the activity of one address
+type indexedAddress struct {
+	counts    map[string]int64
+	firstSeen int64
+	lastSeen  int64
+	recent    []AddressActivity
+}
+
+// AddressIndex indexes recent activity per address
+type AddressIndex struct {
+	mu        sync.RWMutex
+	addresses map[string]*indexedAddress
+}
+
+// Global address index
+var (
+	addressIndex   *AddressIndex
+	addressIndexMu sync.RWMutex
+)
+
+// NewAddressIndex creates an empty address index
+func NewAddressIndex() *AddressIndex {
+	return &AddressIndex{addresses: make(map[string]*indexedAddress)}
+}
+
+// InitializeAddressIndex creates the global address index
+func InitializeAddressIndex() *AddressIndex {
+	addressIndexMu.Lock()
+	defer addressIndexMu.Unlock()
+	addressIndex = NewAddressIndex()
+	return addressIndex
+}
+
+// GetAddressIndex returns the global address index
+func GetAddressIndex() *AddressIndex {
+	addressIndexMu.RLock()
+	defer addressIndexMu.RUnlock()
+	return addressIndex
+}
+
+// ObserveReceipts indexes the senders, recipients and created contracts of
+// a block's transactions
+func (ai *AddressIndex) ObserveReceipts(blockNumber, timestamp int64, receipts []RPCReceipt) {
+	ai.mu.Lock()
+	defer ai.mu.Unlock()
+	for _, receipt := range receipts {
+		from := strings.ToLower(receipt.From)
+		to := strings.ToLower(receipt.To)
+		activity := AddressActivity{BlockNumber: blockNumber, TxHash: receipt.TransactionHash, Timestamp: timestamp}
+		if to == "" && receipt.ContractAddress != "" {
+			to = strings.ToLower(receipt.ContractAddress)
+			activity.Kind, activity.Counterparty = activityContractCreated, from
+			ai.addLocked(to, activity)
+		} else if to != "" {
+			activity.Kind, activity.Counterparty = activityTxReceived, from
+			ai.addLocked(to, activity)
+		}
+		activity.Kind, activity.Counterparty = activityTxSent, to
+		ai.addLocked(from, activity)
+	}
+}
+
+// ObserveLog indexes the emitter of a log and both sides of a token transfer
+func (ai *AddressIndex) ObserveLog(txLog *TransactionLog) {
+	emitter := strings.ToLower(txLog.Address)
+	activity := AddressActivity{Kind: activityLogEmitted, BlockNumber: txLog.BlockNumber, TxHash: txLog.TransactionHash, Timestamp: txLog.Timestamp}
+	if len(txLog.Topics) > 0 {
+		activity.Event = contractEvent(emitter, txLog.Topics[0])
+	}
+
+	ai.mu.Lock()
+	defer ai.mu.Unlock()
+	ai.addLocked(emitter, activity)
+
+	transfer, ok := DecodeTokenTransfer(txLog)
+	if !ok {
+		return
+	}
+	activity.Token = transfer.Token
+	if transfer.Value != nil {
+		activity.Value = transfer.Value.String()
+	} else if transfer.TokenID != nil {
+		activity.Value = transfer.TokenID.String()
+	}
+	activity.Kind, activity.Counterparty = activityTokenSent, transfer.To
+	ai.addLocked(transfer.From, activity)
+	activity.Kind, activity.Counterparty = activityTokenReceived, transfer.From
+	ai.addLocked(transfer.To, activity)
+}
+
+// addLocked appends one activity to an address; caller holds ai.mu
+func (ai *AddressIndex) addLocked(address string, activity AddressActivity) {
+	if address == "" {
+		return
+	}
+	entry, ok := ai.addresses[address]
+	if !ok {
+		if len(ai.addresses) >= maxIndexedAddresses {
+			ai.evictLocked()
+		}
+		entry = &indexedAddress{counts: make(map[string]int64), firstSeen: activity.BlockNumber}
+		ai.addresses[address] = entry
+	}
+	entry.counts[activity.Kind]++
+	if activity.BlockNumber > entry.lastSeen {
+		entry.lastSeen = activity.BlockNumber
+	}
+	entry.recent = append(entry.recent, activity)
+	if len(entry.recent) > maxAddressActivity {
+		entry.recent = entry.recent[1:]
+	}
+}
+
+// evictLocked drops the tenth of addresses seen least recently; caller
+// holds ai.mu
+func (ai *AddressIndex) evictLocked() {
+	addresses := make([]string, 0, len(ai.addresses))
+	for address := range ai.addresses {
+		addresses = append(addresses, address)
+	}
+	sort.Slice(addresses, func(i, j int) bool {
+		return ai.addresses[addresses[i]].lastSeen < ai.addresses[addresses[j]].lastSeen
+	})
+	for _, address := range addresses[:len(addresses)/10+1] {
+		delete(ai.addresses, address)
+	}
+}
+
+// AddressActivitySummary is the indexed activity of an address
+type AddressActivitySummary struct {
+	Indexed        bool              `json:"indexed"` // False when the address was not seen since startup
+	Counts         map[string]int64  `json:"counts"`
+	FirstSeenBlock int64             `json:"first_seen_block,omitempty"`
+	LastSeenBlock  int64             `json:"last_seen_block,omitempty"`
+	Recent         []AddressActivity `json:"recent"` // Newest first
+}
+
+// Activity returns the indexed activity of an address, at most limit
+// recent entries
+func (ai *AddressIndex) Activity(address string, limit int) AddressActivitySummary {
+	ai.mu.RLock()
+	defer ai.mu.RUnlock()
+
+	summary := AddressActivitySummary{Counts: make(map[string]int64), Recent: make([]AddressActivity, 0)}
+	entry, ok := ai.addresses[address]
+	if !ok {
+		return summary
+	}
+	summary.Indexed = true
+	summary.FirstSeenBlock, summary.LastSeenBlock = entry.firstSeen, entry.lastSeen
+	for kind, count := range entry.counts {
+		summary.Counts[kind] = count
+	}
+	for i := len(entry.recent) - 1; i >= 0 && len(summary.Recent) < limit; i-- {
+		summary.Recent = append(summary.Recent, entry.recent[i])
+	}
+	return summary
+}
+
+// AddressResponse is the body of /api/v1/address/:addr
+type AddressResponse struct {
+	Address    string                 `json:"address"`
+	Balance    string                 `json:"balance"` // Wei, decimal
+	BalanceMON float64                `json:"balance_mon"`
+	Nonce      uint64                 `json:"nonce"`
+	IsContract bool                   `json:"is_contract"`
+	CodeSize   int                    `json:"code_size"` // Bytes of deployed code
+	Activity   AddressActivitySummary `json:"activity"`
+}
+
+// handleAddress returns the balance, nonce, code presence and recent
+// activity of an address (?limit= activity entries, default 25)
+func handleAddress(c *gin.Context) {
+	address, err := normalizeAddress(c.Param("addr"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, APIError{Error: err.Error()})
+		return
+	}
+	limit := maxAddressActivity
+	if value := c.Query("limit"); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n >= 0 && n < limit {
+			limit = n
+		}
+	}
+	client := GetMonadClient()
+	if client == nil {
+		c.JSON(http.StatusServiceUnavailable, APIError{Error: "Monad client not initialized"})
+		return
+	}
+
+	balance, err := client.GetBalance(address)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, APIError{Error: err.Error()})
+		return
+	}
+	var nonce, code string
+	if err := client.callResult("eth_getTransactionCount", []interface{}{address, "latest"}, &nonce); err != nil {
+		c.JSON(http.StatusBadGateway, APIError{Error: err.Error()})
+		return
+	}
+	if err := client.callResult("eth_getCode", []interface{}{address, "latest"}, &code); err != nil {
+		c.JSON(http.StatusBadGateway, APIError{Error: err.Error()})
+		return
+	}
+	codeBytes, _ := hexutil.DecodeBytes(code)
+
+	response := AddressResponse{
+		Address:    address,
+		Balance:    balance.String(),
+		BalanceMON: weiToMON(balance),
+		Nonce:      hexutil.Uint64OrZero(nonce),
+		IsContract: len(codeBytes) > 0,
+		CodeSize:   len(codeBytes),
+	}
+	if ai := GetAddressIndex(); ai != nil {
+		response.Activity = ai.Activity(address, limit)
+	} else {
+		response.Activity = AddressActivitySummary{Counts: make(map[string]int64), Recent: make([]AddressActivity, 0)}
+	}
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,415 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"monad-dashboard/hexutil"
+)
+
+// The analytics exporter streams block, transaction and waterfall interval
+// records into ClickHouse or TimescaleDB for long-term analytical queries.
+// Blocks and their transactions are exported once their receipts are in
+// (the fee tracker's pass, which also covers gap-repaired blocks), waterfall
+// intervals as each one closes. Rows are buffered per table and written in
+// batches of EXPORT_BATCH_SIZE, or every EXPORT_FLUSH_INTERVAL; a failed
+// batch stays buffered and is retried with exponential backoff, and once
+// EXPORT_BUFFER rows are waiting the oldest are dropped. Before the first
+// write the backend brings the schema up to date: each sink keeps numbered
+// migrations and records the applied ones in monad_schema_migrations.
+// Writes are idempotent (ReplacingMergeTree in ClickHouse, ON CONFLICT DO
+// NOTHING in TimescaleDB), so a retried batch does not duplicate rows.
+
+// Exported tables
+const (
+	exportBlocks       = "monad_blocks"
+	exportTransactions = "monad_transactions"
+	exportWaterfall    = "monad_waterfall_intervals"
+)
+
+// exportColumns lists each table's columns in row order
+var exportColumns = map[string][]string{
+	exportBlocks: {"time", "number", "hash", "parent_hash", "miner", "tx_count", "gas_used", "gas_limit",
+		"base_fee_per_gas", "succeeded", "reverted"},
+	exportTransactions: {"time", "block_number", "tx_index", "hash", "from_address", "to_address",
+		"contract_address", "success", "gas_used", "effective_gas_price", "log_count"},
+	exportWaterfall: {"time", "interval_seconds", "lifecycle", "stages"},
+}
+
+// exportTableOrder is the order tables are flushed in
+var exportTableOrder = []string{exportBlocks, exportTransactions, exportWaterfall}
+
+// AnalyticsSink writes exported rows to one database
+type AnalyticsSink interface {
+	// Name is the sink kind, "clickhouse" or "timescaledb"
+	Name() string
+	// Migrate applies pending schema migrations and returns the schema version
+	Migrate(ctx context.Context) (int, error)
+	// Insert writes rows (values in exportColumns order) to table
+	Insert(ctx context.Context, table string, rows [][]interface{}) error
+}
+
+// ExportTableStats is one table's export progress
+type ExportTableStats struct {
+	Table         string     `json:"table"`
+	Exported      int64      `json:"exported"`
+	Pending       int        `json:"pending"`
+	Dropped       int64      `json:"dropped"` // Oldest rows dropped while the buffer was full
+	Batches       int64      `json:"batches"`
+	FailedBatches int64      `json:"failed_batches"`
+	LastFlush     *time.Time `json:"last_flush,omitempty"`
+}
+
+// AnalyticsExportStatus is the body of /api/v1/export
+type AnalyticsExportStatus struct {
+	Enabled       bool               `json:"enabled"`
+	Sink          string             `json:"sink,omitempty"`
+	SchemaVersion int                `json:"schema_version"`
+	Migrated      bool               `json:"migrated"`
+	BatchSize     int                `json:"batch_size,omitempty"`
+	FlushSeconds  float64            `json:"flush_interval_seconds,omitempty"`
+	BufferSize    int                `json:"buffer_size,omitempty"`
+	RetryIn       float64            `json:"retry_in_seconds,omitempty"` // Backoff before the next attempt after a failure
+	LastError     string             `json:"last_error,omitempty"`
+	LastErrorAt   *time.Time         `json:"last_error_at,omitempty"`
+	Tables        []ExportTableStats `json:"tables,omitempty"`
+}
+
+// AnalyticsExporter buffers records and writes them to the sink in batches
+type AnalyticsExporter struct {
+	sink       AnalyticsSink
+	batchSize  int
+	flushEvery time.Duration
+	maxBuffer  int
+
+	mu            sync.Mutex
+	pending       map[string][][]interface{}
+	stats         map[string]*ExportTableStats
+	schemaVersion int
+	migrated      bool
+	backoff       time.Duration
+	lastError     string
+	lastErrorAt   *time.Time
+	lastInterval  time.Time      // When the previous waterfall interval closed
+	shifted       map[string]int // Rows dropped from the front while a batch was being written
+
+	wake chan struct{}
+	done chan struct{}
+}
+
+// Retry backoff bounds
+const (
+	minExportBackoff = time.Second
+	maxExportBackoff = time.Minute
+)
+
+// Global analytics exporter instance
+var (
+	analyticsExporter   *AnalyticsExporter
+	analyticsExporterMu sync.RWMutex
+)
+
+// InitializeAnalyticsExporter starts the exporter when EXPORT_SINK is
+// clickhouse or timescaledb, writing to EXPORT_URL
+func InitializeAnalyticsExporter() *AnalyticsExporter {
+	kind := strings.ToLower(os.Getenv("EXPORT_SINK"))
+	if kind == "" {
+		return nil
+	}
+	sink, err := newAnalyticsSink(kind, os.Getenv("EXPORT_URL"))
+	if err != nil {
+		log.Printf("Analytics export disabled: %v", err)
+		return nil
+	}
+
+	e := &AnalyticsExporter{
+		sink:       sink,
+		batchSize:  500,
+		flushEvery: 5 * time.Second,
+		maxBuffer:  100000,
+		pending:    make(map[string][][]interface{}),
+		stats:      make(map[string]*ExportTableStats),
+		shifted:    make(map[string]int),
+		wake:       make(chan struct{}, 1),
+		done:       make(chan struct{}),
+	}
+	if value := os.Getenv("EXPORT_BATCH_SIZE"); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			e.batchSize = n
+		} else {
+			log.Printf("Invalid EXPORT_BATCH_SIZE %q, using %d", value, e.batchSize)
+		}
+	}
+	if value := os.Getenv("EXPORT_FLUSH_INTERVAL"); value != "" {
+		if d, err := time.ParseDuration(value); err == nil && d > 0 {
+			e.flushEvery = d
+		} else {
+			log.Printf("Invalid EXPORT_FLUSH_INTERVAL %q, using %s", value, e.flushEvery)
+		}
+	}
+	if value := os.Getenv("EXPORT_BUFFER"); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			e.maxBuffer = n
+		} else {
+			log.Printf("Invalid EXPORT_BUFFER %q, using %d", value, e.maxBuffer)
+		}
+	}
+	for _, table := range exportTableOrder {
+		e.stats[table] = &ExportTableStats{Table: table}
+	}
+
+	analyticsExporterMu.Lock()
+	analyticsExporter = e
+	analyticsExporterMu.Unlock()
+
+	log.Printf("Analytics export to %s (batches of %d, every %s)", sink.Name(), e.batchSize, e.flushEvery)
+	go e.run()
+	return e
+}
+
+// GetAnalyticsExporter returns the global exporter, nil when disabled
+func GetAnalyticsExporter() *AnalyticsExporter {
+	analyticsExporterMu.RLock()
+	defer analyticsExporterMu.RUnlock()
+	return analyticsExporter
+}
+
+// newAnalyticsSink creates the sink of the given kind
+func newAnalyticsSink(kind, rawURL string) (AnalyticsSink, error) {
+	if rawURL == "" {
+		return nil, fmt.Errorf("EXPORT_URL not set")
+	}
+	switch kind {
+	case "clickhouse":
+		return NewClickHouseSink(rawURL)
+	case "timescaledb", "timescale":
+		return NewTimescaleSink(rawURL)
+	}
+	return nil, fmt.Errorf("unknown EXPORT_SINK %q (clickhouse or timescaledb)", kind)
+}
+
+// enqueue buffers a row, dropping the table's oldest rows when full
+func (e *AnalyticsExporter) enqueue(table string, rows ...[]interface{}) {
+	e.mu.Lock()
+	e.pending[table] = append(e.pending[table], rows...)
+	if over := len(e.pending[table]) - e.maxBuffer; over > 0 {
+		e.pending[table] = e.pending[table][over:]
+		e.stats[table].Dropped += int64(over)
+		e.shifted[table] += over
+	}
+	full := len(e.pending[table]) >= e.batchSize
+	e.mu.Unlock()
+
+	if full {
+		select {
+		case e.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// ObserveBlock exports a block and its transactions from its receipts
+func (e *AnalyticsExporter) ObserveBlock(block *RPCBlock, receipts []RPCReceipt) {
+	number := hexutil.Int64OrZero(block.Number)
+	at := time.Unix(hexutil.Int64OrZero(block.Timestamp), 0).UTC()
+
+	succeeded, reverted := 0, 0
+	txs := make([][]interface{}, 0, len(receipts))
+	for _, receipt := range receipts {
+		success := receipt.Status == "0x1"
+		if success {
+			succeeded++
+		} else {
+			reverted++
+		}
+		txs = append(txs, []interface{}{
+			at, number, hexutil.Int64OrZero(receipt.TransactionIndex), strings.ToLower(receipt.TransactionHash),
+			strings.ToLower(receipt.From), strings.ToLower(receipt.To), strings.ToLower(receipt.ContractAddress),
+			success, hexutil.Uint64OrZero(receipt.GasUsed), hexutil.Uint64OrZero(receipt.EffectiveGasPrice), len(receipt.Logs),
+		})
+	}
+
+	e.enqueue(exportBlocks, []interface{}{
+		at, number, strings.ToLower(block.Hash), strings.ToLower(block.ParentHash), strings.ToLower(block.Miner),
+		len(block.Transactions), hexutil.Uint64OrZero(block.GasUsed), hexutil.Uint64OrZero(block.GasLimit),
+		hexutil.Uint64OrZero(block.BaseFeePerGas), succeeded, reverted,
+	})
+	if len(txs) > 0 {
+		e.enqueue(exportTransactions, txs...)
+	}
+}
+
+// ObserveWaterfallInterval exports a closed waterfall interval
+func (e *AnalyticsExporter) ObserveWaterfallInterval(interval *WaterfallInterval) {
+	lifecycle, _ := json.Marshal(interval.Lifecycle)
+	stages, _ := json.Marshal(interval.Stages)
+
+	e.mu.Lock()
+	var seconds interface{} // Unknown for the first interval
+	if !e.lastInterval.IsZero() {
+		seconds = interval.SampledAt.Sub(e.lastInterval).Seconds()
+	}
+	e.lastInterval = interval.SampledAt
+	e.mu.Unlock()
+
+	e.enqueue(exportWaterfall, []interface{}{interval.SampledAt.UTC(), seconds, string(lifecycle), string(stages)})
+}
+
+// run migrates the schema, then flushes on every tick or full batch until
+// shutdown, when it flushes what is left
+func (e *AnalyticsExporter) run() {
+	defer close(e.done)
+	ticker := time.NewTicker(e.flushEvery)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-e.wake:
+		case <-shutdownContext().Done():
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			e.flush(ctx)
+			cancel()
+			return
+		}
+		if !e.flush(shutdownContext()) {
+			e.mu.Lock()
+			wait := e.backoff
+			e.mu.Unlock()
+			select {
+			case <-time.After(wait):
+			case <-shutdownContext().Done():
+			}
+		}
+	}
+}
+
+// flush migrates if needed and writes every table's full batches (and the
+// remainder); it returns false after a failure, leaving rows buffered
+func (e *AnalyticsExporter) flush(ctx context.Context) bool {
+	e.mu.Lock()
+	migrated := e.migrated
+	e.mu.Unlock()
+	if !migrated {
+		callCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		version, err := e.sink.Migrate(callCtx)
+		cancel()
+		if err != nil {
+			e.fail(fmt.Errorf("schema migration: %w", err))
+			return false
+		}
+		e.mu.Lock()
+		e.schemaVersion, e.migrated = version, true
+		e.mu.Unlock()
+		log.Printf("Analytics export schema at version %d", version)
+	}
+
+	for _, table := range exportTableOrder {
+		for {
+			e.mu.Lock()
+			n := len(e.pending[table])
+			if n > e.batchSize {
+				n = e.batchSize
+			}
+			batch := e.pending[table][:n:n]
+			e.shifted[table] = 0
+			e.mu.Unlock()
+			if n == 0 {
+				break
+			}
+
+			callCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+			err := e.sink.Insert(callCtx, table, batch)
+			cancel()
+			if err != nil {
+				e.mu.Lock()
+				e.stats[table].FailedBatches++
+				e.mu.Unlock()
+				e.fail(fmt.Errorf("%s: %w", table, err))
+				return false
+			}
+
+			now := time.Now()
+			e.mu.Lock()
+			// Rows dropped for space meanwhile came off the front, from this batch
+			if remaining := n - e.shifted[table]; remaining > 0 {
+				e.pending[table] = e.pending[table][remaining:]
+			}
+			stats := e.stats[table]
+			stats.Exported += int64(n)
+			stats.Batches++
+			stats.LastFlush = &now
+			e.backoff = 0
+			e.mu.Unlock()
+		}
+	}
+	return true
+}
+
+// fail records an error and doubles the retry backoff
+func (e *AnalyticsExporter) fail(err error) {
+	now := time.Now()
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.backoff *= 2
+	if e.backoff < minExportBackoff {
+		e.backoff = minExportBackoff
+	}
+	if e.backoff > maxExportBackoff {
+		e.backoff = maxExportBackoff
+	}
+	e.lastError, e.lastErrorAt = err.Error(), &now
+	log.Printf("Analytics export to %s failed (retrying in %s): %v", e.sink.Name(), e.backoff, err)
+}
+
+// Close waits for the final flush after shutdown
+func (e *AnalyticsExporter) Close() {
+	select {
+	case <-e.done:
+	case <-time.After(10 * time.Second):
+	}
+}
+
+// Status reports the sink, schema and per-table progress
+func (e *AnalyticsExporter) Status() AnalyticsExportStatus {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	status := AnalyticsExportStatus{
+		Enabled:       true,
+		Sink:          e.sink.Name(),
+		SchemaVersion: e.schemaVersion,
+		Migrated:      e.migrated,
+		BatchSize:     e.batchSize,
+		FlushSeconds:  e.flushEvery.Seconds(),
+		BufferSize:    e.maxBuffer,
+		RetryIn:       e.backoff.Seconds(),
+		LastError:     e.lastError,
+		LastErrorAt:   e.lastErrorAt,
+	}
+	for _, table := range exportTableOrder {
+		stats := *e.stats[table]
+		stats.Pending = len(e.pending[table])
+		status.Tables = append(status.Tables, stats)
+	}
+	return status
+}
+
+// handleAnalyticsExport reports the analytics exporter's progress
+func handleAnalyticsExport(c *gin.Context) {
+	e := GetAnalyticsExporter()
+	if e == nil {
+		c.JSON(http.StatusOK, AnalyticsExportStatus{})
+		return
+	}
+	c.JSON(http.StatusOK, e.Status())
+}
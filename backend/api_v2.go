@@ -0,0 +1,405 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// /api/v2 serves the metrics, waterfall and consensus views as typed,
+// stage-grouped responses. The v1 routes stay frozen for the bundled
+// frontend; those with a v2 successor announce it in response headers.
+
+// v1Successors maps deprecated v1 routes to their v2 replacement
+var v1Successors = map[string]string{
+	"/api/v1/metrics":      "/api/v2/metrics",
+	"/api/v1/waterfall":    "/api/v2/waterfall",
+	"/api/v1/waterfall/v2": "/api/v2/waterfall",
+	"/api/v1/consensus":    "/api/v2/consensus",
+}
+
+// v1Sunset is API_V1_SUNSET (RFC 3339 date or timestamp) as an HTTP date,
+// empty when not configured
+var v1Sunset = func() string {
+	value := os.Getenv("API_V1_SUNSET")
+	if value == "" {
+		return ""
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC().Format(http.TimeFormat)
+		}
+	}
+	log.Printf("Invalid API_V1_SUNSET %q (want a date like 2027-01-31)", value)
+	return ""
+}()
+
+// deprecateV1 marks responses of v1 routes that have a v2 successor with
+// Deprecation, Link (rel="successor-version") and, when configured, Sunset
+func deprecateV1(c *gin.Context) {
+	if successor, ok := v1Successors[c.FullPath()]; ok {
+		c.Header("Deprecation", "true")
+		c.Header("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", successor))
+		if v1Sunset != "" {
+			c.Header("Sunset", v1Sunset)
+		}
+	}
+	c.Next()
+}
+
+// MetricsV2 is the body of /api/v2/metrics
+type MetricsV2 struct {
+	ObservedAt time.Time             `json:"observed_at"`
+	Node       NodeV2                `json:"node"`
+	Chain      ChainV2               `json:"chain"`
+	Execution  ExecutionV2           `json:"execution"`
+	Network    NetworkV2             `json:"network"`
+	Pipeline   PipelineCountersV2    `json:"pipeline"`
+	Provenance map[string]Provenance `json:"provenance"`
+}
+
+// NodeV2 identifies the monitored node
+type NodeV2 struct {
+	Version       string `json:"version"`
+	ChainID       int    `json:"chain_id"`
+	Name          string `json:"name"`
+	Status        string `json:"status"`
+	UptimeSeconds int64  `json:"uptime_seconds"`
+}
+
+// ChainV2 is the chain head and validator set
+type ChainV2 struct {
+	Height            int64     `json:"height"`
+	LastBlockAt       time.Time `json:"last_block_at"`
+	BlockTimeSeconds  float64   `json:"block_time_seconds"`
+	ValidatorCount    int       `json:"validator_count"`
+	VotingPower       int64     `json:"voting_power"`
+	ParticipationRate float64   `json:"participation_rate"`
+}
+
+// ExecutionV2 is transaction throughput and execution
+type ExecutionV2 struct {
+	TPS                 float64 `json:"tps"`
+	PendingTxs          int64   `json:"pending_txs"`
+	ParallelSuccessRate float64 `json:"parallel_success_rate"`
+	AvgGasPriceWei      int64   `json:"avg_gas_price_wei"`
+	AvgExecutionTime    float64 `json:"avg_execution_time"`
+	StateSize           int64   `json:"state_size"`
+}
+
+// NetworkV2 is peer connectivity and traffic
+type NetworkV2 struct {
+	Peers     PeerCountsV2 `json:"peers"`
+	BytesIn   int64        `json:"bytes_in"`
+	BytesOut  int64        `json:"bytes_out"`
+	LatencyMs float64      `json:"latency_ms"`
+}
+
+// PeerCountsV2 splits peers by direction
+type PeerCountsV2 struct {
+	Total    int `json:"total"`
+	Inbound  int `json:"inbound"`
+	Outbound int `json:"outbound"`
+}
+
+// PipelineCountersV2 are the v1 waterfall counters grouped by lifecycle stage
+type PipelineCountersV2 struct {
+	Ingress struct {
+		RPC         int64 `json:"rpc"`
+		Gossip      int64 `json:"gossip"`
+		MempoolSize int64 `json:"mempool_size"`
+	} `json:"ingress"`
+	Drops struct {
+		InvalidSignature    int64 `json:"invalid_signature"`
+		NonceDuplicate      int64 `json:"nonce_duplicate"`
+		InvalidGas          int64 `json:"invalid_gas"`
+		InsufficientBalance int64 `json:"insufficient_balance"`
+	} `json:"drops"`
+	Execution struct {
+		Parallel           int64 `json:"parallel"`
+		SequentialFallback int64 `json:"sequential_fallback"`
+		GasUsed            int64 `json:"gas_used"`
+		StateConflicts     int64 `json:"state_conflicts"`
+	} `json:"execution"`
+	Consensus struct {
+		Proposed  int64 `json:"proposed"`
+		Voted     int64 `json:"voted"`
+		Committed int64 `json:"committed"`
+	} `json:"consensus"`
+	Persistence struct {
+		StateUpdated    int64 `json:"state_updated"`
+		TrieDBWritten   int64 `json:"triedb_written"`
+		BlocksBroadcast int64 `json:"blocks_broadcast"`
+	} `json:"persistence"`
+}
+
+// metricsV2 regroups the v1 metrics
+func metricsV2(m MonadMetrics) MetricsV2 {
+	v2 := MetricsV2{
+		ObservedAt: time.Unix(m.Timestamp, 0).UTC(),
+		Node: NodeV2{
+			Version:       m.NodeInfo.Version,
+			ChainID:       m.NodeInfo.ChainID,
+			Name:          m.NodeInfo.NodeName,
+			Status:        m.NodeInfo.Status,
+			UptimeSeconds: m.NodeInfo.Uptime,
+		},
+		Chain: ChainV2{
+			Height:            m.Consensus.CurrentHeight,
+			LastBlockAt:       time.Unix(m.Consensus.LastBlockTime, 0).UTC(),
+			BlockTimeSeconds:  m.Consensus.BlockTime,
+			ValidatorCount:    m.Consensus.ValidatorCount,
+			VotingPower:       m.Consensus.VotingPower,
+			ParticipationRate: m.Consensus.ParticipationRate,
+		},
+		Execution: ExecutionV2{
+			TPS:                 m.Execution.TPS,
+			PendingTxs:          m.Execution.PendingTxCount,
+			ParallelSuccessRate: m.Execution.ParallelSuccessRate,
+			AvgGasPriceWei:      m.Execution.AvgGasPrice,
+			AvgExecutionTime:    m.Execution.AvgExecutionTime,
+			StateSize:           m.Execution.StateSize,
+		},
+		Network: NetworkV2{
+			Peers: PeerCountsV2{
+				Total:    m.Network.PeerCount,
+				Inbound:  m.Network.InboundPeers,
+				Outbound: m.Network.OutboundPeers,
+			},
+			BytesIn:   m.Network.BytesIn,
+			BytesOut:  m.Network.BytesOut,
+			LatencyMs: m.Network.NetworkLatency,
+		},
+		Provenance: m.Provenance,
+	}
+
+	w, p := m.Waterfall, &v2.Pipeline
+	p.Ingress.RPC, p.Ingress.Gossip, p.Ingress.MempoolSize = w.RPCReceived, w.GossipReceived, w.MempoolSize
+	p.Drops.InvalidSignature, p.Drops.NonceDuplicate = w.SignatureFailed, w.NonceDuplicate
+	p.Drops.InvalidGas, p.Drops.InsufficientBalance = w.GasInvalid, w.BalanceInsufficient
+	p.Execution.Parallel, p.Execution.SequentialFallback = w.EVMParallelExecuted, w.EVMSequentialFallback
+	p.Execution.GasUsed, p.Execution.StateConflicts = w.GasUsedTotal, w.StateConflicts
+	p.Consensus.Proposed, p.Consensus.Voted, p.Consensus.Committed = w.BFTProposed, w.BFTVoted, w.BFTCommitted
+	p.Persistence.StateUpdated, p.Persistence.TrieDBWritten, p.Persistence.BlocksBroadcast = w.StateUpdated, w.TrieDBWritten, w.BlocksBroadcast
+	return v2
+}
+
+// handleV2Metrics serves the current metrics grouped by subsystem
+func handleV2Metrics(c *gin.Context) {
+	metrics := getCurrentMetrics()
+	metrics.Provenance = GetSourceResolver().LastProvenance()
+	c.JSON(http.StatusOK, metricsV2(metrics))
+}
+
+// SankeyNode is a waterfall stage
+type SankeyNode struct {
+	ID    string `json:"id"`
+	Label string `json:"label"`
+	Color string `json:"color"`
+}
+
+// SankeyLink is a flow between two stages
+type SankeyLink struct {
+	ID     string   `json:"id,omitempty"` // Stable across payloads: "source->target"
+	Source string   `json:"source"`
+	Target string   `json:"target"`
+	Value  float64  `json:"value"`
+	Delta  *float64 `json:"delta,omitempty"` // Change since the previous interval
+}
+
+// SankeyFlow is a set of stages and the flows between them
+type SankeyFlow struct {
+	Nodes []SankeyNode `json:"nodes"`
+	Links []SankeyLink `json:"links"`
+}
+
+// WaterfallV2 is the body of /api/v2/waterfall: the lifecycle waterfall with
+// its metadata split into typed sections
+type WaterfallV2 struct {
+	GeneratedAt time.Time `json:"generated_at"`
+	Source      string    `json:"source"` // prometheus_metrics, real_ipc_metrics, block_estimation or mock_data
+	SankeyFlow
+	Drops      map[string]float64             `json:"drops,omitempty"`
+	Block      *WaterfallBlockV2              `json:"block,omitempty"`
+	Consensus  *ConsensusState                `json:"consensus,omitempty"`
+	FeeFlow    *FeeFlowV2                     `json:"fee_flow,omitempty"`
+	Timing     map[string]LatencyDistribution `json:"timing,omitempty"`
+	Leader     *LeaderMetadata                `json:"leader,omitempty"`
+	Provenance *Provenance                    `json:"provenance,omitempty"`
+	Removed    []RemovedWaterfallLink         `json:"removed_links,omitempty"` // Links of the previous interval without flow now
+	Metadata   FreeForm                       `json:"metadata"`                // Remaining source-specific metadata
+}
+
+// WaterfallBlockV2 is the block the waterfall was derived from
+type WaterfallBlockV2 struct {
+	Height int64  `json:"height"`
+	Hash   string `json:"hash,omitempty"`
+}
+
+// FeeFlowV2 splits the latest block's fees into burned and priority
+type FeeFlowV2 struct {
+	BlockNumber int64  `json:"block_number"`
+	Unit        string `json:"unit"`
+	SankeyFlow
+}
+
+// waterfallV2 converts a generated v1 waterfall. Metadata keys moved into a
+// typed section are removed from the free-form remainder.
+func waterfallV2(w map[string]interface{}) WaterfallV2 {
+	v2 := WaterfallV2{GeneratedAt: time.Now().UTC(), Metadata: FreeForm{}}
+	v2.Nodes, v2.Links = sankeyNodes(w["nodes"]), sankeyLinks(w["links"])
+
+	v2.Removed, _ = w["removed_links"].([]RemovedWaterfallLink)
+
+	if drops, ok := w["drops"].(map[string]interface{}); ok {
+		v2.Drops = make(map[string]float64, len(drops))
+		for reason, value := range drops {
+			v2.Drops[reason] = numberValue(value)
+		}
+	}
+
+	metadata, _ := w["metadata"].(map[string]interface{})
+	for key, value := range metadata {
+		switch v := value.(type) {
+		case ConsensusState:
+			if key == "consensus_state" {
+				v2.Consensus = &v
+				continue
+			}
+		case Provenance:
+			if key == "provenance" {
+				v2.Provenance = &v
+				continue
+			}
+		}
+		switch key {
+		case "source":
+			v2.Source, _ = value.(string)
+		case "block_height":
+			if height := int64(numberValue(value)); height > 0 {
+				if v2.Block == nil {
+					v2.Block = &WaterfallBlockV2{}
+				}
+				v2.Block.Height = height
+			}
+		case "block_hash":
+			if hash, _ := value.(string); hash != "" {
+				if v2.Block == nil {
+					v2.Block = &WaterfallBlockV2{}
+				}
+				v2.Block.Hash = hash
+			}
+		default:
+			v2.Metadata[key] = value
+		}
+	}
+	if v2.Block != nil && v2.Block.Height == 0 {
+		v2.Block = nil
+	}
+
+	if flow, ok := w["fee_flow"].(map[string]interface{}); ok {
+		unit, _ := flow["unit"].(string)
+		v2.FeeFlow = &FeeFlowV2{
+			BlockNumber: int64(numberValue(flow["block_number"])),
+			Unit:        unit,
+			SankeyFlow:  SankeyFlow{Nodes: sankeyNodes(flow["nodes"]), Links: sankeyLinks(flow["links"])},
+		}
+	}
+	if timing, ok := w["timing"].(map[string]LatencyDistribution); ok {
+		v2.Timing = timing
+	}
+	if leader, ok := w["leader"].(LeaderMetadata); ok {
+		v2.Leader = &leader
+	}
+	return v2
+}
+
+func sankeyNodes(value interface{}) []SankeyNode {
+	raw, _ := value.([]map[string]interface{})
+	nodes := make([]SankeyNode, 0, len(raw))
+	for _, node := range raw {
+		id, _ := node["id"].(string)
+		label, _ := node["label"].(string)
+		color, _ := node["color"].(string)
+		nodes = append(nodes, SankeyNode{ID: id, Label: label, Color: color})
+	}
+	return nodes
+}
+
+func sankeyLinks(value interface{}) []SankeyLink {
+	raw, _ := value.([]map[string]interface{})
+	links := make([]SankeyLink, 0, len(raw))
+	for _, link := range raw {
+		source, _ := link["source"].(string)
+		target, _ := link["target"].(string)
+		id, _ := link["id"].(string)
+		sankeyLink := SankeyLink{ID: id, Source: source, Target: target, Value: numberValue(link["value"])}
+		if delta, ok := link["delta"]; ok {
+			d := numberValue(delta)
+			sankeyLink.Delta = &d
+		}
+		links = append(links, sankeyLink)
+	}
+	return links
+}
+
+// numberValue reads the numeric types the waterfall generators produce
+func numberValue(value interface{}) float64 {
+	switch n := value.(type) {
+	case int:
+		return float64(n)
+	case int64:
+		return float64(n)
+	case uint64:
+		return float64(n)
+	case float64:
+		return n
+	}
+	return 0
+}
+
+// handleV2Waterfall serves the lifecycle waterfall with typed sections
+func handleV2Waterfall(c *gin.Context) {
+	c.JSON(http.StatusOK, waterfallV2(GenerateMonadWaterfallFor(waterfallVersionV2)))
+}
+
+// ConsensusV2 is the body of /api/v2/consensus
+type ConsensusV2 struct {
+	ObservedAt time.Time `json:"observed_at"`
+	Heights    struct {
+		Current   uint64 `json:"current"`
+		Finalized uint64 `json:"finalized"`
+		Behind    uint64 `json:"behind"`
+	} `json:"heights"`
+	Phases struct {
+		Proposed  int `json:"proposed"`
+		Voted     int `json:"voted"`
+		Finalized int `json:"finalized"`
+	} `json:"phases"` // Tracked blocks currently in each phase
+	RecentBlocks []BlockConsensusState `json:"recent_blocks"`
+	Params       ChainParams           `json:"params"`
+}
+
+// handleV2Consensus serves the consensus summary with the chain parameters
+// the phases are measured against
+func handleV2Consensus(c *gin.Context) {
+	tracker := GetConsensusTracker()
+	if tracker == nil {
+		c.JSON(http.StatusServiceUnavailable, APIError{Error: "Consensus tracker not initialized"})
+		return
+	}
+
+	state := tracker.GetConsensusState()
+	v2 := ConsensusV2{
+		ObservedAt:   time.Now().UTC(),
+		RecentBlocks: state.RecentBlocks,
+		Params:       GetChainParams(),
+	}
+	v2.Heights.Current, v2.Heights.Finalized, v2.Heights.Behind = state.CurrentBlock, state.FinalizedBlock, state.BlocksBehind
+	v2.Phases.Proposed, v2.Phases.Voted, v2.Phases.Finalized = state.ProposedBlocks, state.VotedBlocks, state.FinalizedBlocks
+	c.JSON(http.StatusOK, v2)
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Consensus event kinds parsed from the monad-bft log
+const (
+	consensusEventTimeout       = "round_timeout"
+	consensusEventVoteFailure   = "vote_failure"
+	consensusEventProposalError = "proposal_error"
+)
+
+// ConsensusEvent is one timeout, vote failure or proposal error from the
+// monad-bft log
+type ConsensusEvent struct {
+	Timestamp int64  `json:"timestamp"` // Unix milliseconds
+	Kind      string `json:"kind"`
+	Level     string `json:"level,omitempty"`
+	Round     *int64 `json:"round,omitempty"`
+	Epoch     *int64 `json:"epoch,omitempty"`
+	Message   string `json:"message"`
+}
+
+// bftEventRule classifies a log line as one event kind
+type bftEventRule struct {
+	kind    string
+	pattern *regexp.Regexp
+}
+
+// ConsensusLogCollector tails the monad-bft log and keeps recent consensus events
+type ConsensusLogCollector struct {
+	path  string
+	rules []bftEventRule
+
+	mu             sync.RWMutex
+	events         []ConsensusEvent
+	maxEvents      int
+	totals         map[string]int64
+	lines          int64
+	lastAnnotation map[string]time.Time
+}
+
+// Global consensus log collector instance
+var (
+	consensusLogCollector   *ConsensusLogCollector
+	consensusLogCollectorMu sync.RWMutex
+)
+
+var (
+	bftRoundField = regexp.MustCompile(`\bround[=:"\s]+(\d+)`)
+	bftEpochField = regexp.MustCompile(`\bepoch[=:"\s]+(\d+)`)
+	bftLogLevel   = regexp.MustCompile(`\b(TRACE|DEBUG|INFO|WARN|ERROR)\b`)
+)
+
+// defaultBFTRules match monad-bft's tracing output; each can be replaced
+// with BFT_LOG_TIMEOUT_PATTERN, BFT_LOG_VOTE_PATTERN and BFT_LOG_PROPOSAL_PATTERN
+var defaultBFTRules = []struct{ kind, env, pattern string }{
+	{consensusEventTimeout, "BFT_LOG_TIMEOUT_PATTERN", `(?i)(local|round)[ _]timeout|timeout (certificate|message)|\bTimeoutCertificate\b`},
+	{consensusEventVoteFailure, "BFT_LOG_VOTE_PATTERN", `(?i)vote\w*\W.*\b(fail\w*|invalid|reject\w*|error)\b|\b(fail\w*|invalid|reject\w*)\b.*\bvote`},
+	{consensusEventProposalError, "BFT_LOG_PROPOSAL_PATTERN", `(?i)proposal\w*\W.*\b(fail\w*|invalid|reject\w*|error)\b|\b(fail\w*|invalid|reject\w*)\b.*\bproposal`},
+}
+
+// InitializeConsensusLogCollector tails MONAD_BFT_LOG when set
+func InitializeConsensusLogCollector() *ConsensusLogCollector {
+	path := os.Getenv("MONAD_BFT_LOG")
+	if path == "" {
+		return nil
+	}
+
+	lc := &ConsensusLogCollector{
+		path:           path,
+		maxEvents:      1000,
+		totals:         make(map[string]int64),
+		lastAnnotation: make(map[string]time.Time),
+	}
+	for _, rule := range defaultBFTRules {
+		pattern := rule.pattern
+		if custom := os.Getenv(rule.env); custom != "" {
+			pattern = custom
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			log.Printf("Invalid %s %q: %v, using default", rule.env, pattern, err)
+			re = regexp.MustCompile(rule.pattern)
+		}
+		lc.rules = append(lc.rules, bftEventRule{kind: rule.kind, pattern: re})
+	}
+
+	consensusLogCollectorMu.Lock()
+	consensusLogCollector = lc
+	consensusLogCollectorMu.Unlock()
+
+	go NewLogTailer(path, 500*time.Millisecond, lc.handleLine).Run(nil)
+	log.Printf("Consensus log collector tailing %s", path)
+	return lc
+}
+
+// GetConsensusLogCollector returns the global collector, nil when MONAD_BFT_LOG is unset
+func GetConsensusLogCollector() *ConsensusLogCollector {
+	consensusLogCollectorMu.RLock()
+	defer consensusLogCollectorMu.RUnlock()
+	return consensusLogCollector
+}
+
+// handleLine classifies one log line and records it as an event
+func (lc *ConsensusLogCollector) handleLine(line string) {
+	lc.mu.Lock()
+	lc.lines++
+	lc.mu.Unlock()
+
+	event, ok := lc.parseLine(line, time.Now())
+	if !ok {
+		return
+	}
+	lc.record(event)
+}
+
+// parseLine extracts an event from a text or JSON tracing line
+func (lc *ConsensusLogCollector) parseLine(line string, now time.Time) (ConsensusEvent, bool) {
+	event := ConsensusEvent{Timestamp: now.UnixMilli(), Message: line}
+	text := line
+
+	// JSON output: {"timestamp": ..., "level": ..., "fields": {"message": ..., "round": ...}}
+	if strings.HasPrefix(line, "{") {
+		var entry struct {
+			Timestamp string                 `json:"timestamp"`
+			Level     string                 `json:"level"`
+			Fields    map[string]interface{} `json:"fields"`
+		}
+		if err := json.Unmarshal([]byte(line), &entry); err == nil {
+			event.Level = entry.Level
+			if t, err := time.Parse(time.RFC3339Nano, entry.Timestamp); err == nil {
+				event.Timestamp = t.UnixMilli()
+			}
+			if message, ok := entry.Fields["message"].(string); ok {
+				event.Message = message
+			}
+		}
+	} else if fields := strings.Fields(line); len(fields) > 0 {
+		if t, err := time.Parse(time.RFC3339Nano, fields[0]); err == nil {
+			event.Timestamp = t.UnixMilli()
+		}
+		if level := bftLogLevel.FindString(line); level != "" {
+			event.Level = level
+		}
+	}
+
+	for _, rule := range lc.rules {
+		if rule.pattern.MatchString(text) {
+			event.Kind = rule.kind
+			break
+		}
+	}
+	if event.Kind == "" {
+		return event, false
+	}
+	// Routine debug chatter about timers is not a failure
+	if event.Level == "TRACE" || event.Level == "DEBUG" {
+		return event, false
+	}
+
+	if m := bftRoundField.FindStringSubmatch(text); m != nil {
+		if n, err := strconv.ParseInt(m[1], 10, 64); err == nil {
+			event.Round = &n
+		}
+	}
+	if m := bftEpochField.FindStringSubmatch(text); m != nil {
+		if n, err := strconv.ParseInt(m[1], 10, 64); err == nil {
+			event.Epoch = &n
+		}
+	}
+	if len(event.Message) > 512 {
+		event.Message = event.Message[:512]
+	}
+	return event, true
+}
+
+// record stores an event, counts it in the waterfall's consensus rejected
+// flow, annotates the timeline and pushes it to stream clients
+func (lc *ConsensusLogCollector) record(event ConsensusEvent) {
+	now := time.Now()
+	lc.mu.Lock()
+	lc.events = append(lc.events, event)
+	if len(lc.events) > lc.maxEvents {
+		lc.events = lc.events[len(lc.events)-lc.maxEvents:]
+	}
+	lc.totals[event.Kind]++
+	// At most one timeline annotation per kind per minute, so a timeout
+	// storm does not push every other annotation out
+	annotate := now.Sub(lc.lastAnnotation[event.Kind]) >= time.Minute
+	if annotate {
+		lc.lastAnnotation[event.Kind] = now
+	}
+	lc.mu.Unlock()
+
+	GetMonadWaterfallMetrics().ConsensusRejected.Add(1)
+
+	if annotate {
+		if store := GetHistoryStore(); store != nil {
+			store.Annotate("consensus", strings.ReplaceAll(event.Kind, "_", " "), event.Message, "consensus", event.Kind)
+		}
+	}
+	if streamHasAudience() {
+		broadcastToAllClients(FiredancerMessage{
+			Topic: "consensus_events",
+			Key:   "new",
+			Value: event,
+		})
+	}
+}
+
+// Events returns recent events, newest first, optionally of one kind
+func (lc *ConsensusLogCollector) Events(kind string, limit int) []ConsensusEvent {
+	lc.mu.RLock()
+	defer lc.mu.RUnlock()
+	events := []ConsensusEvent{}
+	for i := len(lc.events) - 1; i >= 0 && (limit <= 0 || len(events) < limit); i-- {
+		if kind == "" || lc.events[i].Kind == kind {
+			events = append(events, lc.events[i])
+		}
+	}
+	return events
+}
+
+// Counts returns event totals since start and within the last hour
+func (lc *ConsensusLogCollector) Counts(now time.Time) (map[string]int64, map[string]int64) {
+	lc.mu.RLock()
+	defer lc.mu.RUnlock()
+	total := map[string]int64{}
+	lastHour := map[string]int64{}
+	for _, rule := range lc.rules {
+		total[rule.kind] = lc.totals[rule.kind]
+		lastHour[rule.kind] = 0
+	}
+	cutoff := now.Add(-time.Hour).UnixMilli()
+	for _, event := range lc.events {
+		if event.Timestamp >= cutoff {
+			lastHour[event.Kind]++
+		}
+	}
+	return total, lastHour
+}
+
+// ConsensusEventsResponse is the body of /api/v1/consensus/events
+type ConsensusEventsResponse struct {
+	Path      string           `json:"path"`
+	LinesRead int64            `json:"lines_read"`
+	Totals    map[string]int64 `json:"totals"`
+	LastHour  map[string]int64 `json:"last_hour"`
+	Events    []ConsensusEvent `json:"events"`
+}
+
+// handleConsensusEvents lists consensus events parsed from the monad-bft log
+func handleConsensusEvents(c *gin.Context) {
+	lc := GetConsensusLogCollector()
+	if lc == nil {
+		c.JSON(http.StatusServiceUnavailable, APIError{Error: "Consensus log collector not configured (set MONAD_BFT_LOG)"})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	total, lastHour := lc.Counts(time.Now())
+	lc.mu.RLock()
+	lines := lc.lines
+	lc.mu.RUnlock()
+
+	c.JSON(http.StatusOK, ConsensusEventsResponse{
+		Path:      lc.path,
+		LinesRead: lines,
+		Totals:    total,
+		LastHour:  lastHour,
+		Events:    lc.Events(c.Query("kind"), limit),
+	})
+}
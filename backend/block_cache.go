@@ -0,0 +1,257 @@
+package main
+
+import (
+	"container/list"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"monad-dashboard/hexutil"
+)
+
+// blockCacheEntry is one cached block in the LRU list
+type blockCacheEntry struct {
+	number   int64
+	hash     string
+	block    *RPCBlock
+	cachedAt time.Time
+}
+
+// blockLoad is an in-flight fetch shared by concurrent callers
+type blockLoad struct {
+	done  chan struct{}
+	block *RPCBlock
+	err   error
+}
+
+// BlockCache is an LRU of blocks keyed by number and hash with a TTL.
+// It is shared by the subscriber, fee tracker and metrics collection so a
+// height is fetched from the node once.
+type BlockCache struct {
+	mu       sync.Mutex
+	lru      *list.List // Front = most recently used
+	byNumber map[int64]*list.Element
+	byHash   map[string]*list.Element
+	loading  map[int64]*blockLoad
+	capacity int
+	ttl      time.Duration
+
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+	evictions atomic.Uint64
+	shared    atomic.Uint64 // Misses served by another caller's in-flight fetch
+}
+
+// Global block cache instance
+var (
+	blockCache     *BlockCache
+	blockCacheOnce sync.Once
+)
+
+// GetBlockCache returns the global block cache, sized by BLOCK_CACHE_SIZE
+// (default 2048) with BLOCK_CACHE_TTL expiry (default 5m)
+func GetBlockCache() *BlockCache {
+	blockCacheOnce.Do(func() {
+		capacity := 2048
+		if value := os.Getenv("BLOCK_CACHE_SIZE"); value != "" {
+			if n, err := strconv.Atoi(value); err == nil && n > 0 {
+				capacity = n
+			} else {
+				log.Printf("Invalid BLOCK_CACHE_SIZE %q, using %d", value, capacity)
+			}
+		}
+		ttl := 5 * time.Minute
+		if value := os.Getenv("BLOCK_CACHE_TTL"); value != "" {
+			if d, err := time.ParseDuration(value); err == nil && d > 0 {
+				ttl = d
+			} else {
+				log.Printf("Invalid BLOCK_CACHE_TTL %q, using %s", value, ttl)
+			}
+		}
+
+		blockCache = NewBlockCache(capacity, ttl)
+	})
+	return blockCache
+}
+
+// NewBlockCache creates a block cache holding up to capacity blocks for ttl
+func NewBlockCache(capacity int, ttl time.Duration) *BlockCache {
+	return &BlockCache{
+		lru:      list.New(),
+		byNumber: make(map[int64]*list.Element),
+		byHash:   make(map[string]*list.Element),
+		loading:  make(map[int64]*blockLoad),
+		capacity: capacity,
+		ttl:      ttl,
+	}
+}
+
+// lookupLocked returns a live entry and marks it used; caller holds c.mu
+func (c *BlockCache) lookupLocked(elem *list.Element) *RPCBlock {
+	entry := elem.Value.(*blockCacheEntry)
+	if time.Since(entry.cachedAt) > c.ttl {
+		c.removeLocked(elem)
+		return nil
+	}
+	c.lru.MoveToFront(elem)
+	return entry.block
+}
+
+// removeLocked drops an entry from the list and indexes; caller holds c.mu
+func (c *BlockCache) removeLocked(elem *list.Element) {
+	entry := elem.Value.(*blockCacheEntry)
+	c.lru.Remove(elem)
+	if c.byNumber[entry.number] == elem {
+		delete(c.byNumber, entry.number)
+	}
+	if entry.hash != "" && c.byHash[entry.hash] == elem {
+		delete(c.byHash, entry.hash)
+	}
+}
+
+// GetByNumber returns a cached block by height
+func (c *BlockCache) GetByNumber(number int64) (*RPCBlock, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.byNumber[number]; ok {
+		if block := c.lookupLocked(elem); block != nil {
+			c.hits.Add(1)
+			return block, true
+		}
+	}
+	c.misses.Add(1)
+	return nil, false
+}
+
+// GetByHash returns a cached block by hash
+func (c *BlockCache) GetByHash(hash string) (*RPCBlock, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.byHash[strings.ToLower(hash)]; ok {
+		if block := c.lookupLocked(elem); block != nil {
+			c.hits.Add(1)
+			return block, true
+		}
+	}
+	c.misses.Add(1)
+	return nil, false
+}
+
+// Put stores a block, replacing any cached block at the same height
+func (c *BlockCache) Put(block *RPCBlock) {
+	if block == nil {
+		return
+	}
+	number, err := hexutil.DecodeInt64(block.Number)
+	if err != nil {
+		return
+	}
+	hash := strings.ToLower(block.Hash)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.byNumber[number]; ok {
+		c.removeLocked(elem)
+	}
+	if elem, ok := c.byHash[hash]; ok && hash != "" {
+		c.removeLocked(elem)
+	}
+
+	elem := c.lru.PushFront(&blockCacheEntry{
+		number:   number,
+		hash:     hash,
+		block:    block,
+		cachedAt: time.Now(),
+	})
+	c.byNumber[number] = elem
+	if hash != "" {
+		c.byHash[hash] = elem
+	}
+
+	for c.lru.Len() > c.capacity {
+		c.removeLocked(c.lru.Back())
+		c.evictions.Add(1)
+	}
+}
+
+// LoadByNumber returns the cached block or fetches it, sharing one fetch
+// between concurrent callers asking for the same height
+func (c *BlockCache) LoadByNumber(number int64, fetch func() (*RPCBlock, error)) (*RPCBlock, error) {
+	if block, ok := c.GetByNumber(number); ok {
+		return block, nil
+	}
+
+	c.mu.Lock()
+	if load, ok := c.loading[number]; ok {
+		c.mu.Unlock()
+		<-load.done
+		c.shared.Add(1)
+		return load.block, load.err
+	}
+	load := &blockLoad{done: make(chan struct{})}
+	c.loading[number] = load
+	c.mu.Unlock()
+
+	load.block, load.err = fetch()
+	if load.err == nil {
+		c.Put(load.block)
+	}
+
+	c.mu.Lock()
+	delete(c.loading, number)
+	c.mu.Unlock()
+	close(load.done)
+
+	return load.block, load.err
+}
+
+// BlockCacheStats is the body of /api/v1/cache/blocks
+type BlockCacheStats struct {
+	Size          int     `json:"size"`
+	Capacity      int     `json:"capacity"`
+	TTLSeconds    float64 `json:"ttl_seconds"`
+	Hits          uint64  `json:"hits"`
+	Misses        uint64  `json:"misses"`
+	HitRate       float64 `json:"hit_rate"`
+	Evictions     uint64  `json:"evictions"`
+	SharedFetches uint64  `json:"shared_fetches"` // Misses served by another caller's fetch
+}
+
+// Stats returns cache size and hit/miss counters
+func (c *BlockCache) Stats() BlockCacheStats {
+	c.mu.Lock()
+	size := c.lru.Len()
+	c.mu.Unlock()
+
+	hits, misses := c.hits.Load(), c.misses.Load()
+	hitRate := 0.0
+	if hits+misses > 0 {
+		hitRate = float64(hits) / float64(hits+misses)
+	}
+
+	return BlockCacheStats{
+		Size:          size,
+		Capacity:      c.capacity,
+		TTLSeconds:    c.ttl.Seconds(),
+		Hits:          hits,
+		Misses:        misses,
+		HitRate:       hitRate,
+		Evictions:     c.evictions.Load(),
+		SharedFetches: c.shared.Load(),
+	}
+}
+
+// handleBlockCacheStats returns block cache statistics
+func handleBlockCacheStats(c *gin.Context) {
+	c.JSON(http.StatusOK, GetBlockCache().Stats())
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// BlockEnrichmentStats reports the enrichment pool for /api/v1/subscriptions
+type BlockEnrichmentStats struct {
+	Workers       int     `json:"workers"`
+	QueueDepth    int     `json:"queue_depth"` // Heads accepted but not yet delivered
+	QueueCapacity int     `json:"queue_capacity"`
+	MaxDepth      int     `json:"max_depth"`
+	InFlight      int     `json:"in_flight"` // Fetches running now
+	Enriched      int64   `json:"enriched"`
+	Failed        int64   `json:"failed"`
+	Dropped       int64   `json:"dropped"`    // Rejected with the queue full
+	Duplicates    int64   `json:"duplicates"` // Re-announced heads skipped
+	AvgLatencyMs  float64 `json:"avg_latency_ms"`
+	LastDelivered int64   `json:"last_delivered,omitempty"`
+}
+
+// enrichJob is one head waiting for its full block
+type enrichJob struct {
+	header   *BlockHeader
+	queuedAt time.Time
+	done     chan struct{}
+}
+
+// BlockEnricher fetches full blocks for subscribed heads on a bounded pool
+// of workers. Fetches run concurrently but heads are delivered in the order
+// they arrived, so consumers never see a height before the one preceding it.
+type BlockEnricher struct {
+	workers int
+	enrich  func(header *BlockHeader) bool
+	deliver func(header *BlockHeader)
+
+	work  chan *enrichJob
+	order chan *enrichJob // Submission order; its length is the queue depth
+
+	mu            sync.Mutex
+	inFlight      int
+	maxDepth      int
+	enriched      int64
+	failed        int64
+	dropped       int64
+	duplicates    int64
+	latencyTotal  time.Duration
+	delivered     int64
+	lastNumber    int64
+	lastHash      string
+	lastDropLog   time.Time
+	lastDelivered time.Time
+}
+
+// NewBlockEnricher starts the pool. BLOCK_ENRICH_WORKERS (default 4) sets the
+// concurrency and BLOCK_ENRICH_QUEUE (default 64) the heads held before new
+// ones are dropped.
+func NewBlockEnricher(enrich func(*BlockHeader) bool, deliver func(*BlockHeader)) *BlockEnricher {
+	workers, queue := 4, 64
+	if n, ok := positiveIntEnv("BLOCK_ENRICH_WORKERS"); ok {
+		workers = int(n)
+	}
+	if n, ok := positiveIntEnv("BLOCK_ENRICH_QUEUE"); ok {
+		queue = int(n)
+	}
+
+	be := &BlockEnricher{
+		workers: workers,
+		enrich:  enrich,
+		deliver: deliver,
+		work:    make(chan *enrichJob, queue),
+		order:   make(chan *enrichJob, queue),
+	}
+	for i := 0; i < workers; i++ {
+		go be.worker()
+	}
+	go be.sequence()
+	return be
+}
+
+// Submit queues a head for enrichment without blocking the subscription reader
+func (be *BlockEnricher) Submit(header *BlockHeader) {
+	job := &enrichJob{header: header, queuedAt: time.Now(), done: make(chan struct{})}
+	select {
+	case be.order <- job:
+	default:
+		be.mu.Lock()
+		be.dropped++
+		report := time.Since(be.lastDropLog) >= 10*time.Second
+		if report {
+			be.lastDropLog = time.Now()
+		}
+		dropped := be.dropped
+		be.mu.Unlock()
+		if report {
+			log.Printf("Block enrichment queue full, dropping block %d (%d dropped so far)", header.Number, dropped)
+		}
+		return
+	}
+	// order and work have the same capacity and work drains first, so this
+	// never blocks for long
+	be.work <- job
+
+	be.mu.Lock()
+	if depth := len(be.order); depth > be.maxDepth {
+		be.maxDepth = depth
+	}
+	be.mu.Unlock()
+}
+
+// worker fetches queued blocks
+func (be *BlockEnricher) worker() {
+	for job := range be.work {
+		be.mu.Lock()
+		be.inFlight++
+		be.mu.Unlock()
+
+		ok := be.enrich(job.header)
+
+		be.mu.Lock()
+		be.inFlight--
+		if ok {
+			be.enriched++
+		} else {
+			be.failed++
+		}
+		be.mu.Unlock()
+		close(job.done)
+	}
+}
+
+// sequence delivers heads in arrival order as their fetches complete
+func (be *BlockEnricher) sequence() {
+	for job := range be.order {
+		<-job.done
+
+		be.mu.Lock()
+		duplicate := job.header.Number == be.lastNumber && job.header.Hash == be.lastHash
+		if duplicate {
+			be.duplicates++
+		} else {
+			be.lastNumber, be.lastHash = job.header.Number, job.header.Hash
+			be.delivered++
+			be.latencyTotal += time.Since(job.queuedAt)
+			be.lastDelivered = time.Now()
+		}
+		be.mu.Unlock()
+
+		if !duplicate {
+			be.deliver(job.header)
+		}
+	}
+}
+
+// QueueDepth is the number of heads accepted but not yet delivered
+func (be *BlockEnricher) QueueDepth() int {
+	return len(be.order)
+}
+
+// Stats returns the pool's counters
+func (be *BlockEnricher) Stats() BlockEnrichmentStats {
+	be.mu.Lock()
+	defer be.mu.Unlock()
+	stats := BlockEnrichmentStats{
+		Workers:       be.workers,
+		QueueDepth:    len(be.order),
+		QueueCapacity: cap(be.order),
+		MaxDepth:      be.maxDepth,
+		InFlight:      be.inFlight,
+		Enriched:      be.enriched,
+		Failed:        be.failed,
+		Dropped:       be.dropped,
+		Duplicates:    be.duplicates,
+	}
+	if be.delivered > 0 {
+		stats.AvgLatencyMs = float64(be.latencyTotal.Milliseconds()) / float64(be.delivered)
+	}
+	if !be.lastDelivered.IsZero() {
+		stats.LastDelivered = be.lastDelivered.Unix()
+	}
+	return stats
+}
@@ -0,0 +1,307 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"monad-dashboard/hexutil"
+)
+
+// weiPerGwei converts per-gas prices into gwei for display
+var weiPerGwei = new(big.Float).SetFloat64(1e9)
+
+// OrderedTx is one transaction of a block in execution order
+type OrderedTx struct {
+	Index           int     `json:"index"`
+	Hash            string  `json:"hash"`
+	From            string  `json:"from"`
+	To              string  `json:"to,omitempty"`
+	PriorityFeeGwei float64 `json:"priority_fee_gwei"` // effectiveGasPrice − baseFee
+	FeeRank         int     `json:"fee_rank"`          // Position when sorted by descending priority fee; ties share the first
+	OutbidBy        int     `json:"outbid_by"`         // Later transactions from other senders paying a higher priority fee
+}
+
+// ReorderingEvent is a transaction placed ahead of higher-paying ones
+type ReorderingEvent struct {
+	Index           int     `json:"index"`
+	Hash            string  `json:"hash"`
+	From            string  `json:"from"`
+	PriorityFeeGwei float64 `json:"priority_fee_gwei"`
+	OutbidBy        int     `json:"outbid_by"`
+	MaxLaterFeeGwei float64 `json:"max_later_fee_gwei"` // Highest priority fee among the transactions it precedes
+	PositionsEarly  int     `json:"positions_early"`    // How far ahead of its fee rank it was placed
+}
+
+// SandwichCandidate is a transaction bracketed by two from another sender
+// to the same contract, the shape of a sandwich attack
+type SandwichCandidate struct {
+	FrontIndex  int    `json:"front_index"`
+	VictimIndex int    `json:"victim_index"`
+	BackIndex   int    `json:"back_index"`
+	Attacker    string `json:"attacker"`
+	Victim      string `json:"victim"`
+	Contract    string `json:"contract"`
+}
+
+// BlockOrderingResponse is the body of /api/v1/blocks/:number/ordering
+type BlockOrderingResponse struct {
+	BlockNumber int64   `json:"block_number"`
+	BlockHash   string  `json:"block_hash"`
+	Miner       string  `json:"miner"`
+	BaseFeeGwei float64 `json:"base_fee_gwei"`
+	TxCount     int     `json:"tx_count"`
+
+	// Spearman rank correlation of position and priority fee: -1 when
+	// ordered by descending fee, 0 when unrelated. Null with fewer than two
+	// transactions or a single fee level.
+	FeeCorrelation *float64 `json:"fee_correlation"`
+	// Normalized Shannon entropy (0..1) of each transaction's distance from
+	// its fee rank: 0 when ordered by fee, near 1 when position is random
+	OrderingEntropy float64 `json:"ordering_entropy"`
+
+	// Pairs from different senders where the earlier one pays less.
+	// Same-sender pairs are excluded since nonces fix their order.
+	Inversions      int     `json:"inversions"`
+	ComparablePairs int     `json:"comparable_pairs"`
+	InversionRate   float64 `json:"inversion_rate"`
+	FeeOrdered      bool    `json:"fee_ordered"`
+
+	Reorderings        []ReorderingEvent   `json:"reorderings"` // Most outbid first
+	PossibleSandwiches []SandwichCandidate `json:"possible_sandwiches"`
+	Transactions       []OrderedTx         `json:"transactions,omitempty"` // With ?transactions=true
+}
+
+// weiToGwei converts a wei amount to a float gwei value
+func weiToGwei(wei *big.Int) float64 {
+	gwei, _ := new(big.Float).Quo(new(big.Float).SetInt(wei), weiPerGwei).Float64()
+	return gwei
+}
+
+// AnalyzeBlockOrdering relates a block's transaction order to the priority
+// fees its receipts paid
+func AnalyzeBlockOrdering(block *RPCBlock, receipts []RPCReceipt) BlockOrderingResponse {
+	baseFee := hexutil.BigOrZero(block.BaseFeePerGas)
+	txs := make([]OrderedTx, 0, len(receipts))
+	for _, receipt := range receipts {
+		tip := new(big.Int).Sub(hexutil.BigOrZero(receipt.EffectiveGasPrice), baseFee)
+		if tip.Sign() < 0 {
+			tip.SetInt64(0)
+		}
+		txs = append(txs, OrderedTx{
+			Index:           int(hexutil.Int64OrZero(receipt.TransactionIndex)),
+			Hash:            receipt.TransactionHash,
+			From:            receipt.From,
+			To:              receipt.To,
+			PriorityFeeGwei: weiToGwei(tip),
+		})
+	}
+	sort.SliceStable(txs, func(i, j int) bool { return txs[i].Index < txs[j].Index })
+
+	result := BlockOrderingResponse{
+		BlockNumber:        hexutil.Int64OrZero(block.Number),
+		BlockHash:          block.Hash,
+		Miner:              block.Miner,
+		BaseFeeGwei:        weiToGwei(baseFee),
+		TxCount:            len(txs),
+		Reorderings:        []ReorderingEvent{},
+		PossibleSandwiches: []SandwichCandidate{},
+	}
+	if len(txs) == 0 {
+		result.FeeOrdered = true
+		return result
+	}
+
+	// Fee ranks: the span of positions each fee level would occupy in a
+	// block sorted by descending priority fee
+	fees := make([]float64, len(txs))
+	for i, tx := range txs {
+		fees[i] = tx.PriorityFeeGwei
+	}
+	sorted := append([]float64(nil), fees...)
+	sort.Sort(sort.Reverse(sort.Float64Slice(sorted)))
+	first := make(map[float64]int, len(sorted))
+	last := make(map[float64]int, len(sorted))
+	for pos, fee := range sorted {
+		if _, ok := first[fee]; !ok {
+			first[fee] = pos
+		}
+		last[fee] = pos
+	}
+
+	// Entropy of the displacement from the fee rank span
+	displacements := make(map[int]int)
+	for pos := range txs {
+		fee := fees[pos]
+		txs[pos].FeeRank = first[fee]
+		d := 0
+		if pos < first[fee] {
+			d = first[fee] - pos
+		} else if pos > last[fee] {
+			d = pos - last[fee]
+		}
+		displacements[d]++
+	}
+	if len(txs) > 1 {
+		n := float64(len(txs))
+		for _, count := range displacements {
+			p := float64(count) / n
+			result.OrderingEntropy -= p * math.Log2(p)
+		}
+		result.OrderingEntropy /= math.Log2(n)
+	}
+
+	// Inversions between senders, and how far each transaction was outbid
+	maxLater := make([]float64, len(txs))
+	for i := range txs {
+		for j := i + 1; j < len(txs); j++ {
+			if txs[i].From == txs[j].From {
+				continue
+			}
+			result.ComparablePairs++
+			if fees[i] < fees[j] {
+				result.Inversions++
+				txs[i].OutbidBy++
+				if fees[j] > maxLater[i] {
+					maxLater[i] = fees[j]
+				}
+			}
+		}
+	}
+	if result.ComparablePairs > 0 {
+		result.InversionRate = float64(result.Inversions) / float64(result.ComparablePairs)
+	}
+	result.FeeOrdered = result.Inversions == 0
+
+	for i, tx := range txs {
+		if tx.OutbidBy > 0 {
+			result.Reorderings = append(result.Reorderings, ReorderingEvent{
+				Index:           tx.Index,
+				Hash:            tx.Hash,
+				From:            tx.From,
+				PriorityFeeGwei: tx.PriorityFeeGwei,
+				OutbidBy:        tx.OutbidBy,
+				MaxLaterFeeGwei: maxLater[i],
+				PositionsEarly:  tx.FeeRank - i,
+			})
+		}
+	}
+	sort.SliceStable(result.Reorderings, func(i, j int) bool {
+		return result.Reorderings[i].OutbidBy > result.Reorderings[j].OutbidBy
+	})
+
+	for i := 0; i+2 < len(txs); i++ {
+		front, victim, back := txs[i], txs[i+1], txs[i+2]
+		if front.From == back.From && front.From != victim.From &&
+			front.To != "" && front.To == victim.To && victim.To == back.To {
+			result.PossibleSandwiches = append(result.PossibleSandwiches, SandwichCandidate{
+				FrontIndex:  front.Index,
+				VictimIndex: victim.Index,
+				BackIndex:   back.Index,
+				Attacker:    front.From,
+				Victim:      victim.From,
+				Contract:    front.To,
+			})
+		}
+	}
+
+	result.FeeCorrelation = spearman(fees)
+	result.Transactions = txs
+	return result
+}
+
+// spearman returns the rank correlation of position and value, nil when
+// either is constant
+func spearman(values []float64) *float64 {
+	n := len(values)
+	if n < 2 {
+		return nil
+	}
+
+	// Average ranks, so ties do not depend on their order
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return values[order[a]] < values[order[b]] })
+	ranks := make([]float64, n)
+	for start := 0; start < n; {
+		end := start
+		for end+1 < n && values[order[end+1]] == values[order[start]] {
+			end++
+		}
+		for k := start; k <= end; k++ {
+			ranks[order[k]] = float64(start+end) / 2
+		}
+		start = end + 1
+	}
+
+	mean := float64(n-1) / 2
+	var cov, varPos, varRank float64
+	for i, rank := range ranks {
+		dp, dr := float64(i)-mean, rank-mean
+		cov += dp * dr
+		varPos += dp * dp
+		varRank += dr * dr
+	}
+	if varRank == 0 {
+		return nil
+	}
+	rho := cov / math.Sqrt(varPos*varRank)
+	return &rho
+}
+
+// handleBlockOrdering analyzes a block's transaction order against priority
+// fees (:number is a block number or "latest"; ?limit= reorderings
+// returned, default 20; ?transactions=true lists every transaction)
+func handleBlockOrdering(c *gin.Context) {
+	var number int64
+	if param := c.Param("number"); param == "latest" {
+		head, err := GetMonadClient().GetBlockNumberByTag("latest")
+		if err != nil {
+			c.JSON(http.StatusBadGateway, APIError{Error: err.Error()})
+			return
+		}
+		number = head
+	} else if n, err := strconv.ParseInt(param, 10, 64); err == nil && n >= 0 {
+		number = n
+	} else {
+		c.JSON(http.StatusBadRequest, APIError{Error: "Block number must be a non-negative integer or \"latest\""})
+		return
+	}
+
+	limit := 20
+	if value := c.Query("limit"); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n >= 0 {
+			limit = n
+		}
+	}
+
+	block, err := GetMonadClient().GetBlockByNumber(number)
+	if errors.Is(err, errEmptyResult) {
+		c.JSON(http.StatusNotFound, APIError{Error: "Block not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusBadGateway, APIError{Error: err.Error()})
+		return
+	}
+	receipts, err := GetMonadClient().GetBlockReceipts(number)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, APIError{Error: err.Error()})
+		return
+	}
+
+	result := AnalyzeBlockOrdering(block, receipts)
+	if len(result.Reorderings) > limit {
+		result.Reorderings = result.Reorderings[:limit]
+	}
+	if c.Query("transactions") != "true" {
+		result.Transactions = nil
+	}
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,448 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Replica roles. A collector talks to the node and publishes every stream
+// message; viewers only subscribe and fan out to their own clients.
+const (
+	roleCollector = "collector"
+	roleViewer    = "viewer"
+)
+
+// BroadcastBus carries stream messages between dashboard replicas
+type BroadcastBus interface {
+	Publish(payload []byte) error
+	Subscribe(handler func(payload []byte))
+	Connected() bool
+	Close()
+}
+
+// Global broadcast bus state (bus is nil for single-replica deployments)
+var (
+	broadcastBus     BroadcastBus
+	dashboardRole    = roleCollector
+	busPublished     atomic.Uint64
+	busReceived      atomic.Uint64
+	busPublishErrors atomic.Uint64
+
+	// Last message per topic/key, replayed to clients connecting to a viewer
+	busCacheMu    sync.RWMutex
+	busCache      = make(map[string]map[string]interface{})
+	busCacheOrder []string
+)
+
+// InitializeBroadcastBus connects to BROADCAST_REDIS_URL if set. DASHBOARD_ROLE
+// selects "collector" (default) or "viewer".
+func InitializeBroadcastBus() error {
+	if role := strings.ToLower(os.Getenv("DASHBOARD_ROLE")); role != "" {
+		if role != roleCollector && role != roleViewer {
+			return fmt.Errorf("invalid DASHBOARD_ROLE %q (expected collector or viewer)", role)
+		}
+		dashboardRole = role
+	}
+
+	redisURL := os.Getenv("BROADCAST_REDIS_URL")
+	if redisURL == "" {
+		if dashboardRole == roleViewer {
+			return errors.New("DASHBOARD_ROLE=viewer requires BROADCAST_REDIS_URL")
+		}
+		return nil
+	}
+
+	channel := os.Getenv("BROADCAST_CHANNEL")
+	if channel == "" {
+		channel = "monad-dashboard"
+	}
+
+	bus, err := newRedisBus(redisURL, channel)
+	if err != nil {
+		return err
+	}
+	broadcastBus = bus
+
+	switch dashboardRole {
+	case roleCollector:
+		// One update loop per replica feeds the bus, independent of local clients
+		go sendFiredancerUpdates(publishToBus)
+	case roleViewer:
+		bus.Subscribe(handleBusMessage)
+	}
+
+	log.Printf("✅ Broadcast bus connected (redis channel %q, role %s)", channel, dashboardRole)
+	return nil
+}
+
+// isViewerReplica reports whether this replica gets its data from the bus
+func isViewerReplica() bool {
+	return dashboardRole == roleViewer
+}
+
+// publishToBus publishes a stream message for viewer replicas
+func publishToBus(msg interface{}) error {
+	if broadcastBus == nil || dashboardRole != roleCollector {
+		return nil
+	}
+
+	payload, err := json.Marshal(unwrapMessage(msg))
+	if err != nil {
+		return err
+	}
+	if err := broadcastBus.Publish(payload); err != nil {
+		// Keep the collector's update loop running while Redis is down
+		if busPublishErrors.Add(1)%100 == 1 {
+			log.Printf("Broadcast bus publish error: %v", err)
+		}
+		return nil
+	}
+	busPublished.Add(1)
+	return nil
+}
+
+// handleBusMessage fans a message from the collector out to local clients
+func handleBusMessage(payload []byte) {
+	var msg map[string]interface{}
+	if err := json.Unmarshal(payload, &msg); err != nil {
+		return
+	}
+	busReceived.Add(1)
+
+	topic, _ := msg["topic"].(string)
+	key, _ := msg["key"].(string)
+	if key != "ping" {
+		cacheKey := topic + "." + key
+		busCacheMu.Lock()
+		if _, ok := busCache[cacheKey]; !ok {
+			busCacheOrder = append(busCacheOrder, cacheKey)
+		}
+		busCache[cacheKey] = msg
+		busCacheMu.Unlock()
+	}
+
+	recordSequenced(msg)
+	fanOutToLocalClients(msg)
+}
+
+// replayBusCache sends a new client the latest value of every topic/key
+func replayBusCache(send messageSender) error {
+	busCacheMu.RLock()
+	messages := make([]map[string]interface{}, 0, len(busCacheOrder))
+	for _, cacheKey := range busCacheOrder {
+		messages = append(messages, busCache[cacheKey])
+	}
+	busCacheMu.RUnlock()
+
+	for _, msg := range messages {
+		if err := send(msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BroadcastStatusResponse is the body of /api/v1/broadcast
+type BroadcastStatusResponse struct {
+	Role    string `json:"role"`
+	Enabled bool   `json:"enabled"`
+	*BroadcastBusStats
+}
+
+// BroadcastBusStats are reported only when a bus is configured
+type BroadcastBusStats struct {
+	Connected     bool   `json:"connected"`
+	Published     uint64 `json:"published"`
+	Received      uint64 `json:"received"`
+	PublishErrors uint64 `json:"publish_errors"`
+	CachedKeys    int    `json:"cached_keys"`
+}
+
+// handleBroadcastStatus returns the replica role and bus statistics
+func handleBroadcastStatus(c *gin.Context) {
+	status := BroadcastStatusResponse{
+		Role:    dashboardRole,
+		Enabled: broadcastBus != nil,
+	}
+	if broadcastBus != nil {
+		busCacheMu.RLock()
+		cachedKeys := len(busCache)
+		busCacheMu.RUnlock()
+
+		status.BroadcastBusStats = &BroadcastBusStats{
+			Connected:     broadcastBus.Connected(),
+			Published:     busPublished.Load(),
+			Received:      busReceived.Load(),
+			PublishErrors: busPublishErrors.Load(),
+			CachedKeys:    cachedKeys,
+		}
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+// redisBus is a Redis pub/sub BroadcastBus speaking RESP directly
+type redisBus struct {
+	addr     string
+	password string
+	db       int
+	channel  string
+
+	pubMu   sync.Mutex
+	pubConn *redisConn
+
+	subConnected atomic.Bool
+	closed       chan struct{}
+	closeOnce    sync.Once
+}
+
+// newRedisBus parses redis://[:password@]host:port[/db] and checks connectivity
+func newRedisBus(rawURL, channel string) (*redisBus, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "redis" {
+		return nil, fmt.Errorf("invalid BROADCAST_REDIS_URL %q (expected redis://host:port)", rawURL)
+	}
+
+	bus := &redisBus{
+		addr:    u.Host,
+		channel: channel,
+		closed:  make(chan struct{}),
+	}
+	if !strings.Contains(bus.addr, ":") {
+		bus.addr += ":6379"
+	}
+	if u.User != nil {
+		if password, ok := u.User.Password(); ok {
+			bus.password = password
+		} else {
+			bus.password = u.User.Username()
+		}
+	}
+	if db := strings.Trim(u.Path, "/"); db != "" {
+		if bus.db, err = strconv.Atoi(db); err != nil {
+			return nil, fmt.Errorf("invalid redis database %q", db)
+		}
+	}
+
+	conn, err := bus.dial()
+	if err != nil {
+		return nil, err
+	}
+	bus.pubConn = conn
+	return bus, nil
+}
+
+// dial opens an authenticated connection to Redis
+func (b *redisBus) dial() (*redisConn, error) {
+	conn, err := net.DialTimeout("tcp", b.addr, 5*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to redis at %s: %w", b.addr, err)
+	}
+	rc := &redisConn{conn: conn, r: bufio.NewReader(conn)}
+
+	if b.password != "" {
+		if _, err := rc.do("AUTH", b.password); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("redis auth failed: %w", err)
+		}
+	}
+	if b.db != 0 {
+		if _, err := rc.do("SELECT", strconv.Itoa(b.db)); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("redis select failed: %w", err)
+		}
+	}
+	return rc, nil
+}
+
+// Publish sends payload to the channel, reconnecting once on failure
+func (b *redisBus) Publish(payload []byte) error {
+	b.pubMu.Lock()
+	defer b.pubMu.Unlock()
+
+	for attempt := 0; attempt < 2; attempt++ {
+		if b.pubConn == nil {
+			conn, err := b.dial()
+			if err != nil {
+				return err
+			}
+			b.pubConn = conn
+		}
+
+		b.pubConn.conn.SetDeadline(time.Now().Add(2 * time.Second))
+		_, err := b.pubConn.do("PUBLISH", b.channel, string(payload))
+		if err == nil {
+			return nil
+		}
+		b.pubConn.conn.Close()
+		b.pubConn = nil
+	}
+	return errors.New("redis publish failed")
+}
+
+// Subscribe delivers channel messages to handler, reconnecting until Close
+func (b *redisBus) Subscribe(handler func(payload []byte)) {
+	go func() {
+		for {
+			select {
+			case <-b.closed:
+				return
+			default:
+			}
+
+			if err := b.subscribeOnce(handler); err != nil {
+				log.Printf("Broadcast bus subscription lost: %v (reconnecting)", err)
+			}
+			b.subConnected.Store(false)
+
+			select {
+			case <-b.closed:
+				return
+			case <-time.After(2 * time.Second):
+			}
+		}
+	}()
+}
+
+// subscribeOnce runs one subscription until the connection fails
+func (b *redisBus) subscribeOnce(handler func(payload []byte)) error {
+	conn, err := b.dial()
+	if err != nil {
+		return err
+	}
+	defer conn.conn.Close()
+
+	go func() {
+		<-b.closed
+		conn.conn.Close()
+	}()
+
+	if _, err := conn.do("SUBSCRIBE", b.channel); err != nil {
+		return err
+	}
+	b.subConnected.Store(true)
+
+	for {
+		reply, err := conn.readReply()
+		if err != nil {
+			return err
+		}
+		// Pushes are ["message", channel, payload]
+		parts, ok := reply.([]interface{})
+		if !ok || len(parts) != 3 {
+			continue
+		}
+		if kind, _ := parts[0].(string); kind != "message" {
+			continue
+		}
+		if payload, ok := parts[2].(string); ok {
+			handler([]byte(payload))
+		}
+	}
+}
+
+// Connected reports whether the bus can currently deliver messages
+func (b *redisBus) Connected() bool {
+	if dashboardRole == roleViewer {
+		return b.subConnected.Load()
+	}
+	b.pubMu.Lock()
+	defer b.pubMu.Unlock()
+	return b.pubConn != nil
+}
+
+// Close stops the subscription and closes connections
+func (b *redisBus) Close() {
+	b.closeOnce.Do(func() {
+		close(b.closed)
+		b.pubMu.Lock()
+		if b.pubConn != nil {
+			b.pubConn.conn.Close()
+			b.pubConn = nil
+		}
+		b.pubMu.Unlock()
+	})
+}
+
+// redisConn is a minimal RESP client connection
+type redisConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// do sends a command and reads its reply
+func (c *redisConn) do(args ...string) (interface{}, error) {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&sb, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := c.conn.Write([]byte(sb.String())); err != nil {
+		return nil, err
+	}
+	return c.readReply()
+}
+
+// readReply reads one RESP value
+func (c *redisConn) readReply() (interface{}, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("empty redis reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, errors.New(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		size, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if size < 0 {
+			return nil, nil
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(c.r, buf); err != nil {
+			return nil, err
+		}
+		return string(buf[:size]), nil
+	case '*':
+		count, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if count < 0 {
+			return nil, nil
+		}
+		items := make([]interface{}, count)
+		for i := range items {
+			if items[i], err = c.readReply(); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("unexpected redis reply %q", line)
+}
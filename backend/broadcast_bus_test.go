@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestRedisReadReply decodes recorded RESP replies and rejects malformed ones
+func TestRedisReadReply(t *testing.T) {
+	tests := []struct {
+		input string
+		want  interface{}
+		err   string
+	}{
+		{input: "+OK\r\n", want: "OK"},
+		{input: ":42\r\n", want: int64(42)},
+		{input: "$5\r\nhello\r\n", want: "hello"},
+		{input: "$4\r\na\r\nb\r\n", want: "a\r\nb"}, // Bulk strings are length-prefixed
+		{input: "$0\r\n\r\n", want: ""},
+		{input: "$-1\r\n", want: nil},
+		{input: "*-1\r\n", want: nil},
+		{input: "*3\r\n$9\r\nsubscribe\r\n$4\r\nchan\r\n:1\r\n", want: []interface{}{"subscribe", "chan", int64(1)}},
+		{input: "*3\r\n$7\r\nmessage\r\n$4\r\nchan\r\n$7\r\n{\"n\":1}\r\n", want: []interface{}{"message", "chan", `{"n":1}`}},
+		{input: "-WRONGPASS invalid username-password pair\r\n", err: "WRONGPASS invalid username-password pair"},
+		{input: "\r\n", err: "empty redis reply"},
+		{input: "!3\r\nerr\r\n", err: "unexpected redis reply"},
+		{input: ":x\r\n", err: "invalid syntax"},
+		{input: "$x\r\n", err: "invalid syntax"},
+		{input: "$5\r\nhel", err: "EOF"},
+		{input: "*2\r\n+OK\r\n", err: "EOF"},
+		{input: "+OK", err: "EOF"},
+	}
+	for _, tt := range tests {
+		c := &redisConn{r: bufio.NewReader(strings.NewReader(tt.input))}
+		got, err := c.readReply()
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("readReply(%q) = %v, %v; want error %q", tt.input, got, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("readReply(%q) = %#v, %v; want %#v", tt.input, got, err, tt.want)
+		}
+	}
+}
+
+// TestRedisDo sends commands as RESP arrays of bulk strings
+func TestRedisDo(t *testing.T) {
+	const want = "*3\r\n$7\r\nPUBLISH\r\n$4\r\nchan\r\n$6\r\n{\r\n}\r\n\r\n"
+	client, server := net.Pipe()
+	defer client.Close()
+	sent := make(chan string, 1)
+	go func() {
+		defer server.Close()
+		buf := make([]byte, len(want))
+		if _, err := io.ReadFull(server, buf); err != nil {
+			sent <- err.Error()
+			return
+		}
+		sent <- string(buf)
+		io.WriteString(server, ":2\r\n")
+	}()
+
+	c := &redisConn{conn: client, r: bufio.NewReader(client)}
+	reply, err := c.do("PUBLISH", "chan", "{\r\n}\r\n")
+	if err != nil || reply != int64(2) {
+		t.Fatalf("do = %v, %v", reply, err)
+	}
+	if got := <-sent; got != want {
+		t.Errorf("sent %q", got)
+	}
+}
+
+// TestRedisBusAuth authenticates and selects the database on connect, and
+// fails with the server's error when either is refused
+func TestRedisBusAuth(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		url     string
+		replies map[string]string // By command
+		want    []string          // Commands received
+		err     string
+	}{
+		{"ok", "redis://:secret@%s/2", map[string]string{"AUTH": "+OK", "SELECT": "+OK"}, []string{"AUTH secret", "SELECT 2"}, ""},
+		{"no auth", "redis://%s", nil, nil, ""},
+		{"wrong password", "redis://:bad@%s", map[string]string{"AUTH": "-WRONGPASS invalid username-password pair"}, []string{"AUTH bad"}, "redis auth failed: WRONGPASS"},
+		{"bad database", "redis://%s/99", map[string]string{"SELECT": "-ERR DB index is out of range"}, []string{"SELECT 99"}, "redis select failed: ERR DB index"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ln, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer ln.Close()
+			received := make(chan []string, 1)
+			go func() {
+				conn, err := ln.Accept()
+				if err != nil {
+					return
+				}
+				defer conn.Close()
+				c := &redisConn{conn: conn, r: bufio.NewReader(conn)}
+				var commands []string
+				defer func() { received <- commands }()
+				for {
+					reply, err := c.readReply()
+					if err != nil {
+						return
+					}
+					args, _ := reply.([]interface{})
+					var parts []string
+					for _, arg := range args {
+						s, _ := arg.(string)
+						parts = append(parts, s)
+					}
+					if len(parts) == 0 {
+						return
+					}
+					commands = append(commands, strings.Join(parts, " "))
+					io.WriteString(conn, tt.replies[parts[0]]+"\r\n")
+				}
+			}()
+
+			bus, err := newRedisBus(strings.Replace(tt.url, "%s", ln.Addr().String(), 1), "monad")
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("newRedisBus = %v, want %q", err, tt.err)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			} else {
+				bus.Close()
+			}
+			if got := <-received; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("commands %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
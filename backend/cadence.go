@@ -0,0 +1,220 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Cadence is the polling interval of one loop. In adaptive mode the loop
+// slows to Base*IdleFactor while nobody is watching the stream, and loops
+// with WakeOnBlock tick as soon as a block arrives and run at Fast for a
+// moment afterwards.
+type Cadence struct {
+	Name        string
+	Base        time.Duration
+	Fast        time.Duration
+	IdleFactor  int
+	WakeOnBlock bool
+}
+
+// Adaptive cadence state
+var (
+	cadenceAdaptive atomic.Bool
+	lastBlockSignal atomic.Int64 // Unix nanoseconds
+
+	blockWakeMu sync.Mutex
+	blockWake   = make(chan struct{})
+)
+
+// burstWindow is how long a loop stays at its fast cadence after a block
+const burstWindow = time.Second
+
+// Configured cadences, overridable through CADENCE_* environment variables
+var (
+	updateCadence     = newCadence("updates", "CADENCE_UPDATES", 200*time.Millisecond, true)
+	metricsCadence    = newCadence("metrics", "CADENCE_METRICS", time.Second, false)
+	prometheusCadence = newCadence("prometheus", "CADENCE_PROMETHEUS", 5*time.Second, false)
+	ipcCadence        = newCadence("ipc", "CADENCE_IPC", time.Second, false)
+
+	allCadences = []*Cadence{updateCadence, metricsCadence, prometheusCadence, ipcCadence}
+)
+
+// newCadence reads a duration from env, falling back to the default
+func newCadence(name, env string, base time.Duration, wakeOnBlock bool) *Cadence {
+	if value := os.Getenv(env); value != "" {
+		if d, err := time.ParseDuration(value); err == nil && d > 0 {
+			base = d
+		} else {
+			log.Printf("Invalid %s %q, using %s", env, value, base)
+		}
+	}
+	fast := base / 2
+	if fast < 50*time.Millisecond {
+		fast = 50 * time.Millisecond
+	}
+	if fast > base {
+		fast = base
+	}
+	return &Cadence{Name: name, Base: base, Fast: fast, IdleFactor: 5, WakeOnBlock: wakeOnBlock}
+}
+
+// InitializeCadence enables adaptive mode when CADENCE_ADAPTIVE=true.
+// CADENCE_IDLE_FACTOR (default 5) sets the slowdown with no clients.
+func InitializeCadence() {
+	cadenceAdaptive.Store(os.Getenv("CADENCE_ADAPTIVE") == "true")
+
+	idleFactor := 5
+	if value := os.Getenv("CADENCE_IDLE_FACTOR"); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n >= 1 {
+			idleFactor = n
+		} else {
+			log.Printf("Invalid CADENCE_IDLE_FACTOR %q, using %d", value, idleFactor)
+		}
+	}
+	for _, c := range allCadences {
+		c.IdleFactor = idleFactor
+	}
+
+	log.Printf("Cadence: updates=%s metrics=%s prometheus=%s ipc=%s adaptive=%v",
+		updateCadence.Base, metricsCadence.Base, prometheusCadence.Base, ipcCadence.Base, cadenceAdaptive.Load())
+}
+
+// signalBlockArrival wakes loops that follow blocks
+func signalBlockArrival() {
+	lastBlockSignal.Store(time.Now().UnixNano())
+
+	blockWakeMu.Lock()
+	close(blockWake)
+	blockWake = make(chan struct{})
+	blockWakeMu.Unlock()
+}
+
+// blockWakeChannel returns a channel closed at the next block arrival
+func blockWakeChannel() <-chan struct{} {
+	blockWakeMu.Lock()
+	defer blockWakeMu.Unlock()
+	return blockWake
+}
+
+// streamHasAudience reports whether anyone receives stream updates. A
+// collector publishing to the broadcast bus always has an audience, since
+// viewer replicas' clients are not visible here.
+func streamHasAudience() bool {
+	if broadcastBus != nil {
+		return true
+	}
+	wsClientsMu.RLock()
+	wsCount := len(wsClients)
+	wsClientsMu.RUnlock()
+	sseClientsMu.RLock()
+	sseCount := len(sseClients)
+	sseClientsMu.RUnlock()
+	return wsCount+sseCount > 0
+}
+
+// Interval returns the current interval for the loop
+func (c *Cadence) Interval() time.Duration {
+	if !cadenceAdaptive.Load() {
+		return c.Base
+	}
+	if !streamHasAudience() {
+		return c.Base * time.Duration(c.IdleFactor)
+	}
+	if c.WakeOnBlock && time.Since(time.Unix(0, lastBlockSignal.Load())) < burstWindow {
+		return c.Fast
+	}
+	return c.Base
+}
+
+// cadenceTicker delivers ticks at the cadence's current interval
+type cadenceTicker struct {
+	C    <-chan time.Time
+	stop chan struct{}
+}
+
+// newCadenceTicker starts a ticker following c
+func newCadenceTicker(c *Cadence) *cadenceTicker {
+	ticks := make(chan time.Time, 1)
+	t := &cadenceTicker{C: ticks, stop: make(chan struct{})}
+
+	go func() {
+		for {
+			var wake <-chan struct{}
+			if c.WakeOnBlock && cadenceAdaptive.Load() && streamHasAudience() {
+				wake = blockWakeChannel()
+			}
+			timer := time.NewTimer(c.Interval())
+
+			select {
+			case <-t.stop:
+				timer.Stop()
+				return
+			case now := <-timer.C:
+				t.deliver(ticks, now)
+			case <-wake:
+				timer.Stop()
+				t.deliver(ticks, time.Now())
+			}
+		}
+	}()
+	return t
+}
+
+// deliver sends a tick, dropping it if the consumer is still busy
+func (t *cadenceTicker) deliver(ticks chan time.Time, now time.Time) {
+	select {
+	case ticks <- now:
+	default:
+	}
+}
+
+// Stop ends the ticker
+func (t *cadenceTicker) Stop() {
+	close(t.stop)
+}
+
+// CadenceResponse is the body of /api/v1/cadence
+type CadenceResponse struct {
+	Adaptive bool          `json:"adaptive"`
+	Audience bool          `json:"audience"`
+	Loops    []CadenceLoop `json:"loops"`
+	Dedup    DedupStats    `json:"dedup"`
+}
+
+// CadenceLoop is one polling loop's configured and current interval
+type CadenceLoop struct {
+	Name        string `json:"name"`
+	BaseMs      int64  `json:"base_ms"`
+	FastMs      int64  `json:"fast_ms"`
+	IdleFactor  int    `json:"idle_factor"`
+	WakeOnBlock bool   `json:"wake_on_block"`
+	CurrentMs   int64  `json:"current_ms"`
+}
+
+// handleCadence reports configured and current loop intervals
+func handleCadence(c *gin.Context) {
+	loops := make([]CadenceLoop, 0, len(allCadences))
+	for _, cadence := range allCadences {
+		loops = append(loops, CadenceLoop{
+			Name:        cadence.Name,
+			BaseMs:      cadence.Base.Milliseconds(),
+			FastMs:      cadence.Fast.Milliseconds(),
+			IdleFactor:  cadence.IdleFactor,
+			WakeOnBlock: cadence.WakeOnBlock,
+			CurrentMs:   cadence.Interval().Milliseconds(),
+		})
+	}
+	c.JSON(http.StatusOK, CadenceResponse{
+		Adaptive: cadenceAdaptive.Load(),
+		Audience: streamHasAudience(),
+		Loops:    loops,
+		Dedup:    dedupStats(),
+	})
+}
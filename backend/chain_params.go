@@ -0,0 +1,161 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"monad-dashboard/hexutil"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ChainParams are the chain constants block math depends on
+type ChainParams struct {
+	ChainID       uint64            `json:"chain_id,omitempty"`
+	EpochLength   int64             `json:"epoch_length"`   // Blocks per epoch
+	BlockTime     float64           `json:"block_time"`     // Seconds per block
+	FinalityDepth int64             `json:"finality_depth"` // Blocks from proposal to finalization
+	Sources       map[string]string `json:"sources"`        // Per parameter: "default", "config" or "node"
+}
+
+// defaultChainParams are MonadBFT's parameters
+func defaultChainParams() ChainParams {
+	return ChainParams{
+		EpochLength:   50000,
+		BlockTime:     0.4,
+		FinalityDepth: 2,
+		Sources: map[string]string{
+			"epoch_length":   "default",
+			"block_time":     "default",
+			"finality_depth": "default",
+		},
+	}
+}
+
+// Epoch returns the epoch a block belongs to
+func (p ChainParams) Epoch(block int64) int64 {
+	return block / p.EpochLength
+}
+
+// EpochBounds returns an epoch's first block and the first block of the next
+func (p ChainParams) EpochBounds(epoch int64) (int64, int64) {
+	return epoch * p.EpochLength, (epoch + 1) * p.EpochLength
+}
+
+// BlockDuration is the block time as a duration
+func (p ChainParams) BlockDuration() time.Duration {
+	return time.Duration(p.BlockTime * float64(time.Second))
+}
+
+// Global chain parameters
+var (
+	chainParams   = defaultChainParams()
+	chainParamsMu sync.RWMutex
+)
+
+// GetChainParams returns the current chain parameters
+func GetChainParams() ChainParams {
+	chainParamsMu.RLock()
+	defer chainParamsMu.RUnlock()
+	p := chainParams
+	p.Sources = make(map[string]string, len(chainParams.Sources))
+	for name, source := range chainParams.Sources {
+		p.Sources[name] = source
+	}
+	return p
+}
+
+// InitializeChainParams applies CHAIN_EPOCH_LENGTH, CHAIN_BLOCK_TIME and
+// CHAIN_FINALITY_DEPTH, then asks the node for its chain ID and measures the
+// block time over recent blocks for any parameter not configured
+func InitializeChainParams() {
+	chainParamsMu.Lock()
+	if n, ok := positiveIntEnv("CHAIN_EPOCH_LENGTH"); ok {
+		chainParams.EpochLength = n
+		chainParams.Sources["epoch_length"] = "config"
+	}
+	if n, ok := positiveIntEnv("CHAIN_FINALITY_DEPTH"); ok {
+		chainParams.FinalityDepth = n
+		chainParams.Sources["finality_depth"] = "config"
+	}
+	if value := os.Getenv("CHAIN_BLOCK_TIME"); value != "" {
+		if d, err := time.ParseDuration(value); err == nil && d > 0 {
+			chainParams.BlockTime = d.Seconds()
+			chainParams.Sources["block_time"] = "config"
+		} else {
+			log.Printf("Invalid CHAIN_BLOCK_TIME %q (want a duration like 400ms)", value)
+		}
+	}
+	configuredBlockTime := chainParams.Sources["block_time"] == "config"
+	chainParamsMu.Unlock()
+
+	if isDemoMode() {
+		return
+	}
+	go func() {
+		if chainID, err := GetMonadClient().GetChainID(); err == nil {
+			chainParamsMu.Lock()
+			chainParams.ChainID = chainID
+			chainParamsMu.Unlock()
+		}
+		if !configuredBlockTime {
+			if blockTime, ok := measureBlockTime(1000); ok {
+				chainParamsMu.Lock()
+				chainParams.BlockTime = blockTime
+				chainParams.Sources["block_time"] = "node"
+				chainParamsMu.Unlock()
+			}
+		}
+		p := GetChainParams()
+		log.Printf("Chain parameters: epoch %d blocks, block time %.3fs (%s), finality depth %d",
+			p.EpochLength, p.BlockTime, p.Sources["block_time"], p.FinalityDepth)
+	}()
+}
+
+// positiveIntEnv parses a positive integer variable
+func positiveIntEnv(name string) (int64, bool) {
+	value := os.Getenv(name)
+	if value == "" {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n <= 0 {
+		log.Printf("Invalid %s %q (want a positive integer)", name, value)
+		return 0, false
+	}
+	return n, true
+}
+
+// measureBlockTime averages the block time over the last span blocks. Block
+// timestamps have one-second resolution, so short spans are not precise enough.
+func measureBlockTime(span int64) (float64, bool) {
+	head, err := GetMonadClient().GetBlockNumberByTag("latest")
+	if err != nil || head < 100 {
+		return 0, false
+	}
+	if span > head {
+		span = head
+	}
+	latest, err := GetMonadClient().GetBlockByNumber(head)
+	if err != nil {
+		return 0, false
+	}
+	earlier, err := GetMonadClient().GetBlockByNumber(head - span)
+	if err != nil {
+		return 0, false
+	}
+	elapsed := hexutil.Int64OrZero(latest.Timestamp) - hexutil.Int64OrZero(earlier.Timestamp)
+	if elapsed <= 0 {
+		return 0, false
+	}
+	return float64(elapsed) / float64(span), true
+}
+
+// handleChainParams reports the chain parameters in use and where each came from
+func handleChainParams(c *gin.Context) {
+	c.JSON(http.StatusOK, GetChainParams())
+}
@@ -0,0 +1,597 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"monad-dashboard/hexutil"
+)
+
+// knownChainNames names well-known Monad chain IDs
+var knownChainNames = map[uint64]string{
+	10143: "testnet",
+	143:   "mainnet",
+}
+
+// ChainSummary is the head state of one monitored chain
+type ChainSummary struct {
+	Name           string  `json:"name"`
+	ChainID        uint64  `json:"chain_id"`
+	Primary        bool    `json:"primary"`
+	RPCURL         string  `json:"rpc_url"` // Scheme and host only; see redactRPCURL
+	Connected      bool    `json:"connected"`
+	LatestBlock    int64   `json:"latest_block"`
+	FinalizedBlock int64   `json:"finalized_block"`
+	LastBlockTime  int64   `json:"last_block_time"`
+	BlockTime      float64 `json:"block_time"` // Seconds, averaged over recent blocks
+	TPS            float64 `json:"tps"`
+	LastError      string  `json:"last_error,omitempty"`
+}
+
+// ChainBlock is a block observed on a secondary chain
+type ChainBlock struct {
+	Number    int64  `json:"number"`
+	Hash      string `json:"hash"`
+	Timestamp int64  `json:"timestamp"`
+	Miner     string `json:"miner"`
+	TxCount   int    `json:"tx_count"`
+	GasUsed   int64  `json:"gas_used"`
+	GasLimit  int64  `json:"gas_limit"`
+}
+
+// ChainMonitor polls a secondary chain's RPC for new heads. The primary
+// chain is served by the full collector pipeline; secondary chains get head,
+// finality, block time and TPS tracking only.
+type ChainMonitor struct {
+	name    string
+	client  *MonadClient
+	private bool // Started for a tenant's own node; served only to its tenants
+
+	mu        sync.RWMutex
+	chainID   uint64
+	blocks    []ChainBlock // Oldest first
+	maxBlocks int
+	finalized int64
+	lastErr   error
+}
+
+// ChainRegistry holds the primary chain name and the secondary monitors.
+// Monitors of tenants' own nodes are kept apart so the public chain routes,
+// listing and broadcasts never reveal them.
+type ChainRegistry struct {
+	mu        sync.RWMutex
+	primary   string
+	primaryID uint64 // From eth_chainId at startup; 0 when the node was unreachable
+	secondary map[string]*ChainMonitor
+	private   map[string]*ChainMonitor // By tenant ID
+}
+
+// Global chain registry and the router used to forward primary chain requests
+var (
+	chainRegistry = &ChainRegistry{
+		secondary: make(map[string]*ChainMonitor),
+		private:   make(map[string]*ChainMonitor),
+	}
+	apiRouter *gin.Engine
+)
+
+// InitializeChains names the primary chain and starts monitors for the
+// secondary chains. MONAD_CHAIN_NAME names the primary chain (default: from
+// its chain ID, "testnet"/"mainnet", else "local"). MONAD_CHAINS lists
+// secondary chains as comma-separated name=rpc_url pairs, e.g.
+// "mainnet=https://rpc.example.org"; several RPC URLs for one chain are
+// separated by |.
+func InitializeChains() {
+	primaryID, err := GetMonadClient().GetChainID()
+	if err != nil {
+		log.Printf("Could not read the primary chain ID: %v", err)
+	}
+	primary := os.Getenv("MONAD_CHAIN_NAME")
+	if primary == "" {
+		primary = "local"
+		if name, ok := knownChainNames[primaryID]; ok {
+			primary = name
+		}
+	}
+
+	chainRegistry.mu.Lock()
+	chainRegistry.primary = primary
+	chainRegistry.primaryID = primaryID
+	chainRegistry.mu.Unlock()
+
+	for _, entry := range strings.Split(os.Getenv("MONAD_CHAINS"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, rpcURL, ok := strings.Cut(entry, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" || rpcURL == "" {
+			log.Printf("Invalid MONAD_CHAINS entry %q (want name=rpc_url)", entry)
+			continue
+		}
+		if name == primary {
+			log.Printf("MONAD_CHAINS entry %q duplicates the primary chain, skipping", name)
+			continue
+		}
+		startChainMonitor(name, strings.ReplaceAll(rpcURL, "|", ","), false)
+	}
+
+	log.Printf("Primary chain: %s (%d secondary)", primary, len(chainRegistry.secondary))
+}
+
+// redactRPCURL reduces an RPC URL to its scheme and host for public
+// responses: providers routinely put API keys in the path, query or
+// userinfo
+func redactRPCURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+// startChainMonitor registers and starts a secondary chain monitor, a
+// private one for a tenant's own node
+func startChainMonitor(name, rpcURLs string, private bool) *ChainMonitor {
+	client := NewMonadClient(rpcURLs, "", "")
+	client.cache = NewBlockCache(256, 5*time.Minute)
+	monitor := &ChainMonitor{name: name, client: client, maxBlocks: 100, private: private}
+
+	chainRegistry.mu.Lock()
+	if private {
+		chainRegistry.private[name] = monitor
+	} else {
+		chainRegistry.secondary[name] = monitor
+	}
+	chainRegistry.mu.Unlock()
+
+	go monitor.run(time.Second)
+	if private {
+		log.Printf("Monitoring tenant chain %s at %s", name, redactRPCURL(client.ExecutionRPCUrl))
+	} else {
+		log.Printf("Monitoring secondary chain %s at %s", name, client.ExecutionRPCUrl)
+	}
+	return monitor
+}
+
+// chainForRPC returns the monitored chain whose RPC endpoints include
+// rpcURL, so several users of one node share its collector, or starts a
+// private monitor named name for it, reporting whether it did. The name must
+// not shadow a public chain, since tenants see the messages of every chain
+// by their name.
+func chainForRPC(name, rpcURL string) (chain string, started bool, err error) {
+	rpcURL = strings.TrimRight(rpcURL, "/")
+	sameNode := func(client *MonadClient) bool {
+		for _, url := range client.rpc.URLs() {
+			if strings.TrimRight(url, "/") == rpcURL {
+				return true
+			}
+		}
+		return false
+	}
+
+	if sameNode(GetMonadClient()) {
+		return primaryChainName(), false, nil
+	}
+	chainRegistry.mu.RLock()
+	for _, monitors := range []map[string]*ChainMonitor{chainRegistry.secondary, chainRegistry.private} {
+		for chain, monitor := range monitors {
+			if sameNode(monitor.client) {
+				chainRegistry.mu.RUnlock()
+				return chain, false, nil
+			}
+		}
+	}
+	_, taken := chainRegistry.secondary[name]
+	taken = taken || name == chainRegistry.primary
+	chainRegistry.mu.RUnlock()
+	if taken {
+		return "", false, fmt.Errorf("chain %q is already monitored at another RPC URL", name)
+	}
+	return startChainMonitor(name, rpcURL, true).name, true, nil
+}
+
+// primaryChainName returns the name of the chain served by the collectors
+func primaryChainName() string {
+	chainRegistry.mu.RLock()
+	defer chainRegistry.mu.RUnlock()
+	return chainRegistry.primary
+}
+
+// lookupChain resolves a public chain by name or decimal chain ID. It
+// returns a nil monitor for the primary chain.
+func lookupChain(ref string) (monitor *ChainMonitor, primary bool, ok bool) {
+	chainRegistry.mu.RLock()
+	defer chainRegistry.mu.RUnlock()
+
+	if ref == chainRegistry.primary {
+		return nil, true, true
+	}
+	if m, exists := chainRegistry.secondary[ref]; exists {
+		return m, false, true
+	}
+	if id, err := strconv.ParseUint(ref, 10, 64); err == nil {
+		if id == chainRegistry.primaryID {
+			return nil, true, true
+		}
+		for _, m := range chainRegistry.secondary {
+			if m.ChainID() == id {
+				return m, false, true
+			}
+		}
+	}
+	return nil, false, false
+}
+
+// tenantChain returns the monitor of a tenant's secondary chain, private or
+// public
+func tenantChain(name string) (*ChainMonitor, bool) {
+	chainRegistry.mu.RLock()
+	m, ok := chainRegistry.private[name]
+	chainRegistry.mu.RUnlock()
+	if ok {
+		return m, true
+	}
+	m, primary, ok := lookupChain(name)
+	return m, ok && !primary
+}
+
+// run polls the chain head until the process exits
+func (m *ChainMonitor) run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := m.poll(); err != nil {
+			m.mu.Lock()
+			m.lastErr = err
+			m.mu.Unlock()
+		}
+	}
+}
+
+// poll fetches blocks since the last observed head (at most 10 per tick)
+func (m *ChainMonitor) poll() error {
+	if m.ChainID() == 0 {
+		id, err := m.client.GetChainID()
+		if err != nil {
+			return err
+		}
+		m.mu.Lock()
+		m.chainID = id
+		m.mu.Unlock()
+	}
+
+	latest, err := m.client.GetBlockNumberByTag("latest")
+	if err != nil {
+		return err
+	}
+
+	m.mu.RLock()
+	from := latest - 9
+	if n := len(m.blocks); n > 0 && m.blocks[n-1].Number+1 > from {
+		from = m.blocks[n-1].Number + 1
+	}
+	m.mu.RUnlock()
+
+	var added []ChainBlock
+	for number := from; number <= latest; number++ {
+		block, err := m.client.GetBlockByNumber(number)
+		if err != nil {
+			return err
+		}
+		added = append(added, ChainBlock{
+			Number:    number,
+			Hash:      block.Hash,
+			Timestamp: hexutil.Int64OrZero(block.Timestamp),
+			Miner:     block.Miner,
+			TxCount:   len(block.Transactions),
+			GasUsed:   hexutil.Int64OrZero(block.GasUsed),
+			GasLimit:  hexutil.Int64OrZero(block.GasLimit),
+		})
+	}
+
+	finalized, finalizedErr := m.client.GetBlockNumberByTag("finalized")
+
+	m.mu.Lock()
+	m.blocks = append(m.blocks, added...)
+	if len(m.blocks) > m.maxBlocks {
+		m.blocks = append(m.blocks[:0:0], m.blocks[len(m.blocks)-m.maxBlocks:]...)
+	}
+	if finalizedErr == nil {
+		m.finalized = finalized
+	}
+	m.lastErr = nil
+	m.mu.Unlock()
+
+	if len(added) > 0 {
+		update := FiredancerMessage{
+			Topic: "chains",
+			Key:   "update",
+			Chain: m.name,
+			Value: m.Summary(),
+		}
+		if m.private {
+			broadcastToTenantClients(update)
+		} else {
+			broadcastToAllClients(update)
+		}
+	}
+	return m.matchWatchlists(added)
+}
+
+// matchWatchlists matches the logs of new blocks against the watchlists of
+// the tenants on the chain. The primary chain's logs come from the
+// subscriber instead; receipts are only fetched while a tenant watches.
+func (m *ChainMonitor) matchWatchlists(blocks []ChainBlock) error {
+	var watchlists []*Watchlist
+	for _, tenant := range GetTenants() {
+		if tenant.Chain == m.name && tenant.watchlist.Len() > 0 {
+			watchlists = append(watchlists, tenant.watchlist)
+		}
+	}
+	if len(watchlists) == 0 {
+		return nil
+	}
+
+	for _, block := range blocks {
+		if block.TxCount == 0 {
+			continue
+		}
+		receipts, err := m.client.GetBlockReceipts(block.Number)
+		if err != nil {
+			return fmt.Errorf("receipts of block %d: %w", block.Number, err)
+		}
+		for _, receipt := range receipts {
+			for _, raw := range receipt.Logs {
+				var result map[string]interface{}
+				if err := json.Unmarshal(raw, &result); err != nil {
+					continue
+				}
+				txLog := parseTransactionLog(result, block.Timestamp)
+				if txLog == nil {
+					continue
+				}
+				for _, watchlist := range watchlists {
+					watchlist.MatchLog(txLog)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// ChainID returns the chain ID reported by the RPC, or 0 before first contact
+func (m *ChainMonitor) ChainID() uint64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.chainID
+}
+
+// Summary returns the chain's current head state
+func (m *ChainMonitor) Summary() ChainSummary {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	summary := ChainSummary{
+		Name:           m.name,
+		ChainID:        m.chainID,
+		RPCURL:         redactRPCURL(m.client.ExecutionRPCUrl),
+		Connected:      m.lastErr == nil && len(m.blocks) > 0,
+		FinalizedBlock: m.finalized,
+	}
+	if m.lastErr != nil {
+		// HTTP client errors quote the full request URL
+		summary.LastError = strings.ReplaceAll(m.lastErr.Error(), m.client.ExecutionRPCUrl, summary.RPCURL)
+	}
+	if n := len(m.blocks); n > 0 {
+		last := m.blocks[n-1]
+		summary.LatestBlock = last.Number
+		summary.LastBlockTime = last.Timestamp
+
+		first := m.blocks[0]
+		if span := last.Timestamp - first.Timestamp; n > 1 && span > 0 {
+			txs := 0
+			for _, b := range m.blocks[1:] {
+				txs += b.TxCount
+			}
+			summary.BlockTime = float64(span) / float64(last.Number-first.Number)
+			summary.TPS = float64(txs) / float64(span)
+		}
+	}
+	return summary
+}
+
+// RecentBlocks returns up to limit blocks, newest first
+func (m *ChainMonitor) RecentBlocks(limit int) []ChainBlock {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	out := make([]ChainBlock, 0, limit)
+	for i := len(m.blocks) - 1; i >= 0 && len(out) < limit; i-- {
+		out = append(out, m.blocks[i])
+	}
+	return out
+}
+
+// primaryChainSummary builds a summary of the primary chain from the collectors
+func primaryChainSummary() ChainSummary {
+	metrics := getCurrentMetrics()
+	chainRegistry.mu.RLock()
+	name, chainID := chainRegistry.primary, chainRegistry.primaryID
+	chainRegistry.mu.RUnlock()
+
+	summary := ChainSummary{
+		Name:          name,
+		ChainID:       chainID,
+		Primary:       true,
+		RPCURL:        redactRPCURL(GetMonadClient().ExecutionRPCUrl),
+		Connected:     GetSubscriber() != nil && GetSubscriber().IsConnected(),
+		LatestBlock:   metrics.Consensus.CurrentHeight,
+		LastBlockTime: metrics.Consensus.LastBlockTime,
+		BlockTime:     metrics.Consensus.BlockTime,
+		TPS:           metrics.Execution.TPS,
+	}
+	if ht := GetHeadTracker(); ht != nil {
+		_, summary.FinalizedBlock = ht.Heads()
+	}
+	return summary
+}
+
+// withChain tags an outbound message with the primary chain unless it
+// already names one
+func withChain(msg interface{}) interface{} {
+	switch m := msg.(type) {
+	case FiredancerMessage:
+		if m.Chain == "" {
+			m.Chain = primaryChainName()
+		}
+		return m
+	case *FiredancerMessage:
+		if m.Chain == "" {
+			copied := *m
+			copied.Chain = primaryChainName()
+			return copied
+		}
+	case map[string]interface{}:
+		if _, ok := m["chain"]; !ok {
+			copied := make(map[string]interface{}, len(m)+1)
+			for k, v := range m {
+				copied[k] = v
+			}
+			copied["chain"] = primaryChainName()
+			return copied
+		}
+	}
+	return msg
+}
+
+// messageChain returns the chain an outbound message belongs to
+func messageChain(msg interface{}) string {
+	switch m := msg.(type) {
+	case FiredancerMessage:
+		return m.Chain
+	case *FiredancerMessage:
+		return m.Chain
+	case map[string]interface{}:
+		chain, _ := m["chain"].(string)
+		return chain
+	}
+	return ""
+}
+
+// ChainsResponse is the body of /api/v1/chains
+type ChainsResponse struct {
+	Primary string         `json:"primary"`
+	Chains  []ChainSummary `json:"chains"`
+}
+
+// ChainHealthResponse is the body of /api/v1/chains/:chain/health
+type ChainHealthResponse struct {
+	Status      string `json:"status"` // "ok" or "unavailable"
+	Chain       string `json:"chain"`
+	LatestBlock int64  `json:"latest_block"`
+	LastError   string `json:"last_error"`
+}
+
+// ChainBlocksResponse is the body of /api/v1/chains/:chain/blocks
+type ChainBlocksResponse struct {
+	Chain  string       `json:"chain"`
+	Blocks []ChainBlock `json:"blocks"`
+}
+
+// handleChains lists all monitored chains
+func handleChains(c *gin.Context) {
+	chains := []ChainSummary{primaryChainSummary()}
+
+	chainRegistry.mu.RLock()
+	names := make([]string, 0, len(chainRegistry.secondary))
+	for name := range chainRegistry.secondary {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		chains = append(chains, chainRegistry.secondary[name].Summary())
+	}
+	chainRegistry.mu.RUnlock()
+
+	c.JSON(http.StatusOK, ChainsResponse{
+		Primary: primaryChainName(),
+		Chains:  chains,
+	})
+}
+
+// handleChainRoute serves /api/v1/chains/:chain/*path. Requests for the
+// primary chain are forwarded to the regular /api/v1 routes; secondary
+// chains support metrics, blocks and health.
+func handleChainRoute(c *gin.Context) {
+	ref := c.Param("chain")
+	path := strings.TrimSuffix(c.Param("path"), "/")
+
+	monitor, primary, ok := lookupChain(ref)
+	if !ok {
+		c.JSON(http.StatusNotFound, APIError{Error: fmt.Sprintf("unknown chain %q", ref)})
+		return
+	}
+
+	if primary {
+		if path == "" || strings.HasPrefix(path, "/chains") {
+			c.JSON(http.StatusOK, primaryChainSummary())
+			return
+		}
+		c.Request.URL.Path = "/api/v1" + path
+		apiRouter.HandleContext(c)
+		return
+	}
+	serveSecondaryChain(c, monitor, path)
+}
+
+// serveSecondaryChain serves the metrics, health and blocks of a secondary
+// chain
+func serveSecondaryChain(c *gin.Context, monitor *ChainMonitor, path string) {
+	switch path {
+	case "", "/metrics":
+		c.JSON(http.StatusOK, monitor.Summary())
+	case "/health":
+		summary := monitor.Summary()
+		status := http.StatusOK
+		if !summary.Connected {
+			status = http.StatusServiceUnavailable
+		}
+		c.JSON(status, ChainHealthResponse{
+			Status:      map[bool]string{true: "ok", false: "unavailable"}[summary.Connected],
+			Chain:       summary.Name,
+			LatestBlock: summary.LatestBlock,
+			LastError:   summary.LastError,
+		})
+	case "/rpc-usage":
+		c.JSON(http.StatusOK, monitor.client.usage.Report(monitor.name))
+	case "/blocks":
+		limit := 20
+		if value := c.Query("limit"); value != "" {
+			if n, err := strconv.Atoi(value); err == nil && n > 0 {
+				limit = n
+			}
+		}
+		c.JSON(http.StatusOK, ChainBlocksResponse{
+			Chain:  monitor.name,
+			Blocks: monitor.RecentBlocks(limit),
+		})
+	default:
+		c.JSON(http.StatusNotFound, APIError{
+			Error: fmt.Sprintf("%s is only available for the primary chain (%s)", path, primaryChainName()),
+		})
+	}
+}
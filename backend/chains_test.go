@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestChainMonitorMatchesTenantWatchlists matches the receipts of a
+// non-primary chain's new blocks against the watchlists of its tenants only
+func TestChainMonitorMatchesTenantWatchlists(t *testing.T) {
+	const watched = "0x00000000000000000000000000000000000000aa"
+	receiptCalls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     interface{} `json:"id"`
+			Method string      `json:"method"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Method != "eth_getBlockReceipts" {
+			t.Errorf("unexpected call %s", req.Method)
+		}
+		receiptCalls++
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"jsonrpc": "2.0",
+			"id":      req.ID,
+			"result": []map[string]interface{}{{
+				"transactionHash": "0x01",
+				"status":          "0x1",
+				"logs": []map[string]interface{}{{
+					"blockNumber":     "0x5",
+					"transactionHash": "0x01",
+					"address":         watched,
+					"topics":          []string{"0xddf252ad"},
+					"data":            "0x",
+				}},
+			}},
+		})
+	}))
+	defer server.Close()
+
+	mine, other := &Tenant{ID: "acme", Chain: "acme", watchlist: NewWatchlist()}, &Tenant{ID: "beta", Chain: "beta", watchlist: NewWatchlist()}
+	for _, tenant := range []*Tenant{mine, other} {
+		if _, err := tenant.watchlist.Add(watched, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tenantsMu.Lock()
+	saved := tenants
+	tenants = map[string]*Tenant{mine.ID: mine, other.ID: other}
+	tenantsMu.Unlock()
+	defer func() {
+		tenantsMu.Lock()
+		tenants = saved
+		tenantsMu.Unlock()
+	}()
+
+	monitor := &ChainMonitor{name: "acme", client: NewMonadClient(server.URL, "", ""), private: true}
+	if err := monitor.matchWatchlists([]ChainBlock{{Number: 4}, {Number: 5, TxCount: 1, Timestamp: 1700000000}}); err != nil {
+		t.Fatal(err)
+	}
+	if receiptCalls != 1 {
+		t.Errorf("fetched receipts %d times, want once (empty blocks skipped)", receiptCalls)
+	}
+
+	entry, _ := mine.watchlist.Get(watched)
+	if entry.Hits != 1 || entry.LastBlock != 5 || entry.LastSeen == nil {
+		t.Errorf("tenant on the chain: %+v", entry)
+	}
+	if entry, _ := other.watchlist.Get(watched); entry.Hits != 0 {
+		t.Errorf("tenant on another chain matched %d times", entry.Hits)
+	}
+}
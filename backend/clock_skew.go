@@ -0,0 +1,288 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ntpEpochOffset is the seconds between the NTP (1900) and Unix epochs
+const ntpEpochOffset = 2208988800
+
+// ClockStatus is the clock skew estimate reported by /api/v1/health
+type ClockStatus struct {
+	BlockOffsetMs *int64   `json:"block_offset_ms"` // Local receipt time minus block timestamp (minimum over the window)
+	BlockSamples  int      `json:"block_samples"`
+	NTPServer     string   `json:"ntp_server,omitempty"`
+	NTPOffsetMs   *float64 `json:"ntp_offset_ms,omitempty"` // Server time minus local time
+	NTPRTTMs      *float64 `json:"ntp_rtt_ms,omitempty"`
+	NTPCheckedAt  int64    `json:"ntp_checked_at,omitempty"`
+	NTPError      string   `json:"ntp_error,omitempty"`
+	ThresholdMs   int64    `json:"threshold_ms"`
+	Significant   bool     `json:"significant"`
+	CorrectionMs  int64    `json:"correction_ms"` // Added to block timestamps in latency and freshness math
+	Reason        string   `json:"reason,omitempty"`
+}
+
+// blockOffset is one block's receipt offset
+type blockOffset struct {
+	at       time.Time
+	offsetMs int64
+}
+
+// ClockSkew estimates how far block timestamps and the local clock disagree.
+// Block timestamps have one-second resolution and include propagation delay,
+// so the minimum receipt offset over a window is used, and only offsets
+// beyond the threshold count as skew.
+type ClockSkew struct {
+	threshold  time.Duration
+	window     time.Duration
+	minSamples int
+	ntpServer  string
+
+	mu      sync.RWMutex
+	samples []blockOffset
+	ntp     *ntpResult
+	ntpErr  error
+	ntpAt   time.Time
+}
+
+// ntpResult is one SNTP measurement
+type ntpResult struct {
+	offset time.Duration
+	rtt    time.Duration
+}
+
+// Global clock skew estimator
+var (
+	clockSkew   *ClockSkew
+	clockSkewMu sync.RWMutex
+)
+
+// InitializeClockSkew reads CLOCK_SKEW_THRESHOLD (default 2s) and, when
+// NTP_SERVER is set, queries it every NTP_INTERVAL (default 10m)
+func InitializeClockSkew() *ClockSkew {
+	cs := &ClockSkew{
+		threshold:  retentionEnv("CLOCK_SKEW_THRESHOLD", 2*time.Second),
+		window:     5 * time.Minute,
+		minSamples: 10,
+		ntpServer:  os.Getenv("NTP_SERVER"),
+	}
+	if cs.threshold <= 0 {
+		cs.threshold = 2 * time.Second
+	}
+
+	clockSkewMu.Lock()
+	clockSkew = cs
+	clockSkewMu.Unlock()
+
+	if cs.ntpServer != "" {
+		interval := retentionEnv("NTP_INTERVAL", 10*time.Minute)
+		if interval <= 0 {
+			interval = 10 * time.Minute
+		}
+		go cs.pollNTP(interval)
+		log.Printf("Clock skew: checking %s every %s (threshold %s)", cs.ntpServer, interval, cs.threshold)
+	}
+	return cs
+}
+
+// GetClockSkew returns the global clock skew estimator
+func GetClockSkew() *ClockSkew {
+	clockSkewMu.RLock()
+	defer clockSkewMu.RUnlock()
+	return clockSkew
+}
+
+// OnBlock records when a block with the given timestamp (unix seconds) arrived
+func (cs *ClockSkew) OnBlock(timestamp int64, received time.Time) {
+	offset := received.Sub(time.Unix(timestamp, 0)).Milliseconds()
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	cs.samples = append(cs.samples, blockOffset{at: received, offsetMs: offset})
+	cutoff := received.Add(-cs.window)
+	drop := sort.Search(len(cs.samples), func(i int) bool { return cs.samples[i].at.After(cutoff) })
+	cs.samples = cs.samples[drop:]
+}
+
+// blockOffsetLocked is the minimum receipt offset in the window
+func (cs *ClockSkew) blockOffsetLocked() (int64, bool) {
+	if len(cs.samples) < cs.minSamples {
+		return 0, false
+	}
+	offset := cs.samples[0].offsetMs
+	for _, s := range cs.samples[1:] {
+		if s.offsetMs < offset {
+			offset = s.offsetMs
+		}
+	}
+	return offset, true
+}
+
+// correctionLocked is the shift that puts block timestamps on the local
+// clock, zero while the offset is within normal propagation delay
+func (cs *ClockSkew) correctionLocked() time.Duration {
+	offset, ok := cs.blockOffsetLocked()
+	if !ok {
+		return 0
+	}
+	d := time.Duration(offset) * time.Millisecond
+	if d > cs.threshold || d < -cs.threshold {
+		return d
+	}
+	return 0
+}
+
+// BlockTime converts a block timestamp to local clock time, correcting for
+// significant skew
+func (cs *ClockSkew) BlockTime(timestamp int64) time.Time {
+	t := time.Unix(timestamp, 0)
+	if cs == nil {
+		return t
+	}
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+	return t.Add(cs.correctionLocked())
+}
+
+// Status reports the current estimate
+func (cs *ClockSkew) Status() ClockStatus {
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+
+	status := ClockStatus{
+		BlockSamples: len(cs.samples),
+		NTPServer:    cs.ntpServer,
+		ThresholdMs:  cs.threshold.Milliseconds(),
+		CorrectionMs: cs.correctionLocked().Milliseconds(),
+	}
+	if offset, ok := cs.blockOffsetLocked(); ok {
+		status.BlockOffsetMs = &offset
+	}
+	if cs.ntp != nil {
+		offset := float64(cs.ntp.offset.Microseconds()) / 1000
+		rtt := float64(cs.ntp.rtt.Microseconds()) / 1000
+		status.NTPOffsetMs, status.NTPRTTMs = &offset, &rtt
+	}
+	if !cs.ntpAt.IsZero() {
+		status.NTPCheckedAt = cs.ntpAt.Unix()
+	}
+	if cs.ntpErr != nil {
+		status.NTPError = cs.ntpErr.Error()
+	}
+
+	threshold := float64(status.ThresholdMs)
+	switch {
+	case status.NTPOffsetMs != nil && (*status.NTPOffsetMs > threshold || *status.NTPOffsetMs < -threshold):
+		status.Significant = true
+		status.Reason = fmt.Sprintf("local clock %s %s", skewDirection(*status.NTPOffsetMs, "behind", "ahead of"), cs.ntpServer)
+	case status.CorrectionMs != 0:
+		status.Significant = true
+		status.Reason = fmt.Sprintf("block timestamps %s the local clock", skewDirection(float64(status.CorrectionMs), "behind", "ahead of"))
+	}
+	return status
+}
+
+// skewDirection formats an offset in milliseconds as "1.2s behind"
+func skewDirection(offsetMs float64, positive, negative string) string {
+	if offsetMs < 0 {
+		return fmt.Sprintf("%.1fs %s", -offsetMs/1000, negative)
+	}
+	return fmt.Sprintf("%.1fs %s", offsetMs/1000, positive)
+}
+
+// componentState reports the clock as an incident component once estimated
+func (cs *ClockSkew) componentState(states map[string]StateTransition) {
+	status := cs.Status()
+	switch {
+	case status.Significant:
+		states["clock"] = StateTransition{State: stateDegraded, Reason: status.Reason}
+	case status.BlockOffsetMs != nil || status.NTPOffsetMs != nil:
+		states["clock"] = StateTransition{State: stateConnected}
+	}
+}
+
+func (cs *ClockSkew) pollNTP(interval time.Duration) {
+	for {
+		result, err := queryNTP(cs.ntpServer, 5*time.Second)
+
+		cs.mu.Lock()
+		cs.ntpAt = time.Now()
+		cs.ntpErr = err
+		if err == nil {
+			cs.ntp = result
+		}
+		cs.mu.Unlock()
+
+		if err != nil {
+			log.Printf("NTP query to %s failed: %v", cs.ntpServer, err)
+		} else if result.offset > cs.threshold || result.offset < -cs.threshold {
+			log.Printf("⚠️  Local clock %s %s", skewDirection(float64(result.offset.Milliseconds()), "behind", "ahead of"), cs.ntpServer)
+		}
+		time.Sleep(interval)
+	}
+}
+
+// queryNTP makes one SNTP (RFC 4330) request and returns the local clock's
+// offset from the server
+func queryNTP(server string, timeout time.Duration) (*ntpResult, error) {
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "123")
+	}
+	conn, err := net.DialTimeout("udp", server, timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	request := make([]byte, 48)
+	request[0] = 0x23 // LI 0, version 4, mode 3 (client)
+	sent := time.Now()
+	putNTPTime(request[40:], sent)
+	if _, err := conn.Write(request); err != nil {
+		return nil, err
+	}
+
+	response := make([]byte, 48)
+	n, err := conn.Read(response)
+	received := time.Now()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case n < 48:
+		return nil, fmt.Errorf("short NTP response (%d bytes)", n)
+	case response[0]&0x07 != 4:
+		return nil, fmt.Errorf("unexpected NTP mode %d", response[0]&0x07)
+	case response[1] == 0:
+		return nil, fmt.Errorf("NTP kiss-of-death %q", response[12:16])
+	case !bytes.Equal(response[24:32], request[40:48]):
+		return nil, fmt.Errorf("NTP response does not match request")
+	}
+
+	serverReceive := ntpTime(response[32:])
+	serverTransmit := ntpTime(response[40:])
+	return &ntpResult{
+		offset: (serverReceive.Sub(sent) + serverTransmit.Sub(received)) / 2,
+		rtt:    received.Sub(sent) - serverTransmit.Sub(serverReceive),
+	}, nil
+}
+
+func putNTPTime(b []byte, t time.Time) {
+	binary.BigEndian.PutUint32(b, uint32(t.Unix()+ntpEpochOffset))
+	binary.BigEndian.PutUint32(b[4:], uint32((uint64(t.Nanosecond())<<32)/1e9))
+}
+
+func ntpTime(b []byte) time.Time {
+	seconds := int64(binary.BigEndian.Uint32(b)) - ntpEpochOffset
+	fraction := uint64(binary.BigEndian.Uint32(b[4:]))
+	return time.Unix(seconds, int64((fraction*1e9)>>32))
+}
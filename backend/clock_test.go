@@ -0,0 +1,72 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+var fakeClockStart = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestFakeClockAfter(t *testing.T) {
+	clock := NewFakeClock(fakeClockStart)
+	fired := clock.After(5 * time.Second)
+
+	clock.Advance(4 * time.Second)
+	select {
+	case <-fired:
+		t.Fatal("After fired before its deadline")
+	default:
+	}
+
+	clock.Advance(time.Second)
+	select {
+	case at := <-fired:
+		if want := fakeClockStart.Add(5 * time.Second); !at.Equal(want) {
+			t.Fatalf("After fired at %v, want %v", at, want)
+		}
+	default:
+		t.Fatal("After did not fire at its deadline")
+	}
+	if got := clock.Since(fakeClockStart); got != 5*time.Second {
+		t.Fatalf("Since = %v, want 5s", got)
+	}
+}
+
+func TestFakeClockTicker(t *testing.T) {
+	clock := NewFakeClock(fakeClockStart)
+	ticker := clock.NewTicker(time.Second)
+
+	clock.Advance(time.Second)
+	if at := <-ticker.C(); !at.Equal(fakeClockStart.Add(time.Second)) {
+		t.Fatalf("first tick at %v", at)
+	}
+
+	// Like time.Ticker, ticks the reader missed are dropped
+	clock.Advance(3 * time.Second)
+	<-ticker.C()
+	select {
+	case <-ticker.C():
+		t.Fatal("missed ticks were queued")
+	default:
+	}
+
+	// The next deadline stays on the ticker's period
+	clock.Advance(500 * time.Millisecond)
+	select {
+	case <-ticker.C():
+		t.Fatal("ticked between periods")
+	default:
+	}
+	clock.Advance(500 * time.Millisecond)
+	if at := <-ticker.C(); !at.Equal(fakeClockStart.Add(5 * time.Second)) {
+		t.Fatalf("tick at %v, want 5s after start", at)
+	}
+
+	ticker.Stop()
+	clock.Advance(time.Minute)
+	select {
+	case <-ticker.C():
+		t.Fatal("stopped ticker ticked")
+	default:
+	}
+}
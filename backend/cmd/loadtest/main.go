@@ -0,0 +1,237 @@
+// Command loadtest spawns simulated WebSocket clients against the dashboard
+// backend and reports broadcast latency percentiles and drop rates.
+//
+//	go run ./cmd/loadtest -url ws://127.0.0.1:4000/websocket -clients 1000 -duration 60s
+//
+// Latency is measured on broadcast messages (those carrying seq/server_ts),
+// so run it on the same host as the backend or with synchronized clocks.
+// A gap in a client's seq numbers counts as dropped messages.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"os/signal"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// streamFrame holds the fields the load test reads from either protocol
+type streamFrame struct {
+	Topic    string `json:"topic"`
+	Key      string `json:"key"`
+	Type     string `json:"type"` // Native protocol
+	Seq      uint64 `json:"seq"`
+	ServerTS int64  `json:"server_ts"`
+	TS       int64  `json:"ts"` // Native protocol
+}
+
+// clientStats are one simulated client's counters
+type clientStats struct {
+	messages   uint64
+	broadcasts uint64
+	dropped    uint64
+	bytes      uint64
+	latencies  []time.Duration
+	connected  bool
+	disconnect error
+	lastSeq    uint64
+}
+
+// stats aggregates all clients
+type stats struct {
+	mu          sync.Mutex
+	clients     []*clientStats
+	connectFail atomic.Uint64
+	connectTime []time.Duration
+}
+
+func main() {
+	target := flag.String("url", "ws://127.0.0.1:4000/websocket", "WebSocket URL (/websocket or /ws/native)")
+	clients := flag.Int("clients", 100, "number of simulated clients")
+	duration := flag.Duration("duration", 30*time.Second, "test duration after ramp-up")
+	ramp := flag.Duration("ramp", 5*time.Second, "time over which clients connect")
+	token := flag.String("token", "", "session token (when WS_AUTH_SECRET is set)")
+	flag.Parse()
+
+	u, err := url.Parse(*target)
+	if err != nil {
+		log.Fatalf("invalid -url: %v", err)
+	}
+	if *token != "" {
+		q := u.Query()
+		q.Set("token", *token)
+		u.RawQuery = q.Encode()
+	}
+
+	log.Printf("Connecting %d clients to %s over %s, then running for %s", *clients, u.Redacted(), *ramp, *duration)
+
+	s := &stats{clients: make([]*clientStats, *clients)}
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+
+	interval := time.Duration(0)
+	if *clients > 1 {
+		interval = *ramp / time.Duration(*clients)
+	}
+
+	start := time.Now()
+	for i := 0; i < *clients; i++ {
+		cs := &clientStats{}
+		s.clients[i] = cs
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			runClient(u.String(), cs, s, stop)
+		}()
+		time.Sleep(interval)
+	}
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	select {
+	case <-time.After(*duration):
+	case <-interrupt:
+		log.Printf("Interrupted, stopping early")
+	}
+	close(stop)
+	wg.Wait()
+
+	report(s, time.Since(start))
+}
+
+// runClient connects one client and reads until stop
+func runClient(target string, cs *clientStats, s *stats, stop <-chan struct{}) {
+	dialStart := time.Now()
+	conn, _, err := websocket.DefaultDialer.Dial(target, nil)
+	if err != nil {
+		s.connectFail.Add(1)
+		return
+	}
+	defer conn.Close()
+
+	s.mu.Lock()
+	s.connectTime = append(s.connectTime, time.Since(dialStart))
+	cs.connected = true
+	s.mu.Unlock()
+
+	go func() {
+		<-stop
+		conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
+			time.Now().Add(time.Second))
+		conn.Close()
+	}()
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			select {
+			case <-stop:
+			default:
+				s.mu.Lock()
+				cs.disconnect = err
+				s.mu.Unlock()
+			}
+			return
+		}
+		received := time.Now()
+
+		var frame streamFrame
+		if json.Unmarshal(data, &frame) != nil {
+			continue
+		}
+
+		s.mu.Lock()
+		cs.messages++
+		cs.bytes += uint64(len(data))
+		if frame.Seq > 0 {
+			cs.broadcasts++
+			if cs.lastSeq > 0 && frame.Seq > cs.lastSeq+1 {
+				cs.dropped += frame.Seq - cs.lastSeq - 1
+			}
+			if frame.Seq > cs.lastSeq {
+				cs.lastSeq = frame.Seq
+			}
+
+			sentAt := frame.ServerTS
+			if sentAt == 0 {
+				sentAt = frame.TS
+			}
+			if sentAt > 0 {
+				cs.latencies = append(cs.latencies, received.Sub(time.UnixMilli(sentAt)))
+			}
+		}
+		s.mu.Unlock()
+	}
+}
+
+// percentile returns the p-th percentile of sorted durations
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	index := int(float64(len(sorted)-1) * p)
+	return sorted[index]
+}
+
+// report prints the aggregated results
+func report(s *stats, elapsed time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var connected, disconnected int
+	var messages, broadcasts, dropped, bytes uint64
+	var latencies []time.Duration
+	for _, cs := range s.clients {
+		if cs == nil {
+			continue
+		}
+		if cs.connected {
+			connected++
+		}
+		if cs.disconnect != nil {
+			disconnected++
+		}
+		messages += cs.messages
+		broadcasts += cs.broadcasts
+		dropped += cs.dropped
+		bytes += cs.bytes
+		latencies = append(latencies, cs.latencies...)
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	sort.Slice(s.connectTime, func(i, j int) bool { return s.connectTime[i] < s.connectTime[j] })
+
+	dropRate := 0.0
+	if broadcasts+dropped > 0 {
+		dropRate = float64(dropped) / float64(broadcasts+dropped) * 100
+	}
+
+	fmt.Println()
+	fmt.Println("=== WebSocket load test report ===")
+	fmt.Printf("Elapsed:             %s\n", elapsed.Round(time.Millisecond))
+	fmt.Printf("Clients:             %d connected, %d failed to connect, %d disconnected early\n",
+		connected, s.connectFail.Load(), disconnected)
+	fmt.Printf("Connect time:        p50 %s  p99 %s\n",
+		percentile(s.connectTime, 0.50).Round(time.Microsecond), percentile(s.connectTime, 0.99).Round(time.Microsecond))
+	fmt.Printf("Messages:            %d total (%.0f/s), %.1f MB\n",
+		messages, float64(messages)/elapsed.Seconds(), float64(bytes)/1e6)
+	fmt.Printf("Broadcasts:          %d received, %d dropped (%.3f%%)\n", broadcasts, dropped, dropRate)
+	if len(latencies) > 0 {
+		fmt.Printf("Broadcast latency:   p50 %s  p90 %s  p99 %s  max %s\n",
+			percentile(latencies, 0.50).Round(time.Microsecond),
+			percentile(latencies, 0.90).Round(time.Microsecond),
+			percentile(latencies, 0.99).Round(time.Microsecond),
+			latencies[len(latencies)-1].Round(time.Microsecond))
+	} else {
+		fmt.Println("Broadcast latency:   no broadcast messages received (is the node producing blocks?)")
+	}
+}
@@ -0,0 +1,44 @@
+// Command mocknode runs a fake Monad node for local dashboard development.
+//
+//	go run ./cmd/mocknode
+//	go run . # in another terminal; the dashboard connects to the default ports
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"monad-dashboard/mocknode"
+)
+
+func main() {
+	cfg := mocknode.DefaultConfig()
+	flag.StringVar(&cfg.RPCAddr, "rpc", cfg.RPCAddr, "JSON-RPC listen address")
+	flag.StringVar(&cfg.WSAddr, "ws", cfg.WSAddr, "WebSocket listen address")
+	flag.StringVar(&cfg.PrometheusAddr, "prometheus", cfg.PrometheusAddr, "Prometheus /metrics listen address")
+	flag.DurationVar(&cfg.BlockTime, "block-time", cfg.BlockTime, "interval between blocks")
+	flag.IntVar(&cfg.MaxTxsPerBlock, "max-txs", cfg.MaxTxsPerBlock, "maximum transactions per block")
+	flag.IntVar(&cfg.Validators, "validators", cfg.Validators, "number of round-robin proposers")
+	flag.IntVar(&cfg.HistoryBlocks, "history", cfg.HistoryBlocks, "blocks to pre-generate with backdated timestamps")
+	flag.Uint64Var(&cfg.ChainID, "chain-id", cfg.ChainID, "chain ID reported by eth_chainId")
+	flag.Int64Var(&cfg.Seed, "seed", cfg.Seed, "random seed for the generated chain")
+	flag.DurationVar(&cfg.ClockOffset, "clock-offset", cfg.ClockOffset, "offset added to block timestamps (simulates node clock skew)")
+	flag.StringVar(&cfg.DropKind, "drop-sub", cfg.DropKind, "subscription kind to silently drop every -drop-every (e.g. monadLogs)")
+	flag.DurationVar(&cfg.DropEvery, "drop-every", cfg.DropEvery, "interval between -drop-sub drops")
+	flag.Parse()
+
+	node := mocknode.New(cfg)
+	if err := node.Start(); err != nil {
+		log.Fatalf("Failed to start mock node: %v", err)
+	}
+	log.Printf("🧪 Mock Monad node: rpc=%s ws=%s prometheus=%s block-time=%s",
+		cfg.RPCAddr, cfg.WSAddr, cfg.PrometheusAddr, cfg.BlockTime)
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	<-sig
+	node.Close()
+}
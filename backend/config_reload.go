@@ -0,0 +1,265 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Runtime reload of the rule-like configuration files: log tail rules
+// (LOG_TAIL_CONFIG), alert notifiers (NOTIFIER_CONFIG), watched addresses
+// (WATCHLIST_CONFIG) and metric mappings (METRIC_MAPPINGS_CONFIG). Each
+// reload parses and validates the whole file before swapping it in, so a
+// bad edit leaves the running configuration untouched; stream clients stay
+// connected throughout. Files are reloaded from POST /api/v1/admin/reload
+// and, unless CONFIG_WATCH_INTERVAL is 0, whenever they change on disk.
+
+// reloadableConfig is one configuration file that can be swapped at runtime
+type reloadableConfig struct {
+	name   string
+	env    string       // Variable naming the file
+	reload func() error // Parse, validate and swap; only called when the file is set
+}
+
+// reloadableConfigs lists every reloadable file
+var reloadableConfigs = []reloadableConfig{
+	{name: "log_rules", env: "LOG_TAIL_CONFIG", reload: func() error {
+		e := GetLogRuleEngine()
+		if e == nil {
+			return fmt.Errorf("log tailing did not start; fix LOG_TAIL_CONFIG and restart")
+		}
+		return e.Reload()
+	}},
+	{name: "notifiers", env: "NOTIFIER_CONFIG", reload: ReloadNotifiers},
+	{name: "watchlist", env: "WATCHLIST_CONFIG", reload: func() error {
+		w := GetWatchlist()
+		if w == nil {
+			return fmt.Errorf("watchlist not initialized")
+		}
+		added, removed, err := w.ReloadConfig(os.Getenv("WATCHLIST_CONFIG"))
+		if err == nil {
+			log.Printf("Watchlist config: %d added, %d removed", added, removed)
+		}
+		return err
+	}},
+	{name: "metric_mappings", env: "METRIC_MAPPINGS_CONFIG", reload: func() error {
+		return ReloadMetricMappings(os.Getenv("METRIC_MAPPINGS_CONFIG"))
+	}},
+}
+
+// ConfigReloadStatus is one file's latest reload
+type ConfigReloadStatus struct {
+	Config     string     `json:"config"`
+	Env        string     `json:"env"`
+	Path       string     `json:"path,omitempty"` // Empty when not configured
+	Reloads    int64      `json:"reloads"`
+	Failures   int64      `json:"failures"`
+	LastReload *time.Time `json:"last_reload,omitempty"`
+	LastError  string     `json:"last_error,omitempty"` // Set while the file on disk is rejected
+}
+
+// configFileState is a watched file's last seen modification
+type configFileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// ConfigReloader reloads configuration files on request or on change
+type ConfigReloader struct {
+	mu       sync.Mutex
+	statuses map[string]*ConfigReloadStatus
+	seen     map[string]configFileState
+}
+
+// Global config reloader
+var (
+	configReloader   *ConfigReloader
+	configReloaderMu sync.RWMutex
+)
+
+// NewConfigReloader creates a reloader for reloadableConfigs
+func NewConfigReloader() *ConfigReloader {
+	r := &ConfigReloader{statuses: make(map[string]*ConfigReloadStatus), seen: make(map[string]configFileState)}
+	for _, config := range reloadableConfigs {
+		r.statuses[config.name] = &ConfigReloadStatus{Config: config.name, Env: config.env}
+		if path := os.Getenv(config.env); path != "" {
+			r.seen[config.name] = statConfigFile(path)
+		}
+	}
+	return r
+}
+
+// InitializeConfigReloader loads METRIC_MAPPINGS_CONFIG, which has no other
+// loader, and watches every configured file every CONFIG_WATCH_INTERVAL
+// (default 5s; 0 disables watching)
+func InitializeConfigReloader() *ConfigReloader {
+	r := NewConfigReloader()
+	if path := os.Getenv("METRIC_MAPPINGS_CONFIG"); path != "" {
+		if err := ReloadMetricMappings(path); err != nil {
+			log.Printf("Invalid metric mappings %s: %v", path, err)
+		}
+	}
+
+	configReloaderMu.Lock()
+	configReloader = r
+	configReloaderMu.Unlock()
+
+	interval := 5 * time.Second
+	if value := os.Getenv("CONFIG_WATCH_INTERVAL"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed < 0 {
+			log.Printf("Invalid CONFIG_WATCH_INTERVAL %q, using %s", value, interval)
+		} else {
+			interval = parsed
+		}
+	}
+	if interval > 0 {
+		go r.watch(interval)
+	}
+	return r
+}
+
+// GetConfigReloader returns the global config reloader
+func GetConfigReloader() *ConfigReloader {
+	configReloaderMu.RLock()
+	defer configReloaderMu.RUnlock()
+	return configReloader
+}
+
+// statConfigFile returns a file's modification time and size, zero when
+// it cannot be read
+func statConfigFile(path string) configFileState {
+	info, err := os.Stat(path)
+	if err != nil {
+		return configFileState{}
+	}
+	return configFileState{modTime: info.ModTime(), size: info.Size()}
+}
+
+// Reload reloads the named configs, or every configured one when names is
+// empty, and reports each one's status
+func (r *ConfigReloader) Reload(names []string) ([]ConfigReloadStatus, error) {
+	selected := make(map[string]bool, len(names))
+	for _, name := range names {
+		if _, ok := r.statuses[name]; !ok {
+			return nil, fmt.Errorf("unknown config %q", name)
+		}
+		selected[name] = true
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	results := make([]ConfigReloadStatus, 0, len(reloadableConfigs))
+	for _, config := range reloadableConfigs {
+		if len(selected) > 0 && !selected[config.name] {
+			continue
+		}
+		results = append(results, r.reloadLocked(config))
+	}
+	return results, nil
+}
+
+// reloadLocked reloads one config if its file is set; caller holds r.mu
+func (r *ConfigReloader) reloadLocked(config reloadableConfig) ConfigReloadStatus {
+	status := r.statuses[config.name]
+	status.Path = os.Getenv(config.env)
+	if status.Path == "" {
+		return *status
+	}
+
+	r.seen[config.name] = statConfigFile(status.Path)
+	now := time.Now().UTC()
+	status.LastReload = &now
+	if err := config.reload(); err != nil {
+		status.Failures++
+		status.LastError = err.Error()
+		log.Printf("Config %s not reloaded from %s: %v", config.name, status.Path, err)
+	} else {
+		status.Reloads++
+		status.LastError = ""
+		log.Printf("Config %s reloaded from %s", config.name, status.Path)
+	}
+	return *status
+}
+
+// Statuses reports every reloadable config
+func (r *ConfigReloader) Statuses() []ConfigReloadStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	statuses := make([]ConfigReloadStatus, 0, len(reloadableConfigs))
+	for _, config := range reloadableConfigs {
+		status := *r.statuses[config.name]
+		status.Path = os.Getenv(config.env)
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// watch reloads configured files whose modification time or size changed
+func (r *ConfigReloader) watch(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-shutdownContext().Done():
+			return
+		case <-ticker.C:
+		}
+
+		r.mu.Lock()
+		for _, config := range reloadableConfigs {
+			path := os.Getenv(config.env)
+			if path == "" {
+				continue
+			}
+			// Skip files that are missing or mid-write (empty)
+			if state := statConfigFile(path); state.size > 0 && state != r.seen[config.name] {
+				r.reloadLocked(config)
+			}
+		}
+		r.mu.Unlock()
+	}
+}
+
+// ConfigReloadResponse is the body of the reload endpoints
+type ConfigReloadResponse struct {
+	Configs []ConfigReloadStatus `json:"configs"`
+}
+
+// handleConfigReloadStatus reports every reloadable config and its last reload
+func handleConfigReloadStatus(c *gin.Context) {
+	r := GetConfigReloader()
+	if r == nil {
+		c.JSON(http.StatusServiceUnavailable, APIError{Error: "Config reloader not initialized"})
+		return
+	}
+	c.JSON(http.StatusOK, ConfigReloadResponse{Configs: r.Statuses()})
+}
+
+// handleConfigReload reloads ?config=name (repeatable), or every configured
+// file. Responds 422 when any file was rejected; those keep running with
+// their previous contents.
+func handleConfigReload(c *gin.Context) {
+	r := GetConfigReloader()
+	if r == nil {
+		c.JSON(http.StatusServiceUnavailable, APIError{Error: "Config reloader not initialized"})
+		return
+	}
+	results, err := r.Reload(c.QueryArray("config"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, APIError{Error: err.Error()})
+		return
+	}
+	status := http.StatusOK
+	for _, result := range results {
+		if result.LastError != "" {
+			status = http.StatusUnprocessableEntity
+		}
+	}
+	c.JSON(status, ConfigReloadResponse{Configs: results})
+}
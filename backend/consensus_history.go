@@ -0,0 +1,516 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// The consensus tracker keeps only its last few blocks. Blocks it evicts
+// are archived here with their full proposed/voted/finalized timeline and
+// appended to hourly segment files under <HISTORY_DIR>/consensus, so
+// finality behavior can be explored over hours or days.
+
+const (
+	// consensusHistoryRecent is how many archived blocks stay in memory;
+	// older ranges are read from the segment files
+	consensusHistoryRecent = 10000
+
+	// consensusHistorySegment is the span of one segment file
+	consensusHistorySegment = time.Hour
+
+	// maxConsensusHistoryRange caps the blocks one query summarizes
+	maxConsensusHistoryRange = 500000
+
+	// maxConsensusHistoryLimit caps the blocks one query lists
+	maxConsensusHistoryLimit = 10000
+)
+
+// consensusSegment is one segment file and the blocks it holds
+type consensusSegment struct {
+	path        string
+	start       time.Time
+	first, last uint64
+}
+
+// ConsensusHistory archives consensus timelines of blocks the tracker no
+// longer holds
+type ConsensusHistory struct {
+	dir       string // Segment directory, empty when not persisted
+	retention time.Duration
+
+	mu       sync.RWMutex
+	recent   []BlockConsensusState // Newest archived blocks, ascending
+	pending  []BlockConsensusState // Not yet written to disk
+	segments []*consensusSegment   // Oldest first
+}
+
+// Global consensus history
+var (
+	consensusHistory   *ConsensusHistory
+	consensusHistoryMu sync.RWMutex
+)
+
+// NewConsensusHistory creates an archive persisted under dir (empty keeps
+// only the recent blocks in memory) for retention
+func NewConsensusHistory(dir string, retention time.Duration) *ConsensusHistory {
+	return &ConsensusHistory{dir: dir, retention: retention}
+}
+
+// InitializeConsensusHistory creates the global consensus history under the
+// history directory (CONSENSUS_HISTORY_RETENTION, default 7d), indexes the
+// persisted segments and starts flushing
+func InitializeConsensusHistory(historyDir string) *ConsensusHistory {
+	dir := ""
+	if historyDir != "" {
+		dir = filepath.Join(historyDir, "consensus")
+	}
+	ch := NewConsensusHistory(dir, retentionEnv("CONSENSUS_HISTORY_RETENTION", 7*24*time.Hour))
+	if dir != "" {
+		if err := ch.load(time.Now()); err != nil {
+			log.Printf("Consensus history %s not loaded: %v", dir, err)
+		}
+	}
+
+	consensusHistoryMu.Lock()
+	consensusHistory = ch
+	consensusHistoryMu.Unlock()
+
+	go ch.run(10 * time.Second)
+	return ch
+}
+
+// GetConsensusHistory returns the global consensus history
+func GetConsensusHistory() *ConsensusHistory {
+	consensusHistoryMu.RLock()
+	defer consensusHistoryMu.RUnlock()
+	return consensusHistory
+}
+
+// Archive records blocks evicted from the consensus tracker, ascending
+func (ch *ConsensusHistory) Archive(blocks []BlockConsensusState) {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+
+	for _, block := range blocks {
+		if n := len(ch.recent); n > 0 && block.BlockNumber <= ch.recent[n-1].BlockNumber {
+			continue // Already archived (tracker restarted below a previous head)
+		}
+		ch.recent = append(ch.recent, block)
+		if ch.dir != "" {
+			ch.pending = append(ch.pending, block)
+		}
+	}
+	if len(ch.recent) > consensusHistoryRecent {
+		ch.recent = append(ch.recent[:0:0], ch.recent[len(ch.recent)-consensusHistoryRecent:]...)
+	}
+}
+
+// run flushes pending blocks and removes expired segments every interval
+// until shutdown
+func (ch *ConsensusHistory) run(interval time.Duration) {
+	if ch.dir == "" {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-shutdownContext().Done():
+			return
+		case now := <-ticker.C:
+			if err := ch.Flush(); err != nil {
+				log.Printf("Failed to write consensus history: %v", err)
+			}
+			if err := ch.removeExpired(now); err != nil {
+				log.Printf("Failed to remove expired consensus history: %v", err)
+			}
+		}
+	}
+}
+
+// segmentPath names the segment file starting at start
+func (ch *ConsensusHistory) segmentPath(start time.Time) string {
+	return filepath.Join(ch.dir, start.Format("20060102T1504")+".jsonl")
+}
+
+// segmentFor returns the indexed segment starting at start, adding it when
+// new; caller holds ch.mu
+func (ch *ConsensusHistory) segmentFor(start time.Time) *consensusSegment {
+	for i := len(ch.segments) - 1; i >= 0; i-- {
+		if ch.segments[i].start.Equal(start) {
+			return ch.segments[i]
+		}
+	}
+	seg := &consensusSegment{path: ch.segmentPath(start), start: start}
+	ch.segments = append(ch.segments, seg)
+	sort.Slice(ch.segments, func(i, j int) bool { return ch.segments[i].start.Before(ch.segments[j].start) })
+	return seg
+}
+
+// Flush appends pending blocks to the segment of their proposal time
+func (ch *ConsensusHistory) Flush() error {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	if ch.dir == "" || len(ch.pending) == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(ch.dir, 0o755); err != nil {
+		return err
+	}
+
+	bySegment := make(map[time.Time][]BlockConsensusState)
+	for _, block := range ch.pending {
+		start := block.ProposedAt.UTC().Truncate(consensusHistorySegment)
+		bySegment[start] = append(bySegment[start], block)
+	}
+	for start, blocks := range bySegment {
+		seg := ch.segmentFor(start)
+		if err := appendConsensusBlocks(seg.path, blocks); err != nil {
+			return err
+		}
+		for _, block := range blocks {
+			seg.extend(block.BlockNumber)
+		}
+	}
+	ch.pending = nil
+	return nil
+}
+
+// extend widens the segment's block range to include n
+func (seg *consensusSegment) extend(n uint64) {
+	if seg.first == 0 || n < seg.first {
+		seg.first = n
+	}
+	if n > seg.last {
+		seg.last = n
+	}
+}
+
+// appendConsensusBlocks appends blocks to a segment file, one JSON line each
+func appendConsensusBlocks(path string, blocks []BlockConsensusState) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, block := range blocks {
+		if err := enc.Encode(block); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// readConsensusSegment calls fn for each block in a segment file
+func readConsensusSegment(path string, fn func(BlockConsensusState)) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var block BlockConsensusState
+		if json.Unmarshal(scanner.Bytes(), &block) != nil || block.BlockNumber == 0 {
+			continue
+		}
+		fn(block)
+	}
+	return scanner.Err()
+}
+
+// load indexes the unexpired segment files and restores the newest blocks
+// into memory
+func (ch *ConsensusHistory) load(now time.Time) error {
+	entries, err := os.ReadDir(ch.dir)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	cutoff := now.Add(-ch.retention)
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	for _, entry := range entries {
+		name := entry.Name()
+		start, err := time.Parse("20060102T1504", strings.TrimSuffix(name, ".jsonl"))
+		if err != nil || entry.IsDir() || !strings.HasSuffix(name, ".jsonl") {
+			continue
+		}
+		seg := &consensusSegment{path: filepath.Join(ch.dir, name), start: start}
+		if start.Add(consensusHistorySegment).Before(cutoff) {
+			ch.segments = append(ch.segments, seg) // Removed by the next flush
+			continue
+		}
+		var blocks []BlockConsensusState
+		if err := readConsensusSegment(seg.path, func(block BlockConsensusState) {
+			seg.extend(block.BlockNumber)
+			blocks = append(blocks, block)
+		}); err != nil {
+			return err
+		}
+		ch.segments = append(ch.segments, seg)
+		// Directory entries come sorted by name, so chronologically
+		ch.recent = append(ch.recent, blocks...)
+		if len(ch.recent) > 2*consensusHistoryRecent {
+			ch.recent = append(ch.recent[:0:0], ch.recent[len(ch.recent)-consensusHistoryRecent:]...)
+		}
+	}
+	sort.Slice(ch.segments, func(i, j int) bool { return ch.segments[i].start.Before(ch.segments[j].start) })
+
+	sort.Slice(ch.recent, func(i, j int) bool { return ch.recent[i].BlockNumber < ch.recent[j].BlockNumber })
+	if len(ch.recent) > consensusHistoryRecent {
+		ch.recent = append(ch.recent[:0:0], ch.recent[len(ch.recent)-consensusHistoryRecent:]...)
+	}
+	log.Printf("Consensus history: %d segments, %d recent blocks from %s", len(ch.segments), len(ch.recent), ch.dir)
+	return nil
+}
+
+// removeExpired deletes segment files whose whole span is past retention
+func (ch *ConsensusHistory) removeExpired(now time.Time) error {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+
+	cutoff := now.Add(-ch.retention)
+	kept := ch.segments[:0]
+	var err error
+	for _, seg := range ch.segments {
+		if seg.start.Add(consensusHistorySegment).Before(cutoff) && err == nil {
+			if err = os.Remove(seg.path); err == nil || os.IsNotExist(err) {
+				err = nil
+				continue
+			}
+		}
+		kept = append(kept, seg)
+	}
+	ch.segments = kept
+	return err
+}
+
+// Range returns the archived blocks numbered within [from, to], ascending:
+// from memory when the range is recent enough, otherwise from the segment
+// files holding it
+func (ch *ConsensusHistory) Range(from, to uint64) ([]BlockConsensusState, error) {
+	ch.mu.RLock()
+	inMemory := len(ch.recent) > 0 && from >= ch.recent[0].BlockNumber
+	if inMemory || ch.dir == "" {
+		start := sort.Search(len(ch.recent), func(i int) bool { return ch.recent[i].BlockNumber >= from })
+		end := sort.Search(len(ch.recent), func(i int) bool { return ch.recent[i].BlockNumber > to })
+		blocks := append([]BlockConsensusState(nil), ch.recent[start:end]...)
+		ch.mu.RUnlock()
+		return blocks, nil
+	}
+
+	var paths []string
+	for _, seg := range ch.segments {
+		if seg.last >= from && seg.first <= to {
+			paths = append(paths, seg.path)
+		}
+	}
+	pending := append([]BlockConsensusState(nil), ch.pending...)
+	ch.mu.RUnlock()
+
+	byNumber := make(map[uint64]BlockConsensusState)
+	for _, path := range paths {
+		err := readConsensusSegment(path, func(block BlockConsensusState) {
+			if block.BlockNumber >= from && block.BlockNumber <= to {
+				byNumber[block.BlockNumber] = block
+			}
+		})
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+	for _, block := range pending {
+		if block.BlockNumber >= from && block.BlockNumber <= to {
+			byNumber[block.BlockNumber] = block
+		}
+	}
+	return sortedConsensusBlocks(byNumber), nil
+}
+
+// sortedConsensusBlocks lists blocks by ascending number
+func sortedConsensusBlocks(byNumber map[uint64]BlockConsensusState) []BlockConsensusState {
+	blocks := make([]BlockConsensusState, 0, len(byNumber))
+	for _, block := range byNumber {
+		blocks = append(blocks, block)
+	}
+	sort.Slice(blocks, func(i, j int) bool { return blocks[i].BlockNumber < blocks[j].BlockNumber })
+	return blocks
+}
+
+// ConsensusHistorySummary aggregates a range's phase timings
+type ConsensusHistorySummary struct {
+	Blocks        int     `json:"blocks"`          // Blocks with a recorded timeline
+	Missing       uint64  `json:"missing"`         // Blocks in the range without one
+	Finalized     int     `json:"finalized"`       // Reached finalized
+	NotFinalized  int     `json:"not_finalized"`   // Evicted or still live before finalizing
+	AvgVoteMs     float64 `json:"avg_vote_ms"`     // Proposed to voted
+	AvgFinalizeMs float64 `json:"avg_finalize_ms"` // Proposed to finalized
+	P50FinalizeMs float64 `json:"p50_finalize_ms"`
+	P99FinalizeMs float64 `json:"p99_finalize_ms"`
+	MaxFinalizeMs float64 `json:"max_finalize_ms"`
+	SlowestBlock  uint64  `json:"slowest_block,omitempty"` // Longest proposed to finalized
+}
+
+// ConsensusHistoryResponse is the body of /api/v1/consensus/history
+type ConsensusHistoryResponse struct {
+	FromBlock      uint64                  `json:"from_block"`
+	ToBlock        uint64                  `json:"to_block"`
+	FinalityDepth  int64                   `json:"finality_depth"`
+	RetentionHours float64                 `json:"retention_hours"`
+	Persisted      bool                    `json:"persisted"` // False when only the recent blocks are kept in memory
+	Summary        ConsensusHistorySummary `json:"summary"`
+	Blocks         []BlockConsensusState   `json:"blocks"`    // Ascending, at most limit
+	Truncated      bool                    `json:"truncated"` // More blocks matched than listed
+}
+
+// summarizeConsensusBlocks computes phase timings over blocks
+func summarizeConsensusBlocks(blocks []BlockConsensusState, from, to uint64) ConsensusHistorySummary {
+	summary := ConsensusHistorySummary{Blocks: len(blocks), Missing: to - from + 1 - uint64(len(blocks))}
+	var voteTotal float64
+	var votes int
+	finalize := make([]float64, 0, len(blocks))
+	for _, block := range blocks {
+		if block.VotedAt != nil {
+			voteTotal += float64(block.VotedAt.Sub(block.ProposedAt).Microseconds()) / 1000
+			votes++
+		}
+		if block.FinalizedAt == nil {
+			summary.NotFinalized++
+			continue
+		}
+		summary.Finalized++
+		ms := float64(block.FinalizedAt.Sub(block.ProposedAt).Microseconds()) / 1000
+		if ms > summary.MaxFinalizeMs || summary.SlowestBlock == 0 {
+			summary.MaxFinalizeMs = ms
+			summary.SlowestBlock = block.BlockNumber
+		}
+		finalize = append(finalize, ms)
+	}
+	if votes > 0 {
+		summary.AvgVoteMs = voteTotal / float64(votes)
+	}
+	if n := len(finalize); n > 0 {
+		total := 0.0
+		for _, ms := range finalize {
+			total += ms
+		}
+		sort.Float64s(finalize)
+		summary.AvgFinalizeMs = total / float64(n)
+		summary.P50FinalizeMs = finalize[n*50/100]
+		summary.P99FinalizeMs = finalize[n*99/100]
+	}
+	return summary
+}
+
+// parseBlockParam reads an optional block number query parameter
+func parseBlockParam(c *gin.Context, name string, def uint64) (uint64, error) {
+	raw := c.Query(name)
+	if raw == "" {
+		return def, nil
+	}
+	n, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be a block number", name)
+	}
+	return n, nil
+}
+
+// handleConsensusHistory serves archived consensus timelines for
+// from_block..to_block (default: the last 1000 blocks), combined with the
+// blocks the tracker still holds
+func handleConsensusHistory(c *gin.Context) {
+	ch := GetConsensusHistory()
+	if ch == nil {
+		c.JSON(http.StatusServiceUnavailable, APIError{Error: "Consensus history not initialized"})
+		return
+	}
+
+	live := GetConsensusTracker().GetRecentBlocks(consensusHistoryRecent)
+	var head uint64
+	if len(live) > 0 {
+		head = live[0].BlockNumber
+	}
+
+	to, err := parseBlockParam(c, "to_block", head)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, APIError{Error: err.Error()})
+		return
+	}
+	defaultFrom := uint64(1)
+	if to > 1000 {
+		defaultFrom = to - 999
+	}
+	from, err := parseBlockParam(c, "from_block", defaultFrom)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, APIError{Error: err.Error()})
+		return
+	}
+	if from == 0 {
+		from = 1
+	}
+	if from > to {
+		c.JSON(http.StatusBadRequest, APIError{Error: "from_block must not be after to_block"})
+		return
+	}
+	if to-from+1 > maxConsensusHistoryRange {
+		c.JSON(http.StatusBadRequest, APIError{Error: fmt.Sprintf("at most %d blocks per query", maxConsensusHistoryRange)})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "1000"))
+	if err != nil || limit < 0 || limit > maxConsensusHistoryLimit {
+		c.JSON(http.StatusBadRequest, APIError{Error: fmt.Sprintf("limit must be between 0 and %d", maxConsensusHistoryLimit)})
+		return
+	}
+
+	archived, err := ch.Range(from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, APIError{Error: err.Error()})
+		return
+	}
+	byNumber := make(map[uint64]BlockConsensusState, len(archived)+len(live))
+	for _, block := range archived {
+		byNumber[block.BlockNumber] = block
+	}
+	for _, block := range live {
+		if block.BlockNumber >= from && block.BlockNumber <= to {
+			byNumber[block.BlockNumber] = block
+		}
+	}
+	blocks := sortedConsensusBlocks(byNumber)
+
+	response := ConsensusHistoryResponse{
+		FromBlock:      from,
+		ToBlock:        to,
+		FinalityDepth:  GetChainParams().FinalityDepth,
+		RetentionHours: ch.retention.Hours(),
+		Persisted:      ch.dir != "",
+		Summary:        summarizeConsensusBlocks(blocks, from, to),
+		Blocks:         blocks,
+	}
+	if len(blocks) > limit {
+		response.Blocks = blocks[:limit]
+		response.Truncated = true
+	}
+	c.JSON(http.StatusOK, response)
+}
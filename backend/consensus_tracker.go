@@ -1,6 +1,8 @@
 package main
 
 import (
+	"sort"
+	"strconv"
 	"sync"
 	"time"
 )
@@ -23,26 +25,21 @@ type ConsensusTracker struct {
 	finalizedBlock uint64
 	mu             sync.RWMutex
 	maxHistory     int // Maximum number of blocks to track
+	clock          Clock
 }
 
-// Global consensus tracker instance
-var consensusTracker *ConsensusTracker
-
-// InitializeConsensusTracker creates a new consensus tracker
-func InitializeConsensusTracker() *ConsensusTracker {
-	consensusTracker = &ConsensusTracker{
+// NewConsensusTracker creates a new consensus tracker timing phases by clock
+func NewConsensusTracker(clock Clock) *ConsensusTracker {
+	return &ConsensusTracker{
 		blocks:     make(map[uint64]*BlockConsensusState),
 		maxHistory: 20, // Track last 20 blocks
+		clock:      clock,
 	}
-	return consensusTracker
 }
 
-// GetConsensusTracker returns the global consensus tracker instance
+// GetConsensusTracker returns the served dashboard's consensus tracker
 func GetConsensusTracker() *ConsensusTracker {
-	if consensusTracker == nil {
-		return InitializeConsensusTracker()
-	}
-	return consensusTracker
+	return GetDashboard().ConsensusTracker()
 }
 
 // OnBlockProposed records when a block is proposed
@@ -57,13 +54,15 @@ func (ct *ConsensusTracker) OnBlockProposed(blockNum uint64, hash string, txCoun
 
 	// Create or update block state
 	if _, exists := ct.blocks[blockNum]; !exists {
-		ct.blocks[blockNum] = &BlockConsensusState{
+		block := &BlockConsensusState{
 			BlockNumber: blockNum,
 			BlockHash:   hash,
 			Phase:       "proposed",
-			ProposedAt:  time.Now(),
+			ProposedAt:  ct.clock.Now(),
 			TxCount:     txCount,
 		}
+		ct.blocks[blockNum] = block
+		publishPhaseChange(block, block.ProposedAt)
 	}
 
 	// Automatically mark previous blocks as voted/finalized based on MonadBFT rules
@@ -75,9 +74,9 @@ func (ct *ConsensusTracker) OnBlockProposed(blockNum uint64, hash string, txCoun
 
 // updatePhases automatically updates block phases based on MonadBFT timing
 // Voted: after 1 block
-// Finalized: after 2 blocks
+// Finalized: after ChainParams.FinalityDepth blocks
 func (ct *ConsensusTracker) updatePhases(currentBlockNum uint64) {
-	now := time.Now()
+	now := ct.clock.Now()
 
 	// Block N-1 should be voted
 	if currentBlockNum >= 1 {
@@ -86,18 +85,21 @@ func (ct *ConsensusTracker) updatePhases(currentBlockNum uint64) {
 			if block.Phase == "proposed" {
 				block.Phase = "voted"
 				block.VotedAt = &now
+				publishPhaseChange(block, now)
 			}
 		}
 	}
 
-	// Block N-2 should be finalized
-	if currentBlockNum >= 2 {
-		finalizedBlockNum := currentBlockNum - 2
+	// Block N-depth should be finalized
+	depth := uint64(GetChainParams().FinalityDepth)
+	if currentBlockNum >= depth {
+		finalizedBlockNum := currentBlockNum - depth
 		if block, exists := ct.blocks[finalizedBlockNum]; exists {
 			if block.Phase != "finalized" {
 				block.Phase = "finalized"
 				block.FinalizedAt = &now
-				ct.finalizedBlock = finalizedBlockNum
+				publishPhaseChange(block, now)
+				ct.advanceFinalized(block)
 			}
 		}
 	}
@@ -109,9 +111,10 @@ func (ct *ConsensusTracker) OnBlockVoted(blockNum uint64) {
 	defer ct.mu.Unlock()
 
 	if block, exists := ct.blocks[blockNum]; exists {
-		now := time.Now()
+		now := ct.clock.Now()
 		block.Phase = "voted"
 		block.VotedAt = &now
+		publishPhaseChange(block, now)
 	}
 }
 
@@ -121,15 +124,40 @@ func (ct *ConsensusTracker) OnBlockFinalized(blockNum uint64) {
 	defer ct.mu.Unlock()
 
 	if block, exists := ct.blocks[blockNum]; exists {
-		now := time.Now()
+		now := ct.clock.Now()
 		block.Phase = "finalized"
 		block.FinalizedAt = &now
-		if blockNum > ct.finalizedBlock {
-			ct.finalizedBlock = blockNum
-		}
+		publishPhaseChange(block, now)
+		ct.advanceFinalized(block)
 	}
 }
 
+// publishPhaseChange publishes a block's new consensus phase to the event bus
+func publishPhaseChange(block *BlockConsensusState, at time.Time) {
+	publishBusEvent(busConsensus, busEventConsensusPhase, strconv.FormatUint(block.BlockNumber, 10), ConsensusPhaseEvent{
+		Number:  block.BlockNumber,
+		Hash:    block.BlockHash,
+		Phase:   block.Phase,
+		TxCount: block.TxCount,
+		At:      at,
+	})
+}
+
+// advanceFinalized moves the finalized head forward and notifies webhooks;
+// caller holds ct.mu
+func (ct *ConsensusTracker) advanceFinalized(block *BlockConsensusState) {
+	if block.BlockNumber <= ct.finalizedBlock {
+		return
+	}
+	ct.finalizedBlock = block.BlockNumber
+	publishWebhookEvent(webhookBlockFinalized, FinalizedBlockEvent{
+		Number:      block.BlockNumber,
+		Hash:        block.BlockHash,
+		TxCount:     block.TxCount,
+		FinalizedAt: *block.FinalizedAt,
+	})
+}
+
 // GetRecentBlocks returns the N most recent blocks
 func (ct *ConsensusTracker) GetRecentBlocks(count int) []BlockConsensusState {
 	ct.mu.RLock()
@@ -138,6 +166,9 @@ func (ct *ConsensusTracker) GetRecentBlocks(count int) []BlockConsensusState {
 	if count > len(ct.blocks) {
 		count = len(ct.blocks)
 	}
+	if count < 0 {
+		count = 0
+	}
 
 	// Collect blocks and sort by block number
 	blocks := make([]BlockConsensusState, 0, len(ct.blocks))
@@ -162,8 +193,19 @@ func (ct *ConsensusTracker) GetRecentBlocks(count int) []BlockConsensusState {
 	return blocks
 }
 
+// ConsensusState is the consensus summary served at /api/v1/consensus
+type ConsensusState struct {
+	CurrentBlock    uint64                `json:"current_block"`
+	FinalizedBlock  uint64                `json:"finalized_block"`
+	BlocksBehind    uint64                `json:"blocks_behind"`
+	ProposedBlocks  int                   `json:"proposed_blocks"`
+	VotedBlocks     int                   `json:"voted_blocks"`
+	FinalizedBlocks int                   `json:"finalized_blocks"`
+	RecentBlocks    []BlockConsensusState `json:"recent_blocks"`
+}
+
 // GetConsensusState returns current consensus state summary
-func (ct *ConsensusTracker) GetConsensusState() map[string]interface{} {
+func (ct *ConsensusTracker) GetConsensusState() ConsensusState {
 	ct.mu.RLock()
 	defer ct.mu.RUnlock()
 
@@ -182,14 +224,14 @@ func (ct *ConsensusTracker) GetConsensusState() map[string]interface{} {
 		}
 	}
 
-	return map[string]interface{}{
-		"current_block":     ct.currentBlock,
-		"finalized_block":   ct.finalizedBlock,
-		"blocks_behind":     ct.currentBlock - ct.finalizedBlock,
-		"proposed_blocks":   proposedCount,
-		"voted_blocks":      votedCount,
-		"finalized_blocks":  finalizedCount,
-		"recent_blocks":     ct.GetRecentBlocks(10),
+	return ConsensusState{
+		CurrentBlock:    ct.currentBlock,
+		FinalizedBlock:  ct.finalizedBlock,
+		BlocksBehind:    ct.currentBlock - ct.finalizedBlock,
+		ProposedBlocks:  proposedCount,
+		VotedBlocks:     votedCount,
+		FinalizedBlocks: finalizedCount,
+		RecentBlocks:    ct.GetRecentBlocks(10),
 	}
 }
 
@@ -204,6 +246,17 @@ func (ct *ConsensusTracker) GetBlockPhase(blockNum uint64) string {
 	return "unknown"
 }
 
+// Block returns the tracked consensus state of a specific block
+func (ct *ConsensusTracker) Block(blockNum uint64) (BlockConsensusState, bool) {
+	ct.mu.RLock()
+	defer ct.mu.RUnlock()
+
+	if block, exists := ct.blocks[blockNum]; exists {
+		return *block, true
+	}
+	return BlockConsensusState{}, false
+}
+
 // GetPhaseProgress returns progress percentage for a block (0-100)
 func (ct *ConsensusTracker) GetPhaseProgress(blockNum uint64) int {
 	phase := ct.GetBlockPhase(blockNum)
@@ -228,12 +281,18 @@ func (ct *ConsensusTracker) cleanupOldBlocks() {
 	// Find the threshold block number
 	threshold := ct.currentBlock - uint64(ct.maxHistory)
 
-	// Remove blocks older than threshold
-	for blockNum := range ct.blocks {
+	// Remove blocks older than threshold, archiving their timelines
+	evicted := make([]BlockConsensusState, 0, len(ct.blocks)-ct.maxHistory)
+	for blockNum, block := range ct.blocks {
 		if blockNum < threshold {
+			evicted = append(evicted, *block)
 			delete(ct.blocks, blockNum)
 		}
 	}
+	if ch := GetConsensusHistory(); ch != nil && len(evicted) > 0 {
+		sort.Slice(evicted, func(i, j int) bool { return evicted[i].BlockNumber < evicted[j].BlockNumber })
+		ch.Archive(evicted)
+	}
 }
 
 // GetMetrics returns consensus metrics for monitoring
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestConsensusTrackerFinalizationTime(t *testing.T) {
+	clock := NewFakeClock(fakeClockStart)
+	ct := NewConsensusTracker(clock)
+	depth := GetChainParams().FinalityDepth
+	blockTime := 400 * time.Millisecond
+
+	for n := uint64(1); n <= uint64(depth)+3; n++ {
+		ct.OnBlockProposed(n, "0x", 1)
+		clock.Advance(blockTime)
+	}
+
+	blocks := ct.GetRecentBlocks(100)
+	for _, block := range blocks {
+		if block.FinalizedAt == nil {
+			continue
+		}
+		if got, want := block.FinalizedAt.Sub(block.ProposedAt), time.Duration(depth)*blockTime; got != want {
+			t.Errorf("block %d finalized %v after proposal, want %v", block.BlockNumber, got, want)
+		}
+		if block.VotedAt == nil || block.VotedAt.Sub(block.ProposedAt) != blockTime {
+			t.Errorf("block %d voted at %v, want one block after proposal", block.BlockNumber, block.VotedAt)
+		}
+	}
+
+	metrics := ct.GetMetrics()
+	want := (time.Duration(depth) * blockTime).Seconds()
+	if got := metrics["avg_finalization_time"].(float64); math.Abs(got-want) > 1e-9 {
+		t.Fatalf("avg_finalization_time = %v, want %v", got, want)
+	}
+	// The last proposal finalizes the block depth behind it
+	if got := metrics["finalized_block"]; got != uint64(3) {
+		t.Fatalf("finalized_block = %v, want 3", got)
+	}
+}
@@ -0,0 +1,374 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/sha3"
+
+	"monad-dashboard/hexutil"
+)
+
+// Verified contract names and ABIs from a Sourcify-compatible server
+// (CONTRACT_VERIFICATION_URL, e.g. https://sourcify.dev/server). Contracts
+// in the activity leaderboards are looked up in the background and labelled
+// once verified; their ABIs name the events they emit.
+
+// Bounds on verification lookups
+const (
+	verificationQueueSize  = 256
+	maxVerifiedContracts   = 10000
+	maxVerificationABISize = 4 << 20
+	verificationErrorRetry = 5 * time.Minute
+)
+
+// errContractNotVerified is a lookup of a contract the server does not know
+var errContractNotVerified = errors.New("contract not verified")
+
+// ContractLabel is the verification status of one contract
+type ContractLabel struct {
+	Address   string            `json:"address"`
+	Verified  bool              `json:"verified"`
+	Match     string            `json:"match,omitempty"` // exact_match or match (partial)
+	Name      string            `json:"name,omitempty"`
+	Compiler  string            `json:"compiler,omitempty"`
+	Events    map[string]string `json:"events,omitempty"` // topic0 -> event signature
+	ABI       json.RawMessage   `json:"abi,omitempty"`
+	Error     string            `json:"error,omitempty"` // Last lookup failure
+	CheckedAt time.Time         `json:"checked_at"`
+}
+
+// ContractVerifier looks up and caches contract verification
+type ContractVerifier struct {
+	baseURL string
+	chainID uint64 // Resolved from the node when zero
+	recheck time.Duration
+	client  *http.Client
+	queue   chan string
+
+	mu      sync.RWMutex
+	labels  map[string]*ContractLabel
+	pending map[string]bool
+}
+
+// Global contract verifier
+var (
+	contractVerifier   *ContractVerifier
+	contractVerifierMu sync.RWMutex
+)
+
+// NewContractVerifier creates a verifier against a Sourcify-compatible
+// server; unverified contracts are looked up again after recheck
+func NewContractVerifier(baseURL string, chainID uint64, recheck time.Duration) *ContractVerifier {
+	return &ContractVerifier{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		chainID: chainID,
+		recheck: recheck,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		queue:   make(chan string, verificationQueueSize),
+		labels:  make(map[string]*ContractLabel),
+		pending: make(map[string]bool),
+	}
+}
+
+// InitializeContractVerifier starts the verifier when CONTRACT_VERIFICATION_URL
+// is set. CONTRACT_VERIFICATION_CHAIN_ID overrides the node's chain ID and
+// CONTRACT_VERIFICATION_RECHECK (default 1h) sets how long an unverified
+// contract is remembered.
+func InitializeContractVerifier() *ContractVerifier {
+	baseURL := os.Getenv("CONTRACT_VERIFICATION_URL")
+	if baseURL == "" {
+		return nil
+	}
+	var chainID uint64
+	if value := os.Getenv("CONTRACT_VERIFICATION_CHAIN_ID"); value != "" {
+		parsed, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			log.Printf("Invalid CONTRACT_VERIFICATION_CHAIN_ID %q, using the node's chain ID", value)
+		}
+		chainID = parsed
+	}
+	recheck := time.Hour
+	if value := os.Getenv("CONTRACT_VERIFICATION_RECHECK"); value != "" {
+		if parsed, err := time.ParseDuration(value); err == nil && parsed > 0 {
+			recheck = parsed
+		} else {
+			log.Printf("Invalid CONTRACT_VERIFICATION_RECHECK %q, using %s", value, recheck)
+		}
+	}
+
+	cv := NewContractVerifier(baseURL, chainID, recheck)
+	go cv.run()
+	log.Printf("Contract verification from %s", cv.baseURL)
+
+	contractVerifierMu.Lock()
+	contractVerifier = cv
+	contractVerifierMu.Unlock()
+	return cv
+}
+
+// GetContractVerifier returns the global contract verifier, nil when
+// CONTRACT_VERIFICATION_URL is unset
+func GetContractVerifier() *ContractVerifier {
+	contractVerifierMu.RLock()
+	defer contractVerifierMu.RUnlock()
+	return contractVerifier
+}
+
+// Cached returns a contract's cached label, queueing a lookup when it is
+// missing or stale. It never blocks on the server.
+func (cv *ContractVerifier) Cached(address string) (*ContractLabel, bool) {
+	address = strings.ToLower(address)
+	cv.mu.Lock()
+	defer cv.mu.Unlock()
+
+	label, ok := cv.labels[address]
+	if (!ok || cv.staleLocked(label)) && !cv.pending[address] {
+		select {
+		case cv.queue <- address:
+			cv.pending[address] = true
+		default:
+		}
+	}
+	return label, ok
+}
+
+// staleLocked reports whether a label should be looked up again; caller
+// holds cv.mu
+func (cv *ContractVerifier) staleLocked(label *ContractLabel) bool {
+	switch {
+	case label.Verified:
+		return false
+	case label.Error != "":
+		return time.Since(label.CheckedAt) > verificationErrorRetry
+	default:
+		return time.Since(label.CheckedAt) > cv.recheck
+	}
+}
+
+// Lookup returns a contract's label, fetching it when missing or stale
+func (cv *ContractVerifier) Lookup(address string) *ContractLabel {
+	address = strings.ToLower(address)
+	cv.mu.RLock()
+	label, ok := cv.labels[address]
+	fresh := ok && !cv.staleLocked(label)
+	cv.mu.RUnlock()
+	if fresh {
+		return label
+	}
+	return cv.refresh(address)
+}
+
+// run looks up queued contracts one at a time
+func (cv *ContractVerifier) run() {
+	for {
+		select {
+		case <-shutdownContext().Done():
+			return
+		case address := <-cv.queue:
+			cv.refresh(address)
+			cv.mu.Lock()
+			delete(cv.pending, address)
+			cv.mu.Unlock()
+		}
+	}
+}
+
+// refresh fetches a contract's verification and caches the result
+func (cv *ContractVerifier) refresh(address string) *ContractLabel {
+	label, err := cv.fetch(address)
+	if errors.Is(err, errContractNotVerified) {
+		label, err = &ContractLabel{Address: address}, nil
+	}
+	if err != nil {
+		log.Printf("Contract verification of %s failed: %v", address, err)
+		label = &ContractLabel{Address: address, Error: err.Error()}
+	}
+	label.CheckedAt = time.Now().UTC()
+
+	cv.mu.Lock()
+	if _, exists := cv.labels[address]; !exists && len(cv.labels) >= maxVerifiedContracts {
+		cv.evictLocked()
+	}
+	cv.labels[address] = label
+	cv.mu.Unlock()
+	return label
+}
+
+// evictLocked drops the label checked longest ago; caller holds cv.mu
+func (cv *ContractVerifier) evictLocked() {
+	var oldest string
+	var oldestAt time.Time
+	for address, label := range cv.labels {
+		if oldest == "" || label.CheckedAt.Before(oldestAt) {
+			oldest, oldestAt = address, label.CheckedAt
+		}
+	}
+	delete(cv.labels, oldest)
+}
+
+// fetch queries GET /v2/contract/{chainId}/{address} of the server
+func (cv *ContractVerifier) fetch(address string) (*ContractLabel, error) {
+	chainID := cv.chainID
+	if chainID == 0 {
+		client := GetMonadClient()
+		if client == nil {
+			return nil, errors.New("monad client not initialized")
+		}
+		id, err := client.GetChainID()
+		if err != nil {
+			return nil, fmt.Errorf("chain ID: %w", err)
+		}
+		chainID = id
+	}
+
+	url := fmt.Sprintf("%s/v2/contract/%d/%s?fields=abi,compilation", cv.baseURL, chainID, address)
+	req, err := http.NewRequestWithContext(shutdownContext(), http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := cv.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, errContractNotVerified
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("verification server returned %s", resp.Status)
+	}
+
+	var body struct {
+		Match       *string         `json:"match"`
+		ABI         json.RawMessage `json:"abi"`
+		Compilation struct {
+			Name            string `json:"name"`
+			Compiler        string `json:"compiler"`
+			CompilerVersion string `json:"compilerVersion"`
+		} `json:"compilation"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxVerificationABISize)).Decode(&body); err != nil {
+		return nil, fmt.Errorf("invalid verification response: %w", err)
+	}
+	if body.Match == nil || *body.Match == "" {
+		return nil, errContractNotVerified
+	}
+
+	label := &ContractLabel{
+		Address:  address,
+		Verified: true,
+		Match:    *body.Match,
+		Name:     body.Compilation.Name,
+		Compiler: strings.TrimSpace(body.Compilation.Compiler + " " + body.Compilation.CompilerVersion),
+		ABI:      body.ABI,
+		Events:   abiEventTopics(body.ABI),
+	}
+	return label, nil
+}
+
+// abiParam is an ABI input, with components for tuples
+type abiParam struct {
+	Type       string     `json:"type"`
+	Components []abiParam `json:"components"`
+}
+
+// canonicalType is an input's type as it appears in a signature, with
+// tuples expanded
+func (p abiParam) canonicalType() string {
+	if !strings.HasPrefix(p.Type, "tuple") {
+		return p.Type
+	}
+	types := make([]string, len(p.Components))
+	for i, component := range p.Components {
+		types[i] = component.canonicalType()
+	}
+	return "(" + strings.Join(types, ",") + ")" + strings.TrimPrefix(p.Type, "tuple")
+}
+
+// abiEventTopics maps the topic0 of every non-anonymous event of an ABI to
+// its signature
+func abiEventTopics(abi json.RawMessage) map[string]string {
+	var entries []struct {
+		Type      string     `json:"type"`
+		Name      string     `json:"name"`
+		Anonymous bool       `json:"anonymous"`
+		Inputs    []abiParam `json:"inputs"`
+	}
+	if err := json.Unmarshal(abi, &entries); err != nil {
+		return nil
+	}
+	events := make(map[string]string)
+	for _, entry := range entries {
+		if entry.Type != "event" || entry.Anonymous {
+			continue
+		}
+		types := make([]string, len(entry.Inputs))
+		for i, input := range entry.Inputs {
+			types[i] = input.canonicalType()
+		}
+		signature := entry.Name + "(" + strings.Join(types, ",") + ")"
+		hash := sha3.NewLegacyKeccak256()
+		hash.Write([]byte(signature))
+		events[hexutil.EncodeBytes(hash.Sum(nil))] = signature
+	}
+	return events
+}
+
+// contractName returns the verified name and match of a contract when the
+// verifier is configured and has it cached, queueing a lookup otherwise
+func contractName(address string) (name, match string) {
+	cv := GetContractVerifier()
+	if cv == nil {
+		return "", ""
+	}
+	if label, ok := cv.Cached(address); ok && label.Verified {
+		return label.Name, label.Match
+	}
+	return "", ""
+}
+
+// contractEvent returns the signature of a log's event from its emitter's
+// verified ABI, when cached
+func contractEvent(address, topic0 string) string {
+	cv := GetContractVerifier()
+	if cv == nil {
+		return ""
+	}
+	if label, ok := cv.Cached(address); ok {
+		return label.Events[strings.ToLower(topic0)]
+	}
+	return ""
+}
+
+// handleContractVerification returns a contract's verification status, name
+// and ABI, looking it up when not cached
+func handleContractVerification(c *gin.Context) {
+	cv := GetContractVerifier()
+	if cv == nil {
+		c.JSON(http.StatusServiceUnavailable, APIError{Error: "Contract verification not configured (CONTRACT_VERIFICATION_URL)"})
+		return
+	}
+	address, err := normalizeAddress(c.Param("addr"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, APIError{Error: err.Error()})
+		return
+	}
+	label := cv.Lookup(address)
+	if label.Error != "" {
+		c.JSON(http.StatusBadGateway, APIError{Error: label.Error})
+		return
+	}
+	c.JSON(http.StatusOK, label)
+}
@@ -0,0 +1,23 @@
+package main
+
+// counterRate returns the per-second rate of a cumulative counter between
+// two samples taken seconds apart. A decrease means the node restarted and
+// the counter reset; the rate is then clamped to zero and reset is reported
+// so the caller can mark the interval as discontinuous.
+func counterRate(prev, cur, seconds float64) (rate float64, reset bool) {
+	if cur < prev {
+		return 0, true
+	}
+	if seconds <= 0 {
+		return 0, false
+	}
+	return (cur - prev) / seconds, false
+}
+
+// counterDelta is counterRate for integer counters without time scaling
+func counterDelta(prev, cur int64) (delta int64, reset bool) {
+	if cur < prev {
+		return 0, true
+	}
+	return cur - prev, false
+}
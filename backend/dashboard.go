@@ -0,0 +1,242 @@
+package main
+
+import (
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// Dashboard owns one Monad node's RPC client and WebSocket endpoint,
+// clock, collectors, the trackers fed by its subscription (consensus,
+// heads, fees, incidents, watchlist) and its latest metrics snapshot, so
+// several can run side by side. The process serves the dashboard installed
+// with SetDashboard; handlers reach its parts through GetDashboard and
+// accessors such as GetMonadClient, GetSubscriber and GetFeeTracker.
+//
+// Services that are not tied to a node (the WebSocket hub, auth, webhooks,
+// notifiers, exporters) stay process-wide, and the per-transaction
+// analytics fed by the fee tracker's receipts are still globals.
+type Dashboard struct {
+	client    *MonadClient
+	wsURL     string // Node WebSocket endpoint for the subscription
+	clock     Clock
+	consensus *ConsensusTracker
+	startTime time.Time
+
+	// Latest metrics snapshot, from subscribed blocks or polling
+	metricsMu sync.RWMutex
+	metrics   MonadMetrics
+
+	mu         sync.RWMutex
+	subscriber *MonadSubscriber
+	ipc        *MonadIPCCollector
+	prometheus *PrometheusCollector
+	eventRings map[string]*EventRingReader // Keyed by ring name
+	fees       *FeeTracker
+	heads      *HeadTracker
+	incidents  *IncidentTracker
+	watchlist  *Watchlist
+}
+
+// NewDashboard creates a dashboard for the node behind client and its
+// WebSocket endpoint wsURL, timed by clock; collectors are connected by
+// Start
+func NewDashboard(client *MonadClient, wsURL string, clock Clock) *Dashboard {
+	return &Dashboard{
+		client:     client,
+		wsURL:      wsURL,
+		clock:      clock,
+		consensus:  NewConsensusTracker(clock),
+		startTime:  clock.Now(),
+		eventRings: make(map[string]*EventRingReader),
+	}
+}
+
+// Served dashboard
+var (
+	dashboard   *Dashboard
+	dashboardMu sync.RWMutex
+)
+
+func init() {
+	// MONAD_RPC_URLS lists execution RPC servers, comma-separated
+	rpcURLs := os.Getenv("MONAD_RPC_URLS")
+	if rpcURLs == "" {
+		rpcURLs = "http://127.0.0.1:8080"
+	}
+	// MONAD_WS_URL is the node's eth_subscribe endpoint
+	wsURL := os.Getenv("MONAD_WS_URL")
+	if wsURL == "" {
+		wsURL = "ws://127.0.0.1:8081"
+	}
+	SetDashboard(NewDashboard(NewMonadClient(
+		rpcURLs, // Monad RPC Server
+		"/home/monad/monad-bft/controlpanel.sock", // BFT Control Panel IPC
+		"/home/monad/monad-bft/mempool.sock",      // Mempool IPC
+	), wsURL, systemClock))
+}
+
+// SetDashboard installs the dashboard served by the API
+func SetDashboard(d *Dashboard) {
+	dashboardMu.Lock()
+	defer dashboardMu.Unlock()
+	dashboard = d
+}
+
+// GetDashboard returns the served dashboard
+func GetDashboard() *Dashboard {
+	dashboardMu.RLock()
+	defer dashboardMu.RUnlock()
+	return dashboard
+}
+
+// GetMonadClient returns the served dashboard's RPC client
+func GetMonadClient() *MonadClient {
+	return GetDashboard().client
+}
+
+// GetSubscriber returns the served dashboard's newHeads subscriber, nil
+// until it connects
+func GetSubscriber() *MonadSubscriber {
+	return GetDashboard().Subscriber()
+}
+
+// Client returns the dashboard's RPC client
+func (d *Dashboard) Client() *MonadClient {
+	return d.client
+}
+
+// Subscriber returns the WebSocket subscriber, nil until it connects
+func (d *Dashboard) Subscriber() *MonadSubscriber {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.subscriber
+}
+
+// IPCCollector returns the IPC metrics collector, nil before StartIPCCollector
+func (d *Dashboard) IPCCollector() *MonadIPCCollector {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.ipc
+}
+
+// PrometheusCollector returns the Prometheus collector, nil until scraping
+// or an OTLP push configured one
+func (d *Dashboard) PrometheusCollector() *PrometheusCollector {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.prometheus
+}
+
+// FeeTracker returns the fee tracker, nil before StartFeeTracker
+func (d *Dashboard) FeeTracker() *FeeTracker {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.fees
+}
+
+// HeadTracker returns the head tracker, nil before StartHeadTracker
+func (d *Dashboard) HeadTracker() *HeadTracker {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.heads
+}
+
+// IncidentTracker returns the incident tracker, nil before
+// StartIncidentTracker
+func (d *Dashboard) IncidentTracker() *IncidentTracker {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.incidents
+}
+
+// Watchlist returns the address watchlist, nil before LoadWatchlist
+func (d *Dashboard) Watchlist() *Watchlist {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.watchlist
+}
+
+// Clock returns the dashboard's time source
+func (d *Dashboard) Clock() Clock {
+	return d.clock
+}
+
+// ConsensusTracker returns the MonadBFT phase tracker
+func (d *Dashboard) ConsensusTracker() *ConsensusTracker {
+	return d.consensus
+}
+
+// Metrics returns the latest metrics snapshot
+func (d *Dashboard) Metrics() MonadMetrics {
+	d.metricsMu.RLock()
+	defer d.metricsMu.RUnlock()
+	return d.metrics
+}
+
+// Close disconnects the node subscription and event rings
+func (d *Dashboard) Close() {
+	if s := d.Subscriber(); s != nil {
+		s.Close()
+	}
+	for _, reader := range d.EventRings() {
+		reader.Disconnect()
+	}
+}
+
+// Start connects the dashboard's collectors to its node: RPC health checks,
+// event rings (MONAD_EVENT_RINGS), Prometheus (PROMETHEUS_ENDPOINT), IPC
+// (MONAD_IPC_PATH) and the WebSocket subscription (MONAD_WS_URL), polling for metrics when
+// the subscription is unavailable. Collectors that cannot connect are
+// skipped.
+func (d *Dashboard) Start() {
+	// Health-check the execution RPC endpoints (MONAD_RPC_URLS)
+	d.client.rpc.StartHealthChecks(5 * time.Second)
+
+	// Initialize event rings connection
+	if err := d.ConnectEventRings(); err != nil {
+		log.Printf("Event rings not available: %v", err)
+		log.Printf("Dashboard will use RPC-only mode")
+	} else {
+		// Start event processing if event rings are available
+		go d.StartEventProcessing()
+	}
+
+	// Initialize Prometheus metrics collector for accurate TPS
+	promEndpoint := os.Getenv("PROMETHEUS_ENDPOINT")
+	if promEndpoint == "" {
+		promEndpoint = "http://127.0.0.1:8889/metrics" // Default OTEL endpoint
+	}
+	log.Printf("Attempting to connect to Prometheus endpoint at %s...", promEndpoint)
+	if err := d.StartPrometheusCollector(promEndpoint); err != nil {
+		log.Printf("Prometheus collector not available: %v", err)
+		log.Printf("Will calculate TPS from block data")
+	} else {
+		log.Printf("✅ Prometheus collector initialized - using accurate TPS from monad_execution_ledger_num_tx_commits")
+	}
+
+	// Initialize IPC metrics collector for real metrics
+	ipcPath := os.Getenv("MONAD_IPC_PATH")
+	if ipcPath == "" {
+		ipcPath = "/home/monad/monad-bft/mempool.sock" // Default path
+	}
+	log.Printf("Attempting to connect to Monad IPC at %s...", ipcPath)
+	if err := d.StartIPCCollector(ipcPath); err != nil {
+		log.Printf("IPC metrics collector not available: %v", err)
+		log.Printf("Will use estimation-based metrics")
+	} else {
+		log.Printf("✅ IPC metrics collector initialized - using real Monad metrics")
+	}
+
+	// Try to initialize real-time WebSocket subscription
+	log.Printf("Attempting to connect to Monad WebSocket at %s...", d.wsURL)
+	if err := d.StartSubscriber(d.wsURL); err != nil {
+		log.Printf("Failed to initialize WebSocket subscriber: %v", err)
+		log.Printf("Falling back to polling mode")
+		// Start metrics collection via polling as fallback
+		go d.startMetricsCollection()
+	} else {
+		log.Printf("Successfully initialized real-time WebSocket subscription")
+	}
+}
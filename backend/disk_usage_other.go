@@ -0,0 +1,18 @@
+//go:build !unix
+
+package main
+
+import (
+	"errors"
+	"io/fs"
+)
+
+// filesystemUsage is only implemented on Unix
+func filesystemUsage(path string) (*FilesystemUsage, error) {
+	return nil, errors.New("filesystem usage not supported on this platform")
+}
+
+// allocatedSize falls back to the apparent size
+func allocatedSize(info fs.FileInfo) int64 {
+	return info.Size()
+}
@@ -0,0 +1,35 @@
+//go:build unix
+
+package main
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// filesystemUsage reports the capacity of the filesystem holding path
+func filesystemUsage(path string) (*FilesystemUsage, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return nil, err
+	}
+	usage := &FilesystemUsage{
+		TotalBytes:     uint64(st.Blocks) * uint64(st.Bsize),
+		FreeBytes:      uint64(st.Bfree) * uint64(st.Bsize),
+		AvailableBytes: uint64(st.Bavail) * uint64(st.Bsize),
+	}
+	// Used share as df reports it: of the space available to users
+	if used := usage.TotalBytes - usage.FreeBytes; used+usage.AvailableBytes > 0 {
+		usage.UsedPercent = float64(used) / float64(used+usage.AvailableBytes) * 100
+	}
+	return usage, nil
+}
+
+// allocatedSize is the space a file occupies on disk, so sparse and
+// preallocated files count what they really use
+func allocatedSize(info fs.FileInfo) int64 {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return int64(st.Blocks) * 512
+	}
+	return info.Size()
+}
@@ -0,0 +1,359 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// dropReason maps one txpool drop reason to its cumulative counter in each
+// source; a nil getter means the source does not report it
+type dropReason struct {
+	Key   string
+	Label string
+	prom  func(m *PrometheusMetrics) float64
+	ipc   func(m *MonadRealMetrics) float64
+}
+
+var dropReasons = []dropReason{
+	{
+		Key: "invalid_signature", Label: "Invalid signature",
+		prom: func(m *PrometheusMetrics) float64 { return m.DropInvalidSignatureTotal },
+		ipc:  func(m *MonadRealMetrics) float64 { return float64(m.DropInvalidSignature) },
+	},
+	{
+		Key: "not_well_formed", Label: "Not well formed",
+		ipc: func(m *MonadRealMetrics) float64 { return float64(m.DropNotWellFormed) },
+	},
+	{
+		Key: "nonce_too_low", Label: "Nonce too low",
+		prom: func(m *PrometheusMetrics) float64 { return m.DropNonceTooLowTotal },
+		ipc:  func(m *MonadRealMetrics) float64 { return float64(m.DropNonceTooLow) },
+	},
+	{
+		Key: "fee_too_low", Label: "Fee too low",
+		prom: func(m *PrometheusMetrics) float64 { return m.DropFeeTooLowTotal },
+		ipc:  func(m *MonadRealMetrics) float64 { return float64(m.DropFeeTooLow) },
+	},
+	{
+		Key: "pool_full", Label: "Pool full",
+		prom: func(m *PrometheusMetrics) float64 { return m.DropPoolFullTotal },
+		ipc:  func(m *MonadRealMetrics) float64 { return float64(m.DropPoolFull) },
+	},
+	{
+		Key: "insufficient_balance", Label: "Insufficient balance",
+		prom: func(m *PrometheusMetrics) float64 { return m.DropInsufficientBalanceTotal },
+		ipc:  func(m *MonadRealMetrics) float64 { return float64(m.DropInsufficientBalance) },
+	},
+}
+
+// Drop sources in priority order
+const (
+	dropSourcePrometheus = "prometheus"
+	dropSourceIPC        = "ipc"
+)
+
+var dropSources = []string{dropSourcePrometheus, dropSourceIPC}
+
+// dropWindows are the selectable breakdown windows
+var dropWindows = map[string]time.Duration{
+	"1m":  time.Minute,
+	"5m":  5 * time.Minute,
+	"15m": 15 * time.Minute,
+	"1h":  time.Hour,
+	"6h":  6 * time.Hour,
+}
+
+// dropSample holds one source's cumulative drop counters, indexed like
+// dropReasons; -1 marks counters the source lacks
+type dropSample struct {
+	At     time.Time
+	Totals []float64
+}
+
+// DropTracker samples cumulative drop counters from every source so drop
+// counts and rates can be computed over any window
+type DropTracker struct {
+	mu        sync.RWMutex
+	samples   map[string][]dropSample // Source -> samples, oldest first
+	retention time.Duration
+}
+
+// Global drop tracker instance
+var (
+	dropTracker   *DropTracker
+	dropTrackerMu sync.RWMutex
+)
+
+// InitializeDropTracker starts sampling drop counters every interval
+func InitializeDropTracker(interval time.Duration) *DropTracker {
+	dt := &DropTracker{
+		samples: make(map[string][]dropSample),
+		// Twice the longest window, so its rate of change has a previous window
+		retention: 2 * dropWindows["6h"],
+	}
+
+	dropTrackerMu.Lock()
+	dropTracker = dt
+	dropTrackerMu.Unlock()
+
+	go dt.run(interval)
+	log.Printf("Drop tracker sampling every %s", interval)
+	return dt
+}
+
+// GetDropTracker returns the global drop tracker
+func GetDropTracker() *DropTracker {
+	dropTrackerMu.RLock()
+	defer dropTrackerMu.RUnlock()
+	return dropTracker
+}
+
+func (dt *DropTracker) run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		dt.sample(now)
+	}
+}
+
+// sample records the current counters of every healthy source
+func (dt *DropTracker) sample(now time.Time) {
+	if prom := GetPrometheusCollector(); prom != nil && prom.IsHealthy() {
+		metrics := prom.GetMetrics()
+		dt.add(dropSourcePrometheus, now, func(r dropReason) (float64, bool) {
+			if r.prom == nil {
+				return 0, false
+			}
+			return r.prom(metrics), true
+		})
+	}
+	if ipc := GetIPCCollector(); ipc != nil && ipc.IsHealthy() {
+		metrics := ipc.GetMetrics()
+		dt.add(dropSourceIPC, now, func(r dropReason) (float64, bool) {
+			if r.ipc == nil {
+				return 0, false
+			}
+			return r.ipc(metrics), true
+		})
+	}
+}
+
+// currentDropTotal sums every drop reason from the highest-priority healthy
+// source, reporting false when none is available
+func currentDropTotal() (float64, bool) {
+	if prom := GetPrometheusCollector(); prom != nil && prom.IsHealthy() {
+		metrics := prom.GetMetrics()
+		total := 0.0
+		for _, reason := range dropReasons {
+			if reason.prom != nil {
+				total += reason.prom(metrics)
+			}
+		}
+		return total, true
+	}
+	if ipc := GetIPCCollector(); ipc != nil && ipc.IsHealthy() {
+		metrics := ipc.GetMetrics()
+		total := 0.0
+		for _, reason := range dropReasons {
+			if reason.ipc != nil {
+				total += reason.ipc(metrics)
+			}
+		}
+		return total, true
+	}
+	return 0, false
+}
+
+func (dt *DropTracker) add(source string, now time.Time, value func(r dropReason) (float64, bool)) {
+	totals := make([]float64, len(dropReasons))
+	for i, reason := range dropReasons {
+		if v, ok := value(reason); ok {
+			totals[i] = v
+		} else {
+			totals[i] = -1
+		}
+	}
+
+	dt.mu.Lock()
+	defer dt.mu.Unlock()
+
+	samples := append(dt.samples[source], dropSample{At: now, Totals: totals})
+	cutoff := now.Add(-dt.retention)
+	drop := 0
+	for drop < len(samples) && samples[drop].At.Before(cutoff) {
+		drop++
+	}
+	dt.samples[source] = append(samples[:0:0], samples[drop:]...)
+}
+
+// DropWindowStats is one reason's drops over a window from one source
+type DropWindowStats struct {
+	Source       string   `json:"source"`
+	Count        float64  `json:"count"`
+	Rate         float64  `json:"rate"`          // Drops per second
+	PreviousRate *float64 `json:"previous_rate"` // Rate over the preceding window, nil without data
+	RateChange   *float64 `json:"rate_change"`   // Rate minus previous rate
+	Coverage     float64  `json:"coverage"`      // Fraction of the window with samples
+	Resets       int      `json:"resets"`        // Counter resets inside the window
+}
+
+// windowStats computes drops for reason i over [from, to] from the given
+// samples, or false when fewer than two samples fall inside
+func windowStats(samples []dropSample, i int, from, to time.Time) (count float64, span time.Duration, resets int, ok bool) {
+	var prev *dropSample
+	for j := range samples {
+		s := &samples[j]
+		if s.At.Before(from) || s.At.After(to) || s.Totals[i] < 0 {
+			continue
+		}
+		if prev != nil {
+			if delta := s.Totals[i] - prev.Totals[i]; delta >= 0 {
+				count += delta
+			} else {
+				// Counter reset (node restart): everything since the reset counts
+				count += s.Totals[i]
+				resets++
+			}
+			span += s.At.Sub(prev.At)
+		}
+		prev = s
+	}
+	return count, span, resets, span > 0
+}
+
+// DropReasonBreakdown is one drop reason over the requested windows
+type DropReasonBreakdown struct {
+	Reason  string                     `json:"reason"`
+	Label   string                     `json:"label"`
+	Windows map[string]DropWindowStats `json:"windows"`
+	Totals  map[string]float64         `json:"totals"` // Latest cumulative counter per source
+}
+
+// WaterfallDropsResponse is the body of /api/v1/waterfall/drops
+type WaterfallDropsResponse struct {
+	Windows   []string              `json:"windows"`
+	Sources   []string              `json:"sources"`
+	Reasons   []DropReasonBreakdown `json:"reasons"`
+	Timestamp int64                 `json:"timestamp"`
+}
+
+// Breakdown returns every drop reason over the named windows, each from the
+// highest-priority source with data for that window
+func (dt *DropTracker) Breakdown(windows []string, now time.Time) []DropReasonBreakdown {
+	dt.mu.RLock()
+	defer dt.mu.RUnlock()
+
+	reasons := make([]DropReasonBreakdown, 0, len(dropReasons))
+	for i, reason := range dropReasons {
+		stats := make(map[string]DropWindowStats, len(windows))
+		for _, name := range windows {
+			window := dropWindows[name]
+			for _, source := range dropSources {
+				samples := dt.samples[source]
+				count, span, resets, ok := windowStats(samples, i, now.Add(-window), now)
+				if !ok {
+					continue
+				}
+				ws := DropWindowStats{
+					Source:   source,
+					Count:    count,
+					Rate:     count / span.Seconds(),
+					Coverage: span.Seconds() / window.Seconds(),
+					Resets:   resets,
+				}
+				if prevCount, prevSpan, _, ok := windowStats(samples, i, now.Add(-2*window), now.Add(-window)); ok {
+					prevRate := prevCount / prevSpan.Seconds()
+					change := ws.Rate - prevRate
+					ws.PreviousRate = &prevRate
+					ws.RateChange = &change
+				}
+				if ws.Coverage > 1 {
+					ws.Coverage = 1
+				}
+				stats[name] = ws
+				break
+			}
+		}
+
+		entry := DropReasonBreakdown{
+			Reason:  reason.Key,
+			Label:   reason.Label,
+			Windows: stats,
+			Totals:  make(map[string]float64),
+		}
+		for _, source := range dropSources {
+			if samples := dt.samples[source]; len(samples) > 0 && samples[len(samples)-1].Totals[i] >= 0 {
+				entry.Totals[source] = samples[len(samples)-1].Totals[i]
+			}
+		}
+		reasons = append(reasons, entry)
+	}
+	return reasons
+}
+
+// Attribute estimates the drops per reason between from and to from the
+// highest-priority source whose samples bracket that span. Samples are
+// seconds apart, so the bracketing samples' counts are scaled down to the
+// span's share of theirs. Returns false without bracketing samples.
+func (dt *DropTracker) Attribute(from, to time.Time) (map[string]float64, string, bool) {
+	dt.mu.RLock()
+	defer dt.mu.RUnlock()
+
+	for _, source := range dropSources {
+		samples := dt.samples[source]
+		before, after := -1, -1
+		for j, s := range samples {
+			if !s.At.After(from) {
+				before = j
+			}
+			if !s.At.Before(to) {
+				after = j
+				break
+			}
+		}
+		if before < 0 || after <= before {
+			continue
+		}
+
+		share := to.Sub(from).Seconds() / samples[after].At.Sub(samples[before].At).Seconds()
+		counts := make(map[string]float64, len(dropReasons))
+		for i, reason := range dropReasons {
+			if count, _, _, ok := windowStats(samples[before:after+1], i, samples[before].At, samples[after].At); ok {
+				counts[reason.Key] = count * share
+			}
+		}
+		return counts, source, true
+	}
+	return nil, "", false
+}
+
+// handleWaterfallDrops returns the drop reason breakdown
+// Query params: window (comma-separated from 1m, 5m, 15m, 1h, 6h; default 1m,5m,15m,1h)
+func handleWaterfallDrops(c *gin.Context) {
+	dt := GetDropTracker()
+	if dt == nil {
+		c.JSON(http.StatusServiceUnavailable, APIError{Error: "Drop tracker not initialized"})
+		return
+	}
+
+	windows := strings.Split(c.DefaultQuery("window", "1m,5m,15m,1h"), ",")
+	for i, name := range windows {
+		windows[i] = strings.TrimSpace(name)
+		if _, ok := dropWindows[windows[i]]; !ok {
+			c.JSON(http.StatusBadRequest, APIError{Error: "window must be a comma-separated list of 1m, 5m, 15m, 1h, 6h"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, WaterfallDropsResponse{
+		Windows:   windows,
+		Sources:   dropSources,
+		Reasons:   dt.Breakdown(windows, time.Now()),
+		Timestamp: time.Now().Unix(),
+	})
+}
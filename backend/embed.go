@@ -0,0 +1,443 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Embed tokens let an operator put a live status widget on a public website
+// without exposing the API. An admin mints a signed, expiring token for a
+// set of topics (within EMBED_TOPICS) and optionally the sites allowed to
+// frame it; /embed?token= serves a read-only widget page and
+// /embed/stream?token= an SSE stream of just those topics. Neither accepts
+// client input beyond the token, so an embed can only watch. Tokens are
+// signed with EMBED_SECRET under their own domain, so they are never
+// accepted as session tokens (or the reverse) even with a shared secret;
+// rotating EMBED_SECRET revokes every embed token.
+
+// Default embed token settings
+const (
+	defaultEmbedTTL        = 30 * 24 * time.Hour
+	defaultEmbedMaxTTL     = 365 * 24 * time.Hour
+	defaultEmbedMaxClients = 100
+)
+
+// defaultEmbedTopics are the topics embeds may carry unless EMBED_TOPICS says otherwise
+var defaultEmbedTopics = []string{"summary", "epoch"}
+
+// EmbedClaims is the signed payload of an embed token
+type EmbedClaims struct {
+	Subject   string   `json:"sub"` // Shown as the widget title
+	Topics    []string `json:"topics"`
+	Origins   []string `json:"origins,omitempty"` // Sites allowed to frame the widget; empty allows any
+	IssuedAt  int64    `json:"iat"`
+	ExpiresAt int64    `json:"exp"`
+}
+
+// Expiry returns the claims' expiry time
+func (e EmbedClaims) Expiry() time.Time {
+	return time.Unix(e.ExpiresAt, 0)
+}
+
+// EmbedAuth issues and validates embed tokens
+type EmbedAuth struct {
+	secret     []byte
+	topics     map[string]bool // Topics an embed token may grant
+	maxTTL     time.Duration
+	maxClients int64
+	baseURL    string // External URL of the dashboard for embed links (EMBED_BASE_URL)
+
+	clients atomic.Int64
+	served  atomic.Int64
+}
+
+// Global embed auth instance (nil when embedding is disabled)
+var embedAuth *EmbedAuth
+
+// InitializeEmbedAuth enables embed tokens if EMBED_SECRET is set
+func InitializeEmbedAuth() {
+	secret := os.Getenv("EMBED_SECRET")
+	if secret == "" {
+		return
+	}
+
+	a := &EmbedAuth{
+		secret:     []byte(secret),
+		topics:     make(map[string]bool),
+		maxTTL:     defaultEmbedMaxTTL,
+		maxClients: defaultEmbedMaxClients,
+		baseURL:    strings.TrimSuffix(os.Getenv("EMBED_BASE_URL"), "/"),
+	}
+	topics := defaultEmbedTopics
+	if value := os.Getenv("EMBED_TOPICS"); value != "" {
+		topics = splitList(value)
+	}
+	for _, topic := range topics {
+		a.topics[topic] = true
+	}
+	if value := os.Getenv("EMBED_MAX_TTL"); value != "" {
+		if parsed, err := time.ParseDuration(value); err == nil && parsed > 0 {
+			a.maxTTL = parsed
+		} else {
+			log.Printf("Invalid EMBED_MAX_TTL %q, using %s", value, a.maxTTL)
+		}
+	}
+	if value := os.Getenv("EMBED_MAX_CLIENTS"); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			a.maxClients = int64(n)
+		} else {
+			log.Printf("Invalid EMBED_MAX_CLIENTS %q, using %d", value, a.maxClients)
+		}
+	}
+
+	embedAuth = a
+	log.Printf("✅ Embed tokens enabled (topics %s, up to %d viewers)", strings.Join(topics, ","), a.maxClients)
+}
+
+// GetEmbedAuth returns the global embed auth instance, or nil when disabled
+func GetEmbedAuth() *EmbedAuth {
+	return embedAuth
+}
+
+// sign returns the base64url HMAC-SHA256 of payload in the embed domain
+func (a *EmbedAuth) sign(payload string) string {
+	mac := hmac.New(sha256.New, a.secret)
+	mac.Write([]byte("embed."))
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Issue mints a token for claims, filling in its issue time
+func (a *EmbedAuth) Issue(claims EmbedClaims) string {
+	claims.IssuedAt = time.Now().Unix()
+	body, _ := json.Marshal(claims)
+	payload := base64.RawURLEncoding.EncodeToString(body)
+	return payload + "." + a.sign(payload)
+}
+
+// Validate checks a token's signature and expiry. Topics no longer allowed
+// by EMBED_TOPICS are removed from the returned claims.
+func (a *EmbedAuth) Validate(token string) (EmbedClaims, error) {
+	var claims EmbedClaims
+	if token == "" {
+		return claims, errTokenMissing
+	}
+
+	payload, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return claims, errTokenMalformed
+	}
+	if subtle.ConstantTimeCompare([]byte(signature), []byte(a.sign(payload))) != 1 {
+		return claims, errTokenSignature
+	}
+
+	body, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return claims, errTokenMalformed
+	}
+	if err := json.Unmarshal(body, &claims); err != nil {
+		return claims, errTokenMalformed
+	}
+	if time.Now().After(claims.Expiry()) {
+		return claims, errTokenExpired
+	}
+
+	allowed := claims.Topics[:0]
+	for _, topic := range claims.Topics {
+		if a.topics[topic] {
+			allowed = append(allowed, topic)
+		}
+	}
+	claims.Topics = allowed
+	return claims, nil
+}
+
+// parseEmbedOrigin validates a site allowed to frame the widget, e.g.
+// https://validator.example.com
+func parseEmbedOrigin(origin string) (string, error) {
+	u, err := url.Parse(origin)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" || (u.Path != "" && u.Path != "/") || u.RawQuery != "" {
+		return "", fmt.Errorf("invalid origin %q (expected e.g. https://example.com)", origin)
+	}
+	return u.Scheme + "://" + u.Host, nil
+}
+
+// embedURL returns the absolute widget URL for a token, from EMBED_BASE_URL
+// or the request that minted it
+func (a *EmbedAuth) embedURL(c *gin.Context, token string) string {
+	base := a.baseURL
+	if base == "" {
+		scheme := "http"
+		if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+			scheme = "https"
+		}
+		base = scheme + "://" + c.Request.Host
+	}
+	return base + "/embed?token=" + url.QueryEscape(token)
+}
+
+// EmbedTokenRequest is the body of POST /api/v1/admin/embed/tokens
+type EmbedTokenRequest struct {
+	Subject string   `json:"subject,omitempty"` // Widget title; default the node name
+	Topics  []string `json:"topics,omitempty"`  // Default ["summary"]
+	Origins []string `json:"origins,omitempty"` // Sites allowed to frame the widget; default any
+	TTL     string   `json:"ttl,omitempty"`     // Go duration, default 720h
+}
+
+// EmbedTokenResponse is a minted embed token with ready-to-use links
+type EmbedTokenResponse struct {
+	Token     string   `json:"token"`
+	Subject   string   `json:"subject"`
+	Topics    []string `json:"topics"`
+	Origins   []string `json:"origins,omitempty"`
+	ExpiresAt int64    `json:"expires_at"` // Unix seconds
+	URL       string   `json:"url"`        // Widget page
+	IFrame    string   `json:"iframe"`     // HTML snippet for the operator's site
+}
+
+// handleIssueEmbedToken mints an embed token (admin)
+func handleIssueEmbedToken(c *gin.Context) {
+	a := GetEmbedAuth()
+	if a == nil {
+		c.JSON(http.StatusNotFound, APIError{Error: "Embedding disabled (EMBED_SECRET not set)"})
+		return
+	}
+
+	var req EmbedTokenRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, APIError{Error: "Invalid request body"})
+			return
+		}
+	}
+
+	claims := EmbedClaims{Subject: req.Subject, Topics: req.Topics}
+	if claims.Subject == "" {
+		claims.Subject = getNodeName()
+	}
+	if len(claims.Topics) == 0 {
+		claims.Topics = []string{"summary"}
+	}
+	for _, topic := range claims.Topics {
+		if !a.topics[topic] {
+			c.JSON(http.StatusBadRequest, APIError{Error: fmt.Sprintf("Topic %q cannot be embedded (EMBED_TOPICS)", topic)})
+			return
+		}
+	}
+	for _, origin := range req.Origins {
+		parsed, err := parseEmbedOrigin(origin)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, APIError{Error: err.Error()})
+			return
+		}
+		claims.Origins = append(claims.Origins, parsed)
+	}
+
+	ttl := defaultEmbedTTL
+	if req.TTL != "" {
+		parsed, err := time.ParseDuration(req.TTL)
+		if err != nil || parsed <= 0 {
+			c.JSON(http.StatusBadRequest, APIError{Error: "Invalid ttl (expected a duration such as 720h)"})
+			return
+		}
+		ttl = parsed
+	}
+	if ttl > a.maxTTL {
+		c.JSON(http.StatusBadRequest, APIError{Error: fmt.Sprintf("ttl exceeds EMBED_MAX_TTL (%s)", a.maxTTL)})
+		return
+	}
+	claims.ExpiresAt = time.Now().Add(ttl).Unix()
+
+	token := a.Issue(claims)
+	link := a.embedURL(c, token)
+	c.JSON(http.StatusOK, EmbedTokenResponse{
+		Token:     token,
+		Subject:   claims.Subject,
+		Topics:    claims.Topics,
+		Origins:   claims.Origins,
+		ExpiresAt: claims.ExpiresAt,
+		URL:       link,
+		IFrame:    fmt.Sprintf(`<iframe src="%s" width="360" height="220" style="border:0" loading="lazy" title="%s"></iframe>`, template.HTMLEscapeString(link), template.HTMLEscapeString(claims.Subject)),
+	})
+}
+
+// embedClaims validates the token of an embed request, writing the error
+// response itself when it is not usable
+func embedClaims(c *gin.Context) (EmbedClaims, bool) {
+	a := GetEmbedAuth()
+	if a == nil {
+		c.String(http.StatusNotFound, "Embedding disabled")
+		return EmbedClaims{}, false
+	}
+	claims, err := a.Validate(c.Query("token"))
+	if err != nil {
+		c.String(http.StatusUnauthorized, err.Error())
+		return EmbedClaims{}, false
+	}
+	if len(claims.Topics) == 0 {
+		c.String(http.StatusForbidden, "No embeddable topics")
+		return EmbedClaims{}, false
+	}
+	return claims, true
+}
+
+// handleEmbedWidget serves the read-only status widget for an embed token
+func handleEmbedWidget(c *gin.Context) {
+	claims, ok := embedClaims(c)
+	if !ok {
+		return
+	}
+
+	ancestors := "*"
+	if len(claims.Origins) > 0 {
+		ancestors = strings.Join(claims.Origins, " ")
+	}
+	nonce := randomID(16)
+	c.Header("Content-Security-Policy", fmt.Sprintf(
+		"default-src 'none'; connect-src 'self'; style-src 'nonce-%s'; script-src 'nonce-%s'; frame-ancestors %s", nonce, nonce, ancestors))
+	c.Header("Referrer-Policy", "no-referrer")
+	c.Header("Cache-Control", "no-store")
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Status(http.StatusOK)
+
+	embedWidgetTemplate.Execute(c.Writer, map[string]interface{}{
+		"Title":  claims.Subject,
+		"Nonce":  nonce,
+		"Stream": "/embed/stream?token=" + url.QueryEscape(c.Query("token")),
+		"Epoch":  containsString(claims.Topics, "epoch"),
+	})
+}
+
+// handleEmbedStream streams the token's topics over Server-Sent Events.
+// ?topics= narrows them further.
+func handleEmbedStream(c *gin.Context) {
+	claims, ok := embedClaims(c)
+	if !ok {
+		return
+	}
+	a := GetEmbedAuth()
+
+	topics := make(map[string]bool)
+	requested := parseTopicFilter(c.Query("topics"))
+	for _, topic := range claims.Topics {
+		if len(requested) == 0 || requested[topic] {
+			topics[topic] = true
+		}
+	}
+	if len(topics) == 0 {
+		c.String(http.StatusForbidden, "No embeddable topics requested")
+		return
+	}
+
+	if a.clients.Add(1) > a.maxClients {
+		a.clients.Add(-1)
+		c.Header("Retry-After", "30")
+		c.String(http.StatusServiceUnavailable, "Too many embed viewers")
+		return
+	}
+	defer a.clients.Add(-1)
+	a.served.Add(1)
+
+	streamSSE(c, topics, time.After(time.Until(claims.Expiry())))
+}
+
+// EmbedStatusResponse is the body of /api/v1/embed
+type EmbedStatusResponse struct {
+	Enabled    bool     `json:"enabled"`
+	Topics     []string `json:"topics,omitempty"` // Topics embed tokens may grant
+	Viewers    int64    `json:"viewers"`
+	MaxViewers int64    `json:"max_viewers,omitempty"`
+	Served     int64    `json:"served"` // Embed streams opened since startup
+}
+
+// handleEmbedStatus reports whether embedding is enabled and its viewers
+func handleEmbedStatus(c *gin.Context) {
+	a := GetEmbedAuth()
+	if a == nil {
+		c.JSON(http.StatusOK, EmbedStatusResponse{})
+		return
+	}
+	status := EmbedStatusResponse{
+		Enabled:    true,
+		Viewers:    a.clients.Load(),
+		MaxViewers: a.maxClients,
+		Served:     a.served.Load(),
+	}
+	for topic := range a.topics {
+		status.Topics = append(status.Topics, topic)
+	}
+	sort.Strings(status.Topics)
+	c.JSON(http.StatusOK, status)
+}
+
+// embedWidgetTemplate is the widget page; it only listens to the stream
+var embedWidgetTemplate = template.Must(template.New("embed").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style nonce="{{.Nonce}}">
+  body { margin: 0; font: 14px/1.4 system-ui, sans-serif; background: #111318; color: #e6e8ee; }
+  .card { padding: 14px 16px; }
+  h1 { font-size: 15px; margin: 0 0 10px; display: flex; align-items: center; gap: 8px; }
+  .dot { width: 9px; height: 9px; border-radius: 50%; background: #666; }
+  .dot.live { background: #3ecf8e; }
+  dl { display: grid; grid-template-columns: auto 1fr; gap: 4px 14px; margin: 0; }
+  dt { color: #8b90a0; }
+  dd { margin: 0; font-variant-numeric: tabular-nums; text-align: right; }
+  .hidden { display: none; }
+</style>
+</head>
+<body>
+<div class="card">
+  <h1><span class="dot" id="status"></span>{{.Title}}</h1>
+  <dl>
+    <dt>Block</dt><dd id="block">–</dd>
+    <dt>Finalized</dt><dd id="finalized">–</dd>
+    <dt>TPS</dt><dd id="tps">–</dd>
+    <dt>Vote state</dt><dd id="vote">–</dd>
+    <dt class="{{if not .Epoch}}hidden{{end}}">Epoch</dt><dd class="{{if not .Epoch}}hidden{{end}}" id="epoch">–</dd>
+  </dl>
+</div>
+<script nonce="{{.Nonce}}">
+(function () {
+  var set = function (id, value) { document.getElementById(id).textContent = value; };
+  var number = function (n) { return typeof n === "number" ? n.toLocaleString() : "–"; };
+  var stream = new EventSource({{.Stream}});
+  stream.onopen = function () { document.getElementById("status").className = "dot live"; };
+  stream.onerror = function () { document.getElementById("status").className = "dot"; };
+  stream.addEventListener("summary", function (e) {
+    var msg = JSON.parse(e.data);
+    switch (msg.key) {
+      case "completed_slot": set("block", number(msg.value)); break;
+      case "finalized_slot": set("finalized", number(msg.value)); break;
+      case "estimated_tps": if (msg.value) set("tps", msg.value.total.toFixed(1)); break;
+      case "vote_state": set("vote", msg.value); break;
+    }
+  });
+  stream.addEventListener("epoch", function (e) {
+    var msg = JSON.parse(e.data);
+    if (msg.value && msg.value.epoch !== undefined) set("epoch", number(msg.value.epoch));
+  });
+})();
+</script>
+</body>
+</html>
+`))
@@ -0,0 +1,639 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"hash/fnv"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// dialBroker connects to host:port, optionally over TLS, bounded by ctx
+func dialBroker(ctx context.Context, addr string, useTLS bool) (net.Conn, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	if useTLS {
+		host, _, _ := net.SplitHostPort(addr)
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: host})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		conn = tlsConn
+	}
+	return conn, nil
+}
+
+// ---- Kafka ----
+//
+// Minimal producer speaking the Kafka protocol directly: Metadata (v8) to
+// find each partition's leader and Produce (v7, acks=all) with v2 record
+// batches. A message goes to the partition picked by the FNV-1a hash of its
+// key, so one block's or one component's events stay in order.
+
+// Kafka API keys and versions used
+const (
+	kafkaProduce         = 0
+	kafkaProduceVersion  = 7
+	kafkaMetadata        = 3
+	kafkaMetadataVersion = 8
+)
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// kafkaConn is a connection to one broker
+type kafkaConn struct {
+	conn net.Conn
+	rd   *bufio.Reader
+}
+
+// kafkaClient produces to a Kafka cluster
+type kafkaClient struct {
+	bootstrap []string
+	useTLS    bool
+
+	mu          sync.Mutex
+	conns       map[string]*kafkaConn // By broker address
+	brokers     map[int32]string      // Node ID -> address
+	leaders     map[string][]int32    // Topic -> leader node ID per partition
+	correlation int32
+}
+
+// newKafkaClient parses kafka://host:9092[,host:9092] (kafka+tls:// for TLS)
+func newKafkaClient(rawURL string) (*kafkaClient, error) {
+	c := &kafkaClient{
+		conns:   make(map[string]*kafkaConn),
+		brokers: make(map[int32]string),
+		leaders: make(map[string][]int32),
+	}
+	switch {
+	case strings.HasPrefix(rawURL, "kafka+tls://"):
+		c.useTLS = true
+		rawURL = strings.TrimPrefix(rawURL, "kafka+tls://")
+	case strings.HasPrefix(rawURL, "kafka://"):
+		rawURL = strings.TrimPrefix(rawURL, "kafka://")
+	case strings.Contains(rawURL, "://"):
+		return nil, fmt.Errorf("Kafka URL must be kafka:// or kafka+tls://")
+	}
+	for _, broker := range splitList(strings.TrimSuffix(rawURL, "/")) {
+		if _, _, err := net.SplitHostPort(broker); err != nil {
+			broker = net.JoinHostPort(broker, "9092")
+		}
+		c.bootstrap = append(c.bootstrap, broker)
+	}
+	if len(c.bootstrap) == 0 {
+		return nil, fmt.Errorf("no Kafka brokers in %q", rawURL)
+	}
+	return c, nil
+}
+
+// Name identifies the client
+func (c *kafkaClient) Name() string {
+	return "kafka"
+}
+
+// conn returns the connection to addr, dialing it if needed; caller holds c.mu
+func (c *kafkaClient) conn(ctx context.Context, addr string) (*kafkaConn, error) {
+	if kc, ok := c.conns[addr]; ok {
+		return kc, nil
+	}
+	conn, err := dialBroker(ctx, addr, c.useTLS)
+	if err != nil {
+		return nil, err
+	}
+	kc := &kafkaConn{conn: conn, rd: bufio.NewReader(conn)}
+	c.conns[addr] = kc
+	return kc, nil
+}
+
+// roundTrip sends one request to addr and returns the response body after
+// the correlation ID; a failed connection is closed. Caller holds c.mu.
+func (c *kafkaClient) roundTrip(ctx context.Context, addr string, apiKey, version int16, body []byte) ([]byte, error) {
+	kc, err := c.conn(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+	c.correlation++
+	correlation := c.correlation
+
+	req := make([]byte, 4, 4+14+len(body))
+	req = binary.BigEndian.AppendUint16(req, uint16(apiKey))
+	req = binary.BigEndian.AppendUint16(req, uint16(version))
+	req = binary.BigEndian.AppendUint32(req, uint32(correlation))
+	req = appendKafkaString(req, "monad-dashboard")
+	req = append(req, body...)
+	binary.BigEndian.PutUint32(req, uint32(len(req)-4))
+
+	resp, err := func() ([]byte, error) {
+		deadline, ok := ctx.Deadline()
+		if !ok {
+			deadline = time.Now().Add(30 * time.Second)
+		}
+		kc.conn.SetDeadline(deadline)
+		if _, err := kc.conn.Write(req); err != nil {
+			return nil, err
+		}
+		var size [4]byte
+		if _, err := io.ReadFull(kc.rd, size[:]); err != nil {
+			return nil, err
+		}
+		n := binary.BigEndian.Uint32(size[:])
+		if n < 4 || n > 64<<20 {
+			return nil, fmt.Errorf("invalid Kafka response size %d", n)
+		}
+		resp := make([]byte, n)
+		if _, err := io.ReadFull(kc.rd, resp); err != nil {
+			return nil, err
+		}
+		if got := int32(binary.BigEndian.Uint32(resp)); got != correlation {
+			return nil, fmt.Errorf("Kafka correlation ID %d, expected %d", got, correlation)
+		}
+		return resp[4:], nil
+	}()
+	if err != nil {
+		kc.conn.Close()
+		delete(c.conns, addr)
+	}
+	return resp, err
+}
+
+// refreshMetadata loads the brokers and the partition leaders of topics
+// from the first bootstrap or known broker that answers; caller holds c.mu
+func (c *kafkaClient) refreshMetadata(ctx context.Context, topics []string) error {
+	body := binary.BigEndian.AppendUint32(nil, uint32(len(topics)))
+	for _, topic := range topics {
+		body = appendKafkaString(body, topic)
+	}
+	body = append(body, 1, 0, 0) // allow_auto_topic_creation, no authorized operations
+
+	addrs := append([]string{}, c.bootstrap...)
+	for _, addr := range c.brokers {
+		addrs = append(addrs, addr)
+	}
+	var lastErr error
+	for _, addr := range addrs {
+		resp, err := c.roundTrip(ctx, addr, kafkaMetadata, kafkaMetadataVersion, body)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		return c.parseMetadata(resp)
+	}
+	return fmt.Errorf("metadata: %w", lastErr)
+}
+
+// parseMetadata stores a Metadata v8 response; caller holds c.mu
+func (c *kafkaClient) parseMetadata(resp []byte) error {
+	r := &kafkaReader{buf: resp}
+	r.int32() // throttle_time_ms
+	brokers := make(map[int32]string)
+	for i := r.arrayLen(); i > 0; i-- {
+		node := r.int32()
+		host := r.string()
+		port := r.int32()
+		r.string() // rack
+		brokers[node] = net.JoinHostPort(host, strconv.Itoa(int(port)))
+	}
+	r.string() // cluster_id
+	r.int32()  // controller_id
+
+	leaders := make(map[string][]int32)
+	var topicErr error
+	for i := r.arrayLen(); i > 0; i-- {
+		code := r.int16()
+		name := r.string()
+		r.int8() // is_internal
+		partitions := make(map[int32]int32)
+		for j := r.arrayLen(); j > 0; j-- {
+			r.int16() // error_code
+			index := r.int32()
+			leader := r.int32()
+			r.int32() // leader_epoch
+			r.skipInt32Array()
+			r.skipInt32Array()
+			r.skipInt32Array()
+			partitions[index] = leader
+		}
+		r.int32() // topic_authorized_operations
+		if code != 0 {
+			topicErr = fmt.Errorf("topic %s: Kafka error %d", name, code)
+			continue
+		}
+		list := make([]int32, len(partitions))
+		for index, leader := range partitions {
+			if int(index) < len(list) {
+				list[index] = leader
+			}
+		}
+		leaders[name] = list
+	}
+	if r.err != nil {
+		return fmt.Errorf("metadata: %w", r.err)
+	}
+
+	c.brokers = brokers
+	for name, list := range leaders {
+		c.leaders[name] = list
+	}
+	return topicErr
+}
+
+// Publish produces msgs, one request per partition leader
+func (c *kafkaClient) Publish(ctx context.Context, msgs []busMessage) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var missing []string
+	for _, msg := range msgs {
+		if _, ok := c.leaders[msg.topic]; !ok && !containsString(missing, msg.topic) {
+			missing = append(missing, msg.topic)
+		}
+	}
+	if len(missing) > 0 {
+		if err := c.refreshMetadata(ctx, missing); err != nil {
+			return err
+		}
+	}
+
+	// Leader -> topic -> partition -> messages
+	byLeader := make(map[int32]map[string]map[int32][]busMessage)
+	for _, msg := range msgs {
+		leaders := c.leaders[msg.topic]
+		if len(leaders) == 0 {
+			return fmt.Errorf("topic %s has no partitions", msg.topic)
+		}
+		h := fnv.New32a()
+		h.Write(msg.key)
+		partition := int32(h.Sum32() % uint32(len(leaders)))
+		leader := leaders[partition]
+		if byLeader[leader] == nil {
+			byLeader[leader] = make(map[string]map[int32][]busMessage)
+		}
+		if byLeader[leader][msg.topic] == nil {
+			byLeader[leader][msg.topic] = make(map[int32][]busMessage)
+		}
+		byLeader[leader][msg.topic][partition] = append(byLeader[leader][msg.topic][partition], msg)
+	}
+
+	for leader, topics := range byLeader {
+		addr, ok := c.brokers[leader]
+		if !ok {
+			c.leaders = make(map[string][]int32)
+			return fmt.Errorf("partition leader %d unknown", leader)
+		}
+		if err := c.produce(ctx, addr, topics); err != nil {
+			// Leadership may have moved; look it up again next time
+			c.leaders = make(map[string][]int32)
+			return err
+		}
+	}
+	return nil
+}
+
+// produce sends one Produce request to a leader and checks every
+// partition's result; caller holds c.mu
+func (c *kafkaClient) produce(ctx context.Context, addr string, topics map[string]map[int32][]busMessage) error {
+	body := binary.BigEndian.AppendUint16(nil, 0xffff) // No transactional_id
+	body = binary.BigEndian.AppendUint16(body, 0xffff) // acks=all
+	body = binary.BigEndian.AppendUint32(body, 10000)  // timeout_ms
+	body = binary.BigEndian.AppendUint32(body, uint32(len(topics)))
+	for topic, partitions := range topics {
+		body = appendKafkaString(body, topic)
+		body = binary.BigEndian.AppendUint32(body, uint32(len(partitions)))
+		for partition, msgs := range partitions {
+			batch := kafkaRecordBatch(msgs, time.Now())
+			body = binary.BigEndian.AppendUint32(body, uint32(partition))
+			body = binary.BigEndian.AppendUint32(body, uint32(len(batch)))
+			body = append(body, batch...)
+		}
+	}
+
+	resp, err := c.roundTrip(ctx, addr, kafkaProduce, kafkaProduceVersion, body)
+	if err != nil {
+		return err
+	}
+	r := &kafkaReader{buf: resp}
+	for i := r.arrayLen(); i > 0; i-- {
+		topic := r.string()
+		for j := r.arrayLen(); j > 0; j-- {
+			partition := r.int32()
+			code := r.int16()
+			r.int64() // base_offset
+			r.int64() // log_append_time_ms
+			r.int64() // log_start_offset
+			if code != 0 && r.err == nil {
+				return fmt.Errorf("produce to %s/%d: Kafka error %d", topic, partition, code)
+			}
+		}
+	}
+	return r.err
+}
+
+// kafkaRecordBatch encodes msgs as one v2 record batch
+func kafkaRecordBatch(msgs []busMessage, now time.Time) []byte {
+	ts := now.UnixMilli()
+
+	var records []byte
+	for i, msg := range msgs {
+		var rec []byte
+		rec = append(rec, 0)                     // attributes
+		rec = binary.AppendVarint(rec, 0)        // timestamp_delta
+		rec = binary.AppendVarint(rec, int64(i)) // offset_delta
+		if len(msg.key) == 0 {
+			rec = binary.AppendVarint(rec, -1)
+		} else {
+			rec = binary.AppendVarint(rec, int64(len(msg.key)))
+			rec = append(rec, msg.key...)
+		}
+		rec = binary.AppendVarint(rec, int64(len(msg.value)))
+		rec = append(rec, msg.value...)
+		rec = binary.AppendVarint(rec, 0) // headers
+		records = binary.AppendVarint(records, int64(len(rec)))
+		records = append(records, rec...)
+	}
+
+	// Everything after the CRC, which covers it
+	var tail []byte
+	tail = binary.BigEndian.AppendUint16(tail, 0)                   // attributes: no compression, create time
+	tail = binary.BigEndian.AppendUint32(tail, uint32(len(msgs)-1)) // last_offset_delta
+	tail = binary.BigEndian.AppendUint64(tail, uint64(ts))          // base_timestamp
+	tail = binary.BigEndian.AppendUint64(tail, uint64(ts))          // max_timestamp
+	tail = binary.BigEndian.AppendUint64(tail, ^uint64(0))          // producer_id -1
+	tail = binary.BigEndian.AppendUint16(tail, 0xffff)              // producer_epoch -1
+	tail = binary.BigEndian.AppendUint32(tail, 0xffffffff)          // base_sequence -1
+	tail = binary.BigEndian.AppendUint32(tail, uint32(len(msgs)))
+	tail = append(tail, records...)
+
+	batch := binary.BigEndian.AppendUint64(nil, 0)                        // base_offset
+	batch = binary.BigEndian.AppendUint32(batch, uint32(4+1+4+len(tail))) // batch_length
+	batch = binary.BigEndian.AppendUint32(batch, 0xffffffff)              // partition_leader_epoch -1
+	batch = append(batch, 2)                                              // magic
+	batch = binary.BigEndian.AppendUint32(batch, crc32.Checksum(tail, castagnoli))
+	return append(batch, tail...)
+}
+
+// appendKafkaString appends an int16-length string
+func appendKafkaString(b []byte, s string) []byte {
+	b = binary.BigEndian.AppendUint16(b, uint16(len(s)))
+	return append(b, s...)
+}
+
+// kafkaReader decodes a response; the first error sticks and later reads
+// return zero values
+type kafkaReader struct {
+	buf []byte
+	pos int
+	err error
+}
+
+func (r *kafkaReader) take(n int) []byte {
+	if r.err != nil || n < 0 || r.pos+n > len(r.buf) {
+		if r.err == nil {
+			r.err = errors.New("truncated Kafka response")
+		}
+		return nil
+	}
+	b := r.buf[r.pos : r.pos+n]
+	r.pos += n
+	return b
+}
+
+func (r *kafkaReader) int8() int8 {
+	if b := r.take(1); b != nil {
+		return int8(b[0])
+	}
+	return 0
+}
+
+func (r *kafkaReader) int16() int16 {
+	if b := r.take(2); b != nil {
+		return int16(binary.BigEndian.Uint16(b))
+	}
+	return 0
+}
+
+func (r *kafkaReader) int32() int32 {
+	if b := r.take(4); b != nil {
+		return int32(binary.BigEndian.Uint32(b))
+	}
+	return 0
+}
+
+func (r *kafkaReader) int64() int64 {
+	if b := r.take(8); b != nil {
+		return int64(binary.BigEndian.Uint64(b))
+	}
+	return 0
+}
+
+// string reads a (nullable) int16-length string
+func (r *kafkaReader) string() string {
+	n := r.int16()
+	if n < 0 {
+		return ""
+	}
+	return string(r.take(int(n)))
+}
+
+// arrayLen reads an array length, 0 for a null array
+func (r *kafkaReader) arrayLen() int {
+	n := r.int32()
+	if n < 0 || r.err != nil {
+		return 0
+	}
+	return int(n)
+}
+
+func (r *kafkaReader) skipInt32Array() {
+	r.take(4 * r.arrayLen())
+}
+
+// Close closes every broker connection
+func (c *kafkaClient) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for addr, kc := range c.conns {
+		kc.conn.Close()
+		delete(c.conns, addr)
+	}
+}
+
+// ---- NATS ----
+//
+// Minimal NATS publisher speaking the text protocol: CONNECT, then PUB per
+// message followed by a PING whose PONG confirms the server processed the
+// batch (and surfaces -ERR, e.g. a permissions violation).
+
+// natsClient publishes to a NATS server
+type natsClient struct {
+	addr   string
+	useTLS bool
+	user   string
+	pass   string
+	token  string
+
+	mu   sync.Mutex
+	conn net.Conn
+	rd   *bufio.Reader
+}
+
+// newNATSClient parses nats://[user:password@|token@]host:4222 (tls:// for TLS)
+func newNATSClient(rawURL string) (*natsClient, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "nats" && u.Scheme != "tls" {
+		return nil, fmt.Errorf("NATS URL must be nats:// or tls://, got %q", u.Scheme)
+	}
+	c := &natsClient{addr: u.Host, useTLS: u.Scheme == "tls"}
+	if u.Port() == "" {
+		c.addr = net.JoinHostPort(u.Hostname(), "4222")
+	}
+	if u.User != nil {
+		if pass, ok := u.User.Password(); ok {
+			c.user, c.pass = u.User.Username(), pass
+		} else {
+			c.token = u.User.Username()
+		}
+	}
+	return c, nil
+}
+
+// Name identifies the client
+func (c *natsClient) Name() string {
+	return "nats"
+}
+
+// connect opens and authenticates a connection; caller holds c.mu
+func (c *natsClient) connect(ctx context.Context) error {
+	conn, err := dialBroker(ctx, c.addr, false)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	rd := bufio.NewReader(conn)
+	line, err := rd.ReadString('\n')
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	if !strings.HasPrefix(line, "INFO ") {
+		conn.Close()
+		return fmt.Errorf("unexpected NATS greeting %q", strings.TrimSpace(line))
+	}
+	var info struct {
+		TLSRequired bool `json:"tls_required"`
+	}
+	json.Unmarshal([]byte(strings.TrimPrefix(line, "INFO ")), &info)
+
+	if c.useTLS || info.TLSRequired {
+		host, _, _ := net.SplitHostPort(c.addr)
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: host})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return err
+		}
+		conn, rd = tlsConn, bufio.NewReader(tlsConn)
+	}
+
+	options, _ := json.Marshal(map[string]interface{}{
+		"verbose": false, "pedantic": false, "name": "monad-dashboard", "lang": "go", "version": "1", "protocol": 1,
+		"user": c.user, "pass": c.pass, "auth_token": c.token,
+	})
+	c.conn, c.rd = conn, rd
+	if _, err := fmt.Fprintf(conn, "CONNECT %s\r\nPING\r\n", options); err != nil {
+		c.close()
+		return err
+	}
+	if err := c.awaitPong(); err != nil {
+		c.close()
+		return err
+	}
+	return nil
+}
+
+// awaitPong reads until the server's PONG, answering its PINGs; caller holds c.mu
+func (c *natsClient) awaitPong() error {
+	for {
+		line, err := c.rd.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			if _, err := io.WriteString(c.conn, "PONG\r\n"); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return fmt.Errorf("NATS %s", line)
+		}
+	}
+}
+
+// Publish sends msgs and waits for the server to confirm them
+func (c *natsClient) Publish(ctx context.Context, msgs []busMessage) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn == nil {
+		if err := c.connect(ctx); err != nil {
+			return err
+		}
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		c.conn.SetDeadline(deadline)
+	}
+
+	w := bufio.NewWriter(c.conn)
+	for _, msg := range msgs {
+		fmt.Fprintf(w, "PUB %s %d\r\n", msg.topic, len(msg.value))
+		w.Write(msg.value)
+		w.WriteString("\r\n")
+	}
+	w.WriteString("PING\r\n")
+	err := w.Flush()
+	if err == nil {
+		err = c.awaitPong()
+	}
+	if err != nil {
+		c.close()
+	}
+	return err
+}
+
+// close drops the connection; caller holds c.mu
+func (c *natsClient) close() {
+	if c.conn != nil {
+		c.conn.Close()
+		c.conn, c.rd = nil, nil
+	}
+}
+
+// Close closes the connection
+func (c *natsClient) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.close()
+}
@@ -0,0 +1,496 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"hash/fnv"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestKafkaRecordBatch encodes a v2 record batch byte for byte as the Kafka
+// protocol specifies it
+func TestKafkaRecordBatch(t *testing.T) {
+	batch := kafkaRecordBatch([]busMessage{
+		{topic: "blocks", key: []byte("k"), value: []byte("v")},
+		{topic: "blocks", value: []byte("{}")},
+	}, time.UnixMilli(1700000000000))
+
+	want := "0000000000000000" + // base_offset
+		"00000043" + // batch_length
+		"ffffffff" + // partition_leader_epoch
+		"02" + // magic
+		"897e8602" + // crc (CRC-32C of the rest)
+		"0000" + // attributes
+		"00000001" + // last_offset_delta
+		"0000018bcfe56800" + // base_timestamp
+		"0000018bcfe56800" + // max_timestamp
+		"ffffffffffffffff" + // producer_id
+		"ffff" + // producer_epoch
+		"ffffffff" + // base_sequence
+		"00000002" + // records
+		"10" + "00" + "00" + "00" + "02" + "6b" + "02" + "76" + "00" + // length 8, key "k", value "v"
+		"10" + "00" + "00" + "02" + "01" + "04" + "7b7d" + "00" // length 8, offset 1, null key, value "{}"
+	if got := hex.EncodeToString(batch); got != want {
+		t.Fatalf("batch\n got %s\nwant %s", got, want)
+	}
+
+	msgs, err := decodeKafkaRecordBatch(batch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != 2 || string(msgs[0].key) != "k" || string(msgs[0].value) != "v" || msgs[1].key != nil || string(msgs[1].value) != "{}" {
+		t.Fatalf("decoded %q", msgs)
+	}
+}
+
+// TestKafkaPublish looks up partition leaders and produces to them through
+// a fake broker, and forgets the leaders when a partition reports an error
+func TestKafkaPublish(t *testing.T) {
+	var produced map[int32][]busMessage
+	produceError := int16(0)
+	broker := serveFakeKafka(t, func(apiKey, version int16, r *kafkaReader, self kafkaTestBroker) []byte {
+		switch apiKey {
+		case kafkaMetadata:
+			if version != kafkaMetadataVersion {
+				t.Errorf("metadata version %d", version)
+			}
+			if n := r.arrayLen(); n != 1 || r.string() != "blocks" {
+				t.Errorf("metadata for %d topics", n)
+			}
+			return kafkaMetadataResponse(self, "blocks", 2, 0)
+
+		case kafkaProduce:
+			if version != kafkaProduceVersion {
+				t.Errorf("produce version %d", version)
+			}
+			if r.int16() != -1 || r.int16() != -1 {
+				t.Error("want no transactional_id and acks=all")
+			}
+			r.int32() // timeout_ms
+			produced = make(map[int32][]busMessage)
+			resp := binary.BigEndian.AppendUint32(nil, uint32(r.arrayLen()))
+			topic := r.string()
+			resp = appendKafkaString(resp, topic)
+			partitions := r.arrayLen()
+			resp = binary.BigEndian.AppendUint32(resp, uint32(partitions))
+			for ; partitions > 0; partitions-- {
+				partition := r.int32()
+				msgs, err := decodeKafkaRecordBatch(r.take(int(r.int32())))
+				if err != nil {
+					t.Error(err)
+				}
+				produced[partition] = msgs
+				resp = binary.BigEndian.AppendUint32(resp, uint32(partition))
+				resp = binary.BigEndian.AppendUint16(resp, uint16(produceError))
+				resp = append(resp, make([]byte, 24)...) // base_offset, log_append_time_ms, log_start_offset
+			}
+			return binary.BigEndian.AppendUint32(resp, 0) // throttle_time_ms
+		}
+		t.Errorf("unexpected API key %d", apiKey)
+		return nil
+	})
+
+	c, err := newKafkaClient("kafka://" + broker.addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	msgs := []busMessage{
+		{topic: "blocks", key: []byte("100"), value: []byte(`{"n":100}`)},
+		{topic: "blocks", key: []byte("101"), value: []byte(`{"n":101}`)},
+		{topic: "blocks", key: []byte("100"), value: []byte(`{"n":100,"late":true}`)},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.Publish(ctx, msgs); err != nil {
+		t.Fatal(err)
+	}
+
+	want := make(map[int32][]busMessage)
+	for _, msg := range msgs {
+		h := fnv.New32a()
+		h.Write(msg.key)
+		partition := int32(h.Sum32() % 2)
+		want[partition] = append(want[partition], msg)
+	}
+	for partition, msgs := range want {
+		got := produced[partition]
+		if len(got) != len(msgs) {
+			t.Fatalf("partition %d got %d messages, want %d", partition, len(got), len(msgs))
+		}
+		for i := range msgs {
+			if !bytes.Equal(got[i].key, msgs[i].key) || !bytes.Equal(got[i].value, msgs[i].value) {
+				t.Errorf("partition %d message %d = %q/%q", partition, i, got[i].key, got[i].value)
+			}
+		}
+	}
+
+	produceError = 6 // NOT_LEADER_OR_FOLLOWER
+	if err := c.Publish(ctx, msgs[:1]); err == nil || !strings.Contains(err.Error(), "Kafka error 6") {
+		t.Fatalf("Publish with a partition error: %v", err)
+	}
+	if len(c.leaders) != 0 {
+		t.Error("leaders kept after a produce error")
+	}
+}
+
+// TestKafkaMetadataErrors reports topic errors and truncated responses
+func TestKafkaMetadataErrors(t *testing.T) {
+	self := kafkaTestBroker{node: 1, host: "127.0.0.1", port: 9092}
+	c, _ := newKafkaClient("kafka://127.0.0.1:9092")
+
+	resp := kafkaMetadataResponse(self, "blocks", 1, 3) // UNKNOWN_TOPIC_OR_PARTITION
+	if err := c.parseMetadata(resp); err == nil || !strings.Contains(err.Error(), "Kafka error 3") {
+		t.Errorf("topic error: %v", err)
+	}
+	if _, ok := c.leaders["blocks"]; ok {
+		t.Error("leaders stored for a failed topic")
+	}
+
+	resp = kafkaMetadataResponse(self, "blocks", 1, 0)
+	for _, n := range []int{0, 10, len(resp) - 5} {
+		if err := c.parseMetadata(resp[:n]); err == nil || !strings.Contains(err.Error(), "truncated") {
+			t.Errorf("response truncated to %d bytes: %v", n, err)
+		}
+	}
+	if err := c.parseMetadata(resp); err != nil {
+		t.Fatal(err)
+	}
+	if c.brokers[1] != "127.0.0.1:9092" || len(c.leaders["blocks"]) != 1 {
+		t.Errorf("brokers %v, leaders %v", c.brokers, c.leaders)
+	}
+}
+
+// TestKafkaCorrelationMismatch drops the connection when a response does
+// not answer the request
+func TestKafkaCorrelationMismatch(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		if _, _, err := readKafkaFrame(conn); err != nil {
+			return
+		}
+		conn.Write([]byte{0, 0, 0, 4, 0, 0, 0, 99})
+	}()
+
+	c, _ := newKafkaClient("kafka://" + ln.Addr().String())
+	defer c.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err = c.Publish(ctx, []busMessage{{topic: "blocks", value: []byte("x")}})
+	if err == nil || !strings.Contains(err.Error(), "correlation ID 99") {
+		t.Fatalf("Publish = %v", err)
+	}
+	if len(c.conns) != 0 {
+		t.Error("connection kept after a mismatched response")
+	}
+}
+
+// kafkaTestBroker is the address a fake broker advertises
+type kafkaTestBroker struct {
+	addr string
+	node int32
+	host string
+	port int32
+}
+
+// serveFakeKafka answers request frames on one connection with handle
+func serveFakeKafka(t *testing.T, handle func(apiKey, version int16, r *kafkaReader, self kafkaTestBroker) []byte) kafkaTestBroker {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	host, port, _ := net.SplitHostPort(ln.Addr().String())
+	p, _ := strconv.Atoi(port)
+	self := kafkaTestBroker{addr: ln.Addr().String(), node: 1, host: host, port: int32(p)}
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			correlation, r, err := readKafkaFrame(conn)
+			if err != nil {
+				return
+			}
+			apiKey, version := r.int16(), r.int16()
+			r.int32() // correlation_id, already read
+			if client := r.string(); client != "monad-dashboard" {
+				t.Errorf("client_id %q", client)
+			}
+			body := handle(apiKey, version, r, self)
+			if r.err != nil {
+				t.Errorf("request: %v", r.err)
+			}
+			frame := binary.BigEndian.AppendUint32(nil, uint32(4+len(body)))
+			frame = binary.BigEndian.AppendUint32(frame, uint32(correlation))
+			if _, err := conn.Write(append(frame, body...)); err != nil {
+				return
+			}
+		}
+	}()
+	return self
+}
+
+// readKafkaFrame reads a size-prefixed request, returning its correlation ID
+// and a reader positioned at the header
+func readKafkaFrame(conn net.Conn) (int32, *kafkaReader, error) {
+	var size [4]byte
+	if _, err := io.ReadFull(conn, size[:]); err != nil {
+		return 0, nil, err
+	}
+	frame := make([]byte, binary.BigEndian.Uint32(size[:]))
+	if _, err := io.ReadFull(conn, frame); err != nil {
+		return 0, nil, err
+	}
+	if len(frame) < 8 {
+		return 0, nil, fmt.Errorf("short frame")
+	}
+	return int32(binary.BigEndian.Uint32(frame[4:])), &kafkaReader{buf: frame}, nil
+}
+
+// kafkaMetadataResponse builds a Metadata v8 response naming self as the
+// only broker and the leader of every partition of topic
+func kafkaMetadataResponse(self kafkaTestBroker, topic string, partitions int, code int16) []byte {
+	b := binary.BigEndian.AppendUint32(nil, 0) // throttle_time_ms
+	b = binary.BigEndian.AppendUint32(b, 1)
+	b = binary.BigEndian.AppendUint32(b, uint32(self.node))
+	b = appendKafkaString(b, self.host)
+	b = binary.BigEndian.AppendUint32(b, uint32(self.port))
+	b = binary.BigEndian.AppendUint16(b, 0xffff) // rack null
+	b = appendKafkaString(b, "cluster")
+	b = binary.BigEndian.AppendUint32(b, uint32(self.node)) // controller_id
+
+	b = binary.BigEndian.AppendUint32(b, 1)
+	b = binary.BigEndian.AppendUint16(b, uint16(code))
+	b = appendKafkaString(b, topic)
+	b = append(b, 0) // is_internal
+	b = binary.BigEndian.AppendUint32(b, uint32(partitions))
+	for i := 0; i < partitions; i++ {
+		b = binary.BigEndian.AppendUint16(b, 0)
+		b = binary.BigEndian.AppendUint32(b, uint32(i))
+		b = binary.BigEndian.AppendUint32(b, uint32(self.node)) // leader
+		b = binary.BigEndian.AppendUint32(b, 0)                 // leader_epoch
+		for _, replicas := range []int{1, 1, 0} {               // replicas, isr, offline
+			b = binary.BigEndian.AppendUint32(b, uint32(replicas))
+			for r := 0; r < replicas; r++ {
+				b = binary.BigEndian.AppendUint32(b, uint32(self.node))
+			}
+		}
+	}
+	b = binary.BigEndian.AppendUint32(b, 0) // topic_authorized_operations
+	return binary.BigEndian.AppendUint32(b, 0)
+}
+
+// decodeKafkaRecordBatch checks a v2 record batch's framing and CRC and
+// returns its records
+func decodeKafkaRecordBatch(b []byte) ([]busMessage, error) {
+	if len(b) < 61 {
+		return nil, fmt.Errorf("batch of %d bytes", len(b))
+	}
+	if n := binary.BigEndian.Uint32(b[8:]); int(n) != len(b)-12 {
+		return nil, fmt.Errorf("batch_length %d, batch holds %d", n, len(b)-12)
+	}
+	if b[16] != 2 {
+		return nil, fmt.Errorf("magic %d", b[16])
+	}
+	if crc := binary.BigEndian.Uint32(b[17:]); crc != crc32.Checksum(b[21:], castagnoli) {
+		return nil, fmt.Errorf("crc %08x mismatch", crc)
+	}
+	count := int(binary.BigEndian.Uint32(b[57:]))
+	if last := int(binary.BigEndian.Uint32(b[23:])); last != count-1 {
+		return nil, fmt.Errorf("last_offset_delta %d of %d records", last, count)
+	}
+
+	rd := bytes.NewReader(b[61:])
+	bytesOf := func() ([]byte, error) {
+		n, err := binary.ReadVarint(rd)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		out := make([]byte, n)
+		_, err = io.ReadFull(rd, out)
+		return out, err
+	}
+	msgs := make([]busMessage, 0, count)
+	for i := 0; i < count; i++ {
+		if _, err := binary.ReadVarint(rd); err != nil { // length
+			return nil, err
+		}
+		rd.ReadByte()         // attributes
+		binary.ReadVarint(rd) // timestamp_delta
+		offset, err := binary.ReadVarint(rd)
+		if err != nil || offset != int64(i) {
+			return nil, fmt.Errorf("record %d has offset_delta %d", i, offset)
+		}
+		var msg busMessage
+		if msg.key, err = bytesOf(); err != nil {
+			return nil, err
+		}
+		if msg.value, err = bytesOf(); err != nil {
+			return nil, err
+		}
+		if headers, _ := binary.ReadVarint(rd); headers != 0 {
+			return nil, fmt.Errorf("record %d has %d headers", i, headers)
+		}
+		msgs = append(msgs, msg)
+	}
+	if rd.Len() != 0 {
+		return nil, fmt.Errorf("%d bytes after the records", rd.Len())
+	}
+	return msgs, nil
+}
+
+// TestNATSPublish authenticates, publishes a batch confirmed by PONG and
+// answers server PINGs, then surfaces -ERR and drops the connection
+func TestNATSPublish(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	published := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		rd := bufio.NewReader(conn)
+		io.WriteString(conn, "INFO {\"server_id\":\"test\",\"max_payload\":1048576}\r\n")
+
+		line, _ := rd.ReadString('\n')
+		var options map[string]interface{}
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(strings.TrimSpace(line), "CONNECT ")), &options); err != nil {
+			t.Errorf("CONNECT %q: %v", line, err)
+		}
+		if options["user"] != "alice" || options["pass"] != "secret" || options["verbose"] != false {
+			t.Errorf("CONNECT options %v", options)
+		}
+		if line, _ := rd.ReadString('\n'); line != "PING\r\n" {
+			t.Errorf("after CONNECT %q", line)
+		}
+		io.WriteString(conn, "PONG\r\n")
+
+		// Everything up to the batch's PING, raw
+		var batch strings.Builder
+		for !strings.HasSuffix(batch.String(), "PING\r\n") {
+			line, err := rd.ReadString('\n')
+			if err != nil {
+				return
+			}
+			batch.WriteString(line)
+		}
+		io.WriteString(conn, "PING\r\n")
+		if line, _ := rd.ReadString('\n'); line != "PONG\r\n" {
+			t.Errorf("answer to PING %q", line)
+		}
+		io.WriteString(conn, "PONG\r\n")
+		published <- batch.String()
+
+		rd.ReadString('\n') // Next batch
+		io.WriteString(conn, "-ERR 'Permissions Violation for Publish to \"denied\"'\r\n")
+	}()
+
+	c, err := newNATSClient("nats://alice:secret@" + ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err = c.Publish(ctx, []busMessage{
+		{topic: "monad.blocks", value: []byte(`{"n":1}`)},
+		{topic: "monad.health", value: []byte("a\r\nb")}, // Payloads are length-prefixed, not line-based
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "PUB monad.blocks 7\r\n{\"n\":1}\r\nPUB monad.health 4\r\na\r\nb\r\nPING\r\n"
+	if got := <-published; got != want {
+		t.Errorf("published\n%q\nwant\n%q", got, want)
+	}
+
+	err = c.Publish(ctx, []busMessage{{topic: "denied", value: []byte("x")}})
+	if err == nil || !strings.Contains(err.Error(), "Permissions Violation") {
+		t.Fatalf("Publish refused by the server: %v", err)
+	}
+	if c.conn != nil {
+		t.Error("connection kept after -ERR")
+	}
+}
+
+// TestNATSConnectErrors rejects a server that is not NATS and a failed
+// authentication
+func TestNATSConnectErrors(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		server func(conn net.Conn, rd *bufio.Reader)
+		want   string
+	}{
+		{"greeting", func(conn net.Conn, _ *bufio.Reader) {
+			io.WriteString(conn, "+OK redis\r\n")
+		}, "unexpected NATS greeting"},
+		{"authorization", func(conn net.Conn, rd *bufio.Reader) {
+			io.WriteString(conn, "INFO {\"auth_required\":true}\r\n")
+			rd.ReadString('\n') // CONNECT
+			rd.ReadString('\n') // PING
+			io.WriteString(conn, "-ERR 'Authorization Violation'\r\n")
+		}, "Authorization Violation"},
+		{"closed", func(conn net.Conn, rd *bufio.Reader) {
+			io.WriteString(conn, "INFO {}\r\n")
+		}, "EOF"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ln, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer ln.Close()
+			go func() {
+				conn, err := ln.Accept()
+				if err != nil {
+					return
+				}
+				defer conn.Close()
+				tt.server(conn, bufio.NewReader(conn))
+			}()
+
+			c, _ := newNATSClient("nats://token@" + ln.Addr().String())
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			err = c.Publish(ctx, []busMessage{{topic: "monad.blocks", value: []byte("x")}})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Publish = %v, want %q", err, tt.want)
+			}
+			if c.conn != nil {
+				t.Error("failed connection kept")
+			}
+		})
+	}
+}
@@ -0,0 +1,554 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// The event publisher mirrors dashboard events onto a Kafka or NATS bus for
+// downstream pipelines. PUBLISH_BROKER selects kafka or nats and PUBLISH_URL
+// the brokers; each event category goes to its own topic (Kafka) or subject
+// (NATS), PUBLISH_TOPIC_PREFIX plus the category: <prefix>.blocks,
+// <prefix>.tx_logs, <prefix>.consensus and <prefix>.alerts. PUBLISH_EVENTS
+// limits the categories. Events are serialized per PUBLISH_FORMAT, json
+// (BusEvent) or protobuf (see busEventProtoSchema). They are queued without
+// blocking the collectors and published in batches; a batch that fails is
+// retried a few times and then dropped, so delivery is at least once while
+// the bus is reachable and best effort when it is not.
+
+// Event categories, each published to its own topic
+const (
+	busBlocks    = "blocks"
+	busTxLogs    = "tx_logs"
+	busConsensus = "consensus"
+	busAlerts    = "alerts"
+)
+
+// busCategories are the categories that can be published
+var busCategories = []string{busBlocks, busTxLogs, busConsensus, busAlerts}
+
+// Event types within the categories (alerts use the webhook event names)
+const (
+	busEventBlock          = "block"
+	busEventTxLog          = "tx_log"
+	busEventConsensusPhase = "consensus.phase"
+)
+
+// Publish batching and retry
+const (
+	publishBatchSize = 500
+	publishAttempts  = 3
+	publishKeepalive = 30 * time.Second
+)
+
+// BusEvent is the JSON form of a published event
+type BusEvent struct {
+	Type      string      `json:"type"`
+	Chain     string      `json:"chain"`
+	Timestamp time.Time   `json:"timestamp"`
+	Data      interface{} `json:"data"` // BlockHeader, TransactionLog, ConsensusPhaseEvent or AlertEvent
+}
+
+// ConsensusPhaseEvent is the data of consensus.phase
+type ConsensusPhaseEvent struct {
+	Number  uint64    `json:"number"`
+	Hash    string    `json:"hash"`
+	Phase   string    `json:"phase"` // proposed, voted or finalized
+	TxCount int       `json:"tx_count"`
+	At      time.Time `json:"at"`
+}
+
+// busMessage is one serialized event bound for a topic
+type busMessage struct {
+	topic string
+	key   []byte // Kafka partition key; events with the same key stay in order
+	value []byte
+}
+
+// eventBusClient delivers messages to one broker kind
+type eventBusClient interface {
+	// Name is the broker kind, "kafka" or "nats"
+	Name() string
+	// Publish delivers msgs; with none it only checks the connection
+	Publish(ctx context.Context, msgs []busMessage) error
+	Close()
+}
+
+// queuedEvent is an event waiting to be serialized and published
+type queuedEvent struct {
+	category string
+	key      string
+	event    BusEvent
+}
+
+// PublisherCategoryStats counts one category's events since startup
+type PublisherCategoryStats struct {
+	Category  string `json:"category"`
+	Topic     string `json:"topic"`
+	Enabled   bool   `json:"enabled"`
+	Published int64  `json:"published"`
+	Dropped   int64  `json:"dropped"` // Queue full
+	Failed    int64  `json:"failed"`  // Dropped after publishAttempts failed attempts
+}
+
+// PublisherStatus is the body of /api/v1/publisher
+type PublisherStatus struct {
+	Enabled     bool                     `json:"enabled"`
+	Broker      string                   `json:"broker,omitempty"`
+	Format      string                   `json:"format,omitempty"`
+	Queued      int                      `json:"queued"`
+	QueueSize   int                      `json:"queue_size,omitempty"`
+	Batches     int64                    `json:"batches"`
+	Retries     int64                    `json:"retries"`
+	LastError   string                   `json:"last_error,omitempty"`
+	LastErrorAt *time.Time               `json:"last_error_at,omitempty"`
+	Categories  []PublisherCategoryStats `json:"categories,omitempty"`
+}
+
+// EventPublisher serializes queued events and publishes them to the bus
+type EventPublisher struct {
+	client  eventBusClient
+	format  string
+	prefix  string
+	enabled map[string]bool
+	queue   chan queuedEvent
+
+	mu          sync.Mutex
+	stats       map[string]*PublisherCategoryStats
+	batches     int64
+	retries     int64
+	lastError   string
+	lastErrorAt *time.Time
+
+	done chan struct{}
+}
+
+// Global event publisher instance
+var (
+	eventPublisher   *EventPublisher
+	eventPublisherMu sync.RWMutex
+)
+
+// InitializeEventPublisher starts the publisher when PUBLISH_BROKER is kafka
+// or nats, publishing to PUBLISH_URL
+func InitializeEventPublisher() *EventPublisher {
+	kind := strings.ToLower(os.Getenv("PUBLISH_BROKER"))
+	if kind == "" {
+		return nil
+	}
+	client, err := newEventBusClient(kind, os.Getenv("PUBLISH_URL"))
+	if err != nil {
+		log.Printf("Event publishing disabled: %v", err)
+		return nil
+	}
+
+	p := &EventPublisher{
+		client:  client,
+		format:  "json",
+		prefix:  "monad",
+		enabled: make(map[string]bool),
+		stats:   make(map[string]*PublisherCategoryStats),
+		done:    make(chan struct{}),
+	}
+	if value := strings.ToLower(os.Getenv("PUBLISH_FORMAT")); value != "" {
+		if value == "json" || value == "protobuf" {
+			p.format = value
+		} else {
+			log.Printf("Invalid PUBLISH_FORMAT %q, using %s", value, p.format)
+		}
+	}
+	if value := os.Getenv("PUBLISH_TOPIC_PREFIX"); value != "" {
+		p.prefix = strings.TrimSuffix(value, ".")
+	}
+	queueSize := 10000
+	if value := os.Getenv("PUBLISH_BUFFER"); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			queueSize = n
+		} else {
+			log.Printf("Invalid PUBLISH_BUFFER %q, using %d", value, queueSize)
+		}
+	}
+	p.queue = make(chan queuedEvent, queueSize)
+
+	categories := busCategories
+	if value := os.Getenv("PUBLISH_EVENTS"); value != "" {
+		categories = nil
+		for _, category := range splitList(value) {
+			if containsString(busCategories, strings.ToLower(category)) {
+				categories = append(categories, strings.ToLower(category))
+			} else {
+				log.Printf("Unknown PUBLISH_EVENTS category %q (expected %s)", category, strings.Join(busCategories, ", "))
+			}
+		}
+	}
+	for _, category := range categories {
+		p.enabled[category] = true
+	}
+	for _, category := range busCategories {
+		p.stats[category] = &PublisherCategoryStats{Category: category, Topic: p.topic(category), Enabled: p.enabled[category]}
+	}
+
+	eventPublisherMu.Lock()
+	eventPublisher = p
+	eventPublisherMu.Unlock()
+
+	log.Printf("Publishing %s events to %s as %s (topics %s.*)", strings.Join(categories, ", "), client.Name(), p.format, p.prefix)
+	go p.run()
+	return p
+}
+
+// GetEventPublisher returns the global publisher, nil when disabled
+func GetEventPublisher() *EventPublisher {
+	eventPublisherMu.RLock()
+	defer eventPublisherMu.RUnlock()
+	return eventPublisher
+}
+
+// publishBusEvent queues an event for the bus, if publishing is enabled
+func publishBusEvent(category, eventType, key string, data interface{}) {
+	if p := GetEventPublisher(); p != nil {
+		p.Publish(category, eventType, key, data)
+	}
+}
+
+// newEventBusClient creates the client of the given kind
+func newEventBusClient(kind, rawURL string) (eventBusClient, error) {
+	if rawURL == "" {
+		return nil, fmt.Errorf("PUBLISH_URL not set")
+	}
+	switch kind {
+	case "kafka":
+		return newKafkaClient(rawURL)
+	case "nats":
+		return newNATSClient(rawURL)
+	}
+	return nil, fmt.Errorf("unknown PUBLISH_BROKER %q (kafka or nats)", kind)
+}
+
+// topic returns the topic or subject of a category
+func (p *EventPublisher) topic(category string) string {
+	return p.prefix + "." + category
+}
+
+// Publish queues an event. It never blocks: when the queue is full the
+// event is dropped and counted.
+func (p *EventPublisher) Publish(category, eventType, key string, data interface{}) {
+	if !p.enabled[category] {
+		return
+	}
+	event := BusEvent{Type: eventType, Chain: primaryChainName(), Timestamp: time.Now().UTC(), Data: data}
+	select {
+	case p.queue <- queuedEvent{category: category, key: key, event: event}:
+	default:
+		p.mu.Lock()
+		p.stats[category].Dropped++
+		p.mu.Unlock()
+	}
+}
+
+// run publishes queued events in batches until shutdown, then publishes
+// what is left
+func (p *EventPublisher) run() {
+	defer close(p.done)
+	keepalive := time.NewTicker(publishKeepalive)
+	defer keepalive.Stop()
+
+	for {
+		var batch []queuedEvent
+		select {
+		case event := <-p.queue:
+			batch = p.drain(append(batch, event))
+		case <-keepalive.C:
+			// Keeps idle connections open and reconnects broken ones
+			ctx, cancel := callContext(10 * time.Second)
+			p.client.Publish(ctx, nil)
+			cancel()
+			continue
+		case <-shutdownContext().Done():
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			for batch = p.drain(nil); len(batch) > 0; batch = p.drain(nil) {
+				p.send(ctx, batch, 1)
+			}
+			cancel()
+			p.client.Close()
+			return
+		}
+		p.send(shutdownContext(), batch, publishAttempts)
+	}
+}
+
+// drain adds queued events to batch, up to publishBatchSize
+func (p *EventPublisher) drain(batch []queuedEvent) []queuedEvent {
+	for len(batch) < publishBatchSize {
+		select {
+		case event := <-p.queue:
+			batch = append(batch, event)
+		default:
+			return batch
+		}
+	}
+	return batch
+}
+
+// send serializes and publishes a batch, retrying with backoff
+func (p *EventPublisher) send(ctx context.Context, batch []queuedEvent, attempts int) {
+	msgs := make([]busMessage, 0, len(batch))
+	for _, queued := range batch {
+		value, err := p.encode(queued.event)
+		if err != nil {
+			log.Printf("Event publisher: encoding %s: %v", queued.event.Type, err)
+			continue
+		}
+		msgs = append(msgs, busMessage{topic: p.topic(queued.category), key: []byte(queued.key), value: value})
+	}
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if attempt > 1 {
+			p.mu.Lock()
+			p.retries++
+			p.mu.Unlock()
+			select {
+			case <-time.After(time.Duration(attempt-1) * time.Second):
+			case <-ctx.Done():
+			}
+		}
+		callCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+		err = p.client.Publish(callCtx, msgs)
+		cancel()
+		if err == nil || ctx.Err() != nil {
+			break
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.batches++
+	for _, queued := range batch {
+		if err == nil {
+			p.stats[queued.category].Published++
+		} else {
+			p.stats[queued.category].Failed++
+		}
+	}
+	if err != nil {
+		now := time.Now()
+		p.lastError, p.lastErrorAt = err.Error(), &now
+		log.Printf("Event publisher: dropped %d events after failing to publish to %s: %v", len(batch), p.client.Name(), err)
+	}
+}
+
+// encode serializes an event in the configured format
+func (p *EventPublisher) encode(event BusEvent) ([]byte, error) {
+	if p.format == "protobuf" {
+		return encodeBusEventProto(event)
+	}
+	return json.Marshal(event)
+}
+
+// Close waits for the final publish after shutdown
+func (p *EventPublisher) Close() {
+	select {
+	case <-p.done:
+	case <-time.After(10 * time.Second):
+	}
+}
+
+// Status reports the broker, queue and per-category counts
+func (p *EventPublisher) Status() PublisherStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	status := PublisherStatus{
+		Enabled:     true,
+		Broker:      p.client.Name(),
+		Format:      p.format,
+		Queued:      len(p.queue),
+		QueueSize:   cap(p.queue),
+		Batches:     p.batches,
+		Retries:     p.retries,
+		LastError:   p.lastError,
+		LastErrorAt: p.lastErrorAt,
+	}
+	for _, category := range busCategories {
+		status.Categories = append(status.Categories, *p.stats[category])
+	}
+	return status
+}
+
+// handlePublisherStatus reports the event publisher's progress
+func handlePublisherStatus(c *gin.Context) {
+	p := GetEventPublisher()
+	if p == nil {
+		c.JSON(http.StatusOK, PublisherStatus{})
+		return
+	}
+	c.JSON(http.StatusOK, p.Status())
+}
+
+// ---- Protobuf encoding ----
+
+// busEventProtoSchema is the schema of PUBLISH_FORMAT=protobuf messages;
+// it is served at /api/v1/publisher/schema.proto
+const busEventProtoSchema = `syntax = "proto3";
+
+package monad.dashboard.v1;
+
+message Event {
+  string type = 1;          // block, tx_log, consensus.phase, alert.fired, alert.resolved
+  string chain = 2;
+  int64 timestamp_ms = 3;
+  oneof data {
+    Block block = 4;
+    TxLog tx_log = 5;
+    ConsensusPhase consensus_phase = 6;
+    Alert alert = 7;
+  }
+}
+
+message Block {
+  int64 number = 1;
+  string hash = 2;
+  int64 timestamp = 3;      // Unix seconds
+  int64 tx_count = 4;
+  int64 gas_used = 5;
+  int64 gas_limit = 6;
+  string miner = 7;
+}
+
+message TxLog {
+  int64 block_number = 1;
+  string transaction_hash = 2;
+  int64 transaction_index = 3;
+  string address = 4;
+  repeated string topics = 5;
+  string data = 6;
+  int64 timestamp = 7;      // Unix seconds
+}
+
+message ConsensusPhase {
+  uint64 number = 1;
+  string hash = 2;
+  string phase = 3;         // proposed, voted, finalized
+  int64 tx_count = 4;
+  int64 at_ms = 5;
+}
+
+message Alert {
+  string component = 1;
+  string state = 2;
+  string previous = 3;
+  string reason = 4;
+  int64 at_ms = 5;
+}
+`
+
+// protoWriter appends protobuf fields, omitting proto3 default values
+type protoWriter struct {
+	buf []byte
+}
+
+func (w *protoWriter) key(field, wire int) {
+	w.buf = binary.AppendUvarint(w.buf, uint64(field)<<3|uint64(wire))
+}
+
+func (w *protoWriter) varintField(field int, v uint64) {
+	if v == 0 {
+		return
+	}
+	w.key(field, wireVarint)
+	w.buf = binary.AppendUvarint(w.buf, v)
+}
+
+func (w *protoWriter) int64Field(field int, v int64) {
+	w.varintField(field, uint64(v))
+}
+
+func (w *protoWriter) stringField(field int, s string) {
+	if s == "" {
+		return
+	}
+	w.bytesField(field, []byte(s))
+}
+
+// bytesField writes a length-delimited field, even when empty (an empty
+// embedded message still selects its oneof case)
+func (w *protoWriter) bytesField(field int, b []byte) {
+	w.key(field, wireBytes)
+	w.buf = binary.AppendUvarint(w.buf, uint64(len(b)))
+	w.buf = append(w.buf, b...)
+}
+
+// unixMillis converts t for the *_ms fields
+func unixMillis(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixMilli()
+}
+
+// encodeBusEventProto encodes an event as a monad.dashboard.v1.Event
+func encodeBusEventProto(event BusEvent) ([]byte, error) {
+	var data protoWriter
+	field := 0
+	switch d := event.Data.(type) {
+	case BlockHeader:
+		field = 4
+		data.int64Field(1, d.Number)
+		data.stringField(2, d.Hash)
+		data.int64Field(3, d.Timestamp)
+		data.int64Field(4, int64(d.Transactions))
+		data.int64Field(5, d.GasUsed)
+		data.int64Field(6, d.GasLimit)
+		data.stringField(7, d.Miner)
+	case TransactionLog:
+		field = 5
+		data.int64Field(1, d.BlockNumber)
+		data.stringField(2, d.TransactionHash)
+		data.int64Field(3, int64(d.TransactionIndex))
+		data.stringField(4, d.Address)
+		for _, topic := range d.Topics {
+			data.bytesField(5, []byte(topic))
+		}
+		data.stringField(6, d.Data)
+		data.int64Field(7, d.Timestamp)
+	case ConsensusPhaseEvent:
+		field = 6
+		data.varintField(1, d.Number)
+		data.stringField(2, d.Hash)
+		data.stringField(3, d.Phase)
+		data.int64Field(4, int64(d.TxCount))
+		data.int64Field(5, unixMillis(d.At))
+	case AlertEvent:
+		field = 7
+		data.stringField(1, d.Component)
+		data.stringField(2, d.State)
+		data.stringField(3, d.Previous)
+		data.stringField(4, d.Reason)
+		data.int64Field(5, unixMillis(d.At))
+	default:
+		return nil, fmt.Errorf("no protobuf encoding for %T", event.Data)
+	}
+
+	var msg protoWriter
+	msg.stringField(1, event.Type)
+	msg.stringField(2, event.Chain)
+	msg.int64Field(3, unixMillis(event.Timestamp))
+	msg.bytesField(field, data.buf)
+	return msg.buf, nil
+}
+
+// handlePublisherSchema serves the protobuf schema of published events
+func handlePublisherSchema(c *gin.Context) {
+	c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(busEventProtoSchema))
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"log"
+	"sync"
+)
+
+// EventProcessor handles one event from a named ring
+type EventProcessor func(ring string, event ExecutionEvent)
+
+// eventRouter dispatches events from each ring to its processors
+var (
+	eventRoutes   = make(map[string][]EventProcessor)
+	eventRoutesMu sync.RWMutex
+)
+
+func init() {
+	RegisterEventProcessor("exec", func(_ string, event ExecutionEvent) { processExecutionEvent(event) })
+	RegisterEventProcessor("consensus", processConsensusEvent)
+	RegisterEventProcessor("mempool", processMempoolEvent)
+}
+
+// RegisterEventProcessor adds a processor for events from the named ring.
+// Processors registered for "*" receive events from every ring.
+func RegisterEventProcessor(ring string, processor EventProcessor) {
+	eventRoutesMu.Lock()
+	defer eventRoutesMu.Unlock()
+	eventRoutes[ring] = append(eventRoutes[ring], processor)
+}
+
+// dispatchRingEvent runs every processor registered for ring (and "*")
+func dispatchRingEvent(ring string, event ExecutionEvent) {
+	eventRoutesMu.RLock()
+	processors := append(append([]EventProcessor{}, eventRoutes[ring]...), eventRoutes["*"]...)
+	eventRoutesMu.RUnlock()
+
+	if len(processors) == 0 {
+		log.Printf("No processor for %s ring event type %d", ring, event.Header.EventType)
+		return
+	}
+	for _, process := range processors {
+		process(ring, event)
+	}
+}
+
+// processConsensusEvent updates consensus state from the consensus ring
+func processConsensusEvent(_ string, event ExecutionEvent) {
+	data, ok := event.Data.(BlockConsensusEvent)
+	if !ok {
+		return
+	}
+
+	lifecycle := GetMonadWaterfallMetrics()
+	stages := GetWaterfallMetrics()
+	ct := GetConsensusTracker()
+
+	switch event.Header.EventType {
+	case EventTypeBlockProposed:
+		lifecycle.ConsensusProposed.Add(1)
+		stages.BlockProposed.Add(1)
+		ct.OnBlockProposed(data.BlockNumber, data.BlockHash, data.TxCount)
+	case EventTypeBlockVoted:
+		lifecycle.ConsensusVoted.Add(1)
+		stages.BlockQCFormed.Add(1)
+		ct.OnBlockVoted(data.BlockNumber)
+	case EventTypeBlockFinalized:
+		lifecycle.ConsensusFinalized.Add(1)
+		stages.BlockFinalized.Add(1)
+		if ht := GetHeadTracker(); ht != nil {
+			ht.OnFinalizedHead(int64(data.BlockNumber), "event_ring")
+		} else {
+			ct.OnBlockFinalized(data.BlockNumber)
+		}
+	}
+}
+
+// processMempoolEvent updates ingress and drop counters from the mempool ring
+func processMempoolEvent(_ string, event ExecutionEvent) {
+	data, ok := event.Data.(MempoolTxEvent)
+	if !ok {
+		return
+	}
+
+	lifecycle := GetMonadWaterfallMetrics()
+	stages := GetWaterfallMetrics()
+
+	switch event.Header.EventType {
+	case EventTypeTxInserted:
+		hash := data.TxHash
+		if hash == "" {
+			hash = eventTxHash(event)
+		}
+		GetTxLifecycleCorrelator().OnMempoolInsert(hash, data.Source)
+
+		lifecycle.MempoolReceived.Add(1)
+		if data.Source == "p2p" {
+			lifecycle.SubmissionP2PReceived.Add(1)
+			stages.NetP2PReceived.Add(1)
+		} else {
+			lifecycle.SubmissionRPCReceived.Add(1)
+			stages.NetRPCReceived.Add(1)
+		}
+	case EventTypeTxDropped:
+		countMempoolDrop(data.Reason, 1)
+	}
+}
+
+// countMempoolDrop adds count drops for reason to the waterfall counters
+func countMempoolDrop(reason string, count int64) {
+	lifecycle := GetMonadWaterfallMetrics()
+	stages := GetWaterfallMetrics()
+
+	switch reason {
+	case "invalid_signature", "not_well_formed":
+		lifecycle.SubmissionInvalidSig.Add(count)
+		stages.VerifySigFailed.Add(count)
+	case "nonce_too_low":
+		lifecycle.MempoolNonceInvalid.Add(count)
+		stages.VerifyNonceFailed.Add(count)
+	case "insufficient_balance":
+		lifecycle.BlockBuildingInsufficientBalance.Add(count)
+		stages.VerifyBalanceFailed.Add(count)
+	case "fee_too_low":
+		stages.PoolFeeDropped.Add(count)
+	case "pool_full":
+		stages.PoolFull.Add(count)
+	default:
+		stages.NetDropped.Add(count)
+	}
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"encoding/binary"
+	"log"
+	"os"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Each ring's events are processed by a pool of shards, each a worker with
+// its own queue. The dispatcher routes an event to a shard by its
+// transaction ID, so one transaction's events are processed in order on one
+// worker while different transactions run in parallel; events without a
+// transaction ID (blocks, consensus) all go to shard 0, keeping their order.
+// A full shard queue blocks the dispatcher, which backs up the reader's
+// channel until the reader drops events (dropped_events) rather than stop
+// reading the socket.
+
+// EventShardStats is one shard's queue and throughput
+type EventShardStats struct {
+	Shard       int     `json:"shard"`
+	Queued      int     `json:"queued"`
+	Capacity    int     `json:"capacity"`
+	Processed   uint64  `json:"processed"`
+	Utilization float64 `json:"utilization"` // Share of the last second spent processing
+}
+
+// EventPoolStats is the processing state of one ring
+type EventPoolStats struct {
+	Shards         int               `json:"shards"`
+	Dispatched     uint64            `json:"dispatched"`
+	Processed      uint64            `json:"processed"`
+	Blocked        uint64            `json:"blocked"` // Dispatches that waited on a full shard queue
+	BlockedSeconds float64           `json:"blocked_seconds"`
+	Saturation     float64           `json:"saturation"`  // Fill of the fullest shard queue, 0-1
+	Utilization    float64           `json:"utilization"` // Mean share of the last second the shards spent processing
+	PerShard       []EventShardStats `json:"per_shard"`
+}
+
+// eventShard is one worker and its queue
+type eventShard struct {
+	queue     chan ExecutionEvent
+	processed atomic.Uint64
+	busyNs    atomic.Int64
+}
+
+// EventShardPool processes one ring's events across its shards
+type EventShardPool struct {
+	ring   string
+	shards []*eventShard
+
+	dispatched atomic.Uint64
+	blocked    atomic.Uint64
+	blockedNs  atomic.Int64
+
+	mu          sync.RWMutex
+	utilization []float64 // Per shard, over the last second
+}
+
+// eventShardConfig reads the shard count from EVENT_WORKERS (default
+// GOMAXPROCS) and the per-shard queue length from EVENT_SHARD_QUEUE
+// (default 1024)
+func eventShardConfig() (workers, queue int) {
+	workers, queue = runtime.GOMAXPROCS(0), 1024
+	if value := os.Getenv("EVENT_WORKERS"); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			workers = n
+		} else {
+			log.Printf("Invalid EVENT_WORKERS %q, using %d", value, workers)
+		}
+	}
+	if value := os.Getenv("EVENT_SHARD_QUEUE"); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			queue = n
+		} else {
+			log.Printf("Invalid EVENT_SHARD_QUEUE %q, using %d", value, queue)
+		}
+	}
+	return workers, queue
+}
+
+// NewEventShardPool creates a pool of workers shards for ring, each
+// queueing up to queue events
+func NewEventShardPool(ring string, workers, queue int) *EventShardPool {
+	p := &EventShardPool{
+		ring:        ring,
+		shards:      make([]*eventShard, workers),
+		utilization: make([]float64, workers),
+	}
+	for i := range p.shards {
+		p.shards[i] = &eventShard{queue: make(chan ExecutionEvent, queue)}
+	}
+	return p
+}
+
+// shardFor picks the shard of an event from its transaction ID
+func (p *EventShardPool) shardFor(event ExecutionEvent) int {
+	id := binary.LittleEndian.Uint64(event.Header.TransactionID[:8]) ^ binary.LittleEndian.Uint64(event.Header.TransactionID[24:])
+	if id == 0 {
+		return 0
+	}
+	return int(id % uint64(len(p.shards)))
+}
+
+// Run starts the shards and dispatches events to them until events is
+// closed or the dashboard shuts down
+func (p *EventShardPool) Run(events <-chan ExecutionEvent) {
+	var workers sync.WaitGroup
+	for _, shard := range p.shards {
+		workers.Add(1)
+		go func(shard *eventShard) {
+			defer workers.Done()
+			p.work(shard)
+		}(shard)
+	}
+	go p.sampleUtilization()
+
+	defer func() {
+		for _, shard := range p.shards {
+			close(shard.queue)
+		}
+		workers.Wait()
+	}()
+
+	for {
+		var event ExecutionEvent
+		var ok bool
+		select {
+		case event, ok = <-events:
+			if !ok {
+				return
+			}
+		case <-shutdownContext().Done():
+			return
+		}
+
+		shard := p.shards[p.shardFor(event)]
+		select {
+		case shard.queue <- event:
+		default:
+			// Backpressure: wait for the shard rather than drop here
+			p.blocked.Add(1)
+			start := time.Now()
+			select {
+			case shard.queue <- event:
+			case <-shutdownContext().Done():
+				return
+			}
+			p.blockedNs.Add(int64(time.Since(start)))
+		}
+		p.dispatched.Add(1)
+	}
+}
+
+// work processes one shard's queue
+func (p *EventShardPool) work(shard *eventShard) {
+	for event := range shard.queue {
+		start := time.Now()
+		dispatchRingEvent(p.ring, event)
+		shard.busyNs.Add(int64(time.Since(start)))
+		shard.processed.Add(1)
+	}
+}
+
+// sampleUtilization measures each shard's busy share every second
+func (p *EventShardPool) sampleUtilization() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	last := make([]int64, len(p.shards))
+	lastAt := time.Now()
+	for {
+		select {
+		case now := <-ticker.C:
+			elapsed := float64(now.Sub(lastAt))
+			lastAt = now
+			p.mu.Lock()
+			for i, shard := range p.shards {
+				busy := shard.busyNs.Load()
+				p.utilization[i] = float64(busy-last[i]) / elapsed
+				if p.utilization[i] > 1 {
+					p.utilization[i] = 1
+				}
+				last[i] = busy
+			}
+			p.mu.Unlock()
+		case <-shutdownContext().Done():
+			return
+		}
+	}
+}
+
+// Stats reports the shards' queues, throughput and saturation
+func (p *EventShardPool) Stats() EventPoolStats {
+	stats := EventPoolStats{
+		Shards:         len(p.shards),
+		Dispatched:     p.dispatched.Load(),
+		Blocked:        p.blocked.Load(),
+		BlockedSeconds: time.Duration(p.blockedNs.Load()).Seconds(),
+		PerShard:       make([]EventShardStats, len(p.shards)),
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	for i, shard := range p.shards {
+		s := EventShardStats{
+			Shard:       i,
+			Queued:      len(shard.queue),
+			Capacity:    cap(shard.queue),
+			Processed:   shard.processed.Load(),
+			Utilization: p.utilization[i],
+		}
+		stats.Processed += s.Processed
+		stats.Utilization += s.Utilization / float64(len(p.shards))
+		if fill := float64(s.Queued) / float64(s.Capacity); fill > stats.Saturation {
+			stats.Saturation = fill
+		}
+		stats.PerShard[i] = s
+	}
+	return stats
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Event types can be left unprocessed, e.g. state reads, which dominate
+// exec ring volume. The filter is applied in the read loop right after the
+// header: a skipped event's payload is discarded without being copied or
+// parsed and never reaches the channel or the processing shards. Sequence
+// numbers are still tracked, so skipped events do not look like gaps.
+// EVENT_TYPES lists the types to process (default all) and
+// EVENT_TYPES_SKIP the ones to skip; both take the names below, and the
+// skipped set can be replaced at runtime through the admin API.
+
+// eventTypeInfo names an event type and the ring that carries it
+type eventTypeInfo struct {
+	Type uint32
+	Name string
+	Ring string
+}
+
+// eventTypes lists every known event type
+var eventTypes = []eventTypeInfo{
+	{EventTypeTransactionStart, "transaction_start", "exec"},
+	{EventTypeTransactionEnd, "transaction_end", "exec"},
+	{EventTypeStateRead, "state_read", "exec"},
+	{EventTypeStateWrite, "state_write", "exec"},
+	{EventTypeLogEmitted, "log_emitted", "exec"},
+	{EventTypeContractCall, "contract_call", "exec"},
+	{EventTypeGasUsage, "gas_usage", "exec"},
+	{EventTypeError, "error", "exec"},
+	{EventTypeBlockProposed, "block_proposed", "consensus"},
+	{EventTypeBlockVoted, "block_voted", "consensus"},
+	{EventTypeBlockFinalized, "block_finalized", "consensus"},
+	{EventTypeTxInserted, "tx_inserted", "mempool"},
+	{EventTypeTxDropped, "tx_dropped", "mempool"},
+}
+
+// maxFilteredEventType bounds the types the filter and per-type counts
+// cover; higher (unknown) types are always processed
+const maxFilteredEventType = 63
+
+// skippedEventTypes has bit t set when event type t is skipped
+var skippedEventTypes atomic.Uint64
+
+// eventTypeSkipped reports whether events of type t are skipped
+func eventTypeSkipped(t uint32) bool {
+	return t <= maxFilteredEventType && skippedEventTypes.Load()&(1<<t) != 0
+}
+
+// eventTypeByName looks up an event type by name
+func eventTypeByName(name string) (eventTypeInfo, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, info := range eventTypes {
+		if info.Name == name {
+			return info, true
+		}
+	}
+	return eventTypeInfo{}, false
+}
+
+// eventTypeName returns the name of event type t, or its number when unknown
+func eventTypeName(t uint32) string {
+	for _, info := range eventTypes {
+		if info.Type == t {
+			return info.Name
+		}
+	}
+	return fmt.Sprintf("type_%d", t)
+}
+
+// eventTypeMask builds a filter mask from type names
+func eventTypeMask(names []string) (uint64, error) {
+	var mask uint64
+	for _, name := range names {
+		info, ok := eventTypeByName(name)
+		if !ok {
+			return 0, fmt.Errorf("unknown event type %q", name)
+		}
+		mask |= 1 << info.Type
+	}
+	return mask, nil
+}
+
+// allEventTypesMask has every known event type's bit set
+func allEventTypesMask() uint64 {
+	var mask uint64
+	for _, info := range eventTypes {
+		mask |= 1 << info.Type
+	}
+	return mask
+}
+
+// InitializeEventTypeFilter reads the processed event types from
+// EVENT_TYPES and EVENT_TYPES_SKIP
+func InitializeEventTypeFilter() {
+	var skipped uint64
+	if value := os.Getenv("EVENT_TYPES"); value != "" {
+		if processed, err := eventTypeMask(splitList(value)); err == nil {
+			skipped = allEventTypesMask() &^ processed
+		} else {
+			log.Printf("Invalid EVENT_TYPES %q (%v), processing all event types", value, err)
+		}
+	}
+	if value := os.Getenv("EVENT_TYPES_SKIP"); value != "" {
+		if mask, err := eventTypeMask(splitList(value)); err == nil {
+			skipped |= mask
+		} else {
+			log.Printf("Invalid EVENT_TYPES_SKIP %q (%v), ignoring it", value, err)
+		}
+	}
+	skippedEventTypes.Store(skipped)
+	if names := skippedEventTypeNames(); len(names) > 0 {
+		log.Printf("Skipping event types: %s", strings.Join(names, ", "))
+	}
+}
+
+// skippedEventTypeNames lists the skipped event types by name
+func skippedEventTypeNames() []string {
+	names := []string{}
+	for _, info := range eventTypes {
+		if eventTypeSkipped(info.Type) {
+			names = append(names, info.Name)
+		}
+	}
+	return names
+}
+
+// EventTypeStatus is one event type's subscription and volume
+type EventTypeStatus struct {
+	Name      string `json:"name"`
+	Type      uint32 `json:"type"`
+	Ring      string `json:"ring"`
+	Processed bool   `json:"processed"`
+	Received  uint64 `json:"received"` // Read from the ring, skipped or not
+	Skipped   uint64 `json:"skipped"`
+}
+
+// EventTypesResponse is the body of /api/v1/event-rings/types
+type EventTypesResponse struct {
+	Types   []EventTypeStatus `json:"types"`
+	Skipped []string          `json:"skipped"`
+}
+
+// eventTypesStatus sums every ring's per-type counts
+func eventTypesStatus() EventTypesResponse {
+	received := make(map[uint32]uint64)
+	skipped := make(map[uint32]uint64)
+	for _, reader := range GetEventRings() {
+		for t := range reader.typeCounts {
+			received[uint32(t)] += reader.typeCounts[t].Load()
+			skipped[uint32(t)] += reader.typeSkipped[t].Load()
+		}
+	}
+
+	response := EventTypesResponse{Skipped: skippedEventTypeNames()}
+	for _, info := range eventTypes {
+		response.Types = append(response.Types, EventTypeStatus{
+			Name:      info.Name,
+			Type:      info.Type,
+			Ring:      info.Ring,
+			Processed: !eventTypeSkipped(info.Type),
+			Received:  received[info.Type],
+			Skipped:   skipped[info.Type],
+		})
+	}
+	sort.SliceStable(response.Types, func(i, j int) bool { return response.Types[i].Received > response.Types[j].Received })
+	return response
+}
+
+// handleEventTypes lists the event types with their subscription and volume
+func handleEventTypes(c *gin.Context) {
+	c.JSON(http.StatusOK, eventTypesStatus())
+}
+
+// EventTypesRequest is the body of PUT /api/v1/admin/event-rings/types
+type EventTypesRequest struct {
+	Skip []string `json:"skip"` // Replaces the skipped set; empty processes every type
+}
+
+// handleSetEventTypes replaces the skipped event types (admin)
+func handleSetEventTypes(c *gin.Context) {
+	var req EventTypesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, APIError{Error: "Invalid request body"})
+		return
+	}
+	mask, err := eventTypeMask(req.Skip)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, APIError{Error: err.Error()})
+		return
+	}
+	if previous := skippedEventTypes.Swap(mask); previous != mask {
+		log.Printf("Skipped event types changed: %s", strings.Join(skippedEventTypeNames(), ", "))
+	}
+	c.JSON(http.StatusOK, eventTypesStatus())
+}
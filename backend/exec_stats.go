@@ -0,0 +1,201 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+
+	"monad-dashboard/hexutil"
+)
+
+// GasDistribution summarizes per-transaction gas used within a block
+type GasDistribution struct {
+	Min  uint64 `json:"min"`
+	P50  uint64 `json:"p50"`
+	P90  uint64 `json:"p90"`
+	Max  uint64 `json:"max"`
+	Mean uint64 `json:"mean"`
+}
+
+// BlockExecStats are execution results of one block computed from its receipts
+type BlockExecStats struct {
+	BlockNumber      int64           `json:"block_number"`
+	TxCount          int             `json:"tx_count"`
+	Succeeded        int             `json:"succeeded"`
+	Reverted         int             `json:"reverted"`
+	ContractsCreated int             `json:"contracts_created"`
+	LogCount         int             `json:"log_count"`
+	GasUsed          uint64          `json:"gas_used"`
+	GasPerTx         GasDistribution `json:"gas_per_tx"`
+}
+
+// ComputeBlockExecStats derives success/revert counts, log counts and the
+// gas distribution from a block's receipts
+func ComputeBlockExecStats(blockNumber int64, receipts []RPCReceipt) *BlockExecStats {
+	stats := &BlockExecStats{
+		BlockNumber: blockNumber,
+		TxCount:     len(receipts),
+	}
+
+	gas := make([]uint64, 0, len(receipts))
+	for _, receipt := range receipts {
+		if receipt.Status == "0x1" {
+			stats.Succeeded++
+		} else {
+			stats.Reverted++
+		}
+		if receipt.ContractAddress != "" {
+			stats.ContractsCreated++
+		}
+		stats.LogCount += len(receipt.Logs)
+
+		used := hexutil.Uint64OrZero(receipt.GasUsed)
+		stats.GasUsed += used
+		gas = append(gas, used)
+	}
+
+	if len(gas) > 0 {
+		sort.Slice(gas, func(i, j int) bool { return gas[i] < gas[j] })
+		stats.GasPerTx = GasDistribution{
+			Min:  gas[0],
+			P50:  gas[len(gas)*50/100],
+			P90:  gas[len(gas)*90/100],
+			Max:  gas[len(gas)-1],
+			Mean: stats.GasUsed / uint64(len(gas)),
+		}
+	}
+	return stats
+}
+
+// ExecStatsTracker keeps receipt-based execution stats for recent blocks
+type ExecStatsTracker struct {
+	mu        sync.RWMutex
+	recent    []*BlockExecStats
+	maxRecent int
+
+	totalTxs      int64
+	totalReverted int64
+	totalLogs     int64
+}
+
+// Global execution stats tracker instance
+var (
+	execStatsTracker     *ExecStatsTracker
+	execStatsTrackerOnce sync.Once
+)
+
+// GetExecStatsTracker returns the global execution stats tracker
+func GetExecStatsTracker() *ExecStatsTracker {
+	execStatsTrackerOnce.Do(func() {
+		execStatsTracker = &ExecStatsTracker{maxRecent: 200}
+	})
+	return execStatsTracker
+}
+
+// Record stores a block's stats and feeds the Execution and State stages of
+// both waterfalls with real counts
+func (t *ExecStatsTracker) Record(stats *BlockExecStats) {
+	t.mu.Lock()
+	t.recent = append(t.recent, stats)
+	if len(t.recent) > t.maxRecent {
+		t.recent = t.recent[1:]
+	}
+	t.totalTxs += int64(stats.TxCount)
+	t.totalReverted += int64(stats.Reverted)
+	t.totalLogs += int64(stats.LogCount)
+	t.mu.Unlock()
+
+	monad := GetMonadWaterfallMetrics()
+	monad.ExecutionReverted.Add(int64(stats.Reverted))
+	monad.ExecutionToStateUpdate.Add(int64(stats.Succeeded))
+	monad.StateLogsEmitted.Add(int64(stats.LogCount))
+	monad.FinalityReceiptsGenerated.Add(int64(stats.TxCount))
+
+	legacy := GetWaterfallMetrics()
+	legacy.ExecFailed.Add(int64(stats.Reverted))
+	legacy.StateLogsEmitted.Add(int64(stats.LogCount))
+}
+
+// ForBlock returns the stats of a recent block
+func (t *ExecStatsTracker) ForBlock(number int64) (*BlockExecStats, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	for i := len(t.recent) - 1; i >= 0; i-- {
+		if t.recent[i].BlockNumber == number {
+			return t.recent[i], true
+		}
+	}
+	return nil, false
+}
+
+// Latest returns the most recently recorded block stats
+func (t *ExecStatsTracker) Latest() (*BlockExecStats, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if len(t.recent) == 0 {
+		return nil, false
+	}
+	return t.recent[len(t.recent)-1], true
+}
+
+// Recent returns up to n of the most recent block stats, newest first
+func (t *ExecStatsTracker) Recent(n int) []*BlockExecStats {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if n <= 0 || n > len(t.recent) {
+		n = len(t.recent)
+	}
+	result := make([]*BlockExecStats, 0, n)
+	for i := len(t.recent) - 1; i >= len(t.recent)-n; i-- {
+		result = append(result, t.recent[i])
+	}
+	return result
+}
+
+// ExecutionTotals are the receipt counters since startup
+type ExecutionTotals struct {
+	Transactions int64    `json:"transactions"`
+	Reverted     int64    `json:"reverted"`
+	Logs         int64    `json:"logs"`
+	RevertRate   *float64 `json:"revert_rate,omitempty"` // Absent until a transaction is seen
+}
+
+// ExecutionBlocksResponse is the body of /api/v1/execution/blocks
+type ExecutionBlocksResponse struct {
+	Blocks []*BlockExecStats `json:"blocks"`
+	Totals ExecutionTotals   `json:"totals"`
+}
+
+// handleExecutionBlocks returns receipt-based execution stats for recent blocks
+func handleExecutionBlocks(c *gin.Context) {
+	recent := 20
+	if value := c.Query("recent"); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			recent = n
+		}
+	}
+
+	t := GetExecStatsTracker()
+	t.mu.RLock()
+	totals := ExecutionTotals{
+		Transactions: t.totalTxs,
+		Reverted:     t.totalReverted,
+		Logs:         t.totalLogs,
+	}
+	if t.totalTxs > 0 {
+		rate := float64(t.totalReverted) / float64(t.totalTxs)
+		totals.RevertRate = &rate
+	}
+	t.mu.RUnlock()
+
+	c.JSON(http.StatusOK, ExecutionBlocksResponse{
+		Blocks: t.Recent(recent),
+		Totals: totals,
+	})
+}
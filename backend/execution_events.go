@@ -1,13 +1,17 @@
 package main
 
 import (
+	"bufio"
 	"encoding/binary"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"net"
+	"os"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -41,6 +45,15 @@ const (
 	EventTypeContractCall
 	EventTypeGasUsage
 	EventTypeError
+
+	// Consensus ring
+	EventTypeBlockProposed
+	EventTypeBlockVoted
+	EventTypeBlockFinalized
+
+	// Mempool ring
+	EventTypeTxInserted
+	EventTypeTxDropped
 )
 
 // Parsed event data structures
@@ -73,26 +86,57 @@ type LogEvent struct {
 	Data    string   `json:"data"`
 }
 
-// EventRingReader manages connection to Monad's event rings
+type GasUsageEvent struct {
+	Address string `json:"address"` // Contract the gas was charged to
+	GasUsed uint64 `json:"gas_used"`
+}
+
+type BlockConsensusEvent struct {
+	BlockNumber uint64 `json:"block_number"`
+	BlockHash   string `json:"block_hash"`
+	TxCount     int    `json:"tx_count"`
+	Round       uint64 `json:"round"`
+}
+
+type MempoolTxEvent struct {
+	TxHash string `json:"tx_hash"`
+	Source string `json:"source"` // "rpc" or "p2p"
+	Reason string `json:"reason,omitempty"` // Drop reason
+}
+
+// EventRingReader manages connection to one of Monad's event rings
 type EventRingReader struct {
+	name           string // Ring name ("exec", "consensus", "mempool")
+	socketPath     string
 	conn           net.Conn
 	connected      bool
 	eventChan      chan ExecutionEvent
 	stopChan       chan struct{}
 	mutex          sync.RWMutex
-	lastSequence   uint64
-	missedEvents   uint64
-
-	// Event processing stats
-	eventsReceived uint64
-	bytesReceived  uint64
-	parseErrors    uint64
+	lastEventAt    time.Time  // When the last in-order event arrived
+	writeMu        sync.Mutex // Serializes replay requests
+	pool           *EventShardPool
+
+	// Event processing stats, updated without locking on the read path
+	lastSequence   atomic.Uint64
+	missedEvents   atomic.Uint64
+	eventsReceived atomic.Uint64
+	bytesReceived  atomic.Uint64
+	parseErrors    atomic.Uint64
+	replayedEvents atomic.Uint64 // Events resent by the ring to fill a gap
+	duplicates     atomic.Uint64
+	channelDrops   atomic.Uint64 // Events dropped because processing fell behind
+	skippedEvents  atomic.Uint64 // Events of types filtered out in the read loop
+	typeCounts     [maxFilteredEventType + 1]atomic.Uint64
+	typeSkipped    [maxFilteredEventType + 1]atomic.Uint64
 }
 
-// NewEventRingReader creates a new reader for Monad execution events
-func NewEventRingReader(socketPath string) *EventRingReader {
+// NewEventRingReader creates a new reader for the named event ring
+func NewEventRingReader(name, socketPath string) *EventRingReader {
 	return &EventRingReader{
-		eventChan: make(chan ExecutionEvent, 1000), // Buffer for high throughput
+		name:       name,
+		socketPath: socketPath,
+		eventChan:  make(chan ExecutionEvent, 1000), // Buffer for high throughput
 		stopChan:  make(chan struct{}),
 	}
 }
@@ -106,7 +150,10 @@ func (r *EventRingReader) Connect(socketPath string) error {
 		return fmt.Errorf("already connected")
 	}
 
-	conn, err := net.Dial("unix", socketPath)
+	ctx, cancel := callContext(2 * time.Second)
+	defer cancel()
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "unix", socketPath)
 	if err != nil {
 		return fmt.Errorf("failed to connect to event ring %s: %w", socketPath, err)
 	}
@@ -114,10 +161,10 @@ func (r *EventRingReader) Connect(socketPath string) error {
 	r.conn = conn
 	r.connected = true
 
-	log.Printf("Connected to Monad event ring: %s", socketPath)
+	log.Printf("Connected to Monad %s event ring: %s", r.name, socketPath)
 
 	// Start reading events in background
-	go r.readEvents()
+	go r.readEvents(conn, r.stopChan)
 
 	return nil
 }
@@ -139,11 +186,23 @@ func (r *EventRingReader) Disconnect() error {
 	}
 
 	r.connected = false
-	log.Printf("Disconnected from Monad event ring")
+	log.Printf("Disconnected from Monad %s event ring", r.name)
 
 	return nil
 }
 
+// IsConnected reports whether the ring socket is connected
+func (r *EventRingReader) IsConnected() bool {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.connected
+}
+
+// Name returns the ring name
+func (r *EventRingReader) Name() string {
+	return r.name
+}
+
 // Events returns the channel for receiving execution events
 func (r *EventRingReader) Events() <-chan ExecutionEvent {
 	return r.eventChan
@@ -152,42 +211,85 @@ func (r *EventRingReader) Events() <-chan ExecutionEvent {
 // GetStats returns current statistics about event processing
 func (r *EventRingReader) GetStats() map[string]interface{} {
 	r.mutex.RLock()
-	defer r.mutex.RUnlock()
-
-	return map[string]interface{}{
-		"connected":        r.connected,
-		"events_received":  r.eventsReceived,
-		"bytes_received":   r.bytesReceived,
-		"missed_events":    r.missedEvents,
-		"parse_errors":     r.parseErrors,
-		"last_sequence":    r.lastSequence,
+	connected, pool := r.connected, r.pool
+	r.mutex.RUnlock()
+
+	stats := map[string]interface{}{
+		"name":             r.name,
+		"socket_path":      r.socketPath,
+		"connected":        connected,
+		"events_received":  r.eventsReceived.Load(),
+		"bytes_received":   r.bytesReceived.Load(),
+		"missed_events":    r.missedEvents.Load(),
+		"parse_errors":     r.parseErrors.Load(),
+		"replayed_events":  r.replayedEvents.Load(),
+		"duplicate_events": r.duplicates.Load(),
+		"dropped_events":   r.channelDrops.Load(),
+		"skipped_events":   r.skippedEvents.Load(),
+		"last_sequence":    r.lastSequence.Load(),
 		"buffer_size":      len(r.eventChan),
 	}
+	if pool != nil {
+		stats["processing"] = pool.Stats()
+	}
+	byType := make(map[string]uint64)
+	for t := range r.typeCounts {
+		if count := r.typeCounts[t].Load(); count > 0 {
+			byType[eventTypeName(uint32(t))] = count
+		}
+	}
+	stats["events_by_type"] = byType
+	return stats
+}
+
+// eventHeaderSize is the encoded size of ExecutionEventHeader
+var eventHeaderSize = binary.Size(ExecutionEventHeader{})
+
+// readEventHeader reads and decodes one header into buf, without the
+// reflection binary.Read would use for every event
+func readEventHeader(rd io.Reader, buf []byte) (ExecutionEventHeader, error) {
+	var header ExecutionEventHeader
+	if _, err := io.ReadFull(rd, buf); err != nil {
+		return header, err
+	}
+	header.SequenceNumber = binary.LittleEndian.Uint64(buf[0:8])
+	header.Timestamp = binary.LittleEndian.Uint64(buf[8:16])
+	header.EventType = binary.LittleEndian.Uint32(buf[16:20])
+	header.PayloadSize = binary.LittleEndian.Uint32(buf[20:24])
+	copy(header.TransactionID[:], buf[24:56])
+	copy(header.Reserved[:], buf[56:72])
+	return header, nil
 }
 
-// readEvents continuously reads events from the socket
-func (r *EventRingReader) readEvents() {
+// readEvents reads events from the socket until Disconnect or shutdown
+func (r *EventRingReader) readEvents(conn net.Conn, stop <-chan struct{}) {
 	defer func() {
 		if r := recover(); r != nil {
 			log.Printf("Event reader panic: %v", r)
 		}
 	}()
 
+	rd := bufio.NewReaderSize(conn, 64<<10) // Fewer syscalls at high event rates
+	headerBuf := make([]byte, eventHeaderSize)
 	buffer := make([]byte, 4096) // Buffer for reading
+	var lastDropLog time.Time
+	var dropsLogged uint64
 
 	for {
 		select {
-		case <-r.stopChan:
+		case <-stop:
+			return
+		case <-shutdownContext().Done():
 			return
 		default:
 		}
 
 		// Set read timeout
-		r.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
 
 		// Read the fixed 64-byte header
-		header := ExecutionEventHeader{}
-		if err := binary.Read(r.conn, binary.LittleEndian, &header); err != nil {
+		header, err := readEventHeader(rd, headerBuf)
+		if err != nil {
 			if err == io.EOF {
 				log.Printf("Event ring connection closed")
 				return
@@ -196,15 +298,40 @@ func (r *EventRingReader) readEvents() {
 			continue
 		}
 
-		// Check for missed events
-		if header.SequenceNumber > r.lastSequence+1 && r.lastSequence > 0 {
-			missed := header.SequenceNumber - r.lastSequence - 1
-			r.mutex.Lock()
-			r.missedEvents += missed
-			r.mutex.Unlock()
-			log.Printf("Missed %d events (seq %d -> %d)", missed, r.lastSequence, header.SequenceNumber)
+		// Check for missed events, and for replayed ones filling an earlier gap
+		now := time.Now()
+		resyncer := GetRingResyncer()
+		replayed, duplicate := false, false
+		lastSequence := r.lastSequence.Load()
+		if header.SequenceNumber > lastSequence+1 && lastSequence > 0 {
+			missed := header.SequenceNumber - lastSequence - 1
+			r.missedEvents.Add(missed)
+			log.Printf("Missed %d events (seq %d -> %d)", missed, lastSequence, header.SequenceNumber)
+			if resyncer != nil {
+				resyncer.OnGap(r, lastSequence+1, header.SequenceNumber-1, r.lastEventAt, now)
+			}
+		} else if header.SequenceNumber <= lastSequence && lastSequence > 0 {
+			replayed = resyncer != nil && resyncer.Replayed(r.name, header.SequenceNumber)
+			duplicate = !replayed
+		}
+		if !replayed && !duplicate {
+			r.lastSequence.Store(header.SequenceNumber)
+			r.lastEventAt = now
+		}
+
+		// Skipped types are discarded here, before any copy or parse
+		if header.EventType <= maxFilteredEventType && !duplicate {
+			r.typeCounts[header.EventType].Add(1)
+		}
+		if !duplicate && eventTypeSkipped(header.EventType) {
+			r.typeSkipped[header.EventType].Add(1)
+			r.skippedEvents.Add(1)
+			if _, err := rd.Discard(int(header.PayloadSize)); err != nil {
+				log.Printf("Failed to skip event payload: %v", err)
+				r.parseErrors.Add(1)
+			}
+			continue
 		}
-		r.lastSequence = header.SequenceNumber
 
 		// Read payload if present
 		var payload []byte
@@ -213,17 +340,19 @@ func (r *EventRingReader) readEvents() {
 				buffer = make([]byte, header.PayloadSize)
 			}
 
-			n, err := io.ReadFull(r.conn, buffer[:header.PayloadSize])
+			n, err := io.ReadFull(rd, buffer[:header.PayloadSize])
 			if err != nil {
 				log.Printf("Failed to read event payload: %v", err)
-				r.mutex.Lock()
-				r.parseErrors++
-				r.mutex.Unlock()
+				r.parseErrors.Add(1)
 				continue
 			}
 			payload = make([]byte, n)
 			copy(payload, buffer[:n])
 		}
+		if duplicate {
+			r.duplicates.Add(1)
+			continue
+		}
 
 		// Create event
 		event := ExecutionEvent{
@@ -234,23 +363,27 @@ func (r *EventRingReader) readEvents() {
 		// Parse payload based on event type
 		if err := r.parseEventPayload(&event); err != nil {
 			log.Printf("Failed to parse event payload (type %d): %v", header.EventType, err)
-			r.mutex.Lock()
-			r.parseErrors++
-			r.mutex.Unlock()
+			r.parseErrors.Add(1)
 		}
 
 		// Update stats
-		r.mutex.Lock()
-		r.eventsReceived++
-		r.bytesReceived += uint64(64 + len(payload)) // Header + payload
-		r.mutex.Unlock()
+		r.eventsReceived.Add(1)
+		r.bytesReceived.Add(uint64(64 + len(payload))) // Header + payload
+		if replayed {
+			r.replayedEvents.Add(1)
+		}
 
-		// Send event to channel (non-blocking)
+		// Send event to channel (non-blocking); the socket must keep being
+		// read, so events are dropped while the processing shards are full
 		select {
 		case r.eventChan <- event:
 		default:
-			// Channel full, drop event and log warning
-			log.Printf("Event channel full, dropping event seq %d", header.SequenceNumber)
+			r.channelDrops.Add(1)
+			if now.Sub(lastDropLog) >= 10*time.Second {
+				drops := r.channelDrops.Load()
+				log.Printf("Event channel full on %s ring, dropped %d events since last report", r.name, drops-dropsLogged)
+				lastDropLog, dropsLogged = now, drops
+			}
 		}
 	}
 }
@@ -290,6 +423,27 @@ func (r *EventRingReader) parseEventPayload(event *ExecutionEvent) error {
 		}
 		event.Data = data
 
+	case EventTypeGasUsage:
+		var data GasUsageEvent
+		if err := json.Unmarshal(event.Payload, &data); err != nil {
+			return err
+		}
+		event.Data = data
+
+	case EventTypeBlockProposed, EventTypeBlockVoted, EventTypeBlockFinalized:
+		var data BlockConsensusEvent
+		if err := json.Unmarshal(event.Payload, &data); err != nil {
+			return err
+		}
+		event.Data = data
+
+	case EventTypeTxInserted, EventTypeTxDropped:
+		var data MempoolTxEvent
+		if err := json.Unmarshal(event.Payload, &data); err != nil {
+			return err
+		}
+		event.Data = data
+
 	default:
 		// Unknown event type, keep raw payload
 		log.Printf("Unknown event type: %d", event.Header.EventType)
@@ -298,54 +452,106 @@ func (r *EventRingReader) parseEventPayload(event *ExecutionEvent) error {
 	return nil
 }
 
-// Global event ring reader instances
-var (
-	executionEventReader *EventRingReader
-	eventReaderMutex     sync.RWMutex
-)
+// defaultEventRings is used when MONAD_EVENT_RINGS is not set
+var defaultEventRings = map[string]string{
+	"exec": "/home/monad/monad-bft/mempool.sock",
+}
+
+// eventRingsFromEnv parses MONAD_EVENT_RINGS ("exec=/path,consensus=/path,mempool=/path")
+func eventRingsFromEnv() map[string]string {
+	value := os.Getenv("MONAD_EVENT_RINGS")
+	if value == "" {
+		return defaultEventRings
+	}
 
-// InitializeEventRings initializes connections to Monad event rings
-func InitializeEventRings() error {
-	eventReaderMutex.Lock()
-	defer eventReaderMutex.Unlock()
+	rings := make(map[string]string)
+	for _, pair := range splitList(value) {
+		if name, path, ok := strings.Cut(pair, "="); ok {
+			rings[strings.TrimSpace(name)] = strings.TrimSpace(path)
+		}
+	}
+	return rings
+}
 
-	// Initialize execution event reader with actual Monad socket path
-	// Try mempool socket first (most likely to have execution events)
-	socketPath := "/home/monad/monad-bft/mempool.sock"
-	executionEventReader = NewEventRingReader(socketPath)
+// ConnectEventRings connects every configured event ring independently.
+// It fails only when no ring could be connected.
+func (d *Dashboard) ConnectEventRings() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
 
-	// Try to connect (will fallback gracefully if socket doesn't exist or doesn't support events)
-	if err := executionEventReader.Connect(socketPath); err != nil {
-		log.Printf("Failed to connect to execution events at %s: %v", socketPath, err)
+	connected := 0
+	for name, socketPath := range eventRingsFromEnv() {
+		reader := NewEventRingReader(name, socketPath)
+		d.eventRings[name] = reader
+
+		// Fall back gracefully if the socket doesn't exist or doesn't support events
+		if err := reader.Connect(socketPath); err != nil {
+			log.Printf("Failed to connect to %s events at %s: %v", name, socketPath, err)
+			continue
+		}
+		connected++
+	}
+
+	if connected == 0 {
 		log.Printf("Event ring features will be disabled")
-		return err
+		return fmt.Errorf("no event rings connected")
 	}
 
-	log.Printf("Event ring connections initialized successfully")
+	log.Printf("Event ring connections initialized successfully (%d/%d rings)", connected, len(d.eventRings))
 	return nil
 }
 
-// GetExecutionEventReader returns the global execution event reader
+// EventRing returns the reader for a named ring
+func (d *Dashboard) EventRing(name string) *EventRingReader {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.eventRings[name]
+}
+
+// EventRings returns all configured ring readers
+func (d *Dashboard) EventRings() map[string]*EventRingReader {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	rings := make(map[string]*EventRingReader, len(d.eventRings))
+	for name, reader := range d.eventRings {
+		rings[name] = reader
+	}
+	return rings
+}
+
+// GetEventRing returns the served dashboard's reader for a named ring
+func GetEventRing(name string) *EventRingReader {
+	return GetDashboard().EventRing(name)
+}
+
+// GetEventRings returns the served dashboard's ring readers
+func GetEventRings() map[string]*EventRingReader {
+	return GetDashboard().EventRings()
+}
+
+// GetExecutionEventReader returns the execution event ring reader
 func GetExecutionEventReader() *EventRingReader {
-	eventReaderMutex.RLock()
-	defer eventReaderMutex.RUnlock()
-	return executionEventReader
+	return GetEventRing("exec")
 }
 
-// StartEventProcessing starts processing execution events for dashboard metrics
-func StartEventProcessing() {
-	go func() {
-		reader := GetExecutionEventReader()
-		if reader == nil {
-			return
+// StartEventProcessing starts a pool of processing shards per connected
+// ring, routing each ring's events to the processors registered for it
+func (d *Dashboard) StartEventProcessing() {
+	workers, queue := eventShardConfig()
+	for name, reader := range d.EventRings() {
+		if !reader.IsConnected() {
+			continue
 		}
 
-		log.Printf("Starting execution event processing...")
+		pool := NewEventShardPool(name, workers, queue)
+		reader.mutex.Lock()
+		reader.pool = pool
+		reader.mutex.Unlock()
 
-		for event := range reader.Events() {
-			processExecutionEvent(event)
-		}
-	}()
+		log.Printf("Starting %s event processing (%d shards)...", name, workers)
+		go pool.Run(reader.Events())
+	}
 }
 
 // processExecutionEvent processes individual execution events and updates metrics
@@ -355,6 +561,8 @@ func processExecutionEvent(event ExecutionEvent) {
 	case EventTypeTransactionStart:
 		if data, ok := event.Data.(TransactionStartEvent); ok {
 			log.Printf("Transaction started: %s -> %s, Gas: %d", data.Sender, data.To, data.GasLimit)
+			GetTxLifecycleCorrelator().OnExecStart(eventTxHash(event), data.To)
+			GetConflictTracker().OnTransactionStart(eventTxHash(event))
 			// Update waterfall metrics: transaction ingress
 			updateWaterfallFromEvent("transaction_start", 1)
 		}
@@ -363,6 +571,10 @@ func processExecutionEvent(event ExecutionEvent) {
 		if data, ok := event.Data.(TransactionEndEvent); ok {
 			log.Printf("Transaction completed: Success=%t, Gas=%d, Duration=%dns",
 				data.Success, data.GasUsed, data.Duration)
+			txHash := eventTxHash(event)
+			GetTxLifecycleCorrelator().OnExecEnd(txHash, data.Success, data.GasUsed, data.Duration)
+			GetSlowTxTracker().Observe(txHash, data.Success, data.GasUsed, data.Duration)
+			GetGasHeatmap().OnTransactionEnd(txHash, data.GasUsed)
 			// Update waterfall metrics: transaction completion
 			if data.Success {
 				updateWaterfallFromEvent("transaction_success", 1)
@@ -371,9 +583,15 @@ func processExecutionEvent(event ExecutionEvent) {
 			}
 		}
 
+	case EventTypeStateRead:
+		if data, ok := event.Data.(StateChangeEvent); ok {
+			GetConflictTracker().OnStateAccess(eventTxHash(event), data.Address, data.Key, false)
+		}
+
 	case EventTypeStateWrite:
 		if data, ok := event.Data.(StateChangeEvent); ok {
 			log.Printf("State change: %s[%s] = %s", data.Address, data.Key, data.NewValue)
+			GetConflictTracker().OnStateAccess(eventTxHash(event), data.Address, data.Key, true)
 			updateWaterfallFromEvent("state_write", 1)
 		}
 
@@ -382,25 +600,38 @@ func processExecutionEvent(event ExecutionEvent) {
 			log.Printf("Log emitted: %s, topics: %v", data.Address, data.Topics)
 			updateWaterfallFromEvent("log_emitted", 1)
 		}
+
+	case EventTypeGasUsage:
+		if data, ok := event.Data.(GasUsageEvent); ok {
+			GetGasHeatmap().OnGasUsage(data.Address, data.GasUsed)
+		}
 	}
 }
 
-// updateWaterfallFromEvent updates waterfall metrics based on execution events
+// updateWaterfallFromEvent counts execution events in the served
+// dashboard's waterfall
 func updateWaterfallFromEvent(eventName string, count int64) {
+	GetDashboard().updateWaterfallFromEvent(eventName, count)
+}
+
+// updateWaterfallFromEvent updates waterfall metrics based on execution events
+func (d *Dashboard) updateWaterfallFromEvent(eventName string, count int64) {
 	// This will integrate with the existing metrics system
-	metricsMutex.Lock()
-	defer metricsMutex.Unlock()
+	d.metricsMu.Lock()
+	defer d.metricsMu.Unlock()
 
 	// Update appropriate waterfall counters based on event type
 	switch eventName {
 	case "transaction_start":
-		currentMetrics.Waterfall.RPCReceived += count
+		d.metrics.Waterfall.RPCReceived += count
 	case "transaction_success":
-		currentMetrics.Waterfall.EVMParallelExecuted += count
+		d.metrics.Waterfall.EVMParallelExecuted += count
 	case "transaction_failed":
-		currentMetrics.Waterfall.SignatureFailed += count
+		d.metrics.Waterfall.SignatureFailed += count
 	case "state_write":
-		currentMetrics.Waterfall.StateUpdated += count
+		d.metrics.Waterfall.StateUpdated += count
+	case "state_conflict":
+		d.metrics.Waterfall.StateConflicts += count
 	case "log_emitted":
 		// Could add a new metric for logs emitted
 	}
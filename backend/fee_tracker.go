@@ -0,0 +1,411 @@
+package main
+
+import (
+	"log"
+	"math/big"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"monad-dashboard/hexutil"
+)
+
+// weiPerMON converts wei amounts into MON for display
+var weiPerMON = new(big.Float).SetFloat64(1e18)
+
+// BlockFees is the fee breakdown of a single block
+type BlockFees struct {
+	BlockNumber   int64    `json:"block_number"`
+	Epoch         int64    `json:"epoch"`
+	Miner         string   `json:"miner"`
+	TxCount       int      `json:"tx_count"`
+	GasUsed       uint64   `json:"gas_used"`
+	BaseFeePerGas *big.Int `json:"base_fee_per_gas"`
+	TotalFees     *big.Int `json:"total_fees"`    // Σ gasUsed × effectiveGasPrice
+	BurnedFees    *big.Int `json:"burned_fees"`   // baseFee × gasUsed
+	PriorityFees  *big.Int `json:"priority_fees"` // Paid to the block proposer
+	Timestamp     int64    `json:"timestamp"`
+}
+
+// feeTotals accumulates fee amounts
+type feeTotals struct {
+	Blocks       int64    `json:"blocks"`
+	TotalFees    *big.Int `json:"total_fees"`
+	BurnedFees   *big.Int `json:"burned_fees"`
+	PriorityFees *big.Int `json:"priority_fees"`
+}
+
+func newFeeTotals() *feeTotals {
+	return &feeTotals{
+		TotalFees:    new(big.Int),
+		BurnedFees:   new(big.Int),
+		PriorityFees: new(big.Int),
+	}
+}
+
+func (t *feeTotals) add(fees *BlockFees) {
+	t.Blocks++
+	t.TotalFees.Add(t.TotalFees, fees.TotalFees)
+	t.BurnedFees.Add(t.BurnedFees, fees.BurnedFees)
+	t.PriorityFees.Add(t.PriorityFees, fees.PriorityFees)
+}
+
+// FeeTotals are accumulated fees in wei (decimal strings) and MON
+type FeeTotals struct {
+	Blocks          int64   `json:"blocks"`
+	TotalFees       string  `json:"total_fees"`
+	BurnedFees      string  `json:"burned_fees"`
+	PriorityFees    string  `json:"priority_fees"`
+	TotalFeesMON    float64 `json:"total_fees_mon"`
+	BurnedFeesMON   float64 `json:"burned_fees_mon"`
+	PriorityFeesMON float64 `json:"priority_fees_mon"`
+}
+
+func (t *feeTotals) summary() FeeTotals {
+	return FeeTotals{
+		Blocks:          t.Blocks,
+		TotalFees:       t.TotalFees.String(),
+		BurnedFees:      t.BurnedFees.String(),
+		PriorityFees:    t.PriorityFees.String(),
+		TotalFeesMON:    weiToMON(t.TotalFees),
+		BurnedFeesMON:   weiToMON(t.BurnedFees),
+		PriorityFeesMON: weiToMON(t.PriorityFees),
+	}
+}
+
+// BlockFeeSummary is a block's fees with wei amounts as decimal strings
+type BlockFeeSummary struct {
+	BlockNumber   int64  `json:"block_number"`
+	Epoch         int64  `json:"epoch"`
+	Miner         string `json:"miner"`
+	TxCount       int    `json:"tx_count"`
+	GasUsed       uint64 `json:"gas_used"`
+	BaseFeePerGas string `json:"base_fee_per_gas"`
+	TotalFees     string `json:"total_fees"`
+	BurnedFees    string `json:"burned_fees"`
+	PriorityFees  string `json:"priority_fees"`
+	Timestamp     int64  `json:"timestamp"`
+}
+
+// FeeQueueStats are the counters of the fee tracker's block queue. Its
+// receipts also feed exec stats, reverts, opcodes, the address index and the
+// fee market, so dropped blocks are missing from all of them.
+type FeeQueueStats struct {
+	Depth       int   `json:"depth"`
+	Capacity    int   `json:"capacity"`
+	Processed   int64 `json:"processed"`
+	Failed      int64 `json:"failed"`  // Block or receipts fetch failed
+	Dropped     int64 `json:"dropped"` // Rejected with the queue full
+	LastDropped int64 `json:"last_dropped,omitempty"`
+}
+
+// FeeSummary is the body of /api/v1/fees
+type FeeSummary struct {
+	Cumulative FeeTotals            `json:"cumulative"`
+	Epochs     map[string]FeeTotals `json:"epochs"` // Keyed by epoch number
+	Recent     []BlockFeeSummary    `json:"recent"` // Newest first
+	Queue      FeeQueueStats        `json:"queue"`
+	Timestamp  int64                `json:"timestamp"`
+}
+
+// weiToMON converts a wei amount to a float MON value
+func weiToMON(wei *big.Int) float64 {
+	mon, _ := new(big.Float).Quo(new(big.Float).SetInt(wei), weiPerMON).Float64()
+	return mon
+}
+
+// FeeTracker computes per-block fee flows from receipts
+type FeeTracker struct {
+	client *MonadClient
+
+	mu         sync.RWMutex
+	cumulative *feeTotals
+	epochs     map[int64]*feeTotals
+	recent     []*BlockFees
+	maxRecent  int
+	maxEpochs  int
+
+	queue       chan int64
+	processed   int64
+	failed      int64
+	dropped     int64
+	lastDropped int64
+	lastDropLog time.Time
+}
+
+// NewFeeTracker creates a fee tracker reading blocks and receipts through
+// client, keeping the last 100 blocks and 10 epochs
+func NewFeeTracker(client *MonadClient) *FeeTracker {
+	return &FeeTracker{
+		client:     client,
+		cumulative: newFeeTotals(),
+		epochs:     make(map[int64]*feeTotals),
+		recent:     make([]*BlockFees, 0, 100),
+		maxRecent:  100,
+		maxEpochs:  10,
+		queue:      make(chan int64, 100),
+	}
+}
+
+// StartFeeTracker creates the dashboard's fee tracker and starts its worker
+func (d *Dashboard) StartFeeTracker() *FeeTracker {
+	ft := NewFeeTracker(d.client)
+	d.mu.Lock()
+	d.fees = ft
+	d.mu.Unlock()
+	go ft.run()
+	return ft
+}
+
+// InitializeFeeTracker starts the served dashboard's fee tracker
+func InitializeFeeTracker() *FeeTracker {
+	return GetDashboard().StartFeeTracker()
+}
+
+// GetFeeTracker returns the served dashboard's fee tracker
+func GetFeeTracker() *FeeTracker {
+	return GetDashboard().FeeTracker()
+}
+
+// Enqueue schedules a block for fee computation (non-blocking). Blocks
+// arriving with the queue full are dropped and counted.
+func (ft *FeeTracker) Enqueue(blockNumber int64) {
+	select {
+	case ft.queue <- blockNumber:
+	default:
+		ft.mu.Lock()
+		ft.dropped++
+		ft.lastDropped = blockNumber
+		report := time.Since(ft.lastDropLog) >= 10*time.Second
+		if report {
+			ft.lastDropLog = time.Now()
+		}
+		dropped := ft.dropped
+		ft.mu.Unlock()
+		if report {
+			log.Printf("Fee tracker queue full, skipping block %d (%d dropped so far)", blockNumber, dropped)
+		}
+	}
+}
+
+// run processes queued blocks sequentially
+func (ft *FeeTracker) run() {
+	for blockNumber := range ft.queue {
+		fees, err := ft.fetchBlockFees(blockNumber)
+		if err != nil {
+			ft.mu.Lock()
+			ft.failed++
+			ft.mu.Unlock()
+			log.Printf("Failed to compute fees for block %d: %v", blockNumber, err)
+			continue
+		}
+		ft.Record(fees)
+	}
+}
+
+// QueueStats returns the block queue's counters
+func (ft *FeeTracker) QueueStats() FeeQueueStats {
+	ft.mu.RLock()
+	defer ft.mu.RUnlock()
+	return ft.queueStatsLocked()
+}
+
+// queueStatsLocked is QueueStats for callers holding ft.mu
+func (ft *FeeTracker) queueStatsLocked() FeeQueueStats {
+	return FeeQueueStats{
+		Depth:       len(ft.queue),
+		Capacity:    cap(ft.queue),
+		Processed:   ft.processed,
+		Failed:      ft.failed,
+		Dropped:     ft.dropped,
+		LastDropped: ft.lastDropped,
+	}
+}
+
+// fetchBlockFees loads the block and its receipts and computes the fee split
+func (ft *FeeTracker) fetchBlockFees(blockNumber int64) (*BlockFees, error) {
+	block, err := ft.client.GetBlockByNumber(blockNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	receipts, err := ft.client.GetBlockReceipts(blockNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	correlator := GetTxLifecycleCorrelator()
+	for _, receipt := range receipts {
+		correlator.OnReceipt(receipt.TransactionHash, receipt.Status == "0x1", hexutil.Uint64OrZero(receipt.GasUsed))
+	}
+
+	// The same receipts give real success/revert and log counts
+	GetExecStatsTracker().Record(ComputeBlockExecStats(blockNumber, receipts))
+	GetRevertTracker().Observe(blockNumber, receipts)
+	GetOpcodeTracer().Observe(blockNumber, receipts)
+	if index := GetAddressIndex(); index != nil {
+		index.ObserveReceipts(blockNumber, hexutil.Int64OrZero(block.Timestamp), receipts)
+	}
+
+	// and the included priority fees for /api/v1/gas/estimate
+	GetFeeMarket().Record(block, receipts)
+	if exporter := GetAnalyticsExporter(); exporter != nil {
+		exporter.ObserveBlock(block, receipts)
+	}
+
+	return ComputeBlockFees(block, receipts), nil
+}
+
+// ComputeBlockFees derives total, burned and priority fees from a block and its receipts
+func ComputeBlockFees(block *RPCBlock, receipts []RPCReceipt) *BlockFees {
+	baseFee := hexutil.BigOrZero(block.BaseFeePerGas)
+	totalFees := new(big.Int)
+	var gasUsed uint64
+
+	for _, receipt := range receipts {
+		receiptGas := hexutil.BigOrZero(receipt.GasUsed)
+		price := hexutil.BigOrZero(receipt.EffectiveGasPrice)
+		totalFees.Add(totalFees, new(big.Int).Mul(receiptGas, price))
+		gasUsed += receiptGas.Uint64()
+	}
+
+	burned := new(big.Int).Mul(baseFee, new(big.Int).SetUint64(gasUsed))
+	priority := new(big.Int).Sub(totalFees, burned)
+	if priority.Sign() < 0 {
+		// effectiveGasPrice below base fee should not happen; avoid negative flows
+		priority.SetInt64(0)
+	}
+
+	number := hexutil.Int64OrZero(block.Number)
+	return &BlockFees{
+		BlockNumber:   number,
+		Epoch:         GetChainParams().Epoch(number),
+		Miner:         block.Miner,
+		TxCount:       len(receipts),
+		GasUsed:       gasUsed,
+		BaseFeePerGas: baseFee,
+		TotalFees:     totalFees,
+		BurnedFees:    burned,
+		PriorityFees:  priority,
+		Timestamp:     hexutil.Int64OrZero(block.Timestamp),
+	}
+}
+
+// Record adds a block's fees to the cumulative and per-epoch totals
+func (ft *FeeTracker) Record(fees *BlockFees) {
+	ft.mu.Lock()
+	defer ft.mu.Unlock()
+
+	ft.processed++
+	ft.cumulative.add(fees)
+
+	epoch, exists := ft.epochs[fees.Epoch]
+	if !exists {
+		epoch = newFeeTotals()
+		ft.epochs[fees.Epoch] = epoch
+		// Drop the oldest epochs
+		for e := range ft.epochs {
+			if e <= fees.Epoch-int64(ft.maxEpochs) {
+				delete(ft.epochs, e)
+			}
+		}
+	}
+	epoch.add(fees)
+
+	ft.recent = append(ft.recent, fees)
+	if len(ft.recent) > ft.maxRecent {
+		ft.recent = ft.recent[1:]
+	}
+}
+
+// Latest returns the most recently processed block fees, or nil
+func (ft *FeeTracker) Latest() *BlockFees {
+	ft.mu.RLock()
+	defer ft.mu.RUnlock()
+	if len(ft.recent) == 0 {
+		return nil
+	}
+	return ft.recent[len(ft.recent)-1]
+}
+
+// GetFeeSummary returns cumulative, per-epoch and recent block fee data
+func (ft *FeeTracker) GetFeeSummary(recentCount int) FeeSummary {
+	ft.mu.RLock()
+	defer ft.mu.RUnlock()
+
+	epochs := make(map[string]FeeTotals, len(ft.epochs))
+	for epoch, totals := range ft.epochs {
+		epochs[strconv.FormatInt(epoch, 10)] = totals.summary()
+	}
+
+	if recentCount > len(ft.recent) {
+		recentCount = len(ft.recent)
+	}
+	recent := make([]BlockFeeSummary, 0, recentCount)
+	for i := len(ft.recent) - 1; i >= len(ft.recent)-recentCount; i-- {
+		fees := ft.recent[i]
+		recent = append(recent, BlockFeeSummary{
+			BlockNumber:   fees.BlockNumber,
+			Epoch:         fees.Epoch,
+			Miner:         fees.Miner,
+			TxCount:       fees.TxCount,
+			GasUsed:       fees.GasUsed,
+			BaseFeePerGas: fees.BaseFeePerGas.String(),
+			TotalFees:     fees.TotalFees.String(),
+			BurnedFees:    fees.BurnedFees.String(),
+			PriorityFees:  fees.PriorityFees.String(),
+			Timestamp:     fees.Timestamp,
+		})
+	}
+
+	return FeeSummary{
+		Cumulative: ft.cumulative.summary(),
+		Epochs:     epochs,
+		Recent:     recent,
+		Queue:      ft.queueStatsLocked(),
+		Timestamp:  time.Now().Unix(),
+	}
+}
+
+// FeeFlow returns the fee flow segment for the waterfall: fees paid by
+// executed transactions split into burned base fees and validator priority fees
+func (ft *FeeTracker) FeeFlow() map[string]interface{} {
+	latest := ft.Latest()
+	if latest == nil {
+		return nil
+	}
+
+	return map[string]interface{}{
+		"block_number": latest.BlockNumber,
+		"nodes": []map[string]interface{}{
+			{"id": "fees_paid", "label": "Fees Paid", "color": "#F44336"},
+			{"id": "fees_burned", "label": "Burned (Base Fee)", "color": "#757575"},
+			{"id": "fees_validator", "label": "Validator (Priority)", "color": "#8BC34A"},
+		},
+		"links": []map[string]interface{}{
+			{"source": "fees_paid", "target": "fees_burned", "value": weiToMON(latest.BurnedFees)},
+			{"source": "fees_paid", "target": "fees_validator", "value": weiToMON(latest.PriorityFees)},
+		},
+		"unit": "MON",
+	}
+}
+
+// handleFees returns cumulative and per-epoch fee totals
+func handleFees(c *gin.Context) {
+	ft := GetFeeTracker()
+	if ft == nil {
+		c.JSON(http.StatusServiceUnavailable, APIError{Error: "Fee tracker not initialized"})
+		return
+	}
+
+	recent, err := strconv.Atoi(c.DefaultQuery("recent", "20"))
+	if err != nil || recent < 0 {
+		c.JSON(http.StatusBadRequest, APIError{Error: "recent must be a non-negative integer"})
+		return
+	}
+
+	c.JSON(http.StatusOK, ft.GetFeeSummary(recent))
+}
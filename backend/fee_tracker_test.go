@@ -0,0 +1,19 @@
+package main
+
+import "testing"
+
+// TestFeeTrackerCountsDrops counts the blocks rejected with the queue full
+func TestFeeTrackerCountsDrops(t *testing.T) {
+	ft := NewFeeTracker(nil) // No worker: the queue only fills
+	for number := int64(1); number <= 102; number++ {
+		ft.Enqueue(number)
+	}
+
+	stats := ft.GetFeeSummary(0).Queue
+	if stats.Depth != 100 || stats.Capacity != 100 {
+		t.Errorf("queue %d/%d, want 100/100", stats.Depth, stats.Capacity)
+	}
+	if stats.Dropped != 2 || stats.LastDropped != 102 {
+		t.Errorf("dropped %d, last %d; want 2, 102", stats.Dropped, stats.LastDropped)
+	}
+}
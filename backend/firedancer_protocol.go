@@ -12,14 +12,46 @@ import (
 // Firedancer protocol message types
 
 type FiredancerMessage struct {
-	Topic string      `json:"topic"`
-	Key   string      `json:"key"`
-	Value interface{} `json:"value,omitempty"`
-	ID    *int        `json:"id,omitempty"`
+	Topic    string      `json:"topic"`
+	Key      string      `json:"key"`
+	Value    interface{} `json:"value,omitempty"`
+	ID       *int        `json:"id,omitempty"`
+	Seq      uint64      `json:"seq,omitempty"`       // Broadcast sequence number
+	ServerTS int64       `json:"server_ts,omitempty"` // Unix ms, set with Seq
+	Chain    string      `json:"chain,omitempty"`     // Chain name; the primary chain unless set
+}
+
+// messageSender delivers a protocol message to a single client, regardless of
+// transport (WebSocket or SSE)
+type messageSender func(msg interface{}) error
+
+// wsSender returns a messageSender writing to a registered WebSocket connection
+func wsSender(conn *websocket.Conn) messageSender {
+	return func(msg interface{}) error {
+		err := safeWriteJSON(conn, msg)
+		if err != nil {
+			reapWSClient(conn, "write failed")
+		}
+		return err
+	}
+}
+
+// messageTopic extracts the topic of an outbound message for filtering
+func messageTopic(msg interface{}) string {
+	switch m := msg.(type) {
+	case FiredancerMessage:
+		return m.Topic
+	case *FiredancerMessage:
+		return m.Topic
+	case map[string]interface{}:
+		topic, _ := m["topic"].(string)
+		return topic
+	}
+	return ""
 }
 
 // Summary messages
-func sendInitialSummaryMessages(conn *websocket.Conn) error {
+func sendInitialSummaryMessages(send messageSender) error {
 	messages := []FiredancerMessage{
 		{
 			Topic: "summary",
@@ -34,7 +66,7 @@ func sendInitialSummaryMessages(conn *websocket.Conn) error {
 		{
 			Topic: "summary",
 			Key:   "identity_key",
-			Value: "MonadValidator1111111111111111111111111",
+			Value: identityKey(),
 		},
 		{
 			Topic: "summary",
@@ -44,48 +76,35 @@ func sendInitialSummaryMessages(conn *websocket.Conn) error {
 		{
 			Topic: "summary",
 			Key:   "startup_progress",
-			Value: map[string]interface{}{
-				"phase":                                                 "running",
-				"downloading_full_snapshot_slot":                        nil,
-				"downloading_full_snapshot_peer":                        nil,
-				"downloading_full_snapshot_elapsed_secs":                nil,
-				"downloading_full_snapshot_remaining_secs":              nil,
-				"downloading_full_snapshot_throughput":                  nil,
-				"downloading_full_snapshot_total_bytes":                 nil,
-				"downloading_full_snapshot_current_bytes":               nil,
-				"downloading_incremental_snapshot_slot":                 nil,
-				"downloading_incremental_snapshot_peer":                 nil,
-				"downloading_incremental_snapshot_elapsed_secs":         nil,
-				"downloading_incremental_snapshot_remaining_secs":       nil,
-				"downloading_incremental_snapshot_throughput":           nil,
-				"downloading_incremental_snapshot_total_bytes":          nil,
-				"downloading_incremental_snapshot_current_bytes":        nil,
-				"ledger_slot":                                           nil,
-				"ledger_max_slot":                                       nil,
-				"waiting_for_supermajority_slot":                        nil,
-				"waiting_for_supermajority_stake_percent":               nil,
-			},
+			Value: currentStartupProgress(),
 		},
 		{
 			Topic: "summary",
 			Key:   "vote_state",
-			Value: "non-voting",
+			Value: currentVoteStatus().State,
 		},
 	}
 
 	for _, msg := range messages {
-		if err := safeWriteJSON(conn, msg); err != nil {
+		if err := send(msg); err != nil {
 			return err
 		}
 	}
 
-	return nil
+	// block_engine, tile and identity topics expected by the Firedancer frontend
+	return sendExtraTopicMessages(send)
 }
 
-// Send peers data to satisfy startup screen requirements
-func sendPeersMessage(conn *websocket.Conn) error {
+// buildPeerList returns the peer/validator entries sent in peers updates.
+// The local validator, when known, is the first active entry and the only
+// one with is_self set.
+func buildPeerList() []map[string]interface{} {
 	// Get node name from config
 	nodeName := getNodeName()
+	selfAddress := ""
+	if lt := GetLeaderTracker(); lt != nil {
+		selfAddress = lt.SelfAddress()
+	}
 
 	// Fixed validator data for Monad testnet
 	// These values can be updated manually as needed
@@ -100,16 +119,19 @@ func sendPeersMessage(conn *websocket.Conn) error {
 		stakePerValidator = int64(totalStake / float64(totalValidators))
 	}
 
-	// Convert MON to "lamports" equivalent (1 MON = 1e18 smallest units)
-	activeStakeLamports := uint64(float64(activeValidators) * float64(stakePerValidator))
-
 	// Create validator list
 	validators := make([]map[string]interface{}, 0)
 
 	// Add active validators
 	for i := 0; i < activeValidators; i++ {
+		identity, name := fmt.Sprintf("MonadValidator%d", i+1), fmt.Sprintf("%s-%d", nodeName, i+1)
+		isSelf := i == 0 && selfAddress != ""
+		if isSelf {
+			identity, name = selfAddress, nodeName
+		}
 		validators = append(validators, map[string]interface{}{
-			"identity_pubkey": fmt.Sprintf("MonadValidator%d", i+1),
+			"identity_pubkey": identity,
+			"is_self":         isSelf,
 			"gossip": map[string]interface{}{
 				"wallclock":     time.Now().Unix(),
 				"shred_version": 1,
@@ -129,7 +151,7 @@ func sendPeersMessage(conn *websocket.Conn) error {
 				},
 			},
 			"info": map[string]interface{}{
-				"name":     fmt.Sprintf("%s-%d", nodeName, i+1),
+				"name":     name,
 				"details":  nil,
 				"website":  nil,
 				"icon_url": nil,
@@ -141,6 +163,7 @@ func sendPeersMessage(conn *websocket.Conn) error {
 	for i := 0; i < offlineValidators; i++ {
 		validators = append(validators, map[string]interface{}{
 			"identity_pubkey": fmt.Sprintf("MonadValidatorOffline%d", i+1),
+			"is_self":         false,
 			"gossip": map[string]interface{}{
 				"wallclock":     time.Now().Unix(),
 				"shred_version": 1,
@@ -173,6 +196,7 @@ func sendPeersMessage(conn *websocket.Conn) error {
 	for i := 0; i < rpcCount; i++ {
 		validators = append(validators, map[string]interface{}{
 			"identity_pubkey": fmt.Sprintf("MonadRPC%d", i+1),
+			"is_self":         false,
 			"gossip": map[string]interface{}{
 				"wallclock":     time.Now().Unix(),
 				"shred_version": 1,
@@ -190,6 +214,33 @@ func sendPeersMessage(conn *websocket.Conn) error {
 		})
 	}
 
+	return validators
+}
+
+// Send peers data to satisfy startup screen requirements
+func sendPeersMessage(send messageSender) error {
+	validators := buildPeerList()
+
+	// Summarize for logging: voting entries are validators, the rest RPC nodes
+	activeValidators, offlineValidators, rpcCount := 0, 0, 0
+	activeStakeLamports := int64(0)
+	for _, peer := range validators {
+		votes, _ := peer["vote"].([]map[string]interface{})
+		if len(votes) == 0 {
+			rpcCount++
+			continue
+		}
+		if delinquent, _ := votes[0]["delinquent"].(bool); delinquent {
+			offlineValidators++
+			continue
+		}
+		activeValidators++
+		if stake, ok := votes[0]["activated_stake"].(int64); ok {
+			activeStakeLamports += stake
+		}
+	}
+	totalValidators := activeValidators + offlineValidators
+
 	peersMsg := FiredancerMessage{
 		Topic: "peers",
 		Key:   "update",
@@ -202,22 +253,20 @@ func sendPeersMessage(conn *websocket.Conn) error {
 		totalValidators, activeValidators, offlineValidators,
 		rpcCount, activeStakeLamports)
 
-	return safeWriteJSON(conn, peersMsg)
+	return send(peersMsg)
 }
 
 // Send epoch information
-func sendEpochMessage(conn *websocket.Conn) error {
+func sendEpochMessage(send messageSender) error {
 	// Get current epoch from Monad
-	epoch, err := monadClient.GetCurrentEpoch()
+	epoch, err := GetMonadClient().GetCurrentEpoch()
 	if err != nil {
 		log.Printf("Failed to get current epoch: %v, using default", err)
 		epoch = 0
 	}
 
-	// Calculate epoch boundaries (50,000 blocks per epoch)
-	epochSize := int64(50000)
-	startSlot := epoch * epochSize
-	endSlot := (epoch + 1) * epochSize
+	// Calculate epoch boundaries
+	startSlot, endSlot := GetChainParams().EpochBounds(epoch)
 
 	epochMsg := FiredancerMessage{
 		Topic: "epoch",
@@ -235,17 +284,21 @@ func sendEpochMessage(conn *websocket.Conn) error {
 		},
 	}
 
-	return safeWriteJSON(conn, epochMsg)
+	return send(epochMsg)
 }
 
 // Send periodic updates
-func sendFiredancerUpdates(conn *websocket.Conn) {
-	// Update every 200ms to catch all blocks (Monad block time is 400ms)
-	ticker := time.NewTicker(200 * time.Millisecond)
+func sendFiredancerUpdates(send messageSender) {
+	// Skip messages unchanged since the last tick (re-sent as heartbeats)
+	send = dedupSender(send)
+
+	// Update every 200ms by default to catch all blocks (Monad block time is 400ms)
+	ticker := newCadenceTicker(updateCadence)
 	defer ticker.Stop()
 
 	pingID := 0
 	lastBlockHeight := int64(0)
+	lastSpeculative, lastFinalized := int64(0), int64(0)
 	lastTPSUpdate := time.Now()
 
 	for {
@@ -253,7 +306,7 @@ func sendFiredancerUpdates(conn *websocket.Conn) {
 		case <-ticker.C:
 			// Fetch fresh metrics directly from Monad on each update
 			// This ensures we don't miss any blocks
-			consensus, err := monadClient.GetConsensusMetrics()
+			consensus, err := GetMonadClient().GetConsensusMetrics()
 			if err != nil {
 				log.Printf("Error fetching consensus metrics: %v", err)
 				continue
@@ -277,7 +330,7 @@ func sendFiredancerUpdates(conn *websocket.Conn) {
 				Value: nil,
 				ID:    &pingID,
 			}
-			if err := safeWriteJSON(conn, pingMsg); err != nil {
+			if err := send(pingMsg); err != nil {
 				log.Printf("Error sending ping: %v", err)
 				return
 			}
@@ -288,7 +341,7 @@ func sendFiredancerUpdates(conn *websocket.Conn) {
 				Key:   "estimated_slot",
 				Value: currentBlockHeight,
 			}
-			if err := safeWriteJSON(conn, estimatedSlotMsg); err != nil {
+			if err := send(estimatedSlotMsg); err != nil {
 				log.Printf("Error sending estimated_slot: %v", err)
 				return
 			}
@@ -299,7 +352,7 @@ func sendFiredancerUpdates(conn *websocket.Conn) {
 				Key:   "root_slot",
 				Value: currentBlockHeight,
 			}
-			if err := safeWriteJSON(conn, rootSlotMsg); err != nil {
+			if err := send(rootSlotMsg); err != nil {
 				log.Printf("Error sending root_slot: %v", err)
 				return
 			}
@@ -309,27 +362,64 @@ func sendFiredancerUpdates(conn *websocket.Conn) {
 				Key:   "completed_slot",
 				Value: currentBlockHeight,
 			}
-			if err := safeWriteJSON(conn, completedSlotMsg); err != nil {
+			if err := send(completedSlotMsg); err != nil {
 				log.Printf("Error sending completed_slot: %v", err)
 				return
 			}
 
-			// Calculate different TPS metrics from subscriber
+			// Send speculative and finalized heads independently as they move
+			if ht := GetHeadTracker(); ht != nil {
+				speculative, finalized := ht.Heads()
+				headsMoved := false
+
+				if speculative != lastSpeculative {
+					lastSpeculative = speculative
+					headsMoved = true
+					if err := send(FiredancerMessage{Topic: "summary", Key: "speculative_slot", Value: speculative}); err != nil {
+						log.Printf("Error sending speculative_slot: %v", err)
+						return
+					}
+				}
+
+				if finalized != lastFinalized {
+					lastFinalized = finalized
+					headsMoved = true
+					if err := send(FiredancerMessage{Topic: "summary", Key: "finalized_slot", Value: finalized}); err != nil {
+						log.Printf("Error sending finalized_slot: %v", err)
+						return
+					}
+				}
+
+				if headsMoved && finalized > 0 {
+					if err := send(FiredancerMessage{Topic: "summary", Key: "finality_gap", Value: ht.FinalityGap()}); err != nil {
+						log.Printf("Error sending finality_gap: %v", err)
+						return
+					}
+				}
+			}
+
+			// Calculate different TPS metrics from the resolved source
 			var oneSecondTPS, avgTPS, instantTPS float64
 			var txCount int
-			if monadSubscriber != nil && monadSubscriber.IsConnected() {
-				oneSecondTPS = monadSubscriber.calculateOneSecondTPS()
-				avgTPS = monadSubscriber.calculateAverageTPS()
-				instantTPS = monadSubscriber.getInstantTPS()
+			tpsSource, tpsProvenance := GetSourceResolver().Resolve(MetricTPS)
+			if tpsSource != nil && tpsSource.Name() == "prometheus_metrics" {
+				promTPS := GetPrometheusCollector().GetMetrics().TPS60s
+				oneSecondTPS = promTPS
+				avgTPS = promTPS
+				instantTPS = promTPS
+			} else if subscriber := GetSubscriber(); tpsSource != nil && tpsSource.Name() == "block_estimation" && subscriber != nil {
+				oneSecondTPS = subscriber.calculateOneSecondTPS()
+				avgTPS = subscriber.calculateAverageTPS()
+				instantTPS = subscriber.getInstantTPS()
 
 				// Get transaction count from latest block
-				if block := monadSubscriber.GetLatestBlock(); block != nil {
+				if block := subscriber.GetLatestBlock(); block != nil {
 					txCount = block.Transactions
 				}
 
 				// Add to history ONLY on new blocks (for chart)
 				if isNewBlock {
-					monadSubscriber.addTPSToHistory(oneSecondTPS, avgTPS, instantTPS, txCount)
+					subscriber.addTPSToHistory(oneSecondTPS, avgTPS, instantTPS, txCount)
 					lastBlockHeight = currentBlockHeight
 				}
 			} else {
@@ -345,97 +435,64 @@ func sendFiredancerUpdates(conn *websocket.Conn) {
 				estimatedTpsMsg := FiredancerMessage{
 					Topic: "summary",
 					Key:   "estimated_tps",
-					Value: map[string]interface{}{
-						"total":           oneSecondTPS,  // 1-second TPS
-						"vote":            0,
-						"nonvote_success": avgTPS,        // Average TPS
-						"nonvote_failed":  instantTPS,    // Instant TPS per block
-						"tx_count":        txCount,       // Latest block tx count
+					Value: EstimatedTPS{
+						Total:          oneSecondTPS,
+						NonvoteSuccess: avgTPS,
+						NonvoteFailed:  instantTPS,
+						TxCount:        txCount,
+						Provenance:     tpsProvenance,
 					},
 				}
-				if err := safeWriteJSON(conn, estimatedTpsMsg); err != nil {
+				if err := send(estimatedTpsMsg); err != nil {
 					log.Printf("Error sending estimated_tps: %v", err)
 					return
 				}
 				if shouldUpdateTPS {
 					lastTPSUpdate = time.Now()
+
+					// Refresh tile status and identity balance once per second
+					if err := sendExtraTopicMessages(send); err != nil {
+						log.Printf("Error sending extra topics: %v", err)
+						return
+					}
 				}
 			}
 
-			// Send Monad waterfall (NEW: Monad lifecycle-aligned)
-			// Generate waterfall data using new Monad-specific structure
-			monadWaterfallData := GenerateMonadWaterfall()
+			// Send Monad waterfall (NEW: Monad lifecycle-aligned). Messages with the
+			// same content for every client are built and encoded once per tick.
+			waterfallMsg := sharedTickMessage("monad_waterfall_v2", func() interface{} {
+				// Generate waterfall data using new Monad-specific structure
+				monadWaterfallData := GenerateMonadWaterfallFor(waterfallVersionStream)
 
-			// Debug: Log waterfall data source
-			if metadata, ok := monadWaterfallData["metadata"].(map[string]interface{}); ok {
-				if source, ok := metadata["source"].(string); ok {
-					log.Printf("🌊 Monad Waterfall source: %s", source)
+				// Debug: Log waterfall data source
+				if metadata, ok := monadWaterfallData["metadata"].(map[string]interface{}); ok {
+					if source, ok := metadata["source"].(string); ok {
+						log.Printf("🌊 Monad Waterfall source: %s", source)
+					}
 				}
-			}
 
-			// Send NEW waterfall format (nodes + links for Sankey diagram)
-			waterfallMsg := FiredancerMessage{
-				Topic: "summary",
-				Key:   "monad_waterfall_v2",
-				Value: monadWaterfallData,
-			}
-			if err := safeWriteJSON(conn, waterfallMsg); err != nil {
+				// NEW waterfall format (nodes + links for Sankey diagram)
+				return FiredancerMessage{
+					Topic: "summary",
+					Key:   "monad_waterfall_v2",
+					Value: monadWaterfallData,
+				}
+			})
+			if err := send(waterfallMsg); err != nil {
 				log.Printf("Error sending Monad waterfall v2: %v", err)
 				return
 			}
 
 			// Also send legacy waterfall format for backward compatibility
 			// TODO: Remove after frontend is fully migrated to v2
-			legacyWaterfallData := GenerateWaterfallFromSubscriber()
-			waterfallIn := legacyWaterfallData["in"].(map[string]interface{})
-			waterfallOut := legacyWaterfallData["out"].(map[string]interface{})
-
-			legacyWaterfallMsg := FiredancerMessage{
-				Topic: "summary",
-				Key:   "live_txn_waterfall",
-				Value: map[string]interface{}{
-					"next_leader_slot": nil,
-					"waterfall": map[string]interface{}{
-						"in": map[string]interface{}{
-							"quic":           waterfallIn["rpc"],
-							"udp":            waterfallIn["p2p"],
-							"gossip":         waterfallIn["gossip"],
-							"pack_cranked":   0,
-							"pack_retained":  0,
-							"resolv_retained": 0,
-							"block_engine":   0,
-						},
-						"out": map[string]interface{}{
-							"net_overrun":           0,
-							"quic_overrun":          0,
-							"quic_frag_drop":        0,
-							"quic_abandoned":        0,
-							"tpu_quic_invalid":      0,
-							"tpu_udp_invalid":       0,
-							"verify_overrun":        0,
-							"verify_parse":          0,
-							"verify_failed":         waterfallOut["verify_failed"],
-							"verify_duplicate":      waterfallOut["nonce_failed"],
-							"dedup_duplicate":       waterfallOut["nonce_failed"],
-							"resolv_lut_failed":     waterfallOut["balance_failed"],
-							"resolv_expired":        waterfallOut["pool_fee_dropped"],
-							"resolv_no_ledger":      0,
-							"resolv_ancient":        0,
-							"resolv_retained":       0,
-							"pack_invalid":          0,
-							"pack_invalid_bundle":   0,
-							"pack_retained":         0,
-							"pack_leader_slow":      0,
-							"pack_wait_full":        waterfallOut["pool_full"],
-							"pack_expired":          0,
-							"bank_invalid":          waterfallOut["exec_failed"],
-							"block_success":         waterfallOut["exec_parallel"],
-							"block_fail":            waterfallOut["exec_sequential"],
-						},
-					},
-				},
-			}
-			if err := safeWriteJSON(conn, legacyWaterfallMsg); err != nil {
+			legacyWaterfallMsg := sharedTickMessage("live_txn_waterfall", func() interface{} {
+				return FiredancerMessage{
+					Topic: "summary",
+					Key:   "live_txn_waterfall",
+					Value: buildLiveTxnWaterfall(),
+				}
+			})
+			if err := send(legacyWaterfallMsg); err != nil {
 				log.Printf("Error sending legacy waterfall: %v", err)
 				return
 			}
@@ -443,24 +500,36 @@ func sendFiredancerUpdates(conn *websocket.Conn) {
 			// Send MonadBFT consensus state
 			consensusTracker := GetConsensusTracker()
 			if consensusTracker != nil {
-				consensusStateMsg := FiredancerMessage{
-					Topic: "summary",
-					Key:   "monad_consensus_state",
-					Value: consensusTracker.GetConsensusState(),
-				}
-				if err := safeWriteJSON(conn, consensusStateMsg); err != nil {
+				consensusStateMsg := sharedTickMessage("monad_consensus_state", func() interface{} {
+					return FiredancerMessage{
+						Topic: "summary",
+						Key:   "monad_consensus_state",
+						Value: consensusTracker.GetConsensusState(),
+					}
+				})
+				if err := send(consensusStateMsg); err != nil {
 					log.Printf("Error sending consensus state: %v", err)
 					return
 				}
 			}
 
-			// Send vote distance
+			// Send vote state and distance (unchanged values are deduplicated)
+			voteStatus := currentVoteStatus()
+			voteStateMsg := FiredancerMessage{
+				Topic: "summary",
+				Key:   "vote_state",
+				Value: voteStatus.State,
+			}
+			if err := send(voteStateMsg); err != nil {
+				log.Printf("Error sending vote_state: %v", err)
+				return
+			}
 			voteDistanceMsg := FiredancerMessage{
 				Topic: "summary",
 				Key:   "vote_distance",
-				Value: 0,
+				Value: voteStatus.Distance,
 			}
-			if err := safeWriteJSON(conn, voteDistanceMsg); err != nil {
+			if err := send(voteDistanceMsg); err != nil {
 				log.Printf("Error sending vote_distance: %v", err)
 				return
 			}
@@ -468,8 +537,8 @@ func sendFiredancerUpdates(conn *websocket.Conn) {
 			// Send TPS history for the chart ONLY on new blocks
 			if isNewBlock {
 				var tpsHistoryData [][]float64
-				if monadSubscriber != nil && monadSubscriber.IsConnected() {
-					history := monadSubscriber.getTPSHistory()
+				if subscriber := GetSubscriber(); subscriber != nil && subscriber.IsConnected() {
+					history := subscriber.getTPSHistory()
 					// Convert [][5]float64 to [][]float64
 					tpsHistoryData = make([][]float64, len(history))
 					for i, h := range history {
@@ -487,7 +556,7 @@ func sendFiredancerUpdates(conn *websocket.Conn) {
 					Key:   "tps_history",
 					Value: tpsHistoryData,
 				}
-				if err := safeWriteJSON(conn, tpsHistoryMsg); err != nil {
+				if err := send(tpsHistoryMsg); err != nil {
 					log.Printf("Error sending tps_history: %v", err)
 					return
 				}
@@ -513,9 +582,34 @@ func handleFiredancerClientMessage(conn *websocket.Conn, msgBytes []byte) error
 
 	// Handle subscription requests
 	if topic, ok := clientMsg["topic"].(string); ok {
-		if topic == "summary" {
+		key, _ := clientMsg["key"].(string)
+		params, _ := clientMsg["params"].(map[string]interface{})
+
+		// tx_flow and queries read the primary node, which tenants of
+		// another chain do not see
+		if tenant := tenantOfConn(conn); tenant != nil && !tenant.primary() && (topic == "tx_flow" || topic == "query") {
+			return nil
+		}
+
+		switch topic {
+		case "summary":
 			// Client is subscribing to summary topic
 			// We already send summary updates periodically
+		case "watchlist":
+			handleWatchlistClientMessage(conn, key, params)
+		case "tx_flow":
+			handleTxFlowClientMessage(conn, key, params)
+		case "auth":
+			handleAuthClientMessage(conn, key, params)
+		case "stream":
+			handleStreamClientMessage(conn, key, params)
+		case "query":
+			var id *int
+			if n, ok := clientMsg["id"].(float64); ok {
+				queryID := int(n)
+				id = &queryID
+			}
+			handleQueryClientMessage(conn, key, id, params)
 		}
 	}
 
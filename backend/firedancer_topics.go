@@ -0,0 +1,182 @@
+package main
+
+import (
+	"log"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Extra Firedancer GUI topics. The real Firedancer frontend expects these
+// even though Monad has no direct equivalent for some of them:
+//
+//	block_engine.update   - Monad has no block engine; sent as an explicit null
+//	summary.tiles         - Monad components the dashboard reads from, as tiles
+//	summary.live_tile_timers - per-tile idle fraction (null when unknown)
+//	summary.identity_balance - MONAD_VALIDATOR_ADDRESS balance in gwei, so the
+//	                           frontend's lamports/1e9 display shows MON
+//	summary.vote_balance  - null (Monad has no separate vote account)
+const (
+	topicBlockEngine = "block_engine"
+	topicTiles       = "tiles"
+	topicIdentity    = "identity"
+)
+
+// defaultExtraTopics are emitted unless FIREDANCER_EXTRA_TOPICS says otherwise
+var defaultExtraTopics = []string{topicBlockEngine, topicTiles, topicIdentity}
+
+var (
+	extraTopics     map[string]bool
+	extraTopicsOnce sync.Once
+)
+
+// enabledExtraTopics parses FIREDANCER_EXTRA_TOPICS ("block_engine,tiles,identity"
+// by default, "none" to disable)
+func enabledExtraTopics() map[string]bool {
+	extraTopicsOnce.Do(func() {
+		names := defaultExtraTopics
+		if value, ok := os.LookupEnv("FIREDANCER_EXTRA_TOPICS"); ok {
+			names = splitList(value)
+		}
+
+		extraTopics = make(map[string]bool)
+		for _, name := range names {
+			switch name {
+			case topicBlockEngine, topicTiles, topicIdentity:
+				extraTopics[name] = true
+			case "none":
+			default:
+				log.Printf("Unknown FIREDANCER_EXTRA_TOPICS entry %q ignored", name)
+			}
+		}
+	})
+	return extraTopics
+}
+
+// dashboardTile is one Monad component presented as a Firedancer tile
+type dashboardTile struct {
+	kind    string
+	kindID  int
+	running bool
+}
+
+// buildDashboardTiles maps the node connections the dashboard uses to tiles
+func buildDashboardTiles() []dashboardTile {
+	tiles := []dashboardTile{
+		{kind: "rpc", running: GetSubscriber() != nil && GetSubscriber().IsConnected()},
+		{kind: "mempool", running: GetIPCCollector() != nil && GetIPCCollector().IsHealthy()},
+	}
+
+	for _, status := range GetSourceResolver().Statuses() {
+		if status.Name == "prometheus_metrics" {
+			tiles = append(tiles, dashboardTile{kind: "metric", running: status.Healthy})
+		}
+	}
+
+	// One "event" tile per event ring, numbered in name order
+	rings := GetEventRings()
+	names := make([]string, 0, len(rings))
+	for name := range rings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for i, name := range names {
+		tiles = append(tiles, dashboardTile{kind: "event", kindID: i, running: rings[name].IsConnected()})
+	}
+
+	return tiles
+}
+
+// identityBalanceCache refreshes the validator balance at most every 10s,
+// so per-client update loops do not each hit the RPC
+var identityBalanceCache struct {
+	mu        sync.Mutex
+	gwei      *big.Int
+	fetchedAt time.Time
+}
+
+// identityBalanceGwei returns the validator's balance in gwei, or nil when unknown
+func identityBalanceGwei() *big.Int {
+	lt := GetLeaderTracker()
+	if lt == nil || lt.SelfAddress() == "" {
+		return nil
+	}
+
+	identityBalanceCache.mu.Lock()
+	defer identityBalanceCache.mu.Unlock()
+
+	if time.Since(identityBalanceCache.fetchedAt) < 10*time.Second {
+		return identityBalanceCache.gwei
+	}
+	identityBalanceCache.fetchedAt = time.Now()
+
+	wei, err := GetMonadClient().GetBalance(lt.SelfAddress())
+	if err != nil {
+		log.Printf("Failed to fetch identity balance: %v", err)
+		return identityBalanceCache.gwei
+	}
+	identityBalanceCache.gwei = new(big.Int).Quo(wei, big.NewInt(1_000_000_000))
+	return identityBalanceCache.gwei
+}
+
+// buildExtraTopicMessages returns the enabled extra topic messages
+func buildExtraTopicMessages() []FiredancerMessage {
+	enabled := enabledExtraTopics()
+	var messages []FiredancerMessage
+
+	if enabled[topicBlockEngine] {
+		messages = append(messages, FiredancerMessage{Topic: "block_engine", Key: "update", Value: nil})
+	}
+
+	if enabled[topicTiles] {
+		tiles := buildDashboardTiles()
+		tileList := make([]map[string]interface{}, len(tiles))
+		timers := make([]interface{}, len(tiles))
+		for i, tile := range tiles {
+			tileList[i] = map[string]interface{}{"kind": tile.kind, "kind_id": tile.kindID}
+			// Monad does not expose per-component busy time; a stopped
+			// component is fully idle, a running one is unknown
+			if !tile.running {
+				timers[i] = 1.0
+			}
+		}
+		messages = append(messages,
+			FiredancerMessage{Topic: "summary", Key: "tiles", Value: tileList},
+			FiredancerMessage{Topic: "summary", Key: "live_tile_timers", Value: timers},
+		)
+	}
+
+	if enabled[topicIdentity] {
+		var balance interface{}
+		if gwei := identityBalanceGwei(); gwei != nil {
+			balance = gwei.Uint64()
+		}
+		messages = append(messages,
+			FiredancerMessage{Topic: "summary", Key: "identity_balance", Value: balance},
+			FiredancerMessage{Topic: "summary", Key: "vote_balance", Value: nil},
+		)
+	}
+
+	return messages
+}
+
+// sendExtraTopicMessages sends the enabled extra topic messages
+func sendExtraTopicMessages(send messageSender) error {
+	for _, msg := range buildExtraTopicMessages() {
+		if err := send(msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// identityKey returns the validator address when configured, else the placeholder key
+func identityKey() string {
+	if lt := GetLeaderTracker(); lt != nil && lt.SelfAddress() != "" {
+		return strings.ToLower(lt.SelfAddress())
+	}
+	return "MonadValidator1111111111111111111111111"
+}
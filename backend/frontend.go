@@ -0,0 +1,241 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"io/fs"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// frontendDirFlag serves the frontend from disk, so UI changes show up on
+// reload without rebuilding the binary. FRONTEND_DIR sets it too.
+var frontendDirFlag = flag.String("frontend-dir", os.Getenv("FRONTEND_DIR"),
+	"serve frontend assets from this directory (e.g. frontend/dist), falling back to the embedded copy")
+
+// overlayFS serves files from disk first and from the embedded build when
+// a file is missing on disk
+type overlayFS struct {
+	disk     fs.FS
+	embedded fs.FS
+}
+
+func (o overlayFS) Open(name string) (fs.File, error) {
+	file, err := o.disk.Open(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return o.embedded.Open(name)
+	}
+	return file, err
+}
+
+// layerOf returns the layer that serves name: the disk when the file is
+// there, else the embedded build
+func (o overlayFS) layerOf(name string) fs.FS {
+	if _, err := fs.Stat(o.disk, name); err == nil {
+		return o.disk
+	}
+	return o.embedded
+}
+
+// frontendFS returns the frontend assets: the embedded build, overlaid by
+// --frontend-dir when set
+func frontendFS() fs.FS {
+	embedded, err := fs.Sub(static, "frontend/dist")
+	if err != nil {
+		log.Fatal("Failed to get static files:", err)
+	}
+
+	dir := *frontendDirFlag
+	if dir == "" {
+		return embedded
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		log.Printf("Frontend directory %s not found; serving the embedded frontend", dir)
+		return embedded
+	}
+	log.Printf("Serving frontend from %s (embedded copy as fallback)", dir)
+	return overlayFS{disk: os.DirFS(dir), embedded: embedded}
+}
+
+// readFrontendFile reads an asset by URL path and returns its name in the
+// asset tree, false when it does not exist
+func readFrontendFile(assets fs.FS, urlPath string) (string, []byte, bool) {
+	name := path.Clean(urlPath)[1:]
+	if name == "" || !fs.ValidPath(name) {
+		return "", nil, false
+	}
+	content, err := fs.ReadFile(assets, name)
+	if err != nil {
+		return "", nil, false
+	}
+	return name, content, true
+}
+
+// Build outputs the system mime tables may not know
+func init() {
+	mime.AddExtensionType(".webmanifest", "application/manifest+json")
+	mime.AddExtensionType(".map", "application/json")
+}
+
+// assetContentType picks a content type from the file extension, sniffing
+// the content for unknown extensions
+func assetContentType(name string, content []byte) string {
+	if contentType := mime.TypeByExtension(path.Ext(name)); contentType != "" {
+		return contentType
+	}
+	return http.DetectContentType(content)
+}
+
+// hashedAssetPattern matches bundler output with a content hash in the
+// file name (Vite: assets/index-4f3a9c2e.js), safe to cache for a year
+var hashedAssetPattern = regexp.MustCompile(`(^|/)assets/.+[-.][A-Za-z0-9_-]{8,}\.[A-Za-z0-9]+$`)
+
+// minCompressSize is the smallest asset worth compressing
+const minCompressSize = 1024
+
+// gzipCache holds compressed assets by content hash, so an asset edited in
+// --frontend-dir is never served from a stale entry
+var (
+	gzipCache   = make(map[string][]byte)
+	gzipCacheMu sync.Mutex
+)
+
+// compressible reports whether a content type benefits from compression
+func compressible(contentType string) bool {
+	contentType, _, _ = strings.Cut(contentType, ";")
+	switch {
+	case strings.HasPrefix(contentType, "text/"):
+		return true
+	case strings.HasSuffix(contentType, "json"), strings.HasSuffix(contentType, "javascript"),
+		strings.HasSuffix(contentType, "xml"), contentType == "application/wasm":
+		return true
+	}
+	return false
+}
+
+// acceptedEncodings parses Accept-Encoding into the codings with a nonzero q
+func acceptedEncodings(header string) map[string]bool {
+	accepted := make(map[string]bool)
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if value, err := strconv.ParseFloat(q, 64); err == nil && value == 0 {
+				continue
+			}
+		}
+		accepted[strings.ToLower(strings.TrimSpace(coding))] = true
+	}
+	return accepted
+}
+
+// gzipAsset compresses content once per content hash
+func gzipAsset(hash string, content []byte) []byte {
+	gzipCacheMu.Lock()
+	defer gzipCacheMu.Unlock()
+
+	if compressed, ok := gzipCache[hash]; ok {
+		return compressed
+	}
+	var buf bytes.Buffer
+	gz, _ := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	gz.Write(content)
+	gz.Close()
+
+	// Assets edited on disk leave old entries behind; start over when large
+	if len(gzipCache) >= 512 {
+		gzipCache = make(map[string][]byte)
+	}
+	gzipCache[hash] = buf.Bytes()
+	return buf.Bytes()
+}
+
+// precompressed reads a build's precompressed sibling of an asset (ext is
+// .br or .gz). It only comes from the layer serving the asset and only when
+// not older than it, so an asset edited in --frontend-dir is never served
+// as the embedded build's stale compression.
+func precompressed(assets fs.FS, name, ext string) ([]byte, bool) {
+	if overlay, ok := assets.(overlayFS); ok {
+		assets = overlay.layerOf(name)
+	}
+	asset, err := fs.Stat(assets, name)
+	if err != nil {
+		return nil, false
+	}
+	sibling, err := fs.Stat(assets, name+ext)
+	if err != nil || sibling.ModTime().Before(asset.ModTime()) {
+		return nil, false
+	}
+	compressed, err := fs.ReadFile(assets, name+ext)
+	return compressed, err == nil
+}
+
+// etagMatches reports whether If-None-Match names any encoding of the asset
+func etagMatches(header, hash string) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.Trim(strings.TrimPrefix(strings.TrimSpace(tag), "W/"), `"`)
+		if tag == "*" || tag == hash || strings.HasPrefix(tag, hash+"-") {
+			return true
+		}
+	}
+	return false
+}
+
+// serveFrontendAsset writes an asset with an ETag and cache headers,
+// answering If-None-Match with 304. Brotli is served when the build ships a
+// current precompressed name.br; gzip uses a current name.gz when present,
+// else compresses.
+func serveFrontendAsset(c *gin.Context, assets fs.FS, name string, content []byte) {
+	sum := sha256.Sum256(content)
+	hash := hex.EncodeToString(sum[:8])
+	contentType := assetContentType(name, content)
+
+	c.Header("Cache-Control", "no-cache")
+	if hashedAssetPattern.MatchString(name) {
+		c.Header("Cache-Control", "public, max-age=31536000, immutable")
+	}
+
+	body, encoding := content, ""
+	if compressible(contentType) && len(content) >= minCompressSize {
+		c.Header("Vary", "Accept-Encoding")
+		accepted := acceptedEncodings(c.GetHeader("Accept-Encoding"))
+		if accepted["br"] {
+			if compressed, ok := precompressed(assets, name, ".br"); ok {
+				body, encoding = compressed, "br"
+			}
+		}
+		if encoding == "" && accepted["gzip"] {
+			compressed, ok := precompressed(assets, name, ".gz")
+			if !ok {
+				compressed = gzipAsset(hash, content)
+			}
+			body, encoding = compressed, "gzip"
+		}
+	}
+
+	etag := `"` + hash + `"`
+	if encoding != "" {
+		etag = `"` + hash + "-" + encoding + `"`
+		c.Header("Content-Encoding", encoding)
+	}
+	c.Header("ETag", etag)
+	if etagMatches(c.GetHeader("If-None-Match"), hash) {
+		c.Header("Content-Encoding", "")
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Header("Content-Length", strconv.Itoa(len(body)))
+	c.Data(http.StatusOK, contentType, body)
+}
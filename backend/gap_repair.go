@@ -0,0 +1,215 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"monad-dashboard/hexutil"
+)
+
+// RepairedRange is a run of blocks missed by the subscription and
+// backfilled over RPC
+type RepairedRange struct {
+	FromBlock   int64  `json:"from_block"`
+	ToBlock     int64  `json:"to_block"`
+	FromTime    int64  `json:"from_time,omitempty"` // Block timestamps, unix seconds
+	ToTime      int64  `json:"to_time,omitempty"`
+	Repaired    int    `json:"repaired"`
+	Failed      int    `json:"failed"`
+	Skipped     int64  `json:"skipped"` // Older blocks beyond GAP_REPAIR_MAX_BLOCKS
+	Status      string `json:"status"`  // "repairing", "repaired" or "partial"
+	DetectedAt  int64  `json:"detected_at"`
+	CompletedAt int64  `json:"completed_at,omitempty"`
+}
+
+// GapRepairer detects holes in the subscribed block heights (after a
+// WebSocket drop or a restart) and backfills them over RPC
+type GapRepairer struct {
+	maxBlocks int64
+	pace      time.Duration
+
+	mu         sync.RWMutex
+	lastHeight int64
+	ranges     []*RepairedRange
+	maxRanges  int
+	queue      chan *RepairedRange
+}
+
+// Global gap repairer instance
+var (
+	gapRepairer   *GapRepairer
+	gapRepairerMu sync.RWMutex
+)
+
+// InitializeGapRepairer starts the repair worker. Each gap backfills at most
+// GAP_REPAIR_MAX_BLOCKS (default 2000) of its most recent blocks;
+// GAP_REPAIR=false disables repair. The last height in persisted history
+// (up to an hour old) seeds detection, so blocks missed while the dashboard
+// was down count too.
+func InitializeGapRepairer() *GapRepairer {
+	if os.Getenv("GAP_REPAIR") == "false" {
+		return nil
+	}
+	gr := &GapRepairer{
+		maxBlocks: 2000,
+		pace:      50 * time.Millisecond,
+		maxRanges: 100,
+		queue:     make(chan *RepairedRange, 64),
+	}
+	if value := os.Getenv("GAP_REPAIR_MAX_BLOCKS"); value != "" {
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil && n > 0 {
+			gr.maxBlocks = n
+		} else {
+			log.Printf("Invalid GAP_REPAIR_MAX_BLOCKS %q, using %d", value, gr.maxBlocks)
+		}
+	}
+	if store := GetHistoryStore(); store != nil && !isDemoMode() {
+		now := time.Now()
+		if points := store.Query("block_height", now.Add(-time.Hour), now, 0); len(points) > 0 {
+			gr.lastHeight = int64(points[len(points)-1].Value)
+		}
+	}
+
+	gapRepairerMu.Lock()
+	gapRepairer = gr
+	gapRepairerMu.Unlock()
+
+	go gr.run()
+	return gr
+}
+
+// GetGapRepairer returns the global gap repairer, nil when disabled
+func GetGapRepairer() *GapRepairer {
+	gapRepairerMu.RLock()
+	defer gapRepairerMu.RUnlock()
+	return gapRepairer
+}
+
+// Observe records a subscribed block height, queueing a repair when heights
+// were skipped since the last one
+func (gr *GapRepairer) Observe(number int64) {
+	gr.mu.Lock()
+	last := gr.lastHeight
+	if number > last || number < last-gr.maxBlocks {
+		gr.lastHeight = number // A far lower height is a different chain or a resynced node
+	}
+	if last <= 0 || number <= last+1 {
+		gr.mu.Unlock()
+		return
+	}
+
+	r := &RepairedRange{FromBlock: last + 1, ToBlock: number - 1, Status: "repairing", DetectedAt: time.Now().Unix()}
+	if missing := r.ToBlock - r.FromBlock + 1; missing > gr.maxBlocks {
+		r.Skipped = missing - gr.maxBlocks
+	}
+	gr.ranges = append(gr.ranges, r)
+	if len(gr.ranges) > gr.maxRanges {
+		gr.ranges = gr.ranges[len(gr.ranges)-gr.maxRanges:]
+	}
+	gr.mu.Unlock()
+
+	log.Printf("Block gap detected: %d-%d (%d blocks), backfilling over RPC", r.FromBlock, r.ToBlock, r.ToBlock-r.FromBlock+1)
+	select {
+	case gr.queue <- r:
+	default:
+		gr.finish(r, "partial")
+		log.Printf("Gap repair queue full, leaving blocks %d-%d unrepaired", r.FromBlock, r.ToBlock)
+	}
+}
+
+// run repairs queued gaps one at a time
+func (gr *GapRepairer) run() {
+	for r := range gr.queue {
+		gr.repair(r)
+	}
+}
+
+// repair fetches every missing block and feeds it to the per-block consumers
+func (gr *GapRepairer) repair(r *RepairedRange) {
+	for number := r.FromBlock + r.Skipped; number <= r.ToBlock; number++ {
+		block, err := GetMonadClient().GetBlockByNumber(number)
+		if err != nil {
+			log.Printf("Gap repair: block %d: %v", number, err)
+			gr.mu.Lock()
+			r.Failed++
+			gr.mu.Unlock()
+			continue
+		}
+		header := &BlockHeader{
+			Number:       number,
+			Hash:         block.Hash,
+			Timestamp:    hexutil.Int64OrZero(block.Timestamp),
+			Transactions: len(block.Transactions),
+			GasUsed:      hexutil.Int64OrZero(block.GasUsed),
+			GasLimit:     hexutil.Int64OrZero(block.GasLimit),
+			Miner:        strings.ToLower(block.Miner),
+		}
+		backfillBlock(header)
+
+		gr.mu.Lock()
+		r.Repaired++
+		if r.FromTime == 0 || header.Timestamp < r.FromTime {
+			r.FromTime = header.Timestamp
+		}
+		if header.Timestamp > r.ToTime {
+			r.ToTime = header.Timestamp
+		}
+		gr.mu.Unlock()
+		time.Sleep(gr.pace) // Leave RPC capacity for live traffic
+	}
+
+	status := "repaired"
+	if r.Failed > 0 || r.Skipped > 0 {
+		status = "partial"
+	}
+	gr.finish(r, status)
+	log.Printf("Block gap %d-%d %s: %d repaired, %d failed, %d skipped", r.FromBlock, r.ToBlock, status, r.Repaired, r.Failed, r.Skipped)
+	if store := GetHistoryStore(); store != nil {
+		store.Annotate("gap_repair", fmt.Sprintf("Blocks %d-%d backfilled", r.FromBlock, r.ToBlock),
+			fmt.Sprintf("%d repaired, %d failed, %d skipped", r.Repaired, r.Failed, r.Skipped), "gap_repair")
+	}
+}
+
+func (gr *GapRepairer) finish(r *RepairedRange, status string) {
+	gr.mu.Lock()
+	defer gr.mu.Unlock()
+	r.Status = status
+	r.CompletedAt = time.Now().Unix()
+}
+
+// backfillBlock records a missed block the way the subscription would have:
+// per-block history points at the block's own time, leader slots and fees
+func backfillBlock(header *BlockHeader) {
+	if store := GetHistoryStore(); store != nil {
+		at := time.Unix(header.Timestamp, 0)
+		store.Record("block_height", at, float64(header.Number))
+		store.Record("block_tx_count", at, float64(header.Transactions))
+		store.Record("block_gas_used", at, float64(header.GasUsed))
+	}
+	if lt := GetLeaderTracker(); lt != nil {
+		lt.OnBlock(header)
+	}
+	if ft := GetFeeTracker(); ft != nil {
+		ft.Enqueue(header.Number)
+	}
+	publishBusEvent(busBlocks, busEventBlock, strconv.FormatInt(header.Number, 10), *header)
+}
+
+// Ranges returns repaired ranges whose blocks fall within [from, to]
+func (gr *GapRepairer) Ranges(from, to time.Time) []RepairedRange {
+	gr.mu.RLock()
+	defer gr.mu.RUnlock()
+	ranges := []RepairedRange{}
+	for _, r := range gr.ranges {
+		// Ranges still being repaired have no times yet; include them
+		if r.Repaired == 0 || (r.ToTime >= from.Unix() && r.FromTime <= to.Unix()) {
+			ranges = append(ranges, *r)
+		}
+	}
+	return ranges
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"math"
+	"math/big"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+
+	"monad-dashboard/hexutil"
+)
+
+// feeTiers are the suggested inclusion targets, in blocks
+var feeTiers = []struct {
+	name   string
+	blocks int
+}{
+	{"fast", 1},
+	{"standard", 3},
+	{"slow", 10},
+}
+
+// feeMarketBlock is one block's included priority fees
+type feeMarketBlock struct {
+	number      int64
+	baseFeeGwei float64
+	fullness    float64
+	floorGwei   float64 // 10th percentile tip: the cheapest fees that still got in, ignoring outliers
+}
+
+// inclusionSample is an included transaction whose mempool arrival was seen
+type inclusionSample struct {
+	tipGwei float64
+	blocks  int // Blocks between mempool arrival and inclusion, at least 1
+}
+
+// FeeMarket keeps recent included priority fees and, for transactions seen
+// in the mempool, how many blocks they waited. Both come from the receipts
+// the fee tracker already fetches.
+type FeeMarket struct {
+	mu         sync.RWMutex
+	blocks     []feeMarketBlock
+	maxBlocks  int
+	samples    []inclusionSample
+	maxSamples int
+}
+
+// Global fee market
+var (
+	feeMarket     *FeeMarket
+	feeMarketOnce sync.Once
+)
+
+// GetFeeMarket returns the global fee market, keeping GAS_ESTIMATE_BLOCKS
+// blocks (default 100)
+func GetFeeMarket() *FeeMarket {
+	feeMarketOnce.Do(func() {
+		feeMarket = &FeeMarket{maxBlocks: 100, maxSamples: 5000}
+		if n, ok := positiveIntEnv("GAS_ESTIMATE_BLOCKS"); ok {
+			feeMarket.maxBlocks = int(n)
+		}
+	})
+	return feeMarket
+}
+
+// Record adds a block's priority fees and the inclusion delay of its
+// transactions that passed through the local mempool
+func (m *FeeMarket) Record(block *RPCBlock, receipts []RPCReceipt) {
+	baseFee := hexutil.BigOrZero(block.BaseFeePerGas)
+	entry := feeMarketBlock{
+		number:      hexutil.Int64OrZero(block.Number),
+		baseFeeGwei: weiToGwei(baseFee),
+	}
+	if limit := hexutil.Uint64OrZero(block.GasLimit); limit > 0 {
+		entry.fullness = float64(hexutil.Uint64OrZero(block.GasUsed)) / float64(limit)
+	}
+
+	blockTime := GetChainParams().BlockTime
+	correlator := GetTxLifecycleCorrelator()
+	tips := make([]float64, 0, len(receipts))
+	var samples []inclusionSample
+	for _, receipt := range receipts {
+		tip := new(big.Int).Sub(hexutil.BigOrZero(receipt.EffectiveGasPrice), baseFee)
+		if tip.Sign() < 0 {
+			tip.SetInt64(0)
+		}
+		gwei := weiToGwei(tip)
+		tips = append(tips, gwei)
+
+		lifecycle, ok := correlator.Get(receipt.TransactionHash)
+		if !ok || lifecycle.MempoolAt == nil || lifecycle.IncludedAt == nil || blockTime <= 0 {
+			continue
+		}
+		waited := int(math.Ceil(lifecycle.IncludedAt.Sub(*lifecycle.MempoolAt).Seconds() / blockTime))
+		if waited < 1 {
+			waited = 1
+		}
+		samples = append(samples, inclusionSample{tipGwei: gwei, blocks: waited})
+	}
+	if len(tips) > 0 {
+		sort.Float64s(tips)
+		entry.floorGwei = tips[len(tips)*10/100]
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.blocks = append(m.blocks, entry)
+	if len(m.blocks) > m.maxBlocks {
+		m.blocks = m.blocks[len(m.blocks)-m.maxBlocks:]
+	}
+	m.samples = append(m.samples, samples...)
+	if len(m.samples) > m.maxSamples {
+		m.samples = append(m.samples[:0:0], m.samples[len(m.samples)-m.maxSamples:]...)
+	}
+}
+
+// FeeSuggestion is the suggested priority fee for one inclusion target
+type FeeSuggestion struct {
+	TargetBlocks       int      `json:"target_blocks"`
+	PriorityFeeGwei    float64  `json:"priority_fee_gwei"`
+	MaxFeeGwei         float64  `json:"max_fee_gwei"`         // 2 × base fee + priority fee
+	ObservedSamples    int      `json:"observed_samples"`     // Mempool transactions paying at least the suggestion
+	ObservedWithinRate *float64 `json:"observed_within_rate"` // Share of them included within the target; null without samples
+}
+
+// GasEstimateResponse is the body of /api/v1/gas/estimate
+type GasEstimateResponse struct {
+	Slow        *FeeSuggestion `json:"slow"`     // Within 10 blocks
+	Standard    *FeeSuggestion `json:"standard"` // Within 3 blocks
+	Fast        *FeeSuggestion `json:"fast"`     // Next block
+	BaseFeeGwei float64        `json:"base_fee_gwei"`
+	Confidence  int            `json:"confidence"` // Percentile of recent windows a suggestion would have cleared
+	Blocks      int            `json:"blocks"`
+	FromBlock   int64          `json:"from_block"`
+	ToBlock     int64          `json:"to_block"`
+	AvgFullness float64        `json:"avg_fullness"`
+
+	// Observed fees of mempool transactions by how many blocks they waited:
+	// 10th/50th/90th percentile tip of those included within 1, 3 and 10 blocks
+	IncludedWithin map[string]FeePercentiles `json:"included_within"`
+}
+
+// FeePercentiles are tips of included transactions, in gwei
+type FeePercentiles struct {
+	Samples int     `json:"samples"`
+	P10     float64 `json:"p10"`
+	P50     float64 `json:"p50"`
+	P90     float64 `json:"p90"`
+}
+
+// Estimate suggests priority fees for each target. A transaction waiting N
+// blocks gets in when it beats the floor of any one of them, so the
+// suggestion for N is the confidence percentile, over every window of N
+// consecutive recent blocks, of the lowest floor in the window.
+func (m *FeeMarket) Estimate(confidence int) GasEstimateResponse {
+	m.mu.RLock()
+	blocks := append([]feeMarketBlock(nil), m.blocks...)
+	samples := append([]inclusionSample(nil), m.samples...)
+	m.mu.RUnlock()
+
+	result := GasEstimateResponse{
+		Confidence:     confidence,
+		Blocks:         len(blocks),
+		IncludedWithin: make(map[string]FeePercentiles),
+	}
+	for _, tier := range feeTiers {
+		var tips []float64
+		for _, s := range samples {
+			if s.blocks <= tier.blocks {
+				tips = append(tips, s.tipGwei)
+			}
+		}
+		sort.Float64s(tips)
+		percentiles := FeePercentiles{Samples: len(tips)}
+		if len(tips) > 0 {
+			percentiles.P10 = tips[len(tips)*10/100]
+			percentiles.P50 = tips[len(tips)*50/100]
+			percentiles.P90 = tips[len(tips)*90/100]
+		}
+		result.IncludedWithin[strconv.Itoa(tier.blocks)] = percentiles
+	}
+	if len(blocks) == 0 {
+		return result
+	}
+
+	latest := blocks[len(blocks)-1]
+	result.BaseFeeGwei = latest.baseFeeGwei
+	result.FromBlock, result.ToBlock = blocks[0].number, latest.number
+	for _, b := range blocks {
+		result.AvgFullness += b.fullness
+	}
+	result.AvgFullness /= float64(len(blocks))
+
+	for _, tier := range feeTiers {
+		n := tier.blocks
+		if n > len(blocks) {
+			n = len(blocks)
+		}
+		floors := make([]float64, 0, len(blocks)-n+1)
+		for start := 0; start+n <= len(blocks); start++ {
+			low := blocks[start].floorGwei
+			for _, b := range blocks[start+1 : start+n] {
+				low = math.Min(low, b.floorGwei)
+			}
+			floors = append(floors, low)
+		}
+		sort.Float64s(floors)
+		index := int(math.Ceil(float64(len(floors))*float64(confidence)/100)) - 1
+		if index < 0 {
+			index = 0
+		}
+
+		suggestion := &FeeSuggestion{TargetBlocks: tier.blocks, PriorityFeeGwei: floors[index]}
+		suggestion.MaxFeeGwei = 2*result.BaseFeeGwei + suggestion.PriorityFeeGwei
+		within := 0
+		for _, s := range samples {
+			if s.tipGwei >= suggestion.PriorityFeeGwei {
+				suggestion.ObservedSamples++
+				if s.blocks <= tier.blocks {
+					within++
+				}
+			}
+		}
+		if suggestion.ObservedSamples > 0 {
+			rate := float64(within) / float64(suggestion.ObservedSamples)
+			suggestion.ObservedWithinRate = &rate
+		}
+
+		switch tier.name {
+		case "fast":
+			result.Fast = suggestion
+		case "standard":
+			result.Standard = suggestion
+		case "slow":
+			result.Slow = suggestion
+		}
+	}
+	return result
+}
+
+// handleGasEstimate suggests slow/standard/fast priority fees from recent
+// inclusion data (?confidence= 50-99, default 90)
+func handleGasEstimate(c *gin.Context) {
+	confidence := 90
+	if value := c.Query("confidence"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 50 || n > 99 {
+			c.JSON(http.StatusBadRequest, APIError{Error: "confidence must be an integer from 50 to 99"})
+			return
+		}
+		confidence = n
+	}
+	c.JSON(http.StatusOK, GetFeeMarket().Estimate(confidence))
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Keys used for gas that cannot be attributed to a contract address
+const (
+	gasContractCreation = "contract_creation"
+	gasContractUnknown  = "unknown"
+)
+
+// GasHeatmap aggregates gas consumption per target contract per minute
+type GasHeatmap struct {
+	mu        sync.Mutex
+	buckets   map[int64]map[string]uint64 // Minute (unix) -> contract -> gas
+	retention int                         // Minutes kept
+
+	// Once GasUsage events arrive they are the attribution source and
+	// TransactionEnd totals are ignored, so gas is not counted twice
+	usageEvents bool
+}
+
+// Global gas heatmap instance
+var (
+	gasHeatmap     *GasHeatmap
+	gasHeatmapOnce sync.Once
+)
+
+// GetGasHeatmap returns the global gas heatmap
+func GetGasHeatmap() *GasHeatmap {
+	gasHeatmapOnce.Do(func() {
+		gasHeatmap = &GasHeatmap{
+			buckets:   make(map[int64]map[string]uint64),
+			retention: 60,
+		}
+	})
+	return gasHeatmap
+}
+
+// OnGasUsage attributes gas from a GasUsage event to its contract
+func (h *GasHeatmap) OnGasUsage(address string, gasUsed uint64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.usageEvents = true
+	h.addLocked(address, gasUsed, time.Now())
+}
+
+// OnTransactionEnd attributes a transaction's total gas to its target contract
+func (h *GasHeatmap) OnTransactionEnd(hash string, gasUsed uint64) {
+	if gasUsed == 0 {
+		return
+	}
+
+	address := gasContractUnknown
+	if record, ok := GetTxLifecycleCorrelator().Get(hash); ok && record.ExecStartAt != nil {
+		address = record.To
+		if address == "" {
+			address = gasContractCreation
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.usageEvents {
+		return
+	}
+	h.addLocked(address, gasUsed, time.Now())
+}
+
+// addLocked adds gas to the current minute bucket; caller holds h.mu
+func (h *GasHeatmap) addLocked(address string, gasUsed uint64, now time.Time) {
+	address = strings.ToLower(address)
+	if address == "" {
+		address = gasContractUnknown
+	}
+
+	minute := now.Unix() / 60 * 60
+	bucket, ok := h.buckets[minute]
+	if !ok {
+		bucket = make(map[string]uint64)
+		h.buckets[minute] = bucket
+
+		// Drop minutes that fell out of retention
+		cutoff := minute - int64(h.retention)*60
+		for m := range h.buckets {
+			if m <= cutoff {
+				delete(h.buckets, m)
+			}
+		}
+	}
+	bucket[address] += gasUsed
+}
+
+// ContractGasSeries is one heatmap row
+type ContractGasSeries struct {
+	Address  string   `json:"address"`
+	TotalGas uint64   `json:"total_gas"`
+	Share    float64  `json:"share"` // Fraction of all gas in the window
+	Series   []uint64 `json:"series"`
+
+	Name         string `json:"name,omitempty"`         // Verified contract name
+	Verification string `json:"verification,omitempty"` // exact_match or match
+}
+
+// GasHeatmapResponse is the body of /api/v1/gas/by-contract
+type GasHeatmapResponse struct {
+	Minutes    []int64              `json:"minutes"` // Unix seconds of each column
+	Contracts  []*ContractGasSeries `json:"contracts"`
+	Other      []uint64             `json:"other"`
+	OtherTotal uint64               `json:"other_total"`
+	TotalGas   uint64               `json:"total_gas"`
+	Source     string               `json:"source"` // "gas_usage" or "transaction_end"
+}
+
+// Heatmap returns per-minute gas for the top contracts over the last minutes;
+// remaining contracts are folded into an "other" row
+func (h *GasHeatmap) Heatmap(minutes, limit int) GasHeatmapResponse {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if minutes <= 0 || minutes > h.retention {
+		minutes = h.retention
+	}
+
+	current := time.Now().Unix() / 60 * 60
+	timestamps := make([]int64, minutes)
+	for i := range timestamps {
+		timestamps[i] = current - int64(minutes-1-i)*60
+	}
+
+	rows := make(map[string]*ContractGasSeries)
+	var total uint64
+	for i, minute := range timestamps {
+		for address, gas := range h.buckets[minute] {
+			row, ok := rows[address]
+			if !ok {
+				row = &ContractGasSeries{Address: address, Series: make([]uint64, minutes)}
+				rows[address] = row
+			}
+			row.Series[i] += gas
+			row.TotalGas += gas
+			total += gas
+		}
+	}
+
+	contracts := make([]*ContractGasSeries, 0, len(rows))
+	for _, row := range rows {
+		if total > 0 {
+			row.Share = float64(row.TotalGas) / float64(total)
+		}
+		contracts = append(contracts, row)
+	}
+	sort.Slice(contracts, func(i, j int) bool {
+		return contracts[i].TotalGas > contracts[j].TotalGas
+	})
+
+	other := make([]uint64, minutes)
+	var otherTotal uint64
+	if limit > 0 && len(contracts) > limit {
+		for _, row := range contracts[limit:] {
+			for i, gas := range row.Series {
+				other[i] += gas
+			}
+			otherTotal += row.TotalGas
+		}
+		contracts = contracts[:limit]
+	}
+
+	source := "transaction_end"
+	if h.usageEvents {
+		source = "gas_usage"
+	}
+
+	return GasHeatmapResponse{
+		Minutes:    timestamps,
+		Contracts:  contracts,
+		Other:      other,
+		OtherTotal: otherTotal,
+		TotalGas:   total,
+		Source:     source,
+	}
+}
+
+// handleGasByContract returns the per-minute gas heatmap by contract
+func handleGasByContract(c *gin.Context) {
+	minutes := 30
+	if value := c.Query("minutes"); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			minutes = n
+		}
+	}
+	limit := 10
+	if value := c.Query("limit"); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			limit = n
+		}
+	}
+
+	heatmap := GetGasHeatmap().Heatmap(minutes, limit)
+	for _, contract := range heatmap.Contracts {
+		contract.Name, contract.Verification = contractName(contract.Address)
+	}
+	c.JSON(http.StatusOK, heatmap)
+}
@@ -0,0 +1,269 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Grafana SimpleJSON / Infinity datasource contract, served from the history
+// store under /api/v1/grafana. Point the datasource URL at that prefix.
+
+// grafanaRange is the time range of a Grafana request
+type grafanaRange struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+// grafanaQueryRequest is the body of POST /query
+type grafanaQueryRequest struct {
+	Range         grafanaRange `json:"range"`
+	IntervalMs    int64        `json:"intervalMs"`
+	MaxDataPoints int          `json:"maxDataPoints"`
+	Targets       []struct {
+		Target string `json:"target"`
+		RefID  string `json:"refId"`
+		Type   string `json:"type"` // "timeserie" (default) or "table"
+		Hide   bool   `json:"hide"`
+	} `json:"targets"`
+}
+
+// grafanaAnnotationRequest is the body of POST /annotations
+type grafanaAnnotationRequest struct {
+	Range      grafanaRange           `json:"range"`
+	Annotation GrafanaAnnotationQuery `json:"annotation"`
+}
+
+// GrafanaAnnotationQuery is the annotation definition Grafana sends and
+// expects echoed back
+type GrafanaAnnotationQuery struct {
+	Name       string `json:"name"`
+	Datasource string `json:"datasource"`
+	Enable     bool   `json:"enable"`
+	Query      string `json:"query"` // Annotation kind filter, e.g. "epoch"
+}
+
+// GrafanaSearchRequest is the optional body of POST /search
+type GrafanaSearchRequest struct {
+	Target string `json:"target"`
+}
+
+// GrafanaQueryResult is one target of a /query response: a time series or,
+// for "table" targets, a table
+type GrafanaQueryResult struct {
+	*GrafanaTimeSeries
+	*GrafanaTable
+}
+
+// GrafanaTimeSeries holds [value, timestamp_ms] pairs
+type GrafanaTimeSeries struct {
+	Target     string       `json:"target"`
+	RefID      string       `json:"refId"`
+	Datapoints [][2]float64 `json:"datapoints"`
+}
+
+// GrafanaTable holds (time, value) rows
+type GrafanaTable struct {
+	Type    string          `json:"type"`
+	Columns []GrafanaColumn `json:"columns"`
+	Rows    [][]interface{} `json:"rows"`
+}
+
+// GrafanaColumn is a table column header
+type GrafanaColumn struct {
+	Text string `json:"text"`
+	Type string `json:"type"`
+}
+
+// GrafanaAnnotation is one timeline annotation
+type GrafanaAnnotation struct {
+	Annotation GrafanaAnnotationQuery `json:"annotation"`
+	Time       int64                  `json:"time"` // Unix milliseconds
+	Title      string                 `json:"title"`
+	Text       string                 `json:"text"`
+	Tags       []string               `json:"tags"`
+}
+
+// HistoryResponse is the body of /api/v1/history?series=...
+type HistoryResponse struct {
+	Series         string          `json:"series"`
+	From           int64           `json:"from"`
+	To             int64           `json:"to"`
+	Points         []HistoryPoint  `json:"points"`
+	RepairedRanges []RepairedRange `json:"repaired_ranges,omitempty"` // Blocks backfilled over RPC
+}
+
+// HistorySeriesResponse is the body of /api/v1/history without ?series
+type HistorySeriesResponse struct {
+	Series []string `json:"series"`
+}
+
+// handleGrafanaTest answers the datasource "Save & test" probe
+func handleGrafanaTest(c *gin.Context) {
+	c.String(http.StatusOK, "OK")
+}
+
+// handleGrafanaSearch lists metric names matching the target prefix
+func handleGrafanaSearch(c *gin.Context) {
+	store := GetHistoryStore()
+	if store == nil {
+		c.JSON(http.StatusOK, []string{})
+		return
+	}
+
+	var req GrafanaSearchRequest
+	// Body is optional; older Grafana versions send none
+	_ = c.ShouldBindJSON(&req)
+
+	names := make([]string, 0)
+	for _, name := range store.SeriesNames() {
+		if req.Target == "" || strings.Contains(name, req.Target) {
+			names = append(names, name)
+		}
+	}
+	c.JSON(http.StatusOK, names)
+}
+
+// handleGrafanaQuery returns time series ([value, timestamp_ms] pairs) or tables
+func handleGrafanaQuery(c *gin.Context) {
+	store := GetHistoryStore()
+	if store == nil {
+		c.JSON(http.StatusServiceUnavailable, APIError{Error: "History store not initialized"})
+		return
+	}
+
+	var req grafanaQueryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, APIError{Error: err.Error()})
+		return
+	}
+
+	from, to := req.Range.From, req.Range.To
+	if to.IsZero() {
+		to = time.Now()
+	}
+	if from.IsZero() {
+		from = to.Add(-time.Hour)
+	}
+
+	results := make([]GrafanaQueryResult, 0, len(req.Targets))
+	for _, target := range req.Targets {
+		if target.Hide || target.Target == "" {
+			continue
+		}
+
+		points := store.Query(target.Target, from, to, req.MaxDataPoints)
+
+		if target.Type == "table" {
+			rows := make([][]interface{}, len(points))
+			for i, p := range points {
+				rows[i] = []interface{}{p.Timestamp, p.Value}
+			}
+			results = append(results, GrafanaQueryResult{GrafanaTable: &GrafanaTable{
+				Type: "table",
+				Columns: []GrafanaColumn{
+					{Text: "Time", Type: "time"},
+					{Text: target.Target, Type: "number"},
+				},
+				Rows: rows,
+			}})
+			continue
+		}
+
+		datapoints := make([][2]float64, len(points))
+		for i, p := range points {
+			datapoints[i] = [2]float64{p.Value, float64(p.Timestamp)}
+		}
+		results = append(results, GrafanaQueryResult{GrafanaTimeSeries: &GrafanaTimeSeries{
+			Target:     target.Target,
+			RefID:      target.RefID,
+			Datapoints: datapoints,
+		}})
+	}
+
+	c.JSON(http.StatusOK, results)
+}
+
+// handleGrafanaAnnotations returns timeline annotations in the requested range
+func handleGrafanaAnnotations(c *gin.Context) {
+	store := GetHistoryStore()
+	if store == nil {
+		c.JSON(http.StatusOK, []GrafanaAnnotation{})
+		return
+	}
+
+	var req grafanaAnnotationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, APIError{Error: err.Error()})
+		return
+	}
+
+	annotations := store.Annotations(req.Range.From, req.Range.To, req.Annotation.Query)
+	results := make([]GrafanaAnnotation, len(annotations))
+	for i, a := range annotations {
+		results[i] = GrafanaAnnotation{
+			Annotation: req.Annotation,
+			Time:       a.Timestamp,
+			Title:      a.Title,
+			Text:       a.Text,
+			Tags:       append([]string{a.Kind}, a.Tags...),
+		}
+	}
+	c.JSON(http.StatusOK, results)
+}
+
+// handleHistory returns raw samples of one series
+// Query params: series (required), from/to (unix seconds), max_points
+func handleHistory(c *gin.Context) {
+	store := GetHistoryStore()
+	if store == nil {
+		c.JSON(http.StatusServiceUnavailable, APIError{Error: "History store not initialized"})
+		return
+	}
+
+	series := c.Query("series")
+	if series == "" {
+		c.JSON(http.StatusOK, HistorySeriesResponse{Series: store.SeriesNames()})
+		return
+	}
+
+	now := time.Now()
+	from := now.Add(-time.Hour)
+	to := now
+	if raw := c.Query("from"); raw != "" {
+		sec, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, APIError{Error: "from must be a unix timestamp"})
+			return
+		}
+		from = time.Unix(sec, 0)
+	}
+	if raw := c.Query("to"); raw != "" {
+		sec, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, APIError{Error: "to must be a unix timestamp"})
+			return
+		}
+		to = time.Unix(sec, 0)
+	}
+	maxPoints, err := strconv.Atoi(c.DefaultQuery("max_points", "0"))
+	if err != nil || maxPoints < 0 {
+		c.JSON(http.StatusBadRequest, APIError{Error: "max_points must be a non-negative integer"})
+		return
+	}
+
+	response := HistoryResponse{
+		Series: series,
+		From:   from.Unix(),
+		To:     to.Unix(),
+		Points: store.Query(series, from, to, maxPoints),
+	}
+	// Blocks missed by the subscription and backfilled over RPC
+	if gr := GetGapRepairer(); gr != nil {
+		response.RepairedRanges = gr.Ranges(from, to)
+	}
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,200 @@
+package graphql
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// Resolver produces the value of a root field. The returned value may be any
+// JSON-encodable data; sub-selections are applied to its JSON form.
+type Resolver func(args map[string]interface{}) (interface{}, error)
+
+// Error is a GraphQL error entry
+type Error struct {
+	Message string        `json:"message"`
+	Path    []interface{} `json:"path,omitempty"`
+}
+
+// Response is a GraphQL response body
+type Response struct {
+	Data   map[string]interface{} `json:"data"`
+	Errors []Error                `json:"errors,omitempty"`
+}
+
+// Schema maps root query fields to resolvers
+type Schema struct {
+	Query map[string]Resolver
+}
+
+// Execute parses and runs a query against the schema
+func (s *Schema) Execute(query string, variables map[string]interface{}) *Response {
+	doc, err := Parse(query)
+	if err != nil {
+		return &Response{Errors: []Error{{Message: "syntax error: " + err.Error()}}}
+	}
+
+	resp := &Response{Data: make(map[string]interface{})}
+	for _, field := range doc.Selection {
+		key := field.ResponseKey()
+
+		if field.Name == "__typename" {
+			resp.Data[key] = "Query"
+			continue
+		}
+
+		resolver, ok := s.Query[field.Name]
+		if !ok {
+			resp.Data[key] = nil
+			resp.Errors = append(resp.Errors, Error{
+				Message: fmt.Sprintf("cannot query field %q on type Query", field.Name),
+				Path:    []interface{}{key},
+			})
+			continue
+		}
+
+		args, err := resolveArguments(field.Arguments, variables)
+		if err != nil {
+			resp.Data[key] = nil
+			resp.Errors = append(resp.Errors, Error{Message: err.Error(), Path: []interface{}{key}})
+			continue
+		}
+
+		value, err := resolver(args)
+		if err != nil {
+			resp.Data[key] = nil
+			resp.Errors = append(resp.Errors, Error{Message: err.Error(), Path: []interface{}{key}})
+			continue
+		}
+
+		projected, errs := project(value, field.Selection, []interface{}{key})
+		resp.Data[key] = projected
+		resp.Errors = append(resp.Errors, errs...)
+	}
+	return resp
+}
+
+// resolveArguments substitutes $variables in argument values
+func resolveArguments(args map[string]interface{}, variables map[string]interface{}) (map[string]interface{}, error) {
+	resolved := make(map[string]interface{}, len(args))
+	for name, value := range args {
+		v, err := resolveValue(value, variables)
+		if err != nil {
+			return nil, err
+		}
+		resolved[name] = v
+	}
+	return resolved, nil
+}
+
+func resolveValue(value interface{}, variables map[string]interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case variableRef:
+		val, ok := variables[string(v)]
+		if !ok {
+			return nil, fmt.Errorf("variable $%s is not defined", string(v))
+		}
+		return val, nil
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			resolvedItem, err := resolveValue(item, variables)
+			if err != nil {
+				return nil, err
+			}
+			list[i] = resolvedItem
+		}
+		return list, nil
+	}
+	return value, nil
+}
+
+// project applies a selection set to a resolved value
+func project(value interface{}, selection []*Field, path []interface{}) (interface{}, []Error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	normalized, err := normalize(value)
+	if err != nil {
+		return nil, []Error{{Message: err.Error(), Path: path}}
+	}
+
+	switch v := normalized.(type) {
+	case map[string]interface{}:
+		if len(selection) == 0 {
+			return nil, []Error{{Message: "field of object type must have a selection of subfields", Path: path}}
+		}
+		out := make(map[string]interface{}, len(selection))
+		var errs []Error
+		for _, field := range selection {
+			key := field.ResponseKey()
+			if field.Name == "__typename" {
+				out[key] = "Object"
+				continue
+			}
+			child, exists := v[field.Name]
+			if !exists {
+				out[key] = nil
+				errs = append(errs, Error{
+					Message: fmt.Sprintf("cannot query field %q", field.Name),
+					Path:    append(append([]interface{}{}, path...), key),
+				})
+				continue
+			}
+			projected, childErrs := project(child, field.Selection, append(append([]interface{}{}, path...), key))
+			out[key] = projected
+			errs = append(errs, childErrs...)
+		}
+		return out, errs
+
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		var errs []Error
+		for i, item := range v {
+			projected, itemErrs := project(item, selection, append(append([]interface{}{}, path...), i))
+			out[i] = projected
+			errs = append(errs, itemErrs...)
+		}
+		return out, errs
+
+	default:
+		if len(selection) > 0 {
+			return nil, []Error{{Message: "field of scalar type cannot have a selection", Path: path}}
+		}
+		return v, nil
+	}
+}
+
+// normalize converts structs, typed maps and typed slices into the generic
+// JSON representation so selections use the JSON field names
+func normalize(value interface{}) (interface{}, error) {
+	switch value.(type) {
+	case map[string]interface{}, []interface{}, string, bool, float64, int, int64, uint64, nil:
+		return value, nil
+	}
+
+	kind := reflect.TypeOf(value).Kind()
+	if kind == reflect.Ptr {
+		if reflect.ValueOf(value).IsNil() {
+			return nil, nil
+		}
+		kind = reflect.TypeOf(value).Elem().Kind()
+	}
+	if kind != reflect.Struct && kind != reflect.Map && kind != reflect.Slice && kind != reflect.Array {
+		return value, nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var generic interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&generic); err != nil {
+		return nil, err
+	}
+	return generic, nil
+}
@@ -0,0 +1,418 @@
+// Package graphql implements the subset of GraphQL used by the dashboard's
+// /api/v1/graphql endpoint: anonymous or named queries with nested field
+// selection, aliases, arguments and variables. Fragments, directives,
+// mutations and subscriptions are not supported.
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Field is a selected field with its arguments and sub-selection
+type Field struct {
+	Alias     string
+	Name      string
+	Arguments map[string]interface{}
+	Selection []*Field
+}
+
+// ResponseKey returns the key the field is reported under
+func (f *Field) ResponseKey() string {
+	if f.Alias != "" {
+		return f.Alias
+	}
+	return f.Name
+}
+
+// variableRef is an unresolved $variable inside an argument value
+type variableRef string
+
+// Document is a parsed query operation
+type Document struct {
+	Name      string
+	Selection []*Field
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenName
+	tokenInt
+	tokenFloat
+	tokenString
+	tokenPunct
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	pos   int
+}
+
+// lexer splits a query into tokens, skipping whitespace, commas and comments
+type lexer struct {
+	input string
+	pos   int
+}
+
+func (l *lexer) next() (token, error) {
+	for l.pos < len(l.input) {
+		ch := rune(l.input[l.pos])
+		if ch == '#' {
+			for l.pos < len(l.input) && l.input[l.pos] != '\n' {
+				l.pos++
+			}
+			continue
+		}
+		if unicode.IsSpace(ch) || ch == ',' {
+			l.pos++
+			continue
+		}
+		break
+	}
+	if l.pos >= len(l.input) {
+		return token{kind: tokenEOF, pos: l.pos}, nil
+	}
+
+	start := l.pos
+	ch := l.input[l.pos]
+
+	switch {
+	case strings.HasPrefix(l.input[l.pos:], "..."):
+		l.pos += 3
+		return token{kind: tokenPunct, value: "...", pos: start}, nil
+
+	case strings.ContainsRune("{}():[]!$=", rune(ch)):
+		l.pos++
+		return token{kind: tokenPunct, value: string(ch), pos: start}, nil
+
+	case ch == '"':
+		l.pos++
+		var sb strings.Builder
+		for l.pos < len(l.input) {
+			c := l.input[l.pos]
+			if c == '"' {
+				l.pos++
+				return token{kind: tokenString, value: sb.String(), pos: start}, nil
+			}
+			if c == '\\' && l.pos+1 < len(l.input) {
+				l.pos++
+				switch l.input[l.pos] {
+				case 'n':
+					sb.WriteByte('\n')
+				case 't':
+					sb.WriteByte('\t')
+				default:
+					sb.WriteByte(l.input[l.pos])
+				}
+				l.pos++
+				continue
+			}
+			sb.WriteByte(c)
+			l.pos++
+		}
+		return token{}, fmt.Errorf("unterminated string at %d", start)
+
+	case ch == '-' || (ch >= '0' && ch <= '9'):
+		l.pos++
+		isFloat := false
+		for l.pos < len(l.input) {
+			c := l.input[l.pos]
+			if c == '.' || c == 'e' || c == 'E' {
+				isFloat = true
+			} else if !(c >= '0' && c <= '9') && !((c == '-' || c == '+') && isFloat) {
+				break
+			}
+			l.pos++
+		}
+		if isFloat {
+			return token{kind: tokenFloat, value: l.input[start:l.pos], pos: start}, nil
+		}
+		return token{kind: tokenInt, value: l.input[start:l.pos], pos: start}, nil
+
+	case ch == '_' || unicode.IsLetter(rune(ch)):
+		for l.pos < len(l.input) {
+			c := rune(l.input[l.pos])
+			if c != '_' && !unicode.IsLetter(c) && !unicode.IsDigit(c) {
+				break
+			}
+			l.pos++
+		}
+		return token{kind: tokenName, value: l.input[start:l.pos], pos: start}, nil
+	}
+
+	return token{}, fmt.Errorf("unexpected character %q at %d", ch, start)
+}
+
+// MaxDepth bounds how deeply selection sets and list values may nest, so
+// a hostile query cannot exhaust the parser's stack
+const MaxDepth = 32
+
+// parser is a recursive-descent parser over the token stream
+type parser struct {
+	lex   *lexer
+	tok   token
+	vars  map[string]interface{}
+	depth int // Selection sets and lists currently open
+}
+
+func (p *parser) advance() error {
+	tok, err := p.lex.next()
+	if err != nil {
+		return err
+	}
+	p.tok = tok
+	return nil
+}
+
+func (p *parser) isPunct(value string) bool {
+	return p.tok.kind == tokenPunct && p.tok.value == value
+}
+
+func (p *parser) expectPunct(value string) error {
+	if !p.isPunct(value) {
+		return fmt.Errorf("expected %q at %d, got %q", value, p.tok.pos, p.tok.value)
+	}
+	return p.advance()
+}
+
+// enter opens a nested selection set or list; callers leave with p.depth--
+func (p *parser) enter() error {
+	p.depth++
+	if p.depth > MaxDepth {
+		return fmt.Errorf("query nested deeper than %d at %d", MaxDepth, p.tok.pos)
+	}
+	return nil
+}
+
+func (p *parser) expectName() (string, error) {
+	if p.tok.kind != tokenName {
+		return "", fmt.Errorf("expected name at %d, got %q", p.tok.pos, p.tok.value)
+	}
+	name := p.tok.value
+	return name, p.advance()
+}
+
+// Parse parses a query document
+func Parse(query string) (*Document, error) {
+	p := &parser{lex: &lexer{input: query}}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	doc := &Document{}
+
+	if p.tok.kind == tokenName {
+		switch p.tok.value {
+		case "query":
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+		case "mutation", "subscription":
+			return nil, fmt.Errorf("%s operations are not supported", p.tok.value)
+		case "fragment":
+			return nil, fmt.Errorf("fragments are not supported")
+		default:
+			return nil, fmt.Errorf("unexpected %q at %d", p.tok.value, p.tok.pos)
+		}
+
+		if p.tok.kind == tokenName {
+			doc.Name = p.tok.value
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+		}
+
+		if p.isPunct("(") {
+			if err := p.skipVariableDefinitions(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	selection, err := p.parseSelectionSet()
+	if err != nil {
+		return nil, err
+	}
+	doc.Selection = selection
+
+	if p.tok.kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %q after query at %d (only one operation is supported)", p.tok.value, p.tok.pos)
+	}
+	return doc, nil
+}
+
+// skipVariableDefinitions consumes "($a: Int = 1, $b: String!)". Variable
+// types are not checked; values are taken from the request as-is.
+func (p *parser) skipVariableDefinitions() error {
+	depth := 0
+	for {
+		if p.tok.kind == tokenEOF {
+			return fmt.Errorf("unterminated variable definitions")
+		}
+		if p.isPunct("(") || p.isPunct("[") {
+			depth++
+		} else if p.isPunct(")") || p.isPunct("]") {
+			depth--
+			if depth == 0 {
+				return p.advance()
+			}
+		}
+		if err := p.advance(); err != nil {
+			return err
+		}
+	}
+}
+
+func (p *parser) parseSelectionSet() ([]*Field, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer func() { p.depth-- }()
+	if err := p.expectPunct("{"); err != nil {
+		return nil, err
+	}
+
+	var fields []*Field
+	for !p.isPunct("}") {
+		if p.tok.kind == tokenEOF {
+			return nil, fmt.Errorf("unterminated selection set")
+		}
+		if p.isPunct("...") {
+			return nil, fmt.Errorf("fragments are not supported")
+		}
+		field, err := p.parseField()
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, field)
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("empty selection set")
+	}
+	return fields, p.advance()
+}
+
+func (p *parser) parseField() (*Field, error) {
+	name, err := p.expectName()
+	if err != nil {
+		return nil, err
+	}
+
+	field := &Field{Name: name}
+	if p.isPunct(":") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		field.Alias = name
+		if field.Name, err = p.expectName(); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.isPunct("(") {
+		if field.Arguments, err = p.parseArguments(); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.isPunct("{") {
+		if field.Selection, err = p.parseSelectionSet(); err != nil {
+			return nil, err
+		}
+	}
+	return field, nil
+}
+
+func (p *parser) parseArguments() (map[string]interface{}, error) {
+	if err := p.expectPunct("("); err != nil {
+		return nil, err
+	}
+
+	args := make(map[string]interface{})
+	for !p.isPunct(")") {
+		name, err := p.expectName()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectPunct(":"); err != nil {
+			return nil, err
+		}
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		args[name] = value
+	}
+	return args, p.advance()
+}
+
+func (p *parser) parseValue() (interface{}, error) {
+	tok := p.tok
+	switch tok.kind {
+	case tokenInt:
+		value, err := strconv.ParseInt(tok.value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid int %q", tok.value)
+		}
+		return value, p.advance()
+	case tokenFloat:
+		value, err := strconv.ParseFloat(tok.value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid float %q", tok.value)
+		}
+		return value, p.advance()
+	case tokenString:
+		return tok.value, p.advance()
+	case tokenName:
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		switch tok.value {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+		return tok.value, nil // Enum values are passed as strings
+	case tokenPunct:
+		switch tok.value {
+		case "$":
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			name, err := p.expectName()
+			if err != nil {
+				return nil, err
+			}
+			return variableRef(name), nil
+		case "[":
+			if err := p.enter(); err != nil {
+				return nil, err
+			}
+			defer func() { p.depth-- }()
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			list := []interface{}{}
+			for !p.isPunct("]") {
+				if p.tok.kind == tokenEOF {
+					return nil, fmt.Errorf("unterminated list")
+				}
+				item, err := p.parseValue()
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, item)
+			}
+			return list, p.advance()
+		}
+	}
+	return nil, fmt.Errorf("unexpected %q at %d", tok.value, tok.pos)
+}
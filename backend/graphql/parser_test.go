@@ -0,0 +1,33 @@
+package graphql
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestParseDepth accepts selections and lists up to MaxDepth and rejects
+// deeper ones with an error instead of recursing without bound
+func TestParseDepth(t *testing.T) {
+	selection := func(depth int) string {
+		return strings.Repeat("{a", depth) + strings.Repeat("}", depth)
+	}
+	if _, err := Parse(selection(MaxDepth)); err != nil {
+		t.Fatalf("depth %d: %v", MaxDepth, err)
+	}
+	if _, err := Parse(selection(MaxDepth + 1)); err == nil {
+		t.Fatalf("depth %d parsed", MaxDepth+1)
+	}
+	if _, err := Parse("{" + strings.Repeat("a{", 1_000_000)); err == nil || !strings.Contains(err.Error(), "nested deeper") {
+		t.Fatalf("deep selection: %v", err)
+	}
+
+	list := func(depth int) string {
+		return "{a(x: " + strings.Repeat("[", depth) + strings.Repeat("]", depth) + ")}"
+	}
+	if _, err := Parse(list(MaxDepth - 1)); err != nil {
+		t.Fatalf("list depth %d: %v", MaxDepth-1, err)
+	}
+	if _, err := Parse(list(1_000_000)); err == nil || !strings.Contains(err.Error(), "nested deeper") {
+		t.Fatalf("deep list: %v", err)
+	}
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"monad-dashboard/graphql"
+)
+
+// dashboardSchema exposes dashboard data to /api/v1/graphql. Field names match
+// the REST JSON keys (snake_case).
+var dashboardSchema = &graphql.Schema{
+	Query: map[string]graphql.Resolver{
+		"metrics":     resolveGraphQLMetrics,
+		"tps_history": resolveGraphQLTPSHistory,
+		"blocks":      resolveGraphQLBlocks,
+		"block":       resolveGraphQLBlock,
+		"consensus":   resolveGraphQLConsensus,
+		"validators":  resolveGraphQLValidators,
+		"waterfall":   resolveGraphQLWaterfall,
+		"fees":        resolveGraphQLFees,
+		"tokens":      resolveGraphQLTokens,
+		"watchlist":   resolveGraphQLWatchlist,
+	},
+}
+
+// intArg reads an integer argument, accepting JSON numbers from variables
+func intArg(args map[string]interface{}, name string, defaultValue int) (int, error) {
+	value, ok := args[name]
+	if !ok || value == nil {
+		return defaultValue, nil
+	}
+	switch v := value.(type) {
+	case int64:
+		return int(v), nil
+	case float64:
+		return int(v), nil
+	case json.Number:
+		n, err := v.Int64()
+		return int(n), err
+	}
+	return 0, fmt.Errorf("argument %q must be an integer", name)
+}
+
+// limitArg reads a count argument, which must be at least 1
+func limitArg(args map[string]interface{}, name string, defaultValue int) (int, error) {
+	n, err := intArg(args, name, defaultValue)
+	if err != nil {
+		return 0, err
+	}
+	if n < 1 {
+		return 0, fmt.Errorf("argument %q must be at least 1", name)
+	}
+	return n, nil
+}
+
+// stringArg reads a string argument
+func stringArg(args map[string]interface{}, name, defaultValue string) (string, error) {
+	value, ok := args[name]
+	if !ok || value == nil {
+		return defaultValue, nil
+	}
+	s, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("argument %q must be a string", name)
+	}
+	return s, nil
+}
+
+func resolveGraphQLMetrics(args map[string]interface{}) (interface{}, error) {
+	return getCurrentMetrics(), nil
+}
+
+// resolveGraphQLTPSHistory returns TPS chart points, newest last
+func resolveGraphQLTPSHistory(args map[string]interface{}) (interface{}, error) {
+	limit, err := limitArg(args, "limit", 200)
+	if err != nil {
+		return nil, err
+	}
+	subscriber := GetSubscriber()
+	if subscriber == nil {
+		return []interface{}{}, nil
+	}
+
+	history := subscriber.getTPSHistory()
+	if limit > 0 && len(history) > limit {
+		history = history[len(history)-limit:]
+	}
+
+	points := make([]map[string]interface{}, len(history))
+	for i, h := range history {
+		points[i] = map[string]interface{}{
+			"total":    h[0],
+			"vote":     h[1],
+			"avg":      h[2],
+			"instant":  h[3],
+			"tx_count": h[4],
+		}
+	}
+	return points, nil
+}
+
+func resolveGraphQLBlocks(args map[string]interface{}) (interface{}, error) {
+	limit, err := limitArg(args, "limit", 10)
+	if err != nil {
+		return nil, err
+	}
+	return GetConsensusTracker().GetRecentBlocks(limit), nil
+}
+
+func resolveGraphQLBlock(args map[string]interface{}) (interface{}, error) {
+	number, err := intArg(args, "number", -1)
+	if err != nil {
+		return nil, err
+	}
+	if number < 0 {
+		return nil, fmt.Errorf("argument \"number\" is required")
+	}
+
+	for _, block := range GetConsensusTracker().GetRecentBlocks(1 << 30) {
+		if block.BlockNumber == uint64(number) {
+			return block, nil
+		}
+	}
+	return nil, nil
+}
+
+func resolveGraphQLConsensus(args map[string]interface{}) (interface{}, error) {
+	return GetConsensusTracker().GetConsensusState(), nil
+}
+
+// resolveGraphQLValidators returns the peer set; delinquent filters by status
+func resolveGraphQLValidators(args map[string]interface{}) (interface{}, error) {
+	peers := buildPeerList()
+
+	filter, hasFilter := args["delinquent"].(bool)
+	if !hasFilter {
+		return peers, nil
+	}
+
+	filtered := make([]map[string]interface{}, 0, len(peers))
+	for _, peer := range peers {
+		votes, _ := peer["vote"].([]map[string]interface{})
+		if len(votes) == 0 {
+			continue
+		}
+		if delinquent, _ := votes[0]["delinquent"].(bool); delinquent == filter {
+			filtered = append(filtered, peer)
+		}
+	}
+	return filtered, nil
+}
+
+func resolveGraphQLWaterfall(args map[string]interface{}) (interface{}, error) {
+	return GenerateMonadWaterfall(), nil
+}
+
+func resolveGraphQLFees(args map[string]interface{}) (interface{}, error) {
+	recent, err := limitArg(args, "recent", 20)
+	if err != nil {
+		return nil, err
+	}
+	ft := GetFeeTracker()
+	if ft == nil {
+		return nil, fmt.Errorf("fee tracker not initialized")
+	}
+	return ft.GetFeeSummary(recent), nil
+}
+
+func resolveGraphQLTokens(args map[string]interface{}) (interface{}, error) {
+	ti := GetTokenIndexer()
+	if ti == nil {
+		return nil, fmt.Errorf("token indexer not initialized")
+	}
+
+	windowName, err := stringArg(args, "window", "5m")
+	if err != nil {
+		return nil, err
+	}
+	window, ok := tokenWindows[windowName]
+	if !ok {
+		return nil, fmt.Errorf("window must be one of 1m, 5m, 15m, 1h")
+	}
+	standard, err := stringArg(args, "standard", "")
+	if err != nil {
+		return nil, err
+	}
+	limit, err := limitArg(args, "limit", 10)
+	if err != nil {
+		return nil, err
+	}
+	return ti.TopTokens(window, strings.ToLower(standard), limit), nil
+}
+
+func resolveGraphQLWatchlist(args map[string]interface{}) (interface{}, error) {
+	w := GetWatchlist()
+	if w == nil {
+		return nil, fmt.Errorf("watchlist not initialized")
+	}
+	return w.List(), nil
+}
+
+// maxGraphQLRequestBytes caps a POST /api/v1/graphql body
+const maxGraphQLRequestBytes = 1 << 20
+
+// GraphQLRequest is the body of POST /api/v1/graphql
+type GraphQLRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+// handleGraphQL executes a GraphQL query from a POST body
+// ({"query": "...", "variables": {...}}) or GET ?query=&variables=
+func handleGraphQL(c *gin.Context) {
+	var req GraphQLRequest
+
+	if c.Request.Method == http.MethodGet {
+		req.Query = c.Query("query")
+		if raw := c.Query("variables"); raw != "" {
+			if err := json.Unmarshal([]byte(raw), &req.Variables); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"errors": []graphql.Error{{Message: "invalid variables: " + err.Error()}}})
+				return
+			}
+		}
+	} else {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxGraphQLRequestBytes)
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"errors": []graphql.Error{{Message: err.Error()}}})
+			return
+		}
+	}
+
+	if strings.TrimSpace(req.Query) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"errors": []graphql.Error{{Message: "query is required"}}})
+		return
+	}
+
+	resp := dashboardSchema.Execute(req.Query, req.Variables)
+	if resp.Data == nil {
+		// Syntax errors: no execution happened
+		c.JSON(http.StatusBadRequest, resp)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// FinalityGapPoint is one sample of the speculative/finalized head distance
+type FinalityGapPoint struct {
+	Timestamp int64 `json:"timestamp"` // Unix milliseconds
+	Gap       int64 `json:"gap"`
+}
+
+// HeadTracker tracks Monad's speculative (proposed) and finalized heads
+// separately. Commit states arrive through the monadNewHeads subscription;
+// when that is unavailable the finalized head is polled over RPC instead.
+type HeadTracker struct {
+	client *MonadClient
+	clock  Clock
+
+	mu              sync.RWMutex
+	speculative     int64
+	finalized       int64
+	finalizedSource string    // "monadNewHeads" or "rpc"
+	lastCommitState time.Time // last finalized update from monadNewHeads
+	gapHistory      []FinalityGapPoint
+	maxGapHistory   int
+}
+
+// NewHeadTracker creates a head tracker polling the finalized head through
+// client, timing updates by clock
+func NewHeadTracker(client *MonadClient, clock Clock) *HeadTracker {
+	return &HeadTracker{
+		client:        client,
+		clock:         clock,
+		gapHistory:    make([]FinalityGapPoint, 0, 200),
+		maxGapHistory: 200,
+	}
+}
+
+// StartHeadTracker creates the dashboard's head tracker and starts the
+// finalized head poller
+func (d *Dashboard) StartHeadTracker(pollInterval time.Duration) *HeadTracker {
+	ht := NewHeadTracker(d.client, d.clock)
+	d.mu.Lock()
+	d.heads = ht
+	d.mu.Unlock()
+	go ht.pollFinalized(pollInterval)
+	return ht
+}
+
+// InitializeHeadTracker starts the served dashboard's head tracker
+func InitializeHeadTracker(pollInterval time.Duration) *HeadTracker {
+	return GetDashboard().StartHeadTracker(pollInterval)
+}
+
+// GetHeadTracker returns the served dashboard's head tracker
+func GetHeadTracker() *HeadTracker {
+	return GetDashboard().HeadTracker()
+}
+
+// OnSpeculativeHead records a proposed block
+func (ht *HeadTracker) OnSpeculativeHead(number int64) {
+	ht.mu.Lock()
+	defer ht.mu.Unlock()
+
+	if number > ht.speculative {
+		ht.speculative = number
+		ht.recordGapLocked()
+	}
+}
+
+// OnFinalizedHead records a finalized block
+func (ht *HeadTracker) OnFinalizedHead(number int64, source string) {
+	ht.mu.Lock()
+	if source == "monadNewHeads" {
+		ht.lastCommitState = ht.clock.Now()
+	}
+	if number <= ht.finalized {
+		ht.mu.Unlock()
+		return
+	}
+	ht.finalized = number
+	ht.finalizedSource = source
+	if number > ht.speculative {
+		ht.speculative = number
+	}
+	ht.recordGapLocked()
+	ht.mu.Unlock()
+
+	// Real finality data overrides the consensus tracker's finality depth estimate
+	if ct := GetConsensusTracker(); ct != nil {
+		ct.OnBlockFinalized(uint64(number))
+	}
+}
+
+// OnCommitState handles a monadNewHeads commit state transition
+func (ht *HeadTracker) OnCommitState(number int64, commitState string) {
+	switch commitState {
+	case "Proposed":
+		ht.OnSpeculativeHead(number)
+	case "Voted":
+		if ct := GetConsensusTracker(); ct != nil {
+			ct.OnBlockVoted(uint64(number))
+		}
+	case "Finalized":
+		ht.OnFinalizedHead(number, "monadNewHeads")
+	}
+}
+
+// recordGapLocked appends the current finality gap; caller holds ht.mu
+func (ht *HeadTracker) recordGapLocked() {
+	if ht.finalized == 0 {
+		return
+	}
+	ht.gapHistory = append(ht.gapHistory, FinalityGapPoint{
+		Timestamp: ht.clock.Now().UnixMilli(),
+		Gap:       ht.speculative - ht.finalized,
+	})
+	if len(ht.gapHistory) > ht.maxGapHistory {
+		ht.gapHistory = ht.gapHistory[1:]
+	}
+}
+
+// Heads returns the speculative and finalized head numbers
+func (ht *HeadTracker) Heads() (speculative, finalized int64) {
+	ht.mu.RLock()
+	defer ht.mu.RUnlock()
+	return ht.speculative, ht.finalized
+}
+
+// FinalityGap returns the current gap and its recent history
+func (ht *HeadTracker) FinalityGap() map[string]interface{} {
+	ht.mu.RLock()
+	defer ht.mu.RUnlock()
+
+	gap := int64(0)
+	if ht.finalized > 0 {
+		gap = ht.speculative - ht.finalized
+	}
+	history := make([]FinalityGapPoint, len(ht.gapHistory))
+	copy(history, ht.gapHistory)
+
+	return map[string]interface{}{
+		"speculative_slot": ht.speculative,
+		"finalized_slot":   ht.finalized,
+		"gap":              gap,
+		"source":           ht.finalizedSource,
+		"history":          history,
+	}
+}
+
+// pollFinalized queries the finalized head over RPC whenever monadNewHeads
+// has not reported a finalized block recently
+func (ht *HeadTracker) pollFinalized(every time.Duration) {
+	ticker := ht.clock.NewTicker(every)
+	defer ticker.Stop()
+
+	for range ticker.C() {
+		ht.mu.RLock()
+		fresh := ht.clock.Since(ht.lastCommitState) < 5*every
+		ht.mu.RUnlock()
+		if fresh {
+			continue
+		}
+
+		number, err := ht.client.GetBlockNumberByTag("finalized")
+		if err != nil {
+			log.Printf("Error fetching finalized head: %v", err)
+			continue
+		}
+		ht.OnFinalizedHead(number, "rpc")
+	}
+}
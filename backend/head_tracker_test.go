@@ -0,0 +1,41 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHeadTrackerFinalityGapHistory(t *testing.T) {
+	clock := NewFakeClock(fakeClockStart)
+	ht := NewHeadTracker(nil, clock)
+
+	ht.OnSpeculativeHead(10) // No finalized head yet, so no gap
+	clock.Advance(time.Second)
+	ht.OnFinalizedHead(8, "rpc")
+	clock.Advance(time.Second)
+	ht.OnSpeculativeHead(12)
+	clock.Advance(time.Second)
+	ht.OnFinalizedHead(11, "monadNewHeads")
+
+	gap := ht.FinalityGap()
+	history := gap["history"].([]FinalityGapPoint)
+	want := []FinalityGapPoint{
+		{Timestamp: fakeClockStart.Add(time.Second).UnixMilli(), Gap: 2},
+		{Timestamp: fakeClockStart.Add(2 * time.Second).UnixMilli(), Gap: 4},
+		{Timestamp: fakeClockStart.Add(3 * time.Second).UnixMilli(), Gap: 1},
+	}
+	if len(history) != len(want) {
+		t.Fatalf("history = %+v, want %+v", history, want)
+	}
+	for i := range want {
+		if history[i] != want[i] {
+			t.Errorf("history[%d] = %+v, want %+v", i, history[i], want[i])
+		}
+	}
+	if gap["gap"] != int64(1) || gap["source"] != "monadNewHeads" {
+		t.Fatalf("gap=%v source=%v, want 1/monadNewHeads", gap["gap"], gap["source"])
+	}
+	if !ht.lastCommitState.Equal(clock.Now()) {
+		t.Fatalf("last commit state at %v, want %v", ht.lastCommitState, clock.Now())
+	}
+}
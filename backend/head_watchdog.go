@@ -0,0 +1,303 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"monad-dashboard/hexutil"
+)
+
+// Head sources reported by /api/v1/subscriptions
+const (
+	headSourceSubscription = "subscription" // newHeads notifications
+	headSourcePolling      = "polling"      // eth_getBlockByNumber("latest") while newHeads is stalled
+)
+
+// headStallComponent names head stalls in the incident log and alerts
+const headStallComponent = "head_stall"
+
+// failbackHeads is how many newHeads notifications must arrive before the
+// subscription takes over from polling again
+const failbackHeads = 3
+
+// headPollCatchUp is the most heads one poll delivers
+const headPollCatchUp = 32
+
+// HeadStall is one period without a new head
+type HeadStall struct {
+	StartedAt       time.Time  `json:"started_at"` // Arrival of the last head before the stall
+	EndedAt         *time.Time `json:"ended_at"`   // Null while stalled
+	DurationSeconds float64    `json:"duration_seconds"`
+	ResumedVia      string     `json:"resumed_via,omitempty"` // subscription or polling
+}
+
+// HeadWatchdogStatus is the head watchdog as reported by /api/v1/subscriptions
+type HeadWatchdogStatus struct {
+	Source         string     `json:"source"` // subscription or polling
+	StallTimeout   string     `json:"stall_timeout"`
+	PollInterval   string     `json:"poll_interval"`
+	Stalled        bool       `json:"stalled"`
+	LastHeadAge    float64    `json:"last_head_age_seconds"`
+	PolledHeads    int64      `json:"polled_heads"` // Delivered by polling since startup
+	Failovers      int64      `json:"failovers"`
+	Failbacks      int64      `json:"failbacks"`
+	LastStall      *HeadStall `json:"last_stall"` // Null before the first stall
+	LastSourceSwap *time.Time `json:"last_source_swap"`
+}
+
+// HeadWatchdog fails over from the newHeads subscription to polling the
+// node for its latest block when no head arrives for HEAD_STALL_TIMEOUT,
+// and back once the subscription delivers heads again. Each stall is
+// recorded in the incident log as head_stall, firing its alert.
+type HeadWatchdog struct {
+	s            *MonadSubscriber
+	clock        Clock
+	timeout      time.Duration
+	pollInterval time.Duration
+
+	mu            sync.Mutex
+	source        string
+	started       time.Time
+	lastHead      time.Time // From either source
+	lastSubHead   time.Time
+	subHeads      int   // newHeads notifications since the last failover
+	polledThrough int64 // Highest head delivered by polling
+	polledHeads   int64
+	stall         *HeadStall
+	lastStall     *HeadStall
+	failovers     int64
+	failbacks     int64
+	lastSwap      time.Time
+}
+
+// NewHeadWatchdog reads HEAD_STALL_TIMEOUT (default 10s, 0 disables
+// failover) and HEAD_POLL_INTERVAL (default 1s)
+func NewHeadWatchdog(s *MonadSubscriber) *HeadWatchdog {
+	wd := &HeadWatchdog{
+		s:            s,
+		clock:        s.clock,
+		timeout:      10 * time.Second,
+		pollInterval: time.Second,
+		source:       headSourceSubscription,
+	}
+	if value := os.Getenv("HEAD_STALL_TIMEOUT"); value != "" {
+		if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+			wd.timeout = d
+		} else {
+			log.Printf("Invalid HEAD_STALL_TIMEOUT %q, using %s", value, wd.timeout)
+		}
+	}
+	if value := os.Getenv("HEAD_POLL_INTERVAL"); value != "" {
+		if d, err := time.ParseDuration(value); err == nil && d > 0 {
+			wd.pollInterval = d
+		} else {
+			log.Printf("Invalid HEAD_POLL_INTERVAL %q, using %s", value, wd.pollInterval)
+		}
+	}
+	return wd
+}
+
+// Start begins watching for stalls once the subscription is up
+func (wd *HeadWatchdog) Start() {
+	if wd.timeout == 0 {
+		log.Printf("Head stall failover disabled (HEAD_STALL_TIMEOUT=0)")
+		return
+	}
+	wd.mu.Lock()
+	wd.started = wd.clock.Now()
+	wd.mu.Unlock()
+	go wd.run()
+}
+
+// OnSubscriptionHead counts a newHeads notification and reports whether it
+// is new; heads polling already delivered are skipped
+func (wd *HeadWatchdog) OnSubscriptionHead(number int64) bool {
+	wd.mu.Lock()
+	defer wd.mu.Unlock()
+	wd.lastSubHead = wd.clock.Now()
+	wd.subHeads++
+	return number > wd.polledThrough
+}
+
+// OnHead notes a head delivered to the pipeline and ends a stall in progress
+func (wd *HeadWatchdog) OnHead() {
+	now := wd.clock.Now()
+
+	wd.mu.Lock()
+	wd.lastHead = now
+	stall := wd.stall
+	if stall == nil {
+		wd.mu.Unlock()
+		return
+	}
+	wd.stall = nil
+	stall.EndedAt = &now
+	stall.DurationSeconds = now.Sub(stall.StartedAt).Seconds()
+	stall.ResumedVia = wd.source
+	wd.lastStall = stall
+	wd.mu.Unlock()
+
+	log.Printf("Chain head resumed via %s after a %.1fs stall", stall.ResumedVia, stall.DurationSeconds)
+	if it := GetIncidentTracker(); it != nil {
+		it.Report(headStallComponent, stateConnected,
+			fmt.Sprintf("heads resumed via %s after %.1fs", stall.ResumedVia, stall.DurationSeconds), now)
+	}
+}
+
+func (wd *HeadWatchdog) run() {
+	ticker := wd.clock.NewTicker(wd.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-wd.s.ctx.Done():
+			return
+		case <-ticker.C():
+		}
+		wd.check(wd.clock.Now())
+
+		wd.mu.Lock()
+		polling := wd.source == headSourcePolling
+		wd.mu.Unlock()
+		if polling {
+			wd.poll()
+		}
+	}
+}
+
+// check starts a stall when no head has arrived for the timeout, failing
+// over to polling, and hands back to the subscription once it is flowing
+func (wd *HeadWatchdog) check(now time.Time) {
+	wd.mu.Lock()
+	since := wd.lastHead
+	if since.IsZero() {
+		since = wd.started
+	}
+
+	var stalled *HeadStall
+	failover, failback := false, false
+	if wd.stall == nil && now.Sub(since) > wd.timeout {
+		wd.stall = &HeadStall{StartedAt: since}
+		stalled = wd.stall
+		if wd.source == headSourceSubscription {
+			failover = true
+			wd.source = headSourcePolling
+			wd.subHeads = 0
+			wd.failovers++
+			wd.lastSwap = now
+		}
+	} else if wd.source == headSourcePolling && wd.subHeads >= failbackHeads && now.Sub(wd.lastSubHead) < wd.timeout {
+		failback = true
+		wd.source = headSourceSubscription
+		wd.failbacks++
+		wd.lastSwap = now
+	}
+	wd.mu.Unlock()
+
+	if stalled != nil {
+		reason := fmt.Sprintf("no new head for %s", wd.timeout)
+		log.Printf("⚠️  Chain head stalled: %s", reason)
+		if it := GetIncidentTracker(); it != nil {
+			it.Report(headStallComponent, stateDegraded, reason, stalled.StartedAt)
+		}
+	}
+	switch {
+	case failover:
+		log.Printf("Polling the node for heads every %s until newHeads delivers again", wd.pollInterval)
+		if store := GetHistoryStore(); store != nil {
+			store.Annotate("head_watchdog", "Head source: polling", fmt.Sprintf("No new head for %s", wd.timeout), "head_watchdog")
+		}
+	case failback:
+		log.Printf("newHeads subscription delivering again; stopped polling for heads")
+		if store := GetHistoryStore(); store != nil {
+			store.Annotate("head_watchdog", "Head source: subscription", "newHeads resumed", "head_watchdog")
+		}
+	}
+}
+
+// poll fetches the latest block and delivers every head since the current
+// one, up to headPollCatchUp; the gap repairer backfills older heights
+func (wd *HeadWatchdog) poll() {
+	var latest RPCBlock
+	if err := wd.s.client.callResult("eth_getBlockByNumber", []interface{}{"latest", false}, &latest); err != nil {
+		log.Printf("Head poll failed: %v", err)
+		return
+	}
+	number, err := hexutil.DecodeInt64(latest.Number)
+	if err != nil {
+		return
+	}
+	from := number
+	if current := wd.s.GetLatestBlock(); current != nil {
+		if number <= current.Number {
+			return
+		}
+		from = current.Number + 1
+	}
+	if number-from >= headPollCatchUp {
+		from = number - headPollCatchUp + 1
+	}
+
+	for height := from; height <= number; height++ {
+		block := &latest
+		if height < number {
+			if block, err = wd.s.client.GetBlockByNumber(height); err != nil {
+				log.Printf("Head poll: block %d: %v", height, err)
+				continue
+			}
+		}
+
+		wd.mu.Lock()
+		wd.polledThrough = height
+		wd.polledHeads++
+		wd.mu.Unlock()
+
+		wd.s.onHead(&BlockHeader{
+			Number:       height,
+			Hash:         block.Hash,
+			Timestamp:    hexutil.Int64OrZero(block.Timestamp),
+			Transactions: len(block.Transactions),
+			GasUsed:      hexutil.Int64OrZero(block.GasUsed),
+			GasLimit:     hexutil.Int64OrZero(block.GasLimit),
+			Miner:        strings.ToLower(block.Miner),
+		})
+	}
+}
+
+// Status reports the current head source and the last stall
+func (wd *HeadWatchdog) Status() HeadWatchdogStatus {
+	now := wd.clock.Now()
+
+	wd.mu.Lock()
+	defer wd.mu.Unlock()
+
+	status := HeadWatchdogStatus{
+		Source:       wd.source,
+		StallTimeout: wd.timeout.String(),
+		PollInterval: wd.pollInterval.String(),
+		Stalled:      wd.stall != nil,
+		PolledHeads:  wd.polledHeads,
+		Failovers:    wd.failovers,
+		Failbacks:    wd.failbacks,
+	}
+	if !wd.lastHead.IsZero() {
+		status.LastHeadAge = now.Sub(wd.lastHead).Seconds()
+	}
+	if wd.stall != nil {
+		stall := *wd.stall
+		stall.DurationSeconds = now.Sub(stall.StartedAt).Seconds()
+		status.LastStall = &stall
+	} else if wd.lastStall != nil {
+		stall := *wd.lastStall
+		status.LastStall = &stall
+	}
+	if !wd.lastSwap.IsZero() {
+		swap := wd.lastSwap
+		status.LastSourceSwap = &swap
+	}
+	return status
+}
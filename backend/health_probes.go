@@ -0,0 +1,211 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Kubernetes-style probes. /livez answers while the process serves
+// requests, so a node outage never restarts the dashboard; /readyz also
+// requires a working data source and recent block data, so a load balancer
+// only routes to replicas that have something to show.
+
+// readyMaxBlockAge is the default READYZ_MAX_BLOCK_AGE
+const readyMaxBlockAge = 30 * time.Second
+
+// componentSuccess remembers when each component was last seen connected,
+// for components that do not track it themselves
+var componentSuccess = struct {
+	sync.Mutex
+	at map[string]time.Time
+}{at: make(map[string]time.Time)}
+
+// noteComponentStates records the connected components of a sample
+func noteComponentStates(states map[string]StateTransition, now time.Time) {
+	componentSuccess.Lock()
+	defer componentSuccess.Unlock()
+	for component, state := range states {
+		if state.State == stateConnected {
+			componentSuccess.at[component] = now
+		}
+	}
+}
+
+// lastComponentSuccess returns when a component was last seen connected
+func lastComponentSuccess(component string) time.Time {
+	componentSuccess.Lock()
+	defer componentSuccess.Unlock()
+	return componentSuccess.at[component]
+}
+
+// ProbeComponent is one component's status in /readyz
+type ProbeComponent struct {
+	Name        string     `json:"name"`
+	Status      string     `json:"status"` // connected, degraded, down or unknown
+	Reason      string     `json:"reason,omitempty"`
+	LastSuccess *time.Time `json:"last_success,omitempty"`
+}
+
+// ProbeCheck is one readiness condition
+type ProbeCheck struct {
+	Name   string `json:"name"`
+	OK     bool   `json:"ok"`
+	Detail string `json:"detail"`
+}
+
+// ReadyzResponse is the body of /readyz
+type ReadyzResponse struct {
+	Ready      bool             `json:"ready"`
+	Timestamp  int64            `json:"timestamp"`
+	Demo       bool             `json:"demo"`
+	Checks     []ProbeCheck     `json:"checks"`
+	Components []ProbeComponent `json:"components"` // Sorted by name
+}
+
+// LivezResponse is the body of /livez
+type LivezResponse struct {
+	Status        string `json:"status"` // ok, or shutting_down
+	Timestamp     int64  `json:"timestamp"`
+	UptimeSeconds int64  `json:"uptime_seconds"`
+}
+
+// readyBlockAge reads READYZ_MAX_BLOCK_AGE
+func readyBlockAge() time.Duration {
+	value := os.Getenv("READYZ_MAX_BLOCK_AGE")
+	if value == "" {
+		return readyMaxBlockAge
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		log.Printf("Invalid READYZ_MAX_BLOCK_AGE %q, using %s", value, readyMaxBlockAge)
+		return readyMaxBlockAge
+	}
+	return d
+}
+
+// probeComponents reports every component with its last success: the
+// latest block for the node, the last update for metrics sources, the last
+// successful call for the RPC pool and the last connected sample otherwise
+func probeComponents(now time.Time) []ProbeComponent {
+	states := componentStates(now)
+	noteComponentStates(states, now)
+
+	lastSuccess := make(map[string]time.Time, len(states)+1)
+	for component := range states {
+		lastSuccess[component] = lastComponentSuccess(component)
+	}
+	if subscriber := GetSubscriber(); subscriber != nil {
+		if block := subscriber.GetLatestBlock(); block != nil {
+			lastSuccess["node"] = GetClockSkew().BlockTime(block.Timestamp)
+		}
+	}
+	for _, status := range GetSourceResolver().Statuses() {
+		if _, ok := states[status.Name]; ok && status.LastUpdated.Unix() > 0 {
+			lastSuccess[status.Name] = status.LastUpdated
+		}
+	}
+
+	rpc := StateTransition{Component: "rpc", State: stateUnknown}
+	var rpcSuccess time.Time
+	if client := GetMonadClient(); client != nil {
+		switch health := client.rpc.Health(); health.State {
+		case breakerClosed:
+			rpc.State = stateConnected
+		case breakerOpen:
+			rpc.State, rpc.Reason = stateDown, "every endpoint's breaker is open"
+		case breakerHalfOpen:
+			rpc.State, rpc.Reason = stateDegraded, "endpoints are being retried"
+		}
+		for _, status := range client.rpc.Statuses() {
+			if at := time.Unix(status.LastSuccess, 0); status.LastSuccess > 0 && at.After(rpcSuccess) {
+				rpcSuccess = at
+			}
+		}
+	}
+	states["rpc"] = rpc
+	lastSuccess["rpc"] = rpcSuccess
+
+	components := make([]ProbeComponent, 0, len(states))
+	for component, state := range states {
+		probe := ProbeComponent{Name: component, Status: state.State, Reason: state.Reason}
+		if at := lastSuccess[component]; !at.IsZero() {
+			probe.LastSuccess = &at
+		}
+		components = append(components, probe)
+	}
+	sort.Slice(components, func(i, j int) bool { return components[i].Name < components[j].Name })
+	return components
+}
+
+// readiness evaluates the readiness checks: not shutting down, at least one
+// healthy data source and a block newer than READYZ_MAX_BLOCK_AGE. Demo
+// data is always ready.
+func readiness(now time.Time) ReadyzResponse {
+	response := ReadyzResponse{Timestamp: now.Unix(), Demo: isDemoMode(), Components: probeComponents(now)}
+
+	serving := ProbeCheck{Name: "serving", OK: shutdownContext().Err() == nil, Detail: "accepting requests"}
+	if !serving.OK {
+		serving.Detail = "shutting down"
+	}
+
+	source := ProbeCheck{Name: "data_source", Detail: "no healthy data source"}
+	for _, status := range GetSourceResolver().Statuses() {
+		if status.Name != "mock_data" && status.Healthy {
+			source.OK, source.Detail = true, status.Name+" healthy"
+			break
+		}
+	}
+
+	maxAge := readyBlockAge()
+	block := ProbeCheck{Name: "recent_block", Detail: "no block received"}
+	if subscriber := GetSubscriber(); subscriber != nil {
+		if latest := subscriber.GetLatestBlock(); latest != nil {
+			age := now.Sub(GetClockSkew().BlockTime(latest.Timestamp))
+			block.OK = age <= maxAge
+			block.Detail = fmt.Sprintf("block %d is %.0fs old (limit %s)", latest.Number, age.Seconds(), maxAge)
+		}
+	}
+
+	if response.Demo {
+		source.OK, source.Detail = true, "demo mode"
+		block.OK, block.Detail = true, "demo mode"
+	}
+	response.Checks = []ProbeCheck{serving, source, block}
+	response.Ready = serving.OK && source.OK && block.OK
+	return response
+}
+
+// handleLivez answers 200 while the process serves requests, 503 once it
+// is shutting down
+func handleLivez(c *gin.Context) {
+	now := GetDashboard().clock.Now()
+	response := LivezResponse{
+		Status:        "ok",
+		Timestamp:     now.Unix(),
+		UptimeSeconds: int64(now.Sub(GetDashboard().startTime).Seconds()),
+	}
+	if shutdownContext().Err() != nil {
+		response.Status = "shutting_down"
+		c.JSON(http.StatusServiceUnavailable, response)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// handleReadyz answers 200 when the dashboard has live data to serve and
+// 503 otherwise, with the checks and per-component statuses either way
+func handleReadyz(c *gin.Context) {
+	response := readiness(GetDashboard().clock.Now())
+	status := http.StatusOK
+	if !response.Ready {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, response)
+}
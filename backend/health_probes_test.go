@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// serveFakeClockDashboard installs a dashboard timed by clock for the
+// duration of a test
+func serveFakeClockDashboard(t *testing.T, clock Clock) {
+	t.Helper()
+	served := GetDashboard()
+	SetDashboard(NewDashboard(served.client, served.wsURL, clock))
+	t.Cleanup(func() { SetDashboard(served) })
+}
+
+func TestLivezUptimeFollowsDashboardClock(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	clock := NewFakeClock(fakeClockStart)
+	serveFakeClockDashboard(t, clock)
+	clock.Advance(90 * time.Second)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	handleLivez(c)
+
+	var response LivezResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusOK || response.UptimeSeconds != 90 || response.Timestamp != clock.Now().Unix() {
+		t.Fatalf("HTTP %d, uptime=%ds timestamp=%d, want 200/90s/%d", w.Code, response.UptimeSeconds, response.Timestamp, clock.Now().Unix())
+	}
+}
+
+func TestReadyzTimestampFollowsDashboardClock(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	clock := NewFakeClock(fakeClockStart)
+	serveFakeClockDashboard(t, clock)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	handleReadyz(c)
+
+	var response ReadyzResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	if response.Timestamp != fakeClockStart.Unix() {
+		t.Fatalf("timestamp = %d, want %d", response.Timestamp, fakeClockStart.Unix())
+	}
+}
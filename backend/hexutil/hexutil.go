@@ -0,0 +1,174 @@
+// Package hexutil implements the 0x-prefixed hex encoding used by Monad's
+// Ethereum-compatible JSON-RPC API.
+//
+// Quantities (block numbers, gas, balances) are decoded into uint64, int64 or
+// *big.Int. Unlike fmt.Sscanf, values that do not fit the requested type are
+// reported as errors instead of being silently truncated.
+package hexutil
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Decoding errors
+var (
+	ErrEmptyString   = errors.New("empty hex string")
+	ErrMissingPrefix = errors.New("hex string without 0x prefix")
+	ErrEmptyNumber   = errors.New("hex string \"0x\"")
+	ErrSyntax        = errors.New("invalid hex string")
+	ErrOddLength     = errors.New("hex string of odd length")
+	ErrUint64Range   = errors.New("hex number > 64 bits")
+	ErrInt64Range    = errors.New("hex number > 63 bits")
+)
+
+// checkNumber validates a hex quantity and returns its digits without prefix
+func checkNumber(input string) (string, error) {
+	if len(input) == 0 {
+		return "", ErrEmptyString
+	}
+	if !has0xPrefix(input) {
+		return "", ErrMissingPrefix
+	}
+	digits := input[2:]
+	if len(digits) == 0 {
+		return "", ErrEmptyNumber
+	}
+	return digits, nil
+}
+
+func has0xPrefix(input string) bool {
+	return len(input) >= 2 && input[0] == '0' && (input[1] == 'x' || input[1] == 'X')
+}
+
+// DecodeUint64 decodes a 0x-prefixed hex quantity as uint64
+func DecodeUint64(input string) (uint64, error) {
+	digits, err := checkNumber(input)
+	if err != nil {
+		return 0, err
+	}
+	value, err := strconv.ParseUint(digits, 16, 64)
+	if err != nil {
+		if errors.Is(err, strconv.ErrRange) {
+			return 0, ErrUint64Range
+		}
+		return 0, ErrSyntax
+	}
+	return value, nil
+}
+
+// DecodeInt64 decodes a 0x-prefixed hex quantity as a non-negative int64
+func DecodeInt64(input string) (int64, error) {
+	value, err := DecodeUint64(input)
+	if err != nil {
+		return 0, err
+	}
+	if value > math.MaxInt64 {
+		return 0, ErrInt64Range
+	}
+	return int64(value), nil
+}
+
+// DecodeBig decodes a 0x-prefixed hex quantity of arbitrary size
+func DecodeBig(input string) (*big.Int, error) {
+	digits, err := checkNumber(input)
+	if err != nil {
+		return nil, err
+	}
+	if digits[0] == '+' || digits[0] == '-' {
+		return nil, ErrSyntax // big.Int.SetString accepts a sign
+	}
+	value, ok := new(big.Int).SetString(digits, 16)
+	if !ok {
+		return nil, ErrSyntax
+	}
+	return value, nil
+}
+
+// DecodeBytes decodes 0x-prefixed hex data (e.g. log data, topics). "0x" is
+// valid and decodes to an empty slice.
+func DecodeBytes(input string) ([]byte, error) {
+	if len(input) == 0 {
+		return nil, ErrEmptyString
+	}
+	if !has0xPrefix(input) {
+		return nil, ErrMissingPrefix
+	}
+	digits := input[2:]
+	if len(digits)%2 != 0 {
+		return nil, ErrOddLength
+	}
+	out := make([]byte, len(digits)/2)
+	for i := 0; i < len(out); i++ {
+		b, err := strconv.ParseUint(digits[2*i:2*i+2], 16, 8)
+		if err != nil {
+			return nil, ErrSyntax
+		}
+		out[i] = byte(b)
+	}
+	return out, nil
+}
+
+// EncodeUint64 encodes a uint64 as a 0x-prefixed hex quantity
+func EncodeUint64(value uint64) string {
+	return "0x" + strconv.FormatUint(value, 16)
+}
+
+// EncodeInt64 encodes an int64 as a 0x-prefixed hex quantity. Negative values
+// are not valid quantities and encode as "0x0".
+func EncodeInt64(value int64) string {
+	if value < 0 {
+		return "0x0"
+	}
+	return EncodeUint64(uint64(value))
+}
+
+// EncodeBig encodes a big integer as a 0x-prefixed hex quantity. Like
+// EncodeInt64, nil and negative values encode as "0x0".
+func EncodeBig(value *big.Int) string {
+	if value == nil || value.Sign() <= 0 {
+		return "0x0"
+	}
+	return "0x" + value.Text(16)
+}
+
+// EncodeBytes encodes a byte slice as 0x-prefixed hex data
+func EncodeBytes(b []byte) string {
+	return fmt.Sprintf("0x%x", b)
+}
+
+// Uint64OrZero decodes a quantity, returning 0 for missing or malformed input.
+// Intended for optional fields where a parse failure should not abort processing.
+func Uint64OrZero(input string) uint64 {
+	value, _ := DecodeUint64(input)
+	return value
+}
+
+// Int64OrZero is the int64 counterpart of Uint64OrZero
+func Int64OrZero(input string) int64 {
+	value, _ := DecodeInt64(input)
+	return value
+}
+
+// BigOrZero decodes a quantity of arbitrary size, returning 0 on failure
+func BigOrZero(input string) *big.Int {
+	value, err := DecodeBig(input)
+	if err != nil {
+		return new(big.Int)
+	}
+	return value
+}
+
+// IsHex reports whether input is 0x-prefixed and contains only hex digits
+func IsHex(input string) bool {
+	if !has0xPrefix(input) {
+		return false
+	}
+	return strings.IndexFunc(input[2:], func(r rune) bool {
+		return !((r >= '0' && r <= '9') || (r >= 'a' && r <= 'f') || (r >= 'A' && r <= 'F'))
+	}) == -1
+}
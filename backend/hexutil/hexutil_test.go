@@ -0,0 +1,75 @@
+package hexutil
+
+import (
+	"math/big"
+	"testing"
+)
+
+// TestDecode checks each decoder against valid and malformed input
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		input  string
+		uint64 uint64
+		big    string // Decimal, or "" when DecodeBig fails
+		err    error  // Of DecodeUint64
+		bigErr error
+	}{
+		{input: "", err: ErrEmptyString, bigErr: ErrEmptyString},
+		{input: "12", err: ErrMissingPrefix, bigErr: ErrMissingPrefix},
+		{input: "0x", err: ErrEmptyNumber, bigErr: ErrEmptyNumber},
+		{input: "0x0", uint64: 0, big: "0"},
+		{input: "0x1", uint64: 1, big: "1"},
+		{input: "0x123", uint64: 0x123, big: "291"}, // Odd length is fine for quantities
+		{input: "0XFF", uint64: 255, big: "255"},
+		{input: "0xDeadBeef", uint64: 0xdeadbeef, big: "3735928559"},
+		{input: "0xffffffffffffffff", uint64: 1<<64 - 1, big: "18446744073709551615"},
+		{input: "0x10000000000000000", err: ErrUint64Range, big: "18446744073709551616"},
+		{input: "0x-1", err: ErrSyntax, bigErr: ErrSyntax},
+		{input: "0x+5", err: ErrSyntax, bigErr: ErrSyntax},
+		{input: "0x1g", err: ErrSyntax, bigErr: ErrSyntax},
+		{input: "0x1_0", err: ErrSyntax, bigErr: ErrSyntax},
+		{input: "0x 1", err: ErrSyntax, bigErr: ErrSyntax},
+	}
+	for _, tt := range tests {
+		value, err := DecodeUint64(tt.input)
+		if err != tt.err || (err == nil && value != tt.uint64) {
+			t.Errorf("DecodeUint64(%q) = %d, %v; want %d, %v", tt.input, value, err, tt.uint64, tt.err)
+		}
+
+		want, _ := new(big.Int).SetString(tt.big, 10)
+		bigValue, err := DecodeBig(tt.input)
+		if err != tt.bigErr || (err == nil && bigValue.Cmp(want) != 0) {
+			t.Errorf("DecodeBig(%q) = %v, %v; want %s, %v", tt.input, bigValue, err, tt.big, tt.bigErr)
+		}
+	}
+
+	if _, err := DecodeInt64("0x8000000000000000"); err != ErrInt64Range {
+		t.Errorf("DecodeInt64 past 63 bits: %v", err)
+	}
+	if value, err := DecodeInt64("0x7fffffffffffffff"); err != nil || value != 1<<63-1 {
+		t.Errorf("DecodeInt64 of the maximum = %d, %v", value, err)
+	}
+}
+
+// TestDecodeBytes requires whole bytes and allows empty data
+func TestDecodeBytes(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string // Re-encoded
+		err   error
+	}{
+		{input: "", err: ErrEmptyString},
+		{input: "ab", err: ErrMissingPrefix},
+		{input: "0x", want: "0x"},
+		{input: "0xABcd", want: "0xabcd"},
+		{input: "0xabc", err: ErrOddLength},
+		{input: "0x-1", err: ErrSyntax},
+		{input: "0xzz", err: ErrSyntax},
+	}
+	for _, tt := range tests {
+		b, err := DecodeBytes(tt.input)
+		if err != tt.err || (err == nil && EncodeBytes(b) != tt.want) {
+			t.Errorf("DecodeBytes(%q) = %x, %v; want %s, %v", tt.input, b, err, tt.want, tt.err)
+		}
+	}
+}
@@ -0,0 +1,663 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// historyTier is one stored resolution of the metric history. The raw tier
+// holds sampler points; each coarser tier averages the tier before it.
+type historyTier struct {
+	name       string
+	resolution time.Duration // 0 for raw samples
+	retention  time.Duration
+	segment    time.Duration // Span of one segment file on disk
+	series     map[string][]HistoryPoint
+	rolledUpTo int64            // Unix ms below which the finer tier has been rolled into this one
+	pending    []persistedPoint // Points not yet written to disk
+}
+
+// persistedPoint is one line of a history segment file
+type persistedPoint struct {
+	Series    string  `json:"s"`
+	Timestamp int64   `json:"t"`
+	Value     float64 `json:"v"`
+}
+
+// RetentionPolicy configures how long each history resolution is kept
+type RetentionPolicy struct {
+	Raw             time.Duration
+	Minute          time.Duration // 1m rollups; 0 disables the tier
+	Hour            time.Duration // 1h rollups; 0 disables the tier
+	Dir             string        // Segment file directory; empty keeps history in memory only
+	CompactInterval time.Duration
+}
+
+// CompactionStats describes the latest compaction run
+type CompactionStats struct {
+	At           time.Time `json:"at"`
+	DurationMs   float64   `json:"duration_ms"`
+	RolledUp     int       `json:"rolled_up_points"`
+	Written      int       `json:"written_points"`
+	RemovedFiles int       `json:"removed_files"`
+	Error        string    `json:"error,omitempty"`
+}
+
+// loadRetentionPolicy reads HISTORY_RETENTION_RAW (default 24h),
+// HISTORY_RETENTION_1M (30d), HISTORY_RETENTION_1H (1y), HISTORY_DIR
+// (default history; HISTORY_PERSIST=false keeps history in memory only) and
+// HISTORY_COMPACT_INTERVAL (1m)
+func loadRetentionPolicy() RetentionPolicy {
+	policy := RetentionPolicy{
+		Raw:             retentionEnv("HISTORY_RETENTION_RAW", 24*time.Hour),
+		Minute:          retentionEnv("HISTORY_RETENTION_1M", 30*24*time.Hour),
+		Hour:            retentionEnv("HISTORY_RETENTION_1H", 365*24*time.Hour),
+		Dir:             os.Getenv("HISTORY_DIR"),
+		CompactInterval: retentionEnv("HISTORY_COMPACT_INTERVAL", time.Minute),
+	}
+	if policy.Raw <= 0 {
+		policy.Raw = time.Hour
+	}
+	if policy.CompactInterval <= 0 {
+		policy.CompactInterval = time.Minute
+	}
+	if policy.Dir == "" {
+		policy.Dir = "history"
+	}
+	if os.Getenv("HISTORY_PERSIST") == "false" || isDemoMode() {
+		// Demo history is simulated; keep it out of the persisted series
+		policy.Dir = ""
+	}
+	return policy
+}
+
+// retentionEnv parses a duration variable, also accepting d (days) and y
+// (365 days) suffixes
+func retentionEnv(name string, def time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return def
+	}
+	d, err := parseRetention(value)
+	if err != nil || d < 0 {
+		log.Printf("Invalid %s %q, using %s", name, value, def)
+		return def
+	}
+	return d
+}
+
+func parseRetention(value string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "y": 365 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(value, suffix); ok {
+			count, err := strconv.ParseFloat(n, 64)
+			if err != nil {
+				return 0, err
+			}
+			return time.Duration(count * float64(unit)), nil
+		}
+	}
+	return time.ParseDuration(value)
+}
+
+// newHistoryTiers builds the raw tier and the enabled rollup tiers
+func newHistoryTiers(policy RetentionPolicy) []*historyTier {
+	tiers := []*historyTier{{name: "raw", retention: policy.Raw, segment: time.Hour}}
+	if policy.Minute > 0 {
+		tiers = append(tiers, &historyTier{name: "1m", resolution: time.Minute, retention: policy.Minute, segment: 24 * time.Hour})
+	}
+	if policy.Hour > 0 {
+		tiers = append(tiers, &historyTier{name: "1h", resolution: time.Hour, retention: policy.Hour, segment: 30 * 24 * time.Hour})
+	}
+	for _, tier := range tiers {
+		tier.series = make(map[string][]HistoryPoint)
+	}
+	return tiers
+}
+
+// insertPoint adds p to a time-ordered series, keeping it sorted when a
+// sample arrives out of order (backfilled history)
+func insertPoint(points []HistoryPoint, p HistoryPoint) []HistoryPoint {
+	n := len(points)
+	if n == 0 || points[n-1].Timestamp <= p.Timestamp {
+		return append(points, p)
+	}
+	i := sort.Search(n, func(i int) bool { return points[i].Timestamp > p.Timestamp })
+	points = append(points, HistoryPoint{})
+	copy(points[i+1:], points[i:])
+	points[i] = p
+	return points
+}
+
+// pruneBefore drops points older than cutoff (Unix ms)
+func pruneBefore(points []HistoryPoint, cutoff int64) []HistoryPoint {
+	drop := sort.Search(len(points), func(i int) bool { return points[i].Timestamp >= cutoff })
+	if drop == 0 {
+		return points
+	}
+	return append(points[:0:0], points[drop:]...)
+}
+
+// tierFor returns the finest tier still holding data from the given time,
+// or the coarsest tier when none reaches back that far
+func (h *HistoryStore) tierFor(from, now time.Time) *historyTier {
+	for _, tier := range h.tiers {
+		if !from.Before(now.Add(-tier.retention)) {
+			return tier
+		}
+	}
+	return h.tiers[len(h.tiers)-1]
+}
+
+// runCompaction rolls up, prunes and persists history every interval
+func (h *HistoryStore) runCompaction(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		h.compact(now)
+	}
+}
+
+// compact rolls each tier's completed buckets into the next coarser tier,
+// drops points past retention, writes new points to disk and removes
+// segment files that have expired
+func (h *HistoryStore) compact(now time.Time) CompactionStats {
+	start := time.Now()
+	stats := CompactionStats{At: now}
+
+	h.mu.Lock()
+	for i := 1; i < len(h.tiers); i++ {
+		stats.RolledUp += rollUp(h.tiers[i-1], h.tiers[i], now, h.dir != "")
+	}
+	for _, tier := range h.tiers[1:] {
+		cutoff := now.Add(-tier.retention).UnixMilli()
+		for name, points := range tier.series {
+			if points = pruneBefore(points, cutoff); len(points) == 0 {
+				delete(tier.series, name)
+			} else {
+				tier.series[name] = points
+			}
+		}
+	}
+	pending := make([][]persistedPoint, len(h.tiers))
+	for i, tier := range h.tiers {
+		pending[i], tier.pending = tier.pending, nil
+	}
+	h.mu.Unlock()
+
+	if h.dir != "" {
+		var errs []string
+		for i, tier := range h.tiers {
+			written, err := tier.write(h.dir, pending[i])
+			stats.Written += written
+			if err != nil {
+				errs = append(errs, err.Error())
+			}
+			removed, err := tier.removeExpired(h.dir, now)
+			stats.RemovedFiles += removed
+			if err != nil {
+				errs = append(errs, err.Error())
+			}
+		}
+		if len(errs) > 0 {
+			stats.Error = strings.Join(errs, "; ")
+			log.Printf("History compaction error: %s", stats.Error)
+		}
+	}
+
+	stats.DurationMs = float64(time.Since(start).Microseconds()) / 1000
+	h.mu.Lock()
+	h.lastCompaction = &stats
+	h.mu.Unlock()
+	return stats
+}
+
+// rollUp averages src points in dst-sized buckets that closed since the
+// last run into dst, returning the number of new dst points; caller holds h.mu
+func rollUp(src, dst *historyTier, now time.Time, persist bool) int {
+	resMs := dst.resolution.Milliseconds()
+	boundary := now.Truncate(dst.resolution).UnixMilli()
+	if boundary <= dst.rolledUpTo {
+		return 0
+	}
+
+	added := 0
+	for name, points := range src.series {
+		from := sort.Search(len(points), func(i int) bool { return points[i].Timestamp >= dst.rolledUpTo })
+		var bucket int64 = -1
+		var sum float64
+		var count int
+		flush := func() {
+			if count == 0 {
+				return
+			}
+			p := HistoryPoint{Timestamp: bucket, Value: sum / float64(count)}
+			dst.series[name] = insertPoint(dst.series[name], p)
+			if persist {
+				dst.pending = append(dst.pending, persistedPoint{Series: name, Timestamp: p.Timestamp, Value: p.Value})
+			}
+			added++
+		}
+		for _, p := range points[from:] {
+			if p.Timestamp >= boundary {
+				break
+			}
+			if b := p.Timestamp - p.Timestamp%resMs; b != bucket {
+				flush()
+				bucket, sum, count = b, 0, 0
+			}
+			sum += p.Value
+			count++
+		}
+		flush()
+	}
+	dst.rolledUpTo = boundary
+	return added
+}
+
+// segmentStart returns the start of the segment file holding ts (Unix ms)
+func (t *historyTier) segmentStart(ts int64) time.Time {
+	return time.UnixMilli(ts).UTC().Truncate(t.segment)
+}
+
+// segmentPath names the segment file starting at start
+func (t *historyTier) segmentPath(dir string, start time.Time) string {
+	return filepath.Join(dir, t.name, start.Format("20060102T1504")+".jsonl")
+}
+
+// write appends points to their segment files
+func (t *historyTier) write(dir string, points []persistedPoint) (int, error) {
+	if len(points) == 0 {
+		return 0, nil
+	}
+	if err := os.MkdirAll(filepath.Join(dir, t.name), 0o755); err != nil {
+		return 0, err
+	}
+
+	bySegment := make(map[time.Time][]persistedPoint)
+	for _, p := range points {
+		if math.IsNaN(p.Value) || math.IsInf(p.Value, 0) {
+			continue
+		}
+		start := t.segmentStart(p.Timestamp)
+		bySegment[start] = append(bySegment[start], p)
+	}
+
+	written := 0
+	for start, segment := range bySegment {
+		f, err := os.OpenFile(t.segmentPath(dir, start), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return written, err
+		}
+		w := bufio.NewWriter(f)
+		enc := json.NewEncoder(w)
+		for _, p := range segment {
+			if err := enc.Encode(p); err != nil {
+				f.Close()
+				return written, err
+			}
+			written++
+		}
+		if err := w.Flush(); err != nil {
+			f.Close()
+			return written, err
+		}
+		if err := f.Close(); err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// segmentFiles lists the tier's segment files with their start times
+func (t *historyTier) segmentFiles(dir string) (map[string]time.Time, error) {
+	entries, err := os.ReadDir(filepath.Join(dir, t.name))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	files := make(map[string]time.Time, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		start, err := time.Parse("20060102T1504", strings.TrimSuffix(name, ".jsonl"))
+		if err != nil || entry.IsDir() || !strings.HasSuffix(name, ".jsonl") {
+			continue
+		}
+		files[filepath.Join(dir, t.name, name)] = start
+	}
+	return files, nil
+}
+
+// removeExpired deletes segment files whose whole span is past retention
+func (t *historyTier) removeExpired(dir string, now time.Time) (int, error) {
+	files, err := t.segmentFiles(dir)
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	for path, start := range files {
+		if start.Add(t.segment).Before(now.Add(-t.retention)) {
+			if err := os.Remove(path); err != nil {
+				return removed, err
+			}
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+// load reads the tier's unexpired segment files into memory
+func (t *historyTier) load(dir string, now time.Time) (int, error) {
+	files, err := t.segmentFiles(dir)
+	if err != nil {
+		return 0, err
+	}
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths) // Names sort chronologically
+
+	cutoff := now.Add(-t.retention).UnixMilli()
+	loaded := 0
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return loaded, err
+		}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			var p persistedPoint
+			if json.Unmarshal(scanner.Bytes(), &p) != nil || p.Series == "" || p.Timestamp < cutoff {
+				continue
+			}
+			t.series[p.Series] = insertPoint(t.series[p.Series], HistoryPoint{Timestamp: p.Timestamp, Value: p.Value})
+			loaded++
+		}
+		f.Close()
+	}
+
+	// Resume rolling up after the newest bucket already stored
+	if t.resolution > 0 {
+		for _, points := range t.series {
+			if n := len(points); n > 0 {
+				if next := points[n-1].Timestamp + t.resolution.Milliseconds(); next > t.rolledUpTo {
+					t.rolledUpTo = next
+				}
+			}
+		}
+	}
+	return loaded, nil
+}
+
+// loadPersisted restores every tier from disk
+func (h *HistoryStore) loadPersisted(now time.Time) {
+	start := time.Now()
+	total := 0
+	for _, tier := range h.tiers {
+		loaded, err := tier.load(h.dir, now)
+		if err != nil {
+			log.Printf("History tier %s not fully loaded: %v", tier.name, err)
+		}
+		total += loaded
+	}
+	if total > 0 {
+		log.Printf("Loaded %d history points from %s in %s", total, h.dir, time.Since(start).Round(time.Millisecond))
+	}
+}
+
+// TierUsage reports one tier's size in memory and on disk
+type TierUsage struct {
+	Name       string  `json:"name"`
+	Resolution string  `json:"resolution"`
+	Retention  string  `json:"retention"`
+	Series     int     `json:"series"`
+	Points     int     `json:"points"`
+	Oldest     *int64  `json:"oldest,omitempty"` // Unix ms
+	Newest     *int64  `json:"newest,omitempty"`
+	DiskBytes  int64   `json:"disk_bytes"`
+	Files      int     `json:"files"`
+	Pending    int     `json:"pending_points"` // Not yet flushed to disk
+	MemoryMB   float64 `json:"memory_mb"`      // Estimated
+}
+
+// StorageUsage reports every tier's usage
+func (h *HistoryStore) StorageUsage() []TierUsage {
+	h.mu.RLock()
+	usage := make([]TierUsage, len(h.tiers))
+	for i, tier := range h.tiers {
+		u := TierUsage{
+			Name:       tier.name,
+			Resolution: "raw",
+			Retention:  tier.retention.String(),
+			Series:     len(tier.series),
+			Pending:    len(tier.pending),
+		}
+		if tier.resolution > 0 {
+			u.Resolution = tier.resolution.String()
+		}
+		for _, points := range tier.series {
+			u.Points += len(points)
+			if len(points) == 0 {
+				continue
+			}
+			if oldest := points[0].Timestamp; u.Oldest == nil || oldest < *u.Oldest {
+				u.Oldest = &oldest
+			}
+			if newest := points[len(points)-1].Timestamp; u.Newest == nil || newest > *u.Newest {
+				u.Newest = &newest
+			}
+		}
+		u.MemoryMB = float64(u.Points*16) / (1 << 20)
+		usage[i] = u
+	}
+	h.mu.RUnlock()
+
+	if h.dir != "" {
+		for i, tier := range h.tiers {
+			files, _ := tier.segmentFiles(h.dir)
+			for path := range files {
+				if info, err := os.Stat(path); err == nil {
+					usage[i].DiskBytes += info.Size()
+					usage[i].Files++
+				}
+			}
+		}
+	}
+	return usage
+}
+
+// StorageResponse is the body of /api/v1/storage
+type StorageResponse struct {
+	Persistent     bool             `json:"persistent"`
+	Tiers          []TierUsage      `json:"tiers"`
+	DiskBytes      int64            `json:"disk_bytes"`
+	DiskHuman      string           `json:"disk_human"`
+	Points         int              `json:"points"`
+	LastCompaction *CompactionStats `json:"last_compaction"`
+	Dir            string           `json:"dir,omitempty"` // Set when persistent
+}
+
+// handleStorage reports history retention tiers, disk usage and compaction
+func handleStorage(c *gin.Context) {
+	store := GetHistoryStore()
+	if store == nil {
+		c.JSON(http.StatusServiceUnavailable, APIError{Error: "History store not initialized"})
+		return
+	}
+
+	tiers := store.StorageUsage()
+	var diskBytes int64
+	points := 0
+	for _, tier := range tiers {
+		diskBytes += tier.DiskBytes
+		points += tier.Points
+	}
+
+	store.mu.RLock()
+	lastCompaction := store.lastCompaction
+	store.mu.RUnlock()
+
+	c.JSON(http.StatusOK, StorageResponse{
+		Persistent:     store.dir != "",
+		Tiers:          tiers,
+		DiskBytes:      diskBytes,
+		DiskHuman:      humanBytes(diskBytes),
+		Points:         points,
+		LastCompaction: lastCompaction,
+		Dir:            store.dir,
+	})
+}
+
+// humanBytes formats a byte count with a binary unit
+func humanBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// TierNames lists the enabled tiers, finest first
+func (h *HistoryStore) TierNames() []string {
+	names := make([]string, len(h.tiers))
+	for i, tier := range h.tiers {
+		names[i] = tier.name
+	}
+	return names
+}
+
+// ExportTier calls fn for every point of the named tier in memory
+func (h *HistoryStore) ExportTier(name string, fn func(p persistedPoint) error) error {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for _, tier := range h.tiers {
+		if tier.name != name {
+			continue
+		}
+		names := make([]string, 0, len(tier.series))
+		for series := range tier.series {
+			names = append(names, series)
+		}
+		sort.Strings(names)
+		for _, series := range names {
+			for _, p := range tier.series[series] {
+				if err := fn(persistedPoint{Series: series, Timestamp: p.Timestamp, Value: p.Value}); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// ExportAnnotations returns every annotation, oldest first
+func (h *HistoryStore) ExportAnnotations() []Annotation {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return append([]Annotation(nil), h.annotations...)
+}
+
+// ImportTier merges points into the named tier, skipping timestamps the
+// series already has, or replaces the tier's contents (and segment files)
+// with them. Points past the tier's retention are dropped; imported points
+// are persisted at the next compaction. Returns how many points were added.
+func (h *HistoryStore) ImportTier(name string, points []persistedPoint, replace bool) (int, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var tier *historyTier
+	for _, t := range h.tiers {
+		if t.name == name {
+			tier = t
+		}
+	}
+	if tier == nil {
+		return 0, fmt.Errorf("tier %s is not enabled on this instance", name)
+	}
+
+	if replace {
+		for series := range tier.series {
+			delete(tier.series, series) // The raw map is shared with h.series
+		}
+		tier.pending = nil
+		if h.dir != "" {
+			if err := os.RemoveAll(filepath.Join(h.dir, tier.name)); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	cutoff := time.Now().Add(-tier.retention).UnixMilli()
+	added := 0
+	for _, p := range points {
+		if p.Series == "" || p.Timestamp < cutoff {
+			continue
+		}
+		existing := tier.series[p.Series]
+		i := sort.Search(len(existing), func(i int) bool { return existing[i].Timestamp >= p.Timestamp })
+		if i < len(existing) && existing[i].Timestamp == p.Timestamp {
+			continue
+		}
+		tier.series[p.Series] = insertPoint(existing, HistoryPoint{Timestamp: p.Timestamp, Value: p.Value})
+		if h.dir != "" {
+			tier.pending = append(tier.pending, p)
+		}
+		if tier.resolution > 0 {
+			if next := p.Timestamp + tier.resolution.Milliseconds(); next > tier.rolledUpTo {
+				tier.rolledUpTo = next
+			}
+		}
+		added++
+	}
+	return added, nil
+}
+
+// ImportAnnotations merges annotations, skipping exact duplicates, or
+// replaces them
+func (h *HistoryStore) ImportAnnotations(annotations []Annotation, replace bool) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if replace {
+		h.annotations = h.annotations[:0]
+	}
+	seen := make(map[string]bool, len(h.annotations))
+	annotationKey := func(a Annotation) string {
+		return fmt.Sprintf("%d|%s|%s|%s", a.Timestamp, a.Kind, a.Title, a.Text)
+	}
+	for _, a := range h.annotations {
+		seen[annotationKey(a)] = true
+	}
+	added := 0
+	for _, a := range annotations {
+		if seen[annotationKey(a)] {
+			continue
+		}
+		seen[annotationKey(a)] = true
+		h.annotations = append(h.annotations, a)
+		added++
+	}
+	sort.SliceStable(h.annotations, func(i, j int) bool { return h.annotations[i].Timestamp < h.annotations[j].Timestamp })
+	if len(h.annotations) > h.maxAnnotations {
+		h.annotations = h.annotations[len(h.annotations)-h.maxAnnotations:]
+	}
+	return added
+}
@@ -0,0 +1,314 @@
+package main
+
+import (
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HistoryPoint is a single sample of a metric series
+type HistoryPoint struct {
+	Timestamp int64   `json:"timestamp"` // Unix milliseconds
+	Value     float64 `json:"value"`
+}
+
+// Annotation marks a notable event on the timeline (epoch change, reconnect, ...)
+type Annotation struct {
+	Timestamp int64    `json:"timestamp"` // Unix milliseconds
+	Kind      string   `json:"kind"`
+	Title     string   `json:"title"`
+	Text      string   `json:"text"`
+	Tags      []string `json:"tags,omitempty"`
+}
+
+// HistoryStore keeps time series of dashboard metrics for charting and
+// export: raw samples plus coarser rollup tiers, optionally persisted to disk
+type HistoryStore struct {
+	mu             sync.RWMutex
+	series         map[string][]HistoryPoint // Raw tier
+	tiers          []*historyTier            // Raw first, then increasingly coarse rollups
+	annotations    []Annotation
+	retention      time.Duration // Raw tier retention
+	maxAnnotations int
+
+	dir            string // Segment file directory, empty when not persisted
+	lastCompaction *CompactionStats
+}
+
+// Global history store instance
+var (
+	historyStore   *HistoryStore
+	historyStoreMu sync.RWMutex
+)
+
+// NewHistoryStore creates a history store with the policy's retention tiers
+func NewHistoryStore(policy RetentionPolicy) *HistoryStore {
+	tiers := newHistoryTiers(policy)
+	return &HistoryStore{
+		series:         tiers[0].series,
+		tiers:          tiers,
+		annotations:    make([]Annotation, 0, 100),
+		retention:      policy.Raw,
+		maxAnnotations: 1000,
+		dir:            policy.Dir,
+	}
+}
+
+// InitializeHistoryStore creates the global history store, restores
+// persisted history and starts sampling and compaction
+func InitializeHistoryStore(policy RetentionPolicy, sampleInterval time.Duration) *HistoryStore {
+	store := NewHistoryStore(policy)
+	if store.dir != "" {
+		store.loadPersisted(time.Now())
+	}
+
+	historyStoreMu.Lock()
+	historyStore = store
+	historyStoreMu.Unlock()
+
+	go store.runSampler(sampleInterval)
+	go store.runCompaction(policy.CompactInterval)
+	return store
+}
+
+// GetHistoryStore returns the global history store
+func GetHistoryStore() *HistoryStore {
+	historyStoreMu.RLock()
+	defer historyStoreMu.RUnlock()
+	return historyStore
+}
+
+// Record appends a sample to a series, dropping samples past retention
+func (h *HistoryStore) Record(name string, at time.Time, value float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	point := HistoryPoint{Timestamp: at.UnixMilli(), Value: value}
+	points := insertPoint(h.series[name], point)
+	h.series[name] = pruneBefore(points, time.Now().Add(-h.retention).UnixMilli())
+
+	if h.dir != "" {
+		raw := h.tiers[0]
+		raw.pending = append(raw.pending, persistedPoint{Series: name, Timestamp: point.Timestamp, Value: value})
+	}
+}
+
+// Annotate records a timeline event
+func (h *HistoryStore) Annotate(kind, title, text string, tags ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.annotations = append(h.annotations, Annotation{
+		Timestamp: time.Now().UnixMilli(),
+		Kind:      kind,
+		Title:     title,
+		Text:      text,
+		Tags:      tags,
+	})
+	if len(h.annotations) > h.maxAnnotations {
+		h.annotations = h.annotations[len(h.annotations)-h.maxAnnotations:]
+	}
+}
+
+// SeriesNames returns the names of all recorded series, sorted
+func (h *HistoryStore) SeriesNames() []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	seen := make(map[string]bool, len(h.series))
+	names := make([]string, 0, len(h.series))
+	for _, tier := range h.tiers {
+		for name := range tier.series {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Query returns samples of a series within [from, to], from the finest tier
+// retaining data back to from. When maxPoints > 0 and more samples exist,
+// consecutive samples are averaged into maxPoints buckets.
+func (h *HistoryStore) Query(name string, from, to time.Time, maxPoints int) []HistoryPoint {
+	h.mu.RLock()
+	points := h.tierFor(from, time.Now()).series[name]
+	fromMs, toMs := from.UnixMilli(), to.UnixMilli()
+
+	start := sort.Search(len(points), func(i int) bool { return points[i].Timestamp >= fromMs })
+	end := sort.Search(len(points), func(i int) bool { return points[i].Timestamp > toMs })
+	selected := make([]HistoryPoint, end-start)
+	copy(selected, points[start:end])
+	h.mu.RUnlock()
+
+	if maxPoints <= 0 || len(selected) <= maxPoints {
+		return selected
+	}
+	return downsamplePoints(selected, maxPoints)
+}
+
+// downsamplePoints averages points into at most maxPoints buckets
+func downsamplePoints(points []HistoryPoint, maxPoints int) []HistoryPoint {
+	bucketSize := (len(points) + maxPoints - 1) / maxPoints
+	out := make([]HistoryPoint, 0, maxPoints)
+	for i := 0; i < len(points); i += bucketSize {
+		end := i + bucketSize
+		if end > len(points) {
+			end = len(points)
+		}
+		sum := 0.0
+		for _, p := range points[i:end] {
+			sum += p.Value
+		}
+		out = append(out, HistoryPoint{
+			Timestamp: points[end-1].Timestamp,
+			Value:     sum / float64(end-i),
+		})
+	}
+	return out
+}
+
+// Annotations returns annotations within [from, to], optionally filtered by kind
+func (h *HistoryStore) Annotations(from, to time.Time, kind string) []Annotation {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	fromMs, toMs := from.UnixMilli(), to.UnixMilli()
+	out := make([]Annotation, 0)
+	for _, a := range h.annotations {
+		if a.Timestamp < fromMs || a.Timestamp > toMs {
+			continue
+		}
+		if kind != "" && a.Kind != kind {
+			continue
+		}
+		out = append(out, a)
+	}
+	return out
+}
+
+// runSampler periodically records the current dashboard metrics
+func (h *HistoryStore) runSampler(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	tiers := make([]string, len(h.tiers))
+	for i, tier := range h.tiers {
+		tiers[i] = tier.name + "=" + tier.retention.String()
+	}
+	log.Printf("History sampler started (interval %s, retention %s, persisted to %q)", interval, strings.Join(tiers, " "), h.dir)
+
+	lastEpoch := int64(-1)
+	for now := range ticker.C {
+		h.sample(now, &lastEpoch)
+	}
+}
+
+// sample records one point for every available series
+func (h *HistoryStore) sample(now time.Time, lastEpoch *int64) {
+	metrics := getCurrentMetrics()
+
+	// Throughput
+	if subscriber := GetSubscriber(); subscriber != nil && subscriber.IsConnected() {
+		h.Record("tps", now, subscriber.calculateOneSecondTPS())
+		h.Record("tps_avg", now, subscriber.calculateAverageTPS())
+		h.Record("enrich_queue_depth", now, float64(subscriber.enricher.QueueDepth()))
+		if block := subscriber.GetLatestBlock(); block != nil {
+			h.Record("block_height", now, float64(block.Number))
+			h.Record("block_tx_count", now, float64(block.Transactions))
+			h.Record("block_gas_used", now, float64(block.GasUsed))
+
+			epoch := GetChainParams().Epoch(block.Number)
+			if *lastEpoch >= 0 && epoch != *lastEpoch {
+				h.Annotate("epoch", "New epoch", "Epoch rolled over", "epoch")
+				publishWebhookEvent(webhookEpochRollover, EpochRolloverEvent{
+					Epoch:         epoch,
+					PreviousEpoch: *lastEpoch,
+					Block:         block.Number,
+				})
+				if vs := GetValidatorSetTracker(); vs != nil {
+					vs.EpochRolledOver(epoch, block.Number)
+				}
+			}
+			*lastEpoch = epoch
+		}
+	} else if metrics.Timestamp > 0 {
+		h.Record("tps", now, metrics.Execution.TPS)
+		h.Record("block_height", now, float64(metrics.Consensus.CurrentHeight))
+	}
+
+	// TxPool (Prometheus)
+	if prom := GetPrometheusCollector(); prom != nil && prom.IsHealthy() {
+		pm := prom.GetMetrics()
+		h.Record("prometheus_tps", now, pm.TPS60s)
+		h.Record("txpool_pending", now, pm.PendingTxs)
+		h.Record("txpool_tracked", now, pm.TrackedTxs)
+		h.Record("txpool_insert_rpc_rate", now, pm.InsertOwnedTxsRate)
+		h.Record("txpool_insert_p2p_rate", now, pm.InsertForwardedTxsRate)
+		h.Record("txpool_drop_invalid_signature_rate", now, pm.DropInvalidSignatureRate)
+		h.Record("txpool_drop_nonce_too_low_rate", now, pm.DropNonceTooLowRate)
+		h.Record("txpool_drop_fee_too_low_rate", now, pm.DropFeeTooLowRate)
+		h.Record("txpool_drop_insufficient_balance_rate", now, pm.DropInsufficientBalanceRate)
+		h.Record("txpool_drop_pool_full_rate", now, pm.DropPoolFullRate)
+		for stage, dist := range pm.TimingDistributions() {
+			h.Record("latency_"+stage+"_p50_ms", now, dist.P50)
+			h.Record("latency_"+stage+"_p99_ms", now, dist.P99)
+		}
+	}
+
+	// Speculative vs. finalized heads
+	if ht := GetHeadTracker(); ht != nil {
+		if speculative, finalized := ht.Heads(); finalized > 0 {
+			h.Record("finality_gap", now, float64(speculative-finalized))
+		}
+	}
+
+	// Consensus
+	if ct := GetConsensusTracker(); ct != nil {
+		consensusMetrics := ct.GetMetrics()
+		if lag, ok := consensusMetrics["finality_lag"].(uint64); ok {
+			h.Record("finality_lag", now, float64(lag))
+		}
+		if avg, ok := consensusMetrics["avg_finalization_time"].(float64); ok {
+			h.Record("avg_finalization_time", now, avg)
+		}
+	}
+
+	// Fees (latest block, MON)
+	if ft := GetFeeTracker(); ft != nil {
+		if latest := ft.Latest(); latest != nil {
+			h.Record("fees_total_mon", now, weiToMON(latest.TotalFees))
+			h.Record("fees_burned_mon", now, weiToMON(latest.BurnedFees))
+			h.Record("fees_priority_mon", now, weiToMON(latest.PriorityFees))
+		}
+	}
+
+	// Host and node process resources
+	if sc := GetSystemCollector(); sc != nil {
+		if stats := sc.Latest(); stats != nil {
+			h.Record("system_cpu_percent", now, stats.Host.CPUPercent)
+			h.Record("system_mem_used_percent", now, stats.Host.MemUsedPercent)
+			if cpu, rss, ok := stats.nodeProcessTotals(); ok {
+				h.Record("node_cpu_percent", now, cpu)
+				h.Record("node_rss_bytes", now, float64(rss))
+			}
+		}
+	}
+
+	// Operator-defined log counters and gauges
+	if e := GetLogRuleEngine(); e != nil {
+		for name, value := range e.Values() {
+			h.Record("log_"+name, now, value)
+		}
+	}
+
+	// Dashboard itself
+	wsClientsMu.RLock()
+	clientCount := len(wsClients)
+	wsClientsMu.RUnlock()
+	h.Record("ws_clients", now, float64(clientCount))
+}
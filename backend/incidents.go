@@ -0,0 +1,540 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Component states recorded in the incident log
+const (
+	stateConnected = "connected"
+	stateDegraded  = "degraded"
+	stateDown      = "down"
+	stateUnknown   = "unknown" // Not configured yet, or the dashboard was restarted
+)
+
+// StateTransition is one line of the incident log
+type StateTransition struct {
+	Component string    `json:"component"`
+	State     string    `json:"state"`
+	At        time.Time `json:"at"`
+	Reason    string    `json:"reason,omitempty"`
+}
+
+// Incident is a period during which a component was degraded or down
+type Incident struct {
+	Component       string     `json:"component"`
+	Severity        string     `json:"severity"` // Worst state during the incident
+	StartedAt       time.Time  `json:"started_at"`
+	EndedAt         *time.Time `json:"ended_at,omitempty"`
+	DurationSeconds float64    `json:"duration_seconds"`
+	Ongoing         bool       `json:"ongoing"`
+	Reason          string     `json:"reason,omitempty"`
+}
+
+// UptimeStats covers one component over one window. Time in the unknown
+// state is excluded; Coverage is the share of the window with a known state.
+type UptimeStats struct {
+	UptimePercent  float64 `json:"uptime_percent"`  // Connected or degraded
+	HealthyPercent float64 `json:"healthy_percent"` // Connected only
+	Coverage       float64 `json:"coverage_percent"`
+}
+
+// IncidentTracker samples the node and every collector, records state
+// transitions to an append-only JSON lines file and derives incidents and
+// uptime from them
+type IncidentTracker struct {
+	path      string
+	retention time.Duration
+
+	mu          sync.RWMutex
+	transitions []StateTransition // Ordered by time
+	current     map[string]string
+	seen        map[string]bool // Collectors that have been connected since startup
+	file        *os.File
+}
+
+// uptimeWindows are the windows reported by /api/v1/incidents
+var uptimeWindows = []struct {
+	name   string
+	window time.Duration
+}{
+	{"24h", 24 * time.Hour},
+	{"7d", 7 * 24 * time.Hour},
+	{"30d", 30 * 24 * time.Hour},
+}
+
+// StartIncidentTracker loads the dashboard's incident log from
+// INCIDENT_LOG_PATH (default incidents.jsonl) and starts sampling component
+// states. Since the log has no record of when the dashboard itself stopped,
+// every component restarts in the unknown state.
+func (d *Dashboard) StartIncidentTracker(interval time.Duration) *IncidentTracker {
+	path := os.Getenv("INCIDENT_LOG_PATH")
+	if path == "" {
+		path = "incidents.jsonl"
+	}
+	it := &IncidentTracker{
+		path:      path,
+		retention: 30 * 24 * time.Hour,
+		current:   make(map[string]string),
+		seen:      make(map[string]bool),
+	}
+	if err := it.load(); err != nil {
+		log.Printf("Incident log %s not loaded: %v", path, err)
+	}
+
+	now := d.clock.Now()
+	for component, state := range it.current {
+		if state != stateUnknown {
+			it.record(StateTransition{Component: component, State: stateUnknown, At: now, Reason: "dashboard restarted"})
+		}
+	}
+
+	d.mu.Lock()
+	d.incidents = it
+	d.mu.Unlock()
+
+	go it.run(interval)
+	return it
+}
+
+// InitializeIncidentTracker starts the served dashboard's incident tracker
+func InitializeIncidentTracker(interval time.Duration) *IncidentTracker {
+	return GetDashboard().StartIncidentTracker(interval)
+}
+
+// GetIncidentTracker returns the served dashboard's incident tracker
+func GetIncidentTracker() *IncidentTracker {
+	return GetDashboard().IncidentTracker()
+}
+
+// load reads the log, drops transitions past retention (keeping the last
+// one per component so its state at the window start is known) and rewrites
+// the compacted file
+func (it *IncidentTracker) load() error {
+	var all []StateTransition
+	if f, err := os.Open(it.path); err == nil {
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			var t StateTransition
+			if json.Unmarshal(scanner.Bytes(), &t) == nil && t.Component != "" {
+				all = append(all, t)
+			}
+		}
+		f.Close()
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	sort.SliceStable(all, func(i, j int) bool { return all[i].At.Before(all[j].At) })
+	cutoff := time.Now().Add(-it.retention)
+	lastBefore := make(map[string]StateTransition)
+	for _, t := range all {
+		if t.At.Before(cutoff) {
+			lastBefore[t.Component] = t
+			continue
+		}
+		it.transitions = append(it.transitions, t)
+	}
+	kept := make([]StateTransition, 0, len(lastBefore)+len(it.transitions))
+	for _, t := range lastBefore {
+		kept = append(kept, t)
+	}
+	sort.SliceStable(kept, func(i, j int) bool { return kept[i].At.Before(kept[j].At) })
+	it.transitions = append(kept, it.transitions...)
+	for _, t := range it.transitions {
+		it.current[t.Component] = t.State
+	}
+	return it.rewriteLocked()
+}
+
+// rewriteLocked replaces the log file with the in-memory transitions and
+// keeps it open for appends; caller holds it.mu or owns it exclusively
+func (it *IncidentTracker) rewriteLocked() error {
+	if it.file != nil {
+		it.file.Close()
+		it.file = nil
+	}
+	tmp := it.path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	for _, t := range it.transitions {
+		line, _ := json.Marshal(t)
+		w.Write(append(line, '\n'))
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	f.Close()
+	if err := os.Rename(tmp, it.path); err != nil {
+		return err
+	}
+	it.file, err = os.OpenFile(it.path, os.O_APPEND|os.O_WRONLY, 0644)
+	return err
+}
+
+// Transitions returns a copy of every retained transition, oldest first
+func (it *IncidentTracker) Transitions() []StateTransition {
+	it.mu.RLock()
+	defer it.mu.RUnlock()
+	return append([]StateTransition(nil), it.transitions...)
+}
+
+// Import merges transitions from another instance into the log, or
+// replaces the log with them, and rewrites the file. Returns how many
+// transitions were added.
+func (it *IncidentTracker) Import(transitions []StateTransition, replace bool) (int, error) {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+
+	type key struct {
+		component, state string
+		at               int64
+	}
+	var merged []StateTransition
+	seen := make(map[key]bool)
+	if !replace {
+		merged = append(merged, it.transitions...)
+		for _, t := range it.transitions {
+			seen[key{t.Component, t.State, t.At.UnixNano()}] = true
+		}
+	}
+	added := 0
+	for _, t := range transitions {
+		k := key{t.Component, t.State, t.At.UnixNano()}
+		if t.Component == "" || seen[k] {
+			continue
+		}
+		seen[k] = true
+		merged = append(merged, t)
+		added++
+	}
+	sort.SliceStable(merged, func(i, j int) bool { return merged[i].At.Before(merged[j].At) })
+
+	it.transitions = merged
+	it.current = make(map[string]string)
+	for _, t := range merged {
+		it.current[t.Component] = t.State
+	}
+	return added, it.rewriteLocked()
+}
+
+// record adds a transition in memory, in time order, and appends it to disk
+func (it *IncidentTracker) record(t StateTransition) {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+
+	// Reported transitions can predate the latest sample
+	i := sort.Search(len(it.transitions), func(i int) bool { return it.transitions[i].At.After(t.At) })
+	it.transitions = append(it.transitions, StateTransition{})
+	copy(it.transitions[i+1:], it.transitions[i:])
+	it.transitions[i] = t
+	it.current[t.Component] = t.State
+	if it.file != nil {
+		line, _ := json.Marshal(t)
+		if _, err := it.file.Write(append(line, '\n')); err != nil {
+			log.Printf("Failed to append to incident log: %v", err)
+		}
+	}
+}
+
+func (it *IncidentTracker) run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		it.sample()
+	}
+}
+
+// sample checks every component and records state changes. Collectors
+// stay unknown until first connected, so ones that are not deployed (e.g.
+// no IPC socket) do not show up as permanent incidents.
+func (it *IncidentTracker) sample() {
+	now := time.Now()
+	states := componentStates(now)
+	noteComponentStates(states, now)
+	for component, observed := range states {
+		it.mu.Lock()
+		if observed.State == stateConnected {
+			it.seen[component] = true
+		} else if !alwaysReported(component) && !it.seen[component] {
+			observed.State, observed.Reason = stateUnknown, ""
+		}
+		previous, known := it.current[component]
+		it.mu.Unlock()
+
+		if observed.State == previous || (!known && observed.State == stateUnknown) {
+			continue
+		}
+		it.transition(component, previous, known, observed, now)
+	}
+}
+
+// Report records a component state observed outside the periodic sample,
+// such as a head stall, as of when it began
+func (it *IncidentTracker) Report(component, state, reason string, at time.Time) {
+	it.mu.RLock()
+	previous, known := it.current[component]
+	it.mu.RUnlock()
+	if state == previous {
+		return
+	}
+	it.transition(component, previous, known, StateTransition{State: state, Reason: reason}, at)
+}
+
+// transition records a state change, marks it on the timeline and fires or
+// resolves the component's alert
+func (it *IncidentTracker) transition(component, previous string, known bool, observed StateTransition, at time.Time) {
+	it.record(StateTransition{Component: component, State: observed.State, At: at, Reason: observed.Reason})
+
+	if observed.State == stateDegraded || observed.State == stateDown || (known && previous != stateUnknown) {
+		log.Printf("Incident log: %s %s -> %s %s", component, previous, observed.State, observed.Reason)
+		if store := GetHistoryStore(); store != nil {
+			store.Annotate("incident", fmt.Sprintf("%s %s", component, observed.State), observed.Reason, "incident", component)
+		}
+	}
+
+	// Alerts fire on entering degraded/down and resolve on recovery
+	event := ""
+	switch {
+	case observed.State == stateDegraded || observed.State == stateDown:
+		event = webhookAlertFired
+	case observed.State == stateConnected && (previous == stateDegraded || previous == stateDown):
+		event = webhookAlertResolved
+	}
+	if event != "" {
+		alert := AlertEvent{Component: component, State: observed.State, Previous: previous, Reason: observed.Reason, At: at}
+		publishWebhookEvent(event, alert)
+		publishBusEvent(busAlerts, event, component, alert)
+		notifyAlert(event, alert)
+	}
+}
+
+// alwaysReported components are reported from their first sample: the node,
+// the local clock and explicitly configured disk paths
+func alwaysReported(component string) bool {
+	return component == "node" || component == "clock" || strings.HasPrefix(component, "disk_")
+}
+
+// componentStates classifies the node and each collector. The node is
+// connected while subscribed blocks keep arriving; a collector is connected
+// while healthy and fresh.
+func componentStates(now time.Time) map[string]StateTransition {
+	states := make(map[string]StateTransition)
+
+	var lastBlock time.Time
+	if subscriber := GetSubscriber(); subscriber != nil {
+		if block := subscriber.GetLatestBlock(); block != nil {
+			lastBlock = GetClockSkew().BlockTime(block.Timestamp)
+		}
+	}
+	age := now.Sub(lastBlock)
+	switch {
+	case lastBlock.IsZero():
+		states["node"] = StateTransition{State: stateDown, Reason: "no blocks received"}
+	case age < 10*time.Second:
+		states["node"] = StateTransition{State: stateConnected}
+	case age < time.Minute:
+		states["node"] = StateTransition{State: stateDegraded, Reason: fmt.Sprintf("last block %.0fs ago", age.Seconds())}
+	default:
+		states["node"] = StateTransition{State: stateDown, Reason: fmt.Sprintf("last block %.0fs ago", age.Seconds())}
+	}
+
+	for _, status := range GetSourceResolver().Statuses() {
+		if status.Name == "mock_data" {
+			continue
+		}
+		switch {
+		case status.LastUpdated.IsZero():
+			states[status.Name] = StateTransition{State: stateUnknown}
+		case !status.Healthy:
+			states[status.Name] = StateTransition{State: stateDown, Reason: "source unhealthy"}
+		case status.Freshness > 30:
+			states[status.Name] = StateTransition{State: stateDegraded, Reason: fmt.Sprintf("stale for %.0fs", status.Freshness)}
+		default:
+			states[status.Name] = StateTransition{State: stateConnected}
+		}
+	}
+
+	if cs := GetClockSkew(); cs != nil {
+		cs.componentState(states)
+	}
+	if m := GetNodeDiskMonitor(); m != nil {
+		m.componentStates(states)
+	}
+
+	for component, state := range states {
+		state.Component = component
+		states[component] = state
+	}
+	return states
+}
+
+// Current returns the latest state of every component
+func (it *IncidentTracker) Current() map[string]string {
+	it.mu.RLock()
+	defer it.mu.RUnlock()
+	current := make(map[string]string, len(it.current))
+	for component, state := range it.current {
+		current[component] = state
+	}
+	return current
+}
+
+// componentTransitions returns a component's transitions in time order
+func (it *IncidentTracker) componentTransitions(component string) []StateTransition {
+	it.mu.RLock()
+	defer it.mu.RUnlock()
+	var out []StateTransition
+	for _, t := range it.transitions {
+		if t.Component == component {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// Incidents returns a component's degraded/down periods, newest first
+func (it *IncidentTracker) Incidents(component string, now time.Time) []Incident {
+	var incidents []Incident
+	var open *Incident
+	for _, t := range it.componentTransitions(component) {
+		switch t.State {
+		case stateDegraded, stateDown:
+			if open == nil {
+				open = &Incident{Component: component, Severity: t.State, StartedAt: t.At, Reason: t.Reason}
+			} else if t.State == stateDown {
+				open.Severity = stateDown
+				open.Reason = t.Reason
+			}
+		default:
+			// Recovery ends the incident; an unknown gap ends it too, since
+			// the dashboard cannot tell how long it lasted
+			if open != nil {
+				ended := t.At
+				open.EndedAt = &ended
+				open.DurationSeconds = ended.Sub(open.StartedAt).Seconds()
+				incidents = append(incidents, *open)
+				open = nil
+			}
+		}
+	}
+	if open != nil {
+		open.Ongoing = true
+		open.DurationSeconds = now.Sub(open.StartedAt).Seconds()
+		incidents = append(incidents, *open)
+	}
+
+	for i, j := 0, len(incidents)-1; i < j; i, j = i+1, j-1 {
+		incidents[i], incidents[j] = incidents[j], incidents[i]
+	}
+	return incidents
+}
+
+// Uptime computes a component's uptime over the window ending at now
+func (it *IncidentTracker) Uptime(component string, window time.Duration, now time.Time) UptimeStats {
+	from := now.Add(-window)
+	transitions := it.componentTransitions(component)
+
+	durations := make(map[string]time.Duration)
+	state := stateUnknown
+	cursor := from
+	for _, t := range transitions {
+		if t.At.After(now) {
+			break
+		}
+		if t.At.After(from) {
+			durations[state] += t.At.Sub(cursor)
+			cursor = t.At
+		}
+		state = t.State
+	}
+	durations[state] += now.Sub(cursor)
+
+	known := window - durations[stateUnknown]
+	stats := UptimeStats{Coverage: percentOf(known, window)}
+	if known > 0 {
+		stats.UptimePercent = percentOf(durations[stateConnected]+durations[stateDegraded], known)
+		stats.HealthyPercent = percentOf(durations[stateConnected], known)
+	}
+	return stats
+}
+
+func percentOf(part, total time.Duration) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return float64(part) / float64(total) * 100
+}
+
+// IncidentsResponse is the body of /api/v1/incidents
+type IncidentsResponse struct {
+	Current   map[string]string                 `json:"current"`   // Component → state
+	Incidents []Incident                        `json:"incidents"` // Newest first
+	Uptime    map[string]map[string]UptimeStats `json:"uptime"`    // Component → window → stats
+}
+
+// handleIncidents returns the incident timeline and uptime per component
+// (?component= to filter, ?limit= incidents per component, default 50)
+func handleIncidents(c *gin.Context) {
+	it := GetIncidentTracker()
+	if it == nil {
+		c.JSON(http.StatusServiceUnavailable, APIError{Error: "incident tracking not enabled"})
+		return
+	}
+
+	limit := 50
+	if value := c.Query("limit"); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			limit = n
+		}
+	}
+
+	now := time.Now()
+	current := it.Current()
+	components := make([]string, 0, len(current))
+	for component := range current {
+		if filter := c.Query("component"); filter == "" || filter == component {
+			components = append(components, component)
+		}
+	}
+	sort.Strings(components)
+
+	incidents := make([]Incident, 0)
+	uptime := make(map[string]map[string]UptimeStats, len(components))
+	for _, component := range components {
+		componentIncidents := it.Incidents(component, now)
+		if len(componentIncidents) > limit {
+			componentIncidents = componentIncidents[:limit]
+		}
+		incidents = append(incidents, componentIncidents...)
+
+		uptime[component] = make(map[string]UptimeStats, len(uptimeWindows))
+		for _, w := range uptimeWindows {
+			uptime[component][w.name] = it.Uptime(component, w.window, now)
+		}
+	}
+	sort.SliceStable(incidents, func(i, j int) bool { return incidents[i].StartedAt.After(incidents[j].StartedAt) })
+
+	c.JSON(http.StatusOK, IncidentsResponse{
+		Current:   current,
+		Incidents: incidents,
+		Uptime:    uptime,
+	})
+}
@@ -0,0 +1,411 @@
+//go:build integration
+
+// Package integration boots the dashboard against an in-process mock Monad
+// node and asserts on its REST and WebSocket outputs.
+//
+//	go test -tags integration ./integration                  # builds the dashboard from ../
+//	go test -tags integration ./integration -args -bin ../monad-dashboard
+//
+// The mock node binds the dashboard's default RPC (8080), WebSocket (8081)
+// and Prometheus (8889) ports and the dashboard listens on 4000, so nothing
+// else may be listening on them; hence the build tag.
+package integration
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"monad-dashboard/mocknode"
+)
+
+// probeKey signs the dashboard's inclusion probe transactions
+const probeKey = "0x4646464646464646464646464646464646464646464646464646464646464646"
+
+var (
+	dashboardURL = "http://127.0.0.1:4000"
+	wsBaseURL    = "ws://127.0.0.1:4000"
+)
+
+var (
+	bin          = flag.String("bin", "", "dashboard binary (built from -src when empty)")
+	src          = flag.String("src", "..", "dashboard source directory used when -bin is empty")
+	checkTimeout = flag.Duration("check-timeout", 20*time.Second, "per-check timeout")
+	logs         = flag.Bool("dashboard-logs", false, "stream dashboard logs")
+)
+
+// TestDashboard runs the checks in order against one mock node and one
+// dashboard process; later checks reuse what earlier ones found
+func TestDashboard(t *testing.T) {
+	node := mocknode.New(mocknode.DefaultConfig())
+	if err := node.Start(); err != nil {
+		t.Fatalf("Failed to start mock node: %v", err)
+	}
+	defer node.Close()
+
+	// Let a few blocks exist before the dashboard connects
+	time.Sleep(time.Second)
+
+	path := *bin
+	if path == "" {
+		path = filepath.Join(t.TempDir(), "monad-dashboard")
+		build := exec.Command("go", "build", "-o", path, ".")
+		build.Dir = *src
+		build.Stdout, build.Stderr = os.Stdout, os.Stderr
+		if err := build.Run(); err != nil {
+			t.Fatalf("Failed to build dashboard: %v", err)
+		}
+	}
+
+	dashboard := exec.Command(path)
+	// The mock node includes valid transfers from any key, so the probe runs unfunded
+	dashboard.Env = append(os.Environ(), "TX_PROBE_KEY="+probeKey, "TX_PROBE_INTERVAL=1s")
+	if *logs {
+		dashboard.Stdout, dashboard.Stderr = os.Stdout, os.Stderr
+	}
+	if err := dashboard.Start(); err != nil {
+		t.Fatalf("Failed to start dashboard: %v", err)
+	}
+	defer func() {
+		dashboard.Process.Kill()
+		dashboard.Wait()
+	}()
+
+	s := &suite{t: t, node: node, timeout: *checkTimeout}
+	s.run()
+}
+
+// suite runs checks that are retried until they pass or time out, since
+// the dashboard fills its trackers asynchronously as blocks arrive
+type suite struct {
+	t       *testing.T
+	node    *mocknode.Node
+	timeout time.Duration
+}
+
+func (s *suite) check(name string, fn func() error) {
+	s.t.Run(name, func(t *testing.T) {
+		deadline := time.Now().Add(s.timeout)
+		var err error
+		for {
+			if err = fn(); err == nil || time.Now().After(deadline) {
+				break
+			}
+			time.Sleep(250 * time.Millisecond)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func (s *suite) run() {
+	chain := s.node.Chain()
+
+	s.check("health", func() error {
+		var body map[string]interface{}
+		if err := getJSON("/api/v1/health", &body); err != nil {
+			return err
+		}
+		if body["status"] != "ok" {
+			return fmt.Errorf("status = %v", body["status"])
+		}
+		return nil
+	})
+
+	s.check("consensus height follows the mock head", func() error {
+		var body struct {
+			Consensus struct {
+				CurrentHeight int64 `json:"current_height"`
+			} `json:"consensus"`
+		}
+		if err := getJSON("/api/v1/metrics", &body); err != nil {
+			return err
+		}
+		head := chain.Head().Number
+		if body.Consensus.CurrentHeight <= 0 || body.Consensus.CurrentHeight > head {
+			return fmt.Errorf("current_height = %d, mock head = %d", body.Consensus.CurrentHeight, head)
+		}
+		return nil
+	})
+
+	s.check("fees track mock blocks", func() error {
+		var body struct {
+			Recent []struct {
+				BlockNumber int64  `json:"block_number"`
+				Miner       string `json:"miner"`
+			} `json:"recent"`
+		}
+		if err := getJSON("/api/v1/fees", &body); err != nil {
+			return err
+		}
+		if len(body.Recent) == 0 {
+			return fmt.Errorf("no recent blocks")
+		}
+		b, ok := chain.Block(body.Recent[0].BlockNumber)
+		if !ok || !strings.EqualFold(b.Miner, body.Recent[0].Miner) {
+			return fmt.Errorf("block %d miner %s does not match the mock", body.Recent[0].BlockNumber, body.Recent[0].Miner)
+		}
+		return nil
+	})
+
+	s.check("execution stats match receipts", func() error {
+		var body struct {
+			Blocks []struct {
+				BlockNumber int64 `json:"block_number"`
+				TxCount     int   `json:"tx_count"`
+				Reverted    int   `json:"reverted"`
+			} `json:"blocks"`
+		}
+		if err := getJSON("/api/v1/execution/blocks", &body); err != nil {
+			return err
+		}
+		if len(body.Blocks) == 0 {
+			return fmt.Errorf("no blocks")
+		}
+		for _, got := range body.Blocks {
+			b, ok := chain.Block(got.BlockNumber)
+			if !ok {
+				return fmt.Errorf("unknown block %d", got.BlockNumber)
+			}
+			if got.TxCount != len(b.Txs) || got.Reverted != b.Reverted() {
+				return fmt.Errorf("block %d: tx_count=%d reverted=%d, mock has %d/%d",
+					got.BlockNumber, got.TxCount, got.Reverted, len(b.Txs), b.Reverted())
+			}
+		}
+		return nil
+	})
+
+	var transferTx string
+	s.check("token transfers indexed from monadLogs", func() error {
+		var body struct {
+			Tokens []struct {
+				Token     string `json:"token"`
+				Transfers int    `json:"transfers"`
+			} `json:"tokens"`
+			Recent []struct {
+				TransactionHash string `json:"transaction_hash"`
+				BlockNumber     int64  `json:"block_number"`
+			} `json:"recent"`
+		}
+		if err := getJSON("/api/v1/tokens/top", &body); err != nil {
+			return err
+		}
+		for _, t := range body.Tokens {
+			if strings.EqualFold(t.Token, mocknode.TokenAddress) && t.Transfers > 0 {
+				// Pick a transfer a few blocks old so its receipt has been fetched
+				for _, r := range body.Recent {
+					if r.BlockNumber < chain.Head().Number-2 {
+						transferTx = r.TransactionHash
+						return nil
+					}
+				}
+				return fmt.Errorf("no settled transfers yet")
+			}
+		}
+		return fmt.Errorf("mock token %s not in top tokens", mocknode.TokenAddress)
+	})
+
+	s.check("tx lifecycle has inclusion and receipt", func() error {
+		if transferTx == "" {
+			return fmt.Errorf("no transaction to look up")
+		}
+		var body struct {
+			Lifecycle struct {
+				BlockNumber int64  `json:"block_number"`
+				IncludedAt  string `json:"included_at"`
+				ReceiptAt   string `json:"receipt_at"`
+			} `json:"lifecycle"`
+		}
+		if err := getJSON("/api/v1/tx/"+transferTx+"/lifecycle", &body); err != nil {
+			return err
+		}
+		b, _ := chain.TxBlock(transferTx)
+		if b == nil || body.Lifecycle.BlockNumber != b.Number {
+			return fmt.Errorf("block_number = %d", body.Lifecycle.BlockNumber)
+		}
+		if body.Lifecycle.IncludedAt == "" || body.Lifecycle.ReceiptAt == "" {
+			return fmt.Errorf("lifecycle incomplete: %+v", body.Lifecycle)
+		}
+		return nil
+	})
+
+	s.check("address inspector shows the mock token contract", func() error {
+		var body struct {
+			IsContract bool `json:"is_contract"`
+			Activity   struct {
+				Counts map[string]int64 `json:"counts"`
+			} `json:"activity"`
+		}
+		if err := getJSON("/api/v1/address/"+mocknode.TokenAddress, &body); err != nil {
+			return err
+		}
+		if !body.IsContract {
+			return fmt.Errorf("is_contract = false")
+		}
+		if body.Activity.Counts["log_emitted"] == 0 || body.Activity.Counts["tx_received"] == 0 {
+			return fmt.Errorf("activity counts = %v", body.Activity.Counts)
+		}
+		return nil
+	})
+
+	s.check("inclusion probe transactions reach finality", func() error {
+		var body struct {
+			Recent []struct {
+				Hash   string `json:"hash"`
+				Status string `json:"status"`
+				Block  int64  `json:"block"`
+			} `json:"recent"`
+		}
+		if err := getJSON("/api/v1/probe/inclusion", &body); err != nil {
+			return err
+		}
+		for _, probe := range body.Recent {
+			if probe.Status != "finalized" {
+				continue
+			}
+			if b, ok := chain.TxBlock(probe.Hash); !ok || b.Number != probe.Block {
+				return fmt.Errorf("probe %s not in mock block %d", probe.Hash, probe.Block)
+			}
+			return nil
+		}
+		return fmt.Errorf("no finalized probe among %d", len(body.Recent))
+	})
+
+	s.check("pending transactions counted from newPendingTransactions", func() error {
+		var body struct {
+			Subscribed bool  `json:"subscribed"`
+			Total      int64 `json:"total"`
+		}
+		if err := getJSON("/api/v1/mempool/pending", &body); err != nil {
+			return err
+		}
+		if !body.Subscribed {
+			return fmt.Errorf("not subscribed")
+		}
+		if body.Total == 0 {
+			return fmt.Errorf("no pending transactions seen")
+		}
+		return nil
+	})
+
+	s.check("prometheus series scraped", func() error {
+		var body struct {
+			Healthy bool                       `json:"healthy"`
+			Gauges  map[string]json.RawMessage `json:"gauges"`
+		}
+		if err := getJSON("/api/v1/prometheus", &body); err != nil {
+			return err
+		}
+		if !body.Healthy {
+			return fmt.Errorf("prometheus source unhealthy")
+		}
+		if _, ok := body.Gauges["monad_bft_txpool_pool_pending_txs"]; !ok {
+			return fmt.Errorf("pending_txs gauge missing")
+		}
+		return nil
+	})
+
+	s.check("firedancer websocket stream", func() error {
+		conn, _, err := websocket.DefaultDialer.Dial(wsBaseURL+"/websocket", nil)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		block := chain.Finalized()
+		conn.WriteJSON(map[string]interface{}{
+			"topic":  "query",
+			"key":    "rpc_block",
+			"id":     42,
+			"params": map[string]interface{}{"number": block.Number},
+		})
+
+		seen := map[string]bool{}
+		var lastSeq uint64
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		for !(seen["summary.estimated_slot"] && seen["sequenced"] && seen["query"]) {
+			var msg struct {
+				Topic string          `json:"topic"`
+				Key   string          `json:"key"`
+				ID    json.RawMessage `json:"id"`
+				Seq   uint64          `json:"seq"`
+				Value json.RawMessage `json:"value"`
+			}
+			if err := conn.ReadJSON(&msg); err != nil {
+				return fmt.Errorf("missing messages %v: %w", seen, err)
+			}
+			seen[msg.Topic+"."+msg.Key] = true
+			if msg.Seq > 0 {
+				if lastSeq > 0 && msg.Seq <= lastSeq {
+					return fmt.Errorf("seq went backwards: %d after %d", msg.Seq, lastSeq)
+				}
+				lastSeq = msg.Seq
+				seen["sequenced"] = true
+			}
+			if msg.Topic == "query" {
+				if string(msg.ID) != "42" || msg.Key != "rpc_block" {
+					return fmt.Errorf("unexpected query reply %s/%s: %s", msg.Key, msg.ID, msg.Value)
+				}
+				var value struct {
+					Block struct {
+						Hash string `json:"hash"`
+					} `json:"block"`
+				}
+				json.Unmarshal(msg.Value, &value)
+				if value.Block.Hash != block.Hash {
+					return fmt.Errorf("rpc_block hash = %s, want %s", value.Block.Hash, block.Hash)
+				}
+				seen["query"] = true
+			}
+		}
+		return nil
+	})
+
+	s.check("native websocket stream", func() error {
+		conn, _, err := websocket.DefaultDialer.Dial(wsBaseURL+"/ws/native", nil)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		for {
+			var msg struct {
+				Type string `json:"type"`
+			}
+			if err := conn.ReadJSON(&msg); err != nil {
+				return err
+			}
+			if msg.Type == "head.block" {
+				return nil
+			}
+		}
+	})
+}
+
+func getJSON(path string, out interface{}) error {
+	resp, err := http.Get(dashboardURL + path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: HTTP %d: %s", path, resp.StatusCode, body)
+	}
+	return json.Unmarshal(body, out)
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// proposalRecord is one block as seen from the local node
+type proposalRecord struct {
+	number     int64
+	proposer   string
+	txs        int
+	fullness   float64
+	intervalMs float64 // Since the previous block arrived, -1 for backfilled or non-consecutive blocks
+	drops      float64 // Txpool drops since the previous block, -1 when unknown
+}
+
+// recordProposalLocked adds a block to the performance window. Arrival
+// intervals and drops are only attributed to blocks that advance the head
+// right after their parent, since backfilled blocks arrive late and in bulk.
+// Caller holds lt.mu.
+func (lt *LeaderTracker) recordProposalLocked(block *BlockHeader, now time.Time, drops float64, dropsKnown bool) {
+	record := proposalRecord{
+		number:     block.Number,
+		proposer:   strings.ToLower(block.Miner),
+		txs:        block.Transactions,
+		intervalMs: -1,
+		drops:      -1,
+	}
+	if block.GasLimit > 0 {
+		record.fullness = float64(block.GasUsed) / float64(block.GasLimit)
+	}
+
+	if block.Number > lt.latestBlock {
+		if block.Number == lt.latestBlock+1 && !lt.lastArrival.IsZero() {
+			record.intervalMs = float64(now.Sub(lt.lastArrival).Microseconds()) / 1000
+			// A counter that went backwards was reset; the delta is unknown
+			if dropsKnown && lt.lastDrops >= 0 && drops >= lt.lastDrops {
+				record.drops = drops - lt.lastDrops
+			}
+		}
+		lt.lastArrival = now
+		lt.lastDrops = -1
+		if dropsKnown {
+			lt.lastDrops = drops
+		}
+	}
+
+	lt.proposals = append(lt.proposals, record)
+	if len(lt.proposals) > lt.maxProposals {
+		lt.proposals = append(lt.proposals[:0:0], lt.proposals[len(lt.proposals)-lt.maxProposals:]...)
+	}
+}
+
+// LeaderPerformance aggregates the blocks of one proposer, or of every
+// proposer for the network average
+type LeaderPerformance struct {
+	Address               string   `json:"address,omitempty"`
+	Blocks                int      `json:"blocks"`
+	ShareOfBlocks         float64  `json:"share_of_blocks"` // Fraction of the window's blocks
+	AvgTxsPerBlock        float64  `json:"avg_txs_per_block"`
+	AvgFullness           float64  `json:"avg_fullness"` // gasUsed / gasLimit
+	EmptyBlockRate        float64  `json:"empty_block_rate"`
+	AvgProposalIntervalMs *float64 `json:"avg_proposal_interval_ms"` // Parent arrival to block arrival; null without consecutive live blocks
+	DropsPerBlock         *float64 `json:"drops_per_block"`          // Local txpool drops while leading; null without drop counters
+}
+
+// PerformanceComparison relates the local validator to the network average
+type PerformanceComparison struct {
+	TxsPerBlockRatio        float64  `json:"txs_per_block_ratio"` // Self / network
+	FullnessDelta           float64  `json:"fullness_delta"`      // Self − network
+	EmptyBlockRateDelta     float64  `json:"empty_block_rate_delta"`
+	ProposalIntervalDeltaMs *float64 `json:"proposal_interval_delta_ms"`
+	DropsPerBlockDelta      *float64 `json:"drops_per_block_delta"`
+	TxsPerBlockRank         int      `json:"txs_per_block_rank"` // 1 = most transactions per block among proposers
+	Proposers               int      `json:"proposers"`
+}
+
+// ValidatorPerformanceResponse is the body of /api/v1/validators/self/performance
+type ValidatorPerformanceResponse struct {
+	SelfAddress  string                 `json:"self_address"`
+	WindowBlocks int                    `json:"window_blocks"`
+	FromBlock    int64                  `json:"from_block"`
+	ToBlock      int64                  `json:"to_block"`
+	Self         *LeaderPerformance     `json:"self"`       // Null when MONAD_VALIDATOR_ADDRESS is unset or it led no block in the window
+	Network      LeaderPerformance      `json:"network"`    // Every block in the window
+	Comparison   *PerformanceComparison `json:"comparison"` // Null without self blocks
+	Leaders      []LeaderPerformance    `json:"leaders"`    // Most blocks first
+}
+
+// performanceAccumulator sums proposal records
+type performanceAccumulator struct {
+	blocks, empty       int
+	txs                 int
+	fullness            float64
+	interval, drops     float64
+	intervals, dropsSet int
+}
+
+func (a *performanceAccumulator) add(r proposalRecord) {
+	a.blocks++
+	a.txs += r.txs
+	a.fullness += r.fullness
+	if r.txs == 0 {
+		a.empty++
+	}
+	if r.intervalMs >= 0 {
+		a.interval += r.intervalMs
+		a.intervals++
+	}
+	if r.drops >= 0 {
+		a.drops += r.drops
+		a.dropsSet++
+	}
+}
+
+func (a *performanceAccumulator) summary(address string, total int) LeaderPerformance {
+	p := LeaderPerformance{Address: address, Blocks: a.blocks}
+	if a.blocks == 0 {
+		return p
+	}
+	n := float64(a.blocks)
+	p.ShareOfBlocks = n / float64(total)
+	p.AvgTxsPerBlock = float64(a.txs) / n
+	p.AvgFullness = a.fullness / n
+	p.EmptyBlockRate = float64(a.empty) / n
+	if a.intervals > 0 {
+		avg := a.interval / float64(a.intervals)
+		p.AvgProposalIntervalMs = &avg
+	}
+	if a.dropsSet > 0 {
+		avg := a.drops / float64(a.dropsSet)
+		p.DropsPerBlock = &avg
+	}
+	return p
+}
+
+// optionalDelta returns a − b when both are known
+func optionalDelta(a, b *float64) *float64 {
+	if a == nil || b == nil {
+		return nil
+	}
+	delta := *a - *b
+	return &delta
+}
+
+// Performance aggregates the window per proposer and compares the local
+// validator with the network
+func (lt *LeaderTracker) Performance() ValidatorPerformanceResponse {
+	lt.mu.RLock()
+	self := lt.selfAddress
+	records := append([]proposalRecord(nil), lt.proposals...)
+	lt.mu.RUnlock()
+
+	result := ValidatorPerformanceResponse{
+		SelfAddress:  self,
+		WindowBlocks: len(records),
+		Leaders:      []LeaderPerformance{},
+	}
+	if len(records) == 0 {
+		return result
+	}
+
+	var network performanceAccumulator
+	byLeader := make(map[string]*performanceAccumulator)
+	result.FromBlock, result.ToBlock = records[0].number, records[0].number
+	for _, r := range records {
+		network.add(r)
+		acc := byLeader[r.proposer]
+		if acc == nil {
+			acc = &performanceAccumulator{}
+			byLeader[r.proposer] = acc
+		}
+		acc.add(r)
+		if r.number < result.FromBlock {
+			result.FromBlock = r.number
+		}
+		if r.number > result.ToBlock {
+			result.ToBlock = r.number
+		}
+	}
+	result.Network = network.summary("", len(records))
+
+	for address, acc := range byLeader {
+		result.Leaders = append(result.Leaders, acc.summary(address, len(records)))
+	}
+	sort.Slice(result.Leaders, func(i, j int) bool {
+		if result.Leaders[i].Blocks != result.Leaders[j].Blocks {
+			return result.Leaders[i].Blocks > result.Leaders[j].Blocks
+		}
+		return result.Leaders[i].Address < result.Leaders[j].Address
+	})
+
+	acc := byLeader[self]
+	if self == "" || acc == nil {
+		return result
+	}
+	perf := acc.summary(self, len(records))
+	result.Self = &perf
+
+	comparison := &PerformanceComparison{
+		FullnessDelta:           perf.AvgFullness - result.Network.AvgFullness,
+		EmptyBlockRateDelta:     perf.EmptyBlockRate - result.Network.EmptyBlockRate,
+		ProposalIntervalDeltaMs: optionalDelta(perf.AvgProposalIntervalMs, result.Network.AvgProposalIntervalMs),
+		DropsPerBlockDelta:      optionalDelta(perf.DropsPerBlock, result.Network.DropsPerBlock),
+		TxsPerBlockRank:         1,
+		Proposers:               len(result.Leaders),
+	}
+	if result.Network.AvgTxsPerBlock > 0 {
+		comparison.TxsPerBlockRatio = perf.AvgTxsPerBlock / result.Network.AvgTxsPerBlock
+	}
+	for _, leader := range result.Leaders {
+		if leader.AvgTxsPerBlock > perf.AvgTxsPerBlock {
+			comparison.TxsPerBlockRank++
+		}
+	}
+	result.Comparison = comparison
+	return result
+}
+
+// handleValidatorPerformance compares the local validator's blocks with
+// every other proposer's over the recent window (?leaders= proposers
+// listed, default 20)
+func handleValidatorPerformance(c *gin.Context) {
+	lt := GetLeaderTracker()
+	if lt == nil {
+		c.JSON(http.StatusServiceUnavailable, APIError{Error: "Leader tracker not initialized"})
+		return
+	}
+
+	limit := 20
+	if value := c.Query("leaders"); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n >= 0 {
+			limit = n
+		}
+	}
+
+	result := lt.Performance()
+	if len(result.Leaders) > limit {
+		result.Leaders = result.Leaders[:limit]
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// RecentProposers returns the last block each proposer of the performance
+// window produced, with the number of blocks the window holds and the head
+func (lt *LeaderTracker) RecentProposers() (proposers map[string]int64, blocks int, head int64) {
+	lt.mu.RLock()
+	defer lt.mu.RUnlock()
+	proposers = make(map[string]int64)
+	for _, r := range lt.proposals {
+		if r.number > proposers[r.proposer] {
+			proposers[r.proposer] = r.number
+		}
+	}
+	return proposers, len(lt.proposals), lt.latestBlock
+}
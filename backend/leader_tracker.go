@@ -0,0 +1,391 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LeaderSlotStats summarizes one block proposed by the local node
+type LeaderSlotStats struct {
+	BlockNumber int64   `json:"block_number"`
+	TxCount     int     `json:"tx_count"`
+	GasUsed     int64   `json:"gas_used"`
+	Fullness    float64 `json:"fullness"` // gasUsed / gasLimit
+	Timestamp   int64   `json:"timestamp"`
+}
+
+// LeaderTracker follows block proposers to tell whether the local node is
+// the current leader, when it leads next, and how its leader slots perform.
+//
+// The leader schedule is read from the BFT control panel when it answers
+// monad_getLeaderSchedule; otherwise the next slot is estimated from the
+// spacing of previously observed self-proposed blocks.
+type LeaderTracker struct {
+	selfAddress   string
+	addressSource string // "env", "node.toml" or "unknown"
+	ipcPath       string
+
+	mu              sync.RWMutex
+	latestBlock     int64
+	latestProposer  string
+	selfSlots       []LeaderSlotStats
+	maxSelfSlots    int
+	schedule        map[int64]string // block number -> leader address
+	scheduleSource  string           // "ipc", "estimated" or "unavailable"
+	scheduleUpdated time.Time
+
+	// Recent blocks of every proposer, for /api/v1/validators/self/performance
+	proposals    []proposalRecord
+	maxProposals int
+	lastArrival  time.Time
+	lastDrops    float64 // Cumulative txpool drops at the previous block, -1 when unknown
+
+	// Scheduled self slots, to derive the skip rate
+	scheduledSlots int64
+	skippedSlots   int64
+	recentSkipped  []int64
+}
+
+// Global leader tracker instance
+var (
+	leaderTracker   *LeaderTracker
+	leaderTrackerMu sync.RWMutex
+)
+
+// NewLeaderTracker creates a leader tracker for the given validator address
+func NewLeaderTracker(selfAddress, ipcPath string) *LeaderTracker {
+	return &LeaderTracker{
+		selfAddress:    strings.ToLower(selfAddress),
+		ipcPath:        ipcPath,
+		selfSlots:      make([]LeaderSlotStats, 0, 100),
+		maxSelfSlots:   100,
+		schedule:       make(map[int64]string),
+		scheduleSource: "unavailable",
+		maxProposals:   10000,
+		lastDrops:      -1,
+	}
+}
+
+// InitializeLeaderTracker creates the global leader tracker. The local
+// validator address comes from MONAD_VALIDATOR_ADDRESS, else from the
+// beneficiary in node.toml.
+func InitializeLeaderTracker(ipcPath string) *LeaderTracker {
+	leaderTrackerMu.Lock()
+	defer leaderTrackerMu.Unlock()
+
+	selfAddress, source := selfValidatorAddress()
+	leaderTracker = NewLeaderTracker(selfAddress, ipcPath)
+	leaderTracker.addressSource = source
+	if n, ok := positiveIntEnv("LEADER_PERF_WINDOW"); ok {
+		leaderTracker.maxProposals = int(n)
+	}
+	if selfAddress == "" {
+		log.Printf("MONAD_VALIDATOR_ADDRESS not set and no beneficiary in node.toml; leader slot tracking disabled")
+	} else {
+		log.Printf("Local validator %s (from %s)", selfAddress, source)
+		go leaderTracker.refreshScheduleLoop(30 * time.Second)
+	}
+	return leaderTracker
+}
+
+// GetLeaderTracker returns the global leader tracker
+func GetLeaderTracker() *LeaderTracker {
+	leaderTrackerMu.RLock()
+	defer leaderTrackerMu.RUnlock()
+	return leaderTracker
+}
+
+// SetSelfAddress updates the local validator address
+func (lt *LeaderTracker) SetSelfAddress(address string) {
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+	lt.selfAddress = strings.ToLower(address)
+}
+
+// SelfAddress returns the configured local validator address
+func (lt *LeaderTracker) SelfAddress() string {
+	lt.mu.RLock()
+	defer lt.mu.RUnlock()
+	return lt.selfAddress
+}
+
+// OnBlock records the proposer of a new block
+func (lt *LeaderTracker) OnBlock(block *BlockHeader) {
+	drops, dropsKnown := currentDropTotal()
+
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+
+	lt.recordProposalLocked(block, time.Now(), drops, dropsKnown)
+	if block.Number > lt.latestBlock {
+		lt.latestBlock = block.Number
+		lt.latestProposer = block.Miner
+	}
+
+	// A scheduled self slot is skipped when someone else's block fills it
+	if leader, ok := lt.schedule[block.Number]; ok {
+		if lt.selfAddress != "" && leader == lt.selfAddress {
+			lt.countScheduledSlotLocked(block.Number, block.Miner == lt.selfAddress)
+		}
+		delete(lt.schedule, block.Number)
+	}
+
+	// Prune past schedule entries; self slots never observed were skipped
+	for slot, leader := range lt.schedule {
+		if slot < lt.latestBlock {
+			if lt.selfAddress != "" && leader == lt.selfAddress {
+				lt.countScheduledSlotLocked(slot, false)
+			}
+			delete(lt.schedule, slot)
+		}
+	}
+
+	if lt.selfAddress == "" || block.Miner != lt.selfAddress {
+		return
+	}
+
+	fullness := 0.0
+	if block.GasLimit > 0 {
+		fullness = float64(block.GasUsed) / float64(block.GasLimit)
+	}
+	lt.selfSlots = append(lt.selfSlots, LeaderSlotStats{
+		BlockNumber: block.Number,
+		TxCount:     block.Transactions,
+		GasUsed:     block.GasUsed,
+		Fullness:    fullness,
+		Timestamp:   block.Timestamp,
+	})
+	if len(lt.selfSlots) > lt.maxSelfSlots {
+		lt.selfSlots = lt.selfSlots[1:]
+	}
+	log.Printf("👑 Local validator proposed block %d (%d txs)", block.Number, block.Transactions)
+}
+
+// countScheduledSlotLocked records the outcome of a scheduled self slot
+func (lt *LeaderTracker) countScheduledSlotLocked(slot int64, produced bool) {
+	lt.scheduledSlots++
+	if produced {
+		return
+	}
+	lt.skippedSlots++
+	lt.recentSkipped = append(lt.recentSkipped, slot)
+	if len(lt.recentSkipped) > 20 {
+		lt.recentSkipped = lt.recentSkipped[1:]
+	}
+	log.Printf("Local validator skipped scheduled slot %d", slot)
+}
+
+// IsLeader reports whether the local node proposed the latest block
+func (lt *LeaderTracker) IsLeader() bool {
+	lt.mu.RLock()
+	defer lt.mu.RUnlock()
+	return lt.selfAddress != "" && lt.latestProposer == lt.selfAddress
+}
+
+// NextLeaderSlot returns the next block the local node is expected to
+// propose and the source of the prediction. ok is false when unknown.
+func (lt *LeaderTracker) NextLeaderSlot() (slot int64, source string, ok bool) {
+	lt.mu.RLock()
+	defer lt.mu.RUnlock()
+
+	if lt.selfAddress == "" {
+		return 0, "unavailable", false
+	}
+
+	// Schedule from the control panel
+	next := int64(-1)
+	for s, leader := range lt.schedule {
+		if leader == lt.selfAddress && s > lt.latestBlock && (next < 0 || s < next) {
+			next = s
+		}
+	}
+	if next >= 0 {
+		return next, "ipc", true
+	}
+
+	// Estimate from the average spacing of observed self slots
+	if len(lt.selfSlots) >= 2 {
+		first := lt.selfSlots[0].BlockNumber
+		last := lt.selfSlots[len(lt.selfSlots)-1].BlockNumber
+		gap := (last - first) / int64(len(lt.selfSlots)-1)
+		if gap > 0 {
+			estimate := last + gap
+			for estimate <= lt.latestBlock {
+				estimate += gap
+			}
+			return estimate, "estimated", true
+		}
+	}
+	return 0, "unavailable", false
+}
+
+// LeaderMetadata is the leader section of waterfall metadata and the body
+// of /api/v1/leader
+type LeaderMetadata struct {
+	SelfAddress       string            `json:"self_address"`
+	NextLeaderSlot    *int64            `json:"next_leader_slot"` // Null when unknown
+	NextSlotSource    string            `json:"next_slot_source"`
+	IsLeader          bool              `json:"is_leader"`
+	CurrentLeader     string            `json:"current_leader"`
+	LeaderSlotStats   LeaderSlotSummary `json:"leader_slot_stats"`
+	ScheduleSource    string            `json:"schedule_source"`
+	ScheduleRefreshed int64             `json:"schedule_refreshed"`
+}
+
+// LeaderSlotSummary aggregates the local validator's observed slots
+type LeaderSlotSummary struct {
+	SlotsObserved int `json:"slots_observed"`
+	*LeaderSlotAverages
+}
+
+// LeaderSlotAverages are reported once at least one slot was observed
+type LeaderSlotAverages struct {
+	AvgTxsPerSlot   float64           `json:"avg_txs_per_slot"`
+	AvgGasPerSlot   float64           `json:"avg_gas_per_slot"`
+	AvgFullness     float64           `json:"avg_fullness"`
+	LastLeaderSlot  int64             `json:"last_leader_slot"`
+	RecentSelfSlots []LeaderSlotStats `json:"recent_self_slots"`
+}
+
+// LeaderMetadata returns the leader section for waterfall metadata
+func (lt *LeaderTracker) LeaderMetadata() LeaderMetadata {
+	next, source, ok := lt.NextLeaderSlot()
+
+	lt.mu.RLock()
+	defer lt.mu.RUnlock()
+
+	var nextSlot *int64
+	if ok {
+		nextSlot = &next
+	}
+
+	return LeaderMetadata{
+		SelfAddress:       lt.selfAddress,
+		NextLeaderSlot:    nextSlot,
+		NextSlotSource:    source,
+		IsLeader:          lt.selfAddress != "" && lt.latestProposer == lt.selfAddress,
+		CurrentLeader:     lt.latestProposer,
+		LeaderSlotStats:   lt.slotStatsLocked(),
+		ScheduleSource:    lt.scheduleSource,
+		ScheduleRefreshed: lt.scheduleUpdated.Unix(),
+	}
+}
+
+// slotStatsLocked aggregates inclusion stats over recorded self slots
+func (lt *LeaderTracker) slotStatsLocked() LeaderSlotSummary {
+	count := len(lt.selfSlots)
+	if count == 0 {
+		return LeaderSlotSummary{}
+	}
+
+	totalTxs := 0
+	totalGas := int64(0)
+	totalFullness := 0.0
+	for _, slot := range lt.selfSlots {
+		totalTxs += slot.TxCount
+		totalGas += slot.GasUsed
+		totalFullness += slot.Fullness
+	}
+
+	recent := make([]LeaderSlotStats, count-max(0, count-10))
+	copy(recent, lt.selfSlots[count-len(recent):])
+
+	return LeaderSlotSummary{
+		SlotsObserved: count,
+		LeaderSlotAverages: &LeaderSlotAverages{
+			AvgTxsPerSlot:   float64(totalTxs) / float64(count),
+			AvgGasPerSlot:   float64(totalGas) / float64(count),
+			AvgFullness:     totalFullness / float64(count),
+			LastLeaderSlot:  lt.selfSlots[count-1].BlockNumber,
+			RecentSelfSlots: recent,
+		},
+	}
+}
+
+// refreshScheduleLoop periodically refreshes the leader schedule via IPC
+func (lt *LeaderTracker) refreshScheduleLoop(every time.Duration) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+
+	for {
+		if err := lt.refreshSchedule(); err != nil {
+			lt.mu.Lock()
+			if len(lt.selfSlots) >= 2 {
+				lt.scheduleSource = "estimated"
+			} else {
+				lt.scheduleSource = "unavailable"
+			}
+			lt.mu.Unlock()
+		}
+		select {
+		case <-ticker.C:
+		case <-shutdownContext().Done():
+			return
+		}
+	}
+}
+
+// refreshSchedule requests the upcoming leader schedule from the BFT control panel
+func (lt *LeaderTracker) refreshSchedule() error {
+	if lt.ipcPath == "" {
+		return fmt.Errorf("no control panel path configured")
+	}
+
+	ctx, cancel := callContext(ipcTimeout)
+	defer cancel()
+	conn, err := dialUnix(ctx, lt.ipcPath)
+	if err != nil {
+		return fmt.Errorf("failed to dial control panel: %w", err)
+	}
+	defer conn.Close()
+
+	request := map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "monad_getLeaderSchedule",
+		"params":  []interface{}{},
+	}
+	if err := json.NewEncoder(conn).Encode(request); err != nil {
+		return fmt.Errorf("failed to send schedule request: %w", err)
+	}
+
+	var response struct {
+		Result []struct {
+			Round  int64  `json:"round"`
+			Leader string `json:"leader"`
+		} `json:"result"`
+		Error *rpcError `json:"error"`
+	}
+	if err := json.NewDecoder(conn).Decode(&response); err != nil {
+		return fmt.Errorf("failed to decode schedule: %w", err)
+	}
+	if response.Error != nil {
+		return response.Error
+	}
+
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+	for _, entry := range response.Result {
+		lt.schedule[entry.Round] = strings.ToLower(entry.Leader)
+	}
+	lt.scheduleSource = "ipc"
+	lt.scheduleUpdated = time.Now()
+	return nil
+}
+
+// handleLeaderStatus returns leader-slot awareness data
+func handleLeaderStatus(c *gin.Context) {
+	lt := GetLeaderTracker()
+	if lt == nil {
+		c.JSON(http.StatusServiceUnavailable, APIError{Error: "Leader tracker not initialized"})
+		return
+	}
+	c.JSON(http.StatusOK, lt.LeaderMetadata())
+}
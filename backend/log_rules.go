@@ -0,0 +1,380 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pelletier/go-toml/v2"
+)
+
+// Log rule types
+const (
+	logRuleCounter = "counter" // Adds 1, or the captured value, per match
+	logRuleGauge   = "gauge"   // Set to the captured value
+	logRuleEvent   = "event"   // Recorded as a timeline event
+)
+
+// LogTailConfig is the LOG_TAIL_CONFIG file: log files and the rules
+// extracting metrics and events from their lines
+type LogTailConfig struct {
+	Sources []LogSourceConfig `toml:"source"`
+}
+
+// LogSourceConfig is one tailed file
+type LogSourceConfig struct {
+	Path  string          `toml:"path"`
+	Rules []LogRuleConfig `toml:"rule"`
+}
+
+// LogRuleConfig maps a regexp to a metric or event. A named group
+// (?P<value>...) supplies counter increments and gauge values.
+type LogRuleConfig struct {
+	Name    string `toml:"name"`
+	Pattern string `toml:"pattern"`
+	Type    string `toml:"type"`
+}
+
+// logRule is a compiled rule and its current state
+type logRule struct {
+	name      string
+	ruleType  string
+	pattern   *regexp.Regexp
+	valueIdx  int // Submatch index of the value group, -1 without one
+	value     float64
+	matches   int64
+	lastMatch time.Time
+	lastLine  string
+	annotated time.Time
+}
+
+// LogRuleEvent is a line matched by an event rule
+type LogRuleEvent struct {
+	Timestamp int64  `json:"timestamp"` // Unix milliseconds
+	Rule      string `json:"rule"`
+	Path      string `json:"path"`
+	Line      string `json:"line"`
+}
+
+// logSource is one tailed file's rules and counters
+type logSource struct {
+	path  string
+	rules []*logRule
+	lines int64
+}
+
+// LogRuleEngine applies operator-defined regexp rules to tailed log files
+type LogRuleEngine struct {
+	path string // LOG_TAIL_CONFIG
+
+	mu        sync.RWMutex
+	sources   []*logSource
+	events    []LogRuleEvent
+	maxEvents int
+	tailers   map[string]chan struct{} // Tailed path → stop channel
+}
+
+// Global log rule engine instance
+var (
+	logRuleEngine   *LogRuleEngine
+	logRuleEngineMu sync.RWMutex
+)
+
+// InitializeLogRules loads LOG_TAIL_CONFIG (TOML) and starts tailing every
+// configured file
+func InitializeLogRules() *LogRuleEngine {
+	path := os.Getenv("LOG_TAIL_CONFIG")
+	if path == "" {
+		return nil
+	}
+	engine, err := loadLogRuleEngine(path)
+	if err != nil {
+		log.Printf("Invalid log tail config %s: %v", path, err)
+		return nil
+	}
+	engine.path = path
+
+	logRuleEngineMu.Lock()
+	logRuleEngine = engine
+	logRuleEngineMu.Unlock()
+
+	engine.mu.Lock()
+	engine.startTailersLocked()
+	engine.mu.Unlock()
+	return engine
+}
+
+// loadLogRuleEngine reads and compiles a LOG_TAIL_CONFIG file
+func loadLogRuleEngine(path string) (*LogRuleEngine, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var config LogTailConfig
+	if err := toml.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+	return newLogRuleEngine(config)
+}
+
+// startTailersLocked starts a tailer for every source without one; caller
+// holds e.mu
+func (e *LogRuleEngine) startTailersLocked() {
+	for _, source := range e.sources {
+		if _, running := e.tailers[source.path]; running {
+			continue
+		}
+		stop := make(chan struct{})
+		e.tailers[source.path] = stop
+		path := source.path
+		go NewLogTailer(path, 500*time.Millisecond, func(line string) {
+			e.apply(path, line, time.Now())
+		}).Run(stop)
+		log.Printf("Log tailer: %s with %d rules", path, len(source.rules))
+	}
+}
+
+// Reload re-reads LOG_TAIL_CONFIG and swaps in its rules once they all
+// compile. Rules keeping their name, type and pattern keep their values;
+// tailers start for new files and stop for removed ones.
+func (e *LogRuleEngine) Reload() error {
+	next, err := loadLogRuleEngine(e.path)
+	if err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	previous := make(map[string]*logRule)
+	lines := make(map[string]int64)
+	for _, source := range e.sources {
+		lines[source.path] = source.lines
+		for _, rule := range source.rules {
+			previous[rule.name] = rule
+		}
+	}
+	paths := make(map[string]bool)
+	for _, source := range next.sources {
+		paths[source.path] = true
+		source.lines = lines[source.path]
+		for i, rule := range source.rules {
+			if old, ok := previous[rule.name]; ok && old.ruleType == rule.ruleType && old.pattern.String() == rule.pattern.String() {
+				source.rules[i] = old
+			}
+		}
+	}
+	e.sources = next.sources
+
+	for path, stop := range e.tailers {
+		if !paths[path] {
+			close(stop)
+			delete(e.tailers, path)
+			log.Printf("Log tailer: stopped %s", path)
+		}
+	}
+	e.startTailersLocked()
+	return nil
+}
+
+// GetLogRuleEngine returns the global engine, nil when LOG_TAIL_CONFIG is unset
+func GetLogRuleEngine() *LogRuleEngine {
+	logRuleEngineMu.RLock()
+	defer logRuleEngineMu.RUnlock()
+	return logRuleEngine
+}
+
+// newLogRuleEngine compiles a config; rule names must be unique since they
+// name history series
+func newLogRuleEngine(config LogTailConfig) (*LogRuleEngine, error) {
+	engine := &LogRuleEngine{maxEvents: 500, tailers: make(map[string]chan struct{})}
+	names := make(map[string]bool)
+	byPath := make(map[string]*logSource)
+	for _, sc := range config.Sources {
+		if sc.Path == "" {
+			return nil, fmt.Errorf("source without path")
+		}
+		// Sections repeating a path share one tailer
+		source, seen := byPath[sc.Path]
+		if !seen {
+			source = &logSource{path: sc.Path}
+			byPath[sc.Path] = source
+			engine.sources = append(engine.sources, source)
+		}
+		for _, rc := range sc.Rules {
+			if rc.Name == "" || names[rc.Name] {
+				return nil, fmt.Errorf("%s: rule name %q missing or duplicated", sc.Path, rc.Name)
+			}
+			names[rc.Name] = true
+
+			pattern, err := regexp.Compile(rc.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("rule %s: %v", rc.Name, err)
+			}
+			rule := &logRule{name: rc.Name, ruleType: rc.Type, pattern: pattern, valueIdx: pattern.SubexpIndex("value")}
+			switch rule.ruleType {
+			case "":
+				rule.ruleType = logRuleCounter
+			case logRuleCounter, logRuleEvent:
+			case logRuleGauge:
+				if rule.valueIdx < 0 {
+					return nil, fmt.Errorf("gauge rule %s needs a (?P<value>...) group", rc.Name)
+				}
+			default:
+				return nil, fmt.Errorf("rule %s: unknown type %q", rc.Name, rc.Type)
+			}
+			source.rules = append(source.rules, rule)
+		}
+	}
+	return engine, nil
+}
+
+// apply runs every current rule of a tailed file against one line
+func (e *LogRuleEngine) apply(path, line string, now time.Time) {
+	var events []*logRule
+	e.mu.Lock()
+	var source *logSource
+	for _, s := range e.sources {
+		if s.path == path {
+			source = s
+			break
+		}
+	}
+	if source == nil {
+		e.mu.Unlock()
+		return // Removed by a reload
+	}
+	source.lines++
+	for _, rule := range source.rules {
+		match := rule.pattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		value := 1.0
+		if rule.valueIdx >= 0 {
+			parsed, err := strconv.ParseFloat(match[rule.valueIdx], 64)
+			if err != nil {
+				continue // Not a number: the line does not count
+			}
+			value = parsed
+		}
+
+		rule.matches++
+		rule.lastMatch = now
+		rule.lastLine = line
+		switch rule.ruleType {
+		case logRuleCounter:
+			rule.value += value
+		case logRuleGauge:
+			rule.value = value
+		case logRuleEvent:
+			rule.value++
+			e.events = append(e.events, LogRuleEvent{Timestamp: now.UnixMilli(), Rule: rule.name, Path: source.path, Line: line})
+			if len(e.events) > e.maxEvents {
+				e.events = e.events[len(e.events)-e.maxEvents:]
+			}
+			// At most one timeline annotation per rule per minute
+			if now.Sub(rule.annotated) >= time.Minute {
+				rule.annotated = now
+				events = append(events, rule)
+			}
+		}
+	}
+	e.mu.Unlock()
+
+	if store := GetHistoryStore(); store != nil {
+		for _, rule := range events {
+			store.Annotate("log", rule.name, line, "log", rule.name)
+		}
+	}
+}
+
+// Values returns every counter and gauge keyed by rule name, for the metric history
+func (e *LogRuleEngine) Values() map[string]float64 {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	values := make(map[string]float64)
+	for _, source := range e.sources {
+		for _, rule := range source.rules {
+			if rule.ruleType != logRuleEvent && rule.matches > 0 {
+				values[rule.name] = rule.value
+			}
+		}
+	}
+	return values
+}
+
+// LogRulesResponse is the body of /api/v1/logtail
+type LogRulesResponse struct {
+	Sources []LogSourceStatus `json:"sources"`
+	Events  []LogRuleEvent    `json:"events"` // Newest first
+}
+
+// LogSourceStatus is one tailed file and its rules
+type LogSourceStatus struct {
+	Path      string          `json:"path"`
+	LinesRead int64           `json:"lines_read"`
+	Rules     []LogRuleStatus `json:"rules"`
+}
+
+// LogRuleStatus is a rule's current value and last match
+type LogRuleStatus struct {
+	Name      string  `json:"name"`
+	Type      string  `json:"type"`
+	Pattern   string  `json:"pattern"`
+	Value     float64 `json:"value"`
+	Matches   int64   `json:"matches"`
+	Series    string  `json:"series,omitempty"` // History series of gauge and counter rules
+	LastMatch int64   `json:"last_match,omitempty"`
+	LastLine  string  `json:"last_line,omitempty"`
+}
+
+// handleLogRules reports every tailed file, its rules' current values and recent events
+func handleLogRules(c *gin.Context) {
+	e := GetLogRuleEngine()
+	if e == nil {
+		c.JSON(http.StatusServiceUnavailable, APIError{Error: "Log tailing not configured (set LOG_TAIL_CONFIG)"})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	sources := make([]LogSourceStatus, 0, len(e.sources))
+	for _, source := range e.sources {
+		rules := make([]LogRuleStatus, 0, len(source.rules))
+		for _, rule := range source.rules {
+			entry := LogRuleStatus{
+				Name:    rule.name,
+				Type:    rule.ruleType,
+				Pattern: rule.pattern.String(),
+				Value:   rule.value,
+				Matches: rule.matches,
+			}
+			if rule.ruleType != logRuleEvent {
+				entry.Series = "log_" + rule.name
+			}
+			if !rule.lastMatch.IsZero() {
+				entry.LastMatch = rule.lastMatch.Unix()
+				entry.LastLine = rule.lastLine
+			}
+			rules = append(rules, entry)
+		}
+		sources = append(sources, LogSourceStatus{Path: source.path, LinesRead: source.lines, Rules: rules})
+	}
+
+	events := make([]LogRuleEvent, 0)
+	for i := len(e.events) - 1; i >= 0 && (limit <= 0 || len(events) < limit); i-- {
+		if rule := c.Query("rule"); rule == "" || e.events[i].Rule == rule {
+			events = append(events, e.events[i])
+		}
+	}
+
+	c.JSON(http.StatusOK, LogRulesResponse{Sources: sources, Events: events})
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+// maxLogLine caps one log line; longer lines are truncated
+const maxLogLine = 64 * 1024
+
+// LogTailer follows a log file like `tail -F`: it starts at the end, reads
+// lines as they are appended and reopens the file after rotation or
+// truncation. A missing file is waited for.
+type LogTailer struct {
+	path     string
+	poll     time.Duration
+	fromHead bool // Read existing content on first open (used after rotation)
+	onLine   func(line string)
+}
+
+// NewLogTailer creates a tailer calling onLine for every complete line
+func NewLogTailer(path string, poll time.Duration, onLine func(line string)) *LogTailer {
+	return &LogTailer{path: path, poll: poll, onLine: onLine}
+}
+
+// Run tails the file until stop is closed
+func (t *LogTailer) Run(stop <-chan struct{}) {
+	var (
+		file    *os.File
+		info    os.FileInfo
+		reader  *bufio.Reader
+		offset  int64
+		partial strings.Builder
+		warned  bool
+	)
+	closeFile := func() {
+		if file != nil {
+			file.Close()
+			file = nil
+		}
+	}
+	defer closeFile()
+
+	ticker := time.NewTicker(t.poll)
+	defer ticker.Stop()
+	for {
+		if file == nil {
+			f, err := os.Open(t.path)
+			if err != nil {
+				if !warned {
+					log.Printf("Log tailer: waiting for %s: %v", t.path, err)
+					warned = true
+				}
+			} else {
+				info, _ = f.Stat()
+				offset = 0
+				if !t.fromHead && info != nil {
+					offset, _ = f.Seek(0, io.SeekEnd)
+				}
+				file, reader, warned = f, bufio.NewReaderSize(f, 32*1024), false
+				partial.Reset()
+				t.fromHead = true // Files that appear or rotate later are read from the start
+			}
+		}
+
+		if file != nil {
+			for {
+				chunk, err := reader.ReadString('\n')
+				offset += int64(len(chunk))
+				if partial.Len() < maxLogLine {
+					partial.WriteString(chunk)
+				}
+				if err != nil {
+					break // Incomplete line stays buffered until the rest is written
+				}
+				line := strings.TrimRight(partial.String(), "\r\n")
+				partial.Reset()
+				if len(line) > maxLogLine {
+					line = line[:maxLogLine]
+				}
+				t.onLine(line)
+			}
+
+			// Rotated (path now names another file) or truncated: reopen
+			if current, err := os.Stat(t.path); err != nil || !os.SameFile(info, current) || current.Size() < offset {
+				closeFile()
+				continue
+			}
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// LogSamplingStats reports monadLogs sampling for /api/v1/subscriptions
+type LogSamplingStats struct {
+	Sampling      bool  `json:"sampling"`
+	SampleRate    int   `json:"sample_rate"` // 1-in-N logs kept
+	QueueLength   int   `json:"queue_length"`
+	QueueCapacity int   `json:"queue_capacity"`
+	HighWater     int   `json:"high_water"`
+	LowWater      int   `json:"low_water"`
+	MaxSampleRate int   `json:"max_sample_rate"`
+	Received      int64 `json:"received"`
+	Queued        int64 `json:"queued"`
+	Sampled       int64 `json:"sampled"`   // Queued while sampling, marked sampled: true
+	Discarded     int64 `json:"discarded"` // Skipped by sampling
+	Dropped       int64 `json:"dropped"`   // Queue full even after sampling
+	SamplingSince int64 `json:"sampling_since,omitempty"`
+}
+
+// logSamplingHold is how long the queue must stay drained before the sample
+// rate is halved, so per-block bursts do not toggle sampling every second
+const logSamplingHold = 10 * time.Second
+
+// LogSampler thins the monadLogs stream when its consumers fall behind.
+// Past the high-water mark only 1 in N logs is queued, N doubling each second
+// the queue stays above it; once the queue has stayed at the low-water mark
+// for logSamplingHold, N halves back toward 1.
+type LogSampler struct {
+	capacity  int
+	highWater int
+	lowWater  int
+	maxRate   int
+
+	mu            sync.Mutex
+	rate          int
+	seq           int64
+	adjustedAt    time.Time
+	drainedSince  time.Time // Queue at or below the low-water mark since
+	samplingSince time.Time
+	lastDropLog   time.Time
+	received      int64
+	queued        int64
+	sampled       int64
+	discarded     int64
+	dropped       int64
+	discardedRun  int64 // Discarded since sampling started
+}
+
+// NewLogSampler creates a sampler for a queue of the given capacity.
+// LOGS_HIGH_WATER and LOGS_LOW_WATER (default 80% and 25% of capacity) set
+// the thresholds, LOGS_MAX_SAMPLE_RATE (default 64) the sparsest sampling.
+func NewLogSampler(capacity int) *LogSampler {
+	ls := &LogSampler{
+		capacity:  capacity,
+		highWater: capacity * 80 / 100,
+		lowWater:  capacity * 25 / 100,
+		maxRate:   64,
+		rate:      1,
+	}
+	if n, ok := positiveIntEnv("LOGS_HIGH_WATER"); ok && n <= int64(capacity) {
+		ls.highWater = int(n)
+	}
+	if n, ok := positiveIntEnv("LOGS_LOW_WATER"); ok && n < int64(ls.highWater) {
+		ls.lowWater = int(n)
+	}
+	if n, ok := positiveIntEnv("LOGS_MAX_SAMPLE_RATE"); ok {
+		ls.maxRate = int(n)
+	}
+	return ls
+}
+
+// Admit decides whether an incoming log is queued, given the queue's current
+// length, and returns the sample rate in effect (1 when not sampling)
+func (ls *LogSampler) Admit(queueLen int, now time.Time) (bool, int) {
+	ls.mu.Lock()
+	ls.received++
+	started, stopped := ls.adjustLocked(queueLen, now)
+	rate := ls.rate
+	ls.seq++
+	keep := rate == 1 || ls.seq%int64(rate) == 0
+	if !keep {
+		ls.discarded++
+		ls.discardedRun++
+	}
+	discardedRun := ls.discardedRun
+	if stopped {
+		ls.discardedRun = 0
+	}
+	ls.mu.Unlock()
+
+	if started {
+		log.Printf("monadLogs queue at %d/%d, sampling 1 in %d logs", queueLen, ls.capacity, rate)
+		if store := GetHistoryStore(); store != nil {
+			store.Annotate("subscriber", "Log sampling started",
+				fmt.Sprintf("monadLogs queue at %d/%d; keeping 1 in %d logs", queueLen, ls.capacity, rate), "logs")
+		}
+	}
+	if stopped {
+		log.Printf("monadLogs queue drained, sampling stopped after discarding %d logs", discardedRun)
+		if store := GetHistoryStore(); store != nil {
+			store.Annotate("subscriber", "Log sampling stopped", fmt.Sprintf("%d logs discarded", discardedRun), "logs")
+		}
+	}
+	return keep, rate
+}
+
+// adjustLocked moves the sample rate at most once a second, except that
+// sampling starts as soon as the queue reaches the high-water mark
+func (ls *LogSampler) adjustLocked(queueLen int, now time.Time) (started, stopped bool) {
+	if queueLen > ls.lowWater {
+		ls.drainedSince = time.Time{}
+	} else if ls.drainedSince.IsZero() {
+		ls.drainedSince = now
+	}
+	if ls.rate > 1 && now.Sub(ls.adjustedAt) < time.Second {
+		return false, false
+	}
+	switch {
+	case queueLen >= ls.highWater && ls.rate < ls.maxRate:
+		started = ls.rate == 1
+		ls.rate *= 2
+		if ls.rate > ls.maxRate {
+			ls.rate = ls.maxRate
+		}
+		ls.adjustedAt = now
+		if started {
+			ls.samplingSince = now
+		}
+	case ls.rate > 1 && !ls.drainedSince.IsZero() && now.Sub(ls.drainedSince) >= logSamplingHold:
+		ls.rate /= 2
+		ls.adjustedAt = now
+		ls.drainedSince = now
+		if ls.rate == 1 {
+			stopped = true
+			ls.samplingSince = time.Time{}
+		}
+	}
+	return started, stopped
+}
+
+// Queued counts a log that made it into the queue
+func (ls *LogSampler) Queued(sampled bool) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	ls.queued++
+	if sampled {
+		ls.sampled++
+	}
+}
+
+// Dropped counts a log lost to a full queue, logging at most every 10s
+func (ls *LogSampler) Dropped(now time.Time) {
+	ls.mu.Lock()
+	ls.dropped++
+	dropped := ls.dropped
+	report := now.Sub(ls.lastDropLog) >= 10*time.Second
+	if report {
+		ls.lastDropLog = now
+	}
+	ls.mu.Unlock()
+
+	if report {
+		log.Printf("monadLogs queue full, %d logs dropped so far", dropped)
+	}
+}
+
+// Stats returns the sampler's counters
+func (ls *LogSampler) Stats(queueLen int) LogSamplingStats {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	stats := LogSamplingStats{
+		Sampling:      ls.rate > 1,
+		SampleRate:    ls.rate,
+		QueueLength:   queueLen,
+		QueueCapacity: ls.capacity,
+		HighWater:     ls.highWater,
+		LowWater:      ls.lowWater,
+		MaxSampleRate: ls.maxRate,
+		Received:      ls.received,
+		Queued:        ls.queued,
+		Sampled:       ls.sampled,
+		Discarded:     ls.discarded,
+		Dropped:       ls.dropped,
+	}
+	if !ls.samplingSince.IsZero() {
+		stats.SamplingSince = ls.samplingSince.Unix()
+	}
+	return stats
+}
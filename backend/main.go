@@ -2,13 +2,16 @@ package main
 
 import (
 	"embed"
+	"errors"
+	"flag"
 	"io/fs"
 	"log"
+	"net"
 	"net/http"
 	"os"
-	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -20,15 +23,70 @@ var static embed.FS
 
 var upgrader = websocket.Upgrader{
 	CheckOrigin: func(r *http.Request) bool {
-		return true // Allow connections from any origin
+		return GetWSUpgradeGuard().originAllowed(r) // WS_ALLOWED_ORIGINS, any origin when unset
 	},
 	Subprotocols: []string{"compress-zstd"},
 }
 
 // wsClient wraps a WebSocket connection with a mutex for safe concurrent writes
 type wsClient struct {
-	conn *websocket.Conn
-	mu   sync.Mutex
+	conn    *websocket.Conn
+	adapter ProtocolAdapter
+	mu      sync.Mutex
+	lastSeq atomic.Uint64 // Highest broadcast seq written, kept for resume
+	tenant  *Tenant       // Nil for the global stream
+}
+
+// noteWritten records the seq of a written message
+func (c *wsClient) noteWritten(msg interface{}) {
+	seq, _ := messageSequence(msg)
+	for {
+		current := c.lastSeq.Load()
+		if seq <= current || c.lastSeq.CompareAndSwap(current, seq) {
+			return
+		}
+	}
+}
+
+// write formats msg with the client's protocol adapter and writes it.
+// Shared messages reuse the frame already encoded for the adapter.
+func (c *wsClient) write(msg interface{}) error {
+	if shared, ok := msg.(*SharedMessage); ok {
+		frame, size, err := shared.frame(c.adapter)
+		if err != nil || frame == nil {
+			return err
+		}
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+		if err := c.conn.WritePreparedMessage(frame); err != nil {
+			return err
+		}
+		recordWSWrite(shared.Msg, size)
+		c.noteWritten(shared.Msg)
+		return nil
+	}
+
+	start := time.Now()
+	formatted, ok := c.adapter.Format(msg)
+	if !ok {
+		return nil
+	}
+	formatTime := time.Since(start)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+	size, encodeTime, err := writeJSONFrameMeasured(c.conn, formatted)
+	if size > 0 {
+		recordWSEncode(msg, formatTime+encodeTime)
+	}
+	if err != nil {
+		return err
+	}
+	recordWSWrite(msg, size)
+	c.noteWritten(msg)
+	return nil
 }
 
 // WebSocket client registry for broadcasting transaction logs
@@ -38,19 +96,24 @@ var (
 )
 
 // registerWSClient adds a WebSocket connection to the registry
-func registerWSClient(conn *websocket.Conn) {
+func registerWSClient(conn *websocket.Conn, adapter ProtocolAdapter, tenant *Tenant) {
 	wsClientsMu.Lock()
 	defer wsClientsMu.Unlock()
-	wsClients[conn] = &wsClient{conn: conn}
-	log.Printf("WebSocket client registered. Total clients: %d", len(wsClients))
+	wsClients[conn] = &wsClient{conn: conn, adapter: adapter, tenant: tenant}
+	log.Printf("WebSocket client registered (%s protocol). Total clients: %d", adapter.Name(), len(wsClients))
 }
 
-// unregisterWSClient removes a WebSocket connection from the registry
-func unregisterWSClient(conn *websocket.Conn) {
+// unregisterWSClient removes a WebSocket connection from the registry,
+// reporting whether it was registered
+func unregisterWSClient(conn *websocket.Conn) bool {
 	wsClientsMu.Lock()
 	defer wsClientsMu.Unlock()
+	if _, ok := wsClients[conn]; !ok {
+		return false
+	}
 	delete(wsClients, conn)
 	log.Printf("WebSocket client unregistered. Total clients: %d", len(wsClients))
+	return true
 }
 
 // getWSClient retrieves the wsClient for a connection
@@ -64,15 +127,21 @@ func getWSClient(conn *websocket.Conn) *wsClient {
 func safeWriteJSON(conn *websocket.Conn, v interface{}) error {
 	client := getWSClient(conn)
 	if client == nil {
-		return conn.WriteJSON(v) // Fallback if not registered yet
+		return conn.WriteJSON(unwrapMessage(v)) // Fallback if not registered yet
 	}
-	client.mu.Lock()
-	defer client.mu.Unlock()
-	return client.conn.WriteJSON(v)
+	return client.write(v)
 }
 
-// broadcastToAllClients sends a message to all connected WebSocket clients
+// broadcastToAllClients sends a message to all connected WebSocket clients,
+// and to viewer replicas when a broadcast bus is configured
 func broadcastToAllClients(msg interface{}) {
+	msg = stampMessage(msg)
+	fanOutToLocalClients(msg)
+	publishToBus(msg)
+}
+
+// fanOutToLocalClients sends a message to this replica's WebSocket and SSE clients
+func fanOutToLocalClients(msg interface{}) {
 	wsClientsMu.RLock()
 	clients := make([]*wsClient, 0, len(wsClients))
 	for _, client := range wsClients {
@@ -80,154 +149,472 @@ func broadcastToAllClients(msg interface{}) {
 	}
 	wsClientsMu.RUnlock()
 
-	// Write to each client with its own mutex to prevent concurrent writes
+	// Encode once per protocol, then write the same frame to each client
+	// with its own mutex to prevent concurrent writes; a failed write means
+	// the client is gone, so reap it
+	shared := newSharedMessage(msg)
+	txFlowSettings := hasTxFlowClients()
 	for _, client := range clients {
-		client.mu.Lock()
-		err := client.conn.WriteJSON(msg)
-		client.mu.Unlock()
+		if txFlowSettings && skipsPerTxFlow(client.conn, msg) {
+			continue
+		}
+		if client.tenant != nil && !client.tenant.sees(msg) {
+			continue
+		}
+		if err := client.write(shared); err != nil {
+			reapWSClient(client.conn, "write failed")
+		}
+	}
 
-		if err != nil {
-			log.Printf("Error broadcasting to client: %v", err)
+	// Fan out to SSE clients sharing the same broadcast stream
+	broadcastToSSEClients(msg)
+
+	// Broadcasts are part of a recorded session
+	recordBroadcast(msg)
+}
+
+// broadcastToClientsWhere sends a message to the clients accepted by the filter
+func broadcastToClientsWhere(msg interface{}, filter func(conn *websocket.Conn) bool) {
+	wsClientsMu.RLock()
+	clients := make([]*wsClient, 0, len(wsClients))
+	for conn, client := range wsClients {
+		if client.tenant != nil && !client.tenant.sees(msg) {
+			continue
+		}
+		if filter(conn) {
+			clients = append(clients, client)
+		}
+	}
+	wsClientsMu.RUnlock()
+
+	shared := newSharedMessage(msg)
+	for _, client := range clients {
+		if err := client.write(shared); err != nil {
+			reapWSClient(client.conn, "write failed")
 		}
 	}
 }
 
 func main() {
-	r := gin.Default()
+	flag.Parse()
+	ctx := initShutdown()
 
-	// Serve static files
-	staticFiles, err := fs.Sub(static, "frontend/dist")
-	if err != nil {
-		log.Fatal("Failed to get static files:", err)
+	// The simulator must be listening before any collector connects
+	if *demoFlag {
+		startDemoNode()
 	}
 
-	r.StaticFS("/assets", http.FS(staticFiles))
+	r := gin.Default()
+
+	// Serve static files (embedded, or from --frontend-dir)
+	staticFiles := frontendFS()
 
-	// Serve index.html for root and any non-API routes
+	// Serve index.html for root and any non-API routes; assets carry ETags,
+	// long-lived caching when hashed, and gzip/brotli encoding
 	r.NoRoute(func(c *gin.Context) {
-		if c.Request.URL.Path != "/" && !gin.IsDebugging() {
-			c.Header("Cache-Control", "no-cache")
-		}
-
 		// Try to serve static files first
 		if c.Request.URL.Path != "/" && c.Request.URL.Path != "/websocket" &&
 		   !strings.HasPrefix(c.Request.URL.Path, "/api") {
-			file, err := static.ReadFile("frontend/dist" + c.Request.URL.Path)
-			if err == nil {
-				// Determine content type
-				ext := filepath.Ext(c.Request.URL.Path)
-				var contentType string
-				switch ext {
-				case ".js":
-					contentType = "application/javascript"
-				case ".css":
-					contentType = "text/css"
-				case ".html":
-					contentType = "text/html; charset=utf-8"
-				case ".json":
-					contentType = "application/json"
-				case ".png":
-					contentType = "image/png"
-				case ".svg":
-					contentType = "image/svg+xml"
-				default:
-					contentType = "application/octet-stream"
-				}
-				c.Data(http.StatusOK, contentType, file)
+			if name, file, ok := readFrontendFile(staticFiles, c.Request.URL.Path); ok {
+				serveFrontendAsset(c, staticFiles, name, file)
 				return
 			}
 		}
 
 		// Fall back to index.html for SPA routing
-		indexHTML, err := static.ReadFile("frontend/dist/index.html")
+		indexHTML, err := fs.ReadFile(staticFiles, "index.html")
 		if err != nil {
 			c.String(http.StatusNotFound, "Frontend not built. Run 'make frontend' first.")
 			return
 		}
-		c.Data(http.StatusOK, "text/html; charset=utf-8", indexHTML)
+		serveFrontendAsset(c, staticFiles, "index.html", indexHTML)
 	})
 
-	// API Routes
-	api := r.Group("/api/v1")
+	// API Routes (frozen; routes with a v2 successor send Deprecation headers)
+	api := r.Group("/api/v1", deprecateV1)
 	{
 		api.GET("/health", handleHealth)
+		api.GET("/livez", handleLivez)   // Liveness: the process serves requests
+		api.GET("/readyz", handleReadyz) // Readiness: a healthy data source and recent block data
 		api.GET("/metrics", handleMetrics)
 		api.GET("/waterfall", handleWaterfall)  // Legacy waterfall
 		api.GET("/waterfall/v2", handleWaterfallV2)  // New Monad lifecycle waterfall
+		api.GET("/waterfall/interval", handleWaterfallInterval)
+		api.GET("/waterfall/cadence", handleWaterfallCadence) // Nominal and measured rate-to-count interval
+		api.GET("/waterfall/debug", handleWaterfallFlowDebug) // Raw vs. sanitized lifecycle waterfall flows
+		api.GET("/waterfall/block/:number", handleBlockWaterfall) // One block's transactions from submission to finality
+		api.GET("/waterfall/drops", handleWaterfallDrops) // Drop reasons over selectable windows
 		api.GET("/consensus", handleConsensusState)  // MonadBFT consensus state
+		api.GET("/consensus/events", handleConsensusEvents) // Timeouts, vote failures and proposal errors from the monad-bft log
+		api.GET("/consensus/supermajority", handleSupermajority) // Stake-weighted participation against the 2/3 quorum
+		api.GET("/consensus/history", handleConsensusHistory) // Persisted proposed/voted/finalized timeline by block range
 		api.GET("/event-rings", handleEventRingsStatus)
+		api.GET("/event-rings/gaps", handleRingGaps) // Sequence gaps and how each was recovered
+		api.GET("/event-rings/types", handleEventTypes) // Processed and skipped event types with their volume
+		api.GET("/sources", handleSources) // Metrics source health and provenance
+		api.GET("/prometheus", handlePrometheusSeries)
+		api.GET("/otlp", handleOTLPStatus)
+		api.GET("/export", handleAnalyticsExport) // ClickHouse/TimescaleDB export progress
+		api.GET("/publisher", handlePublisherStatus) // Kafka/NATS event publishing per category
+		api.GET("/publisher/schema.proto", handlePublisherSchema)
+		api.GET("/broadcast", handleBroadcastStatus) // Replica role and pub/sub stats
+		api.GET("/cache/blocks", handleBlockCacheStats)
+		api.GET("/sync", handleSyncStatus) // Node bootstrap (statesync/blocksync) progress
+		api.GET("/incidents", handleIncidents) // Source state timeline and uptime
+		api.GET("/cadence", handleCadence)     // Polling intervals
+		api.GET("/ws-stats", handleWSStats)    // Outbound WebSocket traffic and encode time per topic/key
+		api.GET("/storage", handleStorage)     // History retention tiers and disk usage
+		api.GET("/storage/node", handleNodeStorage) // Node data directory sizes and disk-full projection
+		api.GET("/system", handleSystemStats)  // Host and node process resources
+		api.GET("/logtail", handleLogRules)     // Metrics and events extracted from tailed logs (LOG_TAIL_CONFIG)
+		api.GET("/mempool/pending", handlePendingTxs) // Ingress measured from newPendingTransactions
+		api.GET("/mempool/nonce-gaps", handleNonceGaps) // Senders with pending transactions stuck behind a missing nonce
+		api.GET("/subscriptions", handleSubscriptions) // Node WebSocket subscriptions, each re-established independently
+		api.GET("/rpc/endpoints", handleRPCEndpoints)  // Execution RPC endpoints, breakers and latency
+		api.GET("/rpc-usage", handleRPCUsage)          // The dashboard's own RPC calls per method
+		api.GET("/propagation", handlePropagation)     // Block timestamp to newHeads receipt delay
+
+		// Multi-chain: /chains/:chain/... serves the primary chain's API or a secondary chain's head state
+		api.GET("/chains", handleChains)
+		api.GET("/chain/params", handleChainParams) // Epoch length, block time and finality depth in use
+		api.GET("/chains/:chain/*path", handleChainRoute)
+
+		// Server-Sent Events fallback for deployments where WebSockets are blocked
+		api.GET("/stream", handleSSEStream)
+
+		// GraphQL query endpoint (blocks, metrics history, validators, waterfall)
+		api.GET("/graphql", handleGraphQL)
+		api.POST("/graphql", handleGraphQL)
+
+		// Metric history and Grafana SimpleJSON datasource
+		api.GET("/history", handleHistory)
+		api.GET("/grafana", handleGrafanaTest)
+		api.POST("/grafana/search", handleGrafanaSearch)
+		api.POST("/grafana/query", handleGrafanaQuery)
+		api.POST("/grafana/annotations", handleGrafanaAnnotations)
+
+		// Address watchlist
+		api.GET("/watchlist", handleWatchlistList)
+		api.POST("/watchlist", requireWatchlistWriter, handleWatchlistAdd)
+		api.GET("/watchlist/:address", handleWatchlistGet)
+		api.DELETE("/watchlist/:address", requireWatchlistWriter, handleWatchlistRemove)
+
+		// Token activity
+		api.GET("/tokens/top", handleTopTokens)
+
+		// Fee burn / priority fee tracking
+		api.GET("/fees", handleFees)
+		api.GET("/leader", handleLeaderStatus)
+		api.GET("/validators/self", handleSelfValidator) // Local validator identity (node.toml), stake, next slot and skip rate
+		api.GET("/validators/self/performance", handleValidatorPerformance) // Local validator's blocks vs the network average
+		api.GET("/validators/self/vote", handleVoteStatus) // Local validator's vote state and distance
+		api.GET("/epochs/:n/validator-changes", handleEpochValidatorChanges) // Validator set diff against the previous epoch
+
+		// Transaction ordering vs priority fee within a block
+		api.GET("/blocks/:number/ordering", handleBlockOrdering)
+
+		// Per-transaction lifecycle (mempool → inclusion → execution → receipt)
+		api.GET("/tx/:hash/lifecycle", handleTxLifecycle)
+		api.GET("/probe/inclusion", handleInclusionProbe) // Synthetic transaction latency and SLO attainment
+		api.GET("/execution/blocks", handleExecutionBlocks)
+		api.GET("/execution/slowest", handleSlowestTransactions)
+		api.GET("/execution/conflicts", handleExecutionConflicts)
+		api.GET("/execution/reverts", handleExecutionReverts)
+		api.GET("/execution/opcodes", handleExecutionOpcodes)
+
+		// Address inspector (balance, nonce, code and indexed activity)
+		api.GET("/address/:addr", handleAddress)
+		api.GET("/contracts/:addr/verification", handleContractVerification)
+		api.GET("/gas/by-contract", handleGasByContract)
+		api.GET("/gas/estimate", handleGasEstimate) // Suggested slow/standard/fast priority fees
+
+		// Signed event webhooks (finalized blocks, epochs, alerts); requires ADMIN_KEY
+		webhooks := api.Group("/webhooks", requireAdminKey)
+		webhooks.GET("", handleWebhooksList)
+		webhooks.POST("", handleWebhookCreate)
+		webhooks.GET("/:id", handleWebhookGet)
+		webhooks.DELETE("/:id", handleWebhookRemove)
+		webhooks.POST("/:id/test", handleWebhookTest)
+
+		// Telegram/Discord alert notifiers
+		api.GET("/notifiers", handleNotifiers)
+		api.POST("/notifiers/test", requireAdminKey, handleNotifiersTest)
+
+		// Stream session recordings; starting, stopping and deleting requires ADMIN_KEY
+		api.GET("/recordings", handleRecordingsList)
+		api.POST("/recordings", requireAdminKey, handleRecordingStart)
+		api.POST("/recordings/stop", requireAdminKey, handleRecordingStop)
+		api.GET("/recordings/:id", handleRecordingGet)
+		api.DELETE("/recordings/:id", requireAdminKey, handleRecordingRemove)
+
+		// Per-user frontend preferences (session token subject or X-Client-Token)
+		api.GET("/preferences", handlePreferences)
+		api.GET("/preferences/:key", handlePreferenceGet)
+		api.PUT("/preferences/:key", handlePreferenceSet)
+		api.DELETE("/preferences/:key", handlePreferenceDelete)
+
+		// Realtime stream session tokens
+		api.POST("/ws/token", handleIssueToken)
+		api.POST("/ws/token/renew", handleRenewToken)
+		api.POST("/ws/upgrade-token", handleMintUpgradeToken) // One-time token for the next upgrade (WS_UPGRADE_TOKENS)
+		api.GET("/embed", handleEmbedStatus)                   // Embeddable topics and current embed viewers
+
+		// OpenAPI 3 spec of these routes and Swagger UI
+		api.GET("/openapi.json", handleOpenAPI)
+		api.GET("/docs", handleSwaggerUI)
 	}
 
-	// WebSocket endpoint (Firedancer uses /websocket)
-	r.GET("/websocket", handleWebSocket)
+	// Restructured typed responses
+	v2 := r.Group("/api/v2")
+	{
+		v2.GET("/metrics", handleV2Metrics)
+		v2.GET("/waterfall", handleV2Waterfall)
+		v2.GET("/consensus", handleV2Consensus)
+	}
 
-	// Initialize Consensus Tracker for MonadBFT phase tracking
-	InitializeConsensusTracker()
-	log.Printf("✅ MonadBFT Consensus Tracker initialized")
+	// OTLP/HTTP metrics receiver (standard path, so exporters can point at the dashboard root)
+	r.POST("/v1/metrics", requireOTLPToken, handleOTLPMetrics)
 
-	// Initialize event rings connection
-	if err := InitializeEventRings(); err != nil {
-		log.Printf("Event rings not available: %v", err)
-		log.Printf("Dashboard will use RPC-only mode")
-	} else {
-		// Start event processing if event rings are available
-		go StartEventProcessing()
-	}
+	// Admin endpoints
+	admin := r.Group("/api/v1/admin")
+	{
+		admin.POST("/waterfall/snapshot-reset", requireAdminKey, handleWaterfallSnapshotReset)
+
+		// Nominal waterfall interval, changed at runtime; requires ADMIN_KEY
+		admin.PUT("/waterfall/cadence", requireAdminKey, handleSetWaterfallCadence)
+
+		// Event types skipped in the ring read loop, changed at runtime; requires ADMIN_KEY
+		admin.PUT("/event-rings/types", requireAdminKey, handleSetEventTypes)
+
+		// Dashboard state (history, consensus timeline, incident log) as a tarball; requires ADMIN_KEY
+		admin.GET("/state/export", requireAdminKey, handleStateExport)
+		admin.POST("/state/import", requireAdminKey, handleStateImport)
+
+		// Rule-like config files, validated and swapped at runtime; requires ADMIN_KEY
+		admin.GET("/reload", requireAdminKey, handleConfigReloadStatus)
+		admin.POST("/reload", requireAdminKey, handleConfigReload)
 
-	// Initialize Prometheus metrics collector for accurate TPS
-	promEndpoint := os.Getenv("PROMETHEUS_ENDPOINT")
-	if promEndpoint == "" {
-		promEndpoint = "http://127.0.0.1:8889/metrics" // Default OTEL endpoint
+		// Configured tenants with their chains and clients; requires ADMIN_KEY
+		admin.GET("/tenants", requireAdminKey, handleTenants)
+
+		// Signed, expiring tokens for the read-only embed widget; requires ADMIN_KEY
+		admin.POST("/embed/tokens", requireAdminKey, handleIssueEmbedToken)
 	}
-	log.Printf("Attempting to connect to Prometheus endpoint at %s...", promEndpoint)
-	if err := InitializePrometheusCollector(promEndpoint); err != nil {
-		log.Printf("Prometheus collector not available: %v", err)
-		log.Printf("Will calculate TPS from block data")
-	} else {
-		log.Printf("✅ Prometheus collector initialized - using accurate TPS from monad_execution_ledger_num_tx_commits")
+
+	// Per-operator dashboards (TENANTS_CONFIG): the API and stream of one
+	// tenant's chain, with its own keys, watchlist and preferences
+	tenantRoutes := r.Group("/t/:tenant", requireTenant)
+	{
+		tenantRoutes.Any("/api/v1/*path", handleTenantAPI)
+		tenantRoutes.GET("/websocket", handleWebSocket)
+		tenantRoutes.GET("/ws/native", handleNativeWebSocket)
 	}
 
-	// Initialize IPC metrics collector for real metrics
-	ipcPath := os.Getenv("MONAD_IPC_PATH")
-	if ipcPath == "" {
-		ipcPath = "/home/monad/monad-bft/mempool.sock" // Default path
+	// Kubernetes probes at the conventional paths
+	r.GET("/livez", handleLivez)
+	r.GET("/readyz", handleReadyz)
+
+	// WebSocket endpoint (Firedancer uses /websocket)
+	r.GET("/websocket", handleWebSocket)
+
+	// Native Monad JSON protocol ({"type", "data", "ts"} envelopes)
+	r.GET("/ws/native", handleNativeWebSocket)
+
+	// Replay of a recorded session (?speed=1|2, ?protocol=native)
+	r.GET("/ws/playback/:id", handlePlaybackWebSocket)
+
+	// Read-only status widget and its stream, authorized by an embed token
+	r.GET("/embed", handleEmbedWidget)
+	r.GET("/embed/stream", handleEmbedStream)
+
+	// Optional session tokens for the realtime stream
+	InitializeWSAuth()
+
+	// Read-only embed widgets for public sites (EMBED_SECRET)
+	InitializeEmbedAuth()
+
+	// Polling cadences (CADENCE_*), adaptive mode and stream deduplication
+	InitializeCadence()
+	InitializeStreamDedup()
+
+	// Epoch length, block time and finality depth (CHAIN_* or measured from the node)
+	InitializeChainParams()
+
+	// Block timestamp vs local clock skew (optionally checked against NTP_SERVER)
+	InitializeClockSkew()
+
+	// Block timestamp to newHeads receipt delay per block
+	InitializePropagationTracker()
+
+	// Consensus events from the monad-bft log (MONAD_BFT_LOG)
+	InitializeConsensusLogCollector()
+
+	// Custom counters, gauges and events from any log file (LOG_TAIL_CONFIG)
+	InitializeLogRules()
+
+	// Initialize metric history (1s samples; raw, 1m and 1h tiers per HISTORY_*)
+	retentionPolicy := loadRetentionPolicy()
+	InitializeHistoryStore(retentionPolicy, time.Second)
+
+	// Archive consensus timelines evicted from the tracker next to the history
+	InitializeConsensusHistory(retentionPolicy.Dir)
+
+	// Host, dashboard and node process resources from /proc (system_stats topic)
+	InitializeSystemCollector(5 * time.Second)
+
+	// triedb/ledger/wal sizes and disk-full projection (NODE_DISK_PATHS)
+	InitializeNodeDiskMonitor()
+	if isDemoMode() {
+		seedDemoHistory()
 	}
-	log.Printf("Attempting to connect to Monad IPC at %s...", ipcPath)
-	if err := InitializeIPCCollector(ipcPath); err != nil {
-		log.Printf("IPC metrics collector not available: %v", err)
-		log.Printf("Will use estimation-based metrics")
-	} else {
-		log.Printf("✅ IPC metrics collector initialized - using real Monad metrics")
+
+	// Close waterfall counter intervals at the nominal waterfall interval
+	// (WATERFALL_INTERVAL, default the Prometheus scrape cadence)
+	InitializeWaterfallInterval()
+	StartWaterfallSampling()
+
+	// Initialize address watchlist (matched against monadLogs)
+	InitializeWatchlist()
+
+	// Initialize token transfer indexer (ERC-20/721 Transfer events from monadLogs)
+	InitializeTokenIndexer()
+
+	// Per-address activity from receipts and monadLogs for /api/v1/address/:addr
+	InitializeAddressIndex()
+
+	// Verified contract names and ABIs (CONTRACT_VERIFICATION_URL)
+	InitializeContractVerifier()
+
+	// Aggregated 100ms tx_flow buckets for clients in aggregate mode
+	InitializeTxFlowAggregator()
+
+	// Initialize fee tracker (receipts-based burned/priority fee split)
+	InitializeFeeTracker()
+
+	// Stream blocks, transactions and waterfall intervals to ClickHouse/TimescaleDB (EXPORT_SINK)
+	InitializeAnalyticsExporter()
+
+	// Mirror blocks, tx logs, consensus phases and alerts onto Kafka/NATS (PUBLISH_BROKER)
+	InitializeEventPublisher()
+
+	// Webhook registrations (WEBHOOKS_PATH) and delivery workers
+	InitializeWebhooks()
+
+	// Frontend preferences per user (PREFERENCES_PATH)
+	InitializePreferenceStore()
+
+	// Telegram/Discord incident messages (NOTIFIER_CONFIG)
+	InitializeNotifiers()
+
+	// Reload rule files (log rules, notifiers, watchlist, metric mappings) on change
+	InitializeConfigReloader()
+
+	// Connect the pub/sub layer shared by dashboard replicas
+	if err := InitializeBroadcastBus(); err != nil {
+		log.Fatalf("Broadcast bus: %v", err)
 	}
 
-	// Try to initialize real-time WebSocket subscription
-	wsURL := "ws://127.0.0.1:8081"
-	log.Printf("Attempting to connect to Monad WebSocket at %s...", wsURL)
-	if err := InitializeSubscriber(wsURL); err != nil {
-		log.Printf("Failed to initialize WebSocket subscriber: %v", err)
-		log.Printf("Falling back to polling mode")
-		// Start metrics collection via polling as fallback
-		go startMetricsCollection()
+	if isViewerReplica() {
+		log.Printf("Viewer replica: node collection disabled, streaming from the broadcast bus")
 	} else {
-		log.Printf("Successfully initialized real-time WebSocket subscription")
+		startNodeCollection()
 	}
 
+	// Stream recordings (RECORDINGS_DIR); --record starts one now
+	InitializeSessionRecordings()
+
+	// Name the primary chain and start secondary chain monitors
+	apiRouter = r
+	InitializeChains()
+
+	// Operator tenants (TENANTS_CONFIG), sharing chain monitors where their nodes overlap
+	InitializeTenants()
+
+	// Routes added without an apiOperations entry are missing from the spec
+	checkAPIDocs(r.Routes())
+
 	port := ":4000" // Changed from 3000 to 4000
 	log.Printf("Monad Dashboard starting on %s", port)
-	log.Fatal(r.Run(port))
+	if err := serveHTTP(ctx, port, r); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// startNodeCollection connects every collector to the local Monad node
+func startNodeCollection() {
+	// Report node bootstrap progress (summary.startup_progress)
+	InitializeSyncTracker(2 * time.Second)
+
+	// Initialize speculative/finalized head tracking
+	InitializeHeadTracker(time.Second)
+
+	// Initialize leader tracker (schedule from the BFT control panel when available)
+	controlPanelPath := os.Getenv("MONAD_CONTROL_PANEL_PATH")
+	if controlPanelPath == "" {
+		controlPanelPath = "/home/monad/monad-bft/controlpanel.sock"
+	}
+	InitializeLeaderTracker(controlPanelPath)
+
+	// Derive the local validator's vote state (summary.vote_state)
+	InitializeVoteTracker(controlPanelPath)
+
+	// Snapshot the validator set from the staking precompile at each epoch
+	InitializeValidatorSetTracker()
+
+	// Alert when the stake observed participating nears the 2/3 supermajority
+	InitializeSupermajorityMonitor(30 * time.Second)
+
+	// Backfill blocks the subscription misses (reconnects, restarts) over RPC
+	InitializeGapRepairer()
+
+	// Replay or reconcile events missed in event ring sequence gaps
+	InitializeRingResync()
+
+	// Event types left unprocessed (EVENT_TYPES, EVENT_TYPES_SKIP)
+	InitializeEventTypeFilter()
+
+	// Event rings, Prometheus, IPC and the WebSocket subscription
+	GetDashboard().Start()
+
+	// Accept OTLP/HTTP metric pushes, merged with scraped Prometheus metrics
+	InitializeOTLPReceiver()
+
+	// Record node/collector state transitions for the incident timeline
+	InitializeIncidentTracker(5 * time.Second)
+
+	// Sample txpool drop counters for /api/v1/waterfall/drops
+	InitializeDropTracker(5 * time.Second)
+
+	// Time synthetic transactions end to end when TX_PROBE_KEY is set
+	InitializeInclusionProbe()
+}
+
+// HealthResponse is the body of /api/v1/health
+type HealthResponse struct {
+	Status    string      `json:"status"`
+	Timestamp int64       `json:"timestamp"`
+	Version   string      `json:"version"`
+	Demo      bool        `json:"demo"`
+	Clock     ClockStatus `json:"clock"`
+	RPC       RPCHealth   `json:"rpc"`   // Execution RPC circuit breakers
+	Ready     bool        `json:"ready"` // Same verdict as /readyz
 }
 
 func handleHealth(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
-		"status":    "ok",
-		"timestamp": time.Now().Unix(),
-		"version":   "0.1.0",
+	c.JSON(http.StatusOK, HealthResponse{
+		Status:    "ok",
+		Timestamp: time.Now().Unix(),
+		Version:   "0.1.0",
+		Demo:      isDemoMode(),
+		Clock:     GetClockSkew().Status(),
+		RPC:       GetMonadClient().rpc.Health(),
+		Ready:     readiness(time.Now()).Ready,
 	})
 }
 
 func handleEventRingsStatus(c *gin.Context) {
-	reader := GetExecutionEventReader()
-	if reader == nil {
+	rings := GetEventRings()
+	if len(rings) == 0 {
 		c.JSON(http.StatusOK, gin.H{
 			"connected": false,
 			"message":   "Event rings not initialized",
@@ -235,11 +622,29 @@ func handleEventRingsStatus(c *gin.Context) {
 		return
 	}
 
-	stats := reader.GetStats()
+	// Execution ring stats stay at the top level for existing clients
+	stats := map[string]interface{}{"connected": false}
+	if reader := GetExecutionEventReader(); reader != nil {
+		stats = reader.GetStats()
+	}
+
+	ringStats := make(map[string]interface{}, len(rings))
+	for name, reader := range rings {
+		ringStats[name] = reader.GetStats()
+		if reader.IsConnected() {
+			stats["connected"] = true
+		}
+	}
+	stats["rings"] = ringStats
+
 	c.JSON(http.StatusOK, stats)
 }
 
-func handleWebSocket(c *gin.Context) {
+// serveWebSocket runs one stream connection, formatting outbound messages with adapter
+func serveWebSocket(c *gin.Context, adapter ProtocolAdapter) {
+	if !authorizeWSUpgrade(c) {
+		return
+	}
 	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
 		log.Printf("WebSocket upgrade error: %v", err)
@@ -249,36 +654,107 @@ func handleWebSocket(c *gin.Context) {
 
 	log.Printf("WebSocket client connected from %s", c.Request.RemoteAddr)
 
+	// Require a session token when auth is enabled
+	auth := GetWSAuth()
+	var subject string
+	if auth != nil {
+		claims, err := auth.Authenticate(c, conn)
+		if err != nil {
+			log.Printf("WebSocket auth failed from %s: %v", c.Request.RemoteAddr, err)
+			conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.ClosePolicyViolation, err.Error()),
+				time.Now().Add(time.Second))
+			return
+		}
+		defer auth.Release(conn)
+		subject = claims.Subject
+		log.Printf("WebSocket client authenticated as %q (expires %s)", claims.Subject, claims.Expiry().Format(time.RFC3339))
+	}
+
+	// Streams under /t/:tenant only carry the tenant's chain, and resume
+	// tokens are only honoured on the tenant they were issued by
+	tenant := tenantOf(c)
+	if tenant != nil {
+		subject = "tenant:" + tenant.ID + ":" + subject
+	}
+
 	// Register this client for broadcasts
-	registerWSClient(conn)
+	registerWSClient(conn, adapter, tenant)
 	defer unregisterWSClient(conn)
+	defer func() {
+		if w := watchlistFor(tenant); w != nil {
+			w.Unsubscribe(conn)
+		}
+		forgetTxFlowClient(conn)
+	}()
 
-	// Send initial Firedancer protocol messages
-	if err := sendInitialSummaryMessages(conn); err != nil {
-		log.Printf("Error sending initial messages: %v", err)
-		return
-	}
+	// Keep this connection's position for a client that reconnects with its
+	// resume token (runs before the cleanup above)
+	resumes := GetResumeStore()
+	token := newResumeToken()
+	client := getWSClient(conn)
+	defer func() {
+		resumes.Save(token, resumeSession{
+			subject:        subject,
+			tenant:         tenant,
+			lastSeq:        client.lastSeq.Load(),
+			txFlow:         txFlowClientFor(conn),
+			disconnectedAt: time.Now(),
+		})
+	}()
 
-	// Send peers message to remove startup screen
-	if err := sendPeersMessage(conn); err != nil {
-		log.Printf("Error sending peers message: %v", err)
-		return
+	send := wsSender(conn)
+
+	// A client resuming a recent session gets the broadcasts it missed
+	// instead of a snapshot
+	resumed := false
+	if resume := c.Query("resume"); resume != "" {
+		if session, ok := resumes.Take(resume, subject, time.Now()); ok {
+			if session.txFlow.aggregate {
+				if a := GetTxFlowAggregator(); a != nil {
+					a.SetAggregate(conn, true)
+				}
+			}
+			if session.txFlow.filter != nil {
+				setTxFlowFilter(conn, session.txFlow.filter)
+			}
+			resumed = resumeStream(send, session, c.Query("last_seq"))
+		}
+		if !resumed {
+			send(FiredancerMessage{Topic: "stream", Key: "resume", Value: map[string]interface{}{
+				"mode":        "snapshot",
+				"current_seq": streamSeq.Load(),
+			}})
+		}
 	}
 
-	// Send epoch information
-	if err := sendEpochMessage(conn); err != nil {
-		log.Printf("Error sending epoch message: %v", err)
-		return
+	// Otherwise start from a snapshot; viewer replicas replay the
+	// collector's latest messages instead. Tenants of a secondary chain get
+	// its blocks as they arrive.
+	if !resumed && (tenant == nil || tenant.primary()) {
+		if err := sendStreamSnapshot(send); err != nil {
+			log.Printf("Error sending initial messages: %v", err)
+			return
+		}
 	}
 
+	send(FiredancerMessage{Topic: "stream", Key: "resume_token", Value: map[string]interface{}{
+		"token":       token,
+		"ttl_seconds": int64(resumes.ttl.Seconds()),
+	}})
+
 	// Start goroutine to handle incoming client messages
 	done := make(chan struct{})
+	startWSKeepalive(conn, done)
 	go func() {
 		defer close(done)
 		for {
 			_, message, err := conn.ReadMessage()
 			if err != nil {
-				if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
+				var netErr net.Error
+				if errors.As(err, &netErr) && netErr.Timeout() {
+					reapWSClient(conn, "pong timeout")
+				} else if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
 					log.Printf("WebSocket read error: %v", err)
 				}
 				return
@@ -289,8 +765,15 @@ func handleWebSocket(c *gin.Context) {
 		}
 	}()
 
-	// Send periodic updates using Firedancer protocol
-	go sendFiredancerUpdates(conn)
+	// Send periodic updates using Firedancer protocol (viewers get them from the bus)
+	if !isViewerReplica() && (tenant == nil || tenant.primary()) {
+		go sendFiredancerUpdates(send)
+	}
+
+	// Close the connection when its session expires without renewal
+	if auth != nil {
+		go auth.EnforceExpiry(conn, done)
+	}
 
 	// Wait for connection to close
 	<-done
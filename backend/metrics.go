@@ -5,8 +5,6 @@ import (
 	"log"
 	"math/rand"
 	"net/http"
-	"sync"
-	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -18,6 +16,8 @@ type MonadMetrics struct {
 	Consensus ConsensusMetrics `json:"consensus"`
 	Execution ExecutionMetrics `json:"execution"`
 	Network   NetworkMetrics   `json:"network"`
+	// Source selected for each resolved metric (see metrics_source.go)
+	Provenance map[string]Provenance `json:"provenance,omitempty"`
 }
 
 type NodeInfo struct {
@@ -84,63 +84,49 @@ type NetworkMetrics struct {
 	NetworkLatency   float64 `json:"network_latency"`
 }
 
-var (
-	currentMetrics MonadMetrics
-	metricsMutex   sync.RWMutex
-	startTime      = time.Now()
-)
-
-var monadClient *MonadClient
-
-func init() {
-	// Initialize Monad client with actual socket paths
-	monadClient = NewMonadClient(
-		"http://127.0.0.1:8080",                           // Monad RPC Server
-		"/home/monad/monad-bft/controlpanel.sock",        // BFT Control Panel IPC
-		"/home/monad/monad-bft/mempool.sock",             // Mempool IPC
-	)
-}
-
-func startMetricsCollection() {
-	ticker := time.NewTicker(1 * time.Second)
+// startMetricsCollection polls the node for metrics until shutdown
+func (d *Dashboard) startMetricsCollection() {
+	ticker := newCadenceTicker(metricsCadence)
 	defer ticker.Stop()
 
-	log.Printf("Starting metrics collection from Monad RPC at %s...", monadClient.ExecutionRPCUrl)
+	log.Printf("Starting metrics collection from Monad RPC at %s...", d.client.ExecutionRPCUrl)
 
 	for {
 		select {
 		case <-ticker.C:
 			// Try to get real metrics from Monad, fall back to mock on failure
 			log.Printf("Collecting metrics from Monad...")
-			updateMetricsFromMonad() // Use real Monad data
+			d.updateMetricsFromMonad() // Use real Monad data
+		case <-shutdownContext().Done():
+			return
 		}
 	}
 }
 
-func updateMetricsFromMonad() {
-	metricsMutex.Lock()
-	defer metricsMutex.Unlock()
+func (d *Dashboard) updateMetricsFromMonad() {
+	d.metricsMu.Lock()
+	defer d.metricsMu.Unlock()
 
-	now := time.Now()
+	now := d.clock.Now()
 
 	// Try to get real metrics from Monad nodes
-	consensus, err := monadClient.GetConsensusMetrics()
+	consensus, err := d.client.GetConsensusMetrics()
 	if err != nil {
 		log.Printf("Failed to get consensus metrics: %v, using mock data", err)
 		// Fall back to mock data
-		updateMetrics()
+		d.updateMockMetricsLocked()
 		return
 	}
 
-	execution, err := monadClient.GetExecutionMetrics()
+	execution, err := d.client.GetExecutionMetrics()
 	if err != nil {
 		log.Printf("Failed to get execution metrics: %v, using mock data", err)
 		// Fall back to mock data
-		updateMetrics()
+		d.updateMockMetricsLocked()
 		return
 	}
 
-	network, err := monadClient.GetNetworkMetrics()
+	network, err := d.client.GetNetworkMetrics()
 	if err != nil {
 		log.Printf("Failed to get network metrics: %v, using defaults", err)
 		// Use defaults
@@ -157,14 +143,14 @@ func updateMetricsFromMonad() {
 	log.Printf("Successfully collected metrics from Monad nodes")
 
 	// Update current metrics with real data
-	currentMetrics = MonadMetrics{
+	d.metrics = MonadMetrics{
 		Timestamp: now.Unix(),
 		NodeInfo: NodeInfo{
 			Version:  "0.1.0",
 			ChainID:  20143,
 			NodeName: "monad-validator-ubuntu",
 			Status:   "running",
-			Uptime:   int64(now.Sub(startTime).Seconds()),
+			Uptime:   int64(now.Sub(d.startTime).Seconds()),
 		},
 		Waterfall: generateWaterfallFromExecution(execution),
 		Consensus: *consensus,
@@ -199,45 +185,45 @@ func generateWaterfallFromExecution(exec *ExecutionMetrics) WaterfallMetrics {
 	}
 }
 
-func updateMetrics() {
-	metricsMutex.Lock()
-	defer metricsMutex.Unlock()
-
-	now := time.Now()
+// updateMockMetricsLocked simulates metrics when the node cannot be read;
+// caller holds d.metricsMu
+func (d *Dashboard) updateMockMetricsLocked() {
+	now := d.clock.Now()
+	current := d.metrics
 
 	// Simulate realistic metrics with some randomness
-	currentMetrics = MonadMetrics{
+	d.metrics = MonadMetrics{
 		Timestamp: now.Unix(),
 		NodeInfo: NodeInfo{
 			Version:  "0.1.0",
 			ChainID:  20143,
 			NodeName: "monad-validator-01",
 			Status:   "running",
-			Uptime:   int64(now.Sub(startTime).Seconds()),
+			Uptime:   int64(now.Sub(d.startTime).Seconds()),
 		},
 		Waterfall: WaterfallMetrics{
-			RPCReceived:           randomWalk(currentMetrics.Waterfall.RPCReceived, 100, 2000),
-			GossipReceived:        randomWalk(currentMetrics.Waterfall.GossipReceived, 50, 500),
-			MempoolSize:          randomWalk(currentMetrics.Waterfall.MempoolSize, 1000, 5000),
-			SignatureFailed:       randomWalk(currentMetrics.Waterfall.SignatureFailed, 0, 50),
-			NonceDuplicate:        randomWalk(currentMetrics.Waterfall.NonceDuplicate, 0, 20),
-			GasInvalid:           randomWalk(currentMetrics.Waterfall.GasInvalid, 0, 30),
-			BalanceInsufficient:  randomWalk(currentMetrics.Waterfall.BalanceInsufficient, 0, 40),
-			EVMParallelExecuted:  randomWalk(currentMetrics.Waterfall.EVMParallelExecuted, 800, 1800),
-			EVMSequentialFallback: randomWalk(currentMetrics.Waterfall.EVMSequentialFallback, 50, 200),
-			GasUsedTotal:         randomWalk(currentMetrics.Waterfall.GasUsedTotal, 50000000, 200000000),
-			StateConflicts:       randomWalk(currentMetrics.Waterfall.StateConflicts, 10, 100),
-			BFTProposed:          randomWalk(currentMetrics.Waterfall.BFTProposed, 1, 10),
-			BFTVoted:             randomWalk(currentMetrics.Waterfall.BFTVoted, 1, 10),
-			BFTCommitted:         randomWalk(currentMetrics.Waterfall.BFTCommitted, 1, 10),
-			StateUpdated:         randomWalk(currentMetrics.Waterfall.StateUpdated, 1, 10),
-			TrieDBWritten:        randomWalk(currentMetrics.Waterfall.TrieDBWritten, 1, 10),
-			BlocksBroadcast:      randomWalk(currentMetrics.Waterfall.BlocksBroadcast, 1, 10),
+			RPCReceived:           randomWalk(current.Waterfall.RPCReceived, 100, 2000),
+			GossipReceived:        randomWalk(current.Waterfall.GossipReceived, 50, 500),
+			MempoolSize:          randomWalk(current.Waterfall.MempoolSize, 1000, 5000),
+			SignatureFailed:       randomWalk(current.Waterfall.SignatureFailed, 0, 50),
+			NonceDuplicate:        randomWalk(current.Waterfall.NonceDuplicate, 0, 20),
+			GasInvalid:           randomWalk(current.Waterfall.GasInvalid, 0, 30),
+			BalanceInsufficient:  randomWalk(current.Waterfall.BalanceInsufficient, 0, 40),
+			EVMParallelExecuted:  randomWalk(current.Waterfall.EVMParallelExecuted, 800, 1800),
+			EVMSequentialFallback: randomWalk(current.Waterfall.EVMSequentialFallback, 50, 200),
+			GasUsedTotal:         randomWalk(current.Waterfall.GasUsedTotal, 50000000, 200000000),
+			StateConflicts:       randomWalk(current.Waterfall.StateConflicts, 10, 100),
+			BFTProposed:          randomWalk(current.Waterfall.BFTProposed, 1, 10),
+			BFTVoted:             randomWalk(current.Waterfall.BFTVoted, 1, 10),
+			BFTCommitted:         randomWalk(current.Waterfall.BFTCommitted, 1, 10),
+			StateUpdated:         randomWalk(current.Waterfall.StateUpdated, 1, 10),
+			TrieDBWritten:        randomWalk(current.Waterfall.TrieDBWritten, 1, 10),
+			BlocksBroadcast:      randomWalk(current.Waterfall.BlocksBroadcast, 1, 10),
 		},
 		Consensus: ConsensusMetrics{
-			CurrentHeight:     randomWalk(currentMetrics.Consensus.CurrentHeight, 1000000, 1100000),
+			CurrentHeight:     randomWalk(current.Consensus.CurrentHeight, 1000000, 1100000),
 			LastBlockTime:     now.Unix() - int64(rand.Intn(5)),
-			BlockTime:        0.4,  // Monad block time
+			BlockTime:        GetChainParams().BlockTime,
 			ValidatorCount:   100 + rand.Intn(20),
 			VotingPower:      1000000 + int64(rand.Intn(100000)),
 			ParticipationRate: 0.85 + rand.Float64()*0.1,
@@ -278,14 +264,14 @@ func randomWalk(current, min, max int64) int64 {
 	return result
 }
 
+// getCurrentMetrics returns the served dashboard's metrics snapshot
 func getCurrentMetrics() MonadMetrics {
-	metricsMutex.RLock()
-	defer metricsMutex.RUnlock()
-	return currentMetrics
+	return GetDashboard().Metrics()
 }
 
 func handleMetrics(c *gin.Context) {
 	metrics := getCurrentMetrics()
+	metrics.Provenance = GetSourceResolver().LastProvenance()
 	c.JSON(http.StatusOK, metrics)
 }
 
@@ -380,7 +366,7 @@ func connectToMonadExecution() error {
 
 // handleWaterfallV2 returns new Monad lifecycle-aligned waterfall data
 func handleWaterfallV2(c *gin.Context) {
-	waterfallData := GenerateMonadWaterfall()
+	waterfallData := GenerateMonadWaterfallFor(waterfallVersionV1)
 	c.JSON(http.StatusOK, waterfallData)
 }
 
@@ -388,8 +374,8 @@ func handleWaterfallV2(c *gin.Context) {
 func handleConsensusState(c *gin.Context) {
 	consensusTracker := GetConsensusTracker()
 	if consensusTracker == nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{
-			"error": "Consensus tracker not initialized",
+		c.JSON(http.StatusServiceUnavailable, APIError{
+			Error: "Consensus tracker not initialized",
 		})
 		return
 	}
@@ -0,0 +1,312 @@
+package main
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics resolved through the source resolver
+const (
+	MetricWaterfall  = "waterfall"
+	MetricTPS        = "tps"
+	MetricTPSAverage = "tps_average" // Smoothed TPS for the node metrics snapshot
+)
+
+// SourceStatus describes the current state of a metrics source
+type SourceStatus struct {
+	Name        string    `json:"name"`
+	Healthy     bool      `json:"healthy"`
+	LastUpdated time.Time `json:"last_updated"`
+	Freshness   float64   `json:"freshness_seconds"` // Seconds since the last update
+}
+
+// MetricsSource is one origin of dashboard metrics (Prometheus, IPC,
+// block subscription, RPC polling, mock data)
+type MetricsSource interface {
+	Name() string
+	Status() SourceStatus
+	// Provides reports whether the source currently has usable data for metric
+	Provides(metric string) bool
+	// Confidence rates the source's data for metric from 0 (synthetic) to 1 (measured)
+	Confidence(metric string) float64
+}
+
+// Provenance records which source produced a metric and why
+type Provenance struct {
+	Metric     string   `json:"metric"`
+	Source     string   `json:"source"`
+	Healthy    bool     `json:"healthy"`
+	Freshness  float64  `json:"freshness_seconds"`
+	Confidence float64  `json:"confidence"`
+	Skipped    []string `json:"skipped,omitempty"` // Higher-priority sources passed over
+}
+
+// SourceResolver selects the best available source per metric
+type SourceResolver struct {
+	mu         sync.RWMutex
+	sources    map[string]MetricsSource
+	priorities map[string][]string // metric -> source names, best first
+	last       map[string]Provenance
+}
+
+// Default per-metric source priorities. Block data gives exact per-block TPS,
+// so it outranks Prometheus' 60s average there; the waterfall needs txpool
+// counters, which only Prometheus and IPC expose.
+var defaultSourcePriorities = map[string][]string{
+	MetricWaterfall:  {"prometheus_metrics", "real_ipc_metrics", "block_estimation", "mock_data"},
+	MetricTPS:        {"block_estimation", "prometheus_metrics", "node_metrics"},
+	MetricTPSAverage: {"prometheus_metrics", "block_estimation"},
+}
+
+// Global source resolver instance
+var (
+	sourceResolver     *SourceResolver
+	sourceResolverOnce sync.Once
+)
+
+// NewSourceResolver creates a resolver with the given per-metric priorities
+func NewSourceResolver(priorities map[string][]string) *SourceResolver {
+	return &SourceResolver{
+		sources:    make(map[string]MetricsSource),
+		priorities: priorities,
+		last:       make(map[string]Provenance),
+	}
+}
+
+// GetSourceResolver returns the global resolver with the built-in sources registered
+func GetSourceResolver() *SourceResolver {
+	sourceResolverOnce.Do(func() {
+		sourceResolver = NewSourceResolver(defaultSourcePriorities)
+		sourceResolver.Register(prometheusSource{})
+		sourceResolver.Register(ipcSource{})
+		sourceResolver.Register(blockSource{})
+		sourceResolver.Register(nodeMetricsSource{})
+		sourceResolver.Register(mockSource{})
+	})
+	return sourceResolver
+}
+
+// Register adds or replaces a source
+func (r *SourceResolver) Register(source MetricsSource) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sources[source.Name()] = source
+}
+
+// Resolve returns the highest-priority source that can currently provide
+// metric. The result is nil when no source qualifies.
+func (r *SourceResolver) Resolve(metric string) (MetricsSource, Provenance) {
+	r.mu.RLock()
+	order := r.priorities[metric]
+	candidates := make([]MetricsSource, 0, len(order))
+	for _, name := range order {
+		if source, ok := r.sources[name]; ok {
+			candidates = append(candidates, source)
+		}
+	}
+	r.mu.RUnlock()
+
+	provenance := Provenance{Metric: metric, Source: "none"}
+	var selected MetricsSource
+	for _, source := range candidates {
+		if source.Provides(metric) {
+			selected = source
+			break
+		}
+		provenance.Skipped = append(provenance.Skipped, source.Name())
+	}
+
+	if selected != nil {
+		status := selected.Status()
+		provenance.Source = selected.Name()
+		provenance.Healthy = status.Healthy
+		provenance.Freshness = status.Freshness
+		provenance.Confidence = selected.Confidence(metric)
+	}
+
+	r.mu.Lock()
+	r.last[metric] = provenance
+	r.mu.Unlock()
+
+	return selected, provenance
+}
+
+// LastProvenance returns the most recent resolution for every metric
+func (r *SourceResolver) LastProvenance() map[string]Provenance {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make(map[string]Provenance, len(r.last))
+	for metric, provenance := range r.last {
+		result[metric] = provenance
+	}
+	return result
+}
+
+// Statuses returns the status of every registered source
+func (r *SourceResolver) Statuses() []SourceStatus {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	statuses := make([]SourceStatus, 0, len(r.sources))
+	for _, name := range []string{"prometheus_metrics", "real_ipc_metrics", "block_estimation", "node_metrics", "mock_data"} {
+		if source, ok := r.sources[name]; ok {
+			statuses = append(statuses, source.Status())
+		}
+	}
+	return statuses
+}
+
+// sourceStatus builds a status from a last-updated time
+func sourceStatus(name string, healthy bool, lastUpdated time.Time) SourceStatus {
+	freshness := 0.0
+	if !lastUpdated.IsZero() {
+		freshness = time.Since(lastUpdated).Seconds()
+	}
+	return SourceStatus{
+		Name:        name,
+		Healthy:     healthy,
+		LastUpdated: lastUpdated,
+		Freshness:   freshness,
+	}
+}
+
+// prometheusSource reads the Prometheus collector
+type prometheusSource struct{}
+
+func (prometheusSource) Name() string { return "prometheus_metrics" }
+
+func (s prometheusSource) Status() SourceStatus {
+	collector := GetPrometheusCollector()
+	if collector == nil {
+		return sourceStatus(s.Name(), false, time.Time{})
+	}
+	return sourceStatus(s.Name(), collector.IsHealthy(), collector.GetMetrics().LastUpdated)
+}
+
+func (prometheusSource) Provides(metric string) bool {
+	collector := GetPrometheusCollector()
+	if collector == nil || !collector.IsHealthy() {
+		return false
+	}
+	metrics := collector.GetMetrics()
+	switch metric {
+	case MetricWaterfall:
+		// Only use Prometheus when txpool counters are actually moving
+		return metrics.TPS60s > 0 && (metrics.InsertOwnedTxsRate > 0 || metrics.InsertForwardedTxsRate > 0)
+	case MetricTPS, MetricTPSAverage:
+		return metrics.TPS60s > 0
+	}
+	return false
+}
+
+func (prometheusSource) Confidence(metric string) float64 {
+	if metric == MetricWaterfall {
+		return 0.9 // Ingress and drops are measured, execution split is estimated
+	}
+	return 0.9 // 60s average lags per-block changes
+}
+
+// ipcSource reads the mempool IPC collector
+type ipcSource struct{}
+
+func (ipcSource) Name() string { return "real_ipc_metrics" }
+
+func (s ipcSource) Status() SourceStatus {
+	collector := GetIPCCollector()
+	if collector == nil {
+		return sourceStatus(s.Name(), false, time.Time{})
+	}
+	return sourceStatus(s.Name(), collector.IsHealthy(), collector.GetMetrics().LastUpdated)
+}
+
+func (ipcSource) Provides(metric string) bool {
+	collector := GetIPCCollector()
+	return metric == MetricWaterfall && collector != nil && collector.IsHealthy()
+}
+
+func (ipcSource) Confidence(metric string) float64 { return 1.0 }
+
+// blockSource derives metrics from the newHeads subscription
+type blockSource struct{}
+
+func (blockSource) Name() string { return "block_estimation" }
+
+func (s blockSource) Status() SourceStatus {
+	subscriber := GetSubscriber()
+	if subscriber == nil {
+		return sourceStatus(s.Name(), false, time.Time{})
+	}
+	var lastUpdated time.Time
+	if block := subscriber.GetLatestBlock(); block != nil {
+		lastUpdated = GetClockSkew().BlockTime(block.Timestamp)
+	}
+	return sourceStatus(s.Name(), subscriber.IsConnected(), lastUpdated)
+}
+
+func (blockSource) Provides(metric string) bool {
+	subscriber := GetSubscriber()
+	if subscriber == nil || !subscriber.IsConnected() {
+		return false
+	}
+	switch metric {
+	case MetricWaterfall, MetricTPS, MetricTPSAverage:
+		return subscriber.GetLatestBlock() != nil
+	}
+	return false
+}
+
+func (blockSource) Confidence(metric string) float64 {
+	if metric == MetricTPS || metric == MetricTPSAverage {
+		return 1.0 // Counted from committed blocks
+	}
+	return 0.4 // Stage split is a fixed-ratio estimate
+}
+
+// nodeMetricsSource reads the RPC-polled metrics snapshot
+type nodeMetricsSource struct{}
+
+func (nodeMetricsSource) Name() string { return "node_metrics" }
+
+func (s nodeMetricsSource) Status() SourceStatus {
+	metrics := getCurrentMetrics()
+	lastUpdated := time.Unix(metrics.Timestamp, 0)
+	return sourceStatus(s.Name(), time.Since(lastUpdated) < 5*time.Second, lastUpdated)
+}
+
+func (s nodeMetricsSource) Provides(metric string) bool {
+	return metric == MetricTPS
+}
+
+func (nodeMetricsSource) Confidence(metric string) float64 { return 0.3 }
+
+// mockSource is the last-resort synthetic source
+type mockSource struct{}
+
+func (mockSource) Name() string { return "mock_data" }
+
+func (s mockSource) Status() SourceStatus {
+	return sourceStatus(s.Name(), true, time.Now())
+}
+
+func (mockSource) Provides(metric string) bool { return true }
+
+func (mockSource) Confidence(metric string) float64 { return 0 }
+
+// SourcesResponse is the body of /api/v1/sources
+type SourcesResponse struct {
+	Sources    []SourceStatus        `json:"sources"`
+	Provenance map[string]Provenance `json:"provenance"` // Metric → source it was last read from
+}
+
+// handleSources returns source health and the latest per-metric provenance
+func handleSources(c *gin.Context) {
+	resolver := GetSourceResolver()
+	c.JSON(http.StatusOK, SourcesResponse{
+		Sources:    resolver.Statuses(),
+		Provenance: resolver.LastProvenance(),
+	})
+}
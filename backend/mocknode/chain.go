@@ -0,0 +1,373 @@
+package mocknode
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Well-known addresses used by the generated chain
+const (
+	// TokenAddress emits an ERC-20 Transfer log for every third transaction
+	TokenAddress = "0x00000000000000000000000000000000000000aa"
+	// Validator is the first member of the validator set
+	Validator = "0x1111111111111111111111111111111111111111"
+	// StuckSender announces a pending transaction every 10 blocks that is
+	// never included: its nonces start at 1, so nonce 0 is missing
+	StuckSender = "0x2222222222222222222222222222222222222222"
+
+	transferTopic = "0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"
+)
+
+// Log is an event emitted by a transaction
+type Log struct {
+	Address string
+	Topics  []string
+	Data    string
+}
+
+// Tx is a generated transaction with its execution outcome
+type Tx struct {
+	Hash        string
+	From        string
+	To          string
+	Nonce       uint64
+	Reverted    bool
+	GasUsed     uint64
+	PriorityFee uint64 // Wei per gas above the base fee
+	Logs        []Log
+}
+
+// Block is a generated block
+type Block struct {
+	Number     int64
+	Hash       string
+	ParentHash string
+	Timestamp  int64
+	Miner      string
+	GasLimit   uint64
+	BaseFee    uint64 // wei
+	Txs        []Tx
+}
+
+// GasUsed sums the gas of all transactions
+func (b *Block) GasUsed() uint64 {
+	var total uint64
+	for _, tx := range b.Txs {
+		total += tx.GasUsed
+	}
+	return total
+}
+
+// Reverted counts reverted transactions
+func (b *Block) Reverted() int {
+	count := 0
+	for _, tx := range b.Txs {
+		if tx.Reverted {
+			count++
+		}
+	}
+	return count
+}
+
+// Chain deterministically generates blocks from a seed
+type Chain struct {
+	mu         sync.RWMutex
+	rng        *rand.Rand
+	maxTxs     int
+	validators []string
+	blocks     []*Block
+	byHash     map[string]*Block
+	txBlocks   map[string]*Block
+	totalTxs   uint64
+	nonces     map[string]uint64 // Sender -> next nonce
+	pool       []Tx              // Submitted with eth_sendRawTransaction, included by the next block
+
+	// ClockOffset skews block timestamps from the local clock
+	ClockOffset time.Duration
+}
+
+// NewChain creates a chain with a genesis block. Blocks are proposed
+// round-robin by numValidators validators, the first being Validator.
+func NewChain(seed int64, maxTxsPerBlock, numValidators int) *Chain {
+	if numValidators < 1 {
+		numValidators = 1
+	}
+	validators := []string{Validator}
+	for i := 1; i < numValidators; i++ {
+		validators = append(validators, address20("validator", i))
+	}
+	c := &Chain{
+		rng:        rand.New(rand.NewSource(seed)),
+		maxTxs:     maxTxsPerBlock,
+		validators: validators,
+		byHash:     make(map[string]*Block),
+		txBlocks:   make(map[string]*Block),
+		nonces:     make(map[string]uint64),
+	}
+	genesis := &Block{
+		Number:     0,
+		Hash:       hash32("block", 0),
+		ParentHash: "0x" + strings.Repeat("0", 64),
+		Timestamp:  time.Now().Unix(),
+		Miner:      Validator,
+		GasLimit:   150_000_000,
+		BaseFee:    50_000_000_000,
+	}
+	c.appendLocked(genesis)
+	return c
+}
+
+// hash32 derives a 32-byte hex hash from its inputs
+func hash32(parts ...interface{}) string {
+	sum := sha256.Sum256([]byte(fmt.Sprint(parts...)))
+	return "0x" + hex.EncodeToString(sum[:])
+}
+
+// address20 derives a 20-byte hex address
+func address20(parts ...interface{}) string {
+	return hash32(parts...)[:42]
+}
+
+func (c *Chain) appendLocked(b *Block) {
+	c.blocks = append(c.blocks, b)
+	c.byHash[b.Hash] = b
+	for _, tx := range b.Txs {
+		c.txBlocks[tx.Hash] = b
+	}
+	c.totalTxs += uint64(len(b.Txs))
+}
+
+// Validators returns the validator set in proposal order
+func (c *Chain) Validators() []string {
+	return append([]string(nil), c.validators...)
+}
+
+// Next generates and appends the next block
+func (c *Chain) Next() *Block {
+	return c.nextAt(time.Now().Add(c.ClockOffset))
+}
+
+// Backfill generates count blocks spaced blockTime apart ending now, so the
+// chain starts with history. The genesis timestamp moves back to match.
+func (c *Chain) Backfill(count int, blockTime time.Duration) {
+	start := time.Now().Add(c.ClockOffset - time.Duration(count)*blockTime)
+	c.mu.Lock()
+	c.blocks[0].Timestamp = start.Unix()
+	c.mu.Unlock()
+	for i := 1; i <= count; i++ {
+		c.nextAt(start.Add(time.Duration(i) * blockTime))
+	}
+}
+
+func (c *Chain) nextAt(at time.Time) *Block {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	parent := c.blocks[len(c.blocks)-1]
+	number := parent.Number + 1
+
+	b := &Block{
+		Number:     number,
+		Hash:       hash32("block", number),
+		ParentHash: parent.Hash,
+		Timestamp:  at.Unix(),
+		Miner:      c.validators[number%int64(len(c.validators))],
+		GasLimit:   parent.GasLimit,
+		BaseFee:    parent.BaseFee,
+	}
+
+	// Submitted transactions lead the block
+	for _, tx := range c.pool {
+		c.nonces[tx.From]++
+		b.Txs = append(b.Txs, tx)
+	}
+	c.pool = nil
+
+	txCount := 0
+	if c.maxTxs > 0 {
+		txCount = c.rng.Intn(c.maxTxs + 1)
+	}
+	for i := 0; i < txCount; i++ {
+		from := address20("sender", c.rng.Intn(50))
+		tx := Tx{
+			Hash:     hash32("tx", number, i),
+			From:     from,
+			To:       address20("contract", c.rng.Intn(10)),
+			Reverted: c.rng.Intn(10) == 0,
+			GasUsed:  21_000 + uint64(c.rng.Intn(200_000)),
+			Nonce:    c.nonces[from],
+		}
+		c.nonces[from]++
+		// Mostly ordered by descending tip, with every 7th transaction
+		// outbidding the ones ahead of it
+		tx.PriorityFee = 1_000_000_000 + uint64(txCount-i)*100_000_000
+		if (number+int64(i))%7 == 0 {
+			tx.PriorityFee += 2_000_000_000
+		}
+		if i%3 == 0 && !tx.Reverted {
+			tx.To = TokenAddress
+			tx.Logs = []Log{{
+				Address: TokenAddress,
+				Topics: []string{
+					transferTopic,
+					"0x000000000000000000000000" + from[2:],
+					"0x000000000000000000000000" + address20("recipient", c.rng.Intn(50))[2:],
+				},
+				Data: fmt.Sprintf("0x%064x", 1+c.rng.Intn(1_000_000)),
+			}}
+		}
+		b.Txs = append(b.Txs, tx)
+	}
+
+	c.appendLocked(b)
+	return b
+}
+
+// Nonce returns the next nonce of a sender, as eth_getTransactionCount
+func (c *Chain) Nonce(address string) uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.nonces[strings.ToLower(address)]
+}
+
+// PendingNonce returns the next nonce of a sender counting submitted
+// transactions, as eth_getTransactionCount at "pending"
+func (c *Chain) PendingNonce(address string) uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	address = strings.ToLower(address)
+	nonce := c.nonces[address]
+	for _, tx := range c.pool {
+		if tx.From == address {
+			nonce++
+		}
+	}
+	return nonce
+}
+
+// Submit queues a signed transfer for the next block. The nonce must be the
+// sender's next pending nonce.
+func (c *Chain) Submit(tx Tx) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.txBlocks[tx.Hash]; ok {
+		return fmt.Errorf("already known")
+	}
+	next := c.nonces[tx.From]
+	for _, pending := range c.pool {
+		if pending.Hash == tx.Hash {
+			return fmt.Errorf("already known")
+		}
+		if pending.From == tx.From {
+			next++
+		}
+	}
+	if tx.Nonce < next {
+		return fmt.Errorf("nonce too low: next nonce %d, tx nonce %d", next, tx.Nonce)
+	}
+	if tx.Nonce > next {
+		return fmt.Errorf("nonce too high: next nonce %d, tx nonce %d", next, tx.Nonce)
+	}
+	c.pool = append(c.pool, tx)
+	return nil
+}
+
+// PendingTx returns a submitted transaction not yet included
+func (c *Chain) PendingTx(hash string) (Tx, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	hash = strings.ToLower(hash)
+	for _, tx := range c.pool {
+		if tx.Hash == hash {
+			return tx, true
+		}
+	}
+	return Tx{}, false
+}
+
+// StuckTx returns the transaction StuckSender announces with block b, if any
+func StuckTx(b *Block) (Tx, bool) {
+	if b.Number%10 != 0 {
+		return Tx{}, false
+	}
+	return Tx{
+		Hash:        hash32("stuck", b.Number),
+		From:        StuckSender,
+		To:          TokenAddress,
+		Nonce:       uint64(b.Number / 10),
+		GasUsed:     21_000,
+		PriorityFee: 1_000_000_000,
+	}, true
+}
+
+// Head returns the latest block
+func (c *Chain) Head() *Block {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.blocks[len(c.blocks)-1]
+}
+
+// Finalized returns the latest finalized block (MonadBFT finalizes two blocks behind)
+func (c *Chain) Finalized() *Block {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	index := len(c.blocks) - 3
+	if index < 0 {
+		index = 0
+	}
+	return c.blocks[index]
+}
+
+// Blocks returns the blocks in [from, to]
+func (c *Chain) Blocks(from, to int64) []*Block {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if from < 0 {
+		from = 0
+	}
+	if last := int64(len(c.blocks)) - 1; to > last {
+		to = last
+	}
+	if from > to {
+		return nil
+	}
+	return append([]*Block(nil), c.blocks[from:to+1]...)
+}
+
+// Block returns a block by number
+func (c *Chain) Block(number int64) (*Block, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if number < 0 || number >= int64(len(c.blocks)) {
+		return nil, false
+	}
+	return c.blocks[number], true
+}
+
+// BlockByHash returns a block by hash
+func (c *Chain) BlockByHash(hash string) (*Block, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	b, ok := c.byHash[strings.ToLower(hash)]
+	return b, ok
+}
+
+// TxBlock returns the block that included a transaction
+func (c *Chain) TxBlock(hash string) (*Block, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	b, ok := c.txBlocks[strings.ToLower(hash)]
+	return b, ok
+}
+
+// TotalTxs returns the number of transactions committed so far
+func (c *Chain) TotalTxs() uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.totalTxs
+}
@@ -0,0 +1,436 @@
+// Package mocknode implements a fake Monad node for local development and
+// integration testing: a JSON-RPC endpoint, a WebSocket endpoint serving
+// newHeads/monadNewHeads/monadLogs/newPendingTransactions subscriptions, and
+// a Prometheus endpoint
+// exposing the execution and txpool counters the dashboard scrapes.
+package mocknode
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Config controls the mock node's listeners and block production
+type Config struct {
+	RPCAddr        string        // JSON-RPC over HTTP
+	WSAddr         string        // JSON-RPC + subscriptions over WebSocket
+	PrometheusAddr string        // Prometheus text exposition at /metrics
+	BlockTime      time.Duration // interval between generated blocks
+	MaxTxsPerBlock int
+	Validators     int // size of the round-robin proposer set
+	HistoryBlocks  int // blocks generated before Start, backdated by BlockTime
+	Seed           int64
+	ChainID        uint64
+	ClockOffset    time.Duration // added to block timestamps, simulating a skewed node clock
+	DropKind       string        // subscription kind silently dropped every DropEvery
+	DropEvery      time.Duration
+}
+
+// DefaultConfig matches the endpoints the dashboard connects to by default
+func DefaultConfig() Config {
+	return Config{
+		RPCAddr:        "127.0.0.1:8080",
+		WSAddr:         "127.0.0.1:8081",
+		PrometheusAddr: "127.0.0.1:8889",
+		BlockTime:      400 * time.Millisecond,
+		MaxTxsPerBlock: 30,
+		Validators:     4,
+		Seed:           1,
+		ChainID:        10143,
+	}
+}
+
+// Node is a running mock Monad node
+type Node struct {
+	cfg   Config
+	chain *Chain
+
+	servers []*http.Server
+	stop    chan struct{}
+	wg      sync.WaitGroup
+
+	mu        sync.Mutex
+	conns     map[*wsConn]bool
+	nextSubID uint64
+}
+
+// New creates a mock node; call Start to begin serving
+func New(cfg Config) *Node {
+	chain := NewChain(cfg.Seed, cfg.MaxTxsPerBlock, cfg.Validators)
+	chain.ClockOffset = cfg.ClockOffset
+	if cfg.HistoryBlocks > 0 {
+		chain.Backfill(cfg.HistoryBlocks, cfg.BlockTime)
+	}
+	return &Node{
+		cfg:   cfg,
+		chain: chain,
+		stop:  make(chan struct{}),
+		conns: make(map[*wsConn]bool),
+	}
+}
+
+// Chain exposes the generated chain so callers can assert against it
+func (n *Node) Chain() *Chain {
+	return n.chain
+}
+
+// Start binds all listeners and begins producing blocks
+func (n *Node) Start() error {
+	rpcMux := http.NewServeMux()
+	rpcMux.HandleFunc("/", n.handleRPC)
+
+	wsMux := http.NewServeMux()
+	wsMux.HandleFunc("/", n.handleWS)
+
+	promMux := http.NewServeMux()
+	promMux.HandleFunc("/metrics", n.handleMetrics)
+
+	listeners := []struct {
+		addr    string
+		handler http.Handler
+	}{
+		{n.cfg.RPCAddr, rpcMux},
+		{n.cfg.WSAddr, wsMux},
+		{n.cfg.PrometheusAddr, promMux},
+	}
+
+	for _, l := range listeners {
+		if l.addr == "" {
+			continue
+		}
+		ln, err := net.Listen("tcp", l.addr)
+		if err != nil {
+			n.Close()
+			return fmt.Errorf("mocknode: listen %s: %w", l.addr, err)
+		}
+		srv := &http.Server{Handler: l.handler}
+		n.servers = append(n.servers, srv)
+		go srv.Serve(ln)
+	}
+
+	n.wg.Add(1)
+	go n.produceBlocks()
+	if n.cfg.DropKind != "" && n.cfg.DropEvery > 0 {
+		n.wg.Add(1)
+		go n.dropSubscriptions()
+	}
+	return nil
+}
+
+// Close stops block production and shuts down all listeners
+func (n *Node) Close() {
+	select {
+	case <-n.stop:
+		return
+	default:
+		close(n.stop)
+	}
+	for _, srv := range n.servers {
+		srv.Close()
+	}
+	n.mu.Lock()
+	for c := range n.conns {
+		c.conn.Close()
+	}
+	n.mu.Unlock()
+	n.wg.Wait()
+}
+
+func (n *Node) produceBlocks() {
+	defer n.wg.Done()
+	ticker := time.NewTicker(n.cfg.BlockTime)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-n.stop:
+			return
+		case <-ticker.C:
+			b := n.chain.Next()
+			n.publish(b)
+		}
+	}
+}
+
+// dropSubscriptions periodically forgets one kind of subscription without
+// telling the client, as a node losing a subscription does
+func (n *Node) dropSubscriptions() {
+	defer n.wg.Done()
+	ticker := time.NewTicker(n.cfg.DropEvery)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-n.stop:
+			return
+		case <-ticker.C:
+			if dropped := n.DropSubscriptions(n.cfg.DropKind); dropped > 0 {
+				log.Printf("mocknode: dropped %d %s subscriptions", dropped, n.cfg.DropKind)
+			}
+		}
+	}
+}
+
+// DropSubscriptions silently removes every subscription of a kind, returning
+// how many were removed; the connections stay open
+func (n *Node) DropSubscriptions(kind string) int {
+	dropped := 0
+	for _, c := range n.connsSnapshot() {
+		c.mu.Lock()
+		for id, k := range c.subs {
+			if k == kind || (kind == "newPendingTransactions" && k == pendingFullKind) {
+				delete(c.subs, id)
+				dropped++
+			}
+		}
+		c.mu.Unlock()
+	}
+	return dropped
+}
+
+func (n *Node) handleRPC(w http.ResponseWriter, r *http.Request) {
+	var req rpcRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(rpcResponse{JSONRPC: "2.0", Error: &rpcError{Code: -32700, Message: "parse error"}})
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(n.dispatch(req))
+}
+
+// wsConn is a subscriber connection; writes are serialized by mu
+type wsConn struct {
+	conn *websocket.Conn
+	mu   sync.Mutex
+	subs map[string]string // subscription ID -> kind
+}
+
+func (c *wsConn) send(v interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+	return c.conn.WriteJSON(v)
+}
+
+// subsSnapshot copies the connection's subscriptions
+func (c *wsConn) subsSnapshot() map[string]string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	subs := make(map[string]string, len(c.subs))
+	for id, kind := range c.subs {
+		subs[id] = kind
+	}
+	return subs
+}
+
+// notify sends results as eth_subscription notifications, closing the
+// connection and returning false when a write fails
+func (c *wsConn) notify(id string, results []interface{}) bool {
+	for _, result := range results {
+		msg := notification{
+			JSONRPC: "2.0",
+			Method:  "eth_subscription",
+			Params:  notificationParams{Subscription: id, Result: result},
+		}
+		if err := c.send(msg); err != nil {
+			log.Printf("mocknode: dropping subscriber: %v", err)
+			c.conn.Close()
+			return false
+		}
+	}
+	return true
+}
+
+var upgrader = websocket.Upgrader{CheckOrigin: func(r *http.Request) bool { return true }}
+
+func (n *Node) handleWS(w http.ResponseWriter, r *http.Request) {
+	if !websocket.IsWebSocketUpgrade(r) {
+		// Plain HTTP POSTs to the WS port behave like the RPC port
+		n.handleRPC(w, r)
+		return
+	}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	c := &wsConn{conn: conn, subs: make(map[string]string)}
+
+	n.mu.Lock()
+	n.conns[c] = true
+	n.mu.Unlock()
+
+	defer func() {
+		n.mu.Lock()
+		delete(n.conns, c)
+		n.mu.Unlock()
+		conn.Close()
+	}()
+
+	for {
+		var req rpcRequest
+		if err := conn.ReadJSON(&req); err != nil {
+			return
+		}
+		var resp rpcResponse
+		switch req.Method {
+		case "eth_subscribe":
+			resp = n.subscribe(c, req)
+		case "eth_unsubscribe":
+			resp = n.unsubscribe(c, req)
+		default:
+			resp = n.dispatch(req)
+		}
+		if err := c.send(resp); err != nil {
+			return
+		}
+	}
+}
+
+func (n *Node) subscribe(c *wsConn, req rpcRequest) rpcResponse {
+	resp := rpcResponse{JSONRPC: "2.0", ID: req.ID}
+	var kind string
+	if len(req.Params) > 0 {
+		json.Unmarshal(req.Params[0], &kind)
+	}
+	switch kind {
+	case "newHeads", "monadNewHeads", "monadLogs", "logs":
+	case "newPendingTransactions":
+		// An optional true parameter asks for full transaction bodies
+		var full bool
+		if len(req.Params) > 1 {
+			json.Unmarshal(req.Params[1], &full)
+		}
+		if full {
+			kind = pendingFullKind
+		}
+	default:
+		resp.Error = &rpcError{Code: -32602, Message: fmt.Sprintf("unsupported subscription %q", kind)}
+		return resp
+	}
+
+	n.mu.Lock()
+	n.nextSubID++
+	id := fmt.Sprintf("0x%032x", n.nextSubID)
+	n.mu.Unlock()
+
+	c.mu.Lock()
+	c.subs[id] = kind
+	c.mu.Unlock()
+
+	resp.Result = id
+	return resp
+}
+
+func (n *Node) unsubscribe(c *wsConn, req rpcRequest) rpcResponse {
+	var id string
+	if len(req.Params) > 0 {
+		json.Unmarshal(req.Params[0], &id)
+	}
+	c.mu.Lock()
+	_, ok := c.subs[id]
+	delete(c.subs, id)
+	c.mu.Unlock()
+	return rpcResponse{JSONRPC: "2.0", ID: req.ID, Result: ok}
+}
+
+// pendingFullKind marks a newPendingTransactions subscription with bodies
+const pendingFullKind = "newPendingTransactions/full"
+
+type notification struct {
+	JSONRPC string             `json:"jsonrpc"`
+	Method  string             `json:"method"`
+	Params  notificationParams `json:"params"`
+}
+
+type notificationParams struct {
+	Subscription string      `json:"subscription"`
+	Result       interface{} `json:"result"`
+}
+
+// publish delivers a new block to every subscription. Its transactions are
+// announced as pending first, since blocks are generated all at once.
+func (n *Node) publish(b *Block) {
+	n.publishPending(b)
+
+	var logs []interface{}
+	for i, tx := range b.Txs {
+		for j, l := range tx.Logs {
+			logs = append(logs, logJSON(b, i, tx, j, l))
+		}
+	}
+
+	// monadNewHeads reports the new block as proposed and advances the
+	// two blocks behind it through voted and finalized.
+	var commits []interface{}
+	for offset, state := range []string{"Proposed", "Voted", "Finalized"} {
+		prev, ok := n.chain.Block(b.Number - int64(offset))
+		if !ok {
+			continue
+		}
+		head := headerJSON(prev)
+		head["commitState"] = state
+		commits = append(commits, head)
+	}
+
+	for _, c := range n.connsSnapshot() {
+		for id, kind := range c.subsSnapshot() {
+			var results []interface{}
+			switch kind {
+			case "newHeads":
+				results = []interface{}{headerJSON(b)}
+			case "monadNewHeads":
+				results = commits
+			case "monadLogs", "logs":
+				results = logs
+			}
+			if !c.notify(id, results) {
+				break
+			}
+		}
+	}
+}
+
+// connsSnapshot returns the open WebSocket connections
+func (n *Node) connsSnapshot() []*wsConn {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	conns := make([]*wsConn, 0, len(n.conns))
+	for c := range n.conns {
+		conns = append(conns, c)
+	}
+	return conns
+}
+
+// publishPending announces a block's transactions to newPendingTransactions
+// subscribers, as hashes or full bodies
+func (n *Node) publishPending(b *Block) {
+	for _, c := range n.connsSnapshot() {
+		for id, kind := range c.subsSnapshot() {
+			var results []interface{}
+			switch kind {
+			case "newPendingTransactions":
+				for _, tx := range b.Txs {
+					results = append(results, tx.Hash)
+				}
+			case pendingFullKind:
+				for i, tx := range b.Txs {
+					results = append(results, pendingTxJSON(b, i, tx))
+				}
+				if tx, ok := StuckTx(b); ok {
+					results = append(results, pendingTxJSON(b, 0, tx))
+				}
+			}
+			if !c.notify(id, results) {
+				break
+			}
+		}
+	}
+}
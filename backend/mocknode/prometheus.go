@@ -0,0 +1,50 @@
+package mocknode
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// handleMetrics serves the execution ledger and txpool series in the
+// Prometheus text format. Counters are derived from the generated chain so
+// rates computed by the dashboard track the mock block production.
+func (n *Node) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	head := n.chain.Head()
+	txs := n.chain.TotalTxs()
+
+	// Every committed transaction passed through the pool; a small share of
+	// submissions is dropped for each reason.
+	owned := txs * 3 / 5
+	forwarded := txs - owned
+	drops := txs / 50
+	pending := uint64(0)
+	if len(head.Txs) > 0 {
+		pending = uint64(len(head.Txs)) * 2
+	}
+
+	series := []struct {
+		name, kind string
+		value      uint64
+	}{
+		{"monad_execution_ledger_num_tx_commits", "counter", txs},
+		{"monad_execution_ledger_num_blocks_committed", "counter", uint64(head.Number)},
+		{"monad_bft_txpool_pool_insert_owned_txs", "counter", owned},
+		{"monad_bft_txpool_pool_insert_forwarded_txs", "counter", forwarded},
+		{"monad_bft_txpool_pool_drop_not_well_formed", "counter", drops / 4},
+		{"monad_bft_txpool_pool_drop_nonce_too_low", "counter", drops},
+		{"monad_bft_txpool_pool_drop_fee_too_low", "counter", drops / 2},
+		{"monad_bft_txpool_pool_drop_insufficient_balance", "counter", drops / 3},
+		{"monad_bft_txpool_pool_drop_pool_full", "counter", 0},
+		{"monad_bft_txpool_pool_pending_txs", "gauge", pending},
+		{"monad_bft_txpool_pool_tracked_txs", "gauge", pending + 40},
+	}
+
+	var sb strings.Builder
+	for _, s := range series {
+		fmt.Fprintf(&sb, "# TYPE %s %s\n%s{job=\"mocknode\"} %d\n", s.name, s.kind, s.name, s.value)
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	w.Write([]byte(sb.String()))
+}
@@ -0,0 +1,351 @@
+package mocknode
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"monad-dashboard/txsign"
+)
+
+type rpcRequest struct {
+	JSONRPC string            `json:"jsonrpc"`
+	ID      json.RawMessage   `json:"id"`
+	Method  string            `json:"method"`
+	Params  []json.RawMessage `json:"params"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+func hexUint(v uint64) string {
+	return "0x" + strconv.FormatUint(v, 16)
+}
+
+func hexInt(v int64) string {
+	return "0x" + strconv.FormatInt(v, 16)
+}
+
+// dispatch executes a JSON-RPC method against the chain
+func (n *Node) dispatch(req rpcRequest) rpcResponse {
+	resp := rpcResponse{JSONRPC: "2.0", ID: req.ID}
+	result, err := n.call(req.Method, req.Params)
+	if err != nil {
+		resp.Error = err
+	} else {
+		resp.Result = result
+	}
+	// A null result must still be serialized for "not found" lookups
+	if resp.Result == nil && resp.Error == nil {
+		resp.Result = json.RawMessage("null")
+	}
+	return resp
+}
+
+func (n *Node) call(method string, params []json.RawMessage) (interface{}, *rpcError) {
+	stringParam := func(i int) string {
+		if i >= len(params) {
+			return ""
+		}
+		var s string
+		json.Unmarshal(params[i], &s)
+		return s
+	}
+	boolParam := func(i int) bool {
+		if i >= len(params) {
+			return false
+		}
+		var b bool
+		json.Unmarshal(params[i], &b)
+		return b
+	}
+
+	switch method {
+	case "eth_blockNumber":
+		return hexInt(n.chain.Head().Number), nil
+
+	case "eth_chainId":
+		return hexUint(n.cfg.ChainID), nil
+
+	case "eth_getBlockByNumber":
+		b, ok := n.resolveTag(stringParam(0))
+		if !ok {
+			return nil, nil
+		}
+		return blockJSON(b, boolParam(1)), nil
+
+	case "eth_getBlockByHash":
+		b, ok := n.chain.BlockByHash(stringParam(0))
+		if !ok {
+			return nil, nil
+		}
+		return blockJSON(b, boolParam(1)), nil
+
+	case "eth_getBlockReceipts":
+		b, ok := n.resolveTag(stringParam(0))
+		if !ok {
+			return nil, nil
+		}
+		receipts := make([]interface{}, 0, len(b.Txs))
+		var cumulative uint64
+		for i, tx := range b.Txs {
+			cumulative += tx.GasUsed
+			receipts = append(receipts, receiptJSON(b, i, tx, cumulative))
+		}
+		return receipts, nil
+
+	case "eth_getTransactionReceipt":
+		hash := strings.ToLower(stringParam(0))
+		b, ok := n.chain.TxBlock(hash)
+		if !ok {
+			return nil, nil
+		}
+		var cumulative uint64
+		for i, tx := range b.Txs {
+			cumulative += tx.GasUsed
+			if tx.Hash == hash {
+				return receiptJSON(b, i, tx, cumulative), nil
+			}
+		}
+		return nil, nil
+
+	case "eth_getBalance":
+		// 1234.5 MON for every account
+		return "0x42ec36d8bd73b8f0000", nil
+
+	case "eth_getTransactionCount":
+		if strings.EqualFold(stringParam(0), StuckSender) {
+			return "0x0", nil
+		}
+		if stringParam(1) == "pending" {
+			return hexUint(n.chain.PendingNonce(stringParam(0))), nil
+		}
+		return hexUint(n.chain.Nonce(stringParam(0))), nil
+
+	case "eth_sendRawTransaction":
+		return n.sendRawTransaction(stringParam(0))
+
+	case "eth_getTransactionByHash":
+		hash := strings.ToLower(stringParam(0))
+		if tx, ok := n.chain.PendingTx(hash); ok {
+			return pendingTxJSON(n.chain.Head(), 0, tx), nil
+		}
+		b, ok := n.chain.TxBlock(hash)
+		if !ok {
+			return nil, nil
+		}
+		for i, tx := range b.Txs {
+			if tx.Hash == hash {
+				return txJSON(b, i, tx), nil
+			}
+		}
+		return nil, nil
+
+	case "eth_getCode":
+		if strings.EqualFold(stringParam(0), TokenAddress) {
+			return "0x6080604052348015600f57600080fd5b50", nil
+		}
+		return "0x", nil
+
+	case "eth_call":
+		var call struct {
+			To    string `json:"to"`
+			Data  string `json:"data"`
+			Input string `json:"input"`
+		}
+		if len(params) > 0 {
+			json.Unmarshal(params[0], &call)
+		}
+		if call.Data == "" {
+			call.Data = call.Input
+		}
+		b, ok := n.resolveTag(stringParam(1))
+		if !ok {
+			return nil, &rpcError{Code: -32000, Message: "header not found"}
+		}
+		if strings.EqualFold(call.To, StakingPrecompile) {
+			if out, ok := n.chain.stakingCall(call.Data, b.Number); ok {
+				return out, nil
+			}
+			return nil, &rpcError{Code: -32000, Message: "execution reverted"}
+		}
+		return "0x", nil
+
+	case "eth_gasPrice":
+		return hexUint(n.chain.Head().BaseFee + 1_000_000_000), nil
+
+	case "eth_pendingTransactions", "txpool_content":
+		return []interface{}{}, nil
+
+	case "eth_syncing":
+		return false, nil
+
+	case "net_peerCount":
+		return "0x8", nil
+
+	case "web3_clientVersion":
+		return "monad-mocknode/1.0", nil
+	}
+
+	return nil, &rpcError{Code: -32601, Message: fmt.Sprintf("the method %s does not exist/is not available", method)}
+}
+
+// sendRawTransaction verifies a signed legacy transaction and queues it for
+// the next block
+func (n *Node) sendRawTransaction(input string) (interface{}, *rpcError) {
+	raw, err := hex.DecodeString(strings.TrimPrefix(input, "0x"))
+	if err != nil {
+		return nil, &rpcError{Code: -32602, Message: "invalid raw transaction hex"}
+	}
+	decoded, from, err := txsign.Decode(raw)
+	if err != nil {
+		return nil, &rpcError{Code: -32000, Message: err.Error()}
+	}
+	if decoded.ChainID != n.cfg.ChainID {
+		return nil, &rpcError{Code: -32000, Message: fmt.Sprintf("invalid chain id %d", decoded.ChainID)}
+	}
+	baseFee := new(big.Int).SetUint64(n.chain.Head().BaseFee)
+	if decoded.GasPrice.Cmp(baseFee) < 0 {
+		return nil, &rpcError{Code: -32000, Message: "gas price below base fee"}
+	}
+	if decoded.Gas < 21_000 {
+		return nil, &rpcError{Code: -32000, Message: "intrinsic gas too low"}
+	}
+
+	tx := Tx{
+		Hash:        txsign.Hash(raw),
+		From:        from,
+		To:          decoded.To,
+		Nonce:       decoded.Nonce,
+		GasUsed:     21_000,
+		PriorityFee: new(big.Int).Sub(decoded.GasPrice, baseFee).Uint64(),
+	}
+	if err := n.chain.Submit(tx); err != nil {
+		return nil, &rpcError{Code: -32000, Message: err.Error()}
+	}
+	return tx.Hash, nil
+}
+
+// resolveTag maps a block tag or hex number to a block
+func (n *Node) resolveTag(tag string) (*Block, bool) {
+	switch tag {
+	case "", "latest", "pending", "safe":
+		return n.chain.Head(), true
+	case "finalized":
+		return n.chain.Finalized(), true
+	case "earliest":
+		return n.chain.Block(0)
+	}
+	number, err := strconv.ParseInt(strings.TrimPrefix(tag, "0x"), 16, 64)
+	if err != nil {
+		return nil, false
+	}
+	return n.chain.Block(number)
+}
+
+func headerJSON(b *Block) map[string]interface{} {
+	return map[string]interface{}{
+		"number":        hexInt(b.Number),
+		"hash":          b.Hash,
+		"parentHash":    b.ParentHash,
+		"timestamp":     hexInt(b.Timestamp),
+		"miner":         b.Miner,
+		"gasUsed":       hexUint(b.GasUsed()),
+		"gasLimit":      hexUint(b.GasLimit),
+		"baseFeePerGas": hexUint(b.BaseFee),
+	}
+}
+
+func blockJSON(b *Block, fullTxs bool) map[string]interface{} {
+	out := headerJSON(b)
+	txs := make([]interface{}, 0, len(b.Txs))
+	for i, tx := range b.Txs {
+		if !fullTxs {
+			txs = append(txs, tx.Hash)
+			continue
+		}
+		txs = append(txs, txJSON(b, i, tx))
+	}
+	out["transactions"] = txs
+	out["size"] = hexInt(int64(500 + 120*len(b.Txs)))
+	return out
+}
+
+func txJSON(b *Block, index int, tx Tx) map[string]interface{} {
+	return map[string]interface{}{
+		"hash":                 tx.Hash,
+		"from":                 tx.From,
+		"to":                   tx.To,
+		"blockNumber":          hexInt(b.Number),
+		"blockHash":            b.Hash,
+		"transactionIndex":     hexInt(int64(index)),
+		"gas":                  hexUint(tx.GasUsed * 2),
+		"maxFeePerGas":         hexUint(b.BaseFee * 2),
+		"maxPriorityFeePerGas": hexUint(tx.PriorityFee),
+		"value":                "0x0",
+		"type":                 "0x2",
+		"nonce":                hexUint(tx.Nonce),
+	}
+}
+
+// pendingTxJSON renders a transaction as a newPendingTransactions body,
+// which has no block yet
+func pendingTxJSON(b *Block, index int, tx Tx) map[string]interface{} {
+	out := txJSON(b, index, tx)
+	out["blockNumber"] = nil
+	out["blockHash"] = nil
+	out["transactionIndex"] = nil
+	return out
+}
+
+func logJSON(b *Block, txIndex int, tx Tx, logIndex int, l Log) map[string]interface{} {
+	return map[string]interface{}{
+		"address":          l.Address,
+		"topics":           l.Topics,
+		"data":             l.Data,
+		"blockNumber":      hexInt(b.Number),
+		"blockHash":        b.Hash,
+		"transactionHash":  tx.Hash,
+		"transactionIndex": hexInt(int64(txIndex)),
+		"logIndex":         hexInt(int64(logIndex)),
+		"removed":          false,
+	}
+}
+
+func receiptJSON(b *Block, index int, tx Tx, cumulative uint64) map[string]interface{} {
+	status := "0x1"
+	if tx.Reverted {
+		status = "0x0"
+	}
+	logs := make([]interface{}, 0, len(tx.Logs))
+	for i, l := range tx.Logs {
+		logs = append(logs, logJSON(b, index, tx, i, l))
+	}
+	return map[string]interface{}{
+		"transactionHash":   tx.Hash,
+		"transactionIndex":  hexInt(int64(index)),
+		"blockNumber":       hexInt(b.Number),
+		"blockHash":         b.Hash,
+		"from":              tx.From,
+		"to":                tx.To,
+		"contractAddress":   nil,
+		"status":            status,
+		"gasUsed":           hexUint(tx.GasUsed),
+		"cumulativeGasUsed": hexUint(cumulative),
+		"effectiveGasPrice": hexUint(b.BaseFee + tx.PriorityFee),
+		"type":              "0x2",
+		"logs":              logs,
+	}
+}
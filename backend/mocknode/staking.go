@@ -0,0 +1,100 @@
+package mocknode
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"math/big"
+	"strings"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// StakingPrecompile serves the validator set of the mock chain: every
+// proposer is a validator (IDs from 1) with a 10% commission and a stake
+// that grows by its ID in MON every 1000 blocks
+const StakingPrecompile = "0x0000000000000000000000000000000000001000"
+
+// epochLength matches the dashboard's default CHAIN_EPOCH_LENGTH
+const epochLength = 50000
+
+func selector(signature string) string {
+	hash := sha3.NewLegacyKeccak256()
+	hash.Write([]byte(signature))
+	return hex.EncodeToString(hash.Sum(nil)[:4])
+}
+
+var (
+	selectorGetConsensusValidatorSet = selector("getConsensusValidatorSet(uint32)")
+	selectorGetValidator             = selector("getValidator(uint64)")
+	selectorGetEpoch                 = selector("getEpoch()")
+)
+
+var weiPerMON = new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)
+
+// word encodes a uint256
+func word(v *big.Int) []byte {
+	return v.FillBytes(make([]byte, 32))
+}
+
+func wordUint(v uint64) []byte {
+	return word(new(big.Int).SetUint64(v))
+}
+
+// stakingCall answers an eth_call to the staking precompile at block
+func (c *Chain) stakingCall(input string, block int64) (string, bool) {
+	data, err := hex.DecodeString(strings.TrimPrefix(input, "0x"))
+	if err != nil || len(data) < 4 {
+		return "", false
+	}
+	var arg uint64
+	if len(data) >= 36 {
+		arg = binary.BigEndian.Uint64(data[28:36])
+	}
+	validators := c.Validators()
+	epoch := uint64(block / epochLength)
+
+	var out []byte
+	switch hex.EncodeToString(data[:4]) {
+	case selectorGetEpoch:
+		out = append(wordUint(epoch), wordUint(0)...)
+
+	case selectorGetConsensusValidatorSet:
+		// One page: isDone, nextIndex, uint64[] valIds
+		out = append(wordUint(1), wordUint(uint64(len(validators)))...)
+		out = append(out, wordUint(96)...)
+		out = append(out, wordUint(uint64(len(validators)))...)
+		for i := range validators {
+			out = append(out, wordUint(uint64(i+1))...)
+		}
+
+	case selectorGetValidator:
+		if arg < 1 || arg > uint64(len(validators)) {
+			return "0x", true
+		}
+		auth, _ := hex.DecodeString(strings.TrimPrefix(validators[arg-1], "0x"))
+		stake := new(big.Int).Mul(new(big.Int).SetUint64(1_000_000+arg*uint64(block/1000)), weiPerMON)
+		commission := new(big.Int).Div(weiPerMON, big.NewInt(10))
+		pubkey, _ := hex.DecodeString(strings.TrimPrefix(hash32("secp", arg), "0x"))
+		pubkey = append([]byte{0x02}, pubkey...)
+
+		out = append(word(new(big.Int).SetBytes(auth)), wordUint(0)...) // authAddress, flags
+		out = append(out, word(stake)...)                               // stake
+		out = append(out, wordUint(0)...)                               // accRewardPerToken
+		out = append(out, word(commission)...)                          // commission
+		out = append(out, wordUint(0)...)                               // unclaimedRewards
+		out = append(out, word(stake)...)                               // consensusStake
+		out = append(out, word(commission)...)                          // consensusCommission
+		out = append(out, word(stake)...)                               // snapshotStake
+		out = append(out, word(commission)...)                          // snapshotCommission
+		out = append(out, wordUint(12*32)...)                           // secpPubkey offset
+		out = append(out, wordUint(12*32+32+64)...)                     // blsPubkey offset
+		out = append(out, wordUint(uint64(len(pubkey)))...)
+		out = append(out, pubkey...)
+		out = append(out, make([]byte, 64-len(pubkey))...)
+		out = append(out, wordUint(0)...) // Empty blsPubkey
+
+	default:
+		return "", false
+	}
+	return "0x" + hex.EncodeToString(out), true
+}
@@ -1,33 +1,50 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"math/big"
 	"math/rand"
-	"net"
 	"net/http"
-	"strings"
 	"time"
+
+	"monad-dashboard/hexutil"
 )
 
+// ipcTimeout bounds one request/response exchange over a node IPC socket
+const ipcTimeout = 5 * time.Second
+
+// errEmptyResult is returned for a null result, e.g. a block that does not exist yet
+var errEmptyResult = errors.New("empty result")
+
 type MonadClient struct {
 	BFTRPCUrl      string
 	ExecutionRPCUrl string
 	BFTIPCPath     string
 	ExecutionIPCPath string
-	httpClient     *http.Client
+	rpc            *RPCPool    // Every execution RPC endpoint; the URLs above name the first
+	cache          *BlockCache // nil uses the global block cache
+	usage          *RPCUsage   // Calls per method, for /api/v1/rpc-usage
 }
 
+// NewMonadClient creates a client; monadRPC may list several
+// comma-separated RPC URLs to balance calls across
 func NewMonadClient(monadRPC, bftIPC, execIPC string) *MonadClient {
+	rpc := NewRPCPool(monadRPC, &http.Client{})
+	primary := ""
+	if urls := rpc.URLs(); len(urls) > 0 {
+		primary = urls[0]
+	}
 	return &MonadClient{
-		BFTRPCUrl:        monadRPC, // Use same RPC server for BFT metrics
-		ExecutionRPCUrl:  monadRPC, // This is actually monad-rpc server
+		BFTRPCUrl:        primary, // Use same RPC server for BFT metrics
+		ExecutionRPCUrl:  primary, // This is actually monad-rpc server
 		BFTIPCPath:       bftIPC,
 		ExecutionIPCPath: execIPC,
-		httpClient: &http.Client{
-			Timeout: 5 * time.Second,
-		},
+		rpc:              rpc,
+		usage:            NewRPCUsage(systemClock),
 	}
 }
 
@@ -54,7 +71,7 @@ func (c *MonadClient) GetConsensusMetrics() (*ConsensusMetrics, error) {
 
 func (c *MonadClient) getConsensusViaRPC() (*ConsensusMetrics, error) {
 	// Get latest block number
-	blockNumResp, err := c.rpcCall(c.BFTRPCUrl, "eth_blockNumber", []interface{}{})
+	blockNumResp, err := c.rpcCall("eth_blockNumber", []interface{}{})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get block number: %w", err)
 	}
@@ -67,32 +84,22 @@ func (c *MonadClient) getConsensusViaRPC() (*ConsensusMetrics, error) {
 		return nil, fmt.Errorf("failed to decode block number: %w", err)
 	}
 
-	// Get latest block
-	blockResp, err := c.rpcCall(c.BFTRPCUrl, "eth_getBlockByNumber", []interface{}{"latest", false})
+	height, err := hexutil.DecodeInt64(blockNumResult.Result)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get latest block: %w", err)
+		return nil, fmt.Errorf("failed to parse block number %q: %w", blockNumResult.Result, err)
 	}
 
-	var block struct {
-		Result struct {
-			Number    string `json:"number"`
-			Timestamp string `json:"timestamp"`
-			Hash      string `json:"hash"`
-		} `json:"result"`
-	}
-
-	if err := json.Unmarshal(blockResp, &block); err != nil {
-		return nil, fmt.Errorf("failed to decode block: %w", err)
+	// Get latest block (cached, so repeated polls of the same height are free)
+	block, err := c.GetBlockByNumber(height)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest block: %w", err)
 	}
-
-	// Parse block height and timestamp
-	height, _ := parseHexToInt64(block.Result.Number)
-	timestamp, _ := parseHexToInt64(block.Result.Timestamp)
+	timestamp := hexutil.Int64OrZero(block.Timestamp)
 
 	return &ConsensusMetrics{
 		CurrentHeight:     height,
 		LastBlockTime:     timestamp,
-		BlockTime:         0.4,  // Monad block time
+		BlockTime:         GetChainParams().BlockTime,
 		ValidatorCount:    100,  // Default - would need custom endpoint
 		VotingPower:       1000000, // Default
 		ParticipationRate: 0.9,  // Default
@@ -101,7 +108,9 @@ func (c *MonadClient) getConsensusViaRPC() (*ConsensusMetrics, error) {
 
 func (c *MonadClient) getConsensusViaIPC() (*ConsensusMetrics, error) {
 	// Connect to Unix socket
-	conn, err := net.Dial("unix", c.BFTIPCPath)
+	ctx, cancel := callContext(ipcTimeout)
+	defer cancel()
+	conn, err := dialUnix(ctx, c.BFTIPCPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to BFT IPC: %w", err)
 	}
@@ -141,7 +150,7 @@ func (c *MonadClient) GetExecutionMetrics() (*ExecutionMetrics, error) {
 
 func (c *MonadClient) getExecutionViaRPC() (*ExecutionMetrics, error) {
 	// Get latest block
-	blockResp, err := c.rpcCall(c.ExecutionRPCUrl, "eth_getBlockByNumber", []interface{}{"latest", false})
+	blockResp, err := c.rpcCall("eth_getBlockByNumber", []interface{}{"latest", false})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get latest block: %w", err)
 	}
@@ -159,7 +168,7 @@ func (c *MonadClient) getExecutionViaRPC() (*ExecutionMetrics, error) {
 	}
 
 	// Get pending transactions
-	pendingResp, err := c.rpcCall(c.ExecutionRPCUrl, "eth_pendingTransactions", []interface{}{})
+	pendingResp, err := c.rpcCall("eth_pendingTransactions", []interface{}{})
 	if err != nil {
 		log.Printf("Failed to get pending transactions: %v", err)
 	}
@@ -176,10 +185,7 @@ func (c *MonadClient) getExecutionViaRPC() (*ExecutionMetrics, error) {
 	}
 
 	// Calculate TPS (rough estimation)
-	tps := float64(len(block.Result.Transactions)) / 0.4 // Monad 0.4s block time
-
-	gasUsed, _ := parseHexToInt64(block.Result.GasUsed)
-	_ = gasUsed // Use the variable to avoid unused error
+	tps := float64(len(block.Result.Transactions)) / GetChainParams().BlockTime
 
 	return &ExecutionMetrics{
 		TPS:                 tps,
@@ -192,7 +198,9 @@ func (c *MonadClient) getExecutionViaRPC() (*ExecutionMetrics, error) {
 }
 
 func (c *MonadClient) getExecutionViaIPC() (*ExecutionMetrics, error) {
-	conn, err := net.Dial("unix", c.ExecutionIPCPath)
+	ctx, cancel := callContext(ipcTimeout)
+	defer cancel()
+	conn, err := dialUnix(ctx, c.ExecutionIPCPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to execution IPC: %w", err)
 	}
@@ -229,7 +237,14 @@ func (c *MonadClient) GetNetworkMetrics() (*NetworkMetrics, error) {
 }
 
 // Helper functions
-func (c *MonadClient) rpcCall(url, method string, params []interface{}) ([]byte, error) {
+
+// rpcCall sends a JSON-RPC request through the endpoint pool, abandoned on shutdown
+func (c *MonadClient) rpcCall(method string, params []interface{}) ([]byte, error) {
+	return c.rpcCallContext(shutdownContext(), method, params)
+}
+
+// rpcCallContext is rpcCall bounded by ctx
+func (c *MonadClient) rpcCallContext(ctx context.Context, method string, params []interface{}) ([]byte, error) {
 	request := map[string]interface{}{
 		"jsonrpc": "2.0",
 		"method":  method,
@@ -242,18 +257,12 @@ func (c *MonadClient) rpcCall(url, method string, params []interface{}) ([]byte,
 		return nil, err
 	}
 
-	resp, err := c.httpClient.Post(url, "application/json", strings.NewReader(string(reqBody)))
-	if err != nil {
-		return nil, err
+	start := time.Now()
+	resp, err := c.rpc.Call(ctx, reqBody)
+	if ctx.Err() == nil {
+		c.usage.Record(method, time.Since(start), resp, err)
 	}
-	defer resp.Body.Close()
-
-	var result json.RawMessage
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, err
-	}
-
-	return result, nil
+	return resp, err
 }
 
 func parseStringToInt64(s string) (int64, error) {
@@ -262,18 +271,11 @@ func parseStringToInt64(s string) (int64, error) {
 	return result, err
 }
 
-func parseHexToInt64(s string) (int64, error) {
-	var result int64
-	_, err := fmt.Sscanf(s, "0x%x", &result)
-	return result, err
-}
-
 // Get current epoch information
 func (c *MonadClient) GetCurrentEpoch() (int64, error) {
 	// Monad doesn't have epochs in the same way as Solana
-	// We'll calculate a pseudo-epoch based on block height
-	// For Monad: every 50,000 blocks = 1 epoch
-	blockNumResp, err := c.rpcCall(c.ExecutionRPCUrl, "eth_blockNumber", []interface{}{})
+	// We'll calculate a pseudo-epoch based on block height (ChainParams.EpochLength)
+	blockNumResp, err := c.rpcCall("eth_blockNumber", []interface{}{})
 	if err != nil {
 		return 0, fmt.Errorf("failed to get block number: %w", err)
 	}
@@ -286,10 +288,186 @@ func (c *MonadClient) GetCurrentEpoch() (int64, error) {
 		return 0, fmt.Errorf("failed to decode block number: %w", err)
 	}
 
-	blockHeight, _ := parseHexToInt64(blockNumResult.Result)
+	blockHeight, err := hexutil.DecodeInt64(blockNumResult.Result)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse block number %q: %w", blockNumResult.Result, err)
+	}
 
-	// Calculate epoch: 1 epoch = 50,000 blocks
-	epoch := blockHeight / 50000
+	epoch := GetChainParams().Epoch(blockHeight)
 
 	return epoch, nil
-}
\ No newline at end of file
+}
+// rpcError is the error object of a JSON-RPC response
+type rpcError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"` // Revert data of a failed eth_call
+}
+
+func (e *rpcError) Error() string {
+	return fmt.Sprintf("rpc error %d: %s", e.Code, e.Message)
+}
+
+// callResult performs a JSON-RPC call against the execution RPC and decodes
+// the result field into out, surfacing JSON-RPC errors
+func (c *MonadClient) callResult(method string, params []interface{}, out interface{}) error {
+	return c.callResultContext(shutdownContext(), method, params, out)
+}
+
+// callResultContext is callResult bounded by ctx
+func (c *MonadClient) callResultContext(ctx context.Context, method string, params []interface{}, out interface{}) error {
+	resp, err := c.rpcCallContext(ctx, method, params)
+	if err != nil {
+		return fmt.Errorf("%s: %w", method, err)
+	}
+
+	var envelope struct {
+		Result json.RawMessage `json:"result"`
+		Error  *rpcError       `json:"error"`
+	}
+	if err := json.Unmarshal(resp, &envelope); err != nil {
+		return fmt.Errorf("%s: failed to decode response: %w", method, err)
+	}
+	if envelope.Error != nil {
+		return fmt.Errorf("%s: %w", method, envelope.Error)
+	}
+	if len(envelope.Result) == 0 || string(envelope.Result) == "null" {
+		return fmt.Errorf("%s: %w", method, errEmptyResult)
+	}
+
+	if err := json.Unmarshal(envelope.Result, out); err != nil {
+		return fmt.Errorf("%s: failed to decode result: %w", method, err)
+	}
+	return nil
+}
+
+// RPCBlock is a block as returned by eth_getBlockByNumber without full transactions
+type RPCBlock struct {
+	Number        string   `json:"number"`
+	Hash          string   `json:"hash"`
+	ParentHash    string   `json:"parentHash"`
+	Timestamp     string   `json:"timestamp"`
+	Miner         string   `json:"miner"`
+	GasUsed       string   `json:"gasUsed"`
+	GasLimit      string   `json:"gasLimit"`
+	BaseFeePerGas string   `json:"baseFeePerGas"`
+	Transactions  []string `json:"transactions"`
+}
+
+// RPCReceipt is a transaction receipt as returned by eth_getBlockReceipts
+type RPCReceipt struct {
+	TransactionHash   string            `json:"transactionHash"`
+	TransactionIndex  string            `json:"transactionIndex"`
+	From              string            `json:"from"`
+	To                string            `json:"to"`
+	ContractAddress   string            `json:"contractAddress"`
+	Status            string            `json:"status"`
+	GasUsed           string            `json:"gasUsed"`
+	CumulativeGasUsed string            `json:"cumulativeGasUsed"`
+	EffectiveGasPrice string            `json:"effectiveGasPrice"`
+	Logs              []json.RawMessage `json:"logs"`
+}
+
+// blocks returns the client's block cache
+func (c *MonadClient) blocks() *BlockCache {
+	if c.cache != nil {
+		return c.cache
+	}
+	return GetBlockCache()
+}
+
+// GetChainID fetches the chain ID (eth_chainId)
+func (c *MonadClient) GetChainID() (uint64, error) {
+	var chainID string
+	if err := c.callResult("eth_chainId", []interface{}{}, &chainID); err != nil {
+		return 0, err
+	}
+	return hexutil.Uint64OrZero(chainID), nil
+}
+
+// GetBlockByNumber fetches a block header with transaction hashes, served
+// from the shared block cache when possible
+func (c *MonadClient) GetBlockByNumber(number int64) (*RPCBlock, error) {
+	return c.blocks().LoadByNumber(number, func() (*RPCBlock, error) {
+		var block RPCBlock
+		if err := c.callResult("eth_getBlockByNumber", []interface{}{hexutil.EncodeInt64(number), false}, &block); err != nil {
+			return nil, err
+		}
+		return &block, nil
+	})
+}
+
+// GetBlockByHash fetches a block header by hash, served from the shared block cache when possible
+func (c *MonadClient) GetBlockByHash(hash string) (*RPCBlock, error) {
+	if block, ok := c.blocks().GetByHash(hash); ok {
+		return block, nil
+	}
+
+	var block RPCBlock
+	if err := c.callResult("eth_getBlockByHash", []interface{}{hash, false}, &block); err != nil {
+		return nil, err
+	}
+	c.blocks().Put(&block)
+	return &block, nil
+}
+
+// GetBlockNumberByTag resolves a block tag ("latest", "safe", "finalized") to a block number
+func (c *MonadClient) GetBlockNumberByTag(tag string) (int64, error) {
+	var block struct {
+		Number string `json:"number"`
+	}
+	if err := c.callResult("eth_getBlockByNumber", []interface{}{tag, false}, &block); err != nil {
+		return 0, err
+	}
+	return hexutil.DecodeInt64(block.Number)
+}
+
+// SyncStatus is the eth_syncing result while the node is catching up
+type SyncStatus struct {
+	StartingBlock int64
+	CurrentBlock  int64
+	HighestBlock  int64
+}
+
+// GetSyncing returns the node's sync status, or nil when it is not syncing
+func (c *MonadClient) GetSyncing() (*SyncStatus, error) {
+	var raw json.RawMessage
+	if err := c.callResult("eth_syncing", []interface{}{}, &raw); err != nil {
+		return nil, err
+	}
+	if string(raw) == "false" {
+		return nil, nil
+	}
+
+	var status struct {
+		StartingBlock string `json:"startingBlock"`
+		CurrentBlock  string `json:"currentBlock"`
+		HighestBlock  string `json:"highestBlock"`
+	}
+	if err := json.Unmarshal(raw, &status); err != nil {
+		return nil, fmt.Errorf("eth_syncing: failed to decode result: %w", err)
+	}
+	return &SyncStatus{
+		StartingBlock: hexutil.Int64OrZero(status.StartingBlock),
+		CurrentBlock:  hexutil.Int64OrZero(status.CurrentBlock),
+		HighestBlock:  hexutil.Int64OrZero(status.HighestBlock),
+	}, nil
+}
+
+// GetBalance fetches the latest balance of an address in wei
+func (c *MonadClient) GetBalance(address string) (*big.Int, error) {
+	var balance string
+	if err := c.callResult("eth_getBalance", []interface{}{address, "latest"}, &balance); err != nil {
+		return nil, err
+	}
+	return hexutil.DecodeBig(balance)
+}
+
+// GetBlockReceipts fetches all receipts of a block
+func (c *MonadClient) GetBlockReceipts(number int64) ([]RPCReceipt, error) {
+	var receipts []RPCReceipt
+	if err := c.callResult("eth_getBlockReceipts", []interface{}{hexutil.EncodeInt64(number)}, &receipts); err != nil {
+		return nil, err
+	}
+	return receipts, nil
+}
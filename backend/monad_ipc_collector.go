@@ -6,6 +6,8 @@ import (
 	"io"
 	"log"
 	"net"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 )
@@ -14,10 +16,12 @@ import (
 type MonadIPCCollector struct {
 	ipcPath string
 	conn    net.Conn
+	clock   Clock
 	mu      sync.RWMutex
 
 	// Real-time counters from Monad
-	metrics *MonadRealMetrics
+	metrics   *MonadRealMetrics
+	hasSample bool // At least one successful poll (deltas need a baseline)
 }
 
 // MonadRealMetrics represents actual metrics from Monad node
@@ -51,15 +55,44 @@ type MonadRealMetrics struct {
 	StateReads           int64
 	StateWrites          int64
 
-	LastUpdated time.Time
+	// Change of each cumulative counter since the previous poll. Counters
+	// that went backwards (node restart) are clamped to zero and listed in
+	// ResetCounters, and the interval is marked Discontinuous.
+	Deltas        map[string]int64
+	Discontinuous bool
+	ResetCounters []string
+
+	LastUpdated  time.Time
+	PollInterval time.Duration // Time the deltas cover, zero before the second poll
+}
+
+// counterValues lists the cumulative counters by name
+func (m *MonadRealMetrics) counterValues() map[string]int64 {
+	return map[string]int64{
+		"insert_owned_txs":          m.InsertOwnedTxs,
+		"insert_forwarded_txs":      m.InsertForwardedTxs,
+		"drop_not_well_formed":      m.DropNotWellFormed,
+		"drop_invalid_signature":    m.DropInvalidSignature,
+		"drop_nonce_too_low":        m.DropNonceTooLow,
+		"drop_fee_too_low":          m.DropFeeTooLow,
+		"drop_insufficient_balance": m.DropInsufficientBalance,
+		"drop_pool_full":            m.DropPoolFull,
+		"create_proposal":           m.CreateProposal,
+		"create_proposal_txs":       m.CreateProposalTxs,
+		"parallel_success":          m.ParallelSuccess,
+		"sequential_fallback":       m.SequentialFallback,
+		"state_reads":               m.StateReads,
+		"state_writes":              m.StateWrites,
+	}
 }
 
 // NewMonadIPCCollector creates a new IPC-based metrics collector
-func NewMonadIPCCollector(ipcPath string) *MonadIPCCollector {
+func NewMonadIPCCollector(ipcPath string, clock Clock) *MonadIPCCollector {
 	return &MonadIPCCollector{
 		ipcPath: ipcPath,
+		clock:   clock,
 		metrics: &MonadRealMetrics{
-			LastUpdated: time.Now(),
+			LastUpdated: clock.Now(),
 		},
 	}
 }
@@ -69,7 +102,10 @@ func (c *MonadIPCCollector) Connect() error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	conn, err := net.Dial("unix", c.ipcPath)
+	ctx, cancel := callContext(ipcTimeout)
+	defer cancel()
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "unix", c.ipcPath)
 	if err != nil {
 		return fmt.Errorf("failed to connect to Monad IPC %s: %w", c.ipcPath, err)
 	}
@@ -83,22 +119,27 @@ func (c *MonadIPCCollector) Connect() error {
 	return nil
 }
 
-// collectMetrics continuously collects metrics from Monad
+// collectMetrics collects metrics from Monad until shutdown
 func (c *MonadIPCCollector) collectMetrics() {
-	ticker := time.NewTicker(1 * time.Second)
+	ticker := newCadenceTicker(ipcCadence)
 	defer ticker.Stop()
 
 	errorCount := 0
 	lastErrorLog := time.Time{}
 
-	for range ticker.C {
+	for {
+		select {
+		case <-ticker.C:
+		case <-shutdownContext().Done():
+			return
+		}
 		if err := c.requestMetrics(); err != nil {
 			errorCount++
 			// Only log every 30 seconds to reduce noise
-			if time.Since(lastErrorLog) > 30*time.Second {
+			if c.clock.Since(lastErrorLog) > 30*time.Second {
 				log.Printf("IPC metrics collection failing (monad_getMetrics not implemented): %d errors in last 30s", errorCount)
 				errorCount = 0
-				lastErrorLog = time.Now()
+				lastErrorLog = c.clock.Now()
 			}
 			continue
 		}
@@ -109,8 +150,11 @@ func (c *MonadIPCCollector) collectMetrics() {
 
 // requestMetrics requests current metrics snapshot from Monad
 func (c *MonadIPCCollector) requestMetrics() error {
-	// Create a new connection for each request to avoid broken pipe
-	conn, err := net.Dial("unix", c.ipcPath)
+	// Create a new connection for each request to avoid broken pipe; the
+	// whole exchange shares one deadline and ends on shutdown
+	ctx, cancel := callContext(ipcTimeout)
+	defer cancel()
+	conn, err := dialUnix(ctx, c.ipcPath)
 	if err != nil {
 		return fmt.Errorf("failed to dial IPC: %w", err)
 	}
@@ -119,7 +163,7 @@ func (c *MonadIPCCollector) requestMetrics() error {
 	// Send metrics request (JSON-RPC style)
 	request := map[string]interface{}{
 		"jsonrpc": "2.0",
-		"id":      time.Now().Unix(),
+		"id":      c.clock.Now().Unix(),
 		"method":  "monad_getMetrics",
 		"params":  []interface{}{},
 	}
@@ -130,13 +174,11 @@ func (c *MonadIPCCollector) requestMetrics() error {
 	}
 
 	// Write request
-	conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
 	if _, err := conn.Write(append(requestBytes, '\n')); err != nil {
 		return fmt.Errorf("failed to write request: %w", err)
 	}
 
 	// Read response
-	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
 	buffer := make([]byte, 4096)
 	n, err := conn.Read(buffer)
 	if err != nil && err != io.EOF {
@@ -188,6 +230,8 @@ func (c *MonadIPCCollector) requestMetrics() error {
 
 	// Update metrics
 	c.mu.Lock()
+	prevCounters := c.metrics.counterValues()
+	hadSample := c.hasSample
 	c.metrics.InsertOwnedTxs = response.Result.TxPool.InsertOwnedTxs
 	c.metrics.InsertForwardedTxs = response.Result.TxPool.InsertForwardedTxs
 	c.metrics.DropNotWellFormed = response.Result.TxPool.DropNotWellFormed
@@ -207,9 +251,39 @@ func (c *MonadIPCCollector) requestMetrics() error {
 	c.metrics.SequentialFallback = response.Result.Execution.SequentialFallback
 	c.metrics.StateReads = response.Result.Execution.StateReads
 	c.metrics.StateWrites = response.Result.Execution.StateWrites
-	c.metrics.LastUpdated = time.Now()
+
+	// Per-interval deltas with counter reset detection
+	c.metrics.Deltas = make(map[string]int64)
+	c.metrics.ResetCounters = nil
+	if hadSample {
+		for name, cur := range c.metrics.counterValues() {
+			delta, reset := counterDelta(prevCounters[name], cur)
+			c.metrics.Deltas[name] = delta
+			if reset {
+				c.metrics.ResetCounters = append(c.metrics.ResetCounters, name)
+			}
+		}
+		sort.Strings(c.metrics.ResetCounters)
+	}
+	c.metrics.Discontinuous = len(c.metrics.ResetCounters) > 0
+	resetCounters := c.metrics.ResetCounters
+	c.hasSample = true
+	now := c.clock.Now()
+	c.metrics.PollInterval = 0
+	if hadSample {
+		c.metrics.PollInterval = now.Sub(c.metrics.LastUpdated)
+	}
+	c.metrics.LastUpdated = now
 	c.mu.Unlock()
 
+	if len(resetCounters) > 0 {
+		log.Printf("⚠️  IPC: counter reset detected (%s), deltas clamped to zero for this interval",
+			strings.Join(resetCounters, ", "))
+		if store := GetHistoryStore(); store != nil {
+			store.Annotate("counter_reset", "Counter reset", strings.Join(resetCounters, ", "), "ipc")
+		}
+	}
+
 	log.Printf("Updated real metrics: RPC=%d, P2P=%d, SigFailed=%d, Parallel=%d",
 		c.metrics.InsertOwnedTxs, c.metrics.InsertForwardedTxs,
 		c.metrics.DropInvalidSignature, c.metrics.ParallelSuccess)
@@ -233,7 +307,7 @@ func (c *MonadIPCCollector) IsHealthy() bool {
 	defer c.mu.RUnlock()
 
 	// Metrics should be updated within last 5 seconds
-	return time.Since(c.metrics.LastUpdated) < 5*time.Second
+	return c.clock.Since(c.metrics.LastUpdated) < 5*time.Second
 }
 
 // Close closes the IPC connection
@@ -247,21 +321,16 @@ func (c *MonadIPCCollector) Close() error {
 	return nil
 }
 
-// Global IPC collector instance
-var (
-	ipcCollector   *MonadIPCCollector
-	ipcCollectorMu sync.RWMutex
-)
-
-// InitializeIPCCollector initializes the IPC metrics collector
-func InitializeIPCCollector(ipcPath string) error {
-	ipcCollectorMu.Lock()
-	defer ipcCollectorMu.Unlock()
-
-	ipcCollector = NewMonadIPCCollector(ipcPath)
+// StartIPCCollector creates the dashboard's IPC metrics collector and
+// connects it
+func (d *Dashboard) StartIPCCollector(ipcPath string) error {
+	collector := NewMonadIPCCollector(ipcPath, d.clock)
+	d.mu.Lock()
+	d.ipc = collector
+	d.mu.Unlock()
 
 	// Try to connect (fallback to estimation if fails)
-	if err := ipcCollector.Connect(); err != nil {
+	if err := collector.Connect(); err != nil {
 		log.Printf("Failed to connect to Monad IPC: %v", err)
 		log.Printf("Will use estimation-based metrics")
 		return err
@@ -271,9 +340,7 @@ func InitializeIPCCollector(ipcPath string) error {
 	return nil
 }
 
-// GetIPCCollector returns the global IPC collector
+// GetIPCCollector returns the served dashboard's IPC collector
 func GetIPCCollector() *MonadIPCCollector {
-	ipcCollectorMu.RLock()
-	defer ipcCollectorMu.RUnlock()
-	return ipcCollector
+	return GetDashboard().IPCCollector()
 }
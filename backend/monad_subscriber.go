@@ -2,13 +2,16 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"log"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
+
+	"monad-dashboard/hexutil"
 )
 
 // TransactionLog represents a transaction log event from monadLogs
@@ -20,17 +23,31 @@ type TransactionLog struct {
 	Topics           []string `json:"topics"`
 	Data             string   `json:"data"`
 	Timestamp        int64    `json:"timestamp"`
+	Sampled          bool     `json:"sampled,omitempty"`     // Kept by load sampling (see LogSampler)
+	SampleRate       int      `json:"sample_rate,omitempty"` // 1 in SampleRate logs kept
 }
 
 // MonadSubscriber handles real-time subscriptions to Monad node
 type MonadSubscriber struct {
 	wsURL            string
+	dashboard        *Dashboard   // Owner, whose head tracker it feeds
+	client           *MonadClient // Fetches full blocks for enrichment and stall polling
+	clock            Clock
 	conn             *websocket.Conn
-	headsSubID       string // Subscription ID for monadNewHeads
-	logsSubID        string // Subscription ID for monadLogs
+	writeMu          sync.Mutex
+	connects         int64
+
+	// newHeads, monadLogs, monadNewHeads and newPendingTransactions, each
+	// re-established independently (see node_subscriptions.go)
+	subsMu           sync.Mutex
+	subs             []*nodeSubscription
+	nextRequestID    int
 
 	blockChan        chan *BlockHeader
 	logsChan         chan *TransactionLog
+	logSampler       *LogSampler
+	enricher         *BlockEnricher
+	watchdog         *HeadWatchdog
 	errorChan        chan error
 
 	mu             sync.RWMutex
@@ -62,15 +79,22 @@ type BlockHeader struct {
 	Timestamp    int64  `json:"timestamp"`
 	Transactions int    `json:"transactionCount"`
 	GasUsed      int64  `json:"gasUsed"`
+	GasLimit     int64  `json:"gasLimit"`
+	Miner        string `json:"miner"` // Block proposer (beneficiary)
 }
 
-// NewMonadSubscriber creates a new subscriber
-func NewMonadSubscriber(wsURL string) *MonadSubscriber {
-	ctx, cancel := context.WithCancel(context.Background())
-	return &MonadSubscriber{
+// NewMonadSubscriber creates a subscriber to wsURL feeding d's trackers,
+// stopped by Close or shutdown
+func NewMonadSubscriber(d *Dashboard, wsURL string) *MonadSubscriber {
+	ctx, cancel := context.WithCancel(shutdownContext())
+	s := &MonadSubscriber{
 		wsURL:           wsURL,
+		dashboard:       d,
+		client:          d.client,
+		clock:           d.clock,
 		blockChan:       make(chan *BlockHeader, 100),
 		logsChan:        make(chan *TransactionLog, 1000), // Larger buffer for logs
+		logSampler:      NewLogSampler(1000),
 		errorChan:       make(chan error, 10),
 		recentBlocks:    make([]BlockTxInfo, 0, 10),
 		maxRecentBlocks: 10, // Track last 10 blocks (~4 seconds of data)
@@ -79,60 +103,53 @@ func NewMonadSubscriber(wsURL string) *MonadSubscriber {
 		ctx:             ctx,
 		cancel:          cancel,
 	}
+	s.subs = newNodeSubscriptions(s)
+	s.enricher = NewBlockEnricher(s.enrichBlockWithTransactions, s.deliverBlock)
+	s.watchdog = NewHeadWatchdog(s)
+	return s
 }
 
 // Connect establishes WebSocket connection and subscribes to new blocks
 func (s *MonadSubscriber) Connect() error {
 	log.Printf("Connecting to Monad WebSocket at %s...", s.wsURL)
 
-	conn, _, err := websocket.DefaultDialer.Dial(s.wsURL, nil)
+	ctx, cancel := context.WithTimeout(s.ctx, 10*time.Second)
+	defer cancel()
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, s.wsURL, nil)
 	if err != nil {
 		return fmt.Errorf("failed to connect to Monad WebSocket: %w", err)
 	}
 
-	s.conn = conn
-	s.isConnected = true
-
-	// Subscribe to newHeads (standard Ethereum block headers)
-	headsSubMsg := map[string]interface{}{
-		"jsonrpc": "2.0",
-		"id":      1,
-		"method":  "eth_subscribe",
-		"params":  []interface{}{"newHeads"},
-	}
-
-	if err := conn.WriteJSON(headsSubMsg); err != nil {
-		return fmt.Errorf("failed to send newHeads subscribe message: %w", err)
-	}
-
-	// Read newHeads subscription confirmation
-	var headsSubResponse struct {
-		JSONRPC string `json:"jsonrpc"`
-		ID      int    `json:"id"`
-		Result  string `json:"result"`
-	}
-
-	if err := conn.ReadJSON(&headsSubResponse); err != nil {
-		return fmt.Errorf("failed to read newHeads subscription response: %w", err)
+	if err := s.subscribeAll(conn); err != nil {
+		conn.Close()
+		s.markDisconnected()
+		return err
 	}
 
-	s.headsSubID = headsSubResponse.Result
-	log.Printf("Successfully subscribed to newHeads with subscription ID: %s", s.headsSubID)
-
-	// Note: Not subscribing to logs subscription as it only captures smart contract events
-	// We'll use transaction data from newHeads instead for more complete coverage
+	s.mu.Lock()
+	s.conn = conn
+	s.isConnected = true
+	s.connects++
+	s.mu.Unlock()
 
 	// Start listening for messages
 	go s.listen()
+	go s.watchSubscriptions(conn)
 
 	return nil
 }
 
 // listen continuously reads messages from WebSocket
 func (s *MonadSubscriber) listen() {
+	s.mu.RLock()
+	conn := s.conn
+	s.mu.RUnlock()
 	defer func() {
+		// A successful reconnect has already replaced the connection
 		s.mu.Lock()
-		s.isConnected = false
+		if s.conn == conn {
+			s.isConnected = false
+		}
 		s.mu.Unlock()
 	}()
 
@@ -144,11 +161,18 @@ func (s *MonadSubscriber) listen() {
 		default:
 			var msg map[string]interface{}
 			if err := s.conn.ReadJSON(&msg); err != nil {
+				if s.ctx.Err() != nil {
+					return // Closed on shutdown
+				}
 				log.Printf("Error reading from Monad WebSocket: %v", err)
 				s.errorChan <- err
 
 				// Try to reconnect after error
-				time.Sleep(2 * time.Second)
+				select {
+				case <-s.clock.After(2 * time.Second):
+				case <-s.ctx.Done():
+					return
+				}
 				if err := s.reconnect(); err != nil {
 					log.Printf("Failed to reconnect: %v", err)
 					continue
@@ -156,24 +180,8 @@ func (s *MonadSubscriber) listen() {
 				return
 			}
 
-			// Check if this is a subscription message
-			if method, ok := msg["method"].(string); ok && method == "eth_subscription" {
-				// Determine which subscription this is for
-				params, ok := msg["params"].(map[string]interface{})
-				if !ok {
-					continue
-				}
-
-				subID, ok := params["subscription"].(string)
-				if !ok {
-					continue
-				}
-
-				// Route to block handler (only newHeads subscription)
-				if subID == s.headsSubID {
-					s.handleBlockMessage(msg)
-				}
-			}
+			// Subscription notifications and eth_subscribe responses
+			s.handleMessage(msg)
 		}
 	}
 }
@@ -195,30 +203,72 @@ func (s *MonadSubscriber) handleBlockMessage(msg map[string]interface{}) {
 	if header == nil {
 		return
 	}
+	// While polling covers a stall, heads it already delivered are skipped
+	if s.watchdog != nil && !s.watchdog.OnSubscriptionHead(header.Number) {
+		return
+	}
+	if pt := GetPropagationTracker(); pt != nil {
+		pt.OnNotification(header, s.clock.Now())
+	}
+	s.onHead(header)
+}
+
+// onHead feeds a new head, from the subscription or the stall poller, to the
+// block pipeline
+func (s *MonadSubscriber) onHead(header *BlockHeader) {
+	if s.watchdog != nil {
+		s.watchdog.OnHead()
+	}
+	if cs := GetClockSkew(); cs != nil {
+		cs.OnBlock(header.Timestamp, s.clock.Now())
+	}
+	if gr := GetGapRepairer(); gr != nil {
+		gr.Observe(header.Number)
+	}
 
 	// Update latest block
 	s.mu.Lock()
 	s.latestBlock = header
 	s.mu.Unlock()
 
-	// Fetch full block details to get transaction count and hashes
-	go func() {
-		// Enrich with transaction details first
-		s.enrichBlockWithTransactions(header)
+	if ht := s.dashboard.HeadTracker(); ht != nil {
+		ht.OnSpeculativeHead(header.Number)
+	}
 
-		// Now send the enriched block to the channel for metrics update
-		select {
-		case s.blockChan <- header:
-		default:
-			// Channel full, skip this block
-			log.Printf("Block channel full, skipping block %d", header.Number)
-		}
-	}()
+	// Fetch full block details to get transaction count and hashes; the
+	// pool hands enriched blocks to deliverBlock in arrival order. It gets
+	// its own copy, since latestBlock is read while enrichment runs.
+	enriched := *header
+	s.enricher.Submit(&enriched)
 
 	log.Printf("Received new block: height=%d, hash=%s (enriching...)",
 		header.Number, header.Hash[:10])
 }
 
+// handleCommitStateMessage processes commit state updates from monadNewHeads
+func (s *MonadSubscriber) handleCommitStateMessage(msg map[string]interface{}) {
+	params, ok := msg["params"].(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	result, ok := params["result"].(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	numberStr, _ := result["number"].(string)
+	commitState, _ := result["commitState"].(string)
+	number, err := hexutil.DecodeInt64(numberStr)
+	if err != nil || commitState == "" {
+		return
+	}
+
+	if ht := s.dashboard.HeadTracker(); ht != nil {
+		ht.OnCommitState(number, commitState)
+	}
+}
+
 // handleLogsMessage processes incoming transaction logs from monadLogs
 func (s *MonadSubscriber) handleLogsMessage(msg map[string]interface{}) {
 	params, ok := msg["params"].(map[string]interface{})
@@ -231,29 +281,40 @@ func (s *MonadSubscriber) handleLogsMessage(msg map[string]interface{}) {
 		return
 	}
 
+	// Thin the stream before parsing when consumers fall behind
+	now := s.clock.Now()
+	keep, rate := s.logSampler.Admit(len(s.logsChan), now)
+	if !keep {
+		return
+	}
+
 	// Parse transaction log
-	txLog := s.parseTransactionLog(result)
+	txLog := parseTransactionLog(result, s.clock.Now().Unix()) // Current time approximates the block's
 	if txLog == nil {
 		return
 	}
+	if rate > 1 {
+		txLog.Sampled = true
+		txLog.SampleRate = rate
+	}
 
 	// Send to logs channel
 	select {
 	case s.logsChan <- txLog:
+		s.logSampler.Queued(txLog.Sampled)
 	default:
-		// Channel full, skip this log
-		log.Printf("Logs channel full, skipping log for tx %s", txLog.TransactionHash[:10])
+		s.logSampler.Dropped(now)
 	}
 }
 
 // parseTransactionLog converts JSON to TransactionLog
-func (s *MonadSubscriber) parseTransactionLog(result map[string]interface{}) *TransactionLog {
+func parseTransactionLog(result map[string]interface{}, timestamp int64) *TransactionLog {
 	blockNumberStr, ok := result["blockNumber"].(string)
 	if !ok {
 		return nil
 	}
 
-	blockNumber, err := parseHexToInt64(blockNumberStr)
+	blockNumber, err := hexutil.DecodeInt64(blockNumberStr)
 	if err != nil {
 		log.Printf("Failed to parse block number in log: %v", err)
 		return nil
@@ -266,7 +327,7 @@ func (s *MonadSubscriber) parseTransactionLog(result map[string]interface{}) *Tr
 	// Parse transaction index
 	txIndex := 0
 	if txIndexStr, ok := result["transactionIndex"].(string); ok {
-		if idx, err := parseHexToInt64(txIndexStr); err == nil {
+		if idx, err := hexutil.DecodeInt64(txIndexStr); err == nil {
 			txIndex = int(idx)
 		}
 	}
@@ -288,52 +349,64 @@ func (s *MonadSubscriber) parseTransactionLog(result map[string]interface{}) *Tr
 		Address:          address,
 		Topics:           topics,
 		Data:             data,
-		Timestamp:        time.Now().Unix(), // Use current time as approximation
+		Timestamp:        timestamp,
 	}
 }
 
-// enrichBlockWithTransactions fetches full block details to get transaction count
-func (s *MonadSubscriber) enrichBlockWithTransactions(header *BlockHeader) {
-	// Use monadClient to fetch full block with transaction count
-	blockResp, err := monadClient.rpcCall(monadClient.ExecutionRPCUrl, "eth_getBlockByNumber",
-		[]interface{}{fmt.Sprintf("0x%x", header.Number), false})
-	if err != nil {
-		log.Printf("Failed to fetch block details for enrichment: %v", err)
-		return
+// deliverBlock sends an enriched block to the channel for metrics update
+func (s *MonadSubscriber) deliverBlock(header *BlockHeader) {
+	// Publish the transaction count unless a newer head has arrived
+	s.mu.Lock()
+	if s.latestBlock != nil && s.latestBlock.Number == header.Number {
+		s.latestBlock = header
 	}
+	s.mu.Unlock()
 
-	var block struct {
-		Result struct {
-			Transactions []string `json:"transactions"`
-		} `json:"result"`
+	select {
+	case s.blockChan <- header:
+	default:
+		// Channel full, skip this block
+		log.Printf("Block channel full, skipping block %d", header.Number)
 	}
+}
 
-	if err := json.Unmarshal(blockResp, &block); err != nil {
-		log.Printf("Failed to decode block for enrichment: %v", err)
-		return
+// enrichBlockWithTransactions fetches full block details to get transaction count
+func (s *MonadSubscriber) enrichBlockWithTransactions(header *BlockHeader) bool {
+	// Fetch the full block with transaction count (shared block cache)
+	block, err := s.client.GetBlockByNumber(header.Number)
+	if err != nil {
+		log.Printf("Failed to fetch block details for enrichment: %v", err)
+		return false
 	}
 
 	// Update transaction count
-	header.Transactions = len(block.Result.Transactions)
+	header.Transactions = len(block.Transactions)
 
 	// Add to recent blocks for TPS calculation
 	s.addRecentBlock(header.Timestamp, header.Transactions)
 
 	// Calculate TPS metrics for logging
-	epoch := header.Number / 50000 // 50,000 blocks per epoch
-	instantTPS := float64(header.Transactions) / 0.4
+	params := GetChainParams()
+	epoch := params.Epoch(header.Number)
+	instantTPS := float64(header.Transactions) / params.BlockTime
 	avgTPS := s.calculateAverageTPS()
 
 	log.Printf("Block %d: Epoch %d, Instant TPS: %.2f, Avg TPS: %.2f (txs=%d)",
 		header.Number, epoch, instantTPS, avgTPS, header.Transactions)
 
 	// Broadcast each transaction for Transaction Flow visualization
-	for i, txHash := range block.Result.Transactions {
+	if a := GetTxFlowAggregator(); a != nil {
+		a.AddTransactions(len(block.Transactions))
+	}
+	for i, txHash := range block.Transactions {
+		GetTxLifecycleCorrelator().OnIncluded(txHash, header.Number, i)
+		GetNonceGapTracker().OnIncluded(txHash)
 		broadcastTransactionFromBlock(header.Number, txHash, i, header.Timestamp)
 	}
 
 	// NOTE: Do NOT call updateMetricsFromBlock here!
 	// It will be called from processSubscribedBlocks to avoid duplicate updates
+	return true
 }
 
 // addRecentBlock adds a block to the recent blocks list for TPS calculation
@@ -374,8 +447,8 @@ func (s *MonadSubscriber) calculateAverageTPS() float64 {
 	timeSpanSeconds := float64(lastBlock.Timestamp - firstBlock.Timestamp)
 
 	if timeSpanSeconds <= 0 {
-		// Fallback: use block count * 0.4s
-		timeSpanSeconds = float64(len(s.recentBlocks)-1) * 0.4
+		// Fallback: use block count * block time
+		timeSpanSeconds = float64(len(s.recentBlocks)-1) * GetChainParams().BlockTime
 	}
 
 	return float64(totalTx) / timeSpanSeconds
@@ -417,7 +490,7 @@ func (s *MonadSubscriber) getInstantTPS() float64 {
 	}
 
 	lastBlock := s.recentBlocks[len(s.recentBlocks)-1]
-	return float64(lastBlock.Transactions) / 0.4 // Per 0.4s block time
+	return float64(lastBlock.Transactions) / GetChainParams().BlockTime
 }
 
 // addTPSToHistory adds current TPS metrics to history for charting
@@ -452,7 +525,7 @@ func (s *MonadSubscriber) parseBlockHeader(result map[string]interface{}) *Block
 		return nil
 	}
 
-	number, err := parseHexToInt64(numberStr)
+	number, err := hexutil.DecodeInt64(numberStr)
 	if err != nil {
 		log.Printf("Failed to parse block number: %v", err)
 		return nil
@@ -463,7 +536,7 @@ func (s *MonadSubscriber) parseBlockHeader(result map[string]interface{}) *Block
 		return nil
 	}
 
-	timestamp, err := parseHexToInt64(timestampStr)
+	timestamp, err := hexutil.DecodeInt64(timestampStr)
 	if err != nil {
 		log.Printf("Failed to parse timestamp: %v", err)
 		return nil
@@ -480,7 +553,13 @@ func (s *MonadSubscriber) parseBlockHeader(result map[string]interface{}) *Block
 	// Parse gas used
 	gasUsed := int64(0)
 	if gasUsedStr, ok := result["gasUsed"].(string); ok {
-		gasUsed, _ = parseHexToInt64(gasUsedStr)
+		gasUsed = hexutil.Int64OrZero(gasUsedStr)
+	}
+
+	miner, _ := result["miner"].(string)
+	gasLimit := int64(0)
+	if gasLimitStr, ok := result["gasLimit"].(string); ok {
+		gasLimit = hexutil.Int64OrZero(gasLimitStr)
 	}
 
 	return &BlockHeader{
@@ -489,6 +568,8 @@ func (s *MonadSubscriber) parseBlockHeader(result map[string]interface{}) *Block
 		Timestamp:    timestamp,
 		Transactions: txCount,
 		GasUsed:      gasUsed,
+		GasLimit:     gasLimit,
+		Miner:        strings.ToLower(miner),
 	}
 }
 
@@ -519,6 +600,9 @@ func (s *MonadSubscriber) LogsChannel() <-chan *TransactionLog {
 // reconnect attempts to reconnect to the WebSocket
 func (s *MonadSubscriber) reconnect() error {
 	log.Println("Attempting to reconnect to Monad WebSocket...")
+	if store := GetHistoryStore(); store != nil {
+		store.Annotate("subscriber", "Subscriber reconnect", "Reconnecting to "+s.wsURL, "websocket")
+	}
 
 	s.mu.Lock()
 	if s.conn != nil {
@@ -526,6 +610,7 @@ func (s *MonadSubscriber) reconnect() error {
 	}
 	s.isConnected = false
 	s.mu.Unlock()
+	s.markDisconnected()
 
 	return s.Connect()
 }
@@ -538,28 +623,7 @@ func (s *MonadSubscriber) Close() error {
 	defer s.mu.Unlock()
 
 	if s.conn != nil {
-		// Unsubscribe from newHeads
-		if s.headsSubID != "" {
-			unsubMsg := map[string]interface{}{
-				"jsonrpc": "2.0",
-				"id":      3,
-				"method":  "eth_unsubscribe",
-				"params":  []string{s.headsSubID},
-			}
-			s.conn.WriteJSON(unsubMsg)
-		}
-
-		// Unsubscribe from monadLogs
-		if s.logsSubID != "" {
-			unsubMsg := map[string]interface{}{
-				"jsonrpc": "2.0",
-				"id":      4,
-				"method":  "eth_unsubscribe",
-				"params":  []string{s.logsSubID},
-			}
-			s.conn.WriteJSON(unsubMsg)
-		}
-
+		s.unsubscribeAll(s.conn)
 		return s.conn.Close()
 	}
 
@@ -571,7 +635,7 @@ func (h *BlockHeader) ToConsensusMetrics() *ConsensusMetrics {
 	return &ConsensusMetrics{
 		CurrentHeight:     h.Number,
 		LastBlockTime:     h.Timestamp,
-		BlockTime:         0.4,
+		BlockTime:         GetChainParams().BlockTime,
 		ValidatorCount:    100,
 		VotingPower:       1000000,
 		ParticipationRate: 0.9,
@@ -579,22 +643,18 @@ func (h *BlockHeader) ToConsensusMetrics() *ConsensusMetrics {
 }
 
 // ToExecutionMetrics converts BlockHeader to ExecutionMetrics
-// Note: Prioritizes Prometheus TPS for accuracy
+// Note: Prioritizes Prometheus TPS for accuracy (see defaultSourcePriorities)
 func (h *BlockHeader) ToExecutionMetrics() *ExecutionMetrics {
-	// Priority 1: Use Prometheus TPS (most accurate)
 	var tps float64
-	promCollector := GetPrometheusCollector()
-	if promCollector != nil && promCollector.IsHealthy() {
-		tps = promCollector.GetTPS()
-		// log.Printf("Using Prometheus TPS: %.2f", tps)
-	} else if monadSubscriber != nil {
-		// Priority 2: Use subscriber's average TPS
-		tps = monadSubscriber.calculateAverageTPS()
-		// log.Printf("Using subscriber average TPS: %.2f", tps)
-	} else {
-		// Priority 3: Fallback to instant TPS
-		tps = float64(h.Transactions) / 0.4
-		// log.Printf("Using instant TPS: %.2f", tps)
+	source, _ := GetSourceResolver().Resolve(MetricTPSAverage)
+	switch {
+	case source != nil && source.Name() == "prometheus_metrics":
+		tps = GetPrometheusCollector().GetTPS()
+	case source != nil && source.Name() == "block_estimation":
+		tps = GetSubscriber().calculateAverageTPS()
+	default:
+		// Fallback to instant TPS of this block
+		tps = float64(h.Transactions) / GetChainParams().BlockTime
 	}
 
 	return &ExecutionMetrics{
@@ -607,33 +667,51 @@ func (h *BlockHeader) ToExecutionMetrics() *ExecutionMetrics {
 	}
 }
 
-// Global subscriber instance
-var monadSubscriber *MonadSubscriber
-
-// InitializeSubscriber creates and starts the subscriber
-func InitializeSubscriber(wsURL string) error {
-	monadSubscriber = NewMonadSubscriber(wsURL)
-
-	if err := monadSubscriber.Connect(); err != nil {
+// StartSubscriber connects the dashboard's subscriber and starts processing
+// its blocks
+func (d *Dashboard) StartSubscriber(wsURL string) error {
+	s := NewMonadSubscriber(d, wsURL)
+	if err := s.Connect(); err != nil {
 		return err
 	}
 
+	d.mu.Lock()
+	d.subscriber = s
+	d.mu.Unlock()
+
 	// Start processing blocks
-	go processSubscribedBlocks()
+	go d.processSubscribedBlocks(s)
+
+	// Poll for heads whenever newHeads stalls
+	s.watchdog.Start()
 
 	return nil
 }
 
 // processSubscribedBlocks processes incoming blocks and updates metrics
-func processSubscribedBlocks() {
+func (d *Dashboard) processSubscribedBlocks(s *MonadSubscriber) {
 	for {
 		select {
-		case block := <-monadSubscriber.BlockChannel():
+		case block := <-s.BlockChannel():
 			if block != nil {
-				updateMetricsFromBlock(block)
+				signalBlockArrival()
+				d.updateMetricsFromBlock(block)
+				publishBusEvent(busBlocks, busEventBlock, strconv.FormatInt(block.Number, 10), *block)
+				if lt := GetLeaderTracker(); lt != nil {
+					lt.OnBlock(block)
+				}
+				if ft := d.FeeTracker(); ft != nil {
+					ft.Enqueue(block.Number)
+				}
+			}
+		case txLog := <-s.LogsChannel():
+			if txLog != nil {
+				d.processTransactionLog(txLog)
 			}
-		case err := <-monadSubscriber.errorChan:
+		case err := <-s.errorChan:
 			log.Printf("Subscriber error: %v", err)
+		case <-s.ctx.Done():
+			return
 		}
 	}
 }
@@ -659,27 +737,43 @@ func broadcastTransactionFromBlock(blockNumber int64, txHash string, txIndex int
 	broadcastToAllClients(msg)
 }
 
+// processTransactionLog runs a monadLogs entry through the log consumers
+func (d *Dashboard) processTransactionLog(txLog *TransactionLog) {
+	if watchlist := d.Watchlist(); watchlist != nil {
+		watchlist.MatchLog(txLog)
+	}
+	matchTenantWatchlists(txLog)
+	if indexer := GetTokenIndexer(); indexer != nil {
+		indexer.ProcessLog(txLog)
+	}
+	if index := GetAddressIndex(); index != nil {
+		index.ObserveLog(txLog)
+	}
+	if a := GetTxFlowAggregator(); a != nil {
+		a.AddLog(txLog)
+	}
+	broadcastFilteredTxFlow(txLog)
+	publishBusEvent(busTxLogs, busEventTxLog, txLog.TransactionHash, *txLog)
+}
+
 // broadcastTransactionLog sends transaction log to all connected WebSocket clients (DEPRECATED)
 func broadcastTransactionLog(txLog *TransactionLog) {
 	// This function is no longer used since we're not using logs subscription
 	// Kept for reference only
 }
 
-// updateMetricsFromBlock updates global metrics from a new block
-func updateMetricsFromBlock(block *BlockHeader) {
-	metricsMutex.Lock()
-	defer metricsMutex.Unlock()
+// updateMetricsFromBlock updates the metrics snapshot from a new block
+func (d *Dashboard) updateMetricsFromBlock(block *BlockHeader) {
+	d.metricsMu.Lock()
+	defer d.metricsMu.Unlock()
 
 	// Update consensus tracker with new block
-	consensusTracker := GetConsensusTracker()
-	if consensusTracker != nil {
-		consensusTracker.OnBlockProposed(uint64(block.Number), block.Hash, block.Transactions)
-	}
+	d.consensus.OnBlockProposed(uint64(block.Number), block.Hash, block.Transactions)
 
-	now := time.Now()
+	now := d.clock.Now()
 
 	// Get network metrics (these don't change per block)
-	network, _ := monadClient.GetNetworkMetrics()
+	network, _ := d.client.GetNetworkMetrics()
 	if network == nil {
 		network = &NetworkMetrics{
 			PeerCount:      50,
@@ -695,14 +789,14 @@ func updateMetricsFromBlock(block *BlockHeader) {
 	execution := block.ToExecutionMetrics()
 
 	// Update current metrics with real-time data
-	currentMetrics = MonadMetrics{
+	d.metrics = MonadMetrics{
 		Timestamp: now.Unix(),
 		NodeInfo: NodeInfo{
 			Version:  "0.1.0",
 			ChainID:  20143,
 			NodeName: getNodeName(),
 			Status:   "running",
-			Uptime:   int64(now.Sub(startTime).Seconds()),
+			Uptime:   int64(now.Sub(d.startTime).Seconds()),
 		},
 		Waterfall: generateWaterfallFromExecution(execution),
 		Consensus: *consensus,
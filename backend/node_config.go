@@ -1,49 +1,99 @@
 package main
 
 import (
+	"log"
 	"os"
 	"strings"
+	"sync"
+
+	"github.com/pelletier/go-toml/v2"
 )
 
-// Read node_name from node.toml configuration file
-func getNodeName() string {
-	// Try common paths for node.toml
-	paths := []string{
-		"/home/monad/monad-bft/config/node.toml",
-		"/root/.monad/config/node.toml",
-		"../monad-bft/config/node.toml",
-		"./config/node.toml",
-	}
+// nodeConfigPaths are tried in order when NODE_CONFIG_PATH is unset
+var nodeConfigPaths = []string{
+	"/home/monad/monad-bft/config/node.toml",
+	"/root/.monad/config/node.toml",
+	"../monad-bft/config/node.toml",
+	"./config/node.toml",
+}
 
-	var content []byte
-	var err error
+// zeroAddress is the placeholder beneficiary of nodes that do not collect rewards
+const zeroAddress = "0x0000000000000000000000000000000000000000"
 
-	for _, path := range paths {
-		content, err = os.ReadFile(path)
-		if err == nil {
-			break
-		}
-	}
+// NodeIdentity is the local node as described by its node.toml
+type NodeIdentity struct {
+	NodeName    string `json:"node_name"`
+	NetworkName string `json:"network_name,omitempty"`
+	Beneficiary string `json:"beneficiary,omitempty"` // Block reward recipient, the miner field of the node's blocks
+	P2PAddress  string `json:"p2p_address,omitempty"` // peer_discovery.self_address
+	ConfigPath  string `json:"config_path,omitempty"` // Empty when no node.toml was found
+}
 
-	if err != nil {
-		return "Monad Node"
-	}
+// nodeConfigFile holds the node.toml fields the dashboard uses
+type nodeConfigFile struct {
+	NodeName      string `toml:"node_name"`
+	NetworkName   string `toml:"network_name"`
+	Beneficiary   string `toml:"beneficiary"`
+	PeerDiscovery struct {
+		SelfAddress string `toml:"self_address"`
+	} `toml:"peer_discovery"`
+}
+
+var (
+	nodeIdentity     NodeIdentity
+	nodeIdentityOnce sync.Once
+)
+
+// GetNodeIdentity reads node.toml once, from NODE_CONFIG_PATH or the first
+// of the common locations that exists
+func GetNodeIdentity() NodeIdentity {
+	nodeIdentityOnce.Do(func() {
+		nodeIdentity = NodeIdentity{NodeName: "Monad Node"}
 
-	// Simple TOML parsing for node_name
-	lines := strings.Split(string(content), "\n")
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if strings.HasPrefix(line, "node_name") {
-			parts := strings.SplitN(line, "=", 2)
-			if len(parts) == 2 {
-				name := strings.TrimSpace(parts[1])
-				// Remove quotes
-				name = strings.Trim(name, `"`)
-				name = strings.Trim(name, `'`)
-				return name
+		paths := nodeConfigPaths
+		if path := os.Getenv("NODE_CONFIG_PATH"); path != "" {
+			paths = []string{path}
+		}
+		for _, path := range paths {
+			content, err := os.ReadFile(path)
+			if err != nil {
+				continue
+			}
+			var config nodeConfigFile
+			if err := toml.Unmarshal(content, &config); err != nil {
+				log.Printf("Invalid node config %s: %v", path, err)
+				return
+			}
+
+			nodeIdentity.ConfigPath = path
+			if config.NodeName != "" {
+				nodeIdentity.NodeName = config.NodeName
 			}
+			nodeIdentity.NetworkName = config.NetworkName
+			if beneficiary := strings.ToLower(config.Beneficiary); beneficiary != zeroAddress {
+				nodeIdentity.Beneficiary = beneficiary
+			}
+			nodeIdentity.P2PAddress = config.PeerDiscovery.SelfAddress
+			log.Printf("Node identity from %s: %q beneficiary %s", path, nodeIdentity.NodeName, nodeIdentity.Beneficiary)
+			return
 		}
-	}
+	})
+	return nodeIdentity
+}
 
-	return "Monad Node"
+// Read node_name from node.toml configuration file
+func getNodeName() string {
+	return GetNodeIdentity().NodeName
+}
+
+// selfValidatorAddress returns the local validator address and where it came
+// from: MONAD_VALIDATOR_ADDRESS, else the node.toml beneficiary
+func selfValidatorAddress() (string, string) {
+	if address := os.Getenv("MONAD_VALIDATOR_ADDRESS"); address != "" {
+		return strings.ToLower(address), "env"
+	}
+	if beneficiary := GetNodeIdentity().Beneficiary; beneficiary != "" {
+		return beneficiary, "node.toml"
+	}
+	return "", "unknown"
 }
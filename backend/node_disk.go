@@ -0,0 +1,334 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FilesystemUsage is the capacity of the filesystem holding a node path
+type FilesystemUsage struct {
+	TotalBytes     uint64  `json:"total_bytes"`
+	FreeBytes      uint64  `json:"free_bytes"`
+	AvailableBytes uint64  `json:"available_bytes"` // Free to unprivileged users
+	UsedPercent    float64 `json:"used_percent"`
+}
+
+// NodePathUsage is one measured node directory (triedb, ledger, wal, ...)
+type NodePathUsage struct {
+	Name            string           `json:"name"`
+	Path            string           `json:"path"`
+	SizeBytes       int64            `json:"size_bytes"`
+	SizeHuman       string           `json:"size_human"`
+	Files           int              `json:"files"`
+	Filesystem      *FilesystemUsage `json:"filesystem"`
+	GrowthPerDay    *float64         `json:"growth_bytes_per_day"` // nil until enough samples span the growth window
+	DaysUntilFull   *float64         `json:"days_until_full"`      // nil when not growing
+	ProjectedFullAt *int64           `json:"projected_full_at"`    // Unix seconds
+	State           string           `json:"state"`
+	Reason          string           `json:"reason,omitempty"`
+	Error           string           `json:"error,omitempty"`
+	MeasuredAt      int64            `json:"measured_at"`
+	MeasureMs       int64            `json:"measure_ms"`
+}
+
+// nodePath is a configured directory to measure
+type nodePath struct {
+	name string
+	path string
+}
+
+// NodeDiskMonitor periodically sizes the node's data directories and
+// projects when their filesystems fill up
+type NodeDiskMonitor struct {
+	paths        []nodePath
+	interval     time.Duration
+	growthWindow time.Duration
+	warnDays     float64
+	critDays     float64
+	warnPercent  float64
+	critPercent  float64
+
+	mu     sync.RWMutex
+	latest map[string]NodePathUsage
+}
+
+// Global node disk monitor instance
+var (
+	nodeDiskMonitor   *NodeDiskMonitor
+	nodeDiskMonitorMu sync.RWMutex
+)
+
+// InitializeNodeDiskMonitor reads NODE_DISK_PATHS (name=path,...) and starts
+// measuring every NODE_DISK_INTERVAL (default 5m). Nothing runs when no
+// paths are configured.
+func InitializeNodeDiskMonitor() *NodeDiskMonitor {
+	m := &NodeDiskMonitor{
+		interval:     retentionEnv("NODE_DISK_INTERVAL", 5*time.Minute),
+		growthWindow: retentionEnv("NODE_DISK_GROWTH_WINDOW", 24*time.Hour),
+		warnDays:     floatEnv("NODE_DISK_WARN_DAYS", 7),
+		critDays:     floatEnv("NODE_DISK_CRIT_DAYS", 1),
+		warnPercent:  floatEnv("NODE_DISK_WARN_PERCENT", 85),
+		critPercent:  floatEnv("NODE_DISK_CRIT_PERCENT", 95),
+		latest:       make(map[string]NodePathUsage),
+	}
+	for _, entry := range strings.Split(os.Getenv("NODE_DISK_PATHS"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, path, ok := strings.Cut(entry, "=")
+		name, path = strings.TrimSpace(name), strings.TrimSpace(path)
+		if !ok || name == "" || path == "" {
+			log.Printf("Invalid NODE_DISK_PATHS entry %q (want name=path)", entry)
+			continue
+		}
+		m.paths = append(m.paths, nodePath{name: name, path: path})
+	}
+	if len(m.paths) == 0 {
+		return nil
+	}
+	if m.interval <= 0 {
+		m.interval = 5 * time.Minute
+	}
+
+	nodeDiskMonitorMu.Lock()
+	nodeDiskMonitor = m
+	nodeDiskMonitorMu.Unlock()
+
+	go m.run()
+	log.Printf("Node disk monitor measuring %d paths every %s", len(m.paths), m.interval)
+	return m
+}
+
+// GetNodeDiskMonitor returns the global node disk monitor, nil when unconfigured
+func GetNodeDiskMonitor() *NodeDiskMonitor {
+	nodeDiskMonitorMu.RLock()
+	defer nodeDiskMonitorMu.RUnlock()
+	return nodeDiskMonitor
+}
+
+// floatEnv parses a float variable, falling back to def
+func floatEnv(name string, def float64) float64 {
+	value := os.Getenv(name)
+	if value == "" {
+		return def
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Printf("Invalid %s %q, using %g", name, value, def)
+		return def
+	}
+	return f
+}
+
+func (m *NodeDiskMonitor) run() {
+	m.measureAll(time.Now())
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		m.measureAll(now)
+	}
+}
+
+// measureAll sizes every path, records it to history and updates projections
+func (m *NodeDiskMonitor) measureAll(now time.Time) {
+	for _, p := range m.paths {
+		usage := m.measure(p, now)
+
+		m.mu.Lock()
+		previous := m.latest[p.name]
+		m.latest[p.name] = usage
+		m.mu.Unlock()
+
+		if usage.State != previous.State && usage.Reason != "" {
+			log.Printf("Node disk %s (%s): %s", p.name, p.path, usage.Reason)
+		}
+	}
+}
+
+// measure walks one path and classifies it against the thresholds
+func (m *NodeDiskMonitor) measure(p nodePath, now time.Time) NodePathUsage {
+	usage := NodePathUsage{Name: p.name, Path: p.path, MeasuredAt: now.Unix(), State: stateConnected}
+
+	start := time.Now()
+	size, files, err := directorySize(p.path)
+	usage.MeasureMs = time.Since(start).Milliseconds()
+	usage.SizeBytes, usage.Files, usage.SizeHuman = size, files, humanBytes(size)
+	if err != nil {
+		usage.State, usage.Error, usage.Reason = stateDown, err.Error(), "cannot measure: "+err.Error()
+		return usage
+	}
+	if fsUsage, err := filesystemUsage(p.path); err == nil {
+		usage.Filesystem = fsUsage
+	}
+
+	if store := GetHistoryStore(); store != nil {
+		series := "disk_" + p.name + "_bytes"
+		store.Record(series, now, float64(size))
+		if rate, ok := growthRate(store.Query(series, now.Add(-m.growthWindow), now, 0), m.interval); ok {
+			perDay := rate * 86400
+			usage.GrowthPerDay = &perDay
+			if perDay > 0 && usage.Filesystem != nil {
+				days := float64(usage.Filesystem.AvailableBytes) / perDay
+				fullAt := now.Add(time.Duration(days * float64(24*time.Hour))).Unix()
+				usage.DaysUntilFull, usage.ProjectedFullAt = &days, &fullAt
+			}
+		}
+	}
+
+	switch {
+	case usage.Filesystem != nil && usage.Filesystem.UsedPercent >= m.critPercent:
+		usage.State, usage.Reason = stateDown, fmt.Sprintf("filesystem %.1f%% full", usage.Filesystem.UsedPercent)
+	case usage.DaysUntilFull != nil && *usage.DaysUntilFull < m.critDays:
+		usage.State, usage.Reason = stateDown, fmt.Sprintf("disk full in ~%.1f days", *usage.DaysUntilFull)
+	case usage.Filesystem != nil && usage.Filesystem.UsedPercent >= m.warnPercent:
+		usage.State, usage.Reason = stateDegraded, fmt.Sprintf("filesystem %.1f%% full", usage.Filesystem.UsedPercent)
+	case usage.DaysUntilFull != nil && *usage.DaysUntilFull < m.warnDays:
+		usage.State, usage.Reason = stateDegraded, fmt.Sprintf("disk full in ~%.1f days", *usage.DaysUntilFull)
+	}
+	return usage
+}
+
+// directorySize sums the allocated size of every file under path
+func directorySize(path string) (int64, int, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	if !info.IsDir() {
+		return allocatedSize(info), 1, nil
+	}
+
+	var size int64
+	files := 0
+	err = filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Files vanish under a running node (compaction, WAL rotation)
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		size += allocatedSize(info)
+		files++
+		return nil
+	})
+	return size, files, err
+}
+
+// growthRate is the least-squares slope of the samples in bytes per second.
+// It needs samples spanning at least two measurement intervals.
+func growthRate(points []HistoryPoint, interval time.Duration) (float64, bool) {
+	if len(points) < 2 {
+		return 0, false
+	}
+	first, last := points[0].Timestamp, points[len(points)-1].Timestamp
+	if time.Duration(last-first)*time.Millisecond < 2*interval {
+		return 0, false
+	}
+
+	var sumX, sumY, sumXY, sumXX float64
+	n := float64(len(points))
+	for _, p := range points {
+		x := float64(p.Timestamp-first) / 1000
+		sumX += x
+		sumY += p.Value
+		sumXY += x * p.Value
+		sumXX += x * x
+	}
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 {
+		return 0, false
+	}
+	return (n*sumXY - sumX*sumY) / denominator, true
+}
+
+// Usage returns the latest measurement of every path in configured order
+func (m *NodeDiskMonitor) Usage() []NodePathUsage {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	usage := make([]NodePathUsage, 0, len(m.paths))
+	for _, p := range m.paths {
+		if u, ok := m.latest[p.name]; ok {
+			usage = append(usage, u)
+		}
+	}
+	return usage
+}
+
+// componentStates reports each measured path as a disk_<name> incident component
+func (m *NodeDiskMonitor) componentStates(states map[string]StateTransition) {
+	for _, usage := range m.Usage() {
+		states["disk_"+usage.Name] = StateTransition{State: usage.State, Reason: usage.Reason}
+	}
+}
+
+// NodeStorageResponse is the body of /api/v1/storage/node
+type NodeStorageResponse struct {
+	Paths         []NodePathUsage    `json:"paths"`
+	TotalBytes    int64              `json:"total_bytes"`
+	TotalHuman    string             `json:"total_human"`
+	DaysUntilFull *float64           `json:"days_until_full"` // Soonest projection, null when no path is growing
+	Interval      string             `json:"interval"`
+	GrowthWindow  string             `json:"growth_window"`
+	Thresholds    NodeDiskThresholds `json:"thresholds"`
+}
+
+// NodeDiskThresholds are the configured warning and critical levels
+type NodeDiskThresholds struct {
+	WarnDays    float64 `json:"warn_days"`
+	CritDays    float64 `json:"crit_days"`
+	WarnPercent float64 `json:"warn_percent"`
+	CritPercent float64 `json:"crit_percent"`
+}
+
+// handleNodeStorage reports node data directory sizes and fill projections
+func handleNodeStorage(c *gin.Context) {
+	m := GetNodeDiskMonitor()
+	if m == nil {
+		c.JSON(http.StatusServiceUnavailable, APIError{Error: "Node disk monitoring not configured (set NODE_DISK_PATHS)"})
+		return
+	}
+
+	paths := m.Usage()
+	var total int64
+	var soonest *float64
+	for _, usage := range paths {
+		total += usage.SizeBytes
+		if usage.DaysUntilFull != nil && (soonest == nil || *usage.DaysUntilFull < *soonest) {
+			soonest = usage.DaysUntilFull
+		}
+	}
+
+	c.JSON(http.StatusOK, NodeStorageResponse{
+		Paths:         paths,
+		TotalBytes:    total,
+		TotalHuman:    humanBytes(total),
+		DaysUntilFull: soonest,
+		Interval:      m.interval.String(),
+		GrowthWindow:  m.growthWindow.String(),
+		Thresholds: NodeDiskThresholds{
+			WarnDays:    m.warnDays,
+			CritDays:    m.critDays,
+			WarnPercent: m.warnPercent,
+			CritPercent: m.critPercent,
+		},
+	})
+}
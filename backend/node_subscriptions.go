@@ -0,0 +1,491 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// Node subscription states
+const (
+	subscriptionActive       = "active"
+	subscriptionPending      = "pending"      // eth_subscribe sent, awaiting the ID
+	subscriptionStale        = "stale"        // Silent past its stale window; being re-established
+	subscriptionFailed       = "failed"       // Rejected; retried with backoff
+	subscriptionDisconnected = "disconnected" // WebSocket down; restored on reconnect
+	subscriptionDisabled     = "disabled"
+)
+
+// subscriptionResponseTimeout is how long an eth_subscribe may go unanswered
+// before the socket is presumed dead
+const subscriptionResponseTimeout = 10 * time.Second
+
+// nodeSubscription is one eth_subscribe stream on the node WebSocket. Each is
+// re-established on its own when rejected or silent, so a failing monadLogs
+// does not interrupt newHeads; only a failed socket reconnects them all.
+type nodeSubscription struct {
+	name       string
+	params     []interface{}
+	required   bool          // Connect fails without it
+	staleAfter time.Duration // Resubscribe after this long without a notification
+	handle     func(msg map[string]interface{})
+	onActive   func(active bool)
+
+	id           string
+	status       string
+	requestID    int
+	requestedAt  time.Time
+	subscribedAt time.Time
+	lastMessage  time.Time
+	messages     int64
+	resubscribes int64
+	failures     int64
+	lastError    string
+	retryAt      time.Time
+	backoff      time.Duration
+}
+
+// SubscriptionStatus is one node subscription as reported by /api/v1/subscriptions
+type SubscriptionStatus struct {
+	Name           string `json:"name"`
+	Status         string `json:"status"`
+	SubscriptionID string `json:"subscription_id,omitempty"`
+	SubscribedAt   int64  `json:"subscribed_at,omitempty"`
+	LastMessage    int64  `json:"last_message,omitempty"`
+	Messages       int64  `json:"messages"`
+	Resubscribes   int64  `json:"resubscribes"`
+	Failures       int64  `json:"failures"`
+	LastError      string `json:"last_error,omitempty"`
+	RetryAt        int64  `json:"retry_at,omitempty"`
+	StaleAfter     string `json:"stale_after"`
+}
+
+// newNodeSubscriptions lists the subscriptions opened on every connection.
+// SUBSCRIPTION_STALE_AFTER (default 30s) is the silence after which a
+// block-paced stream is re-established; logs and pending transactions can be
+// legitimately quiet, so they get ten times as long.
+func newNodeSubscriptions(s *MonadSubscriber) []*nodeSubscription {
+	staleAfter := 30 * time.Second
+	if value := os.Getenv("SUBSCRIPTION_STALE_AFTER"); value != "" {
+		if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+			staleAfter = d
+		} else {
+			log.Printf("Invalid SUBSCRIPTION_STALE_AFTER %q, using %s", value, staleAfter)
+		}
+	}
+
+	pendingParams := []interface{}{"newPendingTransactions"}
+	if GetPendingTxMeter().fullBodies {
+		pendingParams = append(pendingParams, true)
+	}
+
+	subs := []*nodeSubscription{
+		{name: "newHeads", params: []interface{}{"newHeads"}, required: true, staleAfter: staleAfter, handle: s.handleBlockMessage},
+		// Contract events for watchlist matching; transaction flow still comes from newHeads
+		{name: "monadLogs", params: []interface{}{"monadLogs", map[string]interface{}{}}, staleAfter: 10 * staleAfter, handle: s.handleLogsMessage},
+		// Speculative/finalized commit states; without it the finalized head is polled over RPC
+		{name: "monadNewHeads", params: []interface{}{"monadNewHeads"}, staleAfter: staleAfter, handle: s.handleCommitStateMessage},
+		{name: "newPendingTransactions", params: pendingParams, staleAfter: 10 * staleAfter, handle: s.handlePendingTxMessage,
+			onActive: GetPendingTxMeter().SetSubscribed},
+	}
+	for _, sub := range subs {
+		sub.status = subscriptionDisconnected
+	}
+	if !pendingTxSubscriptionEnabled() {
+		subs[3].status = subscriptionDisabled
+	}
+	return subs
+}
+
+// writeJSON serializes writes to the node socket, which the subscription
+// watchdog shares with Close
+func (s *MonadSubscriber) writeJSON(conn *websocket.Conn, v interface{}) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+	return conn.WriteJSON(v)
+}
+
+// subscribeAll opens every enabled subscription on a new connection, reading
+// each confirmation before the listener starts. Only a socket error or a
+// rejected required subscription fails the connection.
+func (s *MonadSubscriber) subscribeAll(conn *websocket.Conn) error {
+	for _, sub := range s.subs {
+		s.subsMu.Lock()
+		if sub.status == subscriptionDisabled {
+			s.subsMu.Unlock()
+			continue
+		}
+		requestID := s.beginSubscribeLocked(sub)
+		s.subsMu.Unlock()
+
+		if err := s.writeJSON(conn, subscribeRequest(requestID, sub.params)); err != nil {
+			return fmt.Errorf("failed to send %s subscribe message: %w", sub.name, err)
+		}
+		// Notifications for the subscriptions already open may arrive
+		// first; wait for the response carrying this request's id
+		for s.awaitingResponse(sub, requestID) {
+			var msg map[string]interface{}
+			if err := conn.ReadJSON(&msg); err != nil {
+				return fmt.Errorf("failed to read %s subscription response: %w", sub.name, err)
+			}
+			s.handleMessage(msg)
+		}
+
+		s.subsMu.Lock()
+		rejected := sub.status == subscriptionFailed
+		reason := sub.lastError
+		s.subsMu.Unlock()
+		if rejected && sub.required {
+			return fmt.Errorf("%s subscription rejected: %s", sub.name, reason)
+		}
+	}
+	return nil
+}
+
+// awaitingResponse reports whether sub is still waiting for the response
+// to the eth_subscribe sent with requestID
+func (s *MonadSubscriber) awaitingResponse(sub *nodeSubscription, requestID int) bool {
+	s.subsMu.Lock()
+	defer s.subsMu.Unlock()
+	return sub.status == subscriptionPending && sub.requestID == requestID
+}
+
+// subscribeRequest builds an eth_subscribe call
+func subscribeRequest(requestID int, params []interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      requestID,
+		"method":  "eth_subscribe",
+		"params":  params,
+	}
+}
+
+// beginSubscribeLocked marks a subscription as awaiting its ID; subsMu must be held
+func (s *MonadSubscriber) beginSubscribeLocked(sub *nodeSubscription) int {
+	s.nextRequestID++
+	sub.requestID = s.nextRequestID
+	sub.requestedAt = time.Now()
+	sub.status = subscriptionPending
+	return sub.requestID
+}
+
+// handleSubscribeResponse applies an eth_subscribe response to the
+// subscription that requested it, reporting whether one did
+func (s *MonadSubscriber) handleSubscribeResponse(msg map[string]interface{}) bool {
+	requestID, ok := msg["id"].(float64)
+	if !ok {
+		return false
+	}
+
+	s.subsMu.Lock()
+	var sub *nodeSubscription
+	for _, candidate := range s.subs {
+		if candidate.status == subscriptionPending && candidate.requestID == int(requestID) {
+			sub = candidate
+			break
+		}
+	}
+	if sub == nil {
+		s.subsMu.Unlock()
+		return false // An eth_unsubscribe acknowledgement or a late response
+	}
+
+	now := time.Now()
+	id, _ := msg["result"].(string)
+	if errObj, rejected := msg["error"].(map[string]interface{}); rejected || id == "" {
+		message, _ := errObj["message"].(string)
+		if message == "" {
+			message = "no subscription ID returned"
+		}
+		s.failLocked(sub, message, now)
+		backoff := sub.backoff
+		s.subsMu.Unlock()
+		log.Printf("%s subscription rejected: %s (retrying in %s)", sub.name, message, backoff)
+		return true
+	}
+
+	resubscribed := !sub.subscribedAt.IsZero()
+	if resubscribed {
+		sub.resubscribes++
+	}
+	sub.id = id
+	sub.status = subscriptionActive
+	sub.subscribedAt = now
+	sub.backoff = 0
+	sub.lastError = ""
+	onActive := sub.onActive
+	s.subsMu.Unlock()
+
+	log.Printf("Successfully subscribed to %s with subscription ID: %s", sub.name, id)
+	if onActive != nil {
+		onActive(true)
+	}
+	if resubscribed {
+		if store := GetHistoryStore(); store != nil {
+			store.Annotate("subscriber", sub.name+" resubscribed", "Subscription re-established without reconnecting", "websocket")
+		}
+	}
+	return true
+}
+
+// failLocked records a failed subscribe and schedules a retry with
+// exponential backoff (5s up to 5m); subsMu must be held
+func (s *MonadSubscriber) failLocked(sub *nodeSubscription, reason string, now time.Time) {
+	sub.failures++
+	sub.status = subscriptionFailed
+	sub.lastError = reason
+	sub.id = ""
+	sub.backoff *= 2
+	if sub.backoff == 0 {
+		sub.backoff = 5 * time.Second
+	} else if sub.backoff > 5*time.Minute {
+		sub.backoff = 5 * time.Minute
+	}
+	sub.retryAt = now.Add(sub.backoff)
+}
+
+// handleMessage dispatches one message from the node socket, reporting
+// whether it answered a pending eth_subscribe
+func (s *MonadSubscriber) handleMessage(msg map[string]interface{}) bool {
+	if method, _ := msg["method"].(string); method == "eth_subscription" {
+		params, ok := msg["params"].(map[string]interface{})
+		if !ok {
+			return false
+		}
+		if subID, ok := params["subscription"].(string); ok {
+			s.routeNotification(subID, msg)
+		}
+		return false
+	}
+	return s.handleSubscribeResponse(msg)
+}
+
+// routeNotification hands an eth_subscription notification to its
+// subscription's handler
+func (s *MonadSubscriber) routeNotification(subID string, msg map[string]interface{}) {
+	s.subsMu.Lock()
+	var handle func(map[string]interface{})
+	for _, sub := range s.subs {
+		if sub.id == subID && sub.id != "" {
+			sub.messages++
+			sub.lastMessage = time.Now()
+			handle = sub.handle
+			break
+		}
+	}
+	s.subsMu.Unlock()
+
+	if handle != nil {
+		handle(msg)
+	}
+}
+
+// markDisconnected resets every subscription when the socket goes down
+func (s *MonadSubscriber) markDisconnected() {
+	s.subsMu.Lock()
+	var callbacks []func(bool)
+	for _, sub := range s.subs {
+		if sub.status == subscriptionDisabled {
+			continue
+		}
+		if sub.status == subscriptionActive && sub.onActive != nil {
+			callbacks = append(callbacks, sub.onActive)
+		}
+		sub.status = subscriptionDisconnected
+		sub.id = ""
+		sub.subscribedAt = time.Time{}
+	}
+	s.subsMu.Unlock()
+
+	for _, callback := range callbacks {
+		callback(false)
+	}
+}
+
+// watchSubscriptions re-establishes rejected and silent subscriptions on conn
+// until the connection is replaced. A resubscribe left unanswered means the
+// socket itself is dead, so it is closed and the listener reconnects.
+func (s *MonadSubscriber) watchSubscriptions(conn *websocket.Conn) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		s.mu.RLock()
+		current := s.conn == conn && s.isConnected
+		s.mu.RUnlock()
+		if !current {
+			return
+		}
+
+		if err := s.checkSubscriptions(conn, time.Now()); err != nil {
+			log.Printf("Monad WebSocket unresponsive (%v), reconnecting", err)
+			conn.Close()
+			return
+		}
+	}
+}
+
+// checkSubscriptions runs one watchdog pass over the subscriptions
+func (s *MonadSubscriber) checkSubscriptions(conn *websocket.Conn, now time.Time) error {
+	type resubscribe struct {
+		sub       *nodeSubscription
+		requestID int
+		unsubID   int
+		oldID     string
+		reason    string
+	}
+	var (
+		pending   []resubscribe
+		callbacks []func(bool)
+	)
+
+	s.subsMu.Lock()
+	for _, sub := range s.subs {
+		switch sub.status {
+		case subscriptionPending:
+			if now.Sub(sub.requestedAt) > subscriptionResponseTimeout {
+				s.failLocked(sub, "no response to eth_subscribe", now)
+				s.subsMu.Unlock()
+				return fmt.Errorf("%s subscribe unanswered", sub.name)
+			}
+		case subscriptionFailed:
+			if !now.Before(sub.retryAt) {
+				pending = append(pending, resubscribe{sub: sub, requestID: s.beginSubscribeLocked(sub), reason: "retrying after " + sub.lastError})
+			}
+		case subscriptionActive:
+			last := sub.lastMessage
+			if sub.subscribedAt.After(last) {
+				last = sub.subscribedAt
+			}
+			if sub.staleAfter > 0 && now.Sub(last) > sub.staleAfter {
+				oldID := sub.id
+				sub.status = subscriptionStale
+				sub.lastError = fmt.Sprintf("no notifications for %s", now.Sub(last).Round(time.Second))
+				if sub.onActive != nil {
+					callbacks = append(callbacks, sub.onActive)
+				}
+				s.nextRequestID++
+				unsubID := s.nextRequestID
+				pending = append(pending, resubscribe{sub: sub, requestID: s.beginSubscribeLocked(sub), unsubID: unsubID, oldID: oldID, reason: sub.lastError})
+			}
+		}
+	}
+	s.subsMu.Unlock()
+
+	for _, callback := range callbacks {
+		callback(false)
+	}
+	for _, r := range pending {
+		log.Printf("Resubscribing to %s: %s", r.sub.name, r.reason)
+		if r.oldID != "" {
+			// Best effort: the node may already have dropped it
+			s.writeJSON(conn, map[string]interface{}{
+				"jsonrpc": "2.0",
+				"id":      r.unsubID,
+				"method":  "eth_unsubscribe",
+				"params":  []string{r.oldID},
+			})
+		}
+		if err := s.writeJSON(conn, subscribeRequest(r.requestID, r.sub.params)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unsubscribeAll sends eth_unsubscribe for every active subscription
+func (s *MonadSubscriber) unsubscribeAll(conn *websocket.Conn) {
+	s.subsMu.Lock()
+	var ids []string
+	for _, sub := range s.subs {
+		if sub.id != "" {
+			ids = append(ids, sub.id)
+		}
+	}
+	s.subsMu.Unlock()
+
+	for i, id := range ids {
+		s.writeJSON(conn, map[string]interface{}{
+			"jsonrpc": "2.0",
+			"id":      i + 1,
+			"method":  "eth_unsubscribe",
+			"params":  []string{id},
+		})
+	}
+}
+
+// Subscriptions reports every node subscription's state
+func (s *MonadSubscriber) Subscriptions() []SubscriptionStatus {
+	s.subsMu.Lock()
+	defer s.subsMu.Unlock()
+	statuses := make([]SubscriptionStatus, 0, len(s.subs))
+	for _, sub := range s.subs {
+		status := SubscriptionStatus{
+			Name:           sub.name,
+			Status:         sub.status,
+			SubscriptionID: sub.id,
+			Messages:       sub.messages,
+			Resubscribes:   sub.resubscribes,
+			Failures:       sub.failures,
+			LastError:      sub.lastError,
+			StaleAfter:     sub.staleAfter.String(),
+		}
+		if !sub.subscribedAt.IsZero() {
+			status.SubscribedAt = sub.subscribedAt.Unix()
+		}
+		if !sub.lastMessage.IsZero() {
+			status.LastMessage = sub.lastMessage.Unix()
+		}
+		if sub.status == subscriptionFailed {
+			status.RetryAt = sub.retryAt.Unix()
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// SubscriptionsResponse is the body of /api/v1/subscriptions
+type SubscriptionsResponse struct {
+	URL           string               `json:"url"`
+	Connected     bool                 `json:"connected"`
+	Connects      int64                `json:"connects"`
+	Subscriptions []SubscriptionStatus `json:"subscriptions"`
+	LogsSampling  LogSamplingStats     `json:"logs_sampling"`
+	Enrichment    BlockEnrichmentStats `json:"enrichment"`
+	HeadWatchdog  HeadWatchdogStatus   `json:"head_watchdog"`
+}
+
+// handleSubscriptions reports the node WebSocket and each of its subscriptions
+func handleSubscriptions(c *gin.Context) {
+	subscriber := GetSubscriber()
+	if subscriber == nil {
+		c.JSON(http.StatusServiceUnavailable, APIError{Error: "WebSocket subscriber not running (polling mode)"})
+		return
+	}
+
+	subscriber.mu.RLock()
+	connects := subscriber.connects
+	subscriber.mu.RUnlock()
+
+	c.JSON(http.StatusOK, SubscriptionsResponse{
+		URL:           subscriber.wsURL,
+		Connected:     subscriber.IsConnected(),
+		Connects:      connects,
+		Subscriptions: subscriber.Subscriptions(),
+		LogsSampling:  subscriber.logSampler.Stats(len(subscriber.logsChan)),
+		Enrichment:    subscriber.enricher.Stats(),
+		HeadWatchdog:  subscriber.watchdog.Status(),
+	})
+}
@@ -0,0 +1,347 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"monad-dashboard/hexutil"
+)
+
+// pendingNonceTx is a pending transaction awaiting inclusion
+type pendingNonceTx struct {
+	hash      string
+	firstSeen time.Time
+}
+
+// nonceAccount holds one sender's pending transactions by nonce
+type nonceAccount struct {
+	pending        map[uint64]pendingNonceTx
+	confirmed      uint64 // Next nonce per eth_getTransactionCount
+	confirmedKnown bool
+	checkedAt      time.Time
+}
+
+// NonceGapTracker follows pending transaction bodies per sender to find
+// transactions stuck behind a missing nonce. Pending bodies come from
+// newPendingTransactions with PENDING_TX_BODIES=true; the busiest senders'
+// confirmed nonces are polled with eth_getTransactionCount, and included
+// transactions leave the pool as blocks arrive.
+type NonceGapTracker struct {
+	mu       sync.Mutex
+	accounts map[string]*nonceAccount
+	byHash   map[string]string // Pending hash -> sender
+	txCount  int
+
+	maxTxs      int           // NONCE_GAP_MAX_TXS
+	hotAccounts int           // NONCE_GAP_ACCOUNTS: senders polled per refresh
+	ttl         time.Duration // NONCE_GAP_TTL: pending transactions are forgotten after this
+}
+
+// Global nonce gap tracker
+var (
+	nonceGapTracker     *NonceGapTracker
+	nonceGapTrackerOnce sync.Once
+)
+
+// GetNonceGapTracker returns the global nonce gap tracker, polling
+// confirmed nonces every 10s once created
+func GetNonceGapTracker() *NonceGapTracker {
+	nonceGapTrackerOnce.Do(func() {
+		nonceGapTracker = &NonceGapTracker{
+			accounts:    make(map[string]*nonceAccount),
+			byHash:      make(map[string]string),
+			maxTxs:      50000,
+			hotAccounts: 100,
+			ttl:         time.Hour,
+		}
+		if n, ok := positiveIntEnv("NONCE_GAP_MAX_TXS"); ok {
+			nonceGapTracker.maxTxs = int(n)
+		}
+		if n, ok := positiveIntEnv("NONCE_GAP_ACCOUNTS"); ok {
+			nonceGapTracker.hotAccounts = int(n)
+		}
+		if value := os.Getenv("NONCE_GAP_TTL"); value != "" {
+			if d, err := time.ParseDuration(value); err == nil && d > 0 {
+				nonceGapTracker.ttl = d
+			} else {
+				log.Printf("Invalid NONCE_GAP_TTL %q, using %s", value, nonceGapTracker.ttl)
+			}
+		}
+		go nonceGapTracker.refreshLoop(10 * time.Second)
+	})
+	return nonceGapTracker
+}
+
+// OnPendingTx records a pending transaction body
+func (t *NonceGapTracker) OnPendingTx(hash string, tx map[string]interface{}) {
+	from, _ := tx["from"].(string)
+	nonceHex, _ := tx["nonce"].(string)
+	if from == "" || nonceHex == "" {
+		return
+	}
+	nonce, err := hexutil.DecodeUint64(nonceHex)
+	if err != nil {
+		return
+	}
+	from = strings.ToLower(from)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	account := t.accounts[from]
+	if account == nil {
+		account = &nonceAccount{pending: make(map[uint64]pendingNonceTx)}
+		t.accounts[from] = account
+	}
+	if account.confirmedKnown && nonce < account.confirmed {
+		return // Already used
+	}
+	if previous, ok := account.pending[nonce]; ok {
+		// A replacement keeps the original age: the nonce has been waiting since then
+		delete(t.byHash, previous.hash)
+		account.pending[nonce] = pendingNonceTx{hash: hash, firstSeen: previous.firstSeen}
+		t.byHash[hash] = from
+		return
+	}
+	if t.txCount >= t.maxTxs {
+		return
+	}
+	account.pending[nonce] = pendingNonceTx{hash: hash, firstSeen: time.Now()}
+	t.byHash[hash] = from
+	t.txCount++
+}
+
+// OnIncluded drops an included transaction and every earlier nonce of its sender
+func (t *NonceGapTracker) OnIncluded(hash string) {
+	hash = normalizeTxHash(hash)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	from, ok := t.byHash[hash]
+	if !ok {
+		return
+	}
+	account := t.accounts[from]
+	for nonce, tx := range account.pending {
+		if tx.hash == hash {
+			account.confirmed = nonce + 1
+			account.confirmedKnown = true
+			break
+		}
+	}
+	t.pruneLocked(from, account, time.Now())
+}
+
+// pruneLocked removes used nonces and expired transactions; caller holds t.mu
+func (t *NonceGapTracker) pruneLocked(from string, account *nonceAccount, now time.Time) {
+	for nonce, tx := range account.pending {
+		if (account.confirmedKnown && nonce < account.confirmed) || now.Sub(tx.firstSeen) > t.ttl {
+			delete(account.pending, nonce)
+			delete(t.byHash, tx.hash)
+			t.txCount--
+		}
+	}
+	if len(account.pending) == 0 {
+		delete(t.accounts, from)
+	}
+}
+
+// refreshLoop periodically re-reads the confirmed nonce of the busiest senders
+func (t *NonceGapTracker) refreshLoop(every time.Duration) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+	for range ticker.C {
+		t.refresh()
+	}
+}
+
+// refresh expires old transactions and polls the confirmed nonce of the
+// senders with the most pending transactions
+func (t *NonceGapTracker) refresh() {
+	now := time.Now()
+
+	t.mu.Lock()
+	type candidate struct {
+		address string
+		pending int
+	}
+	candidates := make([]candidate, 0, len(t.accounts))
+	for from, account := range t.accounts {
+		t.pruneLocked(from, account, now)
+		if len(account.pending) > 0 {
+			candidates = append(candidates, candidate{from, len(account.pending)})
+		}
+	}
+	t.mu.Unlock()
+
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].pending > candidates[j].pending })
+	if len(candidates) > t.hotAccounts {
+		candidates = candidates[:t.hotAccounts]
+	}
+
+	for _, c := range candidates {
+		var count string
+		if err := GetMonadClient().callResult("eth_getTransactionCount", []interface{}{c.address, "latest"}, &count); err != nil {
+			log.Printf("Nonce gap check for %s failed: %v", c.address, err)
+			return
+		}
+		confirmed, err := hexutil.DecodeUint64(count)
+		if err != nil {
+			continue
+		}
+
+		t.mu.Lock()
+		if account := t.accounts[c.address]; account != nil {
+			account.confirmed = confirmed
+			account.confirmedKnown = true
+			account.checkedAt = now
+			t.pruneLocked(c.address, account, now)
+		}
+		t.mu.Unlock()
+	}
+}
+
+// NonceGap is a range of missing nonces, inclusive
+type NonceGap struct {
+	From uint64 `json:"from"`
+	To   uint64 `json:"to"`
+}
+
+// NonceGapAccount is one sender's pending transactions
+type NonceGapAccount struct {
+	Address          string     `json:"address"`
+	ConfirmedNonce   *uint64    `json:"confirmed_nonce"` // Next nonce to be included; null before the sender was polled
+	CheckedAt        int64      `json:"checked_at,omitempty"`
+	Pending          int        `json:"pending"`
+	Executable       int        `json:"executable"` // Contiguous from the confirmed nonce
+	Stuck            int        `json:"stuck"`      // Behind a missing nonce
+	MissingNonces    uint64     `json:"missing_nonces"`
+	Gaps             []NonceGap `json:"gaps"`
+	OldestStuckAge   float64    `json:"oldest_stuck_age_seconds"`
+	OldestPendingAge float64    `json:"oldest_pending_age_seconds"`
+	StuckHashes      []string   `json:"stuck_hashes"` // Lowest nonce first, at most 10
+}
+
+// analyzeLocked finds the gaps of one sender; caller holds t.mu
+func (account *nonceAccount) analyzeLocked(address string, now time.Time) NonceGapAccount {
+	result := NonceGapAccount{Address: address, Pending: len(account.pending), Gaps: []NonceGap{}, StuckHashes: []string{}}
+	nonces := make([]uint64, 0, len(account.pending))
+	for nonce := range account.pending {
+		nonces = append(nonces, nonce)
+	}
+	sort.Slice(nonces, func(i, j int) bool { return nonces[i] < nonces[j] })
+
+	// Without the confirmed nonce, only gaps between pending nonces show
+	expected := nonces[0]
+	if account.confirmedKnown {
+		confirmed := account.confirmed
+		result.ConfirmedNonce = &confirmed
+		result.CheckedAt = account.checkedAt.Unix()
+		expected = confirmed
+	}
+
+	stuck := false
+	for _, nonce := range nonces {
+		tx := account.pending[nonce]
+		age := now.Sub(tx.firstSeen).Seconds()
+		if age > result.OldestPendingAge {
+			result.OldestPendingAge = age
+		}
+		if nonce > expected {
+			result.Gaps = append(result.Gaps, NonceGap{From: expected, To: nonce - 1})
+			result.MissingNonces += nonce - expected
+			stuck = true
+		}
+		expected = nonce + 1
+
+		if !stuck {
+			result.Executable++
+			continue
+		}
+		result.Stuck++
+		if age > result.OldestStuckAge {
+			result.OldestStuckAge = age
+		}
+		if len(result.StuckHashes) < 10 {
+			result.StuckHashes = append(result.StuckHashes, tx.hash)
+		}
+	}
+	return result
+}
+
+// NonceGapsResponse is the body of /api/v1/mempool/nonce-gaps
+type NonceGapsResponse struct {
+	Enabled         bool              `json:"enabled"` // Pending bodies are needed (PENDING_TX_BODIES=true)
+	AccountsTracked int               `json:"accounts_tracked"`
+	PendingTracked  int               `json:"pending_tracked"`
+	StuckAccounts   int               `json:"stuck_accounts"`
+	StuckTxs        int               `json:"stuck_txs"`
+	Accounts        []NonceGapAccount `json:"accounts"` // Most stuck first
+}
+
+// Report lists senders with transactions stuck behind nonce gaps, or every
+// sender with pending transactions when all is set
+func (t *NonceGapTracker) Report(all bool) NonceGapsResponse {
+	now := time.Now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	result := NonceGapsResponse{
+		AccountsTracked: len(t.accounts),
+		PendingTracked:  t.txCount,
+		Accounts:        []NonceGapAccount{},
+	}
+	for address, account := range t.accounts {
+		if len(account.pending) == 0 {
+			continue
+		}
+		analysis := account.analyzeLocked(address, now)
+		if analysis.Stuck > 0 {
+			result.StuckAccounts++
+			result.StuckTxs += analysis.Stuck
+		}
+		if all || analysis.Stuck > 0 {
+			result.Accounts = append(result.Accounts, analysis)
+		}
+	}
+	sort.Slice(result.Accounts, func(i, j int) bool {
+		a, b := result.Accounts[i], result.Accounts[j]
+		if a.Stuck != b.Stuck {
+			return a.Stuck > b.Stuck
+		}
+		if a.OldestStuckAge != b.OldestStuckAge {
+			return a.OldestStuckAge > b.OldestStuckAge
+		}
+		return a.Address < b.Address
+	})
+	return result
+}
+
+// handleNonceGaps reports senders whose pending transactions wait behind a
+// missing nonce (?limit= accounts, default 50; ?all=true includes senders
+// without gaps)
+func handleNonceGaps(c *gin.Context) {
+	limit := 50
+	if value := c.Query("limit"); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n >= 0 {
+			limit = n
+		}
+	}
+
+	result := GetNonceGapTracker().Report(c.Query("all") == "true")
+	result.Enabled = pendingTxSubscriptionEnabled() && GetPendingTxMeter().fullBodies
+	if len(result.Accounts) > limit {
+		result.Accounts = result.Accounts[:limit]
+	}
+	c.JSON(http.StatusOK, result)
+}
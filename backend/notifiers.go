@@ -0,0 +1,553 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pelletier/go-toml/v2"
+)
+
+// Default message templates, executed with an AlertMessage
+const (
+	defaultFiredTemplate    = `{{if eq .State "down"}}🔴{{else}}🟠{{end}} [{{.Chain}}] {{.Component}} is {{.State}}{{if .Reason}}: {{.Reason}}{{end}}`
+	defaultResolvedTemplate = `✅ [{{.Chain}}] {{.Component}} recovered{{if .Duration}} after {{.Duration}}{{end}}`
+)
+
+// NotifierConfig is the NOTIFIER_CONFIG file: chat services that receive
+// incident and recovery messages, their templates and rate limit
+type NotifierConfig struct {
+	Telegram   *TelegramConfig   `toml:"telegram"`
+	Discord    *DiscordConfig    `toml:"discord"`
+	Templates  NotifierTemplates `toml:"templates"`
+	RateLimit  NotifierRateLimit `toml:"rate_limit"`
+	Components []string          `toml:"components"` // Only alert on these components (default all)
+}
+
+// TelegramConfig sends messages with the Bot API. The token may be left
+// out of the file and set as TELEGRAM_BOT_TOKEN instead.
+type TelegramConfig struct {
+	BotToken string `toml:"bot_token"`
+	ChatID   string `toml:"chat_id"`
+	APIURL   string `toml:"api_url"` // Default https://api.telegram.org
+}
+
+// DiscordConfig posts to a channel as a bot. The token may be left out of
+// the file and set as DISCORD_BOT_TOKEN instead.
+type DiscordConfig struct {
+	BotToken  string `toml:"bot_token"`
+	ChannelID string `toml:"channel_id"`
+	APIURL    string `toml:"api_url"` // Default https://discord.com/api/v10
+}
+
+// NotifierTemplates are text/template sources executed with an AlertMessage
+type NotifierTemplates struct {
+	Fired    string `toml:"fired"`
+	Resolved string `toml:"resolved"`
+}
+
+// NotifierRateLimit caps messages per notifier; alerts over the limit are
+// suppressed and counted in the next message sent
+type NotifierRateLimit struct {
+	PerMinute int `toml:"per_minute"` // Default 10
+	Burst     int `toml:"burst"`      // Default 5
+}
+
+// AlertMessage is the data available to message templates
+type AlertMessage struct {
+	AlertEvent
+	Chain    string
+	Fired    bool
+	Duration string // How long the component was unhealthy, on recovery
+}
+
+// Notifier sends a formatted message to a chat service
+type Notifier interface {
+	Name() string
+	Send(text string) error
+}
+
+// errRateLimited is returned by a notifier the service asked to back off
+type errRateLimited struct {
+	retryAfter time.Duration
+}
+
+func (e errRateLimited) Error() string {
+	return fmt.Sprintf("rate limited by the service, retry after %s", e.retryAfter)
+}
+
+// TelegramNotifier sends messages to a Telegram chat
+type TelegramNotifier struct {
+	config TelegramConfig
+	client *http.Client
+}
+
+func (t *TelegramNotifier) Name() string { return "telegram" }
+
+// Send calls sendMessage
+func (t *TelegramNotifier) Send(text string) error {
+	body, _ := json.Marshal(map[string]interface{}{
+		"chat_id":                  t.config.ChatID,
+		"text":                     text,
+		"disable_web_page_preview": true,
+	})
+	resp, err := t.client.Post(t.config.APIURL+"/bot"+t.config.BotToken+"/sendMessage", "application/json", bytes.NewReader(body))
+	if err != nil {
+		// The error quotes the URL, which contains the token
+		return fmt.Errorf("sendMessage failed: %v", strings.ReplaceAll(err.Error(), t.config.BotToken, "***"))
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		OK          bool   `json:"ok"`
+		Description string `json:"description"`
+		Parameters  struct {
+			RetryAfter int `json:"retry_after"`
+		} `json:"parameters"`
+	}
+	json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&result)
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return errRateLimited{retryAfter: time.Duration(result.Parameters.RetryAfter) * time.Second}
+	}
+	if !result.OK {
+		return fmt.Errorf("sendMessage: %s %s", resp.Status, result.Description)
+	}
+	return nil
+}
+
+// DiscordNotifier posts messages to a Discord channel
+type DiscordNotifier struct {
+	config DiscordConfig
+	client *http.Client
+}
+
+func (d *DiscordNotifier) Name() string { return "discord" }
+
+// Send creates a channel message
+func (d *DiscordNotifier) Send(text string) error {
+	body, _ := json.Marshal(map[string]interface{}{
+		"content":          text,
+		"allowed_mentions": map[string]interface{}{"parse": []string{}},
+	})
+	req, err := http.NewRequest(http.MethodPost, d.config.APIURL+"/channels/"+d.config.ChannelID+"/messages", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bot "+d.config.BotToken)
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		var result struct {
+			RetryAfter float64 `json:"retry_after"` // Seconds
+		}
+		json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&result)
+		return errRateLimited{retryAfter: time.Duration(result.RetryAfter * float64(time.Second))}
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("create message: %s %s", resp.Status, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
+
+// NotifierStats counts one notifier's messages since startup
+type NotifierStats struct {
+	Sent       int64      `json:"sent"`
+	Failed     int64      `json:"failed"`
+	Suppressed int64      `json:"suppressed"` // Over the rate limit or queue full
+	LastSentAt *time.Time `json:"last_sent_at,omitempty"`
+	LastError  string     `json:"last_error,omitempty"`
+}
+
+// notifierChannel rate limits and queues messages to one notifier
+type notifierChannel struct {
+	notifier Notifier
+	queue    chan string
+
+	mu         sync.Mutex
+	tokens     float64
+	refill     float64 // Tokens per second
+	burst      float64
+	lastRefill time.Time
+	pending    int64 // Suppressed since the last message sent
+	stats      NotifierStats
+	stopped    bool // Replaced by a reload; the queue is closed
+}
+
+// allow takes a token from the bucket, reporting whether one was available
+func (ch *notifierChannel) allow(now time.Time) bool {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	ch.tokens += now.Sub(ch.lastRefill).Seconds() * ch.refill
+	if ch.tokens > ch.burst {
+		ch.tokens = ch.burst
+	}
+	ch.lastRefill = now
+	if ch.tokens < 1 {
+		ch.stats.Suppressed++
+		ch.pending++
+		return false
+	}
+	ch.tokens--
+	return true
+}
+
+// offer queues a message if the rate limit allows it
+func (ch *notifierChannel) offer(text string) {
+	if !ch.allow(time.Now()) {
+		return
+	}
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	if ch.stopped {
+		return
+	}
+	select {
+	case ch.queue <- text:
+	default:
+		ch.stats.Suppressed++
+		ch.pending++
+	}
+}
+
+// stop closes the queue; run returns once the queued messages are sent
+func (ch *notifierChannel) stop() {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	if !ch.stopped {
+		ch.stopped = true
+		close(ch.queue)
+	}
+}
+
+// run sends queued messages, waiting once when the service rate limits us
+func (ch *notifierChannel) run() {
+	for text := range ch.queue {
+		ch.mu.Lock()
+		if ch.pending > 0 {
+			text += fmt.Sprintf("\n(%d more alerts suppressed by the rate limit)", ch.pending)
+			ch.pending = 0
+		}
+		ch.mu.Unlock()
+
+		err := ch.notifier.Send(text)
+		if limited, ok := err.(errRateLimited); ok && limited.retryAfter <= time.Minute {
+			time.Sleep(limited.retryAfter)
+			err = ch.notifier.Send(text)
+		}
+
+		ch.mu.Lock()
+		if err != nil {
+			ch.stats.Failed++
+			ch.stats.LastError = err.Error()
+			log.Printf("Notifier %s: %v", ch.notifier.Name(), err)
+		} else {
+			now := time.Now().UTC()
+			ch.stats.Sent++
+			ch.stats.LastSentAt = &now
+		}
+		ch.mu.Unlock()
+	}
+}
+
+// AlertNotifiers formats incident and recovery alerts with the configured
+// templates and sends them to every configured chat service
+type AlertNotifiers struct {
+	channels   []*notifierChannel
+	fired      *template.Template
+	resolved   *template.Template
+	components []string
+
+	mu      sync.Mutex
+	firedAt map[string]time.Time // Component → start of its open alert
+}
+
+// Global notifiers instance
+var (
+	alertNotifiers   *AlertNotifiers
+	alertNotifiersMu sync.RWMutex
+)
+
+// InitializeNotifiers loads NOTIFIER_CONFIG (TOML) and starts a sender per
+// configured service
+func InitializeNotifiers() *AlertNotifiers {
+	path := os.Getenv("NOTIFIER_CONFIG")
+	if path == "" {
+		return nil
+	}
+	n, err := loadAlertNotifiers(path)
+	if err != nil {
+		log.Printf("Invalid notifier config %s: %v", path, err)
+		return nil
+	}
+
+	alertNotifiersMu.Lock()
+	alertNotifiers = n
+	alertNotifiersMu.Unlock()
+
+	n.start()
+	return n
+}
+
+// loadAlertNotifiers reads and validates a NOTIFIER_CONFIG file
+func loadAlertNotifiers(path string) (*AlertNotifiers, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var config NotifierConfig
+	if err := toml.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+	return newAlertNotifiers(config)
+}
+
+// start runs a sender per configured service
+func (n *AlertNotifiers) start() {
+	for _, ch := range n.channels {
+		go ch.run()
+		log.Printf("✅ Alert notifier: %s", ch.notifier.Name())
+	}
+}
+
+// ReloadNotifiers re-reads NOTIFIER_CONFIG and swaps in the new notifiers
+// once the whole file validates. Open alerts carry over, so their
+// recoveries are still announced; the previous senders finish their queues.
+func ReloadNotifiers() error {
+	path := os.Getenv("NOTIFIER_CONFIG")
+	n, err := loadAlertNotifiers(path)
+	if err != nil {
+		return err
+	}
+
+	alertNotifiersMu.Lock()
+	previous := alertNotifiers
+	if previous != nil {
+		previous.mu.Lock()
+		for component, at := range previous.firedAt {
+			n.firedAt[component] = at
+		}
+		previous.mu.Unlock()
+	}
+	alertNotifiers = n
+	alertNotifiersMu.Unlock()
+
+	n.start()
+	if previous != nil {
+		for _, ch := range previous.channels {
+			ch.stop()
+		}
+	}
+	return nil
+}
+
+// GetNotifiers returns the global notifiers, nil when NOTIFIER_CONFIG is unset
+func GetNotifiers() *AlertNotifiers {
+	alertNotifiersMu.RLock()
+	defer alertNotifiersMu.RUnlock()
+	return alertNotifiers
+}
+
+// newAlertNotifiers validates a config and builds its notifiers
+func newAlertNotifiers(config NotifierConfig) (*AlertNotifiers, error) {
+	if config.Templates.Fired == "" {
+		config.Templates.Fired = defaultFiredTemplate
+	}
+	if config.Templates.Resolved == "" {
+		config.Templates.Resolved = defaultResolvedTemplate
+	}
+	fired, err := template.New("fired").Parse(config.Templates.Fired)
+	if err != nil {
+		return nil, fmt.Errorf("fired template: %v", err)
+	}
+	resolved, err := template.New("resolved").Parse(config.Templates.Resolved)
+	if err != nil {
+		return nil, fmt.Errorf("resolved template: %v", err)
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	var notifiers []Notifier
+	if t := config.Telegram; t != nil {
+		if t.BotToken == "" {
+			t.BotToken = os.Getenv("TELEGRAM_BOT_TOKEN")
+		}
+		if t.APIURL == "" {
+			t.APIURL = "https://api.telegram.org"
+		}
+		if t.BotToken == "" || t.ChatID == "" {
+			return nil, fmt.Errorf("telegram needs bot_token and chat_id")
+		}
+		notifiers = append(notifiers, &TelegramNotifier{config: *t, client: client})
+	}
+	if d := config.Discord; d != nil {
+		if d.BotToken == "" {
+			d.BotToken = os.Getenv("DISCORD_BOT_TOKEN")
+		}
+		if d.APIURL == "" {
+			d.APIURL = "https://discord.com/api/v10"
+		}
+		if d.BotToken == "" || d.ChannelID == "" {
+			return nil, fmt.Errorf("discord needs bot_token and channel_id")
+		}
+		notifiers = append(notifiers, &DiscordNotifier{config: *d, client: client})
+	}
+	if len(notifiers) == 0 {
+		return nil, fmt.Errorf("no [telegram] or [discord] section")
+	}
+
+	perMinute, burst := config.RateLimit.PerMinute, config.RateLimit.Burst
+	if perMinute <= 0 {
+		perMinute = 10
+	}
+	if burst <= 0 {
+		burst = 5
+	}
+
+	n := &AlertNotifiers{
+		fired:      fired,
+		resolved:   resolved,
+		components: config.Components,
+		firedAt:    make(map[string]time.Time),
+	}
+	for _, notifier := range notifiers {
+		n.channels = append(n.channels, &notifierChannel{
+			notifier:   notifier,
+			queue:      make(chan string, 64),
+			tokens:     float64(burst),
+			refill:     float64(perMinute) / 60,
+			burst:      float64(burst),
+			lastRefill: time.Now(),
+		})
+	}
+	return n, nil
+}
+
+// notifyAlert sends an alert.fired or alert.resolved event to the chat
+// services, if configured
+func notifyAlert(event string, alert AlertEvent) {
+	if n := GetNotifiers(); n != nil {
+		n.Notify(event, alert)
+	}
+}
+
+// Notify formats an alert and queues it for every notifier. A recovery is
+// only announced for a component whose alert was sent.
+func (n *AlertNotifiers) Notify(event string, alert AlertEvent) {
+	if len(n.components) > 0 && !containsString(n.components, alert.Component) {
+		return
+	}
+
+	msg := AlertMessage{AlertEvent: alert, Chain: primaryChainName(), Fired: event == webhookAlertFired}
+	tmpl := n.fired
+
+	n.mu.Lock()
+	if msg.Fired {
+		if _, open := n.firedAt[alert.Component]; !open {
+			n.firedAt[alert.Component] = alert.At
+		}
+	} else {
+		started, open := n.firedAt[alert.Component]
+		if !open {
+			n.mu.Unlock()
+			return
+		}
+		delete(n.firedAt, alert.Component)
+		msg.Duration = alert.At.Sub(started).Round(time.Second).String()
+		tmpl = n.resolved
+	}
+	n.mu.Unlock()
+
+	var text bytes.Buffer
+	if err := tmpl.Execute(&text, msg); err != nil {
+		log.Printf("Notifier template for %s: %v", event, err)
+		return
+	}
+	n.broadcast(text.String())
+}
+
+// broadcast queues a message for every notifier
+func (n *AlertNotifiers) broadcast(text string) {
+	for _, ch := range n.channels {
+		ch.offer(text)
+	}
+}
+
+// NotifierStatus is one configured chat service
+type NotifierStatus struct {
+	Name  string        `json:"name"`
+	Stats NotifierStats `json:"stats"`
+}
+
+// Statuses returns every notifier's counters
+func (n *AlertNotifiers) Statuses() []NotifierStatus {
+	statuses := make([]NotifierStatus, 0, len(n.channels))
+	for _, ch := range n.channels {
+		ch.mu.Lock()
+		statuses = append(statuses, NotifierStatus{Name: ch.notifier.Name(), Stats: ch.stats})
+		ch.mu.Unlock()
+	}
+	return statuses
+}
+
+// NotifiersResponse is the body of /api/v1/notifiers
+type NotifiersResponse struct {
+	Enabled    bool             `json:"enabled"`
+	Notifiers  []NotifierStatus `json:"notifiers"`
+	OpenAlerts []string         `json:"open_alerts"` // Components awaiting a recovery message
+}
+
+// NotifierTestResponse names the notifiers a test message was queued for
+type NotifierTestResponse struct {
+	Queued []string `json:"queued"`
+}
+
+// handleNotifiers reports the configured chat services
+func handleNotifiers(c *gin.Context) {
+	n := GetNotifiers()
+	if n == nil {
+		c.JSON(http.StatusOK, NotifiersResponse{Notifiers: []NotifierStatus{}, OpenAlerts: []string{}})
+		return
+	}
+
+	n.mu.Lock()
+	open := make([]string, 0, len(n.firedAt))
+	for component := range n.firedAt {
+		open = append(open, component)
+	}
+	n.mu.Unlock()
+	sort.Strings(open)
+
+	c.JSON(http.StatusOK, NotifiersResponse{Enabled: true, Notifiers: n.Statuses(), OpenAlerts: open})
+}
+
+// handleNotifiersTest sends a test message through every notifier
+func handleNotifiersTest(c *gin.Context) {
+	n := GetNotifiers()
+	if n == nil {
+		c.JSON(http.StatusNotFound, APIError{Error: "Notifiers not configured (NOTIFIER_CONFIG not set)"})
+		return
+	}
+	n.broadcast(fmt.Sprintf("🔔 [%s] Test message from the Monad dashboard", primaryChainName()))
+
+	queued := make([]string, 0, len(n.channels))
+	for _, ch := range n.channels {
+		queued = append(queued, ch.notifier.Name())
+	}
+	c.JSON(http.StatusAccepted, NotifierTestResponse{Queued: queued})
+}
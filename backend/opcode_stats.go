@@ -0,0 +1,339 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"monad-dashboard/hexutil"
+)
+
+// Per-opcode execution statistics from debug_traceTransaction. Tracing is
+// expensive for the node, so it is off unless TRACE_SAMPLE_EVERY is set:
+// 1 in N transactions from each block's receipts is traced, at most
+// TRACE_BUDGET per minute and only those that used at most TRACE_MAX_GAS,
+// which bounds the size of a struct log trace.
+
+// opcodeQueueSize bounds the transactions waiting for a trace
+const opcodeQueueSize = 64
+
+// maxTraceDepth is the deepest call depth counted separately; deeper calls
+// are counted at this depth
+const maxTraceDepth = 16
+
+// structLog is one step of a debug_traceTransaction struct log trace
+type structLog struct {
+	Op      string `json:"op"`
+	Gas     uint64 `json:"gas"`
+	GasCost uint64 `json:"gasCost"`
+	Depth   int    `json:"depth"`
+}
+
+// OpcodeStats are the traced executions of one opcode
+type OpcodeStats struct {
+	Op       string  `json:"op"`
+	Count    uint64  `json:"count"`
+	Gas      uint64  `json:"gas"` // Excludes gas forwarded to calls and creates
+	GasShare float64 `json:"gas_share"`
+	AvgGas   float64 `json:"avg_gas"`
+}
+
+// TracedTx is a sampled transaction with its trace totals
+type TracedTx struct {
+	Hash        string `json:"hash"`
+	BlockNumber int64  `json:"block_number"`
+	To          string `json:"to,omitempty"`
+	GasUsed     uint64 `json:"gas_used"`
+	Steps       int    `json:"steps"`
+	MaxDepth    int    `json:"max_depth"`
+	Failed      bool   `json:"failed"`
+}
+
+// traceJob is one transaction to trace
+type traceJob struct {
+	blockNumber int64
+	txHash      string
+	to          string
+	gasUsed     uint64
+}
+
+// OpcodeTracer samples transactions and aggregates their traces
+type OpcodeTracer struct {
+	every  int64 // Trace 1 in every transactions; 0 disables tracing
+	budget int   // Traces per minute
+	maxGas uint64
+	queue  chan traceJob
+
+	mu            sync.RWMutex
+	seq           int64
+	budgetStart   time.Time
+	budgetUsed    int
+	available     bool // Cleared once debug_traceTransaction is found unavailable
+	opcodes       map[string]*OpcodeStats
+	depthSteps    [maxTraceDepth + 1]uint64 // Steps executed per call depth
+	maxDepths     [maxTraceDepth + 1]uint64 // Traced transactions per deepest call depth
+	totalGas      uint64
+	totalSteps    uint64
+	traced        int64
+	failed        int64
+	skippedBudget int64
+	skippedGas    int64
+	recent        []TracedTx
+}
+
+// Global opcode tracer instance
+var (
+	opcodeTracer     *OpcodeTracer
+	opcodeTracerOnce sync.Once
+)
+
+// GetOpcodeTracer returns the global opcode tracer, configured from
+// TRACE_SAMPLE_EVERY (default 0, disabled), TRACE_BUDGET (default 20 per
+// minute) and TRACE_MAX_GAS (default 3000000)
+func GetOpcodeTracer() *OpcodeTracer {
+	opcodeTracerOnce.Do(func() {
+		ot := &OpcodeTracer{
+			every:     envInt64("TRACE_SAMPLE_EVERY", 0),
+			budget:    int(envInt64("TRACE_BUDGET", 20)),
+			maxGas:    uint64(envInt64("TRACE_MAX_GAS", 3000000)),
+			queue:     make(chan traceJob, opcodeQueueSize),
+			available: true,
+			opcodes:   make(map[string]*OpcodeStats),
+		}
+		if ot.every > 0 && ot.budget > 0 {
+			go ot.run()
+		}
+		opcodeTracer = ot
+	})
+	return opcodeTracer
+}
+
+// envInt64 reads a non-negative integer variable, falling back to def
+func envInt64(name string, def int64) int64 {
+	value := os.Getenv(name)
+	if value == "" {
+		return def
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n < 0 {
+		log.Printf("Invalid %s %q, using %d", name, value, def)
+		return def
+	}
+	return n
+}
+
+// enabled reports whether transactions are being sampled
+func (ot *OpcodeTracer) enabled() bool {
+	return ot.every > 0 && ot.budget > 0
+}
+
+// Observe samples transactions of a block's receipts for tracing
+func (ot *OpcodeTracer) Observe(blockNumber int64, receipts []RPCReceipt) {
+	if !ot.enabled() {
+		return
+	}
+
+	ot.mu.Lock()
+	defer ot.mu.Unlock()
+	if !ot.available {
+		return
+	}
+	now := time.Now()
+	if now.Sub(ot.budgetStart) >= time.Minute {
+		ot.budgetStart, ot.budgetUsed = now, 0
+	}
+	for _, receipt := range receipts {
+		ot.seq++
+		if ot.seq%ot.every != 0 {
+			continue
+		}
+		gasUsed := hexutil.Uint64OrZero(receipt.GasUsed)
+		if gasUsed > ot.maxGas {
+			ot.skippedGas++
+			continue
+		}
+		if ot.budgetUsed >= ot.budget {
+			ot.skippedBudget++
+			continue
+		}
+		select {
+		case ot.queue <- traceJob{blockNumber: blockNumber, txHash: receipt.TransactionHash, to: receipt.To, gasUsed: gasUsed}:
+			ot.budgetUsed++
+		default:
+			ot.skippedBudget++
+		}
+	}
+}
+
+// run traces queued transactions one at a time
+func (ot *OpcodeTracer) run() {
+	for {
+		select {
+		case <-shutdownContext().Done():
+			return
+		case job := <-ot.queue:
+			ot.trace(job)
+		}
+	}
+}
+
+// trace fetches one transaction's struct log trace and records it
+func (ot *OpcodeTracer) trace(job traceJob) {
+	client := GetMonadClient()
+	if client == nil {
+		return
+	}
+
+	var result struct {
+		Failed     bool        `json:"failed"`
+		StructLogs []structLog `json:"structLogs"`
+	}
+	config := map[string]bool{"disableStack": true, "disableMemory": true, "disableStorage": true}
+	if err := client.callResult("debug_traceTransaction", []interface{}{job.txHash, config}, &result); err != nil {
+		ot.mu.Lock()
+		ot.failed++
+		if isMethodUnavailable(err) && ot.available {
+			ot.available = false
+			log.Printf("debug_traceTransaction unavailable, opcode tracing stopped: %v", err)
+		}
+		ot.mu.Unlock()
+		return
+	}
+	ot.record(job, result.Failed, result.StructLogs)
+}
+
+// record adds one trace to the opcode and depth statistics
+func (ot *OpcodeTracer) record(job traceJob, failed bool, steps []structLog) {
+	tx := TracedTx{Hash: job.txHash, BlockNumber: job.blockNumber, To: job.to, GasUsed: job.gasUsed, Steps: len(steps), Failed: failed}
+
+	ot.mu.Lock()
+	defer ot.mu.Unlock()
+	for i, step := range steps {
+		cost := step.GasCost
+		// A call's or create's cost includes the gas forwarded to the callee,
+		// which is what the callee's first step starts with
+		if i+1 < len(steps) && steps[i+1].Depth == step.Depth+1 {
+			if forwarded := steps[i+1].Gas; forwarded <= cost {
+				cost -= forwarded
+			}
+		}
+		stats, ok := ot.opcodes[step.Op]
+		if !ok {
+			stats = &OpcodeStats{Op: step.Op}
+			ot.opcodes[step.Op] = stats
+		}
+		stats.Count++
+		stats.Gas += cost
+		ot.totalGas += cost
+
+		depth := step.Depth
+		if depth > maxTraceDepth {
+			depth = maxTraceDepth
+		}
+		ot.depthSteps[depth]++
+		if depth > tx.MaxDepth {
+			tx.MaxDepth = depth
+		}
+	}
+	ot.maxDepths[tx.MaxDepth]++
+	ot.totalSteps += uint64(len(steps))
+	ot.traced++
+
+	ot.recent = append(ot.recent, tx)
+	if len(ot.recent) > 50 {
+		ot.recent = ot.recent[1:]
+	}
+}
+
+// DepthStats counts steps and traced transactions at one call depth
+type DepthStats struct {
+	Depth        int    `json:"depth"` // 1 is the transaction's top call
+	Steps        uint64 `json:"steps"`
+	Transactions uint64 `json:"transactions"` // Traced transactions whose deepest call reached this depth
+}
+
+// OpcodeStatsResponse is the body of /api/v1/execution/opcodes
+type OpcodeStatsResponse struct {
+	Enabled       bool          `json:"enabled"`
+	Available     bool          `json:"available"` // False once the node rejected debug_traceTransaction
+	SampleEvery   int64         `json:"sample_every"`
+	BudgetPerMin  int           `json:"budget_per_minute"`
+	MaxGas        uint64        `json:"max_gas"`
+	Traced        int64         `json:"traced"`
+	Failed        int64         `json:"failed"`
+	SkippedBudget int64         `json:"skipped_budget"`
+	SkippedGas    int64         `json:"skipped_gas"`
+	TotalSteps    uint64        `json:"total_steps"`
+	TotalGas      uint64        `json:"total_gas"`
+	Opcodes       []OpcodeStats `json:"opcodes"` // Most gas first
+	Depths        []DepthStats  `json:"depths"`
+	Recent        []TracedTx    `json:"recent"` // Newest first
+}
+
+// Stats returns the limit opcodes with the most gas and the depth statistics
+func (ot *OpcodeTracer) Stats(limit int) OpcodeStatsResponse {
+	ot.mu.RLock()
+	defer ot.mu.RUnlock()
+
+	response := OpcodeStatsResponse{
+		Enabled:       ot.enabled(),
+		Available:     ot.available,
+		SampleEvery:   ot.every,
+		BudgetPerMin:  ot.budget,
+		MaxGas:        ot.maxGas,
+		Traced:        ot.traced,
+		Failed:        ot.failed,
+		SkippedBudget: ot.skippedBudget,
+		SkippedGas:    ot.skippedGas,
+		TotalSteps:    ot.totalSteps,
+		TotalGas:      ot.totalGas,
+		Opcodes:       make([]OpcodeStats, 0, len(ot.opcodes)),
+		Depths:        make([]DepthStats, 0),
+		Recent:        make([]TracedTx, 0, len(ot.recent)),
+	}
+	for _, stats := range ot.opcodes {
+		entry := *stats
+		if ot.totalGas > 0 {
+			entry.GasShare = float64(entry.Gas) / float64(ot.totalGas)
+		}
+		entry.AvgGas = float64(entry.Gas) / float64(entry.Count)
+		response.Opcodes = append(response.Opcodes, entry)
+	}
+	sort.Slice(response.Opcodes, func(i, j int) bool {
+		if response.Opcodes[i].Gas != response.Opcodes[j].Gas {
+			return response.Opcodes[i].Gas > response.Opcodes[j].Gas
+		}
+		return response.Opcodes[i].Op < response.Opcodes[j].Op
+	})
+	if len(response.Opcodes) > limit {
+		response.Opcodes = response.Opcodes[:limit]
+	}
+
+	for depth := 1; depth <= maxTraceDepth; depth++ {
+		if ot.depthSteps[depth] > 0 || ot.maxDepths[depth] > 0 {
+			response.Depths = append(response.Depths, DepthStats{Depth: depth, Steps: ot.depthSteps[depth], Transactions: ot.maxDepths[depth]})
+		}
+	}
+	for i := len(ot.recent) - 1; i >= 0; i-- {
+		response.Recent = append(response.Recent, ot.recent[i])
+	}
+	return response
+}
+
+// handleExecutionOpcodes returns per-opcode gas and call depth statistics
+// of sampled transaction traces
+func handleExecutionOpcodes(c *gin.Context) {
+	limit := 30
+	if value := c.Query("limit"); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			limit = n
+		}
+	}
+	c.JSON(http.StatusOK, GetOpcodeTracer().Stats(limit))
+}
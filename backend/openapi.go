@@ -0,0 +1,526 @@
+package main
+
+import (
+	"log"
+	"math/big"
+	"net/http"
+	"os"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"monad-dashboard/graphql"
+)
+
+// APIError is the body of every error response
+type APIError struct {
+	Error string `json:"error"`
+}
+
+// FreeForm marks a response or field whose keys vary (Firedancer-compatible
+// payloads, the waterfall graph); the spec documents it as an open object
+type FreeForm map[string]interface{}
+
+// apiParam is a path or query parameter of a documented route
+type apiParam struct {
+	name        string
+	in          string // "path" or "query"
+	kind        string // OpenAPI type: "string", "integer", "boolean"
+	description string
+}
+
+// apiOperation documents one REST route. response is a zero value of the
+// 200 response type; body, when set, of the JSON request body.
+type apiOperation struct {
+	method   string
+	path     string // gin syntax: /tx/:hash/lifecycle
+	tag      string
+	summary  string
+	params   []apiParam
+	body     interface{}
+	response interface{}
+	status   int    // Success status, 200 when zero
+	produces string // Non-JSON success content type
+
+	alternatives []interface{} // Other shapes of the success response
+}
+
+func query(name, kind, description string) apiParam {
+	return apiParam{name: name, in: "query", kind: kind, description: description}
+}
+
+func pathParam(name, description string) apiParam {
+	return apiParam{name: name, in: "path", kind: "string", description: description}
+}
+
+// apiVersions are the documented route groups; routes missing from them are
+// logged at startup
+var apiVersions = []struct {
+	prefix     string
+	operations []apiOperation
+}{
+	{"/api/v1", apiOperations},
+	{"/api/v2", apiV2Operations},
+}
+
+// apiV2Operations lists every /api/v2 route
+var apiV2Operations = []apiOperation{
+	{method: "GET", path: "/metrics", tag: "metrics", summary: "Current metrics grouped by subsystem and lifecycle stage", response: MetricsV2{}},
+	{method: "GET", path: "/waterfall", tag: "waterfall", summary: "Lifecycle waterfall with typed block, consensus, fee flow, timing and leader sections", response: WaterfallV2{}},
+	{method: "GET", path: "/consensus", tag: "consensus", summary: "Consensus heights, phase counts and the chain parameters they are measured against", response: ConsensusV2{}},
+}
+
+// apiOperations lists every /api/v1 route
+var apiOperations = []apiOperation{
+	{method: "GET", path: "/health", tag: "status", summary: "Liveness, version and clock offset", response: HealthResponse{}},
+	{method: "GET", path: "/livez", tag: "status", summary: "Liveness probe: 200 while serving, 503 once shutting down (also served at /livez)", response: LivezResponse{}},
+	{method: "GET", path: "/readyz", tag: "status", summary: "Readiness probe: 200 with a healthy data source and recent block data, else 503; per-component statuses (also served at /readyz)", response: ReadyzResponse{}},
+	{method: "GET", path: "/metrics", tag: "metrics", summary: "Current consensus, execution, network and waterfall metrics", response: MonadMetrics{}},
+	{method: "GET", path: "/waterfall", tag: "waterfall", summary: "Legacy transaction waterfall", response: FreeForm{}},
+	{method: "GET", path: "/waterfall/v2", tag: "waterfall", summary: "Monad lifecycle waterfall (Sankey nodes and links with metadata)", response: FreeForm{}},
+	{method: "GET", path: "/waterfall/interval", tag: "waterfall", summary: "Latest sampled interval of the event-driven waterfall counters", response: WaterfallInterval{}},
+	{method: "GET", path: "/waterfall/cadence", tag: "waterfall", summary: "Nominal waterfall interval and the interval each collector measured", response: WaterfallCadenceResponse{}},
+	{method: "GET", path: "/waterfall/debug", tag: "waterfall", summary: "Raw and sanitized flow of every lifecycle waterfall link, with stage balances", response: WaterfallFlowReport{}},
+	{method: "GET", path: "/waterfall/block/:number", tag: "waterfall", summary: "Ingress, drops, execution and finality attributed to one block",
+		params: []apiParam{pathParam("number", "Block number or latest")}, response: BlockWaterfall{}},
+	{method: "GET", path: "/waterfall/drops", tag: "waterfall", summary: "Txpool drop reasons over selectable windows",
+		params: []apiParam{query("window", "string", "Comma-separated windows from 1m, 5m, 15m, 1h, 6h")}, response: WaterfallDropsResponse{}},
+	{method: "GET", path: "/consensus", tag: "consensus", summary: "MonadBFT consensus phases of recent blocks", response: ConsensusState{}},
+	{method: "GET", path: "/consensus/events", tag: "consensus", summary: "Timeouts, vote failures and proposal errors from the monad-bft log",
+		params:   []apiParam{query("kind", "string", "round_timeout, vote_failure or proposal_error"), query("limit", "integer", "Events returned (default 100)")},
+		response: ConsensusEventsResponse{}},
+	{method: "GET", path: "/consensus/supermajority", tag: "consensus", summary: "Stake-weighted validator participation against the 2/3 supermajority",
+		params: []apiParam{query("limit", "integer", "Unobserved validators listed, largest stake first (default 20)")}, response: SupermajorityResponse{}},
+	{method: "GET", path: "/consensus/history", tag: "consensus", summary: "Persisted consensus timeline and finality timings for a block range",
+		params: []apiParam{query("from_block", "integer", "First block (default to_block - 999)"), query("to_block", "integer", "Last block (default the current block)"),
+			query("limit", "integer", "Blocks listed (default 1000, at most 10000); the summary covers the whole range")},
+		response: ConsensusHistoryResponse{}},
+	{method: "GET", path: "/event-rings", tag: "sources", summary: "Execution event ring reader statistics", response: FreeForm{}},
+	{method: "GET", path: "/event-rings/gaps", tag: "sources", summary: "Recent event ring sequence gaps and how each was replayed or reconciled",
+		params: []apiParam{query("ring", "string", "Ring name (default all)"), query("limit", "integer", "Gaps returned, newest first (default 50)")}, response: RingGapsResponse{}},
+	{method: "GET", path: "/event-rings/types", tag: "sources", summary: "Event types with whether each is processed and how many were received and skipped", response: EventTypesResponse{}},
+	{method: "GET", path: "/sources", tag: "sources", summary: "Metrics source health and provenance", response: SourcesResponse{}},
+	{method: "GET", path: "/prometheus", tag: "sources", summary: "Scraped Prometheus series", response: PrometheusSeriesResponse{}},
+	{method: "GET", path: "/otlp", tag: "sources", summary: "OTLP receiver statistics", response: OTLPStats{}},
+	{method: "GET", path: "/export", tag: "status", summary: "ClickHouse/TimescaleDB export sink, schema version and per-table progress", response: AnalyticsExportStatus{}},
+	{method: "GET", path: "/publisher", tag: "status", summary: "Kafka/NATS event publisher queue and per-category counts", response: PublisherStatus{}},
+	{method: "GET", path: "/publisher/schema.proto", tag: "status", summary: "Protobuf schema of events published with PUBLISH_FORMAT=protobuf", response: "", produces: "text/plain"},
+	{method: "GET", path: "/broadcast", tag: "status", summary: "Replica role and pub/sub statistics", response: BroadcastStatusResponse{}},
+	{method: "GET", path: "/cache/blocks", tag: "status", summary: "Block cache statistics", response: BlockCacheStats{}},
+	{method: "GET", path: "/sync", tag: "status", summary: "Node bootstrap (statesync/blocksync) progress", response: SyncStatusResponse{}},
+	{method: "GET", path: "/incidents", tag: "status", summary: "Incident timeline and uptime per component",
+		params:   []apiParam{query("component", "string", "Only this component"), query("limit", "integer", "Incidents per component (default 50)")},
+		response: IncidentsResponse{}},
+	{method: "GET", path: "/cadence", tag: "status", summary: "Polling intervals and stream deduplication", response: CadenceResponse{}},
+	{method: "GET", path: "/ws-stats", tag: "stream", summary: "Outbound WebSocket messages/sec, bytes/sec and encode time per topic/key", response: WSStatsResponse{}},
+	{method: "GET", path: "/storage", tag: "history", summary: "History retention tiers, disk usage and compaction", response: StorageResponse{}},
+	{method: "GET", path: "/storage/node", tag: "status", summary: "Node data directory sizes and disk-full projection", response: NodeStorageResponse{}},
+	{method: "GET", path: "/system", tag: "status", summary: "Host and node process resources", response: SystemStats{}},
+	{method: "GET", path: "/logtail", tag: "sources", summary: "Metrics and events extracted from tailed logs",
+		params:   []apiParam{query("rule", "string", "Only events of this rule"), query("limit", "integer", "Events returned (default 100)")},
+		response: LogRulesResponse{}},
+	{method: "GET", path: "/mempool/pending", tag: "mempool", summary: "Ingress measured from newPendingTransactions", response: PendingTxResponse{}},
+	{method: "GET", path: "/mempool/nonce-gaps", tag: "mempool", summary: "Senders whose pending transactions are stuck behind a missing nonce, with counts and ages",
+		params: []apiParam{query("limit", "integer", "Accounts returned, most stuck first (default 50)"), query("all", "boolean", "Include senders without gaps")},
+		response: NonceGapsResponse{}},
+	{method: "GET", path: "/subscriptions", tag: "sources", summary: "Node WebSocket subscriptions, log sampling and block enrichment", response: SubscriptionsResponse{}},
+	{method: "GET", path: "/rpc/endpoints", tag: "sources", summary: "Execution RPC endpoints with breaker state and latency", response: RPCEndpointsResponse{}},
+	{method: "GET", path: "/rpc-usage", tag: "sources", summary: "The dashboard's own JSON-RPC calls, errors and latency per method", response: RPCUsageResponse{}},
+	{method: "GET", path: "/propagation", tag: "sources", summary: "Delay from block timestamp to newHeads receipt: percentiles, histogram and recent blocks", response: PropagationResponse{}},
+	{method: "GET", path: "/chains", tag: "chains", summary: "Monitored chains", response: ChainsResponse{}},
+	{method: "GET", path: "/chain/params", tag: "chains", summary: "Epoch length, block time and finality depth in use", response: ChainParams{}},
+	{method: "GET", path: "/chains/:chain/*path", tag: "chains", summary: "Primary chain API under a chain prefix, or a secondary chain's metrics, health and blocks",
+		params:   []apiParam{pathParam("chain", "Chain name or ID"), pathParam("path", "/metrics, /health, /blocks, /rpc-usage or any /api/v1 path for the primary chain")},
+		response: ChainSummary{}},
+	{method: "GET", path: "/stream", tag: "stream", summary: "Server-Sent Events stream of the realtime topics", response: "", produces: "text/event-stream"},
+	{method: "GET", path: "/graphql", tag: "graphql", summary: "GraphQL query (query and variables as URL parameters)",
+		params: []apiParam{query("query", "string", "GraphQL document"), query("variables", "string", "JSON-encoded variables")}, response: graphql.Response{}},
+	{method: "POST", path: "/graphql", tag: "graphql", summary: "GraphQL query", body: GraphQLRequest{}, response: graphql.Response{}},
+	{method: "GET", path: "/history", tag: "history", summary: "Samples of one metric series, or the series names without ?series",
+		params: []apiParam{query("series", "string", "Series name"), query("from", "integer", "Unix seconds (default an hour ago)"),
+			query("to", "integer", "Unix seconds (default now)"), query("max_points", "integer", "Downsample to at most this many points")},
+		response: HistoryResponse{}, alternatives: []interface{}{HistorySeriesResponse{}}},
+	{method: "GET", path: "/grafana", tag: "grafana", summary: "SimpleJSON datasource test", response: "", produces: "text/plain"},
+	{method: "POST", path: "/grafana/search", tag: "grafana", summary: "SimpleJSON metric search", body: GrafanaSearchRequest{}, response: []string{}},
+	{method: "POST", path: "/grafana/query", tag: "grafana", summary: "SimpleJSON time series and table query", body: grafanaQueryRequest{}, response: []GrafanaQueryResult{}},
+	{method: "POST", path: "/grafana/annotations", tag: "grafana", summary: "SimpleJSON annotations", body: grafanaAnnotationRequest{}, response: []GrafanaAnnotation{}},
+	{method: "GET", path: "/watchlist", tag: "watchlist", summary: "Watched addresses", response: WatchlistResponse{}},
+	{method: "POST", path: "/watchlist", tag: "watchlist", summary: "Watch one or more addresses (requires ADMIN_KEY, or the tenant key under /t/:tenant)", body: WatchlistAddRequest{}, response: WatchlistAddResponse{}, status: http.StatusCreated},
+	{method: "GET", path: "/watchlist/:address", tag: "watchlist", summary: "One watched address", params: []apiParam{pathParam("address", "0x-prefixed address")}, response: WatchedAddress{}},
+	{method: "DELETE", path: "/watchlist/:address", tag: "watchlist", summary: "Stop watching an address (requires ADMIN_KEY, or the tenant key under /t/:tenant)", params: []apiParam{pathParam("address", "0x-prefixed address")}, response: WatchlistRemoveResponse{}},
+	{method: "GET", path: "/tokens/top", tag: "tokens", summary: "Most active tokens by Transfer events",
+		params:   []apiParam{query("window", "string", "1m, 5m, 15m or 1h (default 5m)"), query("standard", "string", "erc20 or erc721"), query("limit", "integer", "Tokens returned (default 10)")},
+		response: TopTokensResponse{}},
+	{method: "GET", path: "/fees", tag: "fees", summary: "Burned and priority fees per block, epoch and in total",
+		params: []apiParam{query("recent", "integer", "Recent blocks returned (default 20)")}, response: FeeSummary{}},
+	{method: "GET", path: "/leader", tag: "consensus", summary: "Leader schedule and the local validator's slots", response: LeaderMetadata{}},
+	{method: "GET", path: "/validators/self", tag: "consensus", summary: "The local validator: node.toml identity, stake, balance, next leader slot and skip rate", response: SelfValidatorResponse{}},
+	{method: "GET", path: "/validators/self/performance", tag: "consensus", summary: "Per-proposer block production over recent blocks, with the local validator compared to the network average",
+		params: []apiParam{query("leaders", "integer", "Proposers listed, most blocks first (default 20)")}, response: ValidatorPerformanceResponse{}},
+	{method: "GET", path: "/validators/self/vote", tag: "consensus", summary: "Whether the local validator is voting: active set membership, distance from its last vote and delinquency", response: VoteStatus{}},
+	{method: "GET", path: "/epochs/:n/validator-changes", tag: "consensus", summary: "Validators that joined or left the consensus set, and stake and commission changes, against the previous epoch's snapshot",
+		params: []apiParam{pathParam("n", "Epoch number or latest")}, response: ValidatorChanges{}},
+	{method: "GET", path: "/blocks/:number/ordering", tag: "execution", summary: "How a block's transaction order relates to priority fees: rank correlation, ordering entropy, inversions and sandwich-shaped triples",
+		params: []apiParam{pathParam("number", "Block number or latest"), query("limit", "integer", "Reorderings returned (default 20)"),
+			query("transactions", "boolean", "Include every transaction with its fee rank")},
+		response: BlockOrderingResponse{}},
+	{method: "GET", path: "/tx/:hash/lifecycle", tag: "transactions", summary: "One transaction's mempool, inclusion, execution and receipt times",
+		params: []apiParam{pathParam("hash", "Transaction hash")}, response: TxLifecycleResponse{}},
+	{method: "GET", path: "/probe/inclusion", tag: "transactions", summary: "Synthetic transaction pending, inclusion and finality latency with SLO attainment",
+		params: []apiParam{query("limit", "integer", "Recent probes listed (default 20)")}, response: InclusionProbeResponse{}},
+	{method: "GET", path: "/execution/blocks", tag: "execution", summary: "Receipt-based execution stats for recent blocks",
+		params: []apiParam{query("recent", "integer", "Blocks returned (default 20)")}, response: ExecutionBlocksResponse{}},
+	{method: "GET", path: "/execution/slowest", tag: "execution", summary: "Slowest transactions by execution time",
+		params: []apiParam{query("limit", "integer", "Transactions returned"), query("window", "string", "Look-back window")}, response: SlowestTransactionsResponse{}},
+	{method: "GET", path: "/execution/conflicts", tag: "execution", summary: "Accounts and storage keys with the most parallel execution conflicts",
+		params: []apiParam{query("limit", "integer", "Entries returned")}, response: ConflictHotspots{}},
+	{method: "GET", path: "/execution/reverts", tag: "execution", summary: "Top decoded revert reasons per contract",
+		params: []apiParam{query("limit", "integer", "Contracts returned (default 20)"), query("reasons", "integer", "Reasons per contract (default 5)"),
+			query("contract", "string", "Only this contract address, or contract_creation")},
+		response: RevertsResponse{}},
+	{method: "GET", path: "/execution/opcodes", tag: "execution", summary: "Per-opcode gas and call depth statistics of sampled transaction traces",
+		params: []apiParam{query("limit", "integer", "Opcodes returned (default 30)")}, response: OpcodeStatsResponse{}},
+	{method: "GET", path: "/address/:addr", tag: "transactions", summary: "An address's balance, nonce, code presence and recent activity",
+		params: []apiParam{pathParam("addr", "20-byte hex address"), query("limit", "integer", "Activity entries returned (default 25)")}, response: AddressResponse{}},
+	{method: "GET", path: "/contracts/:addr/verification", tag: "execution", summary: "A contract's verified name, ABI and event signatures from the Sourcify-compatible CONTRACT_VERIFICATION_URL",
+		params: []apiParam{pathParam("addr", "Contract address")}, response: ContractLabel{}},
+	{method: "GET", path: "/gas/by-contract", tag: "execution", summary: "Gas used per contract per minute",
+		params:   []apiParam{query("minutes", "integer", "Minutes returned"), query("limit", "integer", "Contracts returned; the rest is summed as other")},
+		response: GasHeatmapResponse{}},
+	{method: "GET", path: "/gas/estimate", tag: "fees", summary: "Suggested slow/standard/fast priority fees from recent inclusion data",
+		params: []apiParam{query("confidence", "integer", "Percentile of recent block windows a suggestion would have cleared, 50-99 (default 90)")}, response: GasEstimateResponse{}},
+	{method: "GET", path: "/webhooks", tag: "webhooks", summary: "Registered webhooks and delivery counters (requires ADMIN_KEY)", response: WebhooksResponse{}},
+	{method: "POST", path: "/webhooks", tag: "webhooks", summary: "Register a webhook; the response holds its signing secret (requires ADMIN_KEY)",
+		body: WebhookCreateRequest{}, response: WebhookCreatedResponse{}, status: http.StatusCreated},
+	{method: "GET", path: "/webhooks/:id", tag: "webhooks", summary: "One webhook with its recent deliveries (requires ADMIN_KEY)",
+		params: []apiParam{pathParam("id", "Webhook ID")}, response: WebhookStatus{}},
+	{method: "DELETE", path: "/webhooks/:id", tag: "webhooks", summary: "Remove a webhook (requires ADMIN_KEY)",
+		params: []apiParam{pathParam("id", "Webhook ID")}, response: WebhookRemoveResponse{}},
+	{method: "POST", path: "/webhooks/:id/test", tag: "webhooks", summary: "Send a webhook.test event (requires ADMIN_KEY)",
+		params: []apiParam{pathParam("id", "Webhook ID")}, response: WebhookDelivery{}, status: http.StatusAccepted},
+	{method: "GET", path: "/notifiers", tag: "webhooks", summary: "Telegram/Discord alert notifiers and their message counters", response: NotifiersResponse{}},
+	{method: "POST", path: "/notifiers/test", tag: "webhooks", summary: "Send a test message through every notifier (requires ADMIN_KEY)",
+		response: NotifierTestResponse{}, status: http.StatusAccepted},
+	{method: "GET", path: "/recordings", tag: "stream", summary: "Recorded stream sessions, newest first, and the one in progress", response: RecordingsResponse{}},
+	{method: "POST", path: "/recordings", tag: "stream", summary: "Start recording the outbound stream (requires ADMIN_KEY)",
+		body: RecordingStartRequest{}, response: SessionRecording{}, status: http.StatusCreated},
+	{method: "POST", path: "/recordings/stop", tag: "stream", summary: "Stop the recording in progress (requires ADMIN_KEY)", response: SessionRecording{}},
+	{method: "GET", path: "/recordings/:id", tag: "stream", summary: "One recorded session",
+		params: []apiParam{pathParam("id", "Recording ID")}, response: SessionRecording{}},
+	{method: "DELETE", path: "/recordings/:id", tag: "stream", summary: "Delete a recorded session (requires ADMIN_KEY)",
+		params: []apiParam{pathParam("id", "Recording ID")}, response: RecordingRemoveResponse{}},
+	{method: "GET", path: "/preferences", tag: "preferences", summary: "The caller's stored frontend preferences (session token or X-Client-Token identifies the caller)", response: PreferencesResponse{}},
+	{method: "GET", path: "/preferences/:key", tag: "preferences", summary: "One stored preference value", params: []apiParam{pathParam("key", "Preference key")}, response: FreeForm{}},
+	{method: "PUT", path: "/preferences/:key", tag: "preferences", summary: "Store a JSON value (at most 64 KiB) under a key", params: []apiParam{pathParam("key", "Preference key")},
+		body: FreeForm{}, response: PreferenceSetResponse{}},
+	{method: "DELETE", path: "/preferences/:key", tag: "preferences", summary: "Remove a stored preference", params: []apiParam{pathParam("key", "Preference key")},
+		response: PreferenceRemoveResponse{}},
+	{method: "POST", path: "/ws/token", tag: "stream", summary: "Issue a realtime stream session token", body: WSTokenRequest{}, response: WSTokenResponse{}},
+	{method: "POST", path: "/ws/token/renew", tag: "stream", summary: "Renew a realtime stream session token", body: WSTokenRenewRequest{}, response: WSTokenResponse{}},
+	{method: "POST", path: "/ws/upgrade-token", tag: "stream", summary: "Mint a one-time WebSocket upgrade token (WS_UPGRADE_TOKENS)", response: WSUpgradeTokenResponse{}},
+	{method: "GET", path: "/embed", tag: "stream", summary: "Whether embed tokens are enabled, the embeddable topics and current viewers", response: EmbedStatusResponse{}},
+	{method: "GET", path: "/openapi.json", tag: "meta", summary: "This OpenAPI document", response: FreeForm{}},
+	{method: "GET", path: "/docs", tag: "meta", summary: "Swagger UI", response: "", produces: "text/html"},
+	{method: "POST", path: "/admin/waterfall/snapshot-reset", tag: "admin", summary: "Close the current waterfall interval now (requires ADMIN_KEY)", response: WaterfallInterval{}},
+	{method: "PUT", path: "/admin/waterfall/cadence", tag: "admin", summary: "Change the nominal waterfall interval at runtime (requires ADMIN_KEY)",
+		body: WaterfallCadenceRequest{}, response: WaterfallCadenceResponse{}},
+	{method: "PUT", path: "/admin/event-rings/types", tag: "admin", summary: "Replace the event types skipped in the ring read loop (requires ADMIN_KEY)",
+		body: EventTypesRequest{}, response: EventTypesResponse{}},
+	{method: "GET", path: "/admin/state/export", tag: "admin", summary: "Dashboard state as a .tar.gz (requires ADMIN_KEY)", response: "", produces: "application/gzip"},
+	{method: "POST", path: "/admin/state/import", tag: "admin", summary: "Restore dashboard state from an exported .tar.gz (requires ADMIN_KEY)",
+		params: []apiParam{query("mode", "string", "merge (default) or replace")}, response: StateImportResponse{}},
+	{method: "GET", path: "/admin/reload", tag: "admin", summary: "Reloadable config files and their last reload (requires ADMIN_KEY)", response: ConfigReloadResponse{}},
+	{method: "POST", path: "/admin/reload", tag: "admin", summary: "Validate and swap in config files; 422 when one is rejected (requires ADMIN_KEY)",
+		params: []apiParam{query("config", "string", "log_rules, notifiers, watchlist or metric_mappings; repeatable, default every configured file")}, response: ConfigReloadResponse{}},
+	{method: "GET", path: "/admin/tenants", tag: "admin", summary: "Configured tenants with their chains and clients (requires ADMIN_KEY)", response: TenantsResponse{}},
+	{method: "POST", path: "/admin/embed/tokens", tag: "admin", summary: "Mint a signed, expiring embed token for a read-only status widget (requires ADMIN_KEY)",
+		body: EmbedTokenRequest{}, response: EmbedTokenResponse{}},
+}
+
+// openAPIPath converts gin route syntax to OpenAPI templating
+func openAPIPath(path string) string {
+	parts := strings.Split(path, "/")
+	for i, part := range parts {
+		if strings.HasPrefix(part, ":") || strings.HasPrefix(part, "*") {
+			parts[i] = "{" + part[1:] + "}"
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// checkAPIDocs logs versioned API routes that apiVersions does not describe
+func checkAPIDocs(routes gin.RoutesInfo) {
+	documented := make(map[string]bool)
+	for _, version := range apiVersions {
+		for _, op := range version.operations {
+			documented[op.method+" "+version.prefix+op.path] = true
+		}
+	}
+	for _, route := range routes {
+		versioned := strings.HasPrefix(route.Path, "/api/v1/") || strings.HasPrefix(route.Path, "/api/v2/")
+		if versioned && !documented[route.Method+" "+route.Path] {
+			log.Printf("OpenAPI: %s %s is not documented", route.Method, route.Path)
+		}
+	}
+}
+
+// schemaBuilder turns Go types into JSON Schema, collecting named structs
+// as components
+type schemaBuilder struct {
+	components map[string]interface{}
+}
+
+var (
+	timeType    = reflect.TypeOf(time.Time{})
+	bigIntType  = reflect.TypeOf(big.Int{})
+	freeFormTyp = reflect.TypeOf(FreeForm{})
+)
+
+// schema returns the schema of t, a $ref for named structs
+func (b *schemaBuilder) schema(t reflect.Type) map[string]interface{} {
+	switch t {
+	case timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case bigIntType:
+		return map[string]interface{}{"type": "integer"}
+	case freeFormTyp:
+		return map[string]interface{}{"type": "object", "additionalProperties": true}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		s := b.schema(t.Elem())
+		if _, isRef := s["$ref"]; isRef {
+			return map[string]interface{}{"allOf": []interface{}{s}, "nullable": true}
+		}
+		s["nullable"] = true
+		return s
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Int64, reflect.Uint64:
+		if t == reflect.TypeOf(time.Duration(0)) {
+			return map[string]interface{}{"type": "integer", "description": "Nanoseconds"}
+		}
+		return map[string]interface{}{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 && t.Kind() == reflect.Slice {
+			return map[string]interface{}{"type": "string", "format": "byte"}
+		}
+		s := map[string]interface{}{"type": "array", "items": b.schema(t.Elem())}
+		if t.Kind() == reflect.Array {
+			s["minItems"], s["maxItems"] = t.Len(), t.Len()
+		}
+		return s
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": b.schema(t.Elem())}
+	case reflect.Interface:
+		return map[string]interface{}{}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.structSchema(t)
+		}
+		// Types from other packages are prefixed with the package name
+		name := t.Name()
+		if pkg := t.PkgPath(); pkg != "main" {
+			name = path.Base(pkg) + name
+		}
+		name = strings.ToUpper(name[:1]) + name[1:]
+		if _, seen := b.components[name]; !seen {
+			b.components[name] = nil // Placeholder for recursive types
+			b.components[name] = b.structSchema(t)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+	}
+	return map[string]interface{}{}
+}
+
+// structSchema describes a struct's JSON fields; fields without omitempty
+// are always present and listed as required
+func (b *schemaBuilder) structSchema(t reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{})
+	var required []string
+	b.addFields(t, properties, &required, false)
+	s := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
+
+func (b *schemaBuilder) addFields(t reflect.Type, properties map[string]interface{}, required *[]string, optional bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		// Embedded structs without a tag are flattened, as encoding/json does;
+		// the fields of a nil embedded pointer are omitted
+		if field.Anonymous && name == "" {
+			embedded, viaPointer := field.Type, false
+			if embedded.Kind() == reflect.Ptr {
+				embedded, viaPointer = embedded.Elem(), true
+			}
+			if embedded.Kind() == reflect.Struct {
+				b.addFields(embedded, properties, required, optional || viaPointer)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if opts == "string" {
+			properties[name] = map[string]interface{}{"type": "string"}
+		} else {
+			properties[name] = b.schema(field.Type)
+		}
+		if !optional && !strings.Contains(opts, "omitempty") {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// buildOpenAPISpec generates the OpenAPI 3 document from apiVersions
+func buildOpenAPISpec() map[string]interface{} {
+	b := &schemaBuilder{components: make(map[string]interface{})}
+	paths := make(map[string]interface{})
+	for _, version := range apiVersions {
+		for _, op := range version.operations {
+			b.addOperation(paths, version.prefix, op)
+		}
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":       "Monad Dashboard API",
+			"version":     "0.1.0",
+			"description": "REST API of the Monad validator dashboard. /api/v2 carries the restructured typed views; /api/v1 is frozen, and its routes with a v2 successor are marked deprecated. Realtime data is also available over /websocket, /ws/native and /api/v1/stream.",
+		},
+		"paths":      paths,
+		"components": map[string]interface{}{"schemas": b.components},
+	}
+}
+
+// addOperation documents one route under paths
+func (b *schemaBuilder) addOperation(paths map[string]interface{}, prefix string, op apiOperation) {
+	route := prefix + op.path
+	path := openAPIPath(route)
+	item, ok := paths[path].(map[string]interface{})
+	if !ok {
+		item = make(map[string]interface{})
+		paths[path] = item
+	}
+
+	operation := map[string]interface{}{
+		"tags":        []string{op.tag},
+		"summary":     op.summary,
+		"operationId": strings.ToLower(op.method) + strings.NewReplacer("/api", "", "/", "_", ":", "", "*", "", "-", "_", ".", "_").Replace(route),
+	}
+	if _, ok := v1Successors[route]; ok {
+		operation["deprecated"] = true
+	}
+
+	var params []interface{}
+	for _, p := range op.params {
+		params = append(params, map[string]interface{}{
+			"name":        p.name,
+			"in":          p.in,
+			"required":    p.in == "path",
+			"description": p.description,
+			"schema":      map[string]interface{}{"type": p.kind},
+		})
+	}
+	if params != nil {
+		operation["parameters"] = params
+	}
+	if op.body != nil {
+		operation["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content":  map[string]interface{}{"application/json": map[string]interface{}{"schema": b.schema(reflect.TypeOf(op.body))}},
+		}
+	}
+
+	status := op.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := map[string]interface{}{"description": http.StatusText(status)}
+	if op.produces != "" {
+		success["content"] = map[string]interface{}{op.produces: map[string]interface{}{"schema": map[string]interface{}{"type": "string"}}}
+	} else {
+		schema := b.schema(reflect.TypeOf(op.response))
+		if len(op.alternatives) > 0 {
+			oneOf := []interface{}{schema}
+			for _, alternative := range op.alternatives {
+				oneOf = append(oneOf, b.schema(reflect.TypeOf(alternative)))
+			}
+			schema = map[string]interface{}{"oneOf": oneOf}
+		}
+		success["content"] = map[string]interface{}{"application/json": map[string]interface{}{"schema": schema}}
+	}
+	operation["responses"] = map[string]interface{}{
+		strconv.Itoa(status): success,
+		"default": map[string]interface{}{
+			"description": "Error",
+			"content":     map[string]interface{}{"application/json": map[string]interface{}{"schema": b.schema(reflect.TypeOf(APIError{}))}},
+		},
+	}
+	item[strings.ToLower(op.method)] = operation
+}
+
+// Generated once; the route table is static
+var (
+	openAPISpec     map[string]interface{}
+	openAPISpecOnce sync.Once
+)
+
+// handleOpenAPI serves the OpenAPI 3 document
+func handleOpenAPI(c *gin.Context) {
+	openAPISpecOnce.Do(func() { openAPISpec = buildOpenAPISpec() })
+	c.JSON(http.StatusOK, openAPISpec)
+}
+
+// swaggerUIPage loads Swagger UI from SWAGGER_UI_URL (default unpkg) and
+// points it at the spec
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8" />
+  <title>Monad Dashboard API</title>
+  <link rel="stylesheet" href="{{base}}/swagger-ui.css" />
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="{{base}}/swagger-ui-bundle.js"></script>
+  <script>
+    window.ui = SwaggerUIBundle({ url: "openapi.json", dom_id: "#swagger-ui" });
+  </script>
+</body>
+</html>
+`
+
+// handleSwaggerUI serves the Swagger UI page
+func handleSwaggerUI(c *gin.Context) {
+	base := "https://unpkg.com/swagger-ui-dist@5"
+	if custom := os.Getenv("SWAGGER_UI_URL"); custom != "" {
+		base = strings.TrimSuffix(custom, "/")
+	}
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(strings.ReplaceAll(swaggerUIPage, "{{base}}", base)))
+}
@@ -0,0 +1,944 @@
+package main
+
+import (
+	"compress/gzip"
+	"crypto/subtle"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OTLP/HTTP metrics receiver. A Monad node (or an OTEL SDK) configured to
+// push metrics posts ExportMetricsServiceRequest messages to /v1/metrics,
+// either protobuf-encoded (the OTLP default) or JSON-encoded. Data points
+// are converted to Prometheus-style samples and merged into the Prometheus
+// collector, so the waterfall, TPS and history see them like scraped metrics.
+
+// otlpMaxBodyBytes caps the decompressed request size
+const otlpMaxBodyBytes = 32 << 20
+
+// otlpStaleAfter drops series that have not been pushed for this long
+const otlpStaleAfter = 5 * time.Minute
+
+// otlpMetric is one decoded OTLP metric
+type otlpMetric struct {
+	Name      string
+	Kind      string // "gauge", "counter", "histogram", "summary"
+	Delta     bool   // Aggregation temporality is DELTA
+	Points    []otlpPoint
+	Resources map[string]string
+}
+
+// otlpPoint is one decoded data point of any metric kind
+type otlpPoint struct {
+	Attributes   map[string]string
+	Value        float64
+	Count        float64
+	Sum          float64
+	BucketCounts []float64
+	Bounds       []float64
+	Quantiles    [][2]float64 // {quantile, value}
+}
+
+// otlpSeries is the latest value of one pushed series
+type otlpSeries struct {
+	sample  PrometheusSample
+	updated time.Time
+}
+
+// OTLPReceiver keeps the latest value of every pushed series
+type OTLPReceiver struct {
+	mu           sync.Mutex
+	series       map[string]*otlpSeries
+	types        map[string]string
+	requests     int64
+	dataPoints   int64
+	failures     int64
+	lastReceived time.Time
+}
+
+// Global OTLP receiver instance
+var (
+	otlpReceiver   *OTLPReceiver
+	otlpReceiverMu sync.RWMutex
+)
+
+// InitializeOTLPReceiver creates the receiver and registers it as a push
+// source of the Prometheus collector
+func InitializeOTLPReceiver() *OTLPReceiver {
+	otlpReceiverMu.Lock()
+	defer otlpReceiverMu.Unlock()
+
+	otlpReceiver = &OTLPReceiver{
+		series: make(map[string]*otlpSeries),
+		types:  make(map[string]string),
+	}
+	ensurePrometheusCollector().SetPushSource(otlpReceiver.Samples)
+	return otlpReceiver
+}
+
+// GetOTLPReceiver returns the global OTLP receiver
+func GetOTLPReceiver() *OTLPReceiver {
+	otlpReceiverMu.RLock()
+	defer otlpReceiverMu.RUnlock()
+	return otlpReceiver
+}
+
+// Samples returns the current view of all pushed series
+func (r *OTLPReceiver) Samples() ([]PrometheusSample, map[string]string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	samples := make([]PrometheusSample, 0, len(r.series))
+	for key, s := range r.series {
+		if now.Sub(s.updated) > otlpStaleAfter {
+			delete(r.series, key)
+			continue
+		}
+		samples = append(samples, s.sample)
+	}
+	types := make(map[string]string, len(r.types))
+	for name, kind := range r.types {
+		types[name] = kind
+	}
+	return samples, types
+}
+
+// Ingest stores decoded metrics. Delta sums and histograms are accumulated
+// so the collector always sees cumulative values.
+func (r *OTLPReceiver) Ingest(metrics []otlpMetric) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	points := 0
+	set := func(name string, labels map[string]string, value float64, accumulate bool) {
+		key := seriesKey(name, labels)
+		s, ok := r.series[key]
+		if !ok {
+			s = &otlpSeries{sample: PrometheusSample{Name: name, Labels: labels}}
+			r.series[key] = s
+		}
+		if accumulate {
+			s.sample.Value += value
+		} else {
+			s.sample.Value = value
+		}
+		s.updated = now
+	}
+
+	for _, m := range metrics {
+		name := sanitizeMetricName(m.Name)
+		r.types[name] = m.Kind
+
+		for _, p := range m.Points {
+			points++
+			labels := otlpLabels(m.Resources, p.Attributes)
+
+			switch m.Kind {
+			case "gauge":
+				set(name, labels, p.Value, false)
+			case "counter":
+				set(name, labels, p.Value, m.Delta)
+			case "histogram":
+				cumulative := 0.0
+				for i, count := range p.BucketCounts {
+					cumulative += count
+					le := "+Inf"
+					if i < len(p.Bounds) {
+						le = strconv.FormatFloat(p.Bounds[i], 'g', -1, 64)
+					}
+					set(name+"_bucket", withLabel(labels, "le", le), cumulative, m.Delta)
+				}
+				set(name+"_sum", labels, p.Sum, m.Delta)
+				set(name+"_count", labels, p.Count, m.Delta)
+			case "summary":
+				for _, q := range p.Quantiles {
+					set(name, withLabel(labels, "quantile", strconv.FormatFloat(q[0], 'g', -1, 64)), q[1], false)
+				}
+				set(name+"_sum", labels, p.Sum, false)
+				set(name+"_count", labels, p.Count, false)
+			}
+		}
+	}
+
+	r.requests++
+	r.dataPoints += int64(points)
+	r.lastReceived = now
+	return points
+}
+
+// OTLPStats are the receiver counters served at /api/v1/otlp
+type OTLPStats struct {
+	Requests     int64 `json:"requests"`
+	DataPoints   int64 `json:"data_points"`
+	Failures     int64 `json:"failures"`
+	ActiveSeries int   `json:"active_series"`
+	LastReceived int64 `json:"last_received"` // Unix seconds, 0 before the first export
+}
+
+// Stats returns receiver counters
+func (r *OTLPReceiver) Stats() OTLPStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	lastReceived := int64(0)
+	if !r.lastReceived.IsZero() {
+		lastReceived = r.lastReceived.Unix()
+	}
+	return OTLPStats{
+		Requests:     r.requests,
+		DataPoints:   r.dataPoints,
+		Failures:     r.failures,
+		ActiveSeries: len(r.series),
+		LastReceived: lastReceived,
+	}
+}
+
+func (r *OTLPReceiver) recordFailure() {
+	r.mu.Lock()
+	r.failures++
+	r.mu.Unlock()
+}
+
+// seriesKey identifies a series by name and sorted labels
+func seriesKey(name string, labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(name)
+	for _, k := range keys {
+		b.WriteString("|" + k + "=" + labels[k])
+	}
+	return b.String()
+}
+
+// withLabel returns a copy of labels with one extra label
+func withLabel(labels map[string]string, key, value string) map[string]string {
+	result := make(map[string]string, len(labels)+1)
+	for k, v := range labels {
+		result[k] = v
+	}
+	result[key] = value
+	return result
+}
+
+// otlpLabels converts resource and point attributes to Prometheus labels,
+// mapping service.name/service.instance.id to job/instance
+func otlpLabels(resource, attributes map[string]string) map[string]string {
+	labels := make(map[string]string, len(attributes)+2)
+	if job := resource["service.name"]; job != "" {
+		labels["job"] = job
+	}
+	if instance := resource["service.instance.id"]; instance != "" {
+		labels["instance"] = instance
+	}
+	for k, v := range attributes {
+		labels[sanitizeMetricName(k)] = v
+	}
+	return labels
+}
+
+// sanitizeMetricName maps OTEL names (dots, dashes) to Prometheus names
+func sanitizeMetricName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' || r == ':' {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// requireOTLPToken checks the bearer token of an OTLP push against
+// OTLP_TOKEN. Pushed series feed the dashboard's metrics, so the receiver
+// is off until a token is set.
+func requireOTLPToken(c *gin.Context) {
+	token := os.Getenv("OTLP_TOKEN")
+	if token == "" {
+		c.AbortWithStatusJSON(http.StatusForbidden, APIError{Error: "OTLP receiver disabled (OTLP_TOKEN not set)"})
+		return
+	}
+	provided := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, APIError{Error: "Invalid OTLP token"})
+		return
+	}
+	c.Next()
+}
+
+// handleOTLPMetrics implements the OTLP/HTTP metrics endpoint (POST /v1/metrics)
+func handleOTLPMetrics(c *gin.Context) {
+	receiver := GetOTLPReceiver()
+	if receiver == nil {
+		c.JSON(http.StatusServiceUnavailable, APIError{Error: "OTLP receiver not initialized"})
+		return
+	}
+
+	var body io.Reader = c.Request.Body
+	if c.GetHeader("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(c.Request.Body)
+		if err != nil {
+			receiver.recordFailure()
+			c.JSON(http.StatusBadRequest, APIError{Error: "invalid gzip body"})
+			return
+		}
+		defer gz.Close()
+		body = gz
+	}
+
+	payload, err := io.ReadAll(io.LimitReader(body, otlpMaxBodyBytes+1))
+	if err != nil || len(payload) > otlpMaxBodyBytes {
+		receiver.recordFailure()
+		c.JSON(http.StatusRequestEntityTooLarge, APIError{Error: "request body too large or unreadable"})
+		return
+	}
+
+	isJSON := strings.HasPrefix(c.ContentType(), "application/json")
+	var metrics []otlpMetric
+	if isJSON {
+		metrics, err = decodeOTLPJSON(payload)
+	} else if c.ContentType() == "application/x-protobuf" || c.ContentType() == "" {
+		metrics, err = decodeOTLPProtobuf(payload)
+	} else {
+		receiver.recordFailure()
+		c.JSON(http.StatusUnsupportedMediaType, APIError{Error: "use application/x-protobuf or application/json"})
+		return
+	}
+	if err != nil {
+		receiver.recordFailure()
+		log.Printf("OTLP metrics decode error: %v", err)
+		c.JSON(http.StatusBadRequest, APIError{Error: err.Error()})
+		return
+	}
+
+	receiver.Ingest(metrics)
+	if collector := GetPrometheusCollector(); collector != nil {
+		if err := collector.Refresh(); err != nil {
+			log.Printf("OTLP metrics ingest error: %v", err)
+		}
+	}
+
+	// Empty ExportMetricsServiceResponse (full success)
+	if isJSON {
+		c.JSON(http.StatusOK, gin.H{})
+		return
+	}
+	c.Data(http.StatusOK, "application/x-protobuf", []byte{})
+}
+
+// handleOTLPStatus returns receiver statistics
+func handleOTLPStatus(c *gin.Context) {
+	receiver := GetOTLPReceiver()
+	if receiver == nil {
+		c.JSON(http.StatusServiceUnavailable, APIError{Error: "OTLP receiver not initialized"})
+		return
+	}
+	c.JSON(http.StatusOK, receiver.Stats())
+}
+
+// ---- JSON encoding ----
+
+type otlpJSONRequest struct {
+	ResourceMetrics []struct {
+		Resource struct {
+			Attributes []otlpJSONKeyValue `json:"attributes"`
+		} `json:"resource"`
+		ScopeMetrics []struct {
+			Metrics []otlpJSONMetric `json:"metrics"`
+		} `json:"scopeMetrics"`
+	} `json:"resourceMetrics"`
+}
+
+type otlpJSONKeyValue struct {
+	Key   string `json:"key"`
+	Value struct {
+		StringValue *string          `json:"stringValue"`
+		BoolValue   *bool            `json:"boolValue"`
+		IntValue    *json.RawMessage `json:"intValue"`
+		DoubleValue *float64         `json:"doubleValue"`
+	} `json:"value"`
+}
+
+type otlpJSONNumberPoint struct {
+	Attributes []otlpJSONKeyValue `json:"attributes"`
+	AsDouble   *float64           `json:"asDouble"`
+	AsInt      *json.RawMessage   `json:"asInt"` // int64 is a JSON string in OTLP
+}
+
+type otlpJSONMetric struct {
+	Name  string `json:"name"`
+	Gauge *struct {
+		DataPoints []otlpJSONNumberPoint `json:"dataPoints"`
+	} `json:"gauge"`
+	Sum *struct {
+		DataPoints             []otlpJSONNumberPoint `json:"dataPoints"`
+		AggregationTemporality int                   `json:"aggregationTemporality"`
+		IsMonotonic            bool                  `json:"isMonotonic"`
+	} `json:"sum"`
+	Histogram *struct {
+		DataPoints []struct {
+			Attributes     []otlpJSONKeyValue `json:"attributes"`
+			Count          json.RawMessage    `json:"count"`
+			Sum            *float64           `json:"sum"`
+			BucketCounts   []json.RawMessage  `json:"bucketCounts"`
+			ExplicitBounds []float64          `json:"explicitBounds"`
+		} `json:"dataPoints"`
+		AggregationTemporality int `json:"aggregationTemporality"`
+	} `json:"histogram"`
+	Summary *struct {
+		DataPoints []struct {
+			Attributes     []otlpJSONKeyValue `json:"attributes"`
+			Count          json.RawMessage    `json:"count"`
+			Sum            float64            `json:"sum"`
+			QuantileValues []struct {
+				Quantile float64 `json:"quantile"`
+				Value    float64 `json:"value"`
+			} `json:"quantileValues"`
+		} `json:"dataPoints"`
+	} `json:"summary"`
+}
+
+// otlpDeltaTemporality is AGGREGATION_TEMPORALITY_DELTA
+const otlpDeltaTemporality = 1
+
+// jsonNumber parses an OTLP JSON integer, which may be a string or a number
+func jsonNumber(raw json.RawMessage) float64 {
+	s := strings.Trim(string(raw), `"`)
+	v, _ := strconv.ParseFloat(s, 64)
+	return v
+}
+
+func otlpJSONAttributes(kvs []otlpJSONKeyValue) map[string]string {
+	attrs := make(map[string]string, len(kvs))
+	for _, kv := range kvs {
+		switch {
+		case kv.Value.StringValue != nil:
+			attrs[kv.Key] = *kv.Value.StringValue
+		case kv.Value.BoolValue != nil:
+			attrs[kv.Key] = strconv.FormatBool(*kv.Value.BoolValue)
+		case kv.Value.IntValue != nil:
+			attrs[kv.Key] = strings.Trim(string(*kv.Value.IntValue), `"`)
+		case kv.Value.DoubleValue != nil:
+			attrs[kv.Key] = strconv.FormatFloat(*kv.Value.DoubleValue, 'g', -1, 64)
+		}
+	}
+	return attrs
+}
+
+func decodeOTLPJSON(payload []byte) ([]otlpMetric, error) {
+	var request otlpJSONRequest
+	if err := json.Unmarshal(payload, &request); err != nil {
+		return nil, fmt.Errorf("invalid OTLP JSON: %w", err)
+	}
+
+	var metrics []otlpMetric
+	for _, rm := range request.ResourceMetrics {
+		resource := otlpJSONAttributes(rm.Resource.Attributes)
+		for _, sm := range rm.ScopeMetrics {
+			for _, jm := range sm.Metrics {
+				m := otlpMetric{Name: jm.Name, Resources: resource}
+				numberPoints := func(points []otlpJSONNumberPoint) {
+					for _, p := range points {
+						point := otlpPoint{Attributes: otlpJSONAttributes(p.Attributes)}
+						if p.AsDouble != nil {
+							point.Value = *p.AsDouble
+						} else if p.AsInt != nil {
+							point.Value = jsonNumber(*p.AsInt)
+						}
+						m.Points = append(m.Points, point)
+					}
+				}
+
+				switch {
+				case jm.Gauge != nil:
+					m.Kind = "gauge"
+					numberPoints(jm.Gauge.DataPoints)
+				case jm.Sum != nil:
+					m.Kind = "gauge"
+					if jm.Sum.IsMonotonic {
+						m.Kind = "counter"
+						m.Delta = jm.Sum.AggregationTemporality == otlpDeltaTemporality
+					}
+					numberPoints(jm.Sum.DataPoints)
+				case jm.Histogram != nil:
+					m.Kind = "histogram"
+					m.Delta = jm.Histogram.AggregationTemporality == otlpDeltaTemporality
+					for _, p := range jm.Histogram.DataPoints {
+						point := otlpPoint{
+							Attributes: otlpJSONAttributes(p.Attributes),
+							Count:      jsonNumber(p.Count),
+							Bounds:     p.ExplicitBounds,
+						}
+						if p.Sum != nil {
+							point.Sum = *p.Sum
+						}
+						for _, bc := range p.BucketCounts {
+							point.BucketCounts = append(point.BucketCounts, jsonNumber(bc))
+						}
+						m.Points = append(m.Points, point)
+					}
+				case jm.Summary != nil:
+					m.Kind = "summary"
+					for _, p := range jm.Summary.DataPoints {
+						point := otlpPoint{
+							Attributes: otlpJSONAttributes(p.Attributes),
+							Count:      jsonNumber(p.Count),
+							Sum:        p.Sum,
+						}
+						for _, q := range p.QuantileValues {
+							point.Quantiles = append(point.Quantiles, [2]float64{q.Quantile, q.Value})
+						}
+						m.Points = append(m.Points, point)
+					}
+				default:
+					continue // Exponential histograms are not supported
+				}
+				metrics = append(metrics, m)
+			}
+		}
+	}
+	return metrics, nil
+}
+
+// ---- Protobuf encoding ----
+//
+// Minimal wire-format decoder for the subset of opentelemetry-proto used by
+// metrics exports. Field numbers follow opentelemetry/proto/metrics/v1.
+
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+var errProtoTruncated = errors.New("truncated protobuf message")
+
+// protoReader walks the fields of one protobuf message
+type protoReader struct {
+	buf []byte
+	pos int
+}
+
+// next returns the next field number and wire type; ok is false at the end
+func (p *protoReader) next() (field int, wire int, ok bool, err error) {
+	if p.pos >= len(p.buf) {
+		return 0, 0, false, nil
+	}
+	key, err := p.varint()
+	if err != nil {
+		return 0, 0, false, err
+	}
+	return int(key >> 3), int(key & 7), true, nil
+}
+
+func (p *protoReader) varint() (uint64, error) {
+	v, n := binary.Uvarint(p.buf[p.pos:])
+	if n <= 0 {
+		return 0, errProtoTruncated
+	}
+	p.pos += n
+	return v, nil
+}
+
+func (p *protoReader) fixed64() (uint64, error) {
+	if p.pos+8 > len(p.buf) {
+		return 0, errProtoTruncated
+	}
+	v := binary.LittleEndian.Uint64(p.buf[p.pos:])
+	p.pos += 8
+	return v, nil
+}
+
+func (p *protoReader) bytes() ([]byte, error) {
+	n, err := p.varint()
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(p.buf)-p.pos) < n {
+		return nil, errProtoTruncated
+	}
+	b := p.buf[p.pos : p.pos+int(n)]
+	p.pos += int(n)
+	return b, nil
+}
+
+func (p *protoReader) double() (float64, error) {
+	v, err := p.fixed64()
+	return math.Float64frombits(v), err
+}
+
+// skip discards a field of the given wire type
+func (p *protoReader) skip(wire int) error {
+	switch wire {
+	case wireVarint:
+		_, err := p.varint()
+		return err
+	case wireFixed64:
+		_, err := p.fixed64()
+		return err
+	case wireBytes:
+		_, err := p.bytes()
+		return err
+	case wireFixed32:
+		if p.pos+4 > len(p.buf) {
+			return errProtoTruncated
+		}
+		p.pos += 4
+		return nil
+	}
+	return fmt.Errorf("unsupported wire type %d", wire)
+}
+
+// eachField calls fn for every field of msg; fn must consume or skip the value
+func eachField(msg []byte, fn func(p *protoReader, field, wire int) error) error {
+	p := &protoReader{buf: msg}
+	for {
+		field, wire, ok, err := p.next()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return nil
+		}
+		if err := fn(p, field, wire); err != nil {
+			return err
+		}
+	}
+}
+
+// embedded reads a length-delimited field and passes it to fn
+func embedded(p *protoReader, fn func(msg []byte) error) error {
+	msg, err := p.bytes()
+	if err != nil {
+		return err
+	}
+	return fn(msg)
+}
+
+func decodeOTLPProtobuf(payload []byte) ([]otlpMetric, error) {
+	var metrics []otlpMetric
+
+	// ExportMetricsServiceRequest { repeated ResourceMetrics resource_metrics = 1; }
+	err := eachField(payload, func(p *protoReader, field, wire int) error {
+		if field != 1 || wire != wireBytes {
+			return p.skip(wire)
+		}
+		return embedded(p, func(rm []byte) error {
+			decoded, err := decodeResourceMetrics(rm)
+			metrics = append(metrics, decoded...)
+			return err
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid OTLP protobuf: %w", err)
+	}
+	return metrics, nil
+}
+
+// decodeResourceMetrics: { Resource resource = 1; repeated ScopeMetrics scope_metrics = 2; }
+func decodeResourceMetrics(msg []byte) ([]otlpMetric, error) {
+	resource := map[string]string{}
+	var scopes [][]byte
+
+	err := eachField(msg, func(p *protoReader, field, wire int) error {
+		switch {
+		case field == 1 && wire == wireBytes:
+			// Resource { repeated KeyValue attributes = 1; }
+			return embedded(p, func(res []byte) error {
+				return eachField(res, func(p *protoReader, field, wire int) error {
+					if field != 1 || wire != wireBytes {
+						return p.skip(wire)
+					}
+					return embedded(p, func(kv []byte) error { return decodeKeyValue(kv, resource) })
+				})
+			})
+		case field == 2 && wire == wireBytes:
+			b, err := p.bytes()
+			scopes = append(scopes, b)
+			return err
+		}
+		return p.skip(wire)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// ScopeMetrics { InstrumentationScope scope = 1; repeated Metric metrics = 2; }
+	var metrics []otlpMetric
+	for _, scope := range scopes {
+		err := eachField(scope, func(p *protoReader, field, wire int) error {
+			if field != 2 || wire != wireBytes {
+				return p.skip(wire)
+			}
+			return embedded(p, func(m []byte) error {
+				metric, ok, err := decodeMetric(m)
+				if ok {
+					metric.Resources = resource
+					metrics = append(metrics, metric)
+				}
+				return err
+			})
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return metrics, nil
+}
+
+// decodeKeyValue: { string key = 1; AnyValue value = 2; }
+// AnyValue: { string string_value = 1; bool bool_value = 2; int64 int_value = 3; double double_value = 4; }
+func decodeKeyValue(msg []byte, into map[string]string) error {
+	var key, value string
+	err := eachField(msg, func(p *protoReader, field, wire int) error {
+		switch {
+		case field == 1 && wire == wireBytes:
+			b, err := p.bytes()
+			key = string(b)
+			return err
+		case field == 2 && wire == wireBytes:
+			return embedded(p, func(any []byte) error {
+				return eachField(any, func(p *protoReader, field, wire int) error {
+					switch {
+					case field == 1 && wire == wireBytes:
+						b, err := p.bytes()
+						value = string(b)
+						return err
+					case field == 2 && wire == wireVarint:
+						v, err := p.varint()
+						value = strconv.FormatBool(v != 0)
+						return err
+					case field == 3 && wire == wireVarint:
+						v, err := p.varint()
+						value = strconv.FormatInt(int64(v), 10)
+						return err
+					case field == 4 && wire == wireFixed64:
+						v, err := p.double()
+						value = strconv.FormatFloat(v, 'g', -1, 64)
+						return err
+					}
+					return p.skip(wire)
+				})
+			})
+		}
+		return p.skip(wire)
+	})
+	if key != "" {
+		into[key] = value
+	}
+	return err
+}
+
+// decodeMetric: { string name = 1; Gauge gauge = 5; Sum sum = 7;
+// Histogram histogram = 9; Summary summary = 11; }
+func decodeMetric(msg []byte) (otlpMetric, bool, error) {
+	m := otlpMetric{}
+	supported := false
+
+	err := eachField(msg, func(p *protoReader, field, wire int) error {
+		if wire != wireBytes {
+			return p.skip(wire)
+		}
+		switch field {
+		case 1:
+			b, err := p.bytes()
+			m.Name = string(b)
+			return err
+		case 5: // Gauge { repeated NumberDataPoint data_points = 1; }
+			m.Kind, supported = "gauge", true
+			return embedded(p, func(g []byte) error { return decodeDataPoints(g, &m, decodeNumberPoint) })
+		case 7: // Sum { data_points = 1; aggregation_temporality = 2; is_monotonic = 3; }
+			supported = true
+			return embedded(p, func(s []byte) error {
+				monotonic, delta := false, false
+				err := eachField(s, func(p *protoReader, field, wire int) error {
+					switch {
+					case field == 1 && wire == wireBytes:
+						return embedded(p, func(dp []byte) error { return appendPoint(dp, &m, decodeNumberPoint) })
+					case field == 2 && wire == wireVarint:
+						v, err := p.varint()
+						delta = v == otlpDeltaTemporality
+						return err
+					case field == 3 && wire == wireVarint:
+						v, err := p.varint()
+						monotonic = v != 0
+						return err
+					}
+					return p.skip(wire)
+				})
+				m.Kind = "gauge"
+				if monotonic {
+					m.Kind, m.Delta = "counter", delta
+				}
+				return err
+			})
+		case 9: // Histogram { data_points = 1; aggregation_temporality = 2; }
+			m.Kind, supported = "histogram", true
+			return embedded(p, func(h []byte) error {
+				return eachField(h, func(p *protoReader, field, wire int) error {
+					switch {
+					case field == 1 && wire == wireBytes:
+						return embedded(p, func(dp []byte) error { return appendPoint(dp, &m, decodeHistogramPoint) })
+					case field == 2 && wire == wireVarint:
+						v, err := p.varint()
+						m.Delta = v == otlpDeltaTemporality
+						return err
+					}
+					return p.skip(wire)
+				})
+			})
+		case 11: // Summary { repeated SummaryDataPoint data_points = 1; }
+			m.Kind, supported = "summary", true
+			return embedded(p, func(s []byte) error { return decodeDataPoints(s, &m, decodeSummaryPoint) })
+		}
+		return p.skip(wire)
+	})
+	return m, supported, err
+}
+
+// decodeDataPoints decodes field 1 (data_points) of a Gauge or Summary
+func decodeDataPoints(msg []byte, m *otlpMetric, decode func([]byte) (otlpPoint, error)) error {
+	return eachField(msg, func(p *protoReader, field, wire int) error {
+		if field != 1 || wire != wireBytes {
+			return p.skip(wire)
+		}
+		return embedded(p, func(dp []byte) error { return appendPoint(dp, m, decode) })
+	})
+}
+
+func appendPoint(msg []byte, m *otlpMetric, decode func([]byte) (otlpPoint, error)) error {
+	point, err := decode(msg)
+	if err != nil {
+		return err
+	}
+	m.Points = append(m.Points, point)
+	return nil
+}
+
+// decodeNumberPoint: { double as_double = 4; sfixed64 as_int = 6; repeated KeyValue attributes = 7; }
+func decodeNumberPoint(msg []byte) (otlpPoint, error) {
+	point := otlpPoint{Attributes: map[string]string{}}
+	err := eachField(msg, func(p *protoReader, field, wire int) error {
+		switch {
+		case field == 4 && wire == wireFixed64:
+			v, err := p.double()
+			point.Value = v
+			return err
+		case field == 6 && wire == wireFixed64:
+			v, err := p.fixed64()
+			point.Value = float64(int64(v))
+			return err
+		case field == 7 && wire == wireBytes:
+			return embedded(p, func(kv []byte) error { return decodeKeyValue(kv, point.Attributes) })
+		}
+		return p.skip(wire)
+	})
+	return point, err
+}
+
+// decodeHistogramPoint: { fixed64 count = 4; double sum = 5; repeated fixed64
+// bucket_counts = 6; repeated double explicit_bounds = 7; repeated KeyValue attributes = 9; }
+func decodeHistogramPoint(msg []byte) (otlpPoint, error) {
+	point := otlpPoint{Attributes: map[string]string{}}
+	err := eachField(msg, func(p *protoReader, field, wire int) error {
+		switch {
+		case field == 4 && wire == wireFixed64:
+			v, err := p.fixed64()
+			point.Count = float64(v)
+			return err
+		case field == 5 && wire == wireFixed64:
+			v, err := p.double()
+			point.Sum = v
+			return err
+		case field == 6:
+			return readFixed64s(p, wire, func(v uint64) { point.BucketCounts = append(point.BucketCounts, float64(v)) })
+		case field == 7:
+			return readFixed64s(p, wire, func(v uint64) { point.Bounds = append(point.Bounds, math.Float64frombits(v)) })
+		case field == 9 && wire == wireBytes:
+			return embedded(p, func(kv []byte) error { return decodeKeyValue(kv, point.Attributes) })
+		}
+		return p.skip(wire)
+	})
+	return point, err
+}
+
+// decodeSummaryPoint: { fixed64 count = 4; double sum = 5; repeated
+// ValueAtQuantile quantile_values = 6; repeated KeyValue attributes = 7; }
+func decodeSummaryPoint(msg []byte) (otlpPoint, error) {
+	point := otlpPoint{Attributes: map[string]string{}}
+	err := eachField(msg, func(p *protoReader, field, wire int) error {
+		switch {
+		case field == 4 && wire == wireFixed64:
+			v, err := p.fixed64()
+			point.Count = float64(v)
+			return err
+		case field == 5 && wire == wireFixed64:
+			v, err := p.double()
+			point.Sum = v
+			return err
+		case field == 6 && wire == wireBytes:
+			// ValueAtQuantile { double quantile = 1; double value = 2; }
+			return embedded(p, func(q []byte) error {
+				var pair [2]float64
+				err := eachField(q, func(p *protoReader, field, wire int) error {
+					if (field == 1 || field == 2) && wire == wireFixed64 {
+						v, err := p.double()
+						pair[field-1] = v
+						return err
+					}
+					return p.skip(wire)
+				})
+				point.Quantiles = append(point.Quantiles, pair)
+				return err
+			})
+		case field == 7 && wire == wireBytes:
+			return embedded(p, func(kv []byte) error { return decodeKeyValue(kv, point.Attributes) })
+		}
+		return p.skip(wire)
+	})
+	return point, err
+}
+
+// readFixed64s reads a repeated fixed64/double field, packed or not
+func readFixed64s(p *protoReader, wire int, fn func(uint64)) error {
+	switch wire {
+	case wireFixed64:
+		v, err := p.fixed64()
+		if err == nil {
+			fn(v)
+		}
+		return err
+	case wireBytes:
+		packed, err := p.bytes()
+		if err != nil {
+			return err
+		}
+		if len(packed)%8 != 0 {
+			return errProtoTruncated
+		}
+		for i := 0; i < len(packed); i += 8 {
+			fn(binary.LittleEndian.Uint64(packed[i:]))
+		}
+		return nil
+	}
+	return p.skip(wire)
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PendingTxMeter measures mempool ingress from the node's
+// newPendingTransactions subscription. The measured rate replaces txpool
+// counter rates in the waterfall's Submission stage while subscribed.
+type PendingTxMeter struct {
+	mu sync.Mutex
+
+	// Per-second arrival counts over the last minute
+	buckets     [60]int64
+	bucketSecs  [60]int64
+	total       int64
+	duplicates  int64
+	lastSeen    time.Time
+	subscribed  bool
+	subscribeAt time.Time
+
+	// Recently seen hashes, so re-announced transactions are counted once
+	seen      map[string]struct{}
+	seenOrder []string
+	maxSeen   int
+
+	// Full-body details, when PENDING_TX_BODIES is enabled
+	fullBodies        bool
+	txTypes           map[string]int64
+	contractCreations int64
+}
+
+// Global pending transaction meter
+var (
+	pendingTxMeter     *PendingTxMeter
+	pendingTxMeterOnce sync.Once
+)
+
+// GetPendingTxMeter returns the global pending transaction meter
+func GetPendingTxMeter() *PendingTxMeter {
+	pendingTxMeterOnce.Do(func() {
+		pendingTxMeter = &PendingTxMeter{
+			seen:       make(map[string]struct{}),
+			seenOrder:  make([]string, 0, 1024),
+			maxSeen:    50000,
+			fullBodies: os.Getenv("PENDING_TX_BODIES") == "true",
+			txTypes:    make(map[string]int64),
+		}
+	})
+	return pendingTxMeter
+}
+
+// pendingTxSubscriptionEnabled reports whether to subscribe to
+// newPendingTransactions (PENDING_TX_SUBSCRIBE, default true)
+func pendingTxSubscriptionEnabled() bool {
+	return os.Getenv("PENDING_TX_SUBSCRIBE") != "false"
+}
+
+// SetSubscribed records whether the subscription is active
+func (m *PendingTxMeter) SetSubscribed(subscribed bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if subscribed && !m.subscribed {
+		m.subscribeAt = time.Now()
+	}
+	m.subscribed = subscribed
+}
+
+// Record counts a pending transaction; tx is its body when subscribed with
+// full bodies, else nil
+func (m *PendingTxMeter) Record(hash string, tx map[string]interface{}) {
+	hash = normalizeTxHash(hash)
+	now := time.Now()
+
+	m.mu.Lock()
+	if _, dup := m.seen[hash]; dup {
+		m.duplicates++
+		m.mu.Unlock()
+		return
+	}
+	m.seen[hash] = struct{}{}
+	m.seenOrder = append(m.seenOrder, hash)
+	if len(m.seenOrder) > m.maxSeen {
+		evict := len(m.seenOrder) - m.maxSeen
+		for _, old := range m.seenOrder[:evict] {
+			delete(m.seen, old)
+		}
+		m.seenOrder = append(m.seenOrder[:0], m.seenOrder[evict:]...)
+	}
+
+	sec := now.Unix()
+	slot := sec % int64(len(m.buckets))
+	if m.bucketSecs[slot] != sec {
+		m.bucketSecs[slot] = sec
+		m.buckets[slot] = 0
+	}
+	m.buckets[slot]++
+	m.total++
+	m.lastSeen = now
+
+	if tx != nil {
+		txType, _ := tx["type"].(string)
+		if txType == "" {
+			txType = "0x0"
+		}
+		m.txTypes[txType]++
+		if to, _ := tx["to"].(string); to == "" {
+			m.contractCreations++
+		}
+	}
+	m.mu.Unlock()
+
+	// Mempool arrival is the first stage of the transaction lifecycle
+	GetTxLifecycleCorrelator().OnMempoolInsert(hash, "")
+
+	// Bodies carry the sender and nonce for nonce gap analysis
+	if tx != nil {
+		GetNonceGapTracker().OnPendingTx(hash, tx)
+	}
+}
+
+// Measured returns the number of transactions that arrived in the last
+// window of whole seconds, and false while not subscribed or when the
+// window is longer than the minute the meter keeps
+func (m *PendingTxMeter) Measured(window time.Duration) (int64, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.subscribed || window > time.Duration(len(m.buckets))*time.Second {
+		return 0, false
+	}
+	return m.countLocked(window), true
+}
+
+// countLocked sums the buckets of the last window of completed seconds; caller holds m.mu
+func (m *PendingTxMeter) countLocked(window time.Duration) int64 {
+	seconds := int64(window / time.Second)
+	if seconds > int64(len(m.buckets)) {
+		seconds = int64(len(m.buckets))
+	}
+	now := time.Now().Unix()
+	var count int64
+	for sec := now - seconds; sec < now; sec++ {
+		slot := sec % int64(len(m.buckets))
+		if m.bucketSecs[slot] == sec {
+			count += m.buckets[slot]
+		}
+	}
+	return count
+}
+
+// PendingTxResponse is the body of /api/v1/mempool/pending
+type PendingTxResponse struct {
+	Enabled         bool    `json:"enabled"`
+	Subscribed      bool    `json:"subscribed"`
+	FullBodies      bool    `json:"full_bodies"`
+	Total           int64   `json:"total"`
+	Duplicates      int64   `json:"duplicates"`
+	Rate5s          float64 `json:"rate_5s"`
+	Rate60s         float64 `json:"rate_60s"`
+	LastSeen        int64   `json:"last_seen,omitempty"`
+	SubscribedSince int64   `json:"subscribed_since,omitempty"`
+	*PendingTxBodyStats
+}
+
+// PendingTxBodyStats are reported when the node sends full transaction bodies
+type PendingTxBodyStats struct {
+	TxTypes           map[string]int64 `json:"tx_types"`
+	ContractCreations int64            `json:"contract_creations"`
+}
+
+// Snapshot returns the meter state for the API
+func (m *PendingTxMeter) Snapshot() PendingTxResponse {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	snapshot := PendingTxResponse{
+		Subscribed: m.subscribed,
+		FullBodies: m.fullBodies,
+		Total:      m.total,
+		Duplicates: m.duplicates,
+		Rate5s:     float64(m.countLocked(5*time.Second)) / 5,
+		Rate60s:    float64(m.countLocked(60*time.Second)) / 60,
+	}
+	if !m.lastSeen.IsZero() {
+		snapshot.LastSeen = m.lastSeen.Unix()
+	}
+	if m.subscribed {
+		snapshot.SubscribedSince = m.subscribeAt.Unix()
+	}
+	if m.fullBodies {
+		types := make(map[string]int64, len(m.txTypes))
+		for txType, count := range m.txTypes {
+			types[txType] = count
+		}
+		snapshot.PendingTxBodyStats = &PendingTxBodyStats{TxTypes: types, ContractCreations: m.contractCreations}
+	}
+	return snapshot
+}
+
+// handlePendingTxMessage processes a newPendingTransactions notification,
+// which carries either a hash or a full transaction body
+func (s *MonadSubscriber) handlePendingTxMessage(msg map[string]interface{}) {
+	params, ok := msg["params"].(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	switch result := params["result"].(type) {
+	case string:
+		GetPendingTxMeter().Record(result, nil)
+	case map[string]interface{}:
+		if hash, _ := result["hash"].(string); hash != "" {
+			GetPendingTxMeter().Record(hash, result)
+		}
+	}
+}
+
+// splitSubmission divides a measured ingress count between RPC and P2P in
+// the proportion of the txpool counters. Without counters the origin is
+// unknown and everything is attributed to P2P, the common path on validators.
+func splitSubmission(measured, rpcCounter, p2pCounter int64) (rpc, p2p int64) {
+	if total := rpcCounter + p2pCounter; total > 0 {
+		rpc = measured * rpcCounter / total
+		return rpc, measured - rpc
+	}
+	return 0, measured
+}
+
+// handlePendingTxs serves the mempool ingress measured from pending transactions
+func handlePendingTxs(c *gin.Context) {
+	snapshot := GetPendingTxMeter().Snapshot()
+	snapshot.Enabled = pendingTxSubscriptionEnabled()
+	c.JSON(http.StatusOK, snapshot)
+}
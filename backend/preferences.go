@@ -0,0 +1,445 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Server-side frontend preferences (layout, selected panels, watchlists, ...)
+// so they follow a user across browsers. Each owner holds a small map of
+// JSON values. With WS auth enabled the owner is the subject of the session
+// token sent as a bearer token; otherwise it is an opaque client token the
+// frontend generates once and sends as X-Client-Token (only its hash is
+// stored). Client tokens cost nothing to make up, so writes are rate
+// limited per client address and the store as a whole is capped.
+
+const (
+	// maxPreferenceSize caps one encoded value
+	maxPreferenceSize = 64 * 1024
+
+	// maxPreferenceKeys caps the keys of one owner
+	maxPreferenceKeys = 100
+
+	// maxPreferenceOwners and maxPreferenceBytes (keys and values of every
+	// owner) cap the store. Owners idle for preferenceOwnerIdle make room
+	// for new writes; other owners are never dropped, writes are refused.
+	maxPreferenceOwners = 10000
+	maxPreferenceBytes  = 64 << 20
+	preferenceOwnerIdle = 90 * 24 * time.Hour
+
+	// preferenceSaveInterval batches writes to PREFERENCES_PATH
+	preferenceSaveInterval = 2 * time.Second
+
+	// Writes (PUT and DELETE) allowed per client address: one a second,
+	// bursts of 30
+	preferenceWriteRate  = 1
+	preferenceWriteBurst = 30
+)
+
+var (
+	preferenceKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,64}$`)
+	clientTokenPattern   = regexp.MustCompile(`^[A-Za-z0-9_-]{16,128}$`)
+)
+
+// Errors identifying the preferences owner
+var (
+	errPreferenceOwnerMissing = errors.New("send a session token as a bearer token or a client token as X-Client-Token")
+	errClientTokenInvalid     = errors.New("X-Client-Token must be 16-128 characters of A-Z, a-z, 0-9, _ or -")
+	errTooManyPreferences     = fmt.Errorf("at most %d preferences per owner", maxPreferenceKeys)
+	errPreferencesFull        = errors.New("preference store is full")
+)
+
+// preferenceOwner is one identity's stored preferences
+type preferenceOwner struct {
+	Values    map[string]json.RawMessage `json:"values"`
+	UpdatedAt time.Time                  `json:"updated_at"`
+}
+
+// PreferenceStore keeps preferences per owner in PREFERENCES_PATH
+type PreferenceStore struct {
+	path   string
+	writes *rateLimiter
+
+	mu     sync.RWMutex
+	owners map[string]*preferenceOwner // "sub:<subject>" or "client:<token hash>"
+	bytes  int                         // Keys and values of every owner
+	dirty  bool                        // Changed since the last save
+
+	saveMu sync.Mutex // Serializes writes of the file
+}
+
+// Global preference store
+var (
+	preferenceStore   *PreferenceStore
+	preferenceStoreMu sync.RWMutex
+)
+
+// NewPreferenceStore creates a store saved to path by Flush
+func NewPreferenceStore(path string) *PreferenceStore {
+	return &PreferenceStore{
+		path:   path,
+		writes: newRateLimiter(preferenceWriteRate, preferenceWriteBurst),
+		owners: make(map[string]*preferenceOwner),
+	}
+}
+
+// InitializePreferenceStore loads preferences from PREFERENCES_PATH
+// (default preferences.json)
+func InitializePreferenceStore() *PreferenceStore {
+	path := os.Getenv("PREFERENCES_PATH")
+	if path == "" {
+		path = "preferences.json"
+	}
+	ps := NewPreferenceStore(path)
+	if err := ps.load(); err != nil {
+		log.Printf("Preferences %s not loaded: %v", path, err)
+	}
+
+	preferenceStoreMu.Lock()
+	preferenceStore = ps
+	preferenceStoreMu.Unlock()
+
+	go ps.run()
+	return ps
+}
+
+// GetPreferenceStore returns the global preference store
+func GetPreferenceStore() *PreferenceStore {
+	preferenceStoreMu.RLock()
+	defer preferenceStoreMu.RUnlock()
+	return preferenceStore
+}
+
+// load reads preferences saved by a previous run
+func (ps *PreferenceStore) load() error {
+	raw, err := os.ReadFile(ps.path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	owners := make(map[string]*preferenceOwner)
+	if err := json.Unmarshal(raw, &owners); err != nil {
+		return err
+	}
+	total := 0
+	for _, entry := range owners {
+		total += entry.size()
+	}
+	ps.mu.Lock()
+	ps.owners = owners
+	ps.bytes = total
+	ps.mu.Unlock()
+	return nil
+}
+
+// size returns the bytes an owner's keys and values take up
+func (o *preferenceOwner) size() int {
+	n := 0
+	for key, value := range o.Values {
+		n += len(key) + len(value)
+	}
+	return n
+}
+
+// run saves changed preferences every preferenceSaveInterval until
+// shutdown, which flushes them once more
+func (ps *PreferenceStore) run() {
+	ticker := time.NewTicker(preferenceSaveInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-shutdownContext().Done():
+			return
+		case <-ticker.C:
+			if err := ps.Flush(); err != nil {
+				log.Printf("Failed to save preferences: %v", err)
+			}
+		}
+	}
+}
+
+// Flush writes every owner's preferences to disk if they changed since the
+// last save
+func (ps *PreferenceStore) Flush() error {
+	ps.saveMu.Lock()
+	defer ps.saveMu.Unlock()
+
+	ps.mu.Lock()
+	if !ps.dirty {
+		ps.mu.Unlock()
+		return nil
+	}
+	raw, err := json.Marshal(ps.owners)
+	ps.dirty = false
+	ps.mu.Unlock()
+
+	if err == nil {
+		tmp := ps.path + ".tmp"
+		if err = os.WriteFile(tmp, raw, 0600); err == nil {
+			err = os.Rename(tmp, ps.path)
+		}
+	}
+	if err != nil {
+		ps.mu.Lock()
+		ps.dirty = true
+		ps.mu.Unlock()
+	}
+	return err
+}
+
+// Get returns a copy of an owner's preferences and when they last changed
+func (ps *PreferenceStore) Get(owner string) (map[string]json.RawMessage, time.Time) {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+	values := make(map[string]json.RawMessage)
+	entry, ok := ps.owners[owner]
+	if !ok {
+		return values, time.Time{}
+	}
+	for key, value := range entry.Values {
+		values[key] = value
+	}
+	return values, entry.UpdatedAt
+}
+
+// Set stores one preference, saved by the next Flush
+func (ps *PreferenceStore) Set(owner, key string, value json.RawMessage) error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	now := time.Now().UTC()
+	entry, ok := ps.owners[owner]
+	old, exists := json.RawMessage(nil), false
+	if ok {
+		old, exists = entry.Values[key]
+	}
+	if ok && !exists && len(entry.Values) >= maxPreferenceKeys {
+		return errTooManyPreferences
+	}
+	growth := len(key) + len(value)
+	if exists {
+		growth -= len(key) + len(old)
+	}
+	if (!ok && len(ps.owners) >= maxPreferenceOwners) || ps.bytes+growth > maxPreferenceBytes {
+		ps.evictIdleLocked(now, owner)
+		if (!ok && len(ps.owners) >= maxPreferenceOwners) || ps.bytes+growth > maxPreferenceBytes {
+			return errPreferencesFull
+		}
+	}
+
+	if !ok {
+		entry = &preferenceOwner{Values: make(map[string]json.RawMessage)}
+		ps.owners[owner] = entry
+	}
+	entry.Values[key] = value
+	entry.UpdatedAt = now
+	ps.bytes += growth
+	ps.dirty = true
+	return nil
+}
+
+// Delete removes one preference, reporting whether it existed
+func (ps *PreferenceStore) Delete(owner, key string) bool {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	entry, ok := ps.owners[owner]
+	if !ok {
+		return false
+	}
+	value, exists := entry.Values[key]
+	if !exists {
+		return false
+	}
+	delete(entry.Values, key)
+	ps.bytes -= len(key) + len(value)
+	entry.UpdatedAt = time.Now().UTC()
+	if len(entry.Values) == 0 {
+		delete(ps.owners, owner)
+	}
+	ps.dirty = true
+	return true
+}
+
+// evictIdleLocked drops owners other than keep that have not written for
+// preferenceOwnerIdle; caller holds ps.mu
+func (ps *PreferenceStore) evictIdleLocked(now time.Time, keep string) {
+	for owner, entry := range ps.owners {
+		if owner != keep && now.Sub(entry.UpdatedAt) > preferenceOwnerIdle {
+			ps.bytes -= entry.size()
+			delete(ps.owners, owner)
+			ps.dirty = true
+		}
+	}
+}
+
+// preferenceOwnerOf identifies the caller: the session token subject when
+// WS auth is enabled, otherwise the hash of X-Client-Token. Requests under
+// /t/:tenant are kept apart from the same caller's other tenants.
+func preferenceOwnerOf(c *gin.Context) (owner, kind string, status int, err error) {
+	owner, kind, status, err = callerOf(c)
+	if err == nil {
+		if tenant := tenantOf(c); tenant != nil {
+			owner = "tenant:" + tenant.ID + ":" + owner
+		}
+	}
+	return owner, kind, status, err
+}
+
+// callerOf identifies the caller of a preferences request
+func callerOf(c *gin.Context) (owner, kind string, status int, err error) {
+	if a := GetWSAuth(); a != nil {
+		claims, err := a.Validate(strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer "))
+		if err != nil {
+			return "", "", http.StatusUnauthorized, err
+		}
+		return "sub:" + claims.Subject, "session", 0, nil
+	}
+
+	token := c.GetHeader("X-Client-Token")
+	if token == "" {
+		return "", "", http.StatusUnauthorized, errPreferenceOwnerMissing
+	}
+	if !clientTokenPattern.MatchString(token) {
+		return "", "", http.StatusBadRequest, errClientTokenInvalid
+	}
+	sum := sha256.Sum256([]byte(token))
+	return "client:" + hex.EncodeToString(sum[:]), "client_token", 0, nil
+}
+
+// PreferencesResponse is the body of GET /api/v1/preferences
+type PreferencesResponse struct {
+	Owner       string                     `json:"owner"` // "session" or "client_token"
+	Preferences map[string]json.RawMessage `json:"preferences"`
+	UpdatedAt   *time.Time                 `json:"updated_at,omitempty"`
+}
+
+// PreferenceSetResponse is the body of PUT /api/v1/preferences/:key
+type PreferenceSetResponse struct {
+	Stored string `json:"stored"`
+	Bytes  int    `json:"bytes"`
+}
+
+// PreferenceRemoveResponse is the body of DELETE /api/v1/preferences/:key
+type PreferenceRemoveResponse struct {
+	Removed string `json:"removed"`
+}
+
+// preferencesRequest resolves the store and owner of a preferences request,
+// writing the error response when either is unavailable
+func preferencesRequest(c *gin.Context) (*PreferenceStore, string, string, bool) {
+	ps := GetPreferenceStore()
+	if ps == nil {
+		c.JSON(http.StatusServiceUnavailable, APIError{Error: "Preference store not initialized"})
+		return nil, "", "", false
+	}
+	owner, kind, status, err := preferenceOwnerOf(c)
+	if err != nil {
+		c.JSON(status, APIError{Error: err.Error()})
+		return nil, "", "", false
+	}
+	return ps, owner, kind, true
+}
+
+// handlePreferences returns every preference of the caller
+func handlePreferences(c *gin.Context) {
+	ps, owner, kind, ok := preferencesRequest(c)
+	if !ok {
+		return
+	}
+	values, updatedAt := ps.Get(owner)
+	response := PreferencesResponse{Owner: kind, Preferences: values}
+	if !updatedAt.IsZero() {
+		response.UpdatedAt = &updatedAt
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// handlePreferenceGet returns one preference's value
+func handlePreferenceGet(c *gin.Context) {
+	ps, owner, _, ok := preferencesRequest(c)
+	if !ok {
+		return
+	}
+	values, _ := ps.Get(owner)
+	value, exists := values[c.Param("key")]
+	if !exists {
+		c.JSON(http.StatusNotFound, APIError{Error: "Preference not found"})
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", value)
+}
+
+// allowWrite applies the per-client write rate limit, writing the 429
+// response when it is exceeded
+func (ps *PreferenceStore) allowWrite(c *gin.Context) bool {
+	if ps.writes.allow(clientKey(c), time.Now()) {
+		return true
+	}
+	c.JSON(http.StatusTooManyRequests, APIError{Error: "Too many preference writes, slow down"})
+	return false
+}
+
+// handlePreferenceSet stores the JSON request body as one preference
+func handlePreferenceSet(c *gin.Context) {
+	ps, owner, _, ok := preferencesRequest(c)
+	if !ok || !ps.allowWrite(c) {
+		return
+	}
+	key := c.Param("key")
+	if !preferenceKeyPattern.MatchString(key) {
+		c.JSON(http.StatusBadRequest, APIError{Error: "key must be 1-64 characters of A-Z, a-z, 0-9, _, . or -"})
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxPreferenceSize+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, APIError{Error: err.Error()})
+		return
+	}
+	if len(body) > maxPreferenceSize {
+		c.JSON(http.StatusRequestEntityTooLarge, APIError{Error: fmt.Sprintf("value exceeds %d bytes", maxPreferenceSize)})
+		return
+	}
+	var value bytes.Buffer
+	if err := json.Compact(&value, body); err != nil {
+		c.JSON(http.StatusBadRequest, APIError{Error: "body must be a JSON value"})
+		return
+	}
+
+	if err := ps.Set(owner, key, json.RawMessage(value.Bytes())); errors.Is(err, errTooManyPreferences) {
+		c.JSON(http.StatusBadRequest, APIError{Error: err.Error()})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInsufficientStorage, APIError{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, PreferenceSetResponse{Stored: key, Bytes: value.Len()})
+}
+
+// handlePreferenceDelete removes one preference
+func handlePreferenceDelete(c *gin.Context) {
+	ps, owner, _, ok := preferencesRequest(c)
+	if !ok || !ps.allowWrite(c) {
+		return
+	}
+	if !ps.Delete(owner, c.Param("key")) {
+		c.JSON(http.StatusNotFound, APIError{Error: "Preference not found"})
+		return
+	}
+	c.JSON(http.StatusOK, PreferenceRemoveResponse{Removed: c.Param("key")})
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestPreferenceStoreCaps refuses writes past the owner and byte caps
+// instead of dropping active owners, making room only from idle ones
+func TestPreferenceStoreCaps(t *testing.T) {
+	ps := NewPreferenceStore(filepath.Join(t.TempDir(), "preferences.json"))
+	value := json.RawMessage(`"` + strings.Repeat("x", maxPreferenceSize-2) + `"`)
+
+	owners := maxPreferenceBytes/(maxPreferenceKeys*maxPreferenceSize) + 1
+	for o := 0; o < owners; o++ {
+		for k := 0; k < maxPreferenceKeys; k++ {
+			if err := ps.Set(fmt.Sprintf("client:%d", o), fmt.Sprintf("k%d", k), value); err != nil && err != errPreferencesFull {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := ps.Set("client:new", "k", value); err != errPreferencesFull {
+		t.Fatalf("Set past the byte cap = %v, want errPreferencesFull", err)
+	}
+	if ps.bytes > maxPreferenceBytes {
+		t.Fatalf("store holds %d bytes, cap %d", ps.bytes, maxPreferenceBytes)
+	}
+
+	// An idle owner makes room
+	ps.owners["client:1"].UpdatedAt = time.Now().Add(-preferenceOwnerIdle - time.Hour)
+	if err := ps.Set("client:new", "k", value); err != nil {
+		t.Fatalf("Set after an owner went idle: %v", err)
+	}
+	if _, ok := ps.owners["client:1"]; ok {
+		t.Fatal("idle owner kept")
+	}
+
+	// Overwriting with a smaller value still works when full
+	if err := ps.Set("client:0", "k0", json.RawMessage(`1`)); err != nil {
+		t.Fatalf("shrinking a value: %v", err)
+	}
+	if !ps.Delete("client:0", "k0") || ps.Delete("client:0", "k0") {
+		t.Fatal("Delete reported the wrong result")
+	}
+
+	total := 0
+	for _, entry := range ps.owners {
+		total += entry.size()
+	}
+	if total != ps.bytes {
+		t.Fatalf("bytes = %d, owners hold %d", ps.bytes, total)
+	}
+}
+
+// TestPreferenceStoreFlush writes the file only once something changed
+func TestPreferenceStoreFlush(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "preferences.json")
+	ps := NewPreferenceStore(path)
+	if err := ps.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("unchanged store written: %v", err)
+	}
+
+	if err := ps.Set("client:a", "layout", json.RawMessage(`{"panels":3}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := ps.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	loaded := NewPreferenceStore(path)
+	if err := loaded.load(); err != nil {
+		t.Fatal(err)
+	}
+	if values, _ := loaded.Get("client:a"); string(values["layout"]) != `{"panels":3}` {
+		t.Fatalf("loaded %s", values["layout"])
+	}
+	if loaded.bytes != ps.bytes {
+		t.Fatalf("loaded %d bytes, saved %d", loaded.bytes, ps.bytes)
+	}
+}
+
+// TestRateLimiter refills each key's bucket at the rate, up to the burst
+func TestRateLimiter(t *testing.T) {
+	l := newRateLimiter(1, 3)
+	now := time.Unix(1700000000, 0)
+	for i := 0; i < 3; i++ {
+		if !l.allow("a", now) {
+			t.Fatalf("call %d refused within the burst", i)
+		}
+	}
+	if l.allow("a", now) {
+		t.Fatal("call past the burst allowed")
+	}
+	if !l.allow("b", now) {
+		t.Fatal("another key shares the bucket")
+	}
+	if !l.allow("a", now.Add(time.Second)) || l.allow("a", now.Add(time.Second)) {
+		t.Fatal("refill is not one token a second")
+	}
+}
@@ -5,19 +5,32 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"math"
 	"net/http"
+	"os"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/gin-gonic/gin"
 )
 
 // PrometheusCollector collects metrics from Monad's Prometheus/OTEL endpoint
 type PrometheusCollector struct {
 	endpoint   string
 	httpClient *http.Client
+	filter     PrometheusFilter
+	clock      Clock
 	mu         sync.RWMutex
 
+	// Last scraped samples and pushed (OTLP) samples, merged on every update
+	ingestMu     sync.Mutex
+	scraped      []PrometheusSample
+	scrapedTypes map[string]string
+	pushed       func() ([]PrometheusSample, map[string]string)
+
 	// Real metrics from Prometheus
 	metrics *PrometheusMetrics
 }
@@ -54,21 +67,187 @@ type PrometheusMetrics struct {
 	DropInsufficientBalanceRate float64 // Rate of balance failures
 	DropPoolFullRate         float64 // Rate of pool full drops
 
+	// Set when a counter went backwards since the previous scrape (node
+	// restart); affected rates are clamped to zero for this interval
+	Discontinuous bool
+	ResetCounters []string
+
+	// Per-series gauge values (metric name -> series), since gauges from
+	// different processes must not be summed blindly
+	Gauges map[string][]PrometheusSample
+
+	// Histograms and summaries (aggregated across series), and the latency
+	// distributions derived from them in milliseconds
+	Histograms map[string]*PrometheusHistogram
+	Summaries  map[string]*PrometheusSummary
+	Latencies  map[string]LatencyDistribution
+
+	// Jobs and instances seen in the last scrape (after filtering)
+	Jobs      []string
+	Instances []string
+
 	// Timestamps
 	LastUpdated     time.Time
 	LastUpdateTime  time.Time
+
+	// Time between the two scrapes the rates were computed from, zero
+	// before the second scrape
+	RateWindow time.Duration
+}
+
+// PrometheusSample is one labeled series value from the text exposition format
+type PrometheusSample struct {
+	Name   string            `json:"name"`
+	Labels map[string]string `json:"labels,omitempty"`
+	Value  float64           `json:"value"`
+}
+
+// PrometheusFilter restricts which series are aggregated. Empty lists match all.
+type PrometheusFilter struct {
+	Jobs      []string `json:"jobs,omitempty"`
+	Instances []string `json:"instances,omitempty"`
+}
+
+// Matches reports whether a series passes the job/instance filter
+func (f PrometheusFilter) Matches(labels map[string]string) bool {
+	return matchesAny(f.Jobs, labels["job"]) && matchesAny(f.Instances, labels["instance"])
+}
+
+// matchesAny reports whether value is in allowed (an empty list allows everything)
+func matchesAny(allowed []string, value string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	for _, a := range allowed {
+		if a == value {
+			return true
+		}
+	}
+	return false
+}
+
+// prometheusFilterFromEnv reads PROMETHEUS_JOBS and PROMETHEUS_INSTANCES (comma-separated)
+func prometheusFilterFromEnv() PrometheusFilter {
+	return PrometheusFilter{
+		Jobs:      splitList(os.Getenv("PROMETHEUS_JOBS")),
+		Instances: splitList(os.Getenv("PROMETHEUS_INSTANCES")),
+	}
+}
+
+// splitList splits a comma-separated list, dropping empty entries
+func splitList(s string) []string {
+	var result []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			result = append(result, part)
+		}
+	}
+	return result
+}
+
+// parsePrometheusLine parses one sample line: name{label="value",...} value [timestamp]
+func parsePrometheusLine(line string) (PrometheusSample, bool) {
+	sample := PrometheusSample{}
+
+	idx := strings.IndexAny(line, "{ \t")
+	if idx <= 0 {
+		return sample, false
+	}
+	sample.Name = line[:idx]
+	rest := line[idx:]
+
+	if strings.HasPrefix(rest, "{") {
+		labels, remainder, ok := parsePrometheusLabels(rest[1:])
+		if !ok {
+			return sample, false
+		}
+		sample.Labels = labels
+		rest = remainder
+	}
+
+	fields := strings.Fields(rest)
+	if len(fields) < 1 {
+		return sample, false
+	}
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return sample, false
+	}
+	sample.Value = value
+	return sample, true
+}
+
+// parsePrometheusLabels parses label pairs up to the closing brace and
+// returns the labels and the remainder of the line after the brace
+func parsePrometheusLabels(s string) (map[string]string, string, bool) {
+	labels := make(map[string]string)
+	i := 0
+	for {
+		// Skip separators
+		for i < len(s) && (s[i] == ',' || s[i] == ' ') {
+			i++
+		}
+		if i >= len(s) {
+			return nil, "", false
+		}
+		if s[i] == '}' {
+			return labels, s[i+1:], true
+		}
+
+		eq := strings.IndexByte(s[i:], '=')
+		if eq < 0 {
+			return nil, "", false
+		}
+		name := strings.TrimSpace(s[i : i+eq])
+		i += eq + 1
+		if i >= len(s) || s[i] != '"' {
+			return nil, "", false
+		}
+		i++
+
+		var value strings.Builder
+		for ; i < len(s) && s[i] != '"'; i++ {
+			if s[i] == '\\' && i+1 < len(s) {
+				i++
+				switch s[i] {
+				case 'n':
+					value.WriteByte('\n')
+				default:
+					value.WriteByte(s[i])
+				}
+				continue
+			}
+			value.WriteByte(s[i])
+		}
+		if i >= len(s) {
+			return nil, "", false
+		}
+		i++ // Closing quote
+		labels[name] = value.String()
+	}
 }
 
-// NewPrometheusCollector creates a new Prometheus metrics collector
-func NewPrometheusCollector(endpoint string) *PrometheusCollector {
+// prometheusGauges lists the gauge metrics the collector tracks per series
+var prometheusGauges = map[string]bool{
+	"monad_bft_txpool_pool_pending_txs": true,
+	"monad_bft_txpool_pool_tracked_txs": true,
+	"monad_statesync_progress_estimate": true,
+	"monad_statesync_last_target":       true,
+}
+
+// NewPrometheusCollector creates a new Prometheus metrics collector timing
+// rates by clock
+func NewPrometheusCollector(endpoint string, clock Clock) *PrometheusCollector {
 	return &PrometheusCollector{
 		endpoint: endpoint,
+		filter:   prometheusFilterFromEnv(),
+		clock:    clock,
 		httpClient: &http.Client{
 			Timeout: 5 * time.Second,
 		},
 		metrics: &PrometheusMetrics{
-			LastUpdated:    time.Now(),
-			LastUpdateTime: time.Now(),
+			LastUpdated:    clock.Now(),
+			LastUpdateTime: clock.Now(),
 		},
 	}
 }
@@ -80,10 +259,16 @@ func (c *PrometheusCollector) Start() {
 		log.Printf("Initial Prometheus metrics collection failed: %v", err)
 	}
 
-	// Then collect every 5 seconds
-	ticker := time.NewTicker(5 * time.Second)
+	// Then collect every 5 seconds (CADENCE_PROMETHEUS) until shutdown
+	ticker := newCadenceTicker(prometheusCadence)
 	go func() {
-		for range ticker.C {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+			case <-shutdownContext().Done():
+				return
+			}
 			if err := c.collectMetrics(); err != nil {
 				log.Printf("Prometheus metrics collection error: %v", err)
 			}
@@ -93,7 +278,11 @@ func (c *PrometheusCollector) Start() {
 
 // collectMetrics fetches and parses Prometheus metrics
 func (c *PrometheusCollector) collectMetrics() error {
-	resp, err := c.httpClient.Get(c.endpoint)
+	req, err := http.NewRequestWithContext(shutdownContext(), http.MethodGet, c.endpoint, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return fmt.Errorf("failed to fetch metrics: %w", err)
 	}
@@ -108,21 +297,68 @@ func (c *PrometheusCollector) collectMetrics() error {
 
 // parseMetrics parses Prometheus text format
 func (c *PrometheusCollector) parseMetrics(body io.Reader) error {
-	scanner := bufio.NewScanner(body)
+	samples, types, err := parsePrometheusText(body)
+	if err != nil {
+		return err
+	}
 
-	newMetrics := &PrometheusMetrics{
-		LastUpdated: time.Now(),
+	c.ingestMu.Lock()
+	defer c.ingestMu.Unlock()
+	c.scraped, c.scrapedTypes = samples, types
+	return c.refreshLocked()
+}
+
+// SetPushSource registers a supplier of pushed samples (the OTLP receiver)
+// that is merged with scraped samples on every update
+func (c *PrometheusCollector) SetPushSource(pushed func() ([]PrometheusSample, map[string]string)) {
+	c.ingestMu.Lock()
+	defer c.ingestMu.Unlock()
+	c.pushed = pushed
+}
+
+// Refresh re-aggregates scraped and pushed samples, e.g. after an OTLP push
+func (c *PrometheusCollector) Refresh() error {
+	c.ingestMu.Lock()
+	defer c.ingestMu.Unlock()
+	return c.refreshLocked()
+}
+
+// refreshLocked merges both sample sets and ingests them; caller holds ingestMu
+func (c *PrometheusCollector) refreshLocked() error {
+	samples := c.scraped
+	types := c.scrapedTypes
+	if c.pushed != nil {
+		pushedSamples, pushedTypes := c.pushed()
+		samples = append(append(make([]PrometheusSample, 0, len(samples)+len(pushedSamples)), samples...), pushedSamples...)
+		merged := make(map[string]string, len(types)+len(pushedTypes))
+		for name, kind := range types {
+			merged[name] = kind
+		}
+		for name, kind := range pushedTypes {
+			merged[name] = kind
+		}
+		types = merged
 	}
+	return c.ingestSamples(samples, types)
+}
 
-	// Keep previous values for rate calculation
-	c.mu.RLock()
-	prevMetrics := *c.metrics // Copy all previous values
-	prevTime := c.metrics.LastUpdateTime
-	c.mu.RUnlock()
+// parsePrometheusText reads every sample and "# TYPE" declaration
+func parsePrometheusText(body io.Reader) ([]PrometheusSample, map[string]string, error) {
+	scanner := bufio.NewScanner(body)
+	samples := make([]PrometheusSample, 0, 256)
+	types := make(map[string]string)
 
 	for scanner.Scan() {
 		line := scanner.Text()
 
+		// Honor "# TYPE name gauge|counter|histogram|summary" declarations
+		if strings.HasPrefix(line, "# TYPE ") {
+			if fields := strings.Fields(line); len(fields) == 4 {
+				types[fields[2]] = fields[3]
+			}
+			continue
+		}
+
 		// Skip comments and empty lines
 		if strings.HasPrefix(line, "#") || len(strings.TrimSpace(line)) == 0 {
 			continue
@@ -130,77 +366,142 @@ func (c *PrometheusCollector) parseMetrics(body io.Reader) error {
 
 		// Parse metric line: metric_name{labels} value timestamp
 		// Example: monad_execution_ledger_num_tx_commits{job="testnet"} 863080221 1761214210873
-		parts := strings.Fields(line)
-		if len(parts) < 2 {
+		if sample, ok := parsePrometheusLine(line); ok {
+			samples = append(samples, sample)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("error reading metrics: %w", err)
+	}
+	return samples, types, nil
+}
+
+// ingestSamples aggregates one complete set of samples (a scrape or the
+// OTLP receiver's current view) and computes rates against the previous set
+func (c *PrometheusCollector) ingestSamples(samples []PrometheusSample, types map[string]string) error {
+	newMetrics := &PrometheusMetrics{
+		LastUpdated: c.clock.Now(),
+		Gauges:      make(map[string][]PrometheusSample),
+	}
+
+	// Keep previous values for rate calculation
+	c.mu.RLock()
+	prevMetrics := *c.metrics // Copy all previous values
+	prevTime := c.metrics.LastUpdateTime
+	filter := c.filter
+	c.mu.RUnlock()
+
+	// Counters are summed across all matching series; gauges are summed for
+	// the scalar fields but also kept per series
+	totals := make(map[string]float64)
+	histograms := newHistogramSet()
+	jobs := make(map[string]bool)
+	instances := make(map[string]bool)
+
+	for _, sample := range samples {
+		if math.IsNaN(sample.Value) || !filter.Matches(sample.Labels) {
 			continue
 		}
 
-		// Extract metric name (before '{' or space)
-		metricNameFull := parts[0]
-		metricName := metricNameFull
-		if idx := strings.Index(metricNameFull, "{"); idx > 0 {
-			metricName = metricNameFull[:idx]
+		if job := sample.Labels["job"]; job != "" {
+			jobs[job] = true
+		}
+		if instance := sample.Labels["instance"]; instance != "" {
+			instances[instance] = true
 		}
 
-		// Parse value (second field)
-		valueStr := parts[1]
-		value, err := strconv.ParseFloat(valueStr, 64)
-		if err != nil {
+		if histograms.add(sample, types) {
 			continue
 		}
 
-		// Extract relevant metrics (CUMULATIVE values)
-		switch metricName {
-		case "monad_execution_ledger_num_tx_commits":
-			newMetrics.TxCommitsTotal = value
-		case "monad_execution_ledger_num_blocks_committed":
-			newMetrics.BlocksCommitted = value
-		case "monad_execution_ledger_num_commits":
-			newMetrics.BlocksCommitted = value // Alternative metric name
-
-		// TxPool metrics (actual Monad metric names with "pool_" prefix)
-		case "monad_bft_txpool_pool_insert_owned_txs":
-			newMetrics.InsertOwnedTxsTotal = value
-		case "monad_bft_txpool_pool_insert_forwarded_txs":
-			newMetrics.InsertForwardedTxsTotal = value
-		case "monad_bft_txpool_pool_drop_not_well_formed":
-			newMetrics.DropInvalidSignatureTotal = value
-		case "monad_bft_txpool_pool_drop_nonce_too_low":
-			newMetrics.DropNonceTooLowTotal = value
-		case "monad_bft_txpool_pool_drop_fee_too_low":
-			newMetrics.DropFeeTooLowTotal = value
-		case "monad_bft_txpool_pool_drop_insufficient_balance":
-			newMetrics.DropInsufficientBalanceTotal = value
-		case "monad_bft_txpool_pool_drop_pool_full":
-			newMetrics.DropPoolFullTotal = value
-		case "monad_bft_txpool_pool_pending_txs":
-			newMetrics.PendingTxs = value // Gauge, not cumulative
-		case "monad_bft_txpool_pool_tracked_txs":
-			newMetrics.TrackedTxs = value // Gauge, not cumulative
+		totals[sample.Name] += sample.Value
+		if types[sample.Name] == "gauge" || prometheusGauges[sample.Name] {
+			newMetrics.Gauges[sample.Name] = append(newMetrics.Gauges[sample.Name], sample)
 		}
 	}
 
-	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("error reading metrics: %w", err)
+	// Extract relevant metrics (CUMULATIVE values)
+	newMetrics.TxCommitsTotal = totals["monad_execution_ledger_num_tx_commits"]
+	newMetrics.BlocksCommitted = totals["monad_execution_ledger_num_blocks_committed"]
+	if newMetrics.BlocksCommitted == 0 {
+		newMetrics.BlocksCommitted = totals["monad_execution_ledger_num_commits"] // Alternative metric name
+	}
+
+	// TxPool metrics (actual Monad metric names with "pool_" prefix)
+	newMetrics.InsertOwnedTxsTotal = totals["monad_bft_txpool_pool_insert_owned_txs"]
+	newMetrics.InsertForwardedTxsTotal = totals["monad_bft_txpool_pool_insert_forwarded_txs"]
+	newMetrics.DropInvalidSignatureTotal = totals["monad_bft_txpool_pool_drop_not_well_formed"]
+	newMetrics.DropNonceTooLowTotal = totals["monad_bft_txpool_pool_drop_nonce_too_low"]
+	newMetrics.DropFeeTooLowTotal = totals["monad_bft_txpool_pool_drop_fee_too_low"]
+	newMetrics.DropInsufficientBalanceTotal = totals["monad_bft_txpool_pool_drop_insufficient_balance"]
+	newMetrics.DropPoolFullTotal = totals["monad_bft_txpool_pool_drop_pool_full"]
+	newMetrics.PendingTxs = totals["monad_bft_txpool_pool_pending_txs"] // Gauge, not cumulative
+	newMetrics.TrackedTxs = totals["monad_bft_txpool_pool_tracked_txs"] // Gauge, not cumulative
+
+	// Latency distributions: prefer observations made since the previous
+	// scrape, falling back to the cumulative histogram (first scrape, reset)
+	histograms.finish()
+	newMetrics.Histograms = histograms.histograms
+	newMetrics.Summaries = histograms.summaries
+	newMetrics.Latencies = make(map[string]LatencyDistribution)
+	for name, h := range histograms.histograms {
+		if delta, ok := h.Delta(prevMetrics.Histograms[name]); ok && delta.Count > 0 {
+			newMetrics.Latencies[name] = delta.Distribution(name, "interval")
+		} else {
+			newMetrics.Latencies[name] = h.Distribution(name, "cumulative")
+		}
+	}
+	for name, summary := range histograms.summaries {
+		newMetrics.Latencies[name] = summary.Distribution(name)
+	}
+
+	for job := range jobs {
+		newMetrics.Jobs = append(newMetrics.Jobs, job)
+	}
+	for instance := range instances {
+		newMetrics.Instances = append(newMetrics.Instances, instance)
 	}
+	sort.Strings(newMetrics.Jobs)
+	sort.Strings(newMetrics.Instances)
 
 	// Calculate rates for ALL counters
-	now := time.Now()
+	now := c.clock.Now()
 	timeDiff := now.Sub(prevTime).Seconds()
 
 	if timeDiff > 0 && prevMetrics.TxCommitsTotal > 0 {
+		newMetrics.RateWindow = now.Sub(prevTime)
+
+		// rate clamps a counter's rate to zero on reset and records it
+		rate := func(name string, prev, cur float64) float64 {
+			r, reset := counterRate(prev, cur, timeDiff)
+			if reset {
+				newMetrics.ResetCounters = append(newMetrics.ResetCounters, name)
+			}
+			return r
+		}
+
 		// TPS calculation
 		txDiff := newMetrics.TxCommitsTotal - prevMetrics.TxCommitsTotal
-		newMetrics.TPS60s = txDiff / timeDiff
+		newMetrics.TPS60s = rate("monad_execution_ledger_num_tx_commits", prevMetrics.TxCommitsTotal, newMetrics.TxCommitsTotal)
 
 		// TxPool rates (change since last collection)
-		newMetrics.InsertOwnedTxsRate = (newMetrics.InsertOwnedTxsTotal - prevMetrics.InsertOwnedTxsTotal) / timeDiff
-		newMetrics.InsertForwardedTxsRate = (newMetrics.InsertForwardedTxsTotal - prevMetrics.InsertForwardedTxsTotal) / timeDiff
-		newMetrics.DropInvalidSignatureRate = (newMetrics.DropInvalidSignatureTotal - prevMetrics.DropInvalidSignatureTotal) / timeDiff
-		newMetrics.DropNonceTooLowRate = (newMetrics.DropNonceTooLowTotal - prevMetrics.DropNonceTooLowTotal) / timeDiff
-		newMetrics.DropFeeTooLowRate = (newMetrics.DropFeeTooLowTotal - prevMetrics.DropFeeTooLowTotal) / timeDiff
-		newMetrics.DropInsufficientBalanceRate = (newMetrics.DropInsufficientBalanceTotal - prevMetrics.DropInsufficientBalanceTotal) / timeDiff
-		newMetrics.DropPoolFullRate = (newMetrics.DropPoolFullTotal - prevMetrics.DropPoolFullTotal) / timeDiff
+		newMetrics.InsertOwnedTxsRate = rate("monad_bft_txpool_pool_insert_owned_txs", prevMetrics.InsertOwnedTxsTotal, newMetrics.InsertOwnedTxsTotal)
+		newMetrics.InsertForwardedTxsRate = rate("monad_bft_txpool_pool_insert_forwarded_txs", prevMetrics.InsertForwardedTxsTotal, newMetrics.InsertForwardedTxsTotal)
+		newMetrics.DropInvalidSignatureRate = rate("monad_bft_txpool_pool_drop_not_well_formed", prevMetrics.DropInvalidSignatureTotal, newMetrics.DropInvalidSignatureTotal)
+		newMetrics.DropNonceTooLowRate = rate("monad_bft_txpool_pool_drop_nonce_too_low", prevMetrics.DropNonceTooLowTotal, newMetrics.DropNonceTooLowTotal)
+		newMetrics.DropFeeTooLowRate = rate("monad_bft_txpool_pool_drop_fee_too_low", prevMetrics.DropFeeTooLowTotal, newMetrics.DropFeeTooLowTotal)
+		newMetrics.DropInsufficientBalanceRate = rate("monad_bft_txpool_pool_drop_insufficient_balance", prevMetrics.DropInsufficientBalanceTotal, newMetrics.DropInsufficientBalanceTotal)
+		newMetrics.DropPoolFullRate = rate("monad_bft_txpool_pool_drop_pool_full", prevMetrics.DropPoolFullTotal, newMetrics.DropPoolFullTotal)
+
+		if len(newMetrics.ResetCounters) > 0 {
+			newMetrics.Discontinuous = true
+			log.Printf("⚠️  Prometheus: counter reset detected (%s), rates clamped to zero for this interval",
+				strings.Join(newMetrics.ResetCounters, ", "))
+			if store := GetHistoryStore(); store != nil {
+				store.Annotate("counter_reset", "Counter reset", strings.Join(newMetrics.ResetCounters, ", "), "prometheus")
+			}
+		}
 
 		log.Printf("📊 Prometheus TPS: %.2f tx/s (commits: %.0f -> %.0f, diff: %.0f over %.1fs)",
 			newMetrics.TPS60s, prevMetrics.TxCommitsTotal, newMetrics.TxCommitsTotal, txDiff, timeDiff)
@@ -232,6 +533,20 @@ func (c *PrometheusCollector) GetMetrics() *PrometheusMetrics {
 	return &metricsCopy
 }
 
+// SetFilter restricts aggregation to the given jobs/instances
+func (c *PrometheusCollector) SetFilter(filter PrometheusFilter) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.filter = filter
+}
+
+// Filter returns the active job/instance filter
+func (c *PrometheusCollector) Filter() PrometheusFilter {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.filter
+}
+
 // GetTPS returns the current TPS calculated from Prometheus metrics
 func (c *PrometheusCollector) GetTPS() float64 {
 	c.mu.RLock()
@@ -245,37 +560,80 @@ func (c *PrometheusCollector) IsHealthy() bool {
 	defer c.mu.RUnlock()
 
 	// Metrics should be updated within last 10 seconds
-	return time.Since(c.metrics.LastUpdated) < 10*time.Second
+	return c.clock.Since(c.metrics.LastUpdated) < 10*time.Second
 }
 
-// Global Prometheus collector instance
-var (
-	prometheusCollector   *PrometheusCollector
-	prometheusCollectorMu sync.RWMutex
-)
-
-// InitializePrometheusCollector initializes the Prometheus metrics collector
-func InitializePrometheusCollector(endpoint string) error {
-	prometheusCollectorMu.Lock()
-	defer prometheusCollectorMu.Unlock()
-
-	prometheusCollector = NewPrometheusCollector(endpoint)
+// StartPrometheusCollector creates the dashboard's Prometheus collector and
+// starts scraping endpoint
+func (d *Dashboard) StartPrometheusCollector(endpoint string) error {
+	collector := NewPrometheusCollector(endpoint, d.clock)
+	d.mu.Lock()
+	d.prometheus = collector
+	d.mu.Unlock()
 
 	// Test connection
-	if err := prometheusCollector.collectMetrics(); err != nil {
+	if err := collector.collectMetrics(); err != nil {
 		return fmt.Errorf("failed to connect to Prometheus endpoint: %w", err)
 	}
 
 	// Start background collection
-	prometheusCollector.Start()
+	collector.Start()
 
 	log.Printf("✅ Prometheus collector initialized at %s", endpoint)
 	return nil
 }
 
-// GetPrometheusCollector returns the global Prometheus collector
+// ensurePrometheusCollector returns the served dashboard's collector,
+// creating a push-only one (no scrape endpoint) when scraping was never
+// configured
+func ensurePrometheusCollector() *PrometheusCollector {
+	d := GetDashboard()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.prometheus == nil {
+		d.prometheus = NewPrometheusCollector("", d.clock)
+	}
+	return d.prometheus
+}
+
+// GetPrometheusCollector returns the served dashboard's Prometheus collector
 func GetPrometheusCollector() *PrometheusCollector {
-	prometheusCollectorMu.RLock()
-	defer prometheusCollectorMu.RUnlock()
-	return prometheusCollector
+	return GetDashboard().PrometheusCollector()
+}
+
+// PrometheusSeriesResponse is the body of /api/v1/prometheus
+type PrometheusSeriesResponse struct {
+	Endpoint    string                         `json:"endpoint"`
+	Filter      PrometheusFilter               `json:"filter"`
+	Jobs        []string                       `json:"jobs"`
+	Instances   []string                       `json:"instances"`
+	Gauges      map[string][]PrometheusSample  `json:"gauges"`
+	Latencies   map[string]LatencyDistribution `json:"latencies"`
+	Timing      map[string]LatencyDistribution `json:"timing"` // Per waterfall stage
+	Healthy     bool                           `json:"healthy"`
+	LastUpdated int64                          `json:"last_updated"`
+}
+
+// handlePrometheusSeries returns the scrape filter, the jobs/instances seen
+// and per-series gauge values
+func handlePrometheusSeries(c *gin.Context) {
+	collector := GetPrometheusCollector()
+	if collector == nil {
+		c.JSON(http.StatusServiceUnavailable, APIError{Error: "Prometheus collector not initialized"})
+		return
+	}
+
+	metrics := collector.GetMetrics()
+	c.JSON(http.StatusOK, PrometheusSeriesResponse{
+		Endpoint:    collector.endpoint,
+		Filter:      collector.Filter(),
+		Jobs:        metrics.Jobs,
+		Instances:   metrics.Instances,
+		Gauges:      metrics.Gauges,
+		Latencies:   metrics.Latencies,
+		Timing:      metrics.TimingDistributions(),
+		Healthy:     collector.IsHealthy(),
+		LastUpdated: metrics.LastUpdated.Unix(),
+	})
 }
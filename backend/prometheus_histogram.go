@@ -0,0 +1,343 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// HistogramBucket is one cumulative bucket of a Prometheus histogram
+type HistogramBucket struct {
+	UpperBound float64 `json:"le"`
+	Count      float64 `json:"count"`
+}
+
+// PrometheusHistogram is a histogram aggregated across all matching series
+type PrometheusHistogram struct {
+	Buckets []HistogramBucket `json:"buckets"` // Sorted by upper bound, +Inf last
+	Sum     float64           `json:"sum"`
+	Count   float64           `json:"count"`
+}
+
+// PrometheusSummary holds the quantiles a summary exposes. Quantiles of
+// different series cannot be merged, so only the first matching series is kept.
+type PrometheusSummary struct {
+	Quantiles map[string]float64 `json:"quantiles"`
+	Sum       float64            `json:"sum"`
+	Count     float64            `json:"count"`
+}
+
+// LatencyDistribution summarizes a latency metric in milliseconds
+type LatencyDistribution struct {
+	P50    float64 `json:"p50_ms"`
+	P90    float64 `json:"p90_ms"`
+	P99    float64 `json:"p99_ms"`
+	Mean   float64 `json:"mean_ms"`
+	Count  float64 `json:"count"`
+	Metric string  `json:"metric"`
+	Window string  `json:"window"` // "interval" (since last scrape) or "cumulative"
+}
+
+// histogramSet accumulates histogram and summary samples during a scrape
+type histogramSet struct {
+	histograms map[string]*PrometheusHistogram
+	summaries  map[string]*PrometheusSummary
+	bucketIdx  map[string]map[float64]int
+}
+
+func newHistogramSet() *histogramSet {
+	return &histogramSet{
+		histograms: make(map[string]*PrometheusHistogram),
+		summaries:  make(map[string]*PrometheusSummary),
+		bucketIdx:  make(map[string]map[float64]int),
+	}
+}
+
+// add consumes a sample if it belongs to a histogram or summary and
+// reports whether it did
+func (hs *histogramSet) add(sample PrometheusSample, types map[string]string) bool {
+	name := sample.Name
+
+	switch {
+	case strings.HasSuffix(name, "_bucket"):
+		base := strings.TrimSuffix(name, "_bucket")
+		le, ok := sample.Labels["le"]
+		if !ok || (types[base] != "" && types[base] != "histogram") {
+			return false
+		}
+		bound, err := strconv.ParseFloat(le, 64)
+		if err != nil {
+			return false
+		}
+		h := hs.histogram(base)
+		idx, seen := hs.bucketIdx[base][bound]
+		if !seen {
+			h.Buckets = append(h.Buckets, HistogramBucket{UpperBound: bound})
+			idx = len(h.Buckets) - 1
+			hs.bucketIdx[base][bound] = idx
+		}
+		// Buckets with the same bound are summed across series
+		h.Buckets[idx].Count += sample.Value
+		return true
+
+	case strings.HasSuffix(name, "_sum") || strings.HasSuffix(name, "_count"):
+		base := strings.TrimSuffix(strings.TrimSuffix(name, "_sum"), "_count")
+		isSum := strings.HasSuffix(name, "_sum")
+		switch types[base] {
+		case "histogram":
+			h := hs.histogram(base)
+			if isSum {
+				h.Sum += sample.Value
+			} else {
+				h.Count += sample.Value
+			}
+			return true
+		case "summary":
+			s := hs.summary(base)
+			if isSum {
+				s.Sum += sample.Value
+			} else {
+				s.Count += sample.Value
+			}
+			return true
+		}
+		return false
+
+	default:
+		q, ok := sample.Labels["quantile"]
+		if !ok || types[name] != "summary" {
+			return false
+		}
+		s := hs.summary(name)
+		if _, exists := s.Quantiles[q]; !exists {
+			s.Quantiles[q] = sample.Value
+		}
+		return true
+	}
+}
+
+func (hs *histogramSet) histogram(name string) *PrometheusHistogram {
+	h, ok := hs.histograms[name]
+	if !ok {
+		h = &PrometheusHistogram{}
+		hs.histograms[name] = h
+		hs.bucketIdx[name] = make(map[float64]int)
+	}
+	return h
+}
+
+func (hs *histogramSet) summary(name string) *PrometheusSummary {
+	s, ok := hs.summaries[name]
+	if !ok {
+		s = &PrometheusSummary{Quantiles: make(map[string]float64)}
+		hs.summaries[name] = s
+	}
+	return s
+}
+
+// finish sorts bucket bounds so quantile interpolation can walk them in order
+func (hs *histogramSet) finish() {
+	for _, h := range hs.histograms {
+		sort.Slice(h.Buckets, func(i, j int) bool {
+			return h.Buckets[i].UpperBound < h.Buckets[j].UpperBound
+		})
+	}
+}
+
+// Delta returns the histogram of observations made since prev. ok is false
+// when prev is missing, has different buckets, or a counter reset.
+func (h *PrometheusHistogram) Delta(prev *PrometheusHistogram) (*PrometheusHistogram, bool) {
+	if prev == nil || len(prev.Buckets) != len(h.Buckets) {
+		return nil, false
+	}
+	delta := &PrometheusHistogram{Buckets: make([]HistogramBucket, len(h.Buckets))}
+	for i, b := range h.Buckets {
+		if prev.Buckets[i].UpperBound != b.UpperBound || b.Count < prev.Buckets[i].Count {
+			return nil, false
+		}
+		delta.Buckets[i] = HistogramBucket{UpperBound: b.UpperBound, Count: b.Count - prev.Buckets[i].Count}
+	}
+	if h.Count < prev.Count || h.Sum < prev.Sum {
+		return nil, false
+	}
+	delta.Count = h.Count - prev.Count
+	delta.Sum = h.Sum - prev.Sum
+	return delta, true
+}
+
+// Quantile estimates the q-quantile (0..1) by linear interpolation within
+// the bucket containing the target rank, like PromQL's histogram_quantile
+func (h *PrometheusHistogram) Quantile(q float64) float64 {
+	if len(h.Buckets) == 0 {
+		return math.NaN()
+	}
+	total := h.Buckets[len(h.Buckets)-1].Count
+	if total == 0 {
+		return math.NaN()
+	}
+
+	rank := q * total
+	lowerBound, lowerCount := 0.0, 0.0
+	for _, b := range h.Buckets {
+		if b.Count >= rank {
+			if math.IsInf(b.UpperBound, 1) {
+				// Rank falls in the +Inf bucket: report the highest finite bound
+				return lowerBound
+			}
+			if b.Count == lowerCount {
+				return b.UpperBound
+			}
+			return lowerBound + (b.UpperBound-lowerBound)*(rank-lowerCount)/(b.Count-lowerCount)
+		}
+		lowerBound, lowerCount = b.UpperBound, b.Count
+	}
+	return lowerBound
+}
+
+// Distribution converts the histogram into a millisecond latency summary
+func (h *PrometheusHistogram) Distribution(metric, window string) LatencyDistribution {
+	scale := latencyScaleToMs(metric)
+	dist := LatencyDistribution{
+		P50:    finiteOrZero(h.Quantile(0.50) * scale),
+		P90:    finiteOrZero(h.Quantile(0.90) * scale),
+		P99:    finiteOrZero(h.Quantile(0.99) * scale),
+		Count:  h.Count,
+		Metric: metric,
+		Window: window,
+	}
+	if h.Count > 0 {
+		dist.Mean = h.Sum / h.Count * scale
+	}
+	return dist
+}
+
+// Distribution converts the summary into a millisecond latency summary
+func (s *PrometheusSummary) Distribution(metric string) LatencyDistribution {
+	scale := latencyScaleToMs(metric)
+	dist := LatencyDistribution{
+		P50:    finiteOrZero(s.Quantiles["0.5"] * scale),
+		P90:    finiteOrZero(s.Quantiles["0.9"] * scale),
+		P99:    finiteOrZero(s.Quantiles["0.99"] * scale),
+		Count:  s.Count,
+		Metric: metric,
+		Window: "cumulative",
+	}
+	if s.Count > 0 {
+		dist.Mean = s.Sum / s.Count * scale
+	}
+	return dist
+}
+
+// latencyScaleToMs infers the unit from the metric name suffix.
+// Prometheus convention is seconds, which is also the default.
+func latencyScaleToMs(metric string) float64 {
+	switch {
+	case strings.HasSuffix(metric, "_ns") || strings.HasSuffix(metric, "_nanoseconds"):
+		return 1e-6
+	case strings.HasSuffix(metric, "_us") || strings.HasSuffix(metric, "_microseconds"):
+		return 1e-3
+	case strings.HasSuffix(metric, "_ms") || strings.HasSuffix(metric, "_milliseconds"):
+		return 1
+	}
+	return 1000
+}
+
+func finiteOrZero(v float64) float64 {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0
+	}
+	return v
+}
+
+// defaultTimingMetrics maps waterfall timing stages to the histogram or
+// summary that measures them. Override with PROMETHEUS_TIMING_METRICS,
+// e.g. "execution=monad_execution_block_duration_seconds,consensus=...".
+var defaultTimingMetrics = map[string]string{
+	"mempool_propagation": "monad_bft_txpool_forward_latency_seconds",
+	"consensus":           "monad_bft_consensus_round_duration_seconds",
+	"execution":           "monad_execution_block_execution_duration_seconds",
+	"finality":            "monad_bft_consensus_finalization_latency_seconds",
+}
+
+// MetricMappingsConfig is the METRIC_MAPPINGS_CONFIG file, reloadable at
+// runtime:
+//
+//	[timing]
+//	execution = "monad_execution_block_duration_seconds"
+type MetricMappingsConfig struct {
+	Timing map[string]string `toml:"timing"`
+}
+
+var prometheusMetricName = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+
+// timingMetricOverrides holds the METRIC_MAPPINGS_CONFIG timing mappings,
+// applied over PROMETHEUS_TIMING_METRICS
+var timingMetricOverrides = struct {
+	sync.RWMutex
+	stages map[string]string
+}{}
+
+// ReloadMetricMappings reads a metric mappings file and swaps in its
+// mappings once every stage and metric name is valid
+func ReloadMetricMappings(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var config MetricMappingsConfig
+	if err := toml.Unmarshal(data, &config); err != nil {
+		return err
+	}
+	for stage, metric := range config.Timing {
+		if _, known := defaultTimingMetrics[stage]; !known {
+			return fmt.Errorf("unknown timing stage %q", stage)
+		}
+		if !prometheusMetricName.MatchString(metric) {
+			return fmt.Errorf("timing stage %s: invalid metric name %q", stage, metric)
+		}
+	}
+
+	timingMetricOverrides.Lock()
+	timingMetricOverrides.stages = config.Timing
+	timingMetricOverrides.Unlock()
+	return nil
+}
+
+// timingMetrics returns the stage -> metric mapping: the defaults, then
+// PROMETHEUS_TIMING_METRICS, then METRIC_MAPPINGS_CONFIG
+func timingMetrics() map[string]string {
+	mapping := make(map[string]string, len(defaultTimingMetrics))
+	for stage, metric := range defaultTimingMetrics {
+		mapping[stage] = metric
+	}
+	for _, pair := range splitList(os.Getenv("PROMETHEUS_TIMING_METRICS")) {
+		if stage, metric, ok := strings.Cut(pair, "="); ok {
+			mapping[strings.TrimSpace(stage)] = strings.TrimSpace(metric)
+		}
+	}
+	timingMetricOverrides.RLock()
+	for stage, metric := range timingMetricOverrides.stages {
+		mapping[stage] = metric
+	}
+	timingMetricOverrides.RUnlock()
+	return mapping
+}
+
+// TimingDistributions returns latency distributions for each waterfall
+// timing stage that has a histogram or summary in the last scrape
+func (m *PrometheusMetrics) TimingDistributions() map[string]LatencyDistribution {
+	result := make(map[string]LatencyDistribution)
+	for stage, metric := range timingMetrics() {
+		if dist, ok := m.Latencies[metric]; ok {
+			result[stage] = dist
+		}
+	}
+	return result
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// propagationWindow is how long per-block samples are kept
+const propagationWindow = 10 * time.Minute
+
+// propagationBuckets are the histogram upper bounds in milliseconds; the
+// last bucket is open-ended
+var propagationBuckets = []float64{250, 500, 1000, 1500, 2000, 3000, 5000}
+
+// propagationSample is one newHeads notification's delay
+type propagationSample struct {
+	block     int64
+	at        time.Time
+	latencyMs float64
+}
+
+// PropagationTracker measures, per block, the delay between the block
+// timestamp and the local receipt of its newHeads notification: block
+// propagation to the node plus notification delivery to the dashboard.
+// Block timestamps have one-second resolution, so single samples overstate
+// the delay by up to a second; the distribution shows the trend.
+type PropagationTracker struct {
+	clock Clock
+
+	mu      sync.Mutex
+	samples []propagationSample // Oldest first, within propagationWindow
+}
+
+// Global propagation tracker
+var (
+	propagationTracker   *PropagationTracker
+	propagationTrackerMu sync.RWMutex
+)
+
+// NewPropagationTracker creates a tracker timing receipts by clock
+func NewPropagationTracker(clock Clock) *PropagationTracker {
+	return &PropagationTracker{clock: clock}
+}
+
+// InitializePropagationTracker creates the global propagation tracker
+func InitializePropagationTracker() *PropagationTracker {
+	propagationTrackerMu.Lock()
+	defer propagationTrackerMu.Unlock()
+	propagationTracker = NewPropagationTracker(systemClock)
+	return propagationTracker
+}
+
+// GetPropagationTracker returns the global propagation tracker
+func GetPropagationTracker() *PropagationTracker {
+	propagationTrackerMu.RLock()
+	defer propagationTrackerMu.RUnlock()
+	return propagationTracker
+}
+
+// OnNotification records a newHeads notification received at received,
+// correcting the block timestamp for clock skew, and adds it to the
+// propagation_latency_ms history series
+func (pt *PropagationTracker) OnNotification(header *BlockHeader, received time.Time) {
+	blockTime := time.Unix(header.Timestamp, 0)
+	if cs := GetClockSkew(); cs != nil {
+		blockTime = cs.BlockTime(header.Timestamp)
+	}
+	latencyMs := float64(received.Sub(blockTime).Microseconds()) / 1000
+
+	pt.mu.Lock()
+	pt.samples = append(pt.samples, propagationSample{block: header.Number, at: received, latencyMs: latencyMs})
+	cutoff := received.Add(-propagationWindow)
+	drop := 0
+	for drop < len(pt.samples) && pt.samples[drop].at.Before(cutoff) {
+		drop++
+	}
+	pt.samples = pt.samples[drop:]
+	pt.mu.Unlock()
+
+	if store := GetHistoryStore(); store != nil {
+		store.Record("propagation_latency_ms", received, latencyMs)
+	}
+}
+
+// PropagationDistribution summarizes the delays of one window, in milliseconds
+type PropagationDistribution struct {
+	Window  string  `json:"window"`
+	Samples int     `json:"samples"`
+	Min     float64 `json:"min_ms"`
+	Mean    float64 `json:"mean_ms"`
+	P50     float64 `json:"p50_ms"`
+	P90     float64 `json:"p90_ms"`
+	P99     float64 `json:"p99_ms"`
+	Max     float64 `json:"max_ms"`
+}
+
+// PropagationBucket is one histogram bucket of the full window
+type PropagationBucket struct {
+	UpToMs *float64 `json:"up_to_ms"` // Null for the open-ended last bucket
+	Count  int      `json:"count"`
+}
+
+// PropagationPoint is one block's delay
+type PropagationPoint struct {
+	Block     int64   `json:"block"`
+	Received  int64   `json:"received"` // Unix ms
+	LatencyMs float64 `json:"latency_ms"`
+}
+
+// PropagationResponse is the body of /api/v1/propagation
+type PropagationResponse struct {
+	Windows   []PropagationDistribution `json:"windows"`   // 1m, 5m and 10m
+	Histogram []PropagationBucket       `json:"histogram"` // Over 10m
+	Recent    []PropagationPoint        `json:"recent"`    // Last 60 blocks, newest last
+}
+
+// Report summarizes the delays over 1, 5 and 10 minutes
+func (pt *PropagationTracker) Report() PropagationResponse {
+	now := pt.clock.Now()
+
+	pt.mu.Lock()
+	samples := append([]propagationSample(nil), pt.samples...)
+	pt.mu.Unlock()
+
+	response := PropagationResponse{
+		Windows:   make([]PropagationDistribution, 0, 3),
+		Histogram: make([]PropagationBucket, len(propagationBuckets)+1),
+		Recent:    make([]PropagationPoint, 0, 60),
+	}
+	for _, window := range []struct {
+		name string
+		span time.Duration
+	}{{"1m", time.Minute}, {"5m", 5 * time.Minute}, {"10m", propagationWindow}} {
+		var latencies []float64
+		for _, sample := range samples {
+			if now.Sub(sample.at) <= window.span {
+				latencies = append(latencies, sample.latencyMs)
+			}
+		}
+		response.Windows = append(response.Windows, propagationDistribution(window.name, latencies))
+	}
+
+	for i := range propagationBuckets {
+		response.Histogram[i].UpToMs = &propagationBuckets[i]
+	}
+	for _, sample := range samples {
+		i := sort.SearchFloat64s(propagationBuckets, sample.latencyMs)
+		response.Histogram[i].Count++
+	}
+
+	from := len(samples) - 60
+	if from < 0 {
+		from = 0
+	}
+	for _, sample := range samples[from:] {
+		response.Recent = append(response.Recent, PropagationPoint{
+			Block:     sample.block,
+			Received:  sample.at.UnixMilli(),
+			LatencyMs: sample.latencyMs,
+		})
+	}
+	return response
+}
+
+// propagationDistribution computes percentiles over latencies
+func propagationDistribution(window string, latencies []float64) PropagationDistribution {
+	dist := PropagationDistribution{Window: window, Samples: len(latencies)}
+	n := len(latencies)
+	if n == 0 {
+		return dist
+	}
+	sort.Float64s(latencies)
+	sum := 0.0
+	for _, ms := range latencies {
+		sum += ms
+	}
+	dist.Min = latencies[0]
+	dist.Mean = sum / float64(n)
+	dist.P50 = latencies[n*50/100]
+	dist.P90 = latencies[n*90/100]
+	dist.P99 = latencies[n*99/100]
+	dist.Max = latencies[n-1]
+	return dist
+}
+
+// handlePropagation reports the block timestamp to newHeads receipt delay
+func handlePropagation(c *gin.Context) {
+	pt := GetPropagationTracker()
+	if pt == nil {
+		c.JSON(http.StatusServiceUnavailable, APIError{Error: "Propagation tracker not initialized"})
+		return
+	}
+	c.JSON(http.StatusOK, pt.Report())
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPropagationWindows(t *testing.T) {
+	clock := NewFakeClock(fakeClockStart)
+	pt := NewPropagationTracker(clock)
+
+	// One block every minute for 12 minutes, each received 500ms after its timestamp
+	for i := int64(0); i < 12; i++ {
+		if i > 0 {
+			clock.Advance(time.Minute)
+		}
+		received := clock.Now()
+		pt.OnNotification(&BlockHeader{Number: i, Timestamp: received.Add(-500 * time.Millisecond).Unix()}, received)
+	}
+	clock.Advance(30 * time.Second)
+
+	samples := map[string]int{}
+	for _, window := range pt.Report().Windows {
+		samples[window.Window] = window.Samples
+	}
+	// Samples older than 10 minutes are dropped as new ones arrive
+	want := map[string]int{"1m": 1, "5m": 5, "10m": 10}
+	for window, n := range want {
+		if samples[window] != n {
+			t.Errorf("%s window has %d samples, want %d", window, samples[window], n)
+		}
+	}
+}
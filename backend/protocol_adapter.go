@@ -0,0 +1,141 @@
+package main
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ProtocolAdapter formats outbound stream messages for one frontend protocol.
+// Messages are produced in the Firedancer shape (topic/key/value) and
+// translated per connection.
+type ProtocolAdapter interface {
+	Name() string
+	// Format returns the message to write, or false to skip it for this protocol
+	Format(msg interface{}) (interface{}, bool)
+}
+
+// firedancerAdapter passes messages through unchanged for Firedancer-compatible frontends
+type firedancerAdapter struct{}
+
+func (firedancerAdapter) Name() string { return "firedancer" }
+
+func (firedancerAdapter) Format(msg interface{}) (interface{}, bool) {
+	return withChain(msg), true
+}
+
+// NativeMessage is the envelope of the native Monad JSON protocol
+type NativeMessage struct {
+	Type  string      `json:"type"`
+	Data  interface{} `json:"data"`
+	TS    int64       `json:"ts"` // Unix milliseconds
+	ID    *int        `json:"id,omitempty"`
+	Seq   uint64      `json:"seq,omitempty"` // Broadcast sequence number
+	Chain string      `json:"chain"`
+}
+
+// nativeMessageTypes maps Firedancer topic.key pairs to native message types.
+// An empty type drops the message: it duplicates another one or only exists
+// to satisfy the Firedancer GUI.
+var nativeMessageTypes = map[string]string{
+	"summary.ping":                  "ping",
+	"summary.version":               "version",
+	"summary.cluster":               "cluster",
+	"summary.identity_key":          "validator.address",
+	"summary.identity_balance":      "validator.balance_gwei",
+	"summary.startup_time_nanos":    "startup_time_nanos",
+	"summary.estimated_slot":        "head.block",
+	"chains.update":                 "chain.update",
+	"system_stats.update":           "system.stats",
+	"consensus_events.new":          "consensus.event",
+	"summary.speculative_slot":      "head.speculative",
+	"summary.finalized_slot":        "head.finalized",
+	"summary.finality_gap":          "head.finality_gap",
+	"summary.estimated_tps":         "tps",
+	"summary.tps_history":           "tps.history",
+	"summary.monad_waterfall_v2":    "waterfall",
+	"summary.monad_consensus_state": "consensus",
+	"peers.update":                  "validators",
+	"epoch.new":                     "epoch",
+	"epoch.rollover":                "epoch.rollover",
+	"tx_flow.transaction_log":       "tx",
+	"tx_flow.aggregate":             "tx.aggregate",
+	"watchlist.watch_hit":           "watchlist.hit",
+	"summary.vote_state":            "validator.vote_state",
+	"summary.vote_distance":         "validator.vote_distance",
+
+	"summary.root_slot":          "",
+	"summary.completed_slot":     "",
+	"summary.live_txn_waterfall": "",
+	"summary.vote_balance":       "",
+	"summary.startup_progress":   "node.sync",
+	"summary.tiles":              "",
+	"summary.live_tile_timers":   "",
+	"block_engine.update":        "",
+}
+
+// nativeAdapter translates messages into the native Monad JSON protocol:
+// {"type": "head.block", "data": 123, "ts": 1700000000000}
+type nativeAdapter struct{}
+
+func (nativeAdapter) Name() string { return "native" }
+
+func (nativeAdapter) Format(msg interface{}) (interface{}, bool) {
+	topic, key, value, id, ok := messageParts(msg)
+	if !ok {
+		return msg, true
+	}
+
+	msgType, mapped := nativeMessageTypes[topic+"."+key]
+	if mapped && msgType == "" {
+		return nil, false
+	}
+	if !mapped {
+		msgType = topic + "." + key
+	}
+
+	seq, serverTS := messageSequence(msg)
+	if serverTS == 0 {
+		serverTS = time.Now().UnixMilli()
+	}
+
+	return NativeMessage{
+		Type:  msgType,
+		Data:  value,
+		TS:    serverTS,
+		ID:    id,
+		Seq:   seq,
+		Chain: messageChain(withChain(msg)),
+	}, true
+}
+
+// messageParts extracts topic, key, value and id from an outbound message
+func messageParts(msg interface{}) (topic, key string, value interface{}, id *int, ok bool) {
+	switch m := msg.(type) {
+	case FiredancerMessage:
+		return m.Topic, m.Key, m.Value, m.ID, true
+	case *FiredancerMessage:
+		return m.Topic, m.Key, m.Value, m.ID, true
+	case map[string]interface{}:
+		topic, _ = m["topic"].(string)
+		key, _ = m["key"].(string)
+		if topic == "" {
+			return "", "", nil, nil, false
+		}
+		if n, isInt := m["id"].(int); isInt {
+			id = &n
+		}
+		return topic, key, m["value"], id, true
+	}
+	return "", "", nil, nil, false
+}
+
+// handleWebSocket serves the Firedancer-compatible stream
+func handleWebSocket(c *gin.Context) {
+	serveWebSocket(c, firedancerAdapter{})
+}
+
+// handleNativeWebSocket serves the native Monad JSON stream
+func handleNativeWebSocket(c *gin.Context) {
+	serveWebSocket(c, nativeAdapter{})
+}
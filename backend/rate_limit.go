@@ -0,0 +1,87 @@
+package main
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxRateLimitKeys caps the buckets one limiter keeps; idle (full) buckets
+// are dropped first, then the least recently used
+const maxRateLimitKeys = 10000
+
+// rateLimiter is a token bucket per key, e.g. per client address
+type rateLimiter struct {
+	rate  float64 // Tokens per second
+	burst float64
+
+	mu      sync.Mutex
+	buckets map[string]*rateBucket
+}
+
+type rateBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// newRateLimiter allows rate calls per second per key, up to burst at once
+func newRateLimiter(rate, burst float64) *rateLimiter {
+	return &rateLimiter{rate: rate, burst: burst, buckets: make(map[string]*rateBucket)}
+}
+
+// allow takes a token from key's bucket, reporting whether one was available
+func (l *rateLimiter) allow(key string, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := l.buckets[key]
+	if !ok {
+		if len(l.buckets) >= maxRateLimitKeys {
+			l.pruneLocked(now)
+		}
+		b = &rateBucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+	b.tokens += now.Sub(b.last).Seconds() * l.rate
+	if b.tokens > l.burst {
+		b.tokens = l.burst
+	}
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// pruneLocked drops buckets that have refilled, or the least recently used
+// one when none has; caller holds l.mu
+func (l *rateLimiter) pruneLocked(now time.Time) {
+	refilled := time.Duration(l.burst / l.rate * float64(time.Second))
+	var oldest string
+	for key, b := range l.buckets {
+		if now.Sub(b.last) >= refilled {
+			delete(l.buckets, key)
+		} else if oldest == "" || b.last.Before(l.buckets[oldest].last) {
+			oldest = key
+		}
+	}
+	if len(l.buckets) >= maxRateLimitKeys {
+		delete(l.buckets, oldest)
+	}
+}
+
+// clientKey identifies the caller for rate limiting by its peer address
+// (forwarding headers are not trusted), IPv6 by its /64
+func clientKey(c *gin.Context) string {
+	ip := net.ParseIP(c.RemoteIP())
+	if ip == nil {
+		return c.RemoteIP()
+	}
+	if ip.To4() == nil {
+		return ip.Mask(net.CIDRMask(64, 128)).String()
+	}
+	return ip.String()
+}
@@ -0,0 +1,489 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"monad-dashboard/hexutil"
+)
+
+// Revert reasons of reverted transactions. Receipts only say that a
+// transaction reverted, so each one is looked up again: with
+// debug_traceTransaction when the node serves debug APIs, otherwise by
+// replaying it with eth_call on the parent block's state (which misses
+// reverts caused by earlier transactions of the same block). The revert
+// data is decoded as Error(string), Panic(uint256) or a custom error
+// selector and counted per contract.
+
+// Revert data selectors
+const (
+	errorStringSelector = "08c379a0" // Error(string)
+	panicSelector       = "4e487b71" // Panic(uint256)
+)
+
+// Bounds on tracked reverts
+const (
+	revertQueueSize             = 256
+	maxRevertContracts          = 1000
+	maxRevertReasonsPerContract = 50
+	maxRevertReasonLength       = 256
+)
+
+// panicReasons names the Solidity panic codes
+var panicReasons = map[uint64]string{
+	0x00: "generic compiler panic",
+	0x01: "assertion failed",
+	0x11: "arithmetic overflow or underflow",
+	0x12: "division or modulo by zero",
+	0x21: "invalid enum conversion",
+	0x22: "invalid storage byte array encoding",
+	0x31: "pop on empty array",
+	0x32: "array index out of bounds",
+	0x41: "out of memory",
+	0x51: "call to zero-initialized function",
+}
+
+// DecodedRevert is the decoded revert data of one transaction
+type DecodedRevert struct {
+	Kind     string `json:"kind"` // error, panic, custom, vm_error or empty
+	Reason   string `json:"reason"`
+	Selector string `json:"selector,omitempty"` // 4-byte selector of a custom error
+}
+
+// DecodeRevertData decodes Error(string) and Panic(uint256) revert data;
+// anything else is reported by its selector
+func DecodeRevertData(data []byte) DecodedRevert {
+	if len(data) == 0 {
+		return DecodedRevert{Kind: "empty", Reason: "reverted without data"}
+	}
+	if len(data) < 4 {
+		return DecodedRevert{Kind: "custom", Reason: "malformed revert data " + hexutil.EncodeBytes(data)}
+	}
+
+	selector := fmt.Sprintf("%x", data[:4])
+	args := data[4:]
+	switch selector {
+	case errorStringSelector:
+		if reason, ok := decodeABIString(args); ok {
+			return DecodedRevert{Kind: "error", Reason: reason}
+		}
+	case panicSelector:
+		if len(args) >= 32 {
+			code := new(big.Int).SetBytes(args[:32])
+			reason := fmt.Sprintf("panic 0x%x", code)
+			if code.IsUint64() {
+				if name, ok := panicReasons[code.Uint64()]; ok {
+					reason = fmt.Sprintf("%s (0x%02x)", name, code.Uint64())
+				}
+			}
+			return DecodedRevert{Kind: "panic", Reason: reason}
+		}
+	}
+	return DecodedRevert{Kind: "custom", Reason: "custom error 0x" + selector, Selector: "0x" + selector}
+}
+
+// decodeABIString decodes a single ABI-encoded string argument
+func decodeABIString(args []byte) (string, bool) {
+	if len(args) < 64 {
+		return "", false
+	}
+	offset, ok := abiWord(args[:32])
+	if !ok || offset+32 > uint64(len(args)) {
+		return "", false
+	}
+	length, ok := abiWord(args[offset : offset+32])
+	start := offset + 32
+	if !ok || length > uint64(len(args))-start {
+		return "", false
+	}
+	return truncateRevertReason(string(args[start : start+length])), true
+}
+
+// abiWord reads a 32-byte word that must fit in 64 bits
+func abiWord(word []byte) (uint64, bool) {
+	for _, b := range word[:24] {
+		if b != 0 {
+			return 0, false
+		}
+	}
+	return binary.BigEndian.Uint64(word[24:]), true
+}
+
+// truncateRevertReason makes a reason valid UTF-8 of bounded length
+func truncateRevertReason(reason string) string {
+	reason = strings.ToValidUTF8(reason, "�")
+	if len(reason) > maxRevertReasonLength {
+		reason = strings.ToValidUTF8(reason[:maxRevertReasonLength], "") + "…"
+	}
+	return reason
+}
+
+// revertJob is one reverted transaction to look up
+type revertJob struct {
+	blockNumber int64
+	txHash      string
+	contract    string
+}
+
+// RevertReasonStats counts one reason of one contract
+type RevertReasonStats struct {
+	DecodedRevert
+	Count     int64     `json:"count"`
+	LastTx    string    `json:"last_tx"`
+	LastBlock int64     `json:"last_block"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// contractReverts is the revert state of one contract
+type contractReverts struct {
+	reverts  int64
+	decoded  int64
+	lastSeen time.Time
+	reasons  map[string]*RevertReasonStats // kind|reason -> stats
+}
+
+// RevertTracker looks up and aggregates revert reasons
+type RevertTracker struct {
+	perBlock int // Reverted transactions looked up per block
+	queue    chan revertJob
+
+	mu        sync.RWMutex
+	contracts map[string]*contractReverts
+	debugAPI  bool // Cleared once debug_traceTransaction is found unavailable
+	observed  int64
+	decoded   int64
+	failed    int64
+	skipped   int64
+}
+
+// Global revert tracker instance
+var (
+	revertTracker     *RevertTracker
+	revertTrackerOnce sync.Once
+)
+
+// GetRevertTracker returns the global revert tracker. Up to
+// REVERT_DECODE_PER_BLOCK (default 5, 0 disables) reverted transactions of
+// each block are looked up.
+func GetRevertTracker() *RevertTracker {
+	revertTrackerOnce.Do(func() {
+		perBlock := 5
+		if value := os.Getenv("REVERT_DECODE_PER_BLOCK"); value != "" {
+			if n, err := strconv.Atoi(value); err == nil && n >= 0 {
+				perBlock = n
+			} else {
+				log.Printf("Invalid REVERT_DECODE_PER_BLOCK %q, using %d", value, perBlock)
+			}
+		}
+		revertTracker = &RevertTracker{
+			perBlock:  perBlock,
+			queue:     make(chan revertJob, revertQueueSize),
+			contracts: make(map[string]*contractReverts),
+			debugAPI:  true,
+		}
+		go revertTracker.run()
+	})
+	return revertTracker
+}
+
+// Observe queues the reverted transactions of a block's receipts for lookup
+func (rt *RevertTracker) Observe(blockNumber int64, receipts []RPCReceipt) {
+	var observed, skipped int64
+	for _, receipt := range receipts {
+		if receipt.Status == "0x1" {
+			continue
+		}
+		observed++
+		if observed > int64(rt.perBlock) {
+			skipped++
+			continue
+		}
+		contract := strings.ToLower(receipt.To)
+		if contract == "" {
+			contract = "contract_creation"
+		}
+		select {
+		case rt.queue <- revertJob{blockNumber: blockNumber, txHash: receipt.TransactionHash, contract: contract}:
+		default:
+			skipped++
+		}
+	}
+
+	rt.mu.Lock()
+	rt.observed += observed
+	rt.skipped += skipped
+	rt.mu.Unlock()
+}
+
+// run looks up queued reverts one at a time
+func (rt *RevertTracker) run() {
+	for {
+		select {
+		case <-shutdownContext().Done():
+			return
+		case job := <-rt.queue:
+			decoded, err := rt.lookup(job)
+			if err != nil {
+				log.Printf("Revert reason of %s not decoded: %v", job.txHash, err)
+			}
+			rt.record(job, decoded, err == nil)
+		}
+	}
+}
+
+// lookup fetches and decodes the revert data of one transaction
+func (rt *RevertTracker) lookup(job revertJob) (DecodedRevert, error) {
+	client := GetMonadClient()
+	if client == nil {
+		return DecodedRevert{}, errors.New("monad client not initialized")
+	}
+
+	rt.mu.RLock()
+	useDebug := rt.debugAPI
+	rt.mu.RUnlock()
+	if useDebug {
+		decoded, err := traceRevert(client, job.txHash)
+		if err == nil || !isMethodUnavailable(err) {
+			return decoded, err
+		}
+		log.Printf("debug_traceTransaction unavailable, replaying reverts with eth_call: %v", err)
+		rt.mu.Lock()
+		rt.debugAPI = false
+		rt.mu.Unlock()
+	}
+	return replayRevert(client, job)
+}
+
+// traceRevert reads the top call's output from the callTracer
+func traceRevert(client *MonadClient, txHash string) (DecodedRevert, error) {
+	var frame struct {
+		Output string `json:"output"`
+		Error  string `json:"error"`
+	}
+	config := map[string]interface{}{"tracer": "callTracer", "tracerConfig": map[string]bool{"onlyTopCall": true}}
+	if err := client.callResult("debug_traceTransaction", []interface{}{txHash, config}, &frame); err != nil {
+		return DecodedRevert{}, err
+	}
+	data, _ := hexutil.DecodeBytes(frame.Output)
+	if len(data) == 0 && frame.Error != "" && frame.Error != "execution reverted" {
+		return DecodedRevert{Kind: "vm_error", Reason: truncateRevertReason(frame.Error)}, nil
+	}
+	return DecodeRevertData(data), nil
+}
+
+// replayRevert re-executes a transaction with eth_call on its parent
+// block's state and decodes the revert data of the error
+func replayRevert(client *MonadClient, job revertJob) (DecodedRevert, error) {
+	var tx struct {
+		From  string  `json:"from"`
+		To    *string `json:"to"`
+		Input string  `json:"input"`
+		Value string  `json:"value"`
+		Gas   string  `json:"gas"`
+	}
+	if err := client.callResult("eth_getTransactionByHash", []interface{}{job.txHash}, &tx); err != nil {
+		return DecodedRevert{}, err
+	}
+
+	call := map[string]interface{}{"from": tx.From, "data": tx.Input, "value": tx.Value, "gas": tx.Gas}
+	if tx.To != nil {
+		call["to"] = *tx.To
+	}
+	var output string
+	err := client.callResult("eth_call", []interface{}{call, hexutil.EncodeInt64(job.blockNumber - 1)}, &output)
+	if err == nil || errors.Is(err, errEmptyResult) {
+		return DecodedRevert{}, errors.New("replay did not revert on the parent block's state")
+	}
+
+	var rpcErr *rpcError
+	if !errors.As(err, &rpcErr) {
+		return DecodedRevert{}, err
+	}
+	var data string
+	if len(rpcErr.Data) > 0 && json.Unmarshal(rpcErr.Data, &data) == nil {
+		if raw, decodeErr := hexutil.DecodeBytes(data); decodeErr == nil {
+			return DecodeRevertData(raw), nil
+		}
+	}
+	if strings.Contains(rpcErr.Message, "revert") {
+		return DecodeRevertData(nil), nil
+	}
+	return DecodedRevert{Kind: "vm_error", Reason: truncateRevertReason(rpcErr.Message)}, nil
+}
+
+// isMethodUnavailable reports whether a call failed because the node does
+// not serve the method
+func isMethodUnavailable(err error) bool {
+	var rpcErr *rpcError
+	if errors.As(err, &rpcErr) {
+		message := strings.ToLower(rpcErr.Message)
+		return rpcErr.Code == -32601 || strings.Contains(message, "not found") ||
+			strings.Contains(message, "not supported") || strings.Contains(message, "does not exist")
+	}
+	return false
+}
+
+// record counts a looked-up revert against its contract
+func (rt *RevertTracker) record(job revertJob, decoded DecodedRevert, ok bool) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	now := time.Now().UTC()
+	entry, exists := rt.contracts[job.contract]
+	if !exists {
+		if len(rt.contracts) >= maxRevertContracts {
+			rt.evictLocked()
+		}
+		entry = &contractReverts{reasons: make(map[string]*RevertReasonStats)}
+		rt.contracts[job.contract] = entry
+	}
+	entry.reverts++
+	entry.lastSeen = now
+	if !ok {
+		rt.failed++
+		return
+	}
+	rt.decoded++
+	entry.decoded++
+
+	key := decoded.Kind + "|" + decoded.Reason
+	reason, exists := entry.reasons[key]
+	if !exists {
+		if len(entry.reasons) >= maxRevertReasonsPerContract {
+			decoded = DecodedRevert{Kind: "other", Reason: "other reasons"}
+			key = decoded.Kind + "|" + decoded.Reason
+			reason = entry.reasons[key]
+		}
+		if reason == nil {
+			reason = &RevertReasonStats{DecodedRevert: decoded}
+			entry.reasons[key] = reason
+		}
+	}
+	reason.Count++
+	reason.LastTx = job.txHash
+	reason.LastBlock = job.blockNumber
+	reason.LastSeen = now
+}
+
+// evictLocked drops the contract that reverted least recently; caller
+// holds rt.mu
+func (rt *RevertTracker) evictLocked() {
+	var oldest string
+	var oldestAt time.Time
+	for contract, entry := range rt.contracts {
+		if oldest == "" || entry.lastSeen.Before(oldestAt) {
+			oldest, oldestAt = contract, entry.lastSeen
+		}
+	}
+	delete(rt.contracts, oldest)
+}
+
+// ContractRevertStats are the top revert reasons of one contract
+type ContractRevertStats struct {
+	Contract string              `json:"contract"` // contract_creation for reverted deployments
+	Reverts  int64               `json:"reverts"`  // Reverts looked up
+	Decoded  int64               `json:"decoded"`
+	LastSeen time.Time           `json:"last_seen"`
+	Reasons  []RevertReasonStats `json:"reasons"` // Most frequent first
+}
+
+// RevertsResponse is the body of /api/v1/execution/reverts
+type RevertsResponse struct {
+	Method    string                `json:"method"`    // debug_traceTransaction or eth_call
+	PerBlock  int                   `json:"per_block"` // Lookup budget per block; 0 when disabled
+	Observed  int64                 `json:"observed"`  // Reverted transactions in receipts
+	Decoded   int64                 `json:"decoded"`
+	Failed    int64                 `json:"failed"`  // Lookups that returned no revert data
+	Skipped   int64                 `json:"skipped"` // Over the per-block budget or queue
+	Contracts []ContractRevertStats `json:"contracts"`
+}
+
+// Top returns the contracts with the most reverts and each one's most
+// frequent reasons, optionally for one contract
+func (rt *RevertTracker) Top(contract string, limit, reasons int) RevertsResponse {
+	rt.mu.RLock()
+	defer rt.mu.RUnlock()
+
+	response := RevertsResponse{
+		Method:    "eth_call",
+		PerBlock:  rt.perBlock,
+		Observed:  rt.observed,
+		Decoded:   rt.decoded,
+		Failed:    rt.failed,
+		Skipped:   rt.skipped,
+		Contracts: make([]ContractRevertStats, 0),
+	}
+	if rt.debugAPI {
+		response.Method = "debug_traceTransaction"
+	}
+
+	for address, entry := range rt.contracts {
+		if contract != "" && address != contract {
+			continue
+		}
+		stats := ContractRevertStats{Contract: address, Reverts: entry.reverts, Decoded: entry.decoded, LastSeen: entry.lastSeen}
+		for _, reason := range entry.reasons {
+			stats.Reasons = append(stats.Reasons, *reason)
+		}
+		sort.Slice(stats.Reasons, func(i, j int) bool {
+			if stats.Reasons[i].Count != stats.Reasons[j].Count {
+				return stats.Reasons[i].Count > stats.Reasons[j].Count
+			}
+			return stats.Reasons[i].LastSeen.After(stats.Reasons[j].LastSeen)
+		})
+		if len(stats.Reasons) > reasons {
+			stats.Reasons = stats.Reasons[:reasons]
+		}
+		response.Contracts = append(response.Contracts, stats)
+	}
+	sort.Slice(response.Contracts, func(i, j int) bool {
+		if response.Contracts[i].Reverts != response.Contracts[j].Reverts {
+			return response.Contracts[i].Reverts > response.Contracts[j].Reverts
+		}
+		return response.Contracts[i].Contract < response.Contracts[j].Contract
+	})
+	if len(response.Contracts) > limit {
+		response.Contracts = response.Contracts[:limit]
+	}
+	return response
+}
+
+// handleExecutionReverts returns the top revert reasons per contract
+func handleExecutionReverts(c *gin.Context) {
+	limit := 20
+	if value := c.Query("limit"); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			limit = n
+		}
+	}
+	reasons := 5
+	if value := c.Query("reasons"); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			reasons = n
+		}
+	}
+	contract := c.Query("contract")
+	if contract != "" && contract != "contract_creation" {
+		normalized, err := normalizeAddress(contract)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, APIError{Error: err.Error()})
+			return
+		}
+		contract = normalized
+	}
+
+	c.JSON(http.StatusOK, GetRevertTracker().Top(contract, limit, reasons))
+}
@@ -0,0 +1,448 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"monad-dashboard/hexutil"
+)
+
+// A sequence gap on an event ring means events never reached the
+// processors, so the counters they feed drift. On a gap the reader asks the
+// ring to replay from the first missing sequence; the ring resends what it
+// still retains, from that sequence or its oldest retained one, and replayed
+// events that fill the gap are processed as if they had arrived in order.
+// Whatever is not replayed within RING_RESYNC_TIMEOUT is reconciled from a
+// snapshot of the node's state over the gap's span: receipts for the exec
+// ring, blocks and heads for the consensus ring, the drop counter samples
+// for the mempool ring. Each gap is recorded in the incident log as the
+// ring_<name> component degrading until the gap is resolved.
+
+// EventTypeReplayRequest is sent to a ring to have it resend its retained
+// events from the header's sequence number
+const EventTypeReplayRequest = 0xFFFF
+
+// Ring gap statuses
+const (
+	ringGapReplaying  = "replaying"
+	ringGapReplayed   = "replayed"   // Every missed event was replayed
+	ringGapReconciled = "reconciled" // The rest was reconciled from a snapshot
+	ringGapPartial    = "partial"    // Some missed events were neither replayed nor reconciled
+)
+
+// maxRingResyncBlocks bounds the blocks fetched to reconcile one gap
+const maxRingResyncBlocks = 100
+
+// RingGap is a run of sequence numbers missed on one ring
+type RingGap struct {
+	Ring         string           `json:"ring"`
+	FromSequence uint64           `json:"from_sequence"`
+	ToSequence   uint64           `json:"to_sequence"`
+	Missed       uint64           `json:"missed"`
+	Replayed     uint64           `json:"replayed"`
+	Reconciled   map[string]int64 `json:"reconciled,omitempty"` // Counter -> count added from the snapshot
+	Snapshot     string           `json:"snapshot,omitempty"`   // Source of the reconciled counts
+	Status       string           `json:"status"`
+	Error        string           `json:"error,omitempty"`
+	SpanFrom     int64            `json:"span_from"` // Unix ms of the last event before the gap
+	SpanTo       int64            `json:"span_to"`   // Unix ms of the first event after it
+	DetectedAt   int64            `json:"detected_at"`
+	ResolvedAt   int64            `json:"resolved_at,omitempty"`
+
+	lastReplayed uint64        // Replays arrive in order; later duplicates are ignored
+	replayed     chan struct{} // Closed once every missed event was replayed
+}
+
+// RingReconciler adds a ring's missed events to the counters from a
+// snapshot of the node's state over the gap's span, scaling counts by share
+// (the part of the gap not replayed). It returns the counts it added and
+// their source.
+type RingReconciler func(gap RingGap, share float64) (map[string]int64, string, error)
+
+// ringReconcilers are the snapshot reconcilers per ring
+var (
+	ringReconcilers   = make(map[string]RingReconciler)
+	ringReconcilersMu sync.RWMutex
+)
+
+func init() {
+	RegisterRingReconciler("exec", reconcileExecutionGap)
+	RegisterRingReconciler("consensus", reconcileConsensusGap)
+	RegisterRingReconciler("mempool", reconcileMempoolGap)
+}
+
+// RegisterRingReconciler sets the snapshot reconciler for the named ring
+func RegisterRingReconciler(ring string, reconciler RingReconciler) {
+	ringReconcilersMu.Lock()
+	defer ringReconcilersMu.Unlock()
+	ringReconcilers[ring] = reconciler
+}
+
+// RingResyncer replays or reconciles the events missed in ring gaps
+type RingResyncer struct {
+	timeout time.Duration
+
+	mu      sync.RWMutex
+	gaps    []*RingGap
+	maxGaps int
+	open    map[string]int // Ring -> gaps still replaying
+}
+
+// Global ring resyncer instance
+var (
+	ringResyncer   *RingResyncer
+	ringResyncerMu sync.RWMutex
+)
+
+// InitializeRingResync enables gap recovery for the event rings. Replays
+// are awaited for RING_RESYNC_TIMEOUT (default 5s); RING_RESYNC=false
+// disables recovery, leaving gaps counted in missed_events only.
+func InitializeRingResync() *RingResyncer {
+	if os.Getenv("RING_RESYNC") == "false" {
+		return nil
+	}
+	rs := &RingResyncer{
+		timeout: 5 * time.Second,
+		maxGaps: 100,
+		open:    make(map[string]int),
+	}
+	if value := os.Getenv("RING_RESYNC_TIMEOUT"); value != "" {
+		if d, err := time.ParseDuration(value); err == nil && d > 0 {
+			rs.timeout = d
+		} else {
+			log.Printf("Invalid RING_RESYNC_TIMEOUT %q, using %s", value, rs.timeout)
+		}
+	}
+
+	ringResyncerMu.Lock()
+	ringResyncer = rs
+	ringResyncerMu.Unlock()
+	return rs
+}
+
+// GetRingResyncer returns the global ring resyncer, nil when disabled
+func GetRingResyncer() *RingResyncer {
+	ringResyncerMu.RLock()
+	defer ringResyncerMu.RUnlock()
+	return ringResyncer
+}
+
+// ringComponent is the incident log component of a ring
+func ringComponent(ring string) string {
+	return "ring_" + ring
+}
+
+// OnGap records the gap between sequences from and to (inclusive) on the
+// reader's ring, spanning lastAt to at, and starts recovering it
+func (rs *RingResyncer) OnGap(reader *EventRingReader, from, to uint64, lastAt, at time.Time) {
+	gap := &RingGap{
+		Ring:         reader.Name(),
+		FromSequence: from,
+		ToSequence:   to,
+		Missed:       to - from + 1,
+		Status:       ringGapReplaying,
+		SpanFrom:     lastAt.UnixMilli(),
+		SpanTo:       at.UnixMilli(),
+		DetectedAt:   at.Unix(),
+		lastReplayed: from - 1,
+		replayed:     make(chan struct{}),
+	}
+
+	rs.mu.Lock()
+	rs.gaps = append(rs.gaps, gap)
+	if len(rs.gaps) > rs.maxGaps {
+		rs.gaps = rs.gaps[len(rs.gaps)-rs.maxGaps:]
+	}
+	rs.open[gap.Ring]++
+	rs.mu.Unlock()
+
+	if it := GetIncidentTracker(); it != nil {
+		it.Report(ringComponent(gap.Ring), stateDegraded, fmt.Sprintf("missed %d events (seq %d-%d)", gap.Missed, from, to), at)
+	}
+
+	go func() {
+		if err := reader.requestReplay(from); err != nil {
+			log.Printf("Replay request to %s ring failed: %v", gap.Ring, err)
+		} else {
+			select {
+			case <-gap.replayed:
+			case <-time.After(rs.timeout):
+			case <-shutdownContext().Done():
+			}
+		}
+		rs.resolve(gap)
+	}()
+}
+
+// Replayed reports whether seq on ring fills an open gap, counting it
+// as replayed; anything else at or below the last sequence is a duplicate
+func (rs *RingResyncer) Replayed(ring string, seq uint64) bool {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	for _, gap := range rs.gaps {
+		if gap.Ring != ring || gap.Status != ringGapReplaying || seq <= gap.lastReplayed || seq > gap.ToSequence {
+			continue
+		}
+		gap.lastReplayed = seq
+		gap.Replayed++
+		if gap.Replayed == gap.Missed {
+			close(gap.replayed)
+		}
+		return true
+	}
+	return false
+}
+
+// resolve reconciles what the replay left missing and closes the gap
+func (rs *RingResyncer) resolve(gap *RingGap) {
+	rs.mu.Lock()
+	missing := gap.Missed - gap.Replayed
+	gap.Status = "" // Late replays no longer count towards this gap
+	snapshot := *gap
+	rs.mu.Unlock()
+
+	status, counts, source, errText := ringGapReplayed, map[string]int64(nil), "", ""
+	if missing > 0 {
+		ringReconcilersMu.RLock()
+		reconcile := ringReconcilers[gap.Ring]
+		ringReconcilersMu.RUnlock()
+
+		status = ringGapPartial
+		if reconcile == nil {
+			errText = "no snapshot source for this ring"
+		} else if c, s, err := reconcile(snapshot, float64(missing)/float64(gap.Missed)); err != nil {
+			errText = err.Error()
+		} else {
+			status, counts, source = ringGapReconciled, c, s
+		}
+	}
+
+	rs.mu.Lock()
+	gap.Status, gap.Reconciled, gap.Snapshot, gap.Error = status, counts, source, errText
+	gap.ResolvedAt = time.Now().Unix()
+	rs.open[gap.Ring]--
+	stillOpen := rs.open[gap.Ring] > 0
+	rs.mu.Unlock()
+
+	summary := fmt.Sprintf("seq %d-%d %s: %d replayed", gap.FromSequence, gap.ToSequence, status, gap.Replayed)
+	if counts != nil {
+		summary += fmt.Sprintf(", rest reconciled from %s", source)
+	}
+	if errText != "" {
+		summary += ", " + errText
+	}
+	log.Printf("Event ring %s gap %s", gap.Ring, summary)
+	if it := GetIncidentTracker(); it != nil && !stillOpen {
+		it.Report(ringComponent(gap.Ring), stateConnected, summary, time.Now())
+	}
+}
+
+// Gaps returns the most recent gaps, newest first, of one ring or all
+func (rs *RingResyncer) Gaps(ring string, limit int) []RingGap {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	gaps := []RingGap{}
+	for i := len(rs.gaps) - 1; i >= 0 && len(gaps) < limit; i-- {
+		if ring == "" || rs.gaps[i].Ring == ring {
+			gaps = append(gaps, *rs.gaps[i])
+		}
+	}
+	return gaps
+}
+
+// requestReplay asks the ring to resend its retained events from seq
+func (r *EventRingReader) requestReplay(seq uint64) error {
+	r.mutex.RLock()
+	conn := r.conn
+	r.mutex.RUnlock()
+	if conn == nil {
+		return fmt.Errorf("not connected")
+	}
+
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+	conn.SetWriteDeadline(time.Now().Add(2 * time.Second))
+	return binary.Write(conn, binary.LittleEndian, ExecutionEventHeader{
+		SequenceNumber: seq,
+		Timestamp:      uint64(time.Now().UnixNano()),
+		EventType:      EventTypeReplayRequest,
+	})
+}
+
+// scaleCount scales a snapshot count to the share of a gap not replayed
+func scaleCount(count, share float64) int64 {
+	return int64(math.Round(count * share))
+}
+
+// blocksInSpan returns the blocks, oldest first, whose timestamps fall
+// within the span of a gap, walking back from the latest head
+func blocksInSpan(gap RingGap) ([]*RPCBlock, error) {
+	client := GetMonadClient()
+	if client == nil {
+		return nil, fmt.Errorf("Monad client not initialized")
+	}
+	var latest int64
+	if ht := GetHeadTracker(); ht != nil {
+		latest, _ = ht.Heads()
+	}
+	if latest <= 0 {
+		head, err := client.GetBlockNumberByTag("latest")
+		if err != nil {
+			return nil, err
+		}
+		latest = head
+	}
+
+	// Block timestamps are whole seconds
+	from := time.UnixMilli(gap.SpanFrom).Truncate(time.Second)
+	to := time.UnixMilli(gap.SpanTo)
+	var blocks []*RPCBlock
+	for number := latest; number >= 0 && number > latest-maxRingResyncBlocks; number-- {
+		block, err := client.GetBlockByNumber(number)
+		if err != nil {
+			return nil, fmt.Errorf("block %d: %w", number, err)
+		}
+		at := GetClockSkew().BlockTime(hexutil.Int64OrZero(block.Timestamp))
+		if at.Before(from) {
+			break
+		}
+		if !at.After(to) {
+			blocks = append([]*RPCBlock{block}, blocks...)
+		}
+	}
+	return blocks, nil
+}
+
+// reconcileExecutionGap counts the transactions executed during the gap
+// from the receipts of the blocks in its span
+func reconcileExecutionGap(gap RingGap, share float64) (map[string]int64, string, error) {
+	blocks, err := blocksInSpan(gap)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var txs, succeeded, reverted int
+	for _, block := range blocks {
+		number := hexutil.Int64OrZero(block.Number)
+		stats, ok := GetExecStatsTracker().ForBlock(number)
+		if !ok {
+			receipts, err := GetMonadClient().GetBlockReceipts(number)
+			if err != nil {
+				return nil, "", fmt.Errorf("receipts of block %d: %w", number, err)
+			}
+			stats = ComputeBlockExecStats(number, receipts)
+		}
+		txs += stats.TxCount
+		succeeded += stats.Succeeded
+		reverted += stats.Reverted
+	}
+
+	counts := map[string]int64{
+		"transaction_start":   scaleCount(float64(txs), share),
+		"transaction_success": scaleCount(float64(succeeded), share),
+		"transaction_failed":  scaleCount(float64(reverted), share),
+	}
+	for name, count := range counts {
+		updateWaterfallFromEvent(name, count)
+	}
+	return counts, "receipts", nil
+}
+
+// reconcileConsensusGap records the proposals, votes and finalizations
+// the consensus tracker missed for the blocks in the gap's span. Blocks are
+// matched by number, so ones a replay already delivered are not counted.
+func reconcileConsensusGap(gap RingGap, _ float64) (map[string]int64, string, error) {
+	blocks, err := blocksInSpan(gap)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var latest, finalized int64
+	if ht := GetHeadTracker(); ht != nil {
+		latest, finalized = ht.Heads()
+	}
+	lifecycle := GetMonadWaterfallMetrics()
+	stages := GetWaterfallMetrics()
+	ct := GetConsensusTracker()
+	counts := map[string]int64{"block_proposed": 0, "block_voted": 0, "block_finalized": 0}
+	for _, block := range blocks {
+		number := hexutil.Int64OrZero(block.Number)
+		if _, ok := ct.Block(uint64(number)); !ok {
+			ct.OnBlockProposed(uint64(number), block.Hash, len(block.Transactions))
+			lifecycle.ConsensusProposed.Add(1)
+			stages.BlockProposed.Add(1)
+			counts["block_proposed"]++
+		}
+		state, _ := ct.Block(uint64(number))
+		if state.Phase == "proposed" && number < latest {
+			ct.OnBlockVoted(uint64(number))
+			lifecycle.ConsensusVoted.Add(1)
+			stages.BlockQCFormed.Add(1)
+			counts["block_voted"]++
+		}
+		if state.Phase != "finalized" && number <= finalized {
+			ct.OnBlockFinalized(uint64(number))
+			lifecycle.ConsensusFinalized.Add(1)
+			stages.BlockFinalized.Add(1)
+			counts["block_finalized"]++
+		}
+	}
+	return counts, "blocks", nil
+}
+
+// reconcileMempoolGap counts the txpool drops during the gap from the
+// drop counter samples. Mempool ingress has no per-span source and is not
+// reconciled.
+func reconcileMempoolGap(gap RingGap, share float64) (map[string]int64, string, error) {
+	dt := GetDropTracker()
+	if dt == nil {
+		return nil, "", fmt.Errorf("drop tracker not initialized")
+	}
+	drops, source, ok := dt.Attribute(time.UnixMilli(gap.SpanFrom), time.UnixMilli(gap.SpanTo))
+	if !ok {
+		return nil, "", fmt.Errorf("no drop counter samples around the gap")
+	}
+
+	counts := make(map[string]int64, len(drops))
+	for reason, count := range drops {
+		counts[reason] = scaleCount(count, share)
+		countMempoolDrop(reason, counts[reason])
+	}
+	return counts, source, nil
+}
+
+// RingGapsResponse is the body of /api/v1/event-rings/gaps
+type RingGapsResponse struct {
+	Enabled        bool      `json:"enabled"`
+	TimeoutSeconds float64   `json:"timeout_seconds,omitempty"`
+	Gaps           []RingGap `json:"gaps"`
+}
+
+// handleRingGaps lists recent event ring gaps and how each was recovered
+// Query params: ring (optional), limit (default 50)
+func handleRingGaps(c *gin.Context) {
+	rs := GetRingResyncer()
+	if rs == nil {
+		c.JSON(http.StatusOK, RingGapsResponse{Gaps: []RingGap{}})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 {
+		c.JSON(http.StatusBadRequest, APIError{Error: "limit must be a positive integer"})
+		return
+	}
+	c.JSON(http.StatusOK, RingGapsResponse{
+		Enabled:        true,
+		TimeoutSeconds: rs.timeout.Seconds(),
+		Gaps:           rs.Gaps(c.Query("ring"), limit),
+	})
+}
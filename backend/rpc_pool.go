@@ -0,0 +1,559 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"monad-dashboard/hexutil"
+)
+
+// RPC endpoint breaker states
+const (
+	breakerClosed   = "closed"    // Serving calls
+	breakerOpen     = "open"      // Skipped until the cooldown ends or a health check succeeds
+	breakerHalfOpen = "half_open" // Cooldown over; the next call is a trial
+)
+
+// RPC endpoint selection strategies (RPC_STRATEGY)
+const (
+	rpcRoundRobin = "round_robin" // Spread calls across healthy endpoints
+	rpcFailover   = "failover"    // Always the first healthy endpoint in configured order
+)
+
+// errNoRPCEndpoint is returned when every endpoint's breaker is open
+var errNoRPCEndpoint = errors.New("no RPC endpoint available")
+
+// errRPCRateLimited is a JSON-RPC limit exceeded (-32005) response
+var errRPCRateLimited = errors.New("rate limited")
+
+// httpStatusError is a non-2xx RPC response
+type httpStatusError struct {
+	code int
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("HTTP %d", e.code)
+}
+
+// rpcRetryable reports whether a failed call is worth another attempt:
+// refused or reset connections, 5xx, 429 and rate limits, which a node
+// restarting or shedding load returns quickly. Timeouts are not retried
+// since the call already waited the full timeout, nor are responses that
+// will not change, such as a 404 or a body that is not JSON.
+func rpcRetryable(err error) bool {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return false
+	}
+	var statusErr *httpStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.code >= 500 || statusErr.code == http.StatusTooManyRequests
+	}
+	return errors.Is(err, errRPCRateLimited) || errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// rpcLatencySamples is how many recent call latencies each endpoint keeps
+const rpcLatencySamples = 200
+
+// rpcEndpoint is one execution RPC URL with its breaker and call stats
+type rpcEndpoint struct {
+	url string
+
+	mu                  sync.Mutex
+	state               string
+	consecutiveFailures int
+	openedAt            time.Time
+	requests            int64
+	failures            int64
+	latencies           []float64 // Milliseconds, ring of rpcLatencySamples
+	latencyNext         int
+	lastLatency         float64
+	lastError           string
+	lastSuccess         time.Time
+	lastFailure         time.Time
+	blockNumber         int64 // From the last health check
+	checkedAt           time.Time
+}
+
+// RPCPool spreads JSON-RPC calls across the configured execution RPC
+// endpoints. A call fails over to the next endpoint on a transport error or
+// 5xx, and an endpoint whose calls fail RPC_BREAKER_FAILURES times in a row
+// is skipped for RPC_BREAKER_COOLDOWN. Health checks poll eth_blockNumber on
+// every endpoint, so a restarted node rejoins as soon as it answers.
+type RPCPool struct {
+	endpoints        []*rpcEndpoint
+	strategy         string
+	failureThreshold int
+	cooldown         time.Duration
+	retries          int           // Extra rounds over the endpoints after retryable failures
+	retryBackoff     time.Duration // Wait before the first retry, doubled for each one
+	timeout          time.Duration // Per attempt
+	next             uint64        // Round-robin cursor
+	httpClient       *http.Client
+	clock            Clock // Breaker cooldowns, latencies and health checks
+
+	retried   int64 // Calls that needed a retry
+	recovered int64 // Of those, calls a retry answered
+	gaveUp    int64 // Calls that failed on every endpoint and retry
+}
+
+// NewRPCPool creates a pool over comma-separated RPC URLs. Settings come
+// from RPC_STRATEGY (round_robin or failover), RPC_BREAKER_FAILURES
+// (default 3), RPC_BREAKER_COOLDOWN (default 10s), RPC_RETRIES (default 2,
+// 0 disables), RPC_RETRY_BACKOFF (default 100ms) and RPC_TIMEOUT, the limit
+// on each attempt (default 5s).
+func NewRPCPool(urls string, httpClient *http.Client) *RPCPool {
+	p := &RPCPool{
+		strategy:         rpcRoundRobin,
+		failureThreshold: 3,
+		cooldown:         10 * time.Second,
+		retries:          2,
+		retryBackoff:     100 * time.Millisecond,
+		timeout:          5 * time.Second,
+		httpClient:       httpClient,
+		clock:            systemClock,
+	}
+	for _, url := range strings.Split(urls, ",") {
+		if url = strings.TrimSpace(url); url != "" {
+			p.endpoints = append(p.endpoints, &rpcEndpoint{url: url, state: breakerClosed})
+		}
+	}
+
+	switch strategy := os.Getenv("RPC_STRATEGY"); strategy {
+	case "", rpcRoundRobin:
+	case rpcFailover:
+		p.strategy = rpcFailover
+	default:
+		log.Printf("Invalid RPC_STRATEGY %q, using %s", strategy, p.strategy)
+	}
+	if n, ok := positiveIntEnv("RPC_BREAKER_FAILURES"); ok {
+		p.failureThreshold = int(n)
+	}
+	if value := os.Getenv("RPC_BREAKER_COOLDOWN"); value != "" {
+		if d, err := time.ParseDuration(value); err == nil && d > 0 {
+			p.cooldown = d
+		} else {
+			log.Printf("Invalid RPC_BREAKER_COOLDOWN %q, using %s", value, p.cooldown)
+		}
+	}
+	if value := os.Getenv("RPC_RETRIES"); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n >= 0 {
+			p.retries = n
+		} else {
+			log.Printf("Invalid RPC_RETRIES %q, using %d", value, p.retries)
+		}
+	}
+	if value := os.Getenv("RPC_RETRY_BACKOFF"); value != "" {
+		if d, err := time.ParseDuration(value); err == nil && d > 0 {
+			p.retryBackoff = d
+		} else {
+			log.Printf("Invalid RPC_RETRY_BACKOFF %q, using %s", value, p.retryBackoff)
+		}
+	}
+	if value := os.Getenv("RPC_TIMEOUT"); value != "" {
+		if d, err := time.ParseDuration(value); err == nil && d > 0 {
+			p.timeout = d
+		} else {
+			log.Printf("Invalid RPC_TIMEOUT %q, using %s", value, p.timeout)
+		}
+	}
+	return p
+}
+
+// URLs returns the endpoint URLs in configured order
+func (p *RPCPool) URLs() []string {
+	urls := make([]string, len(p.endpoints))
+	for i, ep := range p.endpoints {
+		urls[i] = ep.url
+	}
+	return urls
+}
+
+// available reports whether the endpoint may take a call, moving an open
+// breaker whose cooldown is over to half-open
+func (ep *rpcEndpoint) available(now time.Time, cooldown time.Duration) bool {
+	ep.mu.Lock()
+	defer ep.mu.Unlock()
+	if ep.state == breakerOpen && now.Sub(ep.openedAt) >= cooldown {
+		ep.state = breakerHalfOpen
+	}
+	return ep.state != breakerOpen
+}
+
+// order returns the endpoints to try for one call: available ones first,
+// starting from the round-robin cursor unless failing over in order
+func (p *RPCPool) order() []*rpcEndpoint {
+	now := p.clock.Now()
+	start := 0
+	if p.strategy == rpcRoundRobin && len(p.endpoints) > 1 {
+		start = int(atomic.AddUint64(&p.next, 1) % uint64(len(p.endpoints)))
+	}
+	order := make([]*rpcEndpoint, 0, len(p.endpoints))
+	for i := range p.endpoints {
+		ep := p.endpoints[(start+i)%len(p.endpoints)]
+		if ep.available(now, p.cooldown) {
+			order = append(order, ep)
+		}
+	}
+	return order
+}
+
+// record updates an endpoint's stats and breaker after a call
+func (p *RPCPool) record(ep *rpcEndpoint, latency time.Duration, err error) {
+	now := p.clock.Now()
+	ms := float64(latency.Microseconds()) / 1000
+
+	ep.mu.Lock()
+	ep.requests++
+	ep.lastLatency = ms
+	if len(ep.latencies) < rpcLatencySamples {
+		ep.latencies = append(ep.latencies, ms)
+	} else {
+		ep.latencies[ep.latencyNext] = ms
+		ep.latencyNext = (ep.latencyNext + 1) % rpcLatencySamples
+	}
+
+	previous := ep.state
+	if err == nil {
+		ep.consecutiveFailures = 0
+		ep.lastSuccess = now
+		ep.state = breakerClosed
+	} else {
+		ep.failures++
+		ep.consecutiveFailures++
+		ep.lastError = err.Error()
+		ep.lastFailure = now
+		if ep.state == breakerHalfOpen || ep.consecutiveFailures >= p.failureThreshold {
+			ep.state = breakerOpen
+			ep.openedAt = now
+		}
+	}
+	state, failures := ep.state, ep.consecutiveFailures
+	ep.mu.Unlock()
+
+	if state == previous || (previous == breakerHalfOpen && state == breakerOpen) {
+		return
+	}
+	switch state {
+	case breakerOpen:
+		log.Printf("RPC endpoint %s unavailable after %d failures (%v); retrying in %s", ep.url, failures, err, p.cooldown)
+	case breakerClosed:
+		log.Printf("RPC endpoint %s recovered", ep.url)
+	}
+}
+
+// post sends one request body to an endpoint within RPC_TIMEOUT. Transport
+// errors and 5xx responses are endpoint failures; JSON-RPC errors are not.
+func (p *RPCPool) post(ctx context.Context, url string, body []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusNotFound {
+		io.Copy(io.Discard, resp.Body)
+		return nil, &httpStatusError{code: resp.StatusCode}
+	}
+
+	var result json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	var envelope struct {
+		Error *rpcError `json:"error"`
+	}
+	if json.Unmarshal(result, &envelope) == nil && envelope.Error != nil && envelope.Error.Code == -32005 {
+		return nil, fmt.Errorf("%w: %s", errRPCRateLimited, envelope.Error.Message)
+	}
+	return result, nil
+}
+
+// Call sends a request body to the endpoints in order until one answers.
+// When every endpoint failed and at least one failure was retryable, the
+// round is repeated up to RPC_RETRIES times with exponential backoff; with
+// every breaker open it fails at once. Cancelling ctx abandons the call
+// without counting it against the endpoint.
+func (p *RPCPool) Call(ctx context.Context, body []byte) ([]byte, error) {
+	lastErr := errNoRPCEndpoint
+	for attempt := 0; ; attempt++ {
+		retryable := false
+		for _, ep := range p.order() {
+			start := p.clock.Now()
+			result, err := p.post(ctx, ep.url, body)
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			p.record(ep, p.clock.Since(start), err)
+			if err == nil {
+				if attempt > 0 {
+					atomic.AddInt64(&p.recovered, 1)
+				}
+				return result, nil
+			}
+			retryable = retryable || rpcRetryable(err)
+			lastErr = err
+			if len(p.endpoints) > 1 {
+				lastErr = fmt.Errorf("%s: %w", ep.url, err)
+			}
+		}
+		if !retryable || attempt >= p.retries {
+			break
+		}
+		if attempt == 0 {
+			atomic.AddInt64(&p.retried, 1)
+		}
+		select {
+		case <-p.clock.After(p.backoff(attempt)):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	atomic.AddInt64(&p.gaveUp, 1)
+	return nil, lastErr
+}
+
+// backoff is the wait before retry n (from 0): RPC_RETRY_BACKOFF doubled
+// per retry with jitter, at most 2s
+func (p *RPCPool) backoff(n int) time.Duration {
+	d := p.retryBackoff << n
+	if d > 2*time.Second || d <= 0 {
+		d = 2 * time.Second
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// StartHealthChecks polls every endpoint's eth_blockNumber each interval
+// until shutdown. A successful check closes an open breaker.
+func (p *RPCPool) StartHealthChecks(interval time.Duration) {
+	body, _ := json.Marshal(map[string]interface{}{"jsonrpc": "2.0", "method": "eth_blockNumber", "params": []interface{}{}, "id": 1})
+	go func() {
+		ticker := p.clock.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			for _, ep := range p.endpoints {
+				p.check(ep, body)
+			}
+			select {
+			case <-ticker.C():
+			case <-shutdownContext().Done():
+				return
+			}
+		}
+	}()
+}
+
+// check runs one health check against an endpoint
+func (p *RPCPool) check(ep *rpcEndpoint, body []byte) {
+	start := p.clock.Now()
+	result, err := p.post(shutdownContext(), ep.url, body)
+	if shutdownContext().Err() != nil {
+		return
+	}
+	var number int64
+	if err == nil {
+		var envelope struct {
+			Result string    `json:"result"`
+			Error  *rpcError `json:"error"`
+		}
+		if err = json.Unmarshal(result, &envelope); err == nil && envelope.Error != nil {
+			err = envelope.Error
+		}
+		if err == nil {
+			number, err = hexutil.DecodeInt64(envelope.Result)
+		}
+	}
+	p.record(ep, p.clock.Since(start), err)
+
+	ep.mu.Lock()
+	ep.checkedAt = p.clock.Now()
+	if err == nil {
+		ep.blockNumber = number
+	}
+	ep.mu.Unlock()
+}
+
+// RPCLatency summarizes an endpoint's recent call latencies, in milliseconds
+type RPCLatency struct {
+	Samples int     `json:"samples"`
+	Last    float64 `json:"last"`
+	Avg     float64 `json:"avg"`
+	P50     float64 `json:"p50"`
+	P95     float64 `json:"p95"`
+	Max     float64 `json:"max"`
+}
+
+// RPCEndpointStatus is one endpoint as reported by /api/v1/rpc/endpoints
+type RPCEndpointStatus struct {
+	URL                 string     `json:"url"`
+	State               string     `json:"state"` // closed, open or half_open
+	Healthy             bool       `json:"healthy"`
+	Requests            int64      `json:"requests"`
+	Failures            int64      `json:"failures"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	Latency             RPCLatency `json:"latency_ms"`
+	LastError           string     `json:"last_error,omitempty"`
+	LastSuccess         int64      `json:"last_success,omitempty"`
+	LastFailure         int64      `json:"last_failure,omitempty"`
+	BlockNumber         int64      `json:"block_number"` // From the last health check
+	LagBlocks           int64      `json:"lag_blocks"`   // Behind the highest endpoint
+	CheckedAt           int64      `json:"checked_at,omitempty"`
+}
+
+// Statuses reports every endpoint in configured order
+func (p *RPCPool) Statuses() []RPCEndpointStatus {
+	statuses := make([]RPCEndpointStatus, 0, len(p.endpoints))
+	highest := int64(0)
+	for _, ep := range p.endpoints {
+		ep.mu.Lock()
+		status := RPCEndpointStatus{
+			URL:                 ep.url,
+			State:               ep.state,
+			Healthy:             ep.state == breakerClosed,
+			Requests:            ep.requests,
+			Failures:            ep.failures,
+			ConsecutiveFailures: ep.consecutiveFailures,
+			LastError:           ep.lastError,
+			BlockNumber:         ep.blockNumber,
+		}
+		if !ep.lastSuccess.IsZero() {
+			status.LastSuccess = ep.lastSuccess.Unix()
+		}
+		if !ep.lastFailure.IsZero() {
+			status.LastFailure = ep.lastFailure.Unix()
+		}
+		if !ep.checkedAt.IsZero() {
+			status.CheckedAt = ep.checkedAt.Unix()
+		}
+		latencies := append([]float64(nil), ep.latencies...)
+		status.Latency.Last = ep.lastLatency
+		ep.mu.Unlock()
+
+		if n := len(latencies); n > 0 {
+			sort.Float64s(latencies)
+			sum := 0.0
+			for _, ms := range latencies {
+				sum += ms
+			}
+			status.Latency.Samples = n
+			status.Latency.Avg = sum / float64(n)
+			status.Latency.P50 = latencies[n*50/100]
+			status.Latency.P95 = latencies[n*95/100]
+			status.Latency.Max = latencies[n-1]
+		}
+		if status.BlockNumber > highest {
+			highest = status.BlockNumber
+		}
+		statuses = append(statuses, status)
+	}
+	for i := range statuses {
+		if statuses[i].BlockNumber > 0 {
+			statuses[i].LagBlocks = highest - statuses[i].BlockNumber
+		}
+	}
+	return statuses
+}
+
+// RPCRetryStats counts calls that needed the retry policy
+type RPCRetryStats struct {
+	Retries   int    `json:"retries"` // Extra rounds per call (RPC_RETRIES)
+	Backoff   string `json:"backoff"` // Before the first retry, doubled for each one
+	Retried   int64  `json:"retried"`
+	Recovered int64  `json:"recovered"` // Answered by a retry
+	GaveUp    int64  `json:"gave_up"`   // Failed on every endpoint and retry, or with every breaker open
+}
+
+// RetryStats reports the retry policy and its counters
+func (p *RPCPool) RetryStats() RPCRetryStats {
+	return RPCRetryStats{
+		Retries:   p.retries,
+		Backoff:   p.retryBackoff.String(),
+		Retried:   atomic.LoadInt64(&p.retried),
+		Recovered: atomic.LoadInt64(&p.recovered),
+		GaveUp:    atomic.LoadInt64(&p.gaveUp),
+	}
+}
+
+// RPCBreakerState is one endpoint's breaker in /api/v1/health
+type RPCBreakerState struct {
+	URL                 string `json:"url"`
+	State               string `json:"state"`
+	ConsecutiveFailures int    `json:"consecutive_failures"`
+	RetryAt             int64  `json:"retry_at,omitempty"` // When an open breaker lets a trial call through
+}
+
+// RPCHealth summarizes the execution RPC breakers for /api/v1/health
+type RPCHealth struct {
+	State    string            `json:"state"` // closed while any endpoint serves, open when none does, else half_open
+	Breakers []RPCBreakerState `json:"breakers"`
+	Retry    RPCRetryStats     `json:"retry"`
+}
+
+// Health reports every endpoint's breaker and the pool's overall state
+func (p *RPCPool) Health() RPCHealth {
+	health := RPCHealth{State: breakerOpen, Breakers: make([]RPCBreakerState, 0, len(p.endpoints)), Retry: p.RetryStats()}
+	for _, ep := range p.endpoints {
+		ep.mu.Lock()
+		breaker := RPCBreakerState{URL: ep.url, State: ep.state, ConsecutiveFailures: ep.consecutiveFailures}
+		if ep.state == breakerOpen {
+			breaker.RetryAt = ep.openedAt.Add(p.cooldown).Unix()
+		}
+		ep.mu.Unlock()
+
+		switch {
+		case breaker.State == breakerClosed:
+			health.State = breakerClosed
+		case breaker.State == breakerHalfOpen && health.State == breakerOpen:
+			health.State = breakerHalfOpen
+		}
+		health.Breakers = append(health.Breakers, breaker)
+	}
+	return health
+}
+
+// RPCEndpointsResponse is the body of /api/v1/rpc/endpoints
+type RPCEndpointsResponse struct {
+	Strategy         string              `json:"strategy"`
+	FailureThreshold int                 `json:"failure_threshold"`
+	Cooldown         string              `json:"cooldown"`
+	Retry            RPCRetryStats       `json:"retry"`
+	Endpoints        []RPCEndpointStatus `json:"endpoints"`
+}
+
+// handleRPCEndpoints reports the execution RPC endpoints, their breakers
+// and latency
+func handleRPCEndpoints(c *gin.Context) {
+	p := GetMonadClient().rpc
+	c.JSON(http.StatusOK, RPCEndpointsResponse{
+		Strategy:         p.strategy,
+		FailureThreshold: p.failureThreshold,
+		Cooldown:         p.cooldown.String(),
+		Retry:            p.RetryStats(),
+		Endpoints:        p.Statuses(),
+	})
+}
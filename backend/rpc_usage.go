@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RPCUsage counts the dashboard's own JSON-RPC calls per method, so
+// operators can see what load the dashboard puts on their node. One call
+// is one logical request: pool retries and failover are included in its
+// latency, and JSON-RPC errors count as errors alongside transport ones.
+// Health checks of the endpoint pool and WebSocket subscriptions are not
+// counted.
+type RPCUsage struct {
+	clock Clock
+	since time.Time
+
+	mu      sync.Mutex
+	methods map[string]*rpcMethodStats
+}
+
+// rpcMethodStats accumulates one method's calls
+type rpcMethodStats struct {
+	calls       int64
+	errors      int64
+	lastError   string
+	lastCalled  time.Time
+	lastLatency float64
+	latencies   []float64 // Milliseconds, ring of rpcLatencySamples
+	latencyNext int
+	perSecond   [60]int64 // Calls per second over the last minute, by Unix second
+	secondOf    [60]int64 // Unix second each perSecond slot counts
+}
+
+// NewRPCUsage creates empty usage stats timed by clock
+func NewRPCUsage(clock Clock) *RPCUsage {
+	return &RPCUsage{clock: clock, since: clock.Now(), methods: make(map[string]*rpcMethodStats)}
+}
+
+// Record counts one call of method that took latency and failed with err
+// (transport) or returned a JSON-RPC error in resp
+func (u *RPCUsage) Record(method string, latency time.Duration, resp []byte, err error) {
+	if err == nil && bytes.Contains(resp, []byte(`"error"`)) {
+		var envelope struct {
+			Error *rpcError `json:"error"`
+		}
+		if json.Unmarshal(resp, &envelope) == nil && envelope.Error != nil {
+			err = envelope.Error
+		}
+	}
+
+	now := u.clock.Now()
+	ms := float64(latency.Microseconds()) / 1000
+	second := now.Unix()
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	stats, ok := u.methods[method]
+	if !ok {
+		stats = &rpcMethodStats{}
+		u.methods[method] = stats
+	}
+	stats.calls++
+	stats.lastCalled = now
+	stats.lastLatency = ms
+	if err != nil {
+		stats.errors++
+		stats.lastError = err.Error()
+	}
+	if len(stats.latencies) < rpcLatencySamples {
+		stats.latencies = append(stats.latencies, ms)
+	} else {
+		stats.latencies[stats.latencyNext] = ms
+		stats.latencyNext = (stats.latencyNext + 1) % rpcLatencySamples
+	}
+	slot := second % 60
+	if stats.secondOf[slot] != second {
+		stats.secondOf[slot], stats.perSecond[slot] = second, 0
+	}
+	stats.perSecond[slot]++
+}
+
+// RPCMethodUsage is one method's usage as reported by /api/v1/rpc-usage
+type RPCMethodUsage struct {
+	Method          string     `json:"method"`
+	Calls           int64      `json:"calls"`
+	Errors          int64      `json:"errors"`
+	ErrorRate       float64    `json:"error_rate"`
+	CallsLastMinute int64      `json:"calls_last_minute"`
+	Share           float64    `json:"share"`   // Of all calls since start
+	Latency         RPCLatency `json:"latency"` // Over the last 200 calls
+	LastError       string     `json:"last_error,omitempty"`
+	LastCalled      int64      `json:"last_called"`
+}
+
+// RPCUsageResponse is the body of /api/v1/rpc-usage
+type RPCUsageResponse struct {
+	Chain           string           `json:"chain"`
+	Since           int64            `json:"since"`
+	Calls           int64            `json:"calls"`
+	Errors          int64            `json:"errors"`
+	CallsPerSecond  float64          `json:"calls_per_second"` // Average since start
+	CallsLastMinute int64            `json:"calls_last_minute"`
+	Methods         []RPCMethodUsage `json:"methods"` // Most called first
+}
+
+// Report summarizes usage per method
+func (u *RPCUsage) Report(chain string) RPCUsageResponse {
+	now := u.clock.Now()
+	response := RPCUsageResponse{Chain: chain, Since: u.since.Unix(), Methods: make([]RPCMethodUsage, 0)}
+
+	u.mu.Lock()
+	for method, stats := range u.methods {
+		usage := RPCMethodUsage{
+			Method:     method,
+			Calls:      stats.calls,
+			Errors:     stats.errors,
+			LastError:  stats.lastError,
+			LastCalled: stats.lastCalled.Unix(),
+		}
+		usage.ErrorRate = float64(stats.errors) / float64(stats.calls)
+		for slot, second := range stats.secondOf {
+			if now.Unix()-second < 60 {
+				usage.CallsLastMinute += stats.perSecond[slot]
+			}
+		}
+		usage.Latency.Last = stats.lastLatency
+		if latencies := append([]float64(nil), stats.latencies...); len(latencies) > 0 {
+			n := len(latencies)
+			sort.Float64s(latencies)
+			sum := 0.0
+			for _, ms := range latencies {
+				sum += ms
+			}
+			usage.Latency.Samples = n
+			usage.Latency.Avg = sum / float64(n)
+			usage.Latency.P50 = latencies[n*50/100]
+			usage.Latency.P95 = latencies[n*95/100]
+			usage.Latency.Max = latencies[n-1]
+		}
+		response.Calls += usage.Calls
+		response.Errors += usage.Errors
+		response.CallsLastMinute += usage.CallsLastMinute
+		response.Methods = append(response.Methods, usage)
+	}
+	u.mu.Unlock()
+
+	for i := range response.Methods {
+		response.Methods[i].Share = float64(response.Methods[i].Calls) / float64(response.Calls)
+	}
+	sort.Slice(response.Methods, func(i, j int) bool {
+		if response.Methods[i].Calls != response.Methods[j].Calls {
+			return response.Methods[i].Calls > response.Methods[j].Calls
+		}
+		return response.Methods[i].Method < response.Methods[j].Method
+	})
+	if elapsed := now.Sub(u.since).Seconds(); elapsed > 0 {
+		response.CallsPerSecond = float64(response.Calls) / elapsed
+	}
+	return response
+}
+
+// handleRPCUsage reports the primary chain client's calls per method
+func handleRPCUsage(c *gin.Context) {
+	client := GetMonadClient()
+	if client == nil {
+		c.JSON(http.StatusServiceUnavailable, APIError{Error: "Monad client not initialized"})
+		return
+	}
+	c.JSON(http.StatusOK, client.usage.Report(primaryChainName()))
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRPCUsageCallsLastMinute(t *testing.T) {
+	clock := NewFakeClock(fakeClockStart)
+	usage := NewRPCUsage(clock)
+
+	for i := 0; i < 3; i++ {
+		usage.Record("eth_blockNumber", 10*time.Millisecond, []byte(`{"result":"0x1"}`), nil)
+	}
+	clock.Advance(30 * time.Second)
+	usage.Record("eth_blockNumber", 10*time.Millisecond, []byte(`{"result":"0x2"}`), nil)
+	usage.Record("eth_getBlockByNumber", 20*time.Millisecond, []byte(`{"error":{"code":-32000,"message":"boom"}}`), nil)
+
+	report := usage.Report("test")
+	if report.Calls != 5 || report.CallsLastMinute != 5 || report.Errors != 1 {
+		t.Fatalf("after 30s: calls=%d last_minute=%d errors=%d, want 5/5/1", report.Calls, report.CallsLastMinute, report.Errors)
+	}
+	if report.CallsPerSecond != 5.0/30 {
+		t.Fatalf("calls_per_second = %v, want %v", report.CallsPerSecond, 5.0/30)
+	}
+
+	// The first three calls leave the one-minute window
+	clock.Advance(31 * time.Second)
+	report = usage.Report("test")
+	if report.Calls != 5 || report.CallsLastMinute != 2 {
+		t.Fatalf("after 61s: calls=%d last_minute=%d, want 5/2", report.Calls, report.CallsLastMinute)
+	}
+
+	clock.Advance(time.Minute)
+	if report = usage.Report("test"); report.CallsLastMinute != 0 {
+		t.Fatalf("after 121s: last_minute=%d, want 0", report.CallsLastMinute)
+	}
+}
+
+func TestRPCUsageReusesSecondSlots(t *testing.T) {
+	clock := NewFakeClock(fakeClockStart)
+	usage := NewRPCUsage(clock)
+
+	usage.Record("eth_chainId", time.Millisecond, nil, nil)
+	// Exactly one minute later the same per-second slot is reused
+	clock.Advance(time.Minute)
+	usage.Record("eth_chainId", time.Millisecond, nil, nil)
+
+	if report := usage.Report("test"); report.CallsLastMinute != 1 {
+		t.Fatalf("last_minute=%d, want 1", report.CallsLastMinute)
+	}
+}
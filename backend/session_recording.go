@@ -0,0 +1,597 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// recordFlag records the outbound stream from startup. RECORD_SESSION=true enables it too.
+var recordFlag = flag.Bool("record", os.Getenv("RECORD_SESSION") == "true",
+	"record the outbound stream to RECORDINGS_DIR from startup")
+
+// Recordings are two files in RECORDINGS_DIR: <id>.json holds the
+// SessionRecording and <id>.jsonl one recordedMessage per line.
+
+// SessionRecording describes a recorded stream session
+type SessionRecording struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name,omitempty"`
+	Topics     []string   `json:"topics,omitempty"` // Empty records every topic
+	StartedAt  time.Time  `json:"started_at"`
+	StoppedAt  *time.Time `json:"stopped_at"` // Null while recording
+	Recording  bool       `json:"recording"`
+	Messages   int64      `json:"messages"`
+	DurationMs int64      `json:"duration_ms"`
+	Bytes      int64      `json:"bytes"`
+}
+
+// recordedMessage is one outbound message with its offset from the start
+type recordedMessage struct {
+	OffsetMs int64           `json:"t"`
+	Topic    string          `json:"topic"`
+	Key      string          `json:"key"`
+	Msg      json.RawMessage `json:"msg"`
+}
+
+var errRecordingStopped = errors.New("recording stopped")
+
+// sessionRecorder receives the same messages as a stream client and appends
+// them to disk
+type sessionRecorder struct {
+	meta   SessionRecording
+	topics map[string]bool
+
+	mu     sync.Mutex
+	file   *os.File
+	w      *bufio.Writer
+	closed bool
+	stop   chan struct{}
+}
+
+// send records one message; it fails once the recording stops so the
+// update loop feeding it exits
+func (r *sessionRecorder) send(msg interface{}) error {
+	msg = withChain(unwrapMessage(msg))
+	topic, key, _, id, ok := messageParts(msg)
+	if !ok || id != nil {
+		return nil // Ping replies and query answers belong to a client, not the stream
+	}
+	if len(r.topics) > 0 && !r.topics[topic] {
+		return nil
+	}
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return errRecordingStopped
+	}
+	line, _ := json.Marshal(recordedMessage{
+		OffsetMs: time.Since(r.meta.StartedAt).Milliseconds(),
+		Topic:    topic,
+		Key:      key,
+		Msg:      data,
+	})
+	n, err := r.w.Write(append(line, '\n'))
+	if err != nil {
+		log.Printf("Session recording %s: %v", r.meta.ID, err)
+		return err
+	}
+	r.meta.Messages++
+	r.meta.Bytes += int64(n)
+	return nil
+}
+
+// snapshot returns the recording's metadata
+func (r *sessionRecorder) snapshot() SessionRecording {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	meta := r.meta
+	if meta.Recording {
+		meta.DurationMs = time.Since(meta.StartedAt).Milliseconds()
+	}
+	return meta
+}
+
+// SessionRecorder manages stream recordings; one records at a time
+type SessionRecorder struct {
+	dir         string
+	maxDuration time.Duration
+
+	mu     sync.Mutex
+	active *sessionRecorder
+}
+
+// Global session recorder instance
+var (
+	sessionRecorderInstance *SessionRecorder
+	sessionRecorderMu       sync.RWMutex
+)
+
+// InitializeSessionRecordings sets up recording to RECORDINGS_DIR (default
+// recordings), stopping each one after RECORDING_MAX_DURATION (default 1h,
+// 0 for no limit), and starts recording when --record is set
+func InitializeSessionRecordings() *SessionRecorder {
+	recorder := &SessionRecorder{dir: "recordings", maxDuration: time.Hour}
+	if dir := os.Getenv("RECORDINGS_DIR"); dir != "" {
+		recorder.dir = dir
+	}
+	if value := os.Getenv("RECORDING_MAX_DURATION"); value != "" {
+		if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+			recorder.maxDuration = d
+		} else {
+			log.Printf("Invalid RECORDING_MAX_DURATION %q, using %s", value, recorder.maxDuration)
+		}
+	}
+
+	sessionRecorderMu.Lock()
+	sessionRecorderInstance = recorder
+	sessionRecorderMu.Unlock()
+
+	if *recordFlag {
+		if meta, err := recorder.Start("startup", nil, 0); err != nil {
+			log.Printf("Failed to start session recording: %v", err)
+		} else {
+			log.Printf("Recording the stream to %s", recorder.dataPath(meta.ID))
+		}
+	}
+	return recorder
+}
+
+// GetSessionRecorder returns the global session recorder
+func GetSessionRecorder() *SessionRecorder {
+	sessionRecorderMu.RLock()
+	defer sessionRecorderMu.RUnlock()
+	return sessionRecorderInstance
+}
+
+func (s *SessionRecorder) dataPath(id string) string {
+	return filepath.Join(s.dir, id+".jsonl")
+}
+
+func (s *SessionRecorder) metaPath(id string) string {
+	return filepath.Join(s.dir, id+".json")
+}
+
+// Start begins recording the stream, optionally only some topics, for at
+// most duration (0 uses RECORDING_MAX_DURATION)
+func (s *SessionRecorder) Start(name string, topics []string, duration time.Duration) (SessionRecording, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.active != nil {
+		return SessionRecording{}, fmt.Errorf("recording %s is already running", s.active.meta.ID)
+	}
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return SessionRecording{}, err
+	}
+
+	now := time.Now()
+	id := now.UTC().Format("20060102-150405") + "-" + randomID(3)
+	file, err := os.OpenFile(s.dataPath(id), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return SessionRecording{}, err
+	}
+
+	r := &sessionRecorder{
+		meta:   SessionRecording{ID: id, Name: name, Topics: topics, StartedAt: now, Recording: true},
+		topics: make(map[string]bool),
+		file:   file,
+		w:      bufio.NewWriterSize(file, 64*1024),
+		stop:   make(chan struct{}),
+	}
+	for _, topic := range topics {
+		r.topics[topic] = true
+	}
+	if err := s.writeMeta(r.meta); err != nil {
+		file.Close()
+		os.Remove(s.dataPath(id))
+		return SessionRecording{}, err
+	}
+	s.active = r
+
+	if duration <= 0 || (s.maxDuration > 0 && duration > s.maxDuration) {
+		duration = s.maxDuration
+	}
+	go s.run(r, duration)
+	log.Printf("Session recording %s started", id)
+	return r.meta, nil
+}
+
+// run feeds the recorder like a stream client: initial messages, then the
+// update loop, with broadcasts added by recordBroadcast. It flushes to disk
+// every second and stops after duration.
+func (s *SessionRecorder) run(r *sessionRecorder, duration time.Duration) {
+	if isViewerReplica() {
+		replayBusCache(r.send)
+	} else if sendInitialSummaryMessages(r.send) == nil && sendPeersMessage(r.send) == nil && sendEpochMessage(r.send) == nil {
+		go sendFiredancerUpdates(r.send)
+	}
+
+	var limit <-chan time.Time
+	if duration > 0 {
+		limit = time.After(duration)
+	}
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			r.mu.Lock()
+			r.w.Flush()
+			r.mu.Unlock()
+		case <-limit:
+			log.Printf("Session recording %s reached its %s limit", r.meta.ID, duration)
+			s.Stop()
+			return
+		case <-r.stop:
+			return
+		}
+	}
+}
+
+// Stop ends the active recording and returns it, false when none is running
+func (s *SessionRecorder) Stop() (SessionRecording, bool) {
+	s.mu.Lock()
+	r := s.active
+	s.active = nil
+	s.mu.Unlock()
+	if r == nil {
+		return SessionRecording{}, false
+	}
+
+	r.mu.Lock()
+	r.closed = true
+	now := time.Now()
+	r.meta.StoppedAt = &now
+	r.meta.Recording = false
+	r.meta.DurationMs = now.Sub(r.meta.StartedAt).Milliseconds()
+	if err := r.w.Flush(); err != nil {
+		log.Printf("Session recording %s: %v", r.meta.ID, err)
+	}
+	r.file.Close()
+	meta := r.meta
+	r.mu.Unlock()
+	close(r.stop)
+
+	if err := s.writeMeta(meta); err != nil {
+		log.Printf("Failed to save recording %s: %v", meta.ID, err)
+	}
+	log.Printf("Session recording %s stopped: %d messages over %s", meta.ID, meta.Messages,
+		time.Duration(meta.DurationMs)*time.Millisecond)
+	return meta, true
+}
+
+// recordBroadcast adds a broadcast message to the active recording
+func recordBroadcast(msg interface{}) {
+	s := GetSessionRecorder()
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	r := s.active
+	s.mu.Unlock()
+	if r != nil {
+		r.send(msg)
+	}
+}
+
+// writeMeta saves a recording's metadata next to its messages
+func (s *SessionRecorder) writeMeta(meta SessionRecording) error {
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := s.metaPath(meta.ID) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.metaPath(meta.ID))
+}
+
+// Get returns a recording by ID, live stats for the active one
+func (s *SessionRecorder) Get(id string) (SessionRecording, bool) {
+	s.mu.Lock()
+	active := s.active
+	s.mu.Unlock()
+	if active != nil && active.meta.ID == id {
+		return active.snapshot(), true
+	}
+
+	if strings.ContainsAny(id, `/\.`) {
+		return SessionRecording{}, false
+	}
+	data, err := os.ReadFile(s.metaPath(id))
+	if err != nil {
+		return SessionRecording{}, false
+	}
+	var meta SessionRecording
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return SessionRecording{}, false
+	}
+	// Interrupted by a restart: the metadata still says recording
+	if meta.Recording {
+		meta.Recording = false
+		if info, err := os.Stat(s.dataPath(id)); err == nil {
+			meta.Bytes = info.Size()
+		}
+	}
+	return meta, true
+}
+
+// List returns every recording, newest first
+func (s *SessionRecorder) List() []SessionRecording {
+	recordings := []SessionRecording{}
+	matches, _ := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	for _, path := range matches {
+		if meta, ok := s.Get(strings.TrimSuffix(filepath.Base(path), ".json")); ok {
+			recordings = append(recordings, meta)
+		}
+	}
+	sort.Slice(recordings, func(i, j int) bool {
+		return recordings[i].StartedAt.After(recordings[j].StartedAt)
+	})
+	return recordings
+}
+
+// Delete removes a stopped recording
+func (s *SessionRecorder) Delete(id string) error {
+	meta, ok := s.Get(id)
+	if !ok {
+		return os.ErrNotExist
+	}
+	if meta.Recording {
+		return fmt.Errorf("recording %s is still running", id)
+	}
+	os.Remove(s.dataPath(id))
+	return os.Remove(s.metaPath(id))
+}
+
+// RecordingsResponse is the body of /api/v1/recordings
+type RecordingsResponse struct {
+	Recordings  []SessionRecording `json:"recordings"`
+	Active      *SessionRecording  `json:"active"` // Null when not recording
+	Dir         string             `json:"dir"`
+	MaxDuration string             `json:"max_duration"`
+}
+
+// RecordingStartRequest is the body of POST /api/v1/recordings
+type RecordingStartRequest struct {
+	Name     string   `json:"name"`
+	Topics   []string `json:"topics"`   // Empty records every topic
+	Duration string   `json:"duration"` // Go duration, capped by RECORDING_MAX_DURATION
+}
+
+// RecordingRemoveResponse is the body of DELETE /api/v1/recordings/:id
+type RecordingRemoveResponse struct {
+	Removed string `json:"removed"`
+}
+
+// handleRecordingsList lists recorded sessions
+func handleRecordingsList(c *gin.Context) {
+	s := GetSessionRecorder()
+	if s == nil {
+		c.JSON(http.StatusServiceUnavailable, APIError{Error: "Session recording not initialized"})
+		return
+	}
+
+	result := RecordingsResponse{Recordings: s.List(), Dir: s.dir, MaxDuration: s.maxDuration.String()}
+	for i := range result.Recordings {
+		if result.Recordings[i].Recording {
+			result.Active = &result.Recordings[i]
+		}
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// handleRecordingStart starts recording the outbound stream
+func handleRecordingStart(c *gin.Context) {
+	s := GetSessionRecorder()
+	if s == nil {
+		c.JSON(http.StatusServiceUnavailable, APIError{Error: "Session recording not initialized"})
+		return
+	}
+
+	var req RecordingStartRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, APIError{Error: "Expected {\"name\": \"...\", \"topics\": [...], \"duration\": \"10m\"}"})
+			return
+		}
+	}
+	var duration time.Duration
+	if req.Duration != "" {
+		d, err := time.ParseDuration(req.Duration)
+		if err != nil || d <= 0 {
+			c.JSON(http.StatusBadRequest, APIError{Error: "duration must be a positive Go duration such as 10m"})
+			return
+		}
+		duration = d
+	}
+
+	meta, err := s.Start(req.Name, req.Topics, duration)
+	if err != nil {
+		c.JSON(http.StatusConflict, APIError{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, meta)
+}
+
+// handleRecordingStop stops the active recording
+func handleRecordingStop(c *gin.Context) {
+	s := GetSessionRecorder()
+	if s == nil {
+		c.JSON(http.StatusServiceUnavailable, APIError{Error: "Session recording not initialized"})
+		return
+	}
+	meta, ok := s.Stop()
+	if !ok {
+		c.JSON(http.StatusNotFound, APIError{Error: "No recording in progress"})
+		return
+	}
+	c.JSON(http.StatusOK, meta)
+}
+
+// handleRecordingGet returns one recording
+func handleRecordingGet(c *gin.Context) {
+	s := GetSessionRecorder()
+	if s == nil {
+		c.JSON(http.StatusServiceUnavailable, APIError{Error: "Session recording not initialized"})
+		return
+	}
+	meta, ok := s.Get(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, APIError{Error: "Recording not found"})
+		return
+	}
+	c.JSON(http.StatusOK, meta)
+}
+
+// handleRecordingRemove deletes a stopped recording
+func handleRecordingRemove(c *gin.Context) {
+	s := GetSessionRecorder()
+	if s == nil {
+		c.JSON(http.StatusServiceUnavailable, APIError{Error: "Session recording not initialized"})
+		return
+	}
+	if err := s.Delete(c.Param("id")); errors.Is(err, os.ErrNotExist) {
+		c.JSON(http.StatusNotFound, APIError{Error: "Recording not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusConflict, APIError{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, RecordingRemoveResponse{Removed: c.Param("id")})
+}
+
+// handlePlaybackWebSocket replays a recorded session to one client with the
+// original timing (?speed= multiplier, default 1, up to 16; ?protocol=native
+// for the native envelope). The stream opens with playback.start and ends
+// with playback.end.
+func handlePlaybackWebSocket(c *gin.Context) {
+	s := GetSessionRecorder()
+	if s == nil {
+		c.JSON(http.StatusServiceUnavailable, APIError{Error: "Session recording not initialized"})
+		return
+	}
+	meta, ok := s.Get(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, APIError{Error: "Recording not found"})
+		return
+	}
+	speed := 1.0
+	if value := c.Query("speed"); value != "" {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil || parsed <= 0 || parsed > 16 {
+			c.JSON(http.StatusBadRequest, APIError{Error: "speed must be between 0 and 16"})
+			return
+		}
+		speed = parsed
+	}
+	var adapter ProtocolAdapter = firedancerAdapter{}
+	if c.Query("protocol") == "native" {
+		adapter = nativeAdapter{}
+	}
+
+	file, err := os.Open(s.dataPath(meta.ID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, APIError{Error: "Recording not found"})
+		return
+	}
+	defer file.Close()
+
+	if !authorizeWSUpgrade(c) {
+		return
+	}
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Printf("WebSocket upgrade error: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	if auth := GetWSAuth(); auth != nil {
+		if _, err := auth.Authenticate(c, conn); err != nil {
+			conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.ClosePolicyViolation, err.Error()),
+				time.Now().Add(time.Second))
+			return
+		}
+		defer auth.Release(conn)
+	}
+
+	// Reads only detect the client going away
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	write := func(msg interface{}) error {
+		formatted, ok := adapter.Format(msg)
+		if !ok {
+			return nil
+		}
+		conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+		return writeJSONFrame(conn, formatted)
+	}
+	log.Printf("Replaying recording %s at %gx to %s", meta.ID, speed, c.Request.RemoteAddr)
+	if err := write(FiredancerMessage{Topic: "playback", Key: "start", Value: meta}); err != nil {
+		return
+	}
+
+	start := time.Now()
+	replayed := int64(0)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var record recordedMessage
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			continue
+		}
+		var msg map[string]interface{}
+		if err := json.Unmarshal(record.Msg, &msg); err != nil {
+			continue
+		}
+
+		due := start.Add(time.Duration(float64(record.OffsetMs)/speed) * time.Millisecond)
+		if wait := time.Until(due); wait > 0 {
+			select {
+			case <-time.After(wait):
+			case <-done:
+				return
+			}
+		}
+		if err := write(msg); err != nil {
+			return
+		}
+		replayed++
+	}
+
+	write(FiredancerMessage{Topic: "playback", Key: "end", Value: map[string]interface{}{"id": meta.ID, "messages": replayed}})
+	conn.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseNormalClosure, "playback finished"),
+		time.Now().Add(time.Second))
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// rootCtx is cancelled on SIGINT or SIGTERM. Collectors derive their
+// network calls from it, so dials, RPC calls and socket reads stop when the
+// dashboard shuts down instead of holding it up.
+var rootCtx = context.Background()
+
+// initShutdown cancels the root context on SIGINT or SIGTERM
+func initShutdown() context.Context {
+	rootCtx, _ = signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	return rootCtx
+}
+
+// shutdownContext returns the context cancelled when the dashboard shuts down
+func shutdownContext() context.Context {
+	return rootCtx
+}
+
+// callContext bounds one network call by timeout and by shutdown
+func callContext(timeout time.Duration) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(rootCtx, timeout)
+}
+
+// dialUnix connects to a Unix socket for one request/response exchange.
+// The connection's deadline is ctx's, and it is closed once ctx is done,
+// so shutdown interrupts a blocked read.
+func dialUnix(ctx context.Context, path string) (net.Conn, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	context.AfterFunc(ctx, func() { conn.Close() })
+	return conn, nil
+}
+
+// serveHTTP serves the API until ctx is cancelled, then closes the node
+// connections and waits up to 10s for in-flight requests
+func serveHTTP(ctx context.Context, addr string, handler http.Handler) error {
+	srv := &http.Server{Addr: addr, Handler: handler}
+	errc := make(chan error, 1)
+	go func() {
+		errc <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+	log.Printf("Shutting down")
+
+	GetDashboard().Close()
+	if s := GetSessionRecorder(); s != nil {
+		s.Stop()
+	}
+	if ch := GetConsensusHistory(); ch != nil {
+		if err := ch.Flush(); err != nil {
+			log.Printf("Failed to write consensus history: %v", err)
+		}
+	}
+	if exporter := GetAnalyticsExporter(); exporter != nil {
+		exporter.Close()
+	}
+	if publisher := GetEventPublisher(); publisher != nil {
+		publisher.Close()
+	}
+
+	drain, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	err := srv.Shutdown(drain)
+	// Requests have finished (or timed out), so no preference write is lost
+	if ps := GetPreferenceStore(); ps != nil {
+		if err := ps.Flush(); err != nil {
+			log.Printf("Failed to save preferences: %v", err)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	log.Printf("Shutdown complete")
+	return nil
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SlowTransaction is one entry in the slowest-transactions list
+type SlowTransaction struct {
+	Hash        string    `json:"hash"`
+	Contract    string    `json:"contract,omitempty"`
+	BlockNumber int64     `json:"block_number,omitempty"`
+	GasUsed     uint64    `json:"gas_used"`
+	DurationNs  uint64    `json:"duration_ns"`
+	DurationMs  float64   `json:"duration_ms"`
+	Success     bool      `json:"success"`
+	ObservedAt  time.Time `json:"observed_at"`
+}
+
+// SlowTxTracker keeps a rolling top-N of the slowest executed transactions
+type SlowTxTracker struct {
+	mu       sync.Mutex
+	entries  []SlowTransaction
+	capacity int
+	window   time.Duration
+	observed uint64
+}
+
+// Global slow transaction tracker instance
+var (
+	slowTxTracker     *SlowTxTracker
+	slowTxTrackerOnce sync.Once
+)
+
+// GetSlowTxTracker returns the global slow transaction tracker
+func GetSlowTxTracker() *SlowTxTracker {
+	slowTxTrackerOnce.Do(func() {
+		slowTxTracker = &SlowTxTracker{
+			entries:  make([]SlowTransaction, 0, 100),
+			capacity: 100,
+			window:   10 * time.Minute,
+		}
+	})
+	return slowTxTracker
+}
+
+// Observe records a TransactionEnd; it is kept only if it ranks in the top N
+func (t *SlowTxTracker) Observe(hash string, success bool, gasUsed, durationNs uint64) {
+	if hash == "" || durationNs == 0 {
+		return
+	}
+
+	// Contract and block come from the lifecycle record when available
+	var contract string
+	var blockNumber int64
+	if record, ok := GetTxLifecycleCorrelator().Get(hash); ok {
+		contract = record.To
+		blockNumber = record.BlockNumber
+	}
+
+	now := time.Now()
+	entry := SlowTransaction{
+		Hash:        hash,
+		Contract:    contract,
+		BlockNumber: blockNumber,
+		GasUsed:     gasUsed,
+		DurationNs:  durationNs,
+		DurationMs:  float64(durationNs) / 1e6,
+		Success:     success,
+		ObservedAt:  now,
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.observed++
+	t.pruneLocked(now)
+
+	if len(t.entries) < t.capacity {
+		t.entries = append(t.entries, entry)
+		return
+	}
+
+	// Replace the fastest entry if this one is slower
+	fastest := 0
+	for i := range t.entries {
+		if t.entries[i].DurationNs < t.entries[fastest].DurationNs {
+			fastest = i
+		}
+	}
+	if durationNs > t.entries[fastest].DurationNs {
+		t.entries[fastest] = entry
+	}
+}
+
+// pruneLocked drops entries older than the rolling window; caller holds t.mu
+func (t *SlowTxTracker) pruneLocked(now time.Time) {
+	cutoff := now.Add(-t.window)
+	kept := t.entries[:0]
+	for _, entry := range t.entries {
+		if entry.ObservedAt.After(cutoff) {
+			kept = append(kept, entry)
+		}
+	}
+	t.entries = kept
+}
+
+// Slowest returns up to limit entries, slowest first
+func (t *SlowTxTracker) Slowest(limit int) []SlowTransaction {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.pruneLocked(time.Now())
+
+	result := make([]SlowTransaction, len(t.entries))
+	copy(result, t.entries)
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].DurationNs > result[j].DurationNs
+	})
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result
+}
+
+// SlowestTransactionsResponse is the body of /api/v1/execution/slowest
+type SlowestTransactionsResponse struct {
+	Transactions  []SlowTransaction `json:"transactions"`
+	Count         int               `json:"count"`
+	ObservedTotal uint64            `json:"observed_total"`
+	WindowSeconds int               `json:"window_seconds"`
+}
+
+// handleSlowestTransactions returns the slowest recently executed transactions
+func handleSlowestTransactions(c *gin.Context) {
+	limit := 20
+	if value := c.Query("limit"); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			limit = n
+		}
+	}
+
+	tracker := GetSlowTxTracker()
+	slowest := tracker.Slowest(limit)
+
+	tracker.mu.Lock()
+	observed := tracker.observed
+	window := tracker.window
+	tracker.mu.Unlock()
+
+	c.JSON(http.StatusOK, SlowestTransactionsResponse{
+		Transactions:  slowest,
+		Count:         len(slowest),
+		ObservedTotal: observed,
+		WindowSeconds: int(window.Seconds()),
+	})
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// sseClient is a Server-Sent Events connection receiving the same protocol
+// messages as WebSocket clients, optionally restricted to a set of topics
+type sseClient struct {
+	w       gin.ResponseWriter
+	mu      sync.Mutex
+	topics  map[string]bool // empty = all topics
+	closed  bool
+	eventID uint64
+}
+
+// SSE client registry, fanned out to by broadcastToAllClients
+var (
+	sseClients   = make(map[*sseClient]struct{})
+	sseClientsMu sync.RWMutex
+)
+
+// accepts reports whether the client subscribed to the topic
+func (c *sseClient) accepts(topic string) bool {
+	return len(c.topics) == 0 || c.topics[topic]
+}
+
+// send writes one message as an SSE event named after its topic
+// Filtered-out messages still report a closed client so update loops stop.
+func (c *sseClient) send(msg interface{}) error {
+	msg = unwrapMessage(msg)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return fmt.Errorf("sse client closed")
+	}
+
+	topic := messageTopic(msg)
+	if !c.accepts(topic) {
+		return nil
+	}
+
+	data, err := json.Marshal(withChain(msg))
+	if err != nil {
+		return err
+	}
+
+	c.eventID++
+	if _, err := fmt.Fprintf(c.w, "id: %d\nevent: %s\ndata: %s\n\n", c.eventID, topic, data); err != nil {
+		c.closed = true
+		return err
+	}
+	c.w.Flush()
+	return nil
+}
+
+// close marks the client closed so later writes fail fast
+func (c *sseClient) close() {
+	c.mu.Lock()
+	c.closed = true
+	c.mu.Unlock()
+}
+
+// broadcastToSSEClients sends a broadcast message to all SSE clients
+func broadcastToSSEClients(msg interface{}) {
+	sseClientsMu.RLock()
+	clients := make([]*sseClient, 0, len(sseClients))
+	for client := range sseClients {
+		clients = append(clients, client)
+	}
+	sseClientsMu.RUnlock()
+
+	for _, client := range clients {
+		if err := client.send(msg); err != nil {
+			log.Printf("Error broadcasting to SSE client: %v", err)
+		}
+	}
+}
+
+// parseTopicFilter parses a comma-separated topics query parameter
+func parseTopicFilter(raw string) map[string]bool {
+	topics := make(map[string]bool)
+	for _, topic := range strings.Split(raw, ",") {
+		topic = strings.TrimSpace(topic)
+		if topic != "" {
+			topics[topic] = true
+		}
+	}
+	return topics
+}
+
+// topicList formats a topic filter for logs
+func topicList(topics map[string]bool) string {
+	if len(topics) == 0 {
+		return "all"
+	}
+	names := make([]string, 0, len(topics))
+	for topic := range topics {
+		names = append(names, topic)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+// handleSSEStream streams protocol messages over Server-Sent Events.
+// Query params: topics (comma-separated, e.g. "summary,tx_flow"; default all)
+func handleSSEStream(c *gin.Context) {
+	// EventSource cannot send messages, so the token comes from ?token=
+	var expiry <-chan time.Time
+	if auth := GetWSAuth(); auth != nil {
+		claims, err := auth.Validate(c.Query("token"))
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, APIError{Error: err.Error()})
+			return
+		}
+		expiry = time.After(time.Until(claims.Expiry()))
+	}
+	streamSSE(c, parseTopicFilter(c.Query("topics")), expiry)
+}
+
+// streamSSE serves the stream to one SSE client restricted to topics (all
+// when empty) until the request ends or expiry fires
+func streamSSE(c *gin.Context, topics map[string]bool, expiry <-chan time.Time) {
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // Disable nginx response buffering
+	c.Status(http.StatusOK)
+
+	client := &sseClient{
+		w:      c.Writer,
+		topics: topics,
+	}
+
+	// Tell EventSource how long to wait before reconnecting
+	fmt.Fprintf(c.Writer, "retry: %d\n\n", (3 * time.Second).Milliseconds())
+	c.Writer.Flush()
+
+	sseClientsMu.Lock()
+	sseClients[client] = struct{}{}
+	total := len(sseClients)
+	sseClientsMu.Unlock()
+	log.Printf("SSE client connected from %s (topics: %s). Total SSE clients: %d",
+		c.Request.RemoteAddr, topicList(topics), total)
+
+	defer func() {
+		client.close()
+		sseClientsMu.Lock()
+		delete(sseClients, client)
+		sseClientsMu.Unlock()
+		log.Printf("SSE client disconnected")
+	}()
+
+	// Viewer replicas replay the collector's latest messages, then wait
+	// for bus fan-out instead of running their own update loop
+	if isViewerReplica() {
+		if err := replayBusCache(client.send); err != nil {
+			return
+		}
+		select {
+		case <-c.Request.Context().Done():
+		case <-expiry:
+		}
+		return
+	}
+
+	if err := sendInitialSummaryMessages(client.send); err != nil {
+		return
+	}
+	if err := sendPeersMessage(client.send); err != nil {
+		return
+	}
+	if err := sendEpochMessage(client.send); err != nil {
+		return
+	}
+
+	// Periodic updates stop on the first failed send; close the client when
+	// the request context ends so that happens promptly
+	go func() {
+		select {
+		case <-c.Request.Context().Done():
+		case <-expiry: // Client reconnects with a renewed token
+		}
+		client.close()
+	}()
+
+	sendFiredancerUpdates(client.send)
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// A write is treated as a conflict source for this long. Monad executes a
+// block's transactions optimistically in parallel; a later transaction that
+// touches a slot an earlier one wrote within the same block must re-execute.
+const conflictWindow = time.Second
+
+// Bounds on tracked state so a busy node cannot grow the maps forever
+const (
+	maxConflictAccounts = 10000
+	maxConflictSlots    = 20000
+)
+
+// slotWrite is the most recent write to a storage slot
+type slotWrite struct {
+	txHash string
+	at     time.Time
+}
+
+// AccountConflictStats counts state access and conflicts for one account
+type AccountConflictStats struct {
+	Address   string `json:"address"`
+	Reads     uint64 `json:"reads"`
+	Writes    uint64 `json:"writes"`
+	Conflicts uint64 `json:"conflicts"`
+	Retries   uint64 `json:"retries"` // Re-executions of txs that conflicted on this account
+}
+
+// SlotConflictStats counts conflicts on one storage key
+type SlotConflictStats struct {
+	Address   string `json:"address"`
+	Key       string `json:"key"`
+	Conflicts uint64 `json:"conflicts"`
+}
+
+// ConflictTracker derives parallel-execution conflicts from StateRead/StateWrite events
+type ConflictTracker struct {
+	mu sync.Mutex
+
+	lastWrites map[string]slotWrite // address|key -> last write
+	accounts   map[string]*AccountConflictStats
+	slots      map[string]*SlotConflictStats
+
+	txStarts    map[string]int                 // Tx hash -> TransactionStart count
+	txConflicts map[string]map[string]struct{} // Tx hash -> accounts it conflicted on
+	txSeenAt    map[string]time.Time
+
+	totalConflicts uint64
+	totalRetries   uint64
+	lastPrune      time.Time
+}
+
+// Global conflict tracker instance
+var (
+	conflictTracker     *ConflictTracker
+	conflictTrackerOnce sync.Once
+)
+
+// GetConflictTracker returns the global conflict tracker
+func GetConflictTracker() *ConflictTracker {
+	conflictTrackerOnce.Do(func() {
+		conflictTracker = &ConflictTracker{
+			lastWrites:  make(map[string]slotWrite),
+			accounts:    make(map[string]*AccountConflictStats),
+			slots:       make(map[string]*SlotConflictStats),
+			txStarts:    make(map[string]int),
+			txConflicts: make(map[string]map[string]struct{}),
+			txSeenAt:    make(map[string]time.Time),
+		}
+	})
+	return conflictTracker
+}
+
+// OnTransactionStart records an execution attempt; a repeated start of the
+// same transaction is a parallel-execution retry
+func (t *ConflictTracker) OnTransactionStart(txHash string) {
+	if txHash == "" {
+		return
+	}
+	now := time.Now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.pruneLocked(now)
+	t.txStarts[txHash]++
+	t.txSeenAt[txHash] = now
+	if t.txStarts[txHash] < 2 {
+		return
+	}
+
+	t.totalRetries++
+	GetMonadWaterfallMetrics().ExecutionParallelRetry.Add(1)
+	for address := range t.txConflicts[txHash] {
+		t.accountLocked(address).Retries++
+	}
+}
+
+// OnStateAccess records a StateRead or StateWrite by txHash
+func (t *ConflictTracker) OnStateAccess(txHash, address, key string, write bool) {
+	if address == "" {
+		return
+	}
+	address = strings.ToLower(address)
+	key = strings.ToLower(key)
+	slotID := address + "|" + key
+	now := time.Now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	account := t.accountLocked(address)
+	if write {
+		account.Writes++
+	} else {
+		account.Reads++
+	}
+
+	if last, ok := t.lastWrites[slotID]; ok && txHash != "" && last.txHash != txHash && now.Sub(last.at) < conflictWindow {
+		t.totalConflicts++
+		account.Conflicts++
+
+		slot, ok := t.slots[slotID]
+		if !ok && len(t.slots) < maxConflictSlots {
+			slot = &SlotConflictStats{Address: address, Key: key}
+			t.slots[slotID] = slot
+		}
+		if slot != nil {
+			slot.Conflicts++
+		}
+
+		conflicted, ok := t.txConflicts[txHash]
+		if !ok {
+			conflicted = make(map[string]struct{})
+			t.txConflicts[txHash] = conflicted
+			t.txSeenAt[txHash] = now
+		}
+		conflicted[address] = struct{}{}
+
+		updateWaterfallFromEvent("state_conflict", 1)
+	}
+
+	if write {
+		t.lastWrites[slotID] = slotWrite{txHash: txHash, at: now}
+	}
+}
+
+// accountLocked returns the stats for address, creating them if needed; caller holds t.mu
+func (t *ConflictTracker) accountLocked(address string) *AccountConflictStats {
+	if account, ok := t.accounts[address]; ok {
+		return account
+	}
+
+	if len(t.accounts) >= maxConflictAccounts {
+		// Evict the quietest half, keeping the hotspots
+		accounts := make([]*AccountConflictStats, 0, len(t.accounts))
+		for _, account := range t.accounts {
+			accounts = append(accounts, account)
+		}
+		sort.Slice(accounts, func(i, j int) bool {
+			return accounts[i].Conflicts < accounts[j].Conflicts
+		})
+		for _, account := range accounts[:len(accounts)/2] {
+			delete(t.accounts, account.Address)
+		}
+	}
+
+	account := &AccountConflictStats{Address: address}
+	t.accounts[address] = account
+	return account
+}
+
+// pruneLocked drops expired writes and per-tx state; caller holds t.mu
+func (t *ConflictTracker) pruneLocked(now time.Time) {
+	if now.Sub(t.lastPrune) < 10*time.Second {
+		return
+	}
+	t.lastPrune = now
+
+	for slotID, write := range t.lastWrites {
+		if now.Sub(write.at) > conflictWindow {
+			delete(t.lastWrites, slotID)
+		}
+	}
+	for txHash, seenAt := range t.txSeenAt {
+		if now.Sub(seenAt) > time.Minute {
+			delete(t.txSeenAt, txHash)
+			delete(t.txStarts, txHash)
+			delete(t.txConflicts, txHash)
+		}
+	}
+}
+
+// ConflictHotspots is the body of /api/v1/execution/conflicts
+type ConflictHotspots struct {
+	Accounts       []AccountConflictStats `json:"accounts"`
+	StorageKeys    []SlotConflictStats    `json:"storage_keys"`
+	TotalConflicts uint64                 `json:"total_conflicts"`
+	TotalRetries   uint64                 `json:"total_retries"`
+	WindowMs       int64                  `json:"window_ms"`
+}
+
+// Hotspots returns the accounts and storage keys with the most conflicts
+func (t *ConflictTracker) Hotspots(limit int) ConflictHotspots {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	accounts := make([]AccountConflictStats, 0, len(t.accounts))
+	for _, account := range t.accounts {
+		if account.Conflicts > 0 || account.Retries > 0 {
+			accounts = append(accounts, *account)
+		}
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		if accounts[i].Conflicts != accounts[j].Conflicts {
+			return accounts[i].Conflicts > accounts[j].Conflicts
+		}
+		return accounts[i].Retries > accounts[j].Retries
+	})
+	if limit > 0 && len(accounts) > limit {
+		accounts = accounts[:limit]
+	}
+
+	slots := make([]SlotConflictStats, 0, len(t.slots))
+	for _, slot := range t.slots {
+		slots = append(slots, *slot)
+	}
+	sort.Slice(slots, func(i, j int) bool {
+		return slots[i].Conflicts > slots[j].Conflicts
+	})
+	if limit > 0 && len(slots) > limit {
+		slots = slots[:limit]
+	}
+
+	return ConflictHotspots{
+		Accounts:       accounts,
+		StorageKeys:    slots,
+		TotalConflicts: t.totalConflicts,
+		TotalRetries:   t.totalRetries,
+		WindowMs:       conflictWindow.Milliseconds(),
+	}
+}
+
+// handleExecutionConflicts returns state conflict hotspots
+func handleExecutionConflicts(c *gin.Context) {
+	limit := 20
+	if value := c.Query("limit"); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			limit = n
+		}
+	}
+
+	c.JSON(http.StatusOK, GetConflictTracker().Hotspots(limit))
+}
@@ -0,0 +1,344 @@
+package main
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Dashboard state snapshots are gzipped tarballs:
+//
+//	manifest.json              snapshot metadata
+//	history/<tier>.jsonl       metric history points per retention tier
+//	history/annotations.jsonl  timeline annotations
+//	consensus/blocks.json      recent MonadBFT consensus timeline
+//	incidents.jsonl            source state transitions
+
+const snapshotFormatVersion = 1
+
+// maxSnapshotImportBytes bounds an uploaded snapshot (compressed)
+const maxSnapshotImportBytes = 1 << 30
+
+// Decompressed size limits, so a small gzip bomb cannot exhaust memory
+const (
+	maxSnapshotEntryBytes = 256 << 20 // One file of the tarball
+	maxSnapshotTotalBytes = 1 << 30   // All files together
+)
+
+// SnapshotManifest describes a state snapshot
+type SnapshotManifest struct {
+	Version   int            `json:"version"`
+	CreatedAt time.Time      `json:"created_at"`
+	Chain     string         `json:"chain,omitempty"`
+	Hostname  string         `json:"hostname,omitempty"`
+	Entries   map[string]int `json:"entries"` // File -> record count
+}
+
+// requireAdminKey guards admin endpoints (state, webhooks, notifier test) with ADMIN_KEY as
+// a bearer token; they are disabled when it is not set
+func requireAdminKey(c *gin.Context) {
+	key := os.Getenv("ADMIN_KEY")
+	if key == "" {
+		c.AbortWithStatusJSON(http.StatusForbidden, APIError{Error: "Admin endpoints disabled (ADMIN_KEY not set)"})
+		return
+	}
+	provided := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(provided), []byte(key)) != 1 {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, APIError{Error: "Invalid admin key"})
+		return
+	}
+	c.Next()
+}
+
+// handleStateExport streams the dashboard state as a tarball
+func handleStateExport(c *gin.Context) {
+	entries := make(map[string][]byte)
+	manifest := SnapshotManifest{
+		Version:   snapshotFormatVersion,
+		CreatedAt: time.Now().UTC(),
+		Chain:     primaryChainName(),
+		Entries:   make(map[string]int),
+	}
+	manifest.Hostname, _ = os.Hostname()
+
+	if store := GetHistoryStore(); store != nil {
+		for _, tier := range store.TierNames() {
+			var buf bytes.Buffer
+			enc := json.NewEncoder(&buf)
+			count := 0
+			err := store.ExportTier(tier, func(p persistedPoint) error {
+				count++
+				return enc.Encode(p)
+			})
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, APIError{Error: err.Error()})
+				return
+			}
+			name := "history/" + tier + ".jsonl"
+			entries[name] = buf.Bytes()
+			manifest.Entries[name] = count
+		}
+		annotations := store.ExportAnnotations()
+		entries["history/annotations.jsonl"] = jsonLines(len(annotations), func(i int) interface{} { return annotations[i] })
+		manifest.Entries["history/annotations.jsonl"] = len(annotations)
+	}
+
+	blocks := GetConsensusTracker().GetRecentBlocks(1 << 30)
+	entries["consensus/blocks.json"], _ = json.MarshalIndent(blocks, "", "  ")
+	manifest.Entries["consensus/blocks.json"] = len(blocks)
+
+	if it := GetIncidentTracker(); it != nil {
+		transitions := it.Transitions()
+		entries["incidents.jsonl"] = jsonLines(len(transitions), func(i int) interface{} { return transitions[i] })
+		manifest.Entries["incidents.jsonl"] = len(transitions)
+	}
+
+	entries["manifest.json"], _ = json.MarshalIndent(manifest, "", "  ")
+
+	filename := fmt.Sprintf("monad-dashboard-state-%s.tar.gz", manifest.CreatedAt.Format("20060102T150405Z"))
+	c.Header("Content-Type", "application/gzip")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Status(http.StatusOK)
+
+	gz := gzip.NewWriter(c.Writer)
+	tw := tar.NewWriter(gz)
+	// Manifest first, so importers can check the version before the rest
+	names := append([]string{"manifest.json"}, sortedKeys(manifest.Entries)...)
+	for _, name := range names {
+		data := entries[name]
+		header := &tar.Header{Name: name, Mode: 0o644, Size: int64(len(data)), ModTime: manifest.CreatedAt}
+		if err := tw.WriteHeader(header); err != nil {
+			log.Printf("State export failed: %v", err)
+			return
+		}
+		if _, err := tw.Write(data); err != nil {
+			log.Printf("State export failed: %v", err)
+			return
+		}
+	}
+	tw.Close()
+	gz.Close()
+	log.Printf("Exported dashboard state (%v)", manifest.Entries)
+}
+
+// StateImportResponse is the body of a successful state import
+type StateImportResponse struct {
+	Mode     string           `json:"mode"`
+	Manifest SnapshotManifest `json:"manifest"`
+	Imported map[string]int   `json:"imported"` // Archive file → records added
+	Warnings []string         `json:"warnings"`
+}
+
+// handleStateImport loads a tarball from handleStateExport. Query param
+// mode: merge (default) adds records this instance lacks; replace discards
+// the local history and incident log first.
+func handleStateImport(c *gin.Context) {
+	mode := c.DefaultQuery("mode", "merge")
+	if mode != "merge" && mode != "replace" {
+		c.JSON(http.StatusBadRequest, APIError{Error: "mode must be merge or replace"})
+		return
+	}
+	replace := mode == "replace"
+
+	snapshot, err := readSnapshot(http.MaxBytesReader(c.Writer, c.Request.Body, maxSnapshotImportBytes))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, APIError{Error: err.Error()})
+		return
+	}
+
+	var manifest SnapshotManifest
+	if err := json.Unmarshal(snapshot["manifest.json"], &manifest); err != nil {
+		c.JSON(http.StatusBadRequest, APIError{Error: "missing or invalid manifest.json"})
+		return
+	}
+	if manifest.Version != snapshotFormatVersion {
+		c.JSON(http.StatusBadRequest, APIError{Error: fmt.Sprintf("unsupported snapshot version %d", manifest.Version)})
+		return
+	}
+
+	// Parse every entry before touching any store, so a bad tarball is
+	// rejected without leaving the state half-replaced
+	tiers := make(map[string][]persistedPoint)
+	for name, data := range snapshot {
+		tier, ok := strings.CutPrefix(name, "history/")
+		if !ok || tier == "annotations.jsonl" || path.Ext(tier) != ".jsonl" {
+			continue
+		}
+		var points []persistedPoint
+		err := scanJSONLines(data, func(line []byte) error {
+			var p persistedPoint
+			err := json.Unmarshal(line, &p)
+			points = append(points, p)
+			return err
+		})
+		if err != nil {
+			c.JSON(http.StatusBadRequest, APIError{Error: fmt.Sprintf("%s: %v", name, err)})
+			return
+		}
+		tiers[name] = points
+	}
+	var annotations []Annotation
+	data, hasAnnotations := snapshot["history/annotations.jsonl"]
+	if hasAnnotations {
+		err := scanJSONLines(data, func(line []byte) error {
+			var a Annotation
+			err := json.Unmarshal(line, &a)
+			annotations = append(annotations, a)
+			return err
+		})
+		if err != nil {
+			c.JSON(http.StatusBadRequest, APIError{Error: fmt.Sprintf("history/annotations.jsonl: %v", err)})
+			return
+		}
+	}
+	var transitions []StateTransition
+	data, hasIncidents := snapshot["incidents.jsonl"]
+	if hasIncidents {
+		err := scanJSONLines(data, func(line []byte) error {
+			var t StateTransition
+			err := json.Unmarshal(line, &t)
+			transitions = append(transitions, t)
+			return err
+		})
+		if err != nil {
+			c.JSON(http.StatusBadRequest, APIError{Error: fmt.Sprintf("incidents.jsonl: %v", err)})
+			return
+		}
+	}
+
+	imported := make(map[string]int)
+	var warnings []string
+
+	if store := GetHistoryStore(); store != nil {
+		for name, points := range tiers {
+			tier := strings.TrimSuffix(strings.TrimPrefix(name, "history/"), ".jsonl")
+			added, err := store.ImportTier(tier, points, replace)
+			if err != nil {
+				warnings = append(warnings, err.Error())
+				continue
+			}
+			imported[name] = added
+		}
+		if hasAnnotations {
+			imported["history/annotations.jsonl"] = store.ImportAnnotations(annotations, replace)
+		}
+	}
+
+	if hasIncidents {
+		if it := GetIncidentTracker(); it != nil {
+			added, err := it.Import(transitions, replace)
+			if err != nil {
+				warnings = append(warnings, "incident log: "+err.Error())
+			}
+			imported["incidents.jsonl"] = added
+		}
+	}
+
+	if _, ok := snapshot["consensus/blocks.json"]; ok {
+		// The consensus tracker follows the live chain; an old timeline is
+		// kept in the tarball for reference but not loaded into it
+		warnings = append(warnings, "consensus/blocks.json is live state and was not imported")
+	}
+	if manifest.Chain != "" && manifest.Chain != primaryChainName() {
+		warnings = append(warnings, fmt.Sprintf("snapshot is from chain %s, this instance monitors %s", manifest.Chain, primaryChainName()))
+	}
+
+	log.Printf("Imported dashboard state from %s (%s, %s): %v", manifest.Hostname, manifest.CreatedAt.Format(time.RFC3339), mode, imported)
+	c.JSON(http.StatusOK, StateImportResponse{
+		Mode:     mode,
+		Manifest: manifest,
+		Imported: imported,
+		Warnings: warnings,
+	})
+}
+
+// readSnapshot reads every regular file of a gzipped tarball, refusing
+// files over maxSnapshotEntryBytes and tarballs over maxSnapshotTotalBytes
+// once decompressed
+func readSnapshot(r io.Reader) (map[string][]byte, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("not a gzip stream: %w", err)
+	}
+	defer gz.Close()
+
+	files := make(map[string][]byte)
+	total := 0
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return files, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid tarball: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		if header.Size > maxSnapshotEntryBytes {
+			return nil, fmt.Errorf("%s is larger than %d MiB", header.Name, maxSnapshotEntryBytes>>20)
+		}
+		data, err := io.ReadAll(io.LimitReader(tr, maxSnapshotEntryBytes+1))
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", header.Name, err)
+		}
+		if len(data) > maxSnapshotEntryBytes {
+			return nil, fmt.Errorf("%s is larger than %d MiB", header.Name, maxSnapshotEntryBytes>>20)
+		}
+		if total += len(data); total > maxSnapshotTotalBytes {
+			return nil, fmt.Errorf("snapshot is larger than %d MiB decompressed", maxSnapshotTotalBytes>>20)
+		}
+		files[path.Clean(header.Name)] = data
+	}
+}
+
+// jsonLines encodes count values, one JSON line each
+func jsonLines(count int, value func(i int) interface{}) []byte {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for i := 0; i < count; i++ {
+		enc.Encode(value(i))
+	}
+	return buf.Bytes()
+}
+
+// scanJSONLines calls fn with every non-empty line, stopping at the first error
+func scanJSONLines(data []byte, fn func(line []byte) error) error {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		if err := fn(scanner.Bytes()); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+	return scanner.Err()
+}
+
+// sortedKeys returns the keys of m in order
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"hash/fnv"
+	"log"
+	"os"
+	"regexp"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Many update-loop messages (slots while idle, waterfalls, tiles, consensus
+// state) are identical tick after tick. Each client's update loop remembers
+// a content hash per topic/key and skips repeats, re-sending an unchanged
+// message only as a heartbeat so clients still converge after a lost frame.
+
+var (
+	// dedupHeartbeat is how often unchanged messages are re-sent; 0 disables deduplication
+	dedupHeartbeat = 10 * time.Second
+
+	dedupSent    atomic.Int64
+	dedupSkipped atomic.Int64
+)
+
+// InitializeStreamDedup reads STREAM_DEDUP_HEARTBEAT (default 10s, "0" disables)
+func InitializeStreamDedup() {
+	if value := os.Getenv("STREAM_DEDUP_HEARTBEAT"); value != "" {
+		if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+			dedupHeartbeat = d
+		} else {
+			log.Printf("Invalid STREAM_DEDUP_HEARTBEAT %q, using %s", value, dedupHeartbeat)
+		}
+	}
+	if dedupHeartbeat > 0 {
+		log.Printf("Stream deduplication enabled (heartbeat %s)", dedupHeartbeat)
+	}
+}
+
+type dedupEntry struct {
+	hash   uint64
+	sentAt time.Time
+}
+
+// dedupSender wraps send so unchanged messages are skipped until the
+// heartbeat is due. Messages with an ID (pings, query replies) always go out.
+func dedupSender(send messageSender) messageSender {
+	if dedupHeartbeat <= 0 {
+		return send
+	}
+
+	last := make(map[string]dedupEntry)
+	return func(msg interface{}) error {
+		topic, key, _, id, ok := messageParts(unwrapMessage(msg))
+		if !ok || id != nil {
+			return send(msg)
+		}
+		hash, ok := messageHash(msg)
+		if !ok {
+			return send(msg)
+		}
+
+		slot := topic + "/" + key
+		now := time.Now()
+		if prev, seen := last[slot]; seen && prev.hash == hash && now.Sub(prev.sentAt) < dedupHeartbeat {
+			dedupSkipped.Add(1)
+			return nil
+		}
+		if err := send(msg); err != nil {
+			return err
+		}
+		last[slot] = dedupEntry{hash: hash, sentAt: now}
+		dedupSent.Add(1)
+		return nil
+	}
+}
+
+// messageHash returns a content hash of msg; shared messages are hashed once
+func messageHash(msg interface{}) (uint64, bool) {
+	if shared, ok := msg.(*SharedMessage); ok {
+		shared.hashOnce.Do(func() {
+			shared.hash, shared.hashOK = hashJSON(shared.Msg)
+		})
+		return shared.hash, shared.hashOK
+	}
+	return hashJSON(msg)
+}
+
+// volatileFields match values that change every tick without the data
+// changing (provenance freshness); they are left out of content hashes
+var volatileFields = regexp.MustCompile(`"freshness_seconds":[-+.eE0-9]+`)
+
+// hashJSON hashes the JSON encoding of v, ignoring volatile fields
+func hashJSON(v interface{}) (uint64, bool) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return 0, false
+	}
+	h := fnv.New64a()
+	h.Write(volatileFields.ReplaceAll(data, nil))
+	return h.Sum64(), true
+}
+
+// DedupStats counts update-loop messages sent and skipped as unchanged
+type DedupStats struct {
+	Enabled     bool    `json:"enabled"`
+	HeartbeatMs int64   `json:"heartbeat_ms"`
+	Sent        int64   `json:"sent"`
+	Skipped     int64   `json:"skipped"`
+	SkipRatio   float64 `json:"skip_ratio"`
+}
+
+// dedupStats reports how many update-loop messages were sent and skipped
+func dedupStats() DedupStats {
+	sent, skipped := dedupSent.Load(), dedupSkipped.Load()
+	ratio := 0.0
+	if total := sent + skipped; total > 0 {
+		ratio = float64(skipped) / float64(total)
+	}
+	return DedupStats{
+		Enabled:     dedupHeartbeat > 0,
+		HeartbeatMs: dedupHeartbeat.Milliseconds(),
+		Sent:        sent,
+		Skipped:     skipped,
+		SkipRatio:   ratio,
+	}
+}
+
+// sharedHash is embedded in SharedMessage to cache its content hash
+type sharedHash struct {
+	hashOnce sync.Once
+	hash     uint64
+	hashOK   bool
+}
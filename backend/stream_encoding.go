@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// encodeBuffers pools the buffers used to encode outbound messages
+var encodeBuffers = sync.Pool{
+	New: func() interface{} { return new(bytes.Buffer) },
+}
+
+// writeJSONFrame encodes v with a pooled buffer and writes it as one text
+// frame (same bytes as conn.WriteJSON, without its per-call encoder)
+func writeJSONFrame(conn *websocket.Conn, v interface{}) error {
+	_, _, err := writeJSONFrameMeasured(conn, v)
+	return err
+}
+
+// writeJSONFrameMeasured is writeJSONFrame, also returning the frame size
+// and the time spent encoding it
+func writeJSONFrameMeasured(conn *websocket.Conn, v interface{}) (int, time.Duration, error) {
+	buf := encodeBuffers.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer encodeBuffers.Put(buf)
+
+	start := time.Now()
+	if err := json.NewEncoder(buf).Encode(v); err != nil {
+		return 0, 0, err
+	}
+	took := time.Since(start)
+	return buf.Len(), took, conn.WriteMessage(websocket.TextMessage, buf.Bytes())
+}
+
+// SharedMessage is an outbound message sent unchanged to many clients. It is
+// formatted and encoded once per protocol adapter, and the resulting frame
+// is written to every client using that adapter.
+type SharedMessage struct {
+	Msg interface{}
+	sharedHash
+
+	mu     sync.Mutex
+	frames map[string]*websocket.PreparedMessage // Adapter name -> frame; nil when the adapter drops it
+	sizes  map[string]int                        // Adapter name -> frame size, for ws-stats
+}
+
+// newSharedMessage wraps msg for encode-once delivery
+func newSharedMessage(msg interface{}) *SharedMessage {
+	return &SharedMessage{Msg: msg, frames: make(map[string]*websocket.PreparedMessage, 2), sizes: make(map[string]int, 2)}
+}
+
+// frame returns the prepared frame for an adapter and its size, encoding
+// it on first use
+func (m *SharedMessage) frame(adapter ProtocolAdapter) (*websocket.PreparedMessage, int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	name := adapter.Name()
+	if frame, ok := m.frames[name]; ok {
+		return frame, m.sizes[name], nil
+	}
+
+	start := time.Now()
+	formatted, ok := adapter.Format(m.Msg)
+	if !ok {
+		m.frames[name] = nil
+		return nil, 0, nil
+	}
+	data, err := json.Marshal(formatted)
+	if err != nil {
+		return nil, 0, err
+	}
+	frame, err := websocket.NewPreparedMessage(websocket.TextMessage, append(data, '\n'))
+	if err != nil {
+		return nil, 0, err
+	}
+	recordWSEncode(m.Msg, time.Since(start))
+	m.frames[name] = frame
+	m.sizes[name] = len(data) + 1
+	return frame, len(data) + 1, nil
+}
+
+// unwrapMessage returns the message inside a SharedMessage
+func unwrapMessage(msg interface{}) interface{} {
+	if shared, ok := msg.(*SharedMessage); ok {
+		return shared.Msg
+	}
+	return msg
+}
+
+// sharedTickMessages memoizes messages that every client's update loop sends
+// with the same content, so concurrent loops build and encode them once per
+// update tick instead of once per client
+var sharedTickMessages = struct {
+	sync.Mutex
+	entries map[string]sharedTickEntry
+}{entries: make(map[string]sharedTickEntry)}
+
+type sharedTickEntry struct {
+	at  time.Time
+	msg *SharedMessage
+}
+
+// sharedTickMessage returns the message built for key during the current
+// tick, building it when the last one is older than half the update cadence
+func sharedTickMessage(key string, build func() interface{}) *SharedMessage {
+	maxAge := updateCadence.Base / 2
+
+	sharedTickMessages.Lock()
+	defer sharedTickMessages.Unlock()
+
+	if entry, ok := sharedTickMessages.entries[key]; ok && time.Since(entry.at) < maxAge {
+		return entry.msg
+	}
+	msg := newSharedMessage(build())
+	sharedTickMessages.entries[key] = sharedTickEntry{at: time.Now(), msg: msg}
+	return msg
+}
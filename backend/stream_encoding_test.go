@@ -0,0 +1,90 @@
+package main
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+// benchmarkClients connects n WebSocket clients that discard what they
+// read, registering the server side of each as a Firedancer client
+func benchmarkClients(b *testing.B, n int) {
+	b.Helper()
+	output := log.Writer()
+	log.SetOutput(io.Discard)
+	b.Cleanup(func() { log.SetOutput(output) })
+
+	upgrader := websocket.Upgrader{}
+	registered := make(chan *websocket.Conn, n)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		registerWSClient(conn, firedancerAdapter{}, nil)
+		registered <- conn
+	}))
+	b.Cleanup(server.Close)
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+	for i := 0; i < n; i++ {
+		conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+		if err != nil {
+			b.Fatal(err)
+		}
+		go func() {
+			for {
+				if _, _, err := conn.NextReader(); err != nil {
+					return
+				}
+			}
+		}()
+		b.Cleanup(func() { conn.Close() })
+	}
+	for i := 0; i < n; i++ {
+		conn := <-registered
+		b.Cleanup(func() { unregisterWSClient(conn) })
+	}
+}
+
+// BenchmarkBroadcastWaterfall broadcasts a monad_waterfall_v2 message to
+// 100 WebSocket clients, encoded once for all of them ("shared") or once
+// per client as before SharedMessage ("per_client")
+func BenchmarkBroadcastWaterfall(b *testing.B) {
+	benchmarkClients(b, 100)
+	msg := FiredancerMessage{
+		Topic: "summary",
+		Key:   "monad_waterfall_v2",
+		Value: GenerateMonadWaterfallFor(waterfallVersionStream),
+	}
+
+	b.Run("shared", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			fanOutToLocalClients(msg)
+		}
+	})
+
+	b.Run("per_client", func(b *testing.B) {
+		wsClientsMu.RLock()
+		clients := make([]*wsClient, 0, len(wsClients))
+		for _, client := range wsClients {
+			clients = append(clients, client)
+		}
+		wsClientsMu.RUnlock()
+
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for _, client := range clients {
+				if err := client.write(msg); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+}
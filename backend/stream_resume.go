@@ -0,0 +1,185 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Resume tokens let a reconnecting client pick up where it left off. Every
+// stream connection is given a token ({"topic":"stream","key":"resume_token"});
+// when it disconnects, the last seq written to it and its tx_flow settings
+// are kept under the token for STREAM_RESUME_TTL (default 2m). Connecting
+// with ?resume=<token> (and optionally &last_seq=N, the last seq the client
+// actually received) replays the missed broadcasts from the replay buffer
+// instead of sending a fresh snapshot.
+
+// maxResumeSessions bounds the disconnected sessions kept
+const maxResumeSessions = 10000
+
+// resumeSession is the state of a disconnected stream client
+type resumeSession struct {
+	subject        string  // Session token subject when WS auth is enabled, prefixed by the tenant
+	tenant         *Tenant // Nil for the global stream
+	lastSeq        uint64
+	txFlow         txFlowClient
+	disconnectedAt time.Time
+}
+
+// ResumeStore keeps disconnected sessions by resume token
+type ResumeStore struct {
+	ttl time.Duration
+
+	mu       sync.Mutex
+	sessions map[string]resumeSession
+	resumed  int64
+	expired  int64
+}
+
+// Global resume store
+var (
+	resumeStore     *ResumeStore
+	resumeStoreOnce sync.Once
+)
+
+// GetResumeStore returns the global resume store, keeping sessions for
+// STREAM_RESUME_TTL
+func GetResumeStore() *ResumeStore {
+	resumeStoreOnce.Do(func() {
+		ttl := 2 * time.Minute
+		if value := os.Getenv("STREAM_RESUME_TTL"); value != "" {
+			if parsed, err := time.ParseDuration(value); err == nil && parsed > 0 {
+				ttl = parsed
+			} else {
+				log.Printf("Invalid STREAM_RESUME_TTL %q, using %s", value, ttl)
+			}
+		}
+		resumeStore = &ResumeStore{ttl: ttl, sessions: make(map[string]resumeSession)}
+	})
+	return resumeStore
+}
+
+// newResumeToken returns a random token
+func newResumeToken() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		log.Printf("Failed to generate resume token: %v", err)
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
+// Save keeps a disconnected client's session under its token
+func (rs *ResumeStore) Save(token string, session resumeSession) {
+	if token == "" {
+		return
+	}
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.pruneLocked(session.disconnectedAt)
+	if len(rs.sessions) >= maxResumeSessions {
+		var oldest string
+		for t, s := range rs.sessions {
+			if oldest == "" || s.disconnectedAt.Before(rs.sessions[oldest].disconnectedAt) {
+				oldest = t
+			}
+		}
+		delete(rs.sessions, oldest)
+	}
+	rs.sessions[token] = session
+}
+
+// Take removes and returns the session of a token, false when it is
+// unknown, expired or belongs to another subject
+func (rs *ResumeStore) Take(token, subject string, now time.Time) (resumeSession, bool) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	session, ok := rs.sessions[token]
+	if !ok {
+		return resumeSession{}, false
+	}
+	delete(rs.sessions, token)
+	if now.Sub(session.disconnectedAt) > rs.ttl {
+		rs.expired++
+		return resumeSession{}, false
+	}
+	if session.subject != subject {
+		return resumeSession{}, false
+	}
+	rs.resumed++
+	return session, true
+}
+
+// pruneLocked drops expired sessions; caller holds rs.mu
+func (rs *ResumeStore) pruneLocked(now time.Time) {
+	for token, session := range rs.sessions {
+		if now.Sub(session.disconnectedAt) > rs.ttl {
+			delete(rs.sessions, token)
+			rs.expired++
+		}
+	}
+}
+
+// ResumeStats reports resume token use for /api/v1/ws-stats
+type ResumeStats struct {
+	TTLSeconds int64 `json:"ttl_seconds"`
+	Pending    int   `json:"pending"` // Disconnected sessions waiting to be resumed
+	Resumed    int64 `json:"resumed"`
+	Expired    int64 `json:"expired"`
+}
+
+// Stats returns the store's counters
+func (rs *ResumeStore) Stats() ResumeStats {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	return ResumeStats{
+		TTLSeconds: int64(rs.ttl.Seconds()),
+		Pending:    len(rs.sessions),
+		Resumed:    rs.resumed,
+		Expired:    rs.expired,
+	}
+}
+
+// resumeStream replays the broadcasts a resuming client missed, applying
+// its restored tx_flow settings. It returns false when the client is too far
+// behind for the replay buffer and needs a snapshot instead.
+func resumeStream(send messageSender, session resumeSession, lastSeq string) bool {
+	from := session.lastSeq
+	if value, err := strconv.ParseUint(lastSeq, 10, 64); err == nil && value < from {
+		from = value
+	}
+	missed, ok := streamReplay.since(from)
+	if !ok {
+		return false
+	}
+
+	skipTxFlow := session.txFlow.filter != nil || session.txFlow.aggregate
+	replayed := 0
+	for _, msg := range missed {
+		if skipTxFlow && messageTopic(msg) == "tx_flow" {
+			continue
+		}
+		if session.tenant != nil && !session.tenant.sees(msg) {
+			continue
+		}
+		if err := send(msg); err != nil {
+			return true
+		}
+		replayed++
+	}
+	send(FiredancerMessage{
+		Topic: "stream",
+		Key:   "resume",
+		Value: map[string]interface{}{
+			"mode":        "replay",
+			"from_seq":    from,
+			"replayed":    replayed,
+			"current_seq": streamSeq.Load(),
+		},
+	})
+	return true
+}
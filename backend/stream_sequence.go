@@ -0,0 +1,212 @@
+package main
+
+import (
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Broadcast messages carry a monotonically increasing "seq" and a
+// "server_ts" (unix ms). Clients that see a gap, e.g. after reconnecting,
+// send {"topic":"stream","key":"resync","params":{"last_seq":N}} and get the
+// missed messages replayed, or a fresh snapshot if they are too far behind.
+
+// streamReplayCapacity is how many recent broadcast messages can be replayed
+const streamReplayCapacity = 2048
+
+// Global broadcast sequence and replay buffer
+var (
+	streamSeq    atomic.Uint64
+	streamReplay = &replayBuffer{entries: make([]sequencedMessage, 0, streamReplayCapacity)}
+)
+
+// sequencedMessage is a stamped broadcast message kept for replay
+type sequencedMessage struct {
+	seq uint64
+	msg interface{}
+}
+
+// replayBuffer is a ring of recent broadcast messages in sequence order
+type replayBuffer struct {
+	mu      sync.RWMutex
+	entries []sequencedMessage
+	start   int // Index of the oldest entry once the ring is full
+}
+
+// add records a stamped message
+func (b *replayBuffer) add(seq uint64, msg interface{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.entries) < streamReplayCapacity {
+		b.entries = append(b.entries, sequencedMessage{seq: seq, msg: msg})
+		return
+	}
+	b.entries[b.start] = sequencedMessage{seq: seq, msg: msg}
+	b.start = (b.start + 1) % streamReplayCapacity
+}
+
+// since returns the messages after lastSeq, or false if some were already evicted
+func (b *replayBuffer) since(lastSeq uint64) ([]interface{}, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if len(b.entries) == 0 {
+		return nil, lastSeq >= streamSeq.Load()
+	}
+	oldest := b.entries[b.start].seq
+	if lastSeq+1 < oldest {
+		return nil, false
+	}
+
+	var missed []interface{}
+	for i := 0; i < len(b.entries); i++ {
+		entry := b.entries[(b.start+i)%len(b.entries)]
+		if entry.seq > lastSeq {
+			missed = append(missed, entry.msg)
+		}
+	}
+	return missed, true
+}
+
+// stampMessage assigns the next sequence number and server timestamp
+func stampMessage(msg interface{}) interface{} {
+	seq := streamSeq.Add(1)
+	now := time.Now().UnixMilli()
+
+	var stamped interface{}
+	switch m := msg.(type) {
+	case FiredancerMessage:
+		m.Seq, m.ServerTS = seq, now
+		stamped = m
+	case *FiredancerMessage:
+		copied := *m
+		copied.Seq, copied.ServerTS = seq, now
+		stamped = copied
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(m)+2)
+		for k, v := range m {
+			copied[k] = v
+		}
+		copied["seq"] = seq
+		copied["server_ts"] = now
+		stamped = copied
+	default:
+		return msg
+	}
+
+	streamReplay.add(seq, stamped)
+	return stamped
+}
+
+// recordSequenced keeps a message stamped by another replica for replay
+func recordSequenced(msg map[string]interface{}) {
+	seq, ok := msg["seq"].(float64) // Decoded from JSON
+	if !ok {
+		return
+	}
+	// Follow the collector's numbering so resync requests line up
+	for {
+		current := streamSeq.Load()
+		if uint64(seq) <= current || streamSeq.CompareAndSwap(current, uint64(seq)) {
+			break
+		}
+	}
+	streamReplay.add(uint64(seq), msg)
+}
+
+// messageSequence returns the seq and server_ts of a stamped message
+func messageSequence(msg interface{}) (seq uint64, serverTS int64) {
+	switch m := msg.(type) {
+	case FiredancerMessage:
+		return m.Seq, m.ServerTS
+	case *FiredancerMessage:
+		return m.Seq, m.ServerTS
+	case map[string]interface{}:
+		switch v := m["seq"].(type) {
+		case uint64:
+			seq = v
+		case float64:
+			seq = uint64(v)
+		}
+		switch v := m["server_ts"].(type) {
+		case int64:
+			serverTS = v
+		case float64:
+			serverTS = int64(v)
+		}
+	}
+	return seq, serverTS
+}
+
+// sendStreamSnapshot sends a client the current state: the collector's
+// latest messages on viewer replicas, otherwise the summary, peers and
+// epoch messages
+func sendStreamSnapshot(send messageSender) error {
+	if isViewerReplica() {
+		return replayBusCache(send)
+	}
+	if err := sendInitialSummaryMessages(send); err != nil {
+		return err
+	}
+	if err := sendPeersMessage(send); err != nil {
+		return err
+	}
+	return sendEpochMessage(send)
+}
+
+// handleStreamClientMessage handles {"topic":"stream","key":"resync","params":{"last_seq":N}}
+func handleStreamClientMessage(conn *websocket.Conn, key string, params map[string]interface{}) {
+	if key != "resync" {
+		return
+	}
+
+	lastSeq := uint64(0)
+	if v, ok := params["last_seq"].(float64); ok && v > 0 {
+		lastSeq = uint64(v)
+	}
+	send := wsSender(conn)
+	tenant := tenantOfConn(conn)
+
+	if missed, ok := streamReplay.since(lastSeq); ok {
+		replayed := 0
+		for _, msg := range missed {
+			if tenant != nil && !tenant.sees(msg) {
+				continue
+			}
+			if err := send(msg); err != nil {
+				return
+			}
+			replayed++
+		}
+		send(FiredancerMessage{
+			Topic: "stream",
+			Key:   "resync",
+			Value: map[string]interface{}{
+				"mode":        "replay",
+				"replayed":    replayed,
+				"current_seq": streamSeq.Load(),
+			},
+		})
+		return
+	}
+
+	// Too far behind: send a full snapshot instead
+	log.Printf("Client resync from seq %d is outside the replay buffer, sending snapshot", lastSeq)
+	if tenant == nil || tenant.primary() {
+		if err := sendStreamSnapshot(send); err != nil {
+			return
+		}
+	}
+	send(FiredancerMessage{
+		Topic: "stream",
+		Key:   "resync",
+		Value: map[string]interface{}{
+			"mode":        "snapshot",
+			"current_seq": streamSeq.Load(),
+		},
+	})
+}
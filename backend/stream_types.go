@@ -0,0 +1,115 @@
+package main
+
+// Typed payloads for the messages every client receives on each update
+// tick, replacing nested map[string]interface{} literals on the hot path.
+
+// EstimatedTPS is the summary.estimated_tps payload
+type EstimatedTPS struct {
+	Total          float64    `json:"total"` // 1-second TPS
+	Vote           float64    `json:"vote"`
+	NonvoteSuccess float64    `json:"nonvote_success"` // Average TPS
+	NonvoteFailed  float64    `json:"nonvote_failed"`  // Instant TPS per block
+	TxCount        int        `json:"tx_count"`        // Latest block tx count
+	Provenance     Provenance `json:"provenance"`
+}
+
+// LiveTxnWaterfall is the summary.live_txn_waterfall payload (legacy
+// Firedancer waterfall)
+type LiveTxnWaterfall struct {
+	NextLeaderSlot *int64       `json:"next_leader_slot"`
+	Waterfall      TxnWaterfall `json:"waterfall"`
+}
+
+// TxnWaterfall holds the Firedancer ingress and drop counters
+type TxnWaterfall struct {
+	In  TxnWaterfallIn  `json:"in"`
+	Out TxnWaterfallOut `json:"out"`
+}
+
+// TxnWaterfallIn are Firedancer ingress counters; Monad maps RPC to quic
+// and P2P to udp
+type TxnWaterfallIn struct {
+	Quic           int64 `json:"quic"`
+	UDP            int64 `json:"udp"`
+	Gossip         int64 `json:"gossip"`
+	PackCranked    int64 `json:"pack_cranked"`
+	PackRetained   int64 `json:"pack_retained"`
+	ResolvRetained int64 `json:"resolv_retained"`
+	BlockEngine    int64 `json:"block_engine"`
+}
+
+// TxnWaterfallOut are Firedancer drop counters; stages Monad lacks stay zero
+type TxnWaterfallOut struct {
+	NetOverrun        int64 `json:"net_overrun"`
+	QuicOverrun       int64 `json:"quic_overrun"`
+	QuicFragDrop      int64 `json:"quic_frag_drop"`
+	QuicAbandoned     int64 `json:"quic_abandoned"`
+	TPUQuicInvalid    int64 `json:"tpu_quic_invalid"`
+	TPUUDPInvalid     int64 `json:"tpu_udp_invalid"`
+	VerifyOverrun     int64 `json:"verify_overrun"`
+	VerifyParse       int64 `json:"verify_parse"`
+	VerifyFailed      int64 `json:"verify_failed"`
+	VerifyDuplicate   int64 `json:"verify_duplicate"`
+	DedupDuplicate    int64 `json:"dedup_duplicate"`
+	ResolvLUTFailed   int64 `json:"resolv_lut_failed"`
+	ResolvExpired     int64 `json:"resolv_expired"`
+	ResolvNoLedger    int64 `json:"resolv_no_ledger"`
+	ResolvAncient     int64 `json:"resolv_ancient"`
+	ResolvRetained    int64 `json:"resolv_retained"`
+	PackInvalid       int64 `json:"pack_invalid"`
+	PackInvalidBundle int64 `json:"pack_invalid_bundle"`
+	PackRetained      int64 `json:"pack_retained"`
+	PackLeaderSlow    int64 `json:"pack_leader_slow"`
+	PackWaitFull      int64 `json:"pack_wait_full"`
+	PackExpired       int64 `json:"pack_expired"`
+	BankInvalid       int64 `json:"bank_invalid"`
+	BlockSuccess      int64 `json:"block_success"`
+	BlockFail         int64 `json:"block_fail"`
+}
+
+// buildLiveTxnWaterfall maps the legacy Monad waterfall onto the Firedancer stages
+func buildLiveTxnWaterfall() LiveTxnWaterfall {
+	legacy := GenerateWaterfallFromSubscriber()
+	in, _ := legacy["in"].(map[string]interface{})
+	out, _ := legacy["out"].(map[string]interface{})
+
+	var payload LiveTxnWaterfall
+	if lt := GetLeaderTracker(); lt != nil {
+		if slot, _, ok := lt.NextLeaderSlot(); ok {
+			payload.NextLeaderSlot = &slot
+		}
+	}
+
+	payload.Waterfall.In = TxnWaterfallIn{
+		Quic:   countValue(in["rpc"]),
+		UDP:    countValue(in["p2p"]),
+		Gossip: countValue(in["gossip"]),
+	}
+	payload.Waterfall.Out = TxnWaterfallOut{
+		VerifyFailed:    countValue(out["verify_failed"]),
+		VerifyDuplicate: countValue(out["nonce_failed"]),
+		DedupDuplicate:  countValue(out["nonce_failed"]),
+		ResolvLUTFailed: countValue(out["balance_failed"]),
+		ResolvExpired:   countValue(out["pool_fee_dropped"]),
+		PackWaitFull:    countValue(out["pool_full"]),
+		BankInvalid:     countValue(out["exec_failed"]),
+		BlockSuccess:    countValue(out["exec_parallel"]),
+		BlockFail:       countValue(out["exec_sequential"]),
+	}
+	return payload
+}
+
+// countValue converts a numeric waterfall value to an integer count
+func countValue(v interface{}) int64 {
+	switch n := v.(type) {
+	case int64:
+		return n
+	case int:
+		return int64(n)
+	case uint64:
+		return int64(n)
+	case float64:
+		return int64(n)
+	}
+	return 0
+}
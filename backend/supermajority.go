@@ -0,0 +1,271 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Stake-weighted participation of the consensus validator set: the share
+// of stake held by validators observed voting (last_voted_round from the
+// BFT control panel) or proposing (the leader performance window). MonadBFT
+// needs votes from 2/3 of the stake to make progress, so participation is
+// alerted on as the supermajority component of the incident log: degraded
+// within SUPERMAJORITY_ALERT_MARGIN (default 0.05) above 2/3, down below it.
+
+// supermajorityComponent names supermajority alerts in the incident log
+const supermajorityComponent = "supermajority"
+
+// supermajorityThreshold is the stake share a quorum needs
+const supermajorityThreshold = 2.0 / 3.0
+
+// Participation statuses
+const (
+	participationHealthy = "healthy"
+	participationAtRisk  = "at_risk" // Within the alert margin of the threshold
+	participationBelow   = "below"   // Under the threshold
+	participationUnknown = "unknown" // No validator set or too little evidence
+)
+
+// proposalsPerValidator is how many blocks per validator the leader window
+// must hold before proposals alone are trusted as evidence
+const proposalsPerValidator = 10
+
+// ParticipantStatus is one validator's participation
+type ParticipantStatus struct {
+	ID           uint64  `json:"id"`
+	AuthAddress  string  `json:"auth_address"`
+	StakeMON     float64 `json:"stake_mon"`
+	StakeShare   float64 `json:"stake_share"`
+	Observed     bool    `json:"observed"`
+	Evidence     string  `json:"evidence,omitempty"`      // vote or proposal
+	LastVote     *int64  `json:"last_vote,omitempty"`     // last_voted_round from the control panel
+	LastProposal *int64  `json:"last_proposal,omitempty"` // Last block proposed in the leader window
+}
+
+// SupermajorityResponse is the body of /api/v1/consensus/supermajority
+type SupermajorityResponse struct {
+	Status           string              `json:"status"` // healthy, at_risk, below or unknown
+	Reason           string              `json:"reason,omitempty"`
+	Epoch            int64               `json:"epoch"` // Of the validator set snapshot used
+	Head             int64               `json:"head"`
+	Threshold        float64             `json:"threshold"`
+	AlertMargin      float64             `json:"alert_margin"`
+	Participation    float64             `json:"participation"` // Observed stake / total stake
+	ObservedStakeMON float64             `json:"observed_stake_mon"`
+	TotalStakeMON    float64             `json:"total_stake_mon"`
+	Validators       int                 `json:"validators"`
+	Observed         int                 `json:"observed"`
+	Sources          []string            `json:"sources"`           // Evidence available: vote, proposal
+	VoteWindow       int64               `json:"vote_window"`       // Blocks since last_voted_round still counted as voting
+	ProposalWindow   int                 `json:"proposal_window"`   // Blocks in the leader performance window
+	RoundTimeouts1h  *int64              `json:"round_timeouts_1h"` // From the monad-bft log when MONAD_BFT_LOG is set
+	Unobserved       []ParticipantStatus `json:"unobserved"`        // Largest stake first
+	EvaluatedAt      time.Time           `json:"evaluated_at"`
+}
+
+// SupermajorityMonitor periodically evaluates participation and reports it
+// to the incident log
+type SupermajorityMonitor struct {
+	margin float64
+}
+
+// Global supermajority monitor
+var (
+	supermajorityMonitor   *SupermajorityMonitor
+	supermajorityMonitorMu sync.RWMutex
+)
+
+// InitializeSupermajorityMonitor evaluates participation every interval,
+// alerting within SUPERMAJORITY_ALERT_MARGIN of the threshold
+func InitializeSupermajorityMonitor(interval time.Duration) *SupermajorityMonitor {
+	sm := &SupermajorityMonitor{margin: 0.05}
+	if value := os.Getenv("SUPERMAJORITY_ALERT_MARGIN"); value != "" {
+		if margin, err := strconv.ParseFloat(value, 64); err == nil && margin >= 0 && margin < 1-supermajorityThreshold {
+			sm.margin = margin
+		} else {
+			log.Printf("Invalid SUPERMAJORITY_ALERT_MARGIN %q, using %.2f", value, sm.margin)
+		}
+	}
+	go sm.run(interval)
+
+	supermajorityMonitorMu.Lock()
+	defer supermajorityMonitorMu.Unlock()
+	supermajorityMonitor = sm
+	return sm
+}
+
+// GetSupermajorityMonitor returns the global supermajority monitor
+func GetSupermajorityMonitor() *SupermajorityMonitor {
+	supermajorityMonitorMu.RLock()
+	defer supermajorityMonitorMu.RUnlock()
+	return supermajorityMonitor
+}
+
+// run reports the participation status to the incident log; unknown
+// statuses leave the last report in place
+func (sm *SupermajorityMonitor) run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-shutdownContext().Done():
+			return
+		case now := <-ticker.C:
+			it := GetIncidentTracker()
+			if it == nil {
+				continue
+			}
+			result := sm.Evaluate(now)
+			switch result.Status {
+			case participationHealthy:
+				it.Report(supermajorityComponent, stateConnected, result.Reason, now)
+			case participationAtRisk:
+				it.Report(supermajorityComponent, stateDegraded, result.Reason, now)
+			case participationBelow:
+				it.Report(supermajorityComponent, stateDown, result.Reason, now)
+			}
+		}
+	}
+}
+
+// Evaluate computes the stake-weighted participation of the latest
+// validator set snapshot
+func (sm *SupermajorityMonitor) Evaluate(now time.Time) SupermajorityResponse {
+	result := SupermajorityResponse{
+		Status:      participationUnknown,
+		Threshold:   supermajorityThreshold,
+		AlertMargin: sm.margin,
+		Sources:     make([]string, 0, 2),
+		Unobserved:  make([]ParticipantStatus, 0),
+		EvaluatedAt: now.UTC(),
+	}
+	if lc := GetConsensusLogCollector(); lc != nil {
+		_, lastHour := lc.Counts(now)
+		timeouts := lastHour[consensusEventTimeout]
+		result.RoundTimeouts1h = &timeouts
+	}
+
+	vs := GetValidatorSetTracker()
+	if vs == nil {
+		result.Reason = "validator set tracker not initialized"
+		return result
+	}
+	epoch, validators, ok := vs.Current()
+	if !ok || len(validators) == 0 {
+		result.Reason = "no validator set snapshot yet"
+		return result
+	}
+	result.Epoch = epoch
+	result.Validators = len(validators)
+
+	// Evidence: recent votes from the control panel, and proposals once the
+	// leader window is long enough for every validator to have led
+	var votes map[string]int64
+	var proposers map[string]int64
+	if vt := GetVoteTracker(); vt != nil {
+		var voteOK bool
+		if votes, result.VoteWindow, voteOK = vt.LastVotes(); voteOK {
+			result.Sources = append(result.Sources, "vote")
+		} else {
+			votes = nil
+		}
+	}
+	if lt := GetLeaderTracker(); lt != nil {
+		var blocks int
+		proposers, blocks, result.Head = lt.RecentProposers()
+		result.ProposalWindow = blocks
+		if blocks >= proposalsPerValidator*len(validators) {
+			result.Sources = append(result.Sources, "proposal")
+		} else {
+			proposers = nil
+		}
+	}
+	if len(result.Sources) == 0 {
+		result.Reason = "no vote or proposal evidence yet"
+		return result
+	}
+
+	total, observed := new(big.Int), new(big.Int)
+	for _, v := range validators {
+		total.Add(total, v.stake)
+	}
+	for _, v := range validators {
+		status := ParticipantStatus{ID: v.ID, AuthAddress: v.AuthAddress, StakeMON: v.StakeMON}
+		if total.Sign() > 0 {
+			status.StakeShare, _ = new(big.Rat).SetFrac(v.stake, total).Float64()
+		}
+		address := strings.ToLower(v.AuthAddress)
+		if round, voted := votes[address]; voted {
+			status.LastVote = &round
+			if result.Head-round <= result.VoteWindow {
+				status.Observed, status.Evidence = true, "vote"
+			}
+		}
+		if block, proposed := proposers[address]; proposed {
+			status.LastProposal = &block
+			if !status.Observed {
+				status.Observed, status.Evidence = true, "proposal"
+			}
+		}
+		if status.Observed {
+			result.Observed++
+			observed.Add(observed, v.stake)
+		} else {
+			result.Unobserved = append(result.Unobserved, status)
+		}
+	}
+	sort.Slice(result.Unobserved, func(i, j int) bool {
+		return result.Unobserved[i].StakeShare > result.Unobserved[j].StakeShare
+	})
+
+	result.TotalStakeMON = weiToMON(total)
+	result.ObservedStakeMON = weiToMON(observed)
+	if total.Sign() == 0 {
+		result.Reason = "validator set holds no stake"
+		return result
+	}
+	result.Participation, _ = new(big.Rat).SetFrac(observed, total).Float64()
+
+	switch {
+	case result.Participation < supermajorityThreshold:
+		result.Status = participationBelow
+	case result.Participation < supermajorityThreshold+sm.margin:
+		result.Status = participationAtRisk
+	default:
+		result.Status = participationHealthy
+	}
+	result.Reason = fmt.Sprintf("%.1f%% of stake observed participating (%d of %d validators), supermajority needs %.1f%%",
+		result.Participation*100, result.Observed, result.Validators, supermajorityThreshold*100)
+	return result
+}
+
+// handleSupermajority returns the stake-weighted participation of the
+// validator set (?limit= unobserved validators listed, default 20)
+func handleSupermajority(c *gin.Context) {
+	sm := GetSupermajorityMonitor()
+	if sm == nil {
+		c.JSON(http.StatusServiceUnavailable, APIError{Error: "Supermajority monitor not initialized"})
+		return
+	}
+	limit := 20
+	if value := c.Query("limit"); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n >= 0 {
+			limit = n
+		}
+	}
+	result := sm.Evaluate(time.Now())
+	if len(result.Unobserved) > limit {
+		result.Unobserved = result.Unobserved[:limit]
+	}
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Startup phases reported in summary.startup_progress (Firedancer names)
+const (
+	syncPhaseInitializing = "initializing"
+	syncPhaseDownloading  = "downloading_full_snapshot"
+	syncPhaseLedger       = "processing_ledger"
+	syncPhaseRunning      = "running"
+)
+
+// SyncTracker follows node bootstrap: statesync (reported as the snapshot
+// download) from the Prometheus progress/target gauges, then blocksync
+// (reported as ledger processing) from eth_syncing.
+type SyncTracker struct {
+	progressMetric string
+	targetMetric   string
+
+	mu            sync.RWMutex
+	progress      map[string]interface{}
+	reachable     bool
+	syncStart     time.Time // First poll of the current statesync run
+	lastProgress  float64
+	lastPollAt    time.Time
+	throughput    float64 // Statesync progress units per second (EMA)
+	lastRPCStatus *SyncStatus
+}
+
+// Global sync tracker instance
+var (
+	syncTracker   *SyncTracker
+	syncTrackerMu sync.RWMutex
+)
+
+// InitializeSyncTracker starts polling bootstrap progress. The statesync
+// gauges are SYNC_PROGRESS_METRIC (default monad_statesync_progress_estimate)
+// and SYNC_TARGET_METRIC (default monad_statesync_last_target); overrides
+// must be exported with a gauge TYPE line.
+func InitializeSyncTracker(interval time.Duration) *SyncTracker {
+	st := &SyncTracker{
+		progressMetric: os.Getenv("SYNC_PROGRESS_METRIC"),
+		targetMetric:   os.Getenv("SYNC_TARGET_METRIC"),
+		progress:       startupProgressValue(syncPhaseInitializing),
+	}
+	if st.progressMetric == "" {
+		st.progressMetric = "monad_statesync_progress_estimate"
+	}
+	if st.targetMetric == "" {
+		st.targetMetric = "monad_statesync_last_target"
+	}
+	syncTrackerMu.Lock()
+	syncTracker = st
+	syncTrackerMu.Unlock()
+
+	go st.run(interval)
+	return st
+}
+
+// GetSyncTracker returns the global sync tracker
+func GetSyncTracker() *SyncTracker {
+	syncTrackerMu.RLock()
+	defer syncTrackerMu.RUnlock()
+	return syncTracker
+}
+
+// startupProgressValue returns a startup_progress payload with every field null
+func startupProgressValue(phase string) map[string]interface{} {
+	value := map[string]interface{}{"phase": phase}
+	for _, field := range []string{
+		"downloading_full_snapshot_slot",
+		"downloading_full_snapshot_peer",
+		"downloading_full_snapshot_elapsed_secs",
+		"downloading_full_snapshot_remaining_secs",
+		"downloading_full_snapshot_throughput",
+		"downloading_full_snapshot_total_bytes",
+		"downloading_full_snapshot_current_bytes",
+		"downloading_incremental_snapshot_slot",
+		"downloading_incremental_snapshot_peer",
+		"downloading_incremental_snapshot_elapsed_secs",
+		"downloading_incremental_snapshot_remaining_secs",
+		"downloading_incremental_snapshot_throughput",
+		"downloading_incremental_snapshot_total_bytes",
+		"downloading_incremental_snapshot_current_bytes",
+		"ledger_slot",
+		"ledger_max_slot",
+		"waiting_for_supermajority_slot",
+		"waiting_for_supermajority_stake_percent",
+	} {
+		value[field] = nil
+	}
+	return value
+}
+
+// currentStartupProgress returns the latest startup_progress payload;
+// "running" when no tracker is active (e.g. viewer replicas)
+func currentStartupProgress() map[string]interface{} {
+	if st := GetSyncTracker(); st != nil {
+		return st.Progress()
+	}
+	return startupProgressValue(syncPhaseRunning)
+}
+
+// Progress returns a copy of the current startup_progress payload
+func (st *SyncTracker) Progress() map[string]interface{} {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+	copied := make(map[string]interface{}, len(st.progress))
+	for k, v := range st.progress {
+		copied[k] = v
+	}
+	return copied
+}
+
+func (st *SyncTracker) run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		st.poll()
+	}
+}
+
+// poll recomputes the phase and broadcasts startup_progress when it changes
+func (st *SyncTracker) poll() {
+	now := time.Now()
+	rpcStatus, rpcErr := GetMonadClient().GetSyncing()
+	progress, target, statesync := st.statesyncGauges()
+
+	st.mu.Lock()
+	if rpcErr == nil {
+		st.reachable = true
+		st.lastRPCStatus = rpcStatus
+	} else if getCurrentMetrics().Consensus.CurrentHeight > 0 {
+		// Nodes without eth_syncing count as reachable once blocks arrive
+		st.reachable = true
+	}
+
+	var value map[string]interface{}
+	switch {
+	case statesync && progress < target:
+		value = st.snapshotProgressLocked(now, progress, target)
+	case rpcErr == nil && rpcStatus != nil:
+		st.syncStart = time.Time{}
+		value = startupProgressValue(syncPhaseLedger)
+		value["ledger_slot"] = rpcStatus.CurrentBlock
+		value["ledger_max_slot"] = rpcStatus.HighestBlock
+	case !st.reachable:
+		value = startupProgressValue(syncPhaseInitializing)
+	default:
+		st.syncStart = time.Time{}
+		value = startupProgressValue(syncPhaseRunning)
+	}
+
+	changed := !reflect.DeepEqual(value, st.progress)
+	if changed && value["phase"] != st.progress["phase"] {
+		log.Printf("Node startup phase: %v -> %v", st.progress["phase"], value["phase"])
+	}
+	st.progress = value
+	st.mu.Unlock()
+
+	if changed {
+		broadcastToAllClients(FiredancerMessage{
+			Topic: "summary",
+			Key:   "startup_progress",
+			Value: value,
+		})
+	}
+}
+
+// statesyncGauges reads the statesync progress and target gauges
+func (st *SyncTracker) statesyncGauges() (progress, target float64, ok bool) {
+	pc := GetPrometheusCollector()
+	if pc == nil {
+		return 0, 0, false
+	}
+	metrics := pc.GetMetrics()
+	progressSamples, hasProgress := metrics.Gauges[st.progressMetric]
+	targetSamples, hasTarget := metrics.Gauges[st.targetMetric]
+	if !hasProgress || !hasTarget || len(progressSamples) == 0 || len(targetSamples) == 0 {
+		return 0, 0, false
+	}
+	return progressSamples[0].Value, targetSamples[0].Value, targetSamples[0].Value > 0
+}
+
+// snapshotProgressLocked fills the snapshot download fields, estimating
+// throughput and time remaining from successive progress readings
+func (st *SyncTracker) snapshotProgressLocked(now time.Time, progress, target float64) map[string]interface{} {
+	if st.syncStart.IsZero() {
+		st.syncStart = now
+		st.throughput = 0
+	} else if dt := now.Sub(st.lastPollAt).Seconds(); dt > 0 && progress >= st.lastProgress {
+		rate := (progress - st.lastProgress) / dt
+		if st.throughput == 0 {
+			st.throughput = rate
+		} else {
+			st.throughput = 0.8*st.throughput + 0.2*rate
+		}
+	}
+	st.lastProgress = progress
+	st.lastPollAt = now
+
+	value := startupProgressValue(syncPhaseDownloading)
+	value["downloading_full_snapshot_slot"] = int64(target)
+	value["downloading_full_snapshot_elapsed_secs"] = now.Sub(st.syncStart).Seconds()
+	value["downloading_full_snapshot_current_bytes"] = progress
+	value["downloading_full_snapshot_total_bytes"] = target
+	if st.throughput > 0 {
+		value["downloading_full_snapshot_throughput"] = st.throughput
+		value["downloading_full_snapshot_remaining_secs"] = (target - progress) / st.throughput
+	}
+	if st.lastRPCStatus != nil {
+		value["ledger_slot"] = st.lastRPCStatus.CurrentBlock
+		value["ledger_max_slot"] = st.lastRPCStatus.HighestBlock
+	}
+	return value
+}
+
+// SyncStatusResponse is the body of /api/v1/sync
+type SyncStatusResponse struct {
+	Syncing  bool     `json:"syncing"`
+	Progress FreeForm `json:"progress"` // Firedancer startup_progress fields for the current phase
+}
+
+// handleSyncStatus returns the node's bootstrap progress
+func handleSyncStatus(c *gin.Context) {
+	progress := currentStartupProgress()
+	c.JSON(http.StatusOK, SyncStatusResponse{
+		Syncing:  progress["phase"] != syncPhaseRunning,
+		Progress: progress,
+	})
+}
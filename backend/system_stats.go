@@ -0,0 +1,423 @@
+package main
+
+import (
+	"bufio"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// clockTicks is USER_HZ, the unit of /proc CPU times (100 on every
+// mainstream Linux build; reading it properly needs cgo)
+const clockTicks = 100
+
+// ProcessStats is one monitored process's resource usage
+type ProcessStats struct {
+	Name             string   `json:"name"`
+	PID              int      `json:"pid"`
+	Role             string   `json:"role"`        // "dashboard" or "node"
+	CPUPercent       float64  `json:"cpu_percent"` // Of one core; can exceed 100
+	RSSBytes         uint64   `json:"rss_bytes"`
+	Threads          int      `json:"threads"`
+	OpenFDs          *int     `json:"open_fds"`            // nil without permission
+	ReadBytesPerSec  *float64 `json:"read_bytes_per_sec"`  // Block device reads; nil without permission
+	WriteBytesPerSec *float64 `json:"write_bytes_per_sec"` // Block device writes
+	UptimeSeconds    float64  `json:"uptime_seconds"`
+}
+
+// InterfaceStats is one network interface's throughput
+type InterfaceStats struct {
+	Name          string  `json:"name"`
+	RxBytesPerSec float64 `json:"rx_bytes_per_sec"`
+	TxBytesPerSec float64 `json:"tx_bytes_per_sec"`
+	RxBytes       uint64  `json:"rx_bytes"`
+	TxBytes       uint64  `json:"tx_bytes"`
+}
+
+// HostStats is machine-wide CPU, load and memory
+type HostStats struct {
+	CPUs              int     `json:"cpus"`
+	CPUPercent        float64 `json:"cpu_percent"` // Of all cores
+	Load1             float64 `json:"load_1"`
+	Load5             float64 `json:"load_5"`
+	Load15            float64 `json:"load_15"`
+	MemTotalBytes     uint64  `json:"mem_total_bytes"`
+	MemAvailableBytes uint64  `json:"mem_available_bytes"`
+	MemUsedPercent    float64 `json:"mem_used_percent"`
+}
+
+// SystemStats is one sample of host and process resources
+type SystemStats struct {
+	Timestamp  int64            `json:"timestamp"`
+	Host       HostStats        `json:"host"`
+	Processes  []ProcessStats   `json:"processes"`
+	Interfaces []InterfaceStats `json:"interfaces"`
+	NodeFound  bool             `json:"node_found"` // A configured node process is running on this host
+}
+
+// procSample holds the cumulative counters rates are computed from
+type procSample struct {
+	cpuTicks   uint64
+	readBytes  uint64
+	writeBytes uint64
+	hasIO      bool
+	startTicks uint64
+}
+
+// SystemCollector samples host and process resource usage from /proc
+type SystemCollector struct {
+	procRoot     string
+	processNames map[string]bool
+
+	mu     sync.RWMutex
+	latest *SystemStats
+
+	// Previous counters, for rates
+	at         time.Time
+	procs      map[int]procSample
+	hostBusy   uint64
+	hostTotal  uint64
+	interfaces map[string][2]uint64
+}
+
+// Global system collector instance
+var (
+	systemCollector   *SystemCollector
+	systemCollectorMu sync.RWMutex
+)
+
+// InitializeSystemCollector starts sampling every interval. Node processes
+// are matched by name from MONAD_PROCESS_NAMES (default monad-bft,monad,monad-rpc).
+func InitializeSystemCollector(interval time.Duration) *SystemCollector {
+	names := os.Getenv("MONAD_PROCESS_NAMES")
+	if names == "" {
+		names = "monad-bft,monad,monad-rpc"
+	}
+	sc := &SystemCollector{
+		procRoot:     "/proc",
+		processNames: make(map[string]bool),
+		procs:        make(map[int]procSample),
+		interfaces:   make(map[string][2]uint64),
+	}
+	for _, name := range strings.Split(names, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			sc.processNames[name] = true
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(sc.procRoot, "self", "stat")); err != nil {
+		log.Printf("System stats unavailable (no /proc): %v", err)
+		return nil
+	}
+
+	systemCollectorMu.Lock()
+	systemCollector = sc
+	systemCollectorMu.Unlock()
+
+	sc.sample(time.Now()) // Baseline for the first rates
+	go sc.run(interval)
+	log.Printf("System collector sampling every %s (node processes: %s)", interval, names)
+	return sc
+}
+
+// GetSystemCollector returns the global system collector, nil off Linux
+func GetSystemCollector() *SystemCollector {
+	systemCollectorMu.RLock()
+	defer systemCollectorMu.RUnlock()
+	return systemCollector
+}
+
+func (sc *SystemCollector) run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		stats := sc.sample(now)
+		if streamHasAudience() {
+			broadcastToAllClients(FiredancerMessage{
+				Topic: "system_stats",
+				Key:   "update",
+				Value: stats,
+			})
+		}
+	}
+}
+
+// Latest returns the most recent sample
+func (sc *SystemCollector) Latest() *SystemStats {
+	sc.mu.RLock()
+	defer sc.mu.RUnlock()
+	return sc.latest
+}
+
+// sample reads /proc once and computes rates against the previous sample
+func (sc *SystemCollector) sample(now time.Time) *SystemStats {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	elapsed := now.Sub(sc.at).Seconds()
+	first := sc.at.IsZero()
+	sc.at = now
+	rate := func(current, previous uint64) float64 {
+		if first || elapsed <= 0 || current < previous {
+			return 0
+		}
+		return float64(current-previous) / elapsed
+	}
+
+	stats := &SystemStats{Timestamp: now.Unix(), Processes: []ProcessStats{}, Interfaces: []InterfaceStats{}}
+	bootTime := sc.readHost(stats, first)
+
+	// Processes: the dashboard itself plus every node process
+	pids := map[int]string{os.Getpid(): "dashboard"}
+	for pid := range sc.findNodeProcesses() {
+		if _, self := pids[pid]; !self {
+			pids[pid] = "node"
+			stats.NodeFound = true
+		}
+	}
+	procs := make(map[int]procSample, len(pids))
+	for pid, role := range pids {
+		ps, sampleNow, ok := sc.readProcess(pid, role, bootTime, now)
+		if !ok {
+			continue
+		}
+		if prev, seen := sc.procs[pid]; seen && prev.startTicks == sampleNow.startTicks {
+			ps.CPUPercent = rate(sampleNow.cpuTicks, prev.cpuTicks) / clockTicks * 100
+			if sampleNow.hasIO && prev.hasIO {
+				read, write := rate(sampleNow.readBytes, prev.readBytes), rate(sampleNow.writeBytes, prev.writeBytes)
+				ps.ReadBytesPerSec, ps.WriteBytesPerSec = &read, &write
+			}
+		}
+		procs[pid] = sampleNow
+		stats.Processes = append(stats.Processes, ps)
+	}
+	sc.procs = procs
+	sort.Slice(stats.Processes, func(i, j int) bool {
+		if stats.Processes[i].Role != stats.Processes[j].Role {
+			return stats.Processes[i].Role < stats.Processes[j].Role
+		}
+		return stats.Processes[i].PID < stats.Processes[j].PID
+	})
+
+	// Network interfaces
+	for name, counters := range readNetDev(filepath.Join(sc.procRoot, "net", "dev")) {
+		prev := sc.interfaces[name]
+		stats.Interfaces = append(stats.Interfaces, InterfaceStats{
+			Name:          name,
+			RxBytesPerSec: rate(counters[0], prev[0]),
+			TxBytesPerSec: rate(counters[1], prev[1]),
+			RxBytes:       counters[0],
+			TxBytes:       counters[1],
+		})
+		sc.interfaces[name] = counters
+	}
+	sort.Slice(stats.Interfaces, func(i, j int) bool { return stats.Interfaces[i].Name < stats.Interfaces[j].Name })
+
+	sc.latest = stats
+	return stats
+}
+
+// readHost fills host CPU, load and memory, returning the boot time
+func (sc *SystemCollector) readHost(stats *SystemStats, first bool) time.Time {
+	var bootTime time.Time
+	if f, err := os.Open(filepath.Join(sc.procRoot, "stat")); err == nil {
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			fields := strings.Fields(scanner.Text())
+			switch {
+			case len(fields) > 4 && fields[0] == "cpu":
+				var busy, total uint64
+				for i, field := range fields[1:] {
+					v, _ := strconv.ParseUint(field, 10, 64)
+					total += v
+					if i != 3 && i != 4 { // idle, iowait
+						busy += v
+					}
+				}
+				if !first && total > sc.hostTotal {
+					stats.Host.CPUPercent = float64(busy-sc.hostBusy) / float64(total-sc.hostTotal) * 100
+				}
+				sc.hostBusy, sc.hostTotal = busy, total
+			case strings.HasPrefix(fields[0], "cpu"):
+				stats.Host.CPUs++
+			case fields[0] == "btime" && len(fields) > 1:
+				if secs, err := strconv.ParseInt(fields[1], 10, 64); err == nil {
+					bootTime = time.Unix(secs, 0)
+				}
+			}
+		}
+		f.Close()
+	}
+
+	if data, err := os.ReadFile(filepath.Join(sc.procRoot, "loadavg")); err == nil {
+		fields := strings.Fields(string(data))
+		if len(fields) >= 3 {
+			stats.Host.Load1, _ = strconv.ParseFloat(fields[0], 64)
+			stats.Host.Load5, _ = strconv.ParseFloat(fields[1], 64)
+			stats.Host.Load15, _ = strconv.ParseFloat(fields[2], 64)
+		}
+	}
+
+	meminfo := readKeyValues(filepath.Join(sc.procRoot, "meminfo"))
+	stats.Host.MemTotalBytes = meminfo["MemTotal"] * 1024
+	stats.Host.MemAvailableBytes = meminfo["MemAvailable"] * 1024
+	if stats.Host.MemTotalBytes > 0 {
+		used := stats.Host.MemTotalBytes - stats.Host.MemAvailableBytes
+		stats.Host.MemUsedPercent = float64(used) / float64(stats.Host.MemTotalBytes) * 100
+	}
+	return bootTime
+}
+
+// findNodeProcesses returns the PIDs whose command name is configured
+func (sc *SystemCollector) findNodeProcesses() map[int]string {
+	found := make(map[int]string)
+	entries, err := os.ReadDir(sc.procRoot)
+	if err != nil {
+		return found
+	}
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		comm, err := os.ReadFile(filepath.Join(sc.procRoot, entry.Name(), "comm"))
+		if err != nil {
+			continue
+		}
+		if name := strings.TrimSpace(string(comm)); sc.processNames[name] {
+			found[pid] = name
+		}
+	}
+	return found
+}
+
+// readProcess reads one process's stat, io and fd table
+func (sc *SystemCollector) readProcess(pid int, role string, bootTime, now time.Time) (ProcessStats, procSample, bool) {
+	dir := filepath.Join(sc.procRoot, strconv.Itoa(pid))
+	data, err := os.ReadFile(filepath.Join(dir, "stat"))
+	if err != nil {
+		return ProcessStats{}, procSample{}, false
+	}
+
+	// The command name is parenthesized and may contain spaces
+	text := string(data)
+	open, end := strings.IndexByte(text, '('), strings.LastIndexByte(text, ')')
+	if open < 0 || end < open {
+		return ProcessStats{}, procSample{}, false
+	}
+	fields := strings.Fields(text[end+1:])
+	if len(fields) < 22 {
+		return ProcessStats{}, procSample{}, false
+	}
+	// fields[0] is stat field 3 (state)
+	field := func(n int) uint64 {
+		v, _ := strconv.ParseUint(fields[n-3], 10, 64)
+		return v
+	}
+
+	ps := ProcessStats{
+		Name:     text[open+1 : end],
+		PID:      pid,
+		Role:     role,
+		RSSBytes: field(24) * uint64(os.Getpagesize()),
+		Threads:  int(field(20)),
+	}
+	sample := procSample{cpuTicks: field(14) + field(15), startTicks: field(22)}
+	if !bootTime.IsZero() {
+		started := bootTime.Add(time.Duration(sample.startTicks) * time.Second / clockTicks)
+		ps.UptimeSeconds = now.Sub(started).Seconds()
+	}
+
+	if fds, err := os.ReadDir(filepath.Join(dir, "fd")); err == nil {
+		count := len(fds)
+		ps.OpenFDs = &count
+	}
+	if io := readKeyValues(filepath.Join(dir, "io")); len(io) > 0 {
+		sample.readBytes, sample.writeBytes, sample.hasIO = io["read_bytes"], io["write_bytes"], true
+	}
+	return ps, sample, true
+}
+
+// readKeyValues parses "Key: value [kB]" files such as meminfo and io
+func readKeyValues(path string) map[string]uint64 {
+	values := make(map[string]uint64)
+	f, err := os.Open(path)
+	if err != nil {
+		return values
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, rest, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		if fields := strings.Fields(rest); len(fields) > 0 {
+			if v, err := strconv.ParseUint(fields[0], 10, 64); err == nil {
+				values[key] = v
+			}
+		}
+	}
+	return values
+}
+
+// readNetDev returns received and transmitted bytes per interface, except loopback
+func readNetDev(path string) map[string][2]uint64 {
+	counters := make(map[string][2]uint64)
+	f, err := os.Open(path)
+	if err != nil {
+		return counters
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		name, rest, ok := strings.Cut(scanner.Text(), ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "lo" {
+			continue
+		}
+		fields := strings.Fields(rest)
+		if len(fields) < 9 {
+			continue
+		}
+		rx, _ := strconv.ParseUint(fields[0], 10, 64)
+		tx, _ := strconv.ParseUint(fields[8], 10, 64)
+		counters[name] = [2]uint64{rx, tx}
+	}
+	return counters
+}
+
+// handleSystemStats returns the latest host and process sample
+func handleSystemStats(c *gin.Context) {
+	sc := GetSystemCollector()
+	if sc == nil {
+		c.JSON(http.StatusServiceUnavailable, APIError{Error: "System stats unavailable on this platform"})
+		return
+	}
+	stats := sc.Latest()
+	if stats == nil {
+		c.JSON(http.StatusServiceUnavailable, APIError{Error: "No sample yet"})
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
+
+// nodeProcessTotals sums CPU and RSS over the node's processes
+func (s *SystemStats) nodeProcessTotals() (cpuPercent float64, rssBytes uint64, ok bool) {
+	for _, p := range s.Processes {
+		if p.Role == "node" {
+			cpuPercent += p.CPUPercent
+			rssBytes += p.RSSBytes
+			ok = true
+		}
+	}
+	return cpuPercent, rssBytes, ok
+}
@@ -0,0 +1,355 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/pelletier/go-toml/v2"
+)
+
+// Tenants let one backend host dashboards for several operators. Each
+// tenant of TENANTS_CONFIG is served under /t/<id>/ (API at
+// /t/<id>/api/v1/..., stream at /t/<id>/websocket and /t/<id>/ws/native)
+// with its own API keys, watchlist and preferences, and sees only its own
+// node's chain. Tenants naming the same node share one collector.
+
+// tenantIDPattern restricts tenant IDs to URL-safe slugs
+var tenantIDPattern = regexp.MustCompile(`^[a-z0-9-]{1,32}$`)
+
+// tenantAPIPrefixes are the /api/v1 routes reachable under a tenant prefix.
+// Admin, recording, webhook and replica endpoints stay global.
+var tenantAPIPrefixes = []string{
+	"/health", "/livez", "/readyz", "/metrics", "/waterfall", "/consensus",
+	"/incidents", "/sync", "/mempool", "/propagation", "/chain/params",
+	"/history", "/tokens", "/fees", "/leader", "/validators", "/blocks",
+	"/tx", "/execution", "/address", "/contracts", "/gas", "/watchlist",
+	"/preferences", "/rpc-usage", "/ws/upgrade-token", "/openapi.json",
+}
+
+// TenantsConfig is the TENANTS_CONFIG file
+type TenantsConfig struct {
+	Tenants []TenantConfig `toml:"tenant"`
+}
+
+// TenantConfig is one [[tenant]] of TENANTS_CONFIG. Chain names a chain
+// already monitored (MONAD_RPC_URL or MONAD_CHAINS); RPCURL points at the
+// tenant's own node, monitored as a secondary chain unless another tenant or
+// chain already uses it.
+type TenantConfig struct {
+	ID     string                 `toml:"id"`
+	Name   string                 `toml:"name"`
+	Chain  string                 `toml:"chain"`
+	RPCURL string                 `toml:"rpc_url"`
+	Keys   []string               `toml:"api_keys"` // Empty means the tenant is public
+	Watch  []WatchlistConfigEntry `toml:"watch"`
+}
+
+// Tenant is one operator's isolated view of the dashboard
+type Tenant struct {
+	ID        string
+	Name      string
+	Chain     string
+	ownChain  bool // Chain monitor started for this tenant's node
+	keys      [][sha256.Size]byte
+	watchlist *Watchlist
+}
+
+// Global tenant registry
+var (
+	tenants   map[string]*Tenant
+	tenantsMu sync.RWMutex
+)
+
+// InitializeTenants loads TENANTS_CONFIG (TOML) when set. Call after
+// InitializeChains so tenants can share its chain monitors.
+func InitializeTenants() {
+	path := os.Getenv("TENANTS_CONFIG")
+	if path == "" {
+		return
+	}
+	loaded, err := loadTenants(path)
+	if err != nil {
+		log.Fatalf("Invalid tenants config %s: %v", path, err)
+	}
+
+	tenantsMu.Lock()
+	tenants = loaded
+	tenantsMu.Unlock()
+	log.Printf("Tenants: %d from %s", len(loaded), path)
+}
+
+// loadTenants reads and validates a tenants config, resolving each tenant's
+// chain
+func loadTenants(path string) (map[string]*Tenant, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var config TenantsConfig
+	if err := toml.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+
+	loaded := make(map[string]*Tenant, len(config.Tenants))
+	for _, entry := range config.Tenants {
+		if !tenantIDPattern.MatchString(entry.ID) {
+			return nil, fmt.Errorf("tenant id %q must match %s", entry.ID, tenantIDPattern)
+		}
+		if _, dup := loaded[entry.ID]; dup {
+			return nil, fmt.Errorf("tenant %s listed twice", entry.ID)
+		}
+
+		tenant := &Tenant{ID: entry.ID, Name: entry.Name, watchlist: NewWatchlist()}
+		switch {
+		case entry.Chain != "" && entry.RPCURL != "":
+			return nil, fmt.Errorf("tenant %s: set chain or rpc_url, not both", entry.ID)
+		case entry.Chain != "":
+			monitor, primary, ok := lookupChain(entry.Chain)
+			if !ok {
+				return nil, fmt.Errorf("tenant %s: unknown chain %q", entry.ID, entry.Chain)
+			}
+			tenant.Chain = primaryChainName()
+			if !primary {
+				tenant.Chain = monitor.name
+			}
+		case entry.RPCURL != "":
+			if tenant.Chain, tenant.ownChain, err = chainForRPC(entry.ID, entry.RPCURL); err != nil {
+				return nil, fmt.Errorf("tenant %s: %w", entry.ID, err)
+			}
+		default:
+			tenant.Chain = primaryChainName()
+		}
+
+		for _, key := range entry.Keys {
+			if key == "" {
+				return nil, fmt.Errorf("tenant %s: empty API key", entry.ID)
+			}
+			tenant.keys = append(tenant.keys, sha256.Sum256([]byte(key)))
+		}
+		for _, watch := range entry.Watch {
+			if _, err := tenant.watchlist.Add(watch.Address, watch.Label); err != nil {
+				return nil, fmt.Errorf("tenant %s: %w", entry.ID, err)
+			}
+		}
+		loaded[entry.ID] = tenant
+	}
+	return loaded, nil
+}
+
+// GetTenant returns a configured tenant
+func GetTenant(id string) (*Tenant, bool) {
+	tenantsMu.RLock()
+	defer tenantsMu.RUnlock()
+	tenant, ok := tenants[id]
+	return tenant, ok
+}
+
+// GetTenants returns all configured tenants sorted by ID
+func GetTenants() []*Tenant {
+	tenantsMu.RLock()
+	defer tenantsMu.RUnlock()
+	list := make([]*Tenant, 0, len(tenants))
+	for _, tenant := range tenants {
+		list = append(list, tenant)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+// primary reports whether the tenant's node is the one the collectors read
+func (t *Tenant) primary() bool {
+	return t.Chain == primaryChainName()
+}
+
+// authorize reports whether key is one of the tenant's API keys; tenants
+// without keys are public
+func (t *Tenant) authorize(key string) bool {
+	if len(t.keys) == 0 {
+		return true
+	}
+	sum := sha256.Sum256([]byte(key))
+	for _, k := range t.keys {
+		if subtle.ConstantTimeCompare(sum[:], k[:]) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// sees reports whether an outbound message belongs to the tenant's chain
+func (t *Tenant) sees(msg interface{}) bool {
+	return messageChain(withChain(unwrapMessage(msg))) == t.Chain
+}
+
+// tenantContextKey carries the tenant of a request. The request context
+// survives apiRouter.HandleContext, which resets the gin context's keys.
+type tenantContextKey struct{}
+
+// tenantOf returns the tenant a request was routed through, nil for the
+// global dashboard
+func tenantOf(c *gin.Context) *Tenant {
+	tenant, _ := c.Request.Context().Value(tenantContextKey{}).(*Tenant)
+	return tenant
+}
+
+// watchlistFor returns a tenant's watchlist, the global one for nil
+func watchlistFor(t *Tenant) *Watchlist {
+	if t != nil {
+		return t.watchlist
+	}
+	return GetWatchlist()
+}
+
+// requireTenant resolves /t/:tenant and checks its API key (X-Tenant-Key
+// header or ?tenant_key=), storing the tenant in the request context
+func requireTenant(c *gin.Context) {
+	tenant, ok := GetTenant(c.Param("tenant"))
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusNotFound, APIError{Error: fmt.Sprintf("unknown tenant %q", c.Param("tenant"))})
+		return
+	}
+	key := c.GetHeader("X-Tenant-Key")
+	if key == "" {
+		key = c.Query("tenant_key")
+	}
+	if !tenant.authorize(key) {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, APIError{Error: "Invalid tenant key"})
+		return
+	}
+	c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), tenantContextKey{}, tenant))
+	c.Next()
+}
+
+// tenantAPIAllowed reports whether path may be served under a tenant prefix
+func tenantAPIAllowed(path string) bool {
+	for _, prefix := range tenantAPIPrefixes {
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// handleTenantAPI serves /t/:tenant/api/v1/*path. Tenants of the primary
+// chain get the regular /api/v1 routes; tenants of a secondary chain get
+// its metrics, health and blocks, plus their watchlist and preferences.
+func handleTenantAPI(c *gin.Context) {
+	tenant := tenantOf(c)
+	path := strings.TrimSuffix(c.Param("path"), "/")
+	if !tenantAPIAllowed(path) {
+		c.JSON(http.StatusNotFound, APIError{Error: fmt.Sprintf("%s is not available to tenants", path)})
+		return
+	}
+
+	if !tenant.primary() && !strings.HasPrefix(path, "/watchlist") && !strings.HasPrefix(path, "/preferences") {
+		monitor, ok := tenantChain(tenant.Chain)
+		if !ok {
+			c.JSON(http.StatusServiceUnavailable, APIError{Error: fmt.Sprintf("chain %q not monitored", tenant.Chain)})
+			return
+		}
+		serveSecondaryChain(c, monitor, path)
+		return
+	}
+	c.Request.URL.Path = "/api/v1" + path
+	apiRouter.HandleContext(c)
+}
+
+// matchTenantWatchlists matches a primary chain log against the watchlist
+// of every tenant on the primary chain; the monitors of other chains match
+// their tenants' watchlists (see ChainMonitor.matchWatchlists)
+func matchTenantWatchlists(txLog *TransactionLog) {
+	for _, tenant := range GetTenants() {
+		if tenant.primary() {
+			tenant.watchlist.MatchLog(txLog)
+		}
+	}
+}
+
+// TenantSummary describes one tenant for /api/v1/admin/tenants
+type TenantSummary struct {
+	ID          string `json:"id"`
+	Name        string `json:"name,omitempty"`
+	Chain       string `json:"chain"`
+	SharedChain bool   `json:"shared_chain"` // Collector also serves other tenants or the global dashboard
+	Public      bool   `json:"public"`       // No API keys configured
+	Clients     int    `json:"clients"`      // Connected stream clients
+	Watched     int    `json:"watched"`      // Watchlist size
+}
+
+// TenantsResponse is the body of /api/v1/admin/tenants
+type TenantsResponse struct {
+	Tenants []TenantSummary `json:"tenants"`
+}
+
+// handleTenants lists the configured tenants
+func handleTenants(c *gin.Context) {
+	list := GetTenants()
+	chainUsers := make(map[string]int)
+	for _, tenant := range list {
+		chainUsers[tenant.Chain]++
+	}
+
+	clients := make(map[*Tenant]int)
+	wsClientsMu.RLock()
+	for _, client := range wsClients {
+		if client.tenant != nil {
+			clients[client.tenant]++
+		}
+	}
+	wsClientsMu.RUnlock()
+
+	response := TenantsResponse{Tenants: make([]TenantSummary, 0, len(list))}
+	for _, tenant := range list {
+		response.Tenants = append(response.Tenants, TenantSummary{
+			ID:          tenant.ID,
+			Name:        tenant.Name,
+			Chain:       tenant.Chain,
+			SharedChain: chainUsers[tenant.Chain] > 1 || !tenant.ownChain,
+			Public:      len(tenant.keys) == 0,
+			Clients:     clients[tenant],
+			Watched:     tenant.watchlist.Len(),
+		})
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// broadcastToTenantClients sends a message of a private chain to the
+// stream clients of the tenants on it; the global stream, SSE clients and
+// viewer replicas never see it
+func broadcastToTenantClients(msg interface{}) {
+	wsClientsMu.RLock()
+	clients := make([]*wsClient, 0)
+	for _, client := range wsClients {
+		if client.tenant != nil && client.tenant.sees(msg) {
+			clients = append(clients, client)
+		}
+	}
+	wsClientsMu.RUnlock()
+
+	shared := newSharedMessage(msg)
+	for _, client := range clients {
+		if err := client.write(shared); err != nil {
+			reapWSClient(client.conn, "write failed")
+		}
+	}
+}
+
+// tenantOfConn returns the tenant of a stream connection, nil for the
+// global stream
+func tenantOfConn(conn *websocket.Conn) *Tenant {
+	if client := getWSClient(conn); client != nil {
+		return client.tenant
+	}
+	return nil
+}
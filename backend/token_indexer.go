@@ -0,0 +1,350 @@
+package main
+
+import (
+	"math/big"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"monad-dashboard/hexutil"
+)
+
+// TransferEventTopic is keccak256("Transfer(address,address,uint256)"),
+// shared by ERC-20 and ERC-721
+const TransferEventTopic = "0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"
+
+// Token standards distinguished by Transfer event shape
+const (
+	TokenStandardERC20  = "erc20"  // value in data, 3 topics
+	TokenStandardERC721 = "erc721" // tokenId as 4th topic, empty data
+)
+
+// TokenTransfer is a decoded Transfer event
+type TokenTransfer struct {
+	Token       string   `json:"token"`
+	Standard    string   `json:"standard"`
+	From        string   `json:"from"`
+	To          string   `json:"to"`
+	Value       *big.Int `json:"value,omitempty"`    // ERC-20 amount
+	TokenID     *big.Int `json:"token_id,omitempty"` // ERC-721 token id
+	BlockNumber int64    `json:"block_number"`
+	TxHash      string   `json:"transaction_hash"`
+	Timestamp   int64    `json:"timestamp"`
+}
+
+// DecodeTokenTransfer decodes a Transfer log, returning false for other events
+func DecodeTokenTransfer(txLog *TransactionLog) (*TokenTransfer, bool) {
+	if len(txLog.Topics) < 3 || !strings.EqualFold(txLog.Topics[0], TransferEventTopic) {
+		return nil, false
+	}
+
+	from, ok := topicToAddress(txLog.Topics[1])
+	if !ok {
+		return nil, false
+	}
+	to, ok := topicToAddress(txLog.Topics[2])
+	if !ok {
+		return nil, false
+	}
+
+	transfer := &TokenTransfer{
+		Token:       strings.ToLower(txLog.Address),
+		From:        from,
+		To:          to,
+		BlockNumber: txLog.BlockNumber,
+		TxHash:      txLog.TransactionHash,
+		Timestamp:   txLog.Timestamp,
+	}
+
+	switch len(txLog.Topics) {
+	case 3:
+		data, err := hexutil.DecodeBytes(txLog.Data)
+		if err != nil || len(data) != 32 {
+			return nil, false
+		}
+		transfer.Standard = TokenStandardERC20
+		transfer.Value = new(big.Int).SetBytes(data)
+	case 4:
+		tokenID, err := hexutil.DecodeBytes(txLog.Topics[3])
+		if err != nil || len(tokenID) != 32 {
+			return nil, false
+		}
+		transfer.Standard = TokenStandardERC721
+		transfer.TokenID = new(big.Int).SetBytes(tokenID)
+	default:
+		return nil, false
+	}
+
+	return transfer, true
+}
+
+// tokenBucket aggregates transfers of one token within one minute
+type tokenBucket struct {
+	standard  string
+	transfers int64
+	volume    *big.Int
+	senders   map[string]struct{}
+}
+
+// TokenActivity is the aggregated activity of a token over a window
+type TokenActivity struct {
+	Token           string  `json:"token"`
+	Standard        string  `json:"standard"`
+	Transfers       int64   `json:"transfers"`
+	Volume          string  `json:"volume"` // Decimal string, raw token units
+	UniqueSenders   int     `json:"unique_senders"`
+	TransfersPerSec float64 `json:"transfers_per_sec"`
+	Name            string  `json:"name,omitempty"`         // Verified contract name
+	Verification    string  `json:"verification,omitempty"` // exact_match or match
+}
+
+// TokenIndexer aggregates Transfer events into per-minute buckets
+type TokenIndexer struct {
+	mu        sync.RWMutex
+	buckets   map[int64]map[string]*tokenBucket // minute -> token -> bucket
+	recent    []TokenTransfer
+	maxRecent int
+	retention time.Duration
+
+	totalTransfers int64
+}
+
+// Supported rolling windows for /api/v1/tokens/top
+var tokenWindows = map[string]time.Duration{
+	"1m":  time.Minute,
+	"5m":  5 * time.Minute,
+	"15m": 15 * time.Minute,
+	"1h":  time.Hour,
+}
+
+// Global token indexer instance
+var (
+	tokenIndexer   *TokenIndexer
+	tokenIndexerMu sync.RWMutex
+)
+
+// NewTokenIndexer creates a token indexer retaining one hour of buckets
+func NewTokenIndexer() *TokenIndexer {
+	return &TokenIndexer{
+		buckets:   make(map[int64]map[string]*tokenBucket),
+		recent:    make([]TokenTransfer, 0, 100),
+		maxRecent: 100,
+		retention: time.Hour,
+	}
+}
+
+// InitializeTokenIndexer creates the global token indexer
+func InitializeTokenIndexer() *TokenIndexer {
+	tokenIndexerMu.Lock()
+	defer tokenIndexerMu.Unlock()
+	tokenIndexer = NewTokenIndexer()
+	return tokenIndexer
+}
+
+// GetTokenIndexer returns the global token indexer
+func GetTokenIndexer() *TokenIndexer {
+	tokenIndexerMu.RLock()
+	defer tokenIndexerMu.RUnlock()
+	return tokenIndexer
+}
+
+// ProcessLog indexes a log if it is a Transfer event
+func (ti *TokenIndexer) ProcessLog(txLog *TransactionLog) {
+	transfer, ok := DecodeTokenTransfer(txLog)
+	if !ok {
+		return
+	}
+	ti.Record(transfer, time.Now())
+}
+
+// Record adds a decoded transfer to the bucket for the given time
+func (ti *TokenIndexer) Record(transfer *TokenTransfer, at time.Time) {
+	minute := at.Unix() / 60
+
+	ti.mu.Lock()
+	defer ti.mu.Unlock()
+
+	tokens, exists := ti.buckets[minute]
+	if !exists {
+		tokens = make(map[string]*tokenBucket)
+		ti.buckets[minute] = tokens
+		ti.pruneLocked(minute)
+	}
+
+	bucket, exists := tokens[transfer.Token]
+	if !exists {
+		bucket = &tokenBucket{
+			standard: transfer.Standard,
+			volume:   new(big.Int),
+			senders:  make(map[string]struct{}),
+		}
+		tokens[transfer.Token] = bucket
+	}
+
+	bucket.transfers++
+	if transfer.Value != nil {
+		bucket.volume.Add(bucket.volume, transfer.Value)
+	} else {
+		// NFTs: volume is the number of tokens moved
+		bucket.volume.Add(bucket.volume, big.NewInt(1))
+	}
+	bucket.senders[transfer.From] = struct{}{}
+
+	ti.totalTransfers++
+	ti.recent = append(ti.recent, *transfer)
+	if len(ti.recent) > ti.maxRecent {
+		ti.recent = ti.recent[1:]
+	}
+}
+
+// pruneLocked drops buckets older than the retention period
+func (ti *TokenIndexer) pruneLocked(currentMinute int64) {
+	oldest := currentMinute - int64(ti.retention/time.Minute)
+	for minute := range ti.buckets {
+		if minute <= oldest {
+			delete(ti.buckets, minute)
+		}
+	}
+}
+
+// TopTokens returns the most active tokens over the window, ranked by
+// transfer count. An empty standard matches all tokens.
+func (ti *TokenIndexer) TopTokens(window time.Duration, standard string, limit int) []TokenActivity {
+	fromMinute := (time.Now().Unix() - int64(window.Seconds())) / 60
+
+	type aggregate struct {
+		standard  string
+		transfers int64
+		volume    *big.Int
+		senders   map[string]struct{}
+	}
+
+	ti.mu.RLock()
+	merged := make(map[string]*aggregate)
+	for minute, tokens := range ti.buckets {
+		if minute <= fromMinute {
+			continue
+		}
+		for token, bucket := range tokens {
+			if standard != "" && bucket.standard != standard {
+				continue
+			}
+			agg, exists := merged[token]
+			if !exists {
+				agg = &aggregate{
+					standard: bucket.standard,
+					volume:   new(big.Int),
+					senders:  make(map[string]struct{}),
+				}
+				merged[token] = agg
+			}
+			agg.transfers += bucket.transfers
+			agg.volume.Add(agg.volume, bucket.volume)
+			for sender := range bucket.senders {
+				agg.senders[sender] = struct{}{}
+			}
+		}
+	}
+	ti.mu.RUnlock()
+
+	activity := make([]TokenActivity, 0, len(merged))
+	for token, agg := range merged {
+		activity = append(activity, TokenActivity{
+			Token:           token,
+			Standard:        agg.standard,
+			Transfers:       agg.transfers,
+			Volume:          agg.volume.String(),
+			UniqueSenders:   len(agg.senders),
+			TransfersPerSec: float64(agg.transfers) / window.Seconds(),
+		})
+	}
+
+	sort.Slice(activity, func(i, j int) bool {
+		if activity[i].Transfers != activity[j].Transfers {
+			return activity[i].Transfers > activity[j].Transfers
+		}
+		return activity[i].Token < activity[j].Token
+	})
+
+	if limit > 0 && len(activity) > limit {
+		activity = activity[:limit]
+	}
+	return activity
+}
+
+// RecentTransfers returns the most recent decoded transfers, newest first
+func (ti *TokenIndexer) RecentTransfers() []TokenTransfer {
+	ti.mu.RLock()
+	defer ti.mu.RUnlock()
+
+	transfers := make([]TokenTransfer, len(ti.recent))
+	for i, transfer := range ti.recent {
+		transfers[len(ti.recent)-1-i] = transfer
+	}
+	return transfers
+}
+
+// TotalTransfers returns the number of transfers indexed since startup
+func (ti *TokenIndexer) TotalTransfers() int64 {
+	ti.mu.RLock()
+	defer ti.mu.RUnlock()
+	return ti.totalTransfers
+}
+
+// TopTokensResponse is the body of /api/v1/tokens/top
+type TopTokensResponse struct {
+	Window         string          `json:"window"`
+	Standard       string          `json:"standard"` // Empty for all standards
+	Tokens         []TokenActivity `json:"tokens"`
+	TotalTransfers int64           `json:"total_transfers"`
+	Recent         []TokenTransfer `json:"recent"`
+	Timestamp      int64           `json:"timestamp"`
+}
+
+// handleTopTokens returns the most active tokens over a rolling window
+// Query params: window (1m, 5m, 15m, 1h), standard (erc20, erc721), limit
+func handleTopTokens(c *gin.Context) {
+	ti := GetTokenIndexer()
+	if ti == nil {
+		c.JSON(http.StatusServiceUnavailable, APIError{Error: "Token indexer not initialized"})
+		return
+	}
+
+	windowName := c.DefaultQuery("window", "5m")
+	window, ok := tokenWindows[windowName]
+	if !ok {
+		c.JSON(http.StatusBadRequest, APIError{Error: "window must be one of 1m, 5m, 15m, 1h"})
+		return
+	}
+
+	standard := strings.ToLower(c.Query("standard"))
+	if standard != "" && standard != TokenStandardERC20 && standard != TokenStandardERC721 {
+		c.JSON(http.StatusBadRequest, APIError{Error: "standard must be erc20 or erc721"})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit <= 0 {
+		c.JSON(http.StatusBadRequest, APIError{Error: "limit must be a positive integer"})
+		return
+	}
+
+	tokens := ti.TopTokens(window, standard, limit)
+	for i := range tokens {
+		tokens[i].Name, tokens[i].Verification = contractName(tokens[i].Token)
+	}
+
+	c.JSON(http.StatusOK, TopTokensResponse{
+		Window:         windowName,
+		Standard:       standard,
+		Tokens:         tokens,
+		TotalTransfers: ti.TotalTransfers(),
+		Recent:         ti.RecentTransfers(),
+		Timestamp:      time.Now().Unix(),
+	})
+}
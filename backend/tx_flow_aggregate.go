@@ -0,0 +1,271 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Aggregated tx_flow mode. Clients that only render flow intensity switch
+// to it with {"topic": "tx_flow", "key": "mode", "params": {"mode": "aggregate"}}
+// and receive one tx_flow.aggregate bucket per 100ms (transaction and log
+// counts, logs per contract and per event type) instead of a message per
+// transaction or log. {"mode": "messages"} switches back.
+
+const (
+	txFlowModeMessages  = "messages"
+	txFlowModeAggregate = "aggregate"
+
+	// txFlowBucketInterval is the span of one aggregated bucket
+	txFlowBucketInterval = 100 * time.Millisecond
+
+	// txFlowBucketTop is how many contracts and event types a bucket lists;
+	// the rest are summed as other
+	txFlowBucketTop = 20
+)
+
+// knownEventNames names common event signatures (topic0)
+var knownEventNames = map[string]string{
+	TransferEventTopic: "Transfer",
+	"0x8c5be1e5ebec7d5bd14f71427d1e84f3dd0314c0f7b2291e5b200ac8c7c3b925": "Approval",
+	"0xd78ad95fa46c994b6551d0da85fc275fe613ce37657fb8d5e3d130840159d822": "Swap",       // Uniswap V2
+	"0xc42079f94a6350d7e6235f29174924f928cc2ac818eb64fed8004e115fbcca67": "Swap",       // Uniswap V3
+	"0xe1fffcc4923d04b559f4d29a8bfc6cda04eb5b0d3c460751c2402c5c5cc9109c": "Deposit",    // WETH
+	"0x7fcf532c15f0a6db0bd6d0e038bea71d30d808c7d98cb3bf7268a95bf5081b65": "Withdrawal", // WETH
+	"0xc3d58168c5ae7397731d063d5bbf3d657854427343f4c083240f7aacaa2d0f62": "TransferSingle",
+	"0x4a39dc06d4c0dbc64b70af90fd698a233a518aa5d07e595d983b8c0526c8f7fb": "TransferBatch",
+}
+
+// TxFlowBucketCount is one contract's or event type's log count
+type TxFlowBucketCount struct {
+	Address string `json:"address,omitempty"`
+	Topic0  string `json:"topic0,omitempty"`
+	Name    string `json:"name,omitempty"` // Known event name
+	Count   int64  `json:"count"`
+}
+
+// TxFlowBucket is the tx_flow.aggregate payload
+type TxFlowBucket struct {
+	Start          int64               `json:"start"` // Unix ms
+	IntervalMs     int64               `json:"interval_ms"`
+	Transactions   int64               `json:"transactions"`
+	Logs           int64               `json:"logs"`
+	Estimated      bool                `json:"estimated,omitempty"` // Log counts scaled up while log sampling is active
+	Contracts      []TxFlowBucketCount `json:"contracts"`           // Most logs first
+	OtherContracts int64               `json:"other_contracts"`
+	Events         []TxFlowBucketCount `json:"events"` // Most logs first
+	OtherEvents    int64               `json:"other_events"`
+}
+
+// TxFlowAggregator counts transactions and logs into buckets while any
+// client is in aggregate mode
+type TxFlowAggregator struct {
+	clock Clock
+
+	mu           sync.Mutex
+	clients      map[*websocket.Conn]bool
+	start        time.Time
+	transactions int64
+	logs         int64
+	estimated    bool
+	contracts    map[string]int64
+	events       map[string]int64
+}
+
+// Global tx_flow aggregator
+var (
+	txFlowAggregator   *TxFlowAggregator
+	txFlowAggregatorMu sync.RWMutex
+)
+
+// NewTxFlowAggregator creates an aggregator timing buckets by clock
+func NewTxFlowAggregator(clock Clock) *TxFlowAggregator {
+	return &TxFlowAggregator{
+		clock:     clock,
+		clients:   make(map[*websocket.Conn]bool),
+		contracts: make(map[string]int64),
+		events:    make(map[string]int64),
+	}
+}
+
+// InitializeTxFlowAggregator creates the global aggregator and starts
+// flushing its buckets
+func InitializeTxFlowAggregator() *TxFlowAggregator {
+	txFlowAggregatorMu.Lock()
+	defer txFlowAggregatorMu.Unlock()
+	txFlowAggregator = NewTxFlowAggregator(systemClock)
+	go txFlowAggregator.run()
+	return txFlowAggregator
+}
+
+// GetTxFlowAggregator returns the global aggregator
+func GetTxFlowAggregator() *TxFlowAggregator {
+	txFlowAggregatorMu.RLock()
+	defer txFlowAggregatorMu.RUnlock()
+	return txFlowAggregator
+}
+
+// SetAggregate switches a client in or out of aggregate mode
+func (a *TxFlowAggregator) SetAggregate(conn *websocket.Conn, aggregate bool) {
+	updateTxFlowClient(conn, func(client *txFlowClient) { client.aggregate = aggregate })
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if aggregate {
+		if len(a.clients) == 0 {
+			a.resetLocked(a.clock.Now())
+		}
+		a.clients[conn] = true
+	} else {
+		delete(a.clients, conn)
+	}
+}
+
+// Forget drops a disconnected client
+func (a *TxFlowAggregator) Forget(conn *websocket.Conn) {
+	a.mu.Lock()
+	delete(a.clients, conn)
+	a.mu.Unlock()
+}
+
+// AddTransactions counts transactions included in a block
+func (a *TxFlowAggregator) AddTransactions(n int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(a.clients) > 0 {
+		a.transactions += int64(n)
+	}
+}
+
+// AddLog counts a monadLogs entry; a sampled log stands for SampleRate logs
+func (a *TxFlowAggregator) AddLog(txLog *TransactionLog) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(a.clients) == 0 {
+		return
+	}
+
+	weight := int64(1)
+	if txLog.Sampled && txLog.SampleRate > 1 {
+		weight = int64(txLog.SampleRate)
+		a.estimated = true
+	}
+	a.logs += weight
+	a.contracts[strings.ToLower(txLog.Address)] += weight
+	if len(txLog.Topics) > 0 {
+		a.events[strings.ToLower(txLog.Topics[0])] += weight
+	}
+}
+
+// resetLocked starts a new bucket; caller holds a.mu
+func (a *TxFlowAggregator) resetLocked(now time.Time) {
+	a.start = now
+	a.transactions = 0
+	a.logs = 0
+	a.estimated = false
+	a.contracts = make(map[string]int64)
+	a.events = make(map[string]int64)
+}
+
+// run sends a bucket to aggregating clients every interval until shutdown
+func (a *TxFlowAggregator) run() {
+	ticker := a.clock.NewTicker(txFlowBucketInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-shutdownContext().Done():
+			return
+		case now := <-ticker.C():
+			if bucket, ok := a.flush(now); ok {
+				broadcastToClientsWhere(FiredancerMessage{Topic: "tx_flow", Key: "aggregate", Value: bucket},
+					func(conn *websocket.Conn) bool { return txFlowClientFor(conn).aggregate })
+			}
+		}
+	}
+}
+
+// flush closes the current bucket, reporting false when there is nobody to
+// send it to or nothing happened
+func (a *TxFlowAggregator) flush(now time.Time) (TxFlowBucket, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(a.clients) == 0 {
+		return TxFlowBucket{}, false
+	}
+	if a.transactions == 0 && a.logs == 0 {
+		a.start = now
+		return TxFlowBucket{}, false
+	}
+
+	bucket := TxFlowBucket{
+		Start:        a.start.UnixMilli(),
+		IntervalMs:   now.Sub(a.start).Milliseconds(),
+		Transactions: a.transactions,
+		Logs:         a.logs,
+		Estimated:    a.estimated,
+	}
+	bucket.Contracts, bucket.OtherContracts = topBucketCounts(a.contracts, func(address string, count int64) TxFlowBucketCount {
+		return TxFlowBucketCount{Address: address, Count: count}
+	})
+	bucket.Events, bucket.OtherEvents = topBucketCounts(a.events, func(topic0 string, count int64) TxFlowBucketCount {
+		return TxFlowBucketCount{Topic0: topic0, Name: knownEventNames[topic0], Count: count}
+	})
+	a.resetLocked(now)
+	return bucket, true
+}
+
+// topBucketCounts returns the txFlowBucketTop largest counts and the sum of
+// the rest
+func topBucketCounts(counts map[string]int64, entry func(string, int64) TxFlowBucketCount) ([]TxFlowBucketCount, int64) {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+
+	top := make([]TxFlowBucketCount, 0, txFlowBucketTop)
+	var other int64
+	for i, key := range keys {
+		if i < txFlowBucketTop {
+			top = append(top, entry(key, counts[key]))
+		} else {
+			other += counts[key]
+		}
+	}
+	return top, other
+}
+
+// handleTxFlowModeMessage switches a client between per-message and
+// aggregated tx_flow, acknowledging with tx_flow.mode
+func handleTxFlowModeMessage(conn *websocket.Conn, params map[string]interface{}) {
+	send := wsSender(conn)
+
+	mode, _ := params["mode"].(string)
+	switch mode {
+	case txFlowModeAggregate, txFlowModeMessages:
+	default:
+		send(FiredancerMessage{Topic: "tx_flow", Key: "error",
+			Value: fmt.Sprintf("invalid mode %q: want %s or %s", mode, txFlowModeMessages, txFlowModeAggregate)})
+		return
+	}
+
+	a := GetTxFlowAggregator()
+	if a == nil {
+		send(FiredancerMessage{Topic: "tx_flow", Key: "error", Value: "aggregate mode is not available"})
+		return
+	}
+	a.SetAggregate(conn, mode == txFlowModeAggregate)
+	log.Printf("WebSocket client switched tx_flow to %s mode", mode)
+	send(FiredancerMessage{Topic: "tx_flow", Key: "mode", Value: mode})
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestTxFlowAggregatorBuckets(t *testing.T) {
+	clock := NewFakeClock(fakeClockStart)
+	a := NewTxFlowAggregator(clock)
+	conn := &websocket.Conn{}
+	a.SetAggregate(conn, true)
+	t.Cleanup(func() { a.SetAggregate(conn, false) })
+
+	clock.Advance(time.Second)
+	a.AddTransactions(4)
+	a.AddLog(&TransactionLog{Address: "0xAB", Topics: []string{"0x01"}})
+	a.AddLog(&TransactionLog{Address: "0xab", Topics: []string{"0x01"}, Sampled: true, SampleRate: 4})
+	clock.Advance(time.Second)
+
+	bucket, ok := a.flush(clock.Now())
+	if !ok {
+		t.Fatal("no bucket")
+	}
+	if bucket.Start != fakeClockStart.UnixMilli() || bucket.IntervalMs != 2000 {
+		t.Fatalf("bucket start=%d interval=%dms, want %d/2000", bucket.Start, bucket.IntervalMs, fakeClockStart.UnixMilli())
+	}
+	if bucket.Transactions != 4 || bucket.Logs != 5 || !bucket.Estimated {
+		t.Fatalf("bucket transactions=%d logs=%d estimated=%v, want 4/5/true", bucket.Transactions, bucket.Logs, bucket.Estimated)
+	}
+	if len(bucket.Contracts) != 1 || bucket.Contracts[0].Count != 5 {
+		t.Fatalf("contracts = %+v", bucket.Contracts)
+	}
+
+	// An empty interval sends nothing but restarts the bucket
+	clock.Advance(3 * time.Second)
+	if _, ok := a.flush(clock.Now()); ok {
+		t.Fatal("empty bucket sent")
+	}
+	clock.Advance(time.Second)
+	a.AddTransactions(1)
+	clock.Advance(time.Second)
+	if bucket, _ = a.flush(clock.Now()); bucket.IntervalMs != 2000 {
+		t.Fatalf("interval after an empty bucket = %dms, want 2000", bucket.IntervalMs)
+	}
+}
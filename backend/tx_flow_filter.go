@@ -0,0 +1,279 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+// Server-side tx_flow filters. By default every client receives one
+// tx_flow.transaction_log per included transaction. A client that sets a
+// filter stops receiving those and instead gets the monadLogs entries
+// matching it, so high-TPS deployments only send each client what it
+// renders:
+//
+//	-> {"topic": "tx_flow", "key": "filter", "params": {"addresses": ["0x..."], "topics": ["0xddf2..."], "min_value": "1000000"}}
+//	-> {"topic": "tx_flow", "key": "clear"}
+
+// maxTxFlowFilterEntries caps the addresses and topics of one filter
+const maxTxFlowFilterEntries = 1000
+
+// TxFlowFilter selects the logs forwarded to one client. Empty lists match
+// everything; a log must pass every set condition.
+type TxFlowFilter struct {
+	Addresses map[string]bool // Emitting contracts, lowercase
+	Topics    map[string]bool // topic0 (event signatures), lowercase
+	MinValue  *big.Int        // Minimum of the first data word (e.g. a Transfer amount); nil for none
+}
+
+// Matches reports whether a log passes the filter
+func (f *TxFlowFilter) Matches(txLog *TransactionLog) bool {
+	if len(f.Addresses) > 0 && !f.Addresses[strings.ToLower(txLog.Address)] {
+		return false
+	}
+	if len(f.Topics) > 0 && (len(txLog.Topics) == 0 || !f.Topics[strings.ToLower(txLog.Topics[0])]) {
+		return false
+	}
+	if f.MinValue != nil {
+		value, ok := logDataWord(txLog.Data)
+		if !ok || value.Cmp(f.MinValue) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Summary describes the filter for the client's acknowledgement
+func (f *TxFlowFilter) Summary() map[string]interface{} {
+	summary := map[string]interface{}{
+		"addresses": sortedSet(f.Addresses),
+		"topics":    sortedSet(f.Topics),
+		"min_value": nil,
+	}
+	if f.MinValue != nil {
+		summary["min_value"] = f.MinValue.String()
+	}
+	return summary
+}
+
+// sortedSet lists a set's members in order
+func sortedSet(set map[string]bool) []string {
+	members := make([]string, 0, len(set))
+	for member := range set {
+		members = append(members, member)
+	}
+	sort.Strings(members)
+	return members
+}
+
+// logDataWord decodes the first 32-byte word of hex log data
+func logDataWord(data string) (*big.Int, bool) {
+	data = strings.TrimPrefix(strings.TrimPrefix(data, "0x"), "0X")
+	if len(data) < 64 {
+		return nil, false
+	}
+	return new(big.Int).SetString(data[:64], 16)
+}
+
+// parseTxFlowFilter reads addresses, topics and min_value (a decimal or
+// 0x-prefixed hex string, or a number) from a filter message
+func parseTxFlowFilter(params map[string]interface{}) (*TxFlowFilter, error) {
+	f := &TxFlowFilter{Addresses: make(map[string]bool), Topics: make(map[string]bool)}
+
+	addresses, err := stringList(params["addresses"], "addresses")
+	if err != nil {
+		return nil, err
+	}
+	for _, address := range addresses {
+		addr, err := normalizeAddress(address)
+		if err != nil {
+			return nil, err
+		}
+		f.Addresses[addr] = true
+	}
+
+	topics, err := stringList(params["topics"], "topics")
+	if err != nil {
+		return nil, err
+	}
+	for _, topic := range topics {
+		topic = strings.ToLower(topic)
+		if len(topic) != 66 || !strings.HasPrefix(topic, "0x") {
+			return nil, fmt.Errorf("invalid topic %q: want a 32-byte 0x-prefixed hash", topic)
+		}
+		f.Topics[topic] = true
+	}
+
+	switch v := params["min_value"].(type) {
+	case nil:
+	case float64:
+		if v < 0 {
+			return nil, fmt.Errorf("min_value must not be negative")
+		}
+		f.MinValue, _ = new(big.Float).SetFloat64(v).Int(nil)
+	case string:
+		value, ok := new(big.Int).SetString(v, 0)
+		if !ok || value.Sign() < 0 {
+			return nil, fmt.Errorf("invalid min_value %q", v)
+		}
+		f.MinValue = value
+	default:
+		return nil, fmt.Errorf("min_value must be a string or number")
+	}
+	return f, nil
+}
+
+// stringList reads a JSON array of strings, at most maxTxFlowFilterEntries
+func stringList(v interface{}, name string) ([]string, error) {
+	if v == nil {
+		return nil, nil
+	}
+	list, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s must be a list", name)
+	}
+	if len(list) > maxTxFlowFilterEntries {
+		return nil, fmt.Errorf("%s lists at most %d entries", name, maxTxFlowFilterEntries)
+	}
+	values := make([]string, 0, len(list))
+	for _, item := range list {
+		s, ok := item.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s must be a list of strings", name)
+		}
+		values = append(values, s)
+	}
+	return values, nil
+}
+
+// txFlowClient is one client's tx_flow settings; clients without one get
+// the default per-transaction stream
+type txFlowClient struct {
+	filter    *TxFlowFilter // Per-log mode when set
+	aggregate bool          // Aggregated buckets instead of per-message delivery (see tx_flow_aggregate.go)
+}
+
+// Per-connection tx_flow settings
+var txFlowClients = struct {
+	sync.RWMutex
+	byConn map[*websocket.Conn]txFlowClient
+}{byConn: make(map[*websocket.Conn]txFlowClient)}
+
+// updateTxFlowClient applies change to a client's settings, dropping them
+// once they are back to the default
+func updateTxFlowClient(conn *websocket.Conn, change func(*txFlowClient)) {
+	txFlowClients.Lock()
+	defer txFlowClients.Unlock()
+	client := txFlowClients.byConn[conn]
+	change(&client)
+	if client.filter == nil && !client.aggregate {
+		delete(txFlowClients.byConn, conn)
+	} else {
+		txFlowClients.byConn[conn] = client
+	}
+}
+
+// setTxFlowFilter installs a client's filter, replacing any previous one
+func setTxFlowFilter(conn *websocket.Conn, f *TxFlowFilter) {
+	updateTxFlowClient(conn, func(client *txFlowClient) { client.filter = f })
+}
+
+// clearTxFlowFilter returns a client to the unfiltered tx_flow stream
+func clearTxFlowFilter(conn *websocket.Conn) {
+	updateTxFlowClient(conn, func(client *txFlowClient) { client.filter = nil })
+}
+
+// forgetTxFlowClient drops a disconnected client's settings
+func forgetTxFlowClient(conn *websocket.Conn) {
+	txFlowClients.Lock()
+	client := txFlowClients.byConn[conn]
+	delete(txFlowClients.byConn, conn)
+	txFlowClients.Unlock()
+
+	if a := GetTxFlowAggregator(); a != nil && client.aggregate {
+		a.Forget(conn)
+	}
+}
+
+// hasTxFlowClients reports whether any client left the default stream
+func hasTxFlowClients() bool {
+	txFlowClients.RLock()
+	defer txFlowClients.RUnlock()
+	return len(txFlowClients.byConn) > 0
+}
+
+// txFlowClientFor returns a client's settings
+func txFlowClientFor(conn *websocket.Conn) txFlowClient {
+	txFlowClients.RLock()
+	defer txFlowClients.RUnlock()
+	return txFlowClients.byConn[conn]
+}
+
+// skipsPerTxFlow reports whether a broadcast must skip a client: filtered
+// clients receive matching logs, and aggregating clients buckets, instead
+// of per-transaction messages
+func skipsPerTxFlow(conn *websocket.Conn, msg interface{}) bool {
+	if messageTopic(msg) != "tx_flow" {
+		return false
+	}
+	client := txFlowClientFor(conn)
+	return client.filter != nil || client.aggregate
+}
+
+// broadcastFilteredTxFlow sends a monadLogs entry to the clients whose
+// filter matches it, encoding it once for all of them
+func broadcastFilteredTxFlow(txLog *TransactionLog) {
+	if !hasTxFlowClients() {
+		return
+	}
+
+	value := map[string]interface{}{
+		"block_number":      txLog.BlockNumber,
+		"transaction_hash":  txLog.TransactionHash,
+		"transaction_index": txLog.TransactionIndex,
+		"address":           txLog.Address,
+		"topics":            txLog.Topics,
+		"data":              txLog.Data,
+		"timestamp":         txLog.Timestamp,
+	}
+	if txLog.Sampled {
+		value["sampled"] = true
+		value["sample_rate"] = txLog.SampleRate
+	}
+	msg := FiredancerMessage{Topic: "tx_flow", Key: "transaction_log", Value: value}
+
+	broadcastToClientsWhere(msg, func(conn *websocket.Conn) bool {
+		client := txFlowClientFor(conn)
+		return client.filter != nil && !client.aggregate && client.filter.Matches(txLog)
+	})
+}
+
+// handleTxFlowClientMessage handles tx_flow filter and clear frames,
+// acknowledging with tx_flow.filter (the filter in force, no value once
+// cleared) or tx_flow.error
+func handleTxFlowClientMessage(conn *websocket.Conn, key string, params map[string]interface{}) {
+	send := wsSender(conn)
+
+	switch key {
+	case "filter":
+		f, err := parseTxFlowFilter(params)
+		if err != nil {
+			send(FiredancerMessage{Topic: "tx_flow", Key: "error", Value: err.Error()})
+			return
+		}
+		setTxFlowFilter(conn, f)
+		log.Printf("WebSocket client set a tx_flow filter (%d addresses, %d topics, min value %v)",
+			len(f.Addresses), len(f.Topics), f.MinValue)
+		send(FiredancerMessage{Topic: "tx_flow", Key: "filter", Value: f.Summary()})
+	case "mode":
+		handleTxFlowModeMessage(conn, params)
+	case "clear":
+		clearTxFlowFilter(conn)
+		send(FiredancerMessage{Topic: "tx_flow", Key: "filter", Value: nil})
+	}
+}
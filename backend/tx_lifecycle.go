@@ -0,0 +1,310 @@
+package main
+
+import (
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TxLifecycle joins everything observed about one transaction across
+// stages: mempool insert → block inclusion → execution start/end → receipt
+type TxLifecycle struct {
+	Hash           string     `json:"hash"`
+	Source         string     `json:"source,omitempty"` // "rpc" or "p2p" from the mempool ring
+	To             string     `json:"to,omitempty"`
+	BlockNumber    int64      `json:"block_number,omitempty"`
+	TxIndex        int        `json:"tx_index"`
+	MempoolAt      *time.Time `json:"mempool_at,omitempty"`
+	IncludedAt     *time.Time `json:"included_at,omitempty"`
+	ExecStartAt    *time.Time `json:"exec_start_at,omitempty"`
+	ExecEndAt      *time.Time `json:"exec_end_at,omitempty"`
+	ReceiptAt      *time.Time `json:"receipt_at,omitempty"`
+	Success        *bool      `json:"success,omitempty"`
+	GasUsed        uint64     `json:"gas_used,omitempty"`
+	ExecDurationNs uint64     `json:"exec_duration_ns,omitempty"`
+}
+
+// Durations returns the elapsed time between observed stages in milliseconds
+func (l *TxLifecycle) Durations() map[string]float64 {
+	durations := make(map[string]float64)
+	between := func(key string, from, to *time.Time) {
+		if from != nil && to != nil && !to.Before(*from) {
+			durations[key] = float64(to.Sub(*from).Microseconds()) / 1000
+		}
+	}
+	between("mempool_to_inclusion_ms", l.MempoolAt, l.IncludedAt)
+	between("inclusion_to_receipt_ms", l.IncludedAt, l.ReceiptAt)
+	between("mempool_to_receipt_ms", l.MempoolAt, l.ReceiptAt)
+	if l.ExecDurationNs > 0 {
+		durations["execution_ms"] = float64(l.ExecDurationNs) / 1e6
+	} else {
+		between("execution_ms", l.ExecStartAt, l.ExecEndAt)
+	}
+	return durations
+}
+
+// latencySamples is a fixed-size ring of recent stage latencies
+type latencySamples struct {
+	values []int64
+	next   int
+	full   bool
+}
+
+func (s *latencySamples) add(ns int64) {
+	if s.values == nil {
+		s.values = make([]int64, 256)
+	}
+	s.values[s.next] = ns
+	s.next = (s.next + 1) % len(s.values)
+	if s.next == 0 {
+		s.full = true
+	}
+}
+
+func (s *latencySamples) average() int64 {
+	count := s.next
+	if s.full {
+		count = len(s.values)
+	}
+	if count == 0 {
+		return 0
+	}
+	var total int64
+	for _, v := range s.values[:count] {
+		total += v
+	}
+	return total / int64(count)
+}
+
+// TxLifecycleCorrelator keeps lifecycle records for recently seen transactions
+type TxLifecycleCorrelator struct {
+	mu         sync.Mutex
+	records    map[string]*TxLifecycle
+	order      []string // Insertion order for eviction
+	maxRecords int
+
+	// Recent stage latencies, sampled into the waterfall timing gauges
+	mempoolToInclusion latencySamples
+	execution          latencySamples
+	inclusionToReceipt latencySamples
+}
+
+// Global lifecycle correlator instance
+var (
+	txLifecycle     *TxLifecycleCorrelator
+	txLifecycleOnce sync.Once
+)
+
+// GetTxLifecycleCorrelator returns the global lifecycle correlator
+func GetTxLifecycleCorrelator() *TxLifecycleCorrelator {
+	txLifecycleOnce.Do(func() {
+		txLifecycle = &TxLifecycleCorrelator{
+			records:    make(map[string]*TxLifecycle),
+			order:      make([]string, 0, 1024),
+			maxRecords: 20000,
+		}
+	})
+	return txLifecycle
+}
+
+// normalizeTxHash lowercases a hash and ensures the 0x prefix
+func normalizeTxHash(hash string) string {
+	hash = strings.ToLower(strings.TrimSpace(hash))
+	if !strings.HasPrefix(hash, "0x") {
+		hash = "0x" + hash
+	}
+	return hash
+}
+
+// eventTxHash returns the transaction hash carried in an event header
+func eventTxHash(event ExecutionEvent) string {
+	var zero [32]byte
+	if event.Header.TransactionID == zero {
+		return ""
+	}
+	return "0x" + hex.EncodeToString(event.Header.TransactionID[:])
+}
+
+// recordLocked returns the record for hash, creating it if needed; caller holds c.mu
+func (c *TxLifecycleCorrelator) recordLocked(hash string) *TxLifecycle {
+	hash = normalizeTxHash(hash)
+	if record, ok := c.records[hash]; ok {
+		return record
+	}
+
+	record := &TxLifecycle{Hash: hash}
+	c.records[hash] = record
+	c.order = append(c.order, hash)
+	if len(c.order) > c.maxRecords {
+		evict := len(c.order) - c.maxRecords
+		for _, old := range c.order[:evict] {
+			delete(c.records, old)
+		}
+		c.order = append(c.order[:0], c.order[evict:]...)
+	}
+	return record
+}
+
+// OnMempoolInsert records a transaction entering the mempool
+func (c *TxLifecycleCorrelator) OnMempoolInsert(hash, source string) {
+	if hash == "" {
+		return
+	}
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	record := c.recordLocked(hash)
+	if record.MempoolAt == nil {
+		record.MempoolAt = &now
+		record.Source = source
+	}
+}
+
+// OnIncluded records a transaction's inclusion in a block
+func (c *TxLifecycleCorrelator) OnIncluded(hash string, blockNumber int64, index int) {
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	record := c.recordLocked(hash)
+	if record.IncludedAt != nil {
+		return
+	}
+	record.IncludedAt = &now
+	record.BlockNumber = blockNumber
+	record.TxIndex = index
+
+	if record.MempoolAt != nil {
+		c.mempoolToInclusion.add(now.Sub(*record.MempoolAt).Nanoseconds())
+		GetMonadWaterfallMetrics().MempoolPropagationLatencyNs.Store(c.mempoolToInclusion.average())
+	}
+}
+
+// OnExecStart records the start of a transaction's execution
+func (c *TxLifecycleCorrelator) OnExecStart(hash, to string) {
+	if hash == "" {
+		return
+	}
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	record := c.recordLocked(hash)
+	if record.ExecStartAt == nil {
+		record.ExecStartAt = &now
+		record.To = strings.ToLower(to)
+	}
+}
+
+// OnExecEnd records the end of a transaction's execution
+func (c *TxLifecycleCorrelator) OnExecEnd(hash string, success bool, gasUsed, durationNs uint64) {
+	if hash == "" {
+		return
+	}
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	record := c.recordLocked(hash)
+	record.ExecEndAt = &now
+	record.Success = &success
+	record.GasUsed = gasUsed
+	record.ExecDurationNs = durationNs
+
+	execNs := int64(durationNs)
+	if execNs == 0 && record.ExecStartAt != nil {
+		execNs = now.Sub(*record.ExecStartAt).Nanoseconds()
+	}
+	if execNs > 0 {
+		c.execution.add(execNs)
+		avg := c.execution.average()
+		GetMonadWaterfallMetrics().ExecutionLatencyNs.Store(avg)
+		GetWaterfallMetrics().ExecLatencyNs.Store(avg)
+	}
+}
+
+// OnReceipt records a transaction's receipt becoming available
+func (c *TxLifecycleCorrelator) OnReceipt(hash string, success bool, gasUsed uint64) {
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	record := c.recordLocked(hash)
+	if record.ReceiptAt != nil {
+		return
+	}
+	record.ReceiptAt = &now
+	if record.Success == nil {
+		record.Success = &success
+	}
+	if record.GasUsed == 0 {
+		record.GasUsed = gasUsed
+	}
+
+	if record.IncludedAt != nil {
+		c.inclusionToReceipt.add(now.Sub(*record.IncludedAt).Nanoseconds())
+		GetMonadWaterfallMetrics().FinalityLatencyNs.Store(c.inclusionToReceipt.average())
+	}
+}
+
+// Get returns a copy of the lifecycle record for hash
+func (c *TxLifecycleCorrelator) Get(hash string) (TxLifecycle, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	record, ok := c.records[normalizeTxHash(hash)]
+	if !ok {
+		return TxLifecycle{}, false
+	}
+	return *record, true
+}
+
+// Stats returns the number of tracked records and current stage averages
+func (c *TxLifecycleCorrelator) Stats() map[string]interface{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return map[string]interface{}{
+		"tracked_transactions":        len(c.records),
+		"avg_mempool_to_inclusion_ms": float64(c.mempoolToInclusion.average()) / 1e6,
+		"avg_execution_ms":            float64(c.execution.average()) / 1e6,
+		"avg_inclusion_to_receipt_ms": float64(c.inclusionToReceipt.average()) / 1e6,
+	}
+}
+
+// TxLifecycleResponse is the body of /api/v1/tx/:hash/lifecycle
+type TxLifecycleResponse struct {
+	Lifecycle TxLifecycle        `json:"lifecycle"`
+	Durations map[string]float64 `json:"durations"` // Milliseconds between observed stages
+}
+
+// TxNotTrackedError is the 404 body for a hash the correlator has not seen
+type TxNotTrackedError struct {
+	APIError
+	Hash string `json:"hash"`
+}
+
+// handleTxLifecycle returns the lifecycle record of one transaction
+func handleTxLifecycle(c *gin.Context) {
+	hash := c.Param("hash")
+	record, ok := GetTxLifecycleCorrelator().Get(hash)
+	if !ok {
+		c.JSON(http.StatusNotFound, TxNotTrackedError{APIError{Error: "Transaction not tracked"}, normalizeTxHash(hash)})
+		return
+	}
+
+	c.JSON(http.StatusOK, TxLifecycleResponse{
+		Lifecycle: record,
+		Durations: record.Durations(),
+	})
+}
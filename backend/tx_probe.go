@@ -0,0 +1,448 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"monad-dashboard/hexutil"
+	"monad-dashboard/txsign"
+)
+
+// The inclusion probe measures what users actually experience: with
+// TX_PROBE_KEY (or TX_PROBE_KEY_FILE) set, it signs a zero-value transfer
+// to its own address every TX_PROBE_INTERVAL (default 1m), submits it with
+// eth_sendRawTransaction and follows it through pending (visible to
+// eth_getTransactionByHash or the newPendingTransactions subscription),
+// included (receipt or block notification) and finalized. Each probe is
+// recorded as the probe_*_ms history series, and probe_slo_met marks whether
+// it was included within TX_PROBE_SLO (default 2s). The key only needs
+// enough MON for gas.
+
+// maxProbeResults bounds the probe results kept, a day at the default interval
+const maxProbeResults = 1440
+
+// probePollInterval is how often a submitted probe's progress is checked
+const probePollInterval = 100 * time.Millisecond
+
+// probeGas is the gas limit of a plain transfer
+const probeGas = 21000
+
+// Probe outcomes
+const (
+	probeFinalized = "finalized"
+	probeIncluded  = "included" // Timed out waiting for finalization
+	probeTimeout   = "timeout"  // Never included within TX_PROBE_TIMEOUT
+	probeError     = "error"    // Not submitted; excluded from the SLO
+)
+
+// ProbeResult is one synthetic transaction's journey
+type ProbeResult struct {
+	Hash        string    `json:"hash,omitempty"`
+	Nonce       uint64    `json:"nonce"`
+	SubmittedAt time.Time `json:"submitted_at"`
+	Status      string    `json:"status"`
+	Error       string    `json:"error,omitempty"`
+	Block       int64     `json:"block,omitempty"`
+	PendingMs   *float64  `json:"pending_ms,omitempty"`   // Submit to first seen pending
+	InclusionMs *float64  `json:"inclusion_ms,omitempty"` // Submit to included in a block
+	FinalityMs  *float64  `json:"finality_ms,omitempty"`  // Submit to finalized
+	MetSLO      bool      `json:"met_slo"`
+}
+
+// InclusionProbe periodically submits and times synthetic transactions
+type InclusionProbe struct {
+	key       *txsign.Key
+	interval  time.Duration
+	timeout   time.Duration
+	slo       time.Duration
+	objective float64
+
+	mu      sync.RWMutex
+	results []ProbeResult // Oldest first
+	chainID uint64
+}
+
+// Global inclusion probe
+var (
+	inclusionProbe   *InclusionProbe
+	inclusionProbeMu sync.RWMutex
+)
+
+// InitializeInclusionProbe starts the probe when a key is configured
+func InitializeInclusionProbe() *InclusionProbe {
+	value := os.Getenv("TX_PROBE_KEY")
+	if path := os.Getenv("TX_PROBE_KEY_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			log.Printf("Inclusion probe disabled: %v", err)
+			return nil
+		}
+		value = string(data)
+	}
+	if value == "" {
+		return nil
+	}
+	key, err := txsign.ParseKey(value)
+	if err != nil {
+		log.Printf("Inclusion probe disabled: %v", err)
+		return nil
+	}
+
+	probe := &InclusionProbe{
+		key:       key,
+		interval:  probeDurationEnv("TX_PROBE_INTERVAL", time.Minute),
+		timeout:   probeDurationEnv("TX_PROBE_TIMEOUT", time.Minute),
+		slo:       probeDurationEnv("TX_PROBE_SLO", 2*time.Second),
+		objective: 0.99,
+	}
+	if value := os.Getenv("TX_PROBE_SLO_OBJECTIVE"); value != "" {
+		if objective, err := strconv.ParseFloat(value, 64); err == nil && objective > 0 && objective < 1 {
+			probe.objective = objective
+		} else {
+			log.Printf("Invalid TX_PROBE_SLO_OBJECTIVE %q, using %.3f", value, probe.objective)
+		}
+	}
+	go probe.run()
+
+	inclusionProbeMu.Lock()
+	defer inclusionProbeMu.Unlock()
+	inclusionProbe = probe
+	log.Printf("Inclusion probe: %s every %s (SLO %s at %.1f%%)", key.Address(), probe.interval, probe.slo, probe.objective*100)
+	return probe
+}
+
+// GetInclusionProbe returns the global inclusion probe, nil when disabled
+func GetInclusionProbe() *InclusionProbe {
+	inclusionProbeMu.RLock()
+	defer inclusionProbeMu.RUnlock()
+	return inclusionProbe
+}
+
+// probeDurationEnv reads a positive duration from name
+func probeDurationEnv(name string, def time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return def
+	}
+	if parsed, err := time.ParseDuration(value); err == nil && parsed > 0 {
+		return parsed
+	}
+	log.Printf("Invalid %s %q, using %s", name, value, def)
+	return def
+}
+
+// run probes every interval; a probe still in flight delays the next
+func (p *InclusionProbe) run() {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-shutdownContext().Done():
+			return
+		case <-ticker.C:
+			result := p.probe()
+			p.record(result)
+		}
+	}
+}
+
+// probe submits one transaction and follows it until finalized or timed out
+func (p *InclusionProbe) probe() ProbeResult {
+	result := ProbeResult{SubmittedAt: time.Now().UTC(), Status: probeError}
+	client := GetMonadClient()
+	if client == nil {
+		result.Error = "monad client not initialized"
+		return result
+	}
+
+	raw, err := p.sign(client, &result)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	submitted := time.Now()
+	result.SubmittedAt = submitted.UTC()
+	var hash string
+	if err := client.callResult("eth_sendRawTransaction", []interface{}{hexutil.EncodeBytes(raw)}, &hash); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Hash = normalizeTxHash(hash)
+	result.Status = probeTimeout
+
+	elapsed := func(at time.Time) *float64 {
+		ms := float64(at.Sub(submitted).Microseconds()) / 1000
+		if ms < 0 {
+			ms = 0
+		}
+		return &ms
+	}
+	deadline := submitted.Add(p.timeout)
+	ticker := time.NewTicker(probePollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-shutdownContext().Done():
+			return result
+		case now := <-ticker.C:
+			// The subscription sees stages before polling does
+			lifecycle, _ := GetTxLifecycleCorrelator().Get(result.Hash)
+
+			if result.PendingMs == nil {
+				if lifecycle.MempoolAt != nil {
+					result.PendingMs = elapsed(*lifecycle.MempoolAt)
+				} else if p.pending(client, result.Hash) {
+					result.PendingMs = elapsed(now)
+				}
+			}
+			if result.InclusionMs == nil {
+				if block, ok := p.included(client, result.Hash); ok {
+					result.Block, result.Status = block, probeIncluded
+					at := now
+					if lifecycle.IncludedAt != nil && lifecycle.IncludedAt.Before(now) {
+						at = *lifecycle.IncludedAt
+					}
+					result.InclusionMs = elapsed(at)
+					result.MetSLO = at.Sub(submitted) <= p.slo
+					if result.PendingMs == nil || *result.PendingMs > *result.InclusionMs {
+						result.PendingMs = result.InclusionMs
+					}
+				}
+			}
+			if result.InclusionMs != nil && p.finalized(client, result.Block) {
+				result.Status = probeFinalized
+				result.FinalityMs = elapsed(now)
+				return result
+			}
+			if now.After(deadline) {
+				return result
+			}
+		}
+	}
+}
+
+// sign builds the next probe transaction at the pending nonce and current
+// gas price
+func (p *InclusionProbe) sign(client *MonadClient, result *ProbeResult) ([]byte, error) {
+	p.mu.RLock()
+	chainID := p.chainID
+	p.mu.RUnlock()
+	if chainID == 0 {
+		id, err := client.GetChainID()
+		if err != nil {
+			return nil, err
+		}
+		p.mu.Lock()
+		p.chainID = id
+		p.mu.Unlock()
+		chainID = id
+	}
+
+	var nonceHex, gasPriceHex string
+	if err := client.callResult("eth_getTransactionCount", []interface{}{p.key.Address(), "pending"}, &nonceHex); err != nil {
+		return nil, err
+	}
+	if err := client.callResult("eth_gasPrice", []interface{}{}, &gasPriceHex); err != nil {
+		return nil, err
+	}
+	nonce, err := hexutil.DecodeUint64(nonceHex)
+	if err != nil {
+		return nil, fmt.Errorf("eth_getTransactionCount: %w", err)
+	}
+	gasPrice, err := hexutil.DecodeBig(gasPriceHex)
+	if err != nil {
+		return nil, fmt.Errorf("eth_gasPrice: %w", err)
+	}
+	result.Nonce = nonce
+
+	raw, _, err := p.key.Sign(txsign.Tx{
+		Nonce:    nonce,
+		GasPrice: gasPrice,
+		Gas:      probeGas,
+		To:       p.key.Address(),
+		Value:    new(big.Int),
+		ChainID:  chainID,
+	})
+	return raw, err
+}
+
+// pending reports whether the node knows the transaction
+func (p *InclusionProbe) pending(client *MonadClient, hash string) bool {
+	var tx map[string]interface{}
+	return client.callResult("eth_getTransactionByHash", []interface{}{hash}, &tx) == nil
+}
+
+// included returns the block of the transaction's receipt
+func (p *InclusionProbe) included(client *MonadClient, hash string) (int64, bool) {
+	var receipt struct {
+		BlockNumber string `json:"blockNumber"`
+	}
+	if err := client.callResult("eth_getTransactionReceipt", []interface{}{hash}, &receipt); err != nil {
+		if !errors.Is(err, errEmptyResult) {
+			log.Printf("Inclusion probe: %v", err)
+		}
+		return 0, false
+	}
+	block, err := hexutil.DecodeInt64(receipt.BlockNumber)
+	return block, err == nil
+}
+
+// finalized reports whether block is finalized, from the head tracker when
+// it follows finalization and over RPC otherwise
+func (p *InclusionProbe) finalized(client *MonadClient, block int64) bool {
+	if ht := GetHeadTracker(); ht != nil {
+		if _, finalized := ht.Heads(); finalized >= block {
+			return true
+		}
+	}
+	finalized, err := client.GetBlockNumberByTag("finalized")
+	return err == nil && finalized >= block
+}
+
+// record keeps a result and publishes it to the history series
+func (p *InclusionProbe) record(result ProbeResult) {
+	p.mu.Lock()
+	p.results = append(p.results, result)
+	if len(p.results) > maxProbeResults {
+		p.results = append(p.results[:0], p.results[len(p.results)-maxProbeResults:]...)
+	}
+	p.mu.Unlock()
+
+	if result.Status == probeError {
+		log.Printf("Inclusion probe failed: %s", result.Error)
+	}
+	store := GetHistoryStore()
+	if store == nil || result.Status == probeError {
+		return
+	}
+	at := result.SubmittedAt
+	for name, value := range map[string]*float64{
+		"probe_pending_ms":   result.PendingMs,
+		"probe_inclusion_ms": result.InclusionMs,
+		"probe_finality_ms":  result.FinalityMs,
+	} {
+		if value != nil {
+			store.Record(name, at, *value)
+		}
+	}
+	met := 0.0
+	if result.MetSLO {
+		met = 1
+	}
+	store.Record("probe_slo_met", at, met)
+}
+
+// ProbeSLOWindow is SLO attainment over one window
+type ProbeSLOWindow struct {
+	Window     string   `json:"window"`
+	Probes     int      `json:"probes"` // Submitted probes; errors are excluded
+	Met        int      `json:"met"`
+	Attainment *float64 `json:"attainment"` // Met / probes, null without probes
+	// BudgetRemaining is the share of the error budget (1 - objective) left
+	BudgetRemaining *float64 `json:"budget_remaining"`
+}
+
+// InclusionProbeResponse is the body of /api/v1/probe/inclusion
+type InclusionProbeResponse struct {
+	Enabled         bool                      `json:"enabled"`
+	Address         string                    `json:"address,omitempty"`
+	IntervalSeconds float64                   `json:"interval_seconds,omitempty"`
+	TimeoutSeconds  float64                   `json:"timeout_seconds,omitempty"`
+	SLOMs           float64                   `json:"slo_ms,omitempty"`
+	Objective       float64                   `json:"objective,omitempty"`
+	SLO             []ProbeSLOWindow          `json:"slo"`
+	Latency         []PropagationDistribution `json:"latency"` // Per stage over the kept probes: pending, inclusion, finality
+	Outcomes        map[string]int            `json:"outcomes"`
+	Recent          []ProbeResult             `json:"recent"` // Newest first
+}
+
+// Report summarizes the kept results
+func (p *InclusionProbe) Report(now time.Time, limit int) InclusionProbeResponse {
+	p.mu.RLock()
+	results := append([]ProbeResult(nil), p.results...)
+	p.mu.RUnlock()
+
+	response := InclusionProbeResponse{
+		Enabled:         true,
+		Address:         p.key.Address(),
+		IntervalSeconds: p.interval.Seconds(),
+		TimeoutSeconds:  p.timeout.Seconds(),
+		SLOMs:           float64(p.slo.Milliseconds()),
+		Objective:       p.objective,
+		SLO:             make([]ProbeSLOWindow, 0, 3),
+		Latency:         make([]PropagationDistribution, 0, 3),
+		Outcomes:        map[string]int{probeFinalized: 0, probeIncluded: 0, probeTimeout: 0, probeError: 0},
+		Recent:          make([]ProbeResult, 0, limit),
+	}
+	for _, window := range []struct {
+		name string
+		span time.Duration
+	}{{"1h", time.Hour}, {"6h", 6 * time.Hour}, {"24h", 24 * time.Hour}} {
+		slo := ProbeSLOWindow{Window: window.name}
+		for _, result := range results {
+			if result.Status == probeError || now.Sub(result.SubmittedAt) > window.span {
+				continue
+			}
+			slo.Probes++
+			if result.MetSLO {
+				slo.Met++
+			}
+		}
+		if slo.Probes > 0 {
+			attainment := float64(slo.Met) / float64(slo.Probes)
+			budget := 1 - (1-attainment)/(1-p.objective)
+			slo.Attainment, slo.BudgetRemaining = &attainment, &budget
+		}
+		response.SLO = append(response.SLO, slo)
+	}
+
+	stages := map[string][]float64{}
+	for _, result := range results {
+		response.Outcomes[result.Status]++
+		for stage, value := range map[string]*float64{"pending": result.PendingMs, "inclusion": result.InclusionMs, "finality": result.FinalityMs} {
+			if value != nil {
+				stages[stage] = append(stages[stage], *value)
+			}
+		}
+	}
+	for _, stage := range []string{"pending", "inclusion", "finality"} {
+		response.Latency = append(response.Latency, propagationDistribution(stage, stages[stage]))
+	}
+
+	for i := len(results) - 1; i >= 0 && len(response.Recent) < limit; i-- {
+		response.Recent = append(response.Recent, results[i])
+	}
+	return response
+}
+
+// handleInclusionProbe reports the synthetic transaction latencies and SLO
+// attainment (?limit= recent probes, default 20)
+func handleInclusionProbe(c *gin.Context) {
+	probe := GetInclusionProbe()
+	if probe == nil {
+		c.JSON(http.StatusOK, InclusionProbeResponse{
+			SLO:      []ProbeSLOWindow{},
+			Latency:  []PropagationDistribution{},
+			Outcomes: map[string]int{},
+			Recent:   []ProbeResult{},
+		})
+		return
+	}
+	limit := 20
+	if value := strings.TrimSpace(c.Query("limit")); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n >= 0 && n <= maxProbeResults {
+			limit = n
+		}
+	}
+	c.JSON(http.StatusOK, probe.Report(time.Now(), limit))
+}
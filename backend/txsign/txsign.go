@@ -0,0 +1,281 @@
+// Package txsign signs legacy Ethereum transactions (EIP-155) with a
+// secp256k1 key and recovers their sender.
+//
+// It covers what the dashboard's inclusion probe and the mock node need:
+// zero-value transfers signed by one key and checked by the other. The
+// curve arithmetic and RFC 6979 signing come from decred's secp256k1.
+package txsign
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+	"golang.org/x/crypto/sha3"
+)
+
+// Errors
+var (
+	ErrInvalidKey       = errors.New("private key must be 32 bytes within the curve order")
+	ErrInvalidAddress   = errors.New("address must be 20 bytes of hex")
+	ErrInvalidTx        = errors.New("malformed legacy transaction")
+	ErrInvalidSignature = errors.New("invalid transaction signature")
+)
+
+var bigZero = new(big.Int)
+
+// keccak returns the Keccak-256 hash of data
+func keccak(data ...[]byte) []byte {
+	hash := sha3.NewLegacyKeccak256()
+	for _, d := range data {
+		hash.Write(d)
+	}
+	return hash.Sum(nil)
+}
+
+// pubkeyAddress returns the 0x-prefixed lowercase address of a public key
+func pubkeyAddress(pub *secp256k1.PublicKey) string {
+	return "0x" + hex.EncodeToString(keccak(pub.SerializeUncompressed()[1:])[12:])
+}
+
+// Key is a secp256k1 private key
+type Key struct {
+	priv    *secp256k1.PrivateKey
+	address string
+}
+
+// ParseKey decodes a hex private key, with or without 0x prefix
+func ParseKey(s string) (*Key, error) {
+	raw, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(s), "0x"))
+	if err != nil || len(raw) != 32 {
+		return nil, ErrInvalidKey
+	}
+	var d secp256k1.ModNScalar
+	if overflow := d.SetByteSlice(raw); overflow || d.IsZero() {
+		return nil, ErrInvalidKey
+	}
+	priv := secp256k1.NewPrivateKey(&d)
+	return &Key{priv: priv, address: pubkeyAddress(priv.PubKey())}, nil
+}
+
+// Address returns the key's 0x-prefixed lowercase address
+func (k *Key) Address() string {
+	return k.address
+}
+
+// Tx is a legacy transaction
+type Tx struct {
+	Nonce    uint64
+	GasPrice *big.Int
+	Gas      uint64
+	To       string // Empty for contract creation
+	Value    *big.Int
+	Data     []byte
+	ChainID  uint64 // Zero signs without replay protection
+}
+
+// fields returns the RLP items shared by the signing payload and the raw
+// transaction
+func (tx Tx) fields() ([][]byte, error) {
+	var to []byte
+	if tx.To != "" {
+		decoded, err := hex.DecodeString(strings.TrimPrefix(tx.To, "0x"))
+		if err != nil || len(decoded) != 20 {
+			return nil, ErrInvalidAddress
+		}
+		to = decoded
+	}
+	gasPrice, value := tx.GasPrice, tx.Value
+	if gasPrice == nil {
+		gasPrice = bigZero
+	}
+	if value == nil {
+		value = bigZero
+	}
+	return [][]byte{
+		rlpUint(new(big.Int).SetUint64(tx.Nonce)),
+		rlpUint(gasPrice),
+		rlpUint(new(big.Int).SetUint64(tx.Gas)),
+		rlpBytes(to),
+		rlpUint(value),
+		rlpBytes(tx.Data),
+	}, nil
+}
+
+// signingHash returns the hash a legacy transaction's signature covers
+func (tx Tx) signingHash() ([]byte, error) {
+	fields, err := tx.fields()
+	if err != nil {
+		return nil, err
+	}
+	if tx.ChainID != 0 {
+		fields = append(fields, rlpUint(new(big.Int).SetUint64(tx.ChainID)), rlpBytes(nil), rlpBytes(nil))
+	}
+	return keccak(rlpList(fields...)), nil
+}
+
+// Sign returns the raw signed transaction and its 0x-prefixed hash
+func (k *Key) Sign(tx Tx) ([]byte, string, error) {
+	digest, err := tx.signingHash()
+	if err != nil {
+		return nil, "", err
+	}
+	// <27 + recovery id><32-byte r><32-byte s>, with a low s and an RFC 6979 nonce
+	sig := ecdsa.SignCompact(k.priv, digest, false)
+	recovery := sig[0] - 27
+	r := new(big.Int).SetBytes(sig[1:33])
+	s := new(big.Int).SetBytes(sig[33:65])
+
+	v := new(big.Int).SetUint64(uint64(recovery) + 27)
+	if tx.ChainID != 0 {
+		v.SetUint64(tx.ChainID)
+		v.Lsh(v, 1).Add(v, big.NewInt(int64(35+recovery)))
+	}
+	fields, _ := tx.fields()
+	raw := rlpList(append(fields, rlpUint(v), rlpUint(r), rlpUint(s))...)
+	return raw, Hash(raw), nil
+}
+
+// Hash returns the 0x-prefixed transaction hash of a raw transaction
+func Hash(raw []byte) string {
+	return "0x" + hex.EncodeToString(keccak(raw))
+}
+
+// Decode parses a raw legacy transaction and recovers its sender
+func Decode(raw []byte) (Tx, string, error) {
+	isList, content, rest, err := rlpItem(raw)
+	if err != nil || !isList || len(rest) != 0 {
+		return Tx{}, "", ErrInvalidTx
+	}
+	items := make([][]byte, 0, 9)
+	for len(content) > 0 {
+		var item []byte
+		isList, item, content, err = rlpItem(content)
+		if err != nil || isList {
+			return Tx{}, "", ErrInvalidTx
+		}
+		items = append(items, item)
+	}
+	if len(items) != 9 || len(items[0]) > 8 || len(items[2]) > 8 || (len(items[3]) != 0 && len(items[3]) != 20) {
+		return Tx{}, "", ErrInvalidTx
+	}
+
+	tx := Tx{
+		Nonce:    new(big.Int).SetBytes(items[0]).Uint64(),
+		GasPrice: new(big.Int).SetBytes(items[1]),
+		Gas:      new(big.Int).SetBytes(items[2]).Uint64(),
+		Value:    new(big.Int).SetBytes(items[4]),
+		Data:     items[5],
+	}
+	if len(items[3]) == 20 {
+		tx.To = "0x" + hex.EncodeToString(items[3])
+	}
+
+	v := new(big.Int).SetBytes(items[6])
+	var recovery uint
+	switch {
+	case v.Cmp(big.NewInt(35)) >= 0:
+		offset := new(big.Int).Sub(v, big.NewInt(35))
+		tx.ChainID = new(big.Int).Rsh(offset, 1).Uint64()
+		recovery = offset.Bit(0)
+	case v.Cmp(big.NewInt(27)) == 0 || v.Cmp(big.NewInt(28)) == 0:
+		recovery = uint(v.Uint64() - 27)
+	default:
+		return Tx{}, "", ErrInvalidSignature
+	}
+
+	digest, err := tx.signingHash()
+	if err != nil {
+		return Tx{}, "", err
+	}
+	from, err := recoverAddress(digest, items[7], items[8], recovery)
+	if err != nil {
+		return Tx{}, "", err
+	}
+	return tx, from, nil
+}
+
+// recoverAddress returns the address whose key signed digest
+func recoverAddress(digest, r, s []byte, recovery uint) (string, error) {
+	var rs, ss secp256k1.ModNScalar
+	if len(r) > 32 || len(s) > 32 || rs.SetByteSlice(r) || rs.IsZero() ||
+		ss.SetByteSlice(s) || ss.IsZero() || ss.IsOverHalfOrder() {
+		return "", ErrInvalidSignature
+	}
+	sig := make([]byte, 65)
+	sig[0] = byte(27 + recovery)
+	rs.PutBytesUnchecked(sig[1:33])
+	ss.PutBytesUnchecked(sig[33:65])
+	pub, _, err := ecdsa.RecoverCompact(sig, digest)
+	if err != nil {
+		return "", ErrInvalidSignature
+	}
+	return pubkeyAddress(pub), nil
+}
+
+// rlpUint encodes an unsigned integer
+func rlpUint(v *big.Int) []byte {
+	return rlpBytes(v.Bytes())
+}
+
+// rlpBytes encodes a byte string
+func rlpBytes(b []byte) []byte {
+	if len(b) == 1 && b[0] < 0x80 {
+		return []byte{b[0]}
+	}
+	return append(rlpHeader(0x80, len(b)), b...)
+}
+
+// rlpList encodes a list of encoded items
+func rlpList(items ...[]byte) []byte {
+	var payload []byte
+	for _, item := range items {
+		payload = append(payload, item...)
+	}
+	return append(rlpHeader(0xc0, len(payload)), payload...)
+}
+
+func rlpHeader(offset byte, length int) []byte {
+	if length < 56 {
+		return []byte{offset + byte(length)}
+	}
+	size := new(big.Int).SetInt64(int64(length)).Bytes()
+	return append([]byte{offset + 55 + byte(len(size))}, size...)
+}
+
+// rlpItem splits the first item off b
+func rlpItem(b []byte) (isList bool, content, rest []byte, err error) {
+	if len(b) == 0 {
+		return false, nil, nil, ErrInvalidTx
+	}
+	prefix := b[0]
+	header, length := 1, 0
+	switch {
+	case prefix < 0x80:
+		return false, b[:1], b[1:], nil
+	case prefix < 0xb8:
+		length = int(prefix - 0x80)
+	case prefix < 0xc0:
+		header, length, err = rlpLongLength(b, int(prefix-0xb7))
+	case prefix < 0xf8:
+		isList, length = true, int(prefix-0xc0)
+	default:
+		isList = true
+		header, length, err = rlpLongLength(b, int(prefix-0xf7))
+	}
+	if err != nil || len(b) < header+length {
+		return false, nil, nil, ErrInvalidTx
+	}
+	return isList, b[header : header+length], b[header+length:], nil
+}
+
+func rlpLongLength(b []byte, size int) (int, int, error) {
+	if size > 4 || len(b) < 1+size {
+		return 0, 0, fmt.Errorf("%w: length of %d bytes", ErrInvalidTx, size)
+	}
+	return 1 + size, int(new(big.Int).SetBytes(b[1 : 1+size]).Int64()), nil
+}
@@ -0,0 +1,59 @@
+package txsign
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+)
+
+// TestSignEIP155 signs the example transaction of EIP-155 and recovers its
+// sender
+func TestSignEIP155(t *testing.T) {
+	key, err := ParseKey("0x4646464646464646464646464646464646464646464646464646464646464646")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := Tx{
+		Nonce:    9,
+		GasPrice: big.NewInt(20_000_000_000),
+		Gas:      21000,
+		To:       "0x3535353535353535353535353535353535353535",
+		Value:    new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil),
+		ChainID:  1,
+	}
+	raw, hash, err := key.Sign(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "f86c098504a817c800825208943535353535353535353535353535353535353535880de0b6b3a76400008025a028ef61340bd939bc2195fe537567866003e1a15d3c71ff63e1590620aa636276a067cbe9d8997f761aecb703304b3800ccf555c9f3dc64214b297fb1966a3b6d83"
+	if got := hex.EncodeToString(raw); got != want {
+		t.Fatalf("raw = %s, want %s", got, want)
+	}
+	if hash != "0x33469b22e9f636356c4160a87eb19df52b7412e8eac32a4a55ffe88ea8350788" {
+		t.Errorf("hash = %s", hash)
+	}
+
+	decoded, from, err := Decode(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if from != key.Address() || from != "0x9d8a62f656a8d1615c1294fd71e9cfb3e4855a4f" {
+		t.Errorf("from = %s, key address %s", from, key.Address())
+	}
+	if decoded.ChainID != 1 || decoded.Nonce != 9 || decoded.To != tx.To {
+		t.Errorf("decoded %+v", decoded)
+	}
+}
+
+// TestParseKeyRange rejects zero and keys at or above the curve order
+func TestParseKeyRange(t *testing.T) {
+	for _, key := range []string{
+		"0000000000000000000000000000000000000000000000000000000000000000",
+		"fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141",
+		"4646",
+	} {
+		if _, err := ParseKey(key); err != ErrInvalidKey {
+			t.Errorf("ParseKey(%s) = %v, want ErrInvalidKey", key, err)
+		}
+	}
+}
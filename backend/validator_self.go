@@ -0,0 +1,104 @@
+package main
+
+import (
+	"math/big"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SkipRate covers the local validator's scheduled slots since startup.
+// Slots are only known while the control panel serves the leader schedule.
+type SkipRate struct {
+	ScheduledSlots int64    `json:"scheduled_slots"`
+	ProducedSlots  int64    `json:"produced_slots"`
+	SkippedSlots   int64    `json:"skipped_slots"`
+	SkipRate       *float64 `json:"skip_rate"` // Null before the first scheduled slot
+	RecentSkipped  []int64  `json:"recent_skipped"`
+	ScheduleSource string   `json:"schedule_source"`
+}
+
+// SkipRate returns the outcome of the local validator's scheduled slots
+func (lt *LeaderTracker) SkipRate() SkipRate {
+	lt.mu.RLock()
+	defer lt.mu.RUnlock()
+
+	rate := SkipRate{
+		ScheduledSlots: lt.scheduledSlots,
+		ProducedSlots:  lt.scheduledSlots - lt.skippedSlots,
+		SkippedSlots:   lt.skippedSlots,
+		RecentSkipped:  append([]int64{}, lt.recentSkipped...),
+		ScheduleSource: lt.scheduleSource,
+	}
+	if lt.scheduledSlots > 0 {
+		skip := float64(lt.skippedSlots) / float64(lt.scheduledSlots)
+		rate.SkipRate = &skip
+	}
+	return rate
+}
+
+// AddressSource reports where the local validator address came from
+func (lt *LeaderTracker) AddressSource() string {
+	lt.mu.RLock()
+	defer lt.mu.RUnlock()
+	return lt.addressSource
+}
+
+// SelfValidatorResponse is the body of /api/v1/validators/self
+type SelfValidatorResponse struct {
+	Address        string             `json:"address"`        // Empty when unknown
+	AddressSource  string             `json:"address_source"` // env (MONAD_VALIDATOR_ADDRESS), node.toml (beneficiary) or unknown
+	Identity       NodeIdentity       `json:"identity"`
+	InPeerSet      bool               `json:"in_peer_set"`
+	Stake          *int64             `json:"stake"`        // Activated stake of the peers entry, MON
+	BalanceGwei    *big.Int           `json:"balance_gwei"` // Null when unknown
+	IsLeader       bool               `json:"is_leader"`
+	NextLeaderSlot *int64             `json:"next_leader_slot"`
+	NextSlotSource string             `json:"next_slot_source"`
+	Skips          SkipRate           `json:"skips"`
+	Performance    *LeaderPerformance `json:"performance"` // Recent blocks it proposed, as in /validators/self/performance
+	Vote           VoteStatus         `json:"vote"`        // As in /validators/self/vote
+}
+
+// handleSelfValidator reports the local validator: identity, stake, next
+// leader slot and skip rate
+func handleSelfValidator(c *gin.Context) {
+	lt := GetLeaderTracker()
+	if lt == nil {
+		c.JSON(http.StatusServiceUnavailable, APIError{Error: "Leader tracker not initialized"})
+		return
+	}
+
+	result := SelfValidatorResponse{
+		Address:       lt.SelfAddress(),
+		AddressSource: lt.AddressSource(),
+		Identity:      GetNodeIdentity(),
+		IsLeader:      lt.IsLeader(),
+		Skips:         lt.SkipRate(),
+		Vote:          currentVoteStatus(),
+	}
+	next, source, ok := lt.NextLeaderSlot()
+	result.NextSlotSource = source
+	if ok {
+		result.NextLeaderSlot = &next
+	}
+	if result.Address == "" {
+		c.JSON(http.StatusOK, result)
+		return
+	}
+
+	for _, peer := range buildPeerList() {
+		if isSelf, _ := peer["is_self"].(bool); !isSelf {
+			continue
+		}
+		result.InPeerSet = true
+		if votes, _ := peer["vote"].([]map[string]interface{}); len(votes) > 0 {
+			if stake, ok := votes[0]["activated_stake"].(int64); ok {
+				result.Stake = &stake
+			}
+		}
+	}
+	result.BalanceGwei = identityBalanceGwei()
+	result.Performance = lt.Performance().Self
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,604 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/sha3"
+
+	"monad-dashboard/hexutil"
+)
+
+// Validator set snapshots per epoch, read from the staking precompile
+// (STAKING_PRECOMPILE, default 0x…1000) at the first block seen in each
+// epoch, and the changes between consecutive snapshots: validators that
+// joined or left the consensus set, and stake and commission changes.
+
+// defaultStakingPrecompile is Monad's staking precompile address
+const defaultStakingPrecompile = "0x0000000000000000000000000000000000001000"
+
+// Bounds on validator set snapshots
+const (
+	maxValidatorSnapshots = 32
+	maxValidatorSetSize   = 2000
+)
+
+// Staking precompile selectors
+var (
+	selectorGetConsensusValidatorSet = abiSelector("getConsensusValidatorSet(uint32)")
+	selectorGetValidator             = abiSelector("getValidator(uint64)")
+	selectorGetEpoch                 = abiSelector("getEpoch()")
+)
+
+// commissionScale is a commission of 100% as the precompile reports it
+var commissionScale = new(big.Float).SetFloat64(1e18)
+
+// abiSelector returns the 4-byte selector of a function signature
+func abiSelector(signature string) []byte {
+	hash := sha3.NewLegacyKeccak256()
+	hash.Write([]byte(signature))
+	return hash.Sum(nil)[:4]
+}
+
+// abiWordAt returns the i-th 32-byte word of ABI-encoded data
+func abiWordAt(data []byte, i int) ([]byte, error) {
+	if i < 0 || len(data) < (i+1)*32 {
+		return nil, fmt.Errorf("ABI data too short for word %d", i)
+	}
+	return data[i*32 : (i+1)*32], nil
+}
+
+// abiUint64 decodes the i-th word as a uint64
+func abiUint64(data []byte, i int) (uint64, error) {
+	word, err := abiWordAt(data, i)
+	if err != nil {
+		return 0, err
+	}
+	value, ok := abiWord(word)
+	if !ok {
+		return 0, fmt.Errorf("ABI word %d overflows uint64", i)
+	}
+	return value, nil
+}
+
+// abiDynamicAt returns the length and the data following it of the dynamic
+// value whose offset is the i-th word
+func abiDynamicAt(data []byte, i int) (uint64, []byte, error) {
+	offset, err := abiUint64(data, i)
+	if err != nil {
+		return 0, nil, err
+	}
+	if offset > uint64(len(data)) {
+		return 0, nil, fmt.Errorf("invalid ABI offset %d", offset)
+	}
+	length, err := abiUint64(data[offset:], 0)
+	if err != nil {
+		return 0, nil, err
+	}
+	return length, data[offset+32:], nil
+}
+
+// abiBytesAt decodes the bytes whose offset is the i-th word
+func abiBytesAt(data []byte, i int) ([]byte, error) {
+	length, tail, err := abiDynamicAt(data, i)
+	if err != nil {
+		return nil, err
+	}
+	if length > uint64(len(tail)) {
+		return nil, fmt.Errorf("invalid ABI length %d", length)
+	}
+	return tail[:length], nil
+}
+
+// abiUint64sAt decodes the uint64[] whose offset is the i-th word
+func abiUint64sAt(data []byte, i int) ([]uint64, error) {
+	length, tail, err := abiDynamicAt(data, i)
+	if err != nil {
+		return nil, err
+	}
+	if length > uint64(len(tail))/32 {
+		return nil, fmt.Errorf("invalid ABI length %d", length)
+	}
+	values := make([]uint64, length)
+	for j := range values {
+		if values[j], err = abiUint64(tail, j); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+// EpochValidator is one validator of an epoch's consensus set
+type EpochValidator struct {
+	ID          uint64  `json:"id"`
+	AuthAddress string  `json:"auth_address"`
+	SecpPubkey  string  `json:"secp_pubkey,omitempty"`
+	Stake       string  `json:"stake"` // Consensus stake, wei, decimal
+	StakeMON    float64 `json:"stake_mon"`
+	Commission  float64 `json:"commission"` // Fraction, 0.1 = 10%
+
+	stake      *big.Int
+	commission *big.Int
+}
+
+// StakeChange is a validator whose stake changed between two epochs
+type StakeChange struct {
+	ID            uint64  `json:"id"`
+	AuthAddress   string  `json:"auth_address"`
+	PreviousMON   float64 `json:"previous_mon"`
+	CurrentMON    float64 `json:"current_mon"`
+	DeltaMON      float64 `json:"delta_mon"`
+	PreviousStake string  `json:"previous_stake"` // Wei, decimal
+	CurrentStake  string  `json:"current_stake"`
+}
+
+// CommissionChange is a validator whose commission changed between two epochs
+type CommissionChange struct {
+	ID          uint64  `json:"id"`
+	AuthAddress string  `json:"auth_address"`
+	Previous    float64 `json:"previous"`
+	Current     float64 `json:"current"`
+}
+
+// ValidatorChanges is the diff of an epoch's validator set against the
+// previous snapshot, the body of /api/v1/epochs/:n/validator-changes
+type ValidatorChanges struct {
+	Epoch             int64              `json:"epoch"`
+	StakingEpoch      *uint64            `json:"staking_epoch,omitempty"` // getEpoch() of the precompile
+	Block             int64              `json:"block"`                   // Block the set was read at
+	TakenAt           time.Time          `json:"taken_at"`
+	PreviousEpoch     *int64             `json:"previous_epoch"` // Null for the first snapshot since startup
+	Validators        int                `json:"validators"`
+	TotalStakeMON     float64            `json:"total_stake_mon"`
+	NetStakeMON       float64            `json:"net_stake_mon"` // Total stake change against the previous snapshot
+	Joined            []EpochValidator   `json:"joined"`
+	Left              []EpochValidator   `json:"left"`
+	StakeChanges      []StakeChange      `json:"stake_changes"` // Largest change first
+	CommissionChanges []CommissionChange `json:"commission_changes"`
+}
+
+// validatorSnapshot is the consensus set read for one epoch
+type validatorSnapshot struct {
+	epoch        int64
+	stakingEpoch *uint64
+	block        int64
+	takenAt      time.Time
+	validators   map[uint64]EpochValidator
+	totalStake   *big.Int
+	changes      ValidatorChanges
+}
+
+// epochMark is the first block seen in an epoch
+type epochMark struct {
+	epoch int64
+	block int64
+}
+
+// ValidatorSetTracker snapshots the validator set at epoch boundaries
+type ValidatorSetTracker struct {
+	precompile string
+	rollovers  chan epochMark
+
+	mu        sync.RWMutex
+	snapshots []*validatorSnapshot // Oldest first
+	lastError string
+}
+
+// Global validator set tracker
+var (
+	validatorSetTracker   *ValidatorSetTracker
+	validatorSetTrackerMu sync.RWMutex
+)
+
+// InitializeValidatorSetTracker starts snapshotting the validator set from
+// the staking precompile: now, and at every epoch rollover
+func InitializeValidatorSetTracker() *ValidatorSetTracker {
+	precompile := defaultStakingPrecompile
+	if value := os.Getenv("STAKING_PRECOMPILE"); value != "" {
+		addr, err := normalizeAddress(value)
+		if err != nil {
+			log.Printf("Invalid STAKING_PRECOMPILE %q, using %s", value, precompile)
+		} else {
+			precompile = addr
+		}
+	}
+	vs := &ValidatorSetTracker{precompile: precompile, rollovers: make(chan epochMark, 4)}
+	go vs.run()
+
+	validatorSetTrackerMu.Lock()
+	defer validatorSetTrackerMu.Unlock()
+	validatorSetTracker = vs
+	return vs
+}
+
+// GetValidatorSetTracker returns the global validator set tracker
+func GetValidatorSetTracker() *ValidatorSetTracker {
+	validatorSetTrackerMu.RLock()
+	defer validatorSetTrackerMu.RUnlock()
+	return validatorSetTracker
+}
+
+// EpochRolledOver queues a snapshot of the new epoch's set, read at block
+func (vs *ValidatorSetTracker) EpochRolledOver(epoch, block int64) {
+	select {
+	case vs.rollovers <- epochMark{epoch: epoch, block: block}:
+	default:
+		log.Printf("Validator set snapshot of epoch %d skipped, queue full", epoch)
+	}
+}
+
+// run takes a baseline snapshot, retrying every minute until one
+// succeeds, then one per queued rollover
+func (vs *ValidatorSetTracker) run() {
+	retry := time.NewTicker(time.Minute)
+	defer retry.Stop()
+
+	baseline := vs.snapshotLatest()
+	for {
+		select {
+		case <-shutdownContext().Done():
+			return
+		case <-retry.C:
+			if !baseline {
+				baseline = vs.snapshotLatest()
+			}
+		case mark := <-vs.rollovers:
+			if err := vs.snapshot(mark.epoch, mark.block); err != nil {
+				vs.fail(err)
+				continue
+			}
+			baseline = true
+		}
+	}
+}
+
+// snapshotLatest snapshots the set at the latest block, reporting success
+func (vs *ValidatorSetTracker) snapshotLatest() bool {
+	client := GetMonadClient()
+	if client == nil {
+		return false
+	}
+	block, err := client.GetBlockNumberByTag("latest")
+	if err == nil {
+		err = vs.snapshot(GetChainParams().Epoch(block), block)
+	}
+	if err != nil {
+		vs.fail(err)
+		return false
+	}
+	return true
+}
+
+// fail records a failed snapshot
+func (vs *ValidatorSetTracker) fail(err error) {
+	log.Printf("Validator set snapshot failed: %v", err)
+	vs.mu.Lock()
+	vs.lastError = err.Error()
+	vs.mu.Unlock()
+}
+
+// snapshot reads the consensus set at block, diffs it against the previous
+// snapshot and, for rollovers, streams the summary of the changes
+func (vs *ValidatorSetTracker) snapshot(epoch, block int64) error {
+	client := GetMonadClient()
+	if client == nil {
+		return errors.New("monad client not initialized")
+	}
+	validators, err := vs.fetchSet(client, block)
+	if err != nil {
+		return err
+	}
+
+	snap := &validatorSnapshot{epoch: epoch, block: block, takenAt: time.Now().UTC(), validators: validators, totalStake: new(big.Int)}
+	if stakingEpoch, err := vs.fetchEpoch(client, block); err == nil {
+		snap.stakingEpoch = &stakingEpoch
+	}
+	for _, v := range validators {
+		snap.totalStake.Add(snap.totalStake, v.stake)
+	}
+
+	vs.mu.Lock()
+	var previous *validatorSnapshot
+	for i := len(vs.snapshots) - 1; i >= 0; i-- {
+		if vs.snapshots[i].epoch == epoch {
+			vs.mu.Unlock()
+			return nil // Already taken
+		}
+		if vs.snapshots[i].epoch < epoch {
+			previous = vs.snapshots[i]
+			break
+		}
+	}
+	snap.changes = diffValidatorSets(previous, snap)
+	vs.snapshots = append(vs.snapshots, snap)
+	sort.Slice(vs.snapshots, func(i, j int) bool { return vs.snapshots[i].epoch < vs.snapshots[j].epoch })
+	if len(vs.snapshots) > maxValidatorSnapshots {
+		vs.snapshots = vs.snapshots[len(vs.snapshots)-maxValidatorSnapshots:]
+	}
+	vs.lastError = ""
+	vs.mu.Unlock()
+
+	changes := snap.changes
+	log.Printf("Validator set of epoch %d: %d validators, %d joined, %d left, %d stake and %d commission changes",
+		epoch, changes.Validators, len(changes.Joined), len(changes.Left), len(changes.StakeChanges), len(changes.CommissionChanges))
+	if previous != nil {
+		broadcastToAllClients(FiredancerMessage{
+			Topic: "epoch",
+			Key:   "rollover",
+			Value: map[string]interface{}{
+				"epoch":              changes.Epoch,
+				"previous_epoch":     changes.PreviousEpoch,
+				"block":              changes.Block,
+				"validators":         changes.Validators,
+				"total_stake_mon":    changes.TotalStakeMON,
+				"net_stake_mon":      changes.NetStakeMON,
+				"joined":             len(changes.Joined),
+				"left":               len(changes.Left),
+				"stake_changes":      len(changes.StakeChanges),
+				"commission_changes": len(changes.CommissionChanges),
+			},
+		})
+	}
+	return nil
+}
+
+// ethCall calls the precompile at block and decodes the returned bytes
+func (vs *ValidatorSetTracker) ethCall(client *MonadClient, block int64, data []byte) ([]byte, error) {
+	call := map[string]string{"to": vs.precompile, "data": hexutil.EncodeBytes(data)}
+	var result string
+	if err := client.callResult("eth_call", []interface{}{call, hexutil.EncodeInt64(block)}, &result); err != nil {
+		return nil, err
+	}
+	return hexutil.DecodeBytes(result)
+}
+
+// fetchEpoch reads getEpoch() at block
+func (vs *ValidatorSetTracker) fetchEpoch(client *MonadClient, block int64) (uint64, error) {
+	result, err := vs.ethCall(client, block, selectorGetEpoch)
+	if err != nil {
+		return 0, err
+	}
+	return abiUint64(result, 0)
+}
+
+// fetchSet pages through getConsensusValidatorSet and reads each validator
+func (vs *ValidatorSetTracker) fetchSet(client *MonadClient, block int64) (map[uint64]EpochValidator, error) {
+	var ids []uint64
+	for start := uint64(0); ; {
+		data := append(append([]byte{}, selectorGetConsensusValidatorSet...), make([]byte, 32)...)
+		binary.BigEndian.PutUint64(data[len(data)-8:], start)
+		result, err := vs.ethCall(client, block, data)
+		if err != nil {
+			return nil, fmt.Errorf("getConsensusValidatorSet: %w", err)
+		}
+		done, err := abiUint64(result, 0)
+		if err != nil {
+			return nil, err
+		}
+		next, err := abiUint64(result, 1)
+		if err != nil {
+			return nil, err
+		}
+		page, err := abiUint64sAt(result, 2)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, page...)
+		if done != 0 || next <= start || len(ids) >= maxValidatorSetSize {
+			break
+		}
+		start = next
+	}
+
+	validators := make(map[uint64]EpochValidator, len(ids))
+	for _, id := range ids {
+		v, err := vs.fetchValidator(client, block, id)
+		if err != nil {
+			return nil, fmt.Errorf("getValidator(%d): %w", id, err)
+		}
+		validators[id] = v
+	}
+	return validators, nil
+}
+
+// fetchValidator reads one validator's consensus stake and commission
+func (vs *ValidatorSetTracker) fetchValidator(client *MonadClient, block int64, id uint64) (EpochValidator, error) {
+	data := append(append([]byte{}, selectorGetValidator...), make([]byte, 32)...)
+	binary.BigEndian.PutUint64(data[len(data)-8:], id)
+	result, err := vs.ethCall(client, block, data)
+	if err != nil {
+		return EpochValidator{}, err
+	}
+	// authAddress, flags, stake, accRewardPerToken, commission,
+	// unclaimedRewards, consensusStake, consensusCommission, snapshotStake,
+	// snapshotCommission, secpPubkey, blsPubkey
+	auth, err := abiWordAt(result, 0)
+	if err != nil {
+		return EpochValidator{}, err
+	}
+	stakeWord, err := abiWordAt(result, 6)
+	if err != nil {
+		return EpochValidator{}, err
+	}
+	commissionWord, err := abiWordAt(result, 7)
+	if err != nil {
+		return EpochValidator{}, err
+	}
+	v := EpochValidator{
+		ID:          id,
+		AuthAddress: hexutil.EncodeBytes(auth[12:]),
+		stake:       new(big.Int).SetBytes(stakeWord),
+		commission:  new(big.Int).SetBytes(commissionWord),
+	}
+	if pubkey, err := abiBytesAt(result, 10); err == nil && len(pubkey) > 0 {
+		v.SecpPubkey = hexutil.EncodeBytes(pubkey)
+	}
+	v.Stake = v.stake.String()
+	v.StakeMON = weiToMON(v.stake)
+	v.Commission, _ = new(big.Float).Quo(new(big.Float).SetInt(v.commission), commissionScale).Float64()
+	return v, nil
+}
+
+// diffValidatorSets compares a snapshot with the previous one; a nil
+// previous snapshot yields no changes
+func diffValidatorSets(previous, current *validatorSnapshot) ValidatorChanges {
+	changes := ValidatorChanges{
+		Epoch:             current.epoch,
+		StakingEpoch:      current.stakingEpoch,
+		Block:             current.block,
+		TakenAt:           current.takenAt,
+		Validators:        len(current.validators),
+		TotalStakeMON:     weiToMON(current.totalStake),
+		Joined:            make([]EpochValidator, 0),
+		Left:              make([]EpochValidator, 0),
+		StakeChanges:      make([]StakeChange, 0),
+		CommissionChanges: make([]CommissionChange, 0),
+	}
+	if previous == nil {
+		return changes
+	}
+	changes.PreviousEpoch = &previous.epoch
+	changes.NetStakeMON = weiToMON(new(big.Int).Sub(current.totalStake, previous.totalStake))
+
+	for id, v := range current.validators {
+		before, existed := previous.validators[id]
+		if !existed {
+			changes.Joined = append(changes.Joined, v)
+			continue
+		}
+		if before.stake.Cmp(v.stake) != 0 {
+			changes.StakeChanges = append(changes.StakeChanges, StakeChange{
+				ID:            id,
+				AuthAddress:   v.AuthAddress,
+				PreviousMON:   before.StakeMON,
+				CurrentMON:    v.StakeMON,
+				DeltaMON:      weiToMON(new(big.Int).Sub(v.stake, before.stake)),
+				PreviousStake: before.Stake,
+				CurrentStake:  v.Stake,
+			})
+		}
+		if before.commission.Cmp(v.commission) != 0 {
+			changes.CommissionChanges = append(changes.CommissionChanges, CommissionChange{
+				ID:          id,
+				AuthAddress: v.AuthAddress,
+				Previous:    before.Commission,
+				Current:     v.Commission,
+			})
+		}
+	}
+	for id, v := range previous.validators {
+		if _, remains := current.validators[id]; !remains {
+			changes.Left = append(changes.Left, v)
+		}
+	}
+
+	byID := func(list []EpochValidator) {
+		sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	}
+	byID(changes.Joined)
+	byID(changes.Left)
+	sort.Slice(changes.StakeChanges, func(i, j int) bool {
+		a, b := changes.StakeChanges[i].DeltaMON, changes.StakeChanges[j].DeltaMON
+		if a < 0 {
+			a = -a
+		}
+		if b < 0 {
+			b = -b
+		}
+		if a != b {
+			return a > b
+		}
+		return changes.StakeChanges[i].ID < changes.StakeChanges[j].ID
+	})
+	sort.Slice(changes.CommissionChanges, func(i, j int) bool {
+		return changes.CommissionChanges[i].ID < changes.CommissionChanges[j].ID
+	})
+	return changes
+}
+
+// Changes returns the diff recorded for an epoch
+func (vs *ValidatorSetTracker) Changes(epoch int64) (ValidatorChanges, bool) {
+	vs.mu.RLock()
+	defer vs.mu.RUnlock()
+	for _, snap := range vs.snapshots {
+		if snap.epoch == epoch {
+			return snap.changes, true
+		}
+	}
+	return ValidatorChanges{}, false
+}
+
+// Current returns the validators of the newest snapshot and its epoch
+func (vs *ValidatorSetTracker) Current() (epoch int64, validators []EpochValidator, ok bool) {
+	vs.mu.RLock()
+	defer vs.mu.RUnlock()
+	n := len(vs.snapshots)
+	if n == 0 {
+		return 0, nil, false
+	}
+	snap := vs.snapshots[n-1]
+	validators = make([]EpochValidator, 0, len(snap.validators))
+	for _, v := range snap.validators {
+		validators = append(validators, v)
+	}
+	return snap.epoch, validators, true
+}
+
+// Latest returns the epoch of the newest snapshot and the last error
+func (vs *ValidatorSetTracker) Latest() (epoch int64, ok bool, lastError string) {
+	vs.mu.RLock()
+	defer vs.mu.RUnlock()
+	if n := len(vs.snapshots); n > 0 {
+		return vs.snapshots[n-1].epoch, true, vs.lastError
+	}
+	return 0, false, vs.lastError
+}
+
+// handleEpochValidatorChanges returns the validator set changes of epoch
+// :n (or "latest") against the previous snapshot
+func handleEpochValidatorChanges(c *gin.Context) {
+	vs := GetValidatorSetTracker()
+	if vs == nil {
+		c.JSON(http.StatusServiceUnavailable, APIError{Error: "Validator set tracker not initialized"})
+		return
+	}
+
+	latest, ok, lastError := vs.Latest()
+	var epoch int64
+	if ref := c.Param("n"); strings.EqualFold(ref, "latest") {
+		if !ok {
+			message := "No validator set snapshot yet"
+			if lastError != "" {
+				message += ": " + lastError
+			}
+			c.JSON(http.StatusServiceUnavailable, APIError{Error: message})
+			return
+		}
+		epoch = latest
+	} else {
+		n, err := strconv.ParseInt(ref, 10, 64)
+		if err != nil || n < 0 {
+			c.JSON(http.StatusBadRequest, APIError{Error: fmt.Sprintf("invalid epoch %q", ref)})
+			return
+		}
+		epoch = n
+	}
+
+	changes, found := vs.Changes(epoch)
+	if !found {
+		c.JSON(http.StatusNotFound, APIError{Error: fmt.Sprintf("no validator set snapshot for epoch %d", epoch)})
+		return
+	}
+	c.JSON(http.StatusOK, changes)
+}
@@ -0,0 +1,312 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Vote states reported in summary.vote_state, as in the Firedancer GUI
+const (
+	voteStateVoting     = "voting"
+	voteStateNonVoting  = "non-voting"
+	voteStateDelinquent = "delinquent"
+)
+
+// VoteStatus is whether the local validator takes part in consensus and the
+// body of /api/v1/validators/self/vote
+type VoteStatus struct {
+	State           string `json:"state"`    // voting, non-voting or delinquent
+	Distance        int64  `json:"distance"` // Blocks since the last evidence of a vote, 0 when not voting
+	InActiveSet     bool   `json:"in_active_set"`
+	ActiveSetSource string `json:"active_set_source"` // ipc (monad_getValidatorSet), inferred (leader schedule or proposals) or unavailable
+	ActiveSetSize   int    `json:"active_set_size"`   // 0 when the control panel does not serve the set
+	LastVote        *int64 `json:"last_vote"`         // Null without evidence since startup
+	LastVoteSource  string `json:"last_vote_source"`  // ipc (last_voted_round), proposal or none
+	DelinquentAfter int64  `json:"delinquent_after"`  // Distance beyond which the validator is delinquent
+	VoteFailures1h  int64  `json:"vote_failures_1h"`  // vote_failure events from the monad-bft log
+	Head            int64  `json:"head"`
+}
+
+// VoteTracker derives the local validator's vote state from the BFT
+// control panel's validator set, falling back to the leader schedule and
+// the blocks it proposed when the control panel does not answer
+type VoteTracker struct {
+	ipcPath       string
+	minDelinquent int64
+
+	mu             sync.RWMutex
+	activeSet      map[string]bool
+	lastVotes      map[string]int64 // Address -> last_voted_round, when reported
+	setUpdated     time.Time
+	activeSince    int64 // Head when the validator was first seen in the active set
+	lastStatus     VoteStatus
+	statusComputed time.Time
+}
+
+// Global vote tracker instance
+var (
+	voteTracker   *VoteTracker
+	voteTrackerMu sync.RWMutex
+)
+
+// InitializeVoteTracker creates the global vote tracker. A validator is
+// delinquent once VOTE_DELINQUENT_DISTANCE blocks (default 150) pass
+// without evidence of a vote.
+func InitializeVoteTracker(ipcPath string) *VoteTracker {
+	voteTrackerMu.Lock()
+	defer voteTrackerMu.Unlock()
+
+	voteTracker = &VoteTracker{
+		ipcPath:       ipcPath,
+		minDelinquent: 150,
+		activeSet:     make(map[string]bool),
+		lastVotes:     make(map[string]int64),
+	}
+	if n, ok := positiveIntEnv("VOTE_DELINQUENT_DISTANCE"); ok {
+		voteTracker.minDelinquent = n
+	}
+	if lt := GetLeaderTracker(); lt != nil && lt.SelfAddress() != "" {
+		go voteTracker.refreshLoop(30 * time.Second)
+	}
+	return voteTracker
+}
+
+// GetVoteTracker returns the global vote tracker
+func GetVoteTracker() *VoteTracker {
+	voteTrackerMu.RLock()
+	defer voteTrackerMu.RUnlock()
+	return voteTracker
+}
+
+// refreshLoop periodically refreshes the validator set via IPC
+func (vt *VoteTracker) refreshLoop(every time.Duration) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+
+	for {
+		if err := vt.refreshValidatorSet(); err != nil {
+			vt.mu.Lock()
+			// A stale set no longer describes the validator
+			if time.Since(vt.setUpdated) > 3*every {
+				vt.activeSet = make(map[string]bool)
+				vt.lastVotes = make(map[string]int64)
+				vt.setUpdated = time.Time{}
+			}
+			vt.mu.Unlock()
+		}
+		select {
+		case <-ticker.C:
+		case <-shutdownContext().Done():
+			return
+		}
+	}
+}
+
+// refreshValidatorSet requests the current validator set from the BFT control panel
+func (vt *VoteTracker) refreshValidatorSet() error {
+	if vt.ipcPath == "" {
+		return fmt.Errorf("no control panel path configured")
+	}
+
+	ctx, cancel := callContext(ipcTimeout)
+	defer cancel()
+	conn, err := dialUnix(ctx, vt.ipcPath)
+	if err != nil {
+		return fmt.Errorf("failed to dial control panel: %w", err)
+	}
+	defer conn.Close()
+
+	request := map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "monad_getValidatorSet",
+		"params":  []interface{}{},
+	}
+	if err := json.NewEncoder(conn).Encode(request); err != nil {
+		return fmt.Errorf("failed to send validator set request: %w", err)
+	}
+
+	var response struct {
+		Result []struct {
+			Address        string `json:"address"`
+			LastVotedRound *int64 `json:"last_voted_round"`
+		} `json:"result"`
+		Error *rpcError `json:"error"`
+	}
+	if err := json.NewDecoder(conn).Decode(&response); err != nil {
+		return fmt.Errorf("failed to decode validator set: %w", err)
+	}
+	if response.Error != nil {
+		return response.Error
+	}
+
+	activeSet := make(map[string]bool, len(response.Result))
+	lastVotes := make(map[string]int64)
+	for _, entry := range response.Result {
+		address := strings.ToLower(entry.Address)
+		activeSet[address] = true
+		if entry.LastVotedRound != nil {
+			lastVotes[address] = *entry.LastVotedRound
+		}
+	}
+
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+	vt.activeSet = activeSet
+	vt.lastVotes = lastVotes
+	vt.setUpdated = time.Now()
+	return nil
+}
+
+// voteEvidence returns the head, the last block the local validator
+// proposed (0 when none), whether a scheduled slot names it and how many
+// proposers the performance window holds
+func (lt *LeaderTracker) voteEvidence() (head, lastProposal int64, scheduled bool, proposers int) {
+	lt.mu.RLock()
+	defer lt.mu.RUnlock()
+
+	head = lt.latestBlock
+	if n := len(lt.selfSlots); n > 0 {
+		lastProposal = lt.selfSlots[n-1].BlockNumber
+	}
+	scheduled = lt.scheduledSlots > 0
+	for _, leader := range lt.schedule {
+		if leader == lt.selfAddress {
+			scheduled = true
+			break
+		}
+	}
+	seen := make(map[string]bool)
+	for _, r := range lt.proposals {
+		seen[r.proposer] = true
+	}
+	return head, lastProposal, scheduled, len(seen)
+}
+
+// Status returns the local validator's vote state. It is recomputed at
+// most once per second since every stream connection asks on each tick.
+func (vt *VoteTracker) Status() VoteStatus {
+	vt.mu.RLock()
+	if !vt.statusComputed.IsZero() && time.Since(vt.statusComputed) < time.Second {
+		status := vt.lastStatus
+		vt.mu.RUnlock()
+		return status
+	}
+	vt.mu.RUnlock()
+
+	status := VoteStatus{State: voteStateNonVoting, ActiveSetSource: "unavailable", LastVoteSource: "none"}
+	if lc := GetConsensusLogCollector(); lc != nil {
+		_, lastHour := lc.Counts(time.Now())
+		status.VoteFailures1h = lastHour[consensusEventVoteFailure]
+	}
+
+	lt := GetLeaderTracker()
+	self := ""
+	var lastProposal, proposers int64
+	scheduled := false
+	if lt != nil {
+		self = lt.SelfAddress()
+		var n int
+		status.Head, lastProposal, scheduled, n = lt.voteEvidence()
+		proposers = int64(n)
+	}
+
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+
+	lastVote := int64(0)
+	if !vt.setUpdated.IsZero() {
+		status.ActiveSetSource = "ipc"
+		status.ActiveSetSize = len(vt.activeSet)
+		status.InActiveSet = self != "" && vt.activeSet[self]
+		if round, ok := vt.lastVotes[self]; ok && status.InActiveSet {
+			lastVote = round
+			status.LastVoteSource = "ipc"
+		}
+	} else if self != "" && (lastProposal > 0 || scheduled) {
+		// Only validators in the active set lead slots
+		status.ActiveSetSource = "inferred"
+		status.InActiveSet = true
+	}
+	if status.LastVoteSource == "none" && lastProposal > 0 && status.InActiveSet {
+		lastVote = lastProposal
+		status.LastVoteSource = "proposal"
+	}
+
+	// Proposals are sparse evidence: a validator leads about one block in
+	// every active set size, so allow a few rotations before flagging it
+	status.DelinquentAfter = vt.minDelinquent
+	if status.LastVoteSource != "ipc" {
+		size := int64(status.ActiveSetSize)
+		if size == 0 {
+			size = proposers
+		}
+		if 3*size > status.DelinquentAfter {
+			status.DelinquentAfter = 3 * size
+		}
+	}
+
+	if !status.InActiveSet {
+		vt.activeSince = 0
+	} else {
+		if vt.activeSince == 0 {
+			vt.activeSince = status.Head
+		}
+		since := vt.activeSince
+		if lastVote > 0 {
+			last := lastVote
+			status.LastVote = &last
+			since = lastVote
+		}
+		if status.Head > since {
+			status.Distance = status.Head - since
+		}
+		status.State = voteStateVoting
+		if status.Distance > status.DelinquentAfter {
+			status.State = voteStateDelinquent
+		}
+	}
+
+	if previous := vt.lastStatus.State; !vt.statusComputed.IsZero() && previous != status.State {
+		log.Printf("Local validator vote state %s -> %s (distance %d)", previous, status.State, status.Distance)
+	}
+	vt.lastStatus = status
+	vt.statusComputed = time.Now()
+	return status
+}
+
+// currentVoteStatus returns the vote state, non-voting before the tracker starts
+func currentVoteStatus() VoteStatus {
+	if vt := GetVoteTracker(); vt != nil {
+		return vt.Status()
+	}
+	return VoteStatus{State: voteStateNonVoting, ActiveSetSource: "unavailable", LastVoteSource: "none"}
+}
+
+// handleVoteStatus reports whether the local validator is voting
+func handleVoteStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, currentVoteStatus())
+}
+
+// LastVotes returns the last_voted_round of every validator the control
+// panel reported and the vote distance beyond which one is delinquent;
+// ok is false while the control panel's set is unavailable
+func (vt *VoteTracker) LastVotes() (votes map[string]int64, delinquentAfter int64, ok bool) {
+	vt.mu.RLock()
+	defer vt.mu.RUnlock()
+	if vt.setUpdated.IsZero() {
+		return nil, vt.minDelinquent, false
+	}
+	votes = make(map[string]int64, len(vt.lastVotes))
+	for address, round := range vt.lastVotes {
+		votes[address] = round
+	}
+	return votes, vt.minDelinquent, true
+}
@@ -0,0 +1,529 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/pelletier/go-toml/v2"
+
+	"monad-dashboard/hexutil"
+)
+
+// WatchedAddress is a user-registered address with its activity counters
+type WatchedAddress struct {
+	Address    string     `json:"address"`
+	Label      string     `json:"label,omitempty"`
+	AddedAt    time.Time  `json:"added_at"`
+	Hits       int64      `json:"hits"`
+	AsEmitter  int64      `json:"as_emitter"` // Logs emitted by the address
+	AsTopic    int64      `json:"as_topic"`   // Logs with the address in an indexed topic
+	LastBlock  int64      `json:"last_block"`
+	LastTxHash string     `json:"last_tx_hash,omitempty"`
+	LastSeen   *time.Time `json:"last_seen,omitempty"`
+	FromConfig bool       `json:"from_config,omitempty"` // Listed in WATCHLIST_CONFIG; reloads may remove it
+}
+
+// defaultWatchlistMaxAddresses bounds a watchlist when
+// WATCHLIST_MAX_ADDRESSES is unset
+const defaultWatchlistMaxAddresses = 1000
+
+// errWatchlistFull is returned by Add once the watchlist holds maxAddresses
+var errWatchlistFull = errors.New("watchlist is full")
+
+// Watchlist matches incoming monadLogs against registered addresses
+type Watchlist struct {
+	mu           sync.RWMutex
+	addresses    map[string]*WatchedAddress
+	maxAddresses int // Cap on addresses added over the API
+
+	// WebSocket clients that asked for watch_hit events.
+	// An empty filter set means "all watched addresses".
+	subsMu      sync.RWMutex
+	subscribers map[*websocket.Conn]map[string]bool
+}
+
+// NewWatchlist creates an empty watchlist holding at most
+// WATCHLIST_MAX_ADDRESSES addresses (default 1000)
+func NewWatchlist() *Watchlist {
+	w := &Watchlist{
+		addresses:    make(map[string]*WatchedAddress),
+		maxAddresses: defaultWatchlistMaxAddresses,
+		subscribers:  make(map[*websocket.Conn]map[string]bool),
+	}
+	if value := os.Getenv("WATCHLIST_MAX_ADDRESSES"); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			w.maxAddresses = n
+		} else {
+			log.Printf("Invalid WATCHLIST_MAX_ADDRESSES %q, using %d", value, w.maxAddresses)
+		}
+	}
+	return w
+}
+
+// WatchlistConfig is the WATCHLIST_CONFIG file: addresses watched from
+// startup, next to those added over the API
+type WatchlistConfig struct {
+	Addresses []WatchlistConfigEntry `toml:"address"`
+}
+
+// WatchlistConfigEntry is one [[address]] of WATCHLIST_CONFIG
+type WatchlistConfigEntry struct {
+	Address string `toml:"address"`
+	Label   string `toml:"label"`
+}
+
+// LoadWatchlist creates the dashboard's watchlist, watching the addresses
+// of WATCHLIST_CONFIG (TOML) when set
+func (d *Dashboard) LoadWatchlist() *Watchlist {
+	w := NewWatchlist()
+	if path := os.Getenv("WATCHLIST_CONFIG"); path != "" {
+		if added, _, err := w.ReloadConfig(path); err != nil {
+			log.Printf("Invalid watchlist config %s: %v", path, err)
+		} else {
+			log.Printf("Watchlist: %d addresses from %s", added, path)
+		}
+	}
+
+	d.mu.Lock()
+	d.watchlist = w
+	d.mu.Unlock()
+	return w
+}
+
+// InitializeWatchlist loads the served dashboard's watchlist
+func InitializeWatchlist() *Watchlist {
+	return GetDashboard().LoadWatchlist()
+}
+
+// ReloadConfig reads a watchlist config and, once every address in it is
+// valid, makes its addresses the configured set: new ones are added,
+// labels updated, and configured addresses no longer listed removed.
+// Addresses added over the API are left alone; counters are kept.
+func (w *Watchlist) ReloadConfig(path string) (added, removed int, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	var config WatchlistConfig
+	if err := toml.Unmarshal(data, &config); err != nil {
+		return 0, 0, err
+	}
+	labels := make(map[string]string, len(config.Addresses))
+	for _, entry := range config.Addresses {
+		addr, err := normalizeAddress(entry.Address)
+		if err != nil {
+			return 0, 0, err
+		}
+		if _, dup := labels[addr]; dup {
+			return 0, 0, fmt.Errorf("address %s listed twice", addr)
+		}
+		labels[addr] = entry.Label
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for addr, entry := range w.addresses {
+		if _, listed := labels[addr]; entry.FromConfig && !listed {
+			delete(w.addresses, addr)
+			removed++
+		}
+	}
+	for addr, label := range labels {
+		entry, exists := w.addresses[addr]
+		if !exists {
+			entry = &WatchedAddress{Address: addr, AddedAt: time.Now()}
+			w.addresses[addr] = entry
+			added++
+		}
+		entry.FromConfig = true
+		if label != "" {
+			entry.Label = label
+		}
+	}
+	return added, removed, nil
+}
+
+// GetWatchlist returns the served dashboard's watchlist
+func GetWatchlist() *Watchlist {
+	return GetDashboard().Watchlist()
+}
+
+// normalizeAddress validates a 20-byte hex address and lowercases it
+func normalizeAddress(addr string) (string, error) {
+	addr = strings.TrimSpace(addr)
+	if len(addr) != 42 || !hexutil.IsHex(addr) {
+		return "", fmt.Errorf("invalid address %q", addr)
+	}
+	return strings.ToLower(addr), nil
+}
+
+// topicToAddress extracts the address from a 32-byte indexed topic, if the
+// upper 12 bytes are zero (the ABI encoding of an address argument)
+func topicToAddress(topic string) (string, bool) {
+	if len(topic) != 66 || !hexutil.IsHex(topic) {
+		return "", false
+	}
+	if strings.Trim(topic[2:26], "0") != "" {
+		return "", false
+	}
+	return "0x" + strings.ToLower(topic[26:]), true
+}
+
+// Add registers an address. Re-adding an address updates its label only;
+// a new address is refused with errWatchlistFull once the watchlist holds
+// maxAddresses. Addresses from WATCHLIST_CONFIG count towards the cap but
+// are never refused.
+func (w *Watchlist) Add(address, label string) (*WatchedAddress, error) {
+	addr, err := normalizeAddress(address)
+	if err != nil {
+		return nil, err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	entry, exists := w.addresses[addr]
+	if !exists {
+		if len(w.addresses) >= w.maxAddresses {
+			return nil, fmt.Errorf("%w (%d addresses, WATCHLIST_MAX_ADDRESSES)", errWatchlistFull, w.maxAddresses)
+		}
+		entry = &WatchedAddress{
+			Address: addr,
+			AddedAt: time.Now(),
+		}
+		w.addresses[addr] = entry
+	}
+	if label != "" {
+		entry.Label = label
+	}
+
+	copied := *entry
+	return &copied, nil
+}
+
+// Remove unregisters an address, returning false if it was not watched
+func (w *Watchlist) Remove(address string) bool {
+	addr, err := normalizeAddress(address)
+	if err != nil {
+		return false
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if _, exists := w.addresses[addr]; !exists {
+		return false
+	}
+	delete(w.addresses, addr)
+	return true
+}
+
+// List returns all watched addresses sorted by hit count
+func (w *Watchlist) List() []WatchedAddress {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	entries := make([]WatchedAddress, 0, len(w.addresses))
+	for _, entry := range w.addresses {
+		entries = append(entries, *entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Hits != entries[j].Hits {
+			return entries[i].Hits > entries[j].Hits
+		}
+		return entries[i].Address < entries[j].Address
+	})
+	return entries
+}
+
+// Get returns a single watched address
+func (w *Watchlist) Get(address string) (*WatchedAddress, bool) {
+	addr, err := normalizeAddress(address)
+	if err != nil {
+		return nil, false
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	entry, exists := w.addresses[addr]
+	if !exists {
+		return nil, false
+	}
+	copied := *entry
+	return &copied, true
+}
+
+// Len returns the number of watched addresses
+func (w *Watchlist) Len() int {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return len(w.addresses)
+}
+
+// MatchLog checks a log against the watchlist, updating counters and
+// pushing watch_hit events for every matched address
+func (w *Watchlist) MatchLog(txLog *TransactionLog) {
+	w.mu.Lock()
+	if len(w.addresses) == 0 {
+		w.mu.Unlock()
+		return
+	}
+
+	now := time.Now()
+	hits := make([]map[string]interface{}, 0)
+
+	record := func(addr, role string) {
+		entry, watched := w.addresses[addr]
+		if !watched {
+			return
+		}
+		entry.Hits++
+		if role == "emitter" {
+			entry.AsEmitter++
+		} else {
+			entry.AsTopic++
+		}
+		entry.LastBlock = txLog.BlockNumber
+		entry.LastTxHash = txLog.TransactionHash
+		entry.LastSeen = &now
+
+		hit := map[string]interface{}{
+			"address":          addr,
+			"label":            entry.Label,
+			"role":             role,
+			"hits":             entry.Hits,
+			"block_number":     txLog.BlockNumber,
+			"transaction_hash": txLog.TransactionHash,
+			"log_address":      txLog.Address,
+			"topics":           txLog.Topics,
+			"timestamp":        txLog.Timestamp,
+		}
+		if txLog.Sampled {
+			hit["sampled"] = true
+			hit["sample_rate"] = txLog.SampleRate
+		}
+		hits = append(hits, hit)
+	}
+
+	emitter := strings.ToLower(txLog.Address)
+	record(emitter, "emitter")
+
+	// Indexed address arguments (e.g. Transfer from/to) live in topics[1:]
+	seen := map[string]bool{emitter: true}
+	for i, topic := range txLog.Topics {
+		if i == 0 {
+			continue
+		}
+		if addr, ok := topicToAddress(topic); ok && !seen[addr] {
+			seen[addr] = true
+			record(addr, "topic")
+		}
+	}
+	w.mu.Unlock()
+
+	for _, hit := range hits {
+		w.broadcastHit(hit)
+	}
+}
+
+// Subscribe registers a WebSocket client for watch_hit events. An empty
+// address list subscribes to every watched address.
+func (w *Watchlist) Subscribe(conn *websocket.Conn, addresses []string) {
+	filter := make(map[string]bool)
+	for _, address := range addresses {
+		if addr, err := normalizeAddress(address); err == nil {
+			filter[addr] = true
+		}
+	}
+
+	w.subsMu.Lock()
+	w.subscribers[conn] = filter
+	w.subsMu.Unlock()
+}
+
+// Unsubscribe stops watch_hit events for a WebSocket client
+func (w *Watchlist) Unsubscribe(conn *websocket.Conn) {
+	w.subsMu.Lock()
+	delete(w.subscribers, conn)
+	w.subsMu.Unlock()
+}
+
+// broadcastHit sends a watch_hit event to subscribed clients whose filter matches
+func (w *Watchlist) broadcastHit(hit map[string]interface{}) {
+	addr, _ := hit["address"].(string)
+
+	msg := FiredancerMessage{
+		Topic: "watchlist",
+		Key:   "watch_hit",
+		Value: hit,
+	}
+
+	broadcastToClientsWhere(msg, func(conn *websocket.Conn) bool {
+		w.subsMu.RLock()
+		defer w.subsMu.RUnlock()
+		filter, subscribed := w.subscribers[conn]
+		if !subscribed {
+			return false
+		}
+		return len(filter) == 0 || filter[addr]
+	})
+}
+
+// handleWatchlistClientMessage handles watchlist subscribe/unsubscribe frames
+func handleWatchlistClientMessage(conn *websocket.Conn, key string, params map[string]interface{}) {
+	w := watchlistFor(tenantOfConn(conn))
+	if w == nil {
+		return
+	}
+
+	switch key {
+	case "subscribe":
+		var addresses []string
+		if list, ok := params["addresses"].([]interface{}); ok {
+			for _, item := range list {
+				if address, ok := item.(string); ok {
+					addresses = append(addresses, address)
+				}
+			}
+		}
+		w.Subscribe(conn, addresses)
+		log.Printf("WebSocket client subscribed to watchlist (%d address filters)", len(addresses))
+	case "unsubscribe":
+		w.Unsubscribe(conn)
+	}
+}
+
+// WatchlistResponse is the body of GET /api/v1/watchlist
+type WatchlistResponse struct {
+	Count     int              `json:"count"`
+	Addresses []WatchedAddress `json:"addresses"`
+}
+
+// WatchlistAddRequest is the body of POST /api/v1/watchlist
+type WatchlistAddRequest struct {
+	Address   string   `json:"address,omitempty"`
+	Addresses []string `json:"addresses,omitempty"`
+	Label     string   `json:"label,omitempty"`
+}
+
+// WatchlistAddResponse lists the addresses a POST registered
+type WatchlistAddResponse struct {
+	Added []*WatchedAddress `json:"added"`
+}
+
+// WatchlistRemoveResponse echoes the removed address
+type WatchlistRemoveResponse struct {
+	Removed string `json:"removed"`
+}
+
+// requireWatchlistWriter guards watchlist changes. Under /t/:tenant a
+// tenant with API keys was already authorized by requireTenant; the global
+// watchlist and those of public tenants need ADMIN_KEY.
+func requireWatchlistWriter(c *gin.Context) {
+	if tenant := tenantOf(c); tenant != nil && len(tenant.keys) > 0 {
+		c.Next()
+		return
+	}
+	requireAdminKey(c)
+}
+
+// handleWatchlistList returns all watched addresses with activity counters
+func handleWatchlistList(c *gin.Context) {
+	w := watchlistFor(tenantOf(c))
+	if w == nil {
+		c.JSON(http.StatusServiceUnavailable, APIError{Error: "Watchlist not initialized"})
+		return
+	}
+
+	entries := w.List()
+	c.JSON(http.StatusOK, WatchlistResponse{
+		Count:     len(entries),
+		Addresses: entries,
+	})
+}
+
+// handleWatchlistAdd registers one or more addresses
+func handleWatchlistAdd(c *gin.Context) {
+	w := watchlistFor(tenantOf(c))
+	if w == nil {
+		c.JSON(http.StatusServiceUnavailable, APIError{Error: "Watchlist not initialized"})
+		return
+	}
+
+	var req WatchlistAddRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, APIError{Error: err.Error()})
+		return
+	}
+
+	addresses := req.Addresses
+	if req.Address != "" {
+		addresses = append(addresses, req.Address)
+	}
+	if len(addresses) == 0 {
+		c.JSON(http.StatusBadRequest, APIError{Error: "address or addresses is required"})
+		return
+	}
+
+	added := make([]*WatchedAddress, 0, len(addresses))
+	for _, address := range addresses {
+		entry, err := w.Add(address, req.Label)
+		if errors.Is(err, errWatchlistFull) {
+			c.JSON(http.StatusConflict, APIError{Error: err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, APIError{Error: err.Error()})
+			return
+		}
+		added = append(added, entry)
+	}
+
+	log.Printf("👀 Watchlist: added %d address(es)", len(added))
+	c.JSON(http.StatusCreated, WatchlistAddResponse{
+		Added: added,
+	})
+}
+
+// handleWatchlistGet returns a single watched address
+func handleWatchlistGet(c *gin.Context) {
+	w := watchlistFor(tenantOf(c))
+	if w == nil {
+		c.JSON(http.StatusServiceUnavailable, APIError{Error: "Watchlist not initialized"})
+		return
+	}
+
+	entry, ok := w.Get(c.Param("address"))
+	if !ok {
+		c.JSON(http.StatusNotFound, APIError{Error: "Address not watched"})
+		return
+	}
+	c.JSON(http.StatusOK, entry)
+}
+
+// handleWatchlistRemove unregisters an address
+func handleWatchlistRemove(c *gin.Context) {
+	w := watchlistFor(tenantOf(c))
+	if w == nil {
+		c.JSON(http.StatusServiceUnavailable, APIError{Error: "Watchlist not initialized"})
+		return
+	}
+
+	if !w.Remove(c.Param("address")) {
+		c.JSON(http.StatusNotFound, APIError{Error: "Address not watched"})
+		return
+	}
+	c.JSON(http.StatusOK, WatchlistRemoveResponse{Removed: strings.ToLower(c.Param("address"))})
+}
@@ -0,0 +1,299 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"monad-dashboard/hexutil"
+)
+
+// The block waterfall follows one block's transactions from submission to
+// finality for post-mortem analysis: the submission path comes from the
+// mempool ring as recorded by the lifecycle correlator, execution outcomes
+// from receipts (and execution events where the exec ring saw them), and
+// the consensus phases from the consensus tracker or the finalized head.
+// Txpool drops belong to no block; the ones counted while the block was
+// being built are estimated from the drop counter samples and reported
+// beside the flow rather than in it.
+
+// BlockWaterfallBlock identifies the block
+type BlockWaterfallBlock struct {
+	Number    int64  `json:"number"`
+	Hash      string `json:"hash"`
+	Timestamp int64  `json:"timestamp"`
+	TxCount   int    `json:"tx_count"`
+	GasUsed   uint64 `json:"gas_used"`
+}
+
+// BlockIngress is how the block's transactions entered the mempool
+type BlockIngress struct {
+	RPC        int `json:"rpc"`
+	P2P        int `json:"p2p"`
+	Unobserved int `json:"unobserved"` // Not seen entering the mempool (evicted or before start)
+
+	AvgMempoolToInclusionMs *float64 `json:"avg_mempool_to_inclusion_ms,omitempty"`
+	MaxMempoolToInclusionMs *float64 `json:"max_mempool_to_inclusion_ms,omitempty"`
+}
+
+// BlockDrops are the txpool drops estimated for the span the block was built in
+type BlockDrops struct {
+	From    int64              `json:"from"` // Unix milliseconds
+	To      int64              `json:"to"`
+	Source  string             `json:"source"` // prometheus or ipc
+	Reasons map[string]float64 `json:"reasons"`
+	Total   float64            `json:"total"`
+}
+
+// BlockExecutionEvents summarizes the exec ring's events for the block's transactions
+type BlockExecutionEvents struct {
+	Observed  int     `json:"observed"`
+	Succeeded int     `json:"succeeded"`
+	Failed    int     `json:"failed"`
+	TotalMs   float64 `json:"total_ms"`
+	MaxMs     float64 `json:"max_ms"`
+}
+
+// BlockExecution is the execution outcome of the block's transactions
+type BlockExecution struct {
+	Receipts         bool                  `json:"receipts"` // False while receipts are unavailable
+	Succeeded        int                   `json:"succeeded"`
+	Reverted         int                   `json:"reverted"`
+	ContractsCreated int                   `json:"contracts_created"`
+	Logs             int                   `json:"logs"`
+	Events           *BlockExecutionEvents `json:"events,omitempty"` // Nil when the exec ring saw none
+}
+
+// BlockFinality is the block's consensus progress
+type BlockFinality struct {
+	Phase                string     `json:"phase"`  // proposed, voted, finalized or unknown
+	Source               string     `json:"source"` // consensus_tracker or finalized_head
+	ProposedAt           *time.Time `json:"proposed_at,omitempty"`
+	VotedAt              *time.Time `json:"voted_at,omitempty"`
+	FinalizedAt          *time.Time `json:"finalized_at,omitempty"`
+	ProposalToFinalityMs *float64   `json:"proposal_to_finality_ms,omitempty"`
+}
+
+// BlockWaterfall is the body of /api/v1/waterfall/block/:number
+type BlockWaterfall struct {
+	Block BlockWaterfallBlock `json:"block"`
+	SankeyFlow
+	Ingress   BlockIngress   `json:"ingress"`
+	Drops     *BlockDrops    `json:"drops,omitempty"` // Nil without drop counter samples around the block
+	Execution BlockExecution `json:"execution"`
+	Finality  BlockFinality  `json:"finality"`
+}
+
+// buildBlockWaterfall attributes the lifecycle stages to one block, with
+// stats summarizing its receipts (nil while unavailable)
+func buildBlockWaterfall(block *RPCBlock, stats *BlockExecStats) BlockWaterfall {
+	number := hexutil.Int64OrZero(block.Number)
+	w := BlockWaterfall{
+		Block: BlockWaterfallBlock{
+			Number:    number,
+			Hash:      block.Hash,
+			Timestamp: hexutil.Int64OrZero(block.Timestamp),
+			TxCount:   len(block.Transactions),
+			GasUsed:   hexutil.Uint64OrZero(block.GasUsed),
+		},
+	}
+
+	// Ingress and execution events from the lifecycle records
+	correlator := GetTxLifecycleCorrelator()
+	var waits []float64
+	var events BlockExecutionEvents
+	for _, hash := range block.Transactions {
+		record, ok := correlator.Get(hash)
+		switch {
+		case !ok || record.MempoolAt == nil:
+			w.Ingress.Unobserved++
+		case record.Source == "p2p":
+			w.Ingress.P2P++
+		default:
+			w.Ingress.RPC++
+		}
+		if ok && record.MempoolAt != nil && record.IncludedAt != nil {
+			waits = append(waits, float64(record.IncludedAt.Sub(*record.MempoolAt).Microseconds())/1000)
+		}
+		if ok && record.ExecEndAt != nil {
+			events.Observed++
+			if record.Success != nil && *record.Success {
+				events.Succeeded++
+			} else {
+				events.Failed++
+			}
+			ms := float64(record.ExecDurationNs) / 1e6
+			events.TotalMs += ms
+			events.MaxMs = math.Max(events.MaxMs, ms)
+		}
+	}
+	if len(waits) > 0 {
+		sum, max := 0.0, 0.0
+		for _, wait := range waits {
+			sum += wait
+			max = math.Max(max, wait)
+		}
+		avg := sum / float64(len(waits))
+		w.Ingress.AvgMempoolToInclusionMs, w.Ingress.MaxMempoolToInclusionMs = &avg, &max
+	}
+	if events.Observed > 0 {
+		w.Execution.Events = &events
+	}
+
+	if stats != nil {
+		w.Execution.Receipts = true
+		w.Execution.Succeeded, w.Execution.Reverted = stats.Succeeded, stats.Reverted
+		w.Execution.ContractsCreated, w.Execution.Logs = stats.ContractsCreated, stats.LogCount
+	}
+
+	// Consensus phases, and the span the block was built in
+	blockTime := time.Duration(GetChainParams().BlockTime * float64(time.Second))
+	end := time.Unix(w.Block.Timestamp, 0)
+	start := end.Add(-blockTime)
+	w.Finality.Phase = "unknown"
+	if ct := GetConsensusTracker(); ct != nil {
+		if state, ok := ct.Block(uint64(number)); ok {
+			w.Finality.Phase, w.Finality.Source = state.Phase, "consensus_tracker"
+			proposedAt := state.ProposedAt
+			w.Finality.ProposedAt, w.Finality.VotedAt, w.Finality.FinalizedAt = &proposedAt, state.VotedAt, state.FinalizedAt
+			if state.FinalizedAt != nil {
+				ms := float64(state.FinalizedAt.Sub(state.ProposedAt).Microseconds()) / 1000
+				w.Finality.ProposalToFinalityMs = &ms
+			}
+			end, start = state.ProposedAt, state.ProposedAt.Add(-blockTime)
+			if prev, ok := ct.Block(uint64(number - 1)); ok && prev.ProposedAt.Before(end) {
+				start = prev.ProposedAt
+			}
+		}
+	}
+	if w.Finality.Phase == "unknown" || w.Finality.Phase == "proposed" || w.Finality.Phase == "voted" {
+		if ht := GetHeadTracker(); ht != nil {
+			if _, finalized := ht.Heads(); finalized >= number {
+				w.Finality.Phase, w.Finality.Source = "finalized", "finalized_head"
+			}
+		}
+	}
+
+	if dt := GetDropTracker(); dt != nil {
+		if counts, source, ok := dt.Attribute(start, end); ok {
+			drops := &BlockDrops{From: start.UnixMilli(), To: end.UnixMilli(), Source: source, Reasons: counts}
+			for _, count := range counts {
+				drops.Total += count
+			}
+			w.Drops = drops
+		}
+	}
+
+	w.SankeyFlow = blockWaterfallFlow(w)
+	return w
+}
+
+// blockWaterfallFlow builds the Sankey flow of the block's transactions,
+// stopping at the consensus phase the block has reached
+func blockWaterfallFlow(w BlockWaterfall) SankeyFlow {
+	nodes := []map[string]interface{}{
+		{"id": "submission_rpc", "label": "RPC", "color": "#4CAF50"},
+		{"id": "submission_p2p", "label": "P2P", "color": "#2196F3"},
+		{"id": "submission_unobserved", "label": "Unobserved", "color": "#9E9E9E"},
+		{"id": "mempool", "label": "Mempool", "color": "#FF9800"},
+		{"id": "block_building", "label": "Block Building", "color": "#9C27B0"},
+		{"id": "consensus_proposed", "label": "Proposed", "color": "#3F51B5"},
+		{"id": "consensus_voted", "label": "Voted", "color": "#FFC107"},
+		{"id": "consensus_finalized", "label": "Finalized", "color": "#4CAF50"},
+		{"id": "execution", "label": "Execution", "color": "#F44336"},
+		{"id": "state_update", "label": "State Update", "color": "#00BCD4"},
+		{"id": "finality", "label": "Final (Queryable)", "color": "#8BC34A"},
+		{"id": "dropped", "label": "Reverted", "color": "#757575"},
+	}
+
+	txs := int64(w.Block.TxCount)
+	links := []map[string]interface{}{
+		{"source": "submission_rpc", "target": "mempool", "value": int64(w.Ingress.RPC)},
+		{"source": "submission_p2p", "target": "mempool", "value": int64(w.Ingress.P2P)},
+		{"source": "submission_unobserved", "target": "mempool", "value": int64(w.Ingress.Unobserved)},
+		{"source": "mempool", "target": "block_building", "value": txs},
+		{"source": "block_building", "target": "consensus_proposed", "value": txs},
+	}
+
+	// Execution follows the last phase the block reached
+	reached := "consensus_proposed"
+	switch w.Finality.Phase {
+	case "voted":
+		links = append(links, map[string]interface{}{"source": "consensus_proposed", "target": "consensus_voted", "value": txs})
+		reached = "consensus_voted"
+	case "finalized":
+		links = append(links,
+			map[string]interface{}{"source": "consensus_proposed", "target": "consensus_voted", "value": txs},
+			map[string]interface{}{"source": "consensus_voted", "target": "consensus_finalized", "value": txs},
+		)
+		reached = "consensus_finalized"
+	}
+	if w.Execution.Receipts {
+		executed := int64(w.Execution.Succeeded + w.Execution.Reverted)
+		links = append(links,
+			map[string]interface{}{"source": reached, "target": "execution", "value": executed},
+			map[string]interface{}{"source": "execution", "target": "state_update", "value": int64(w.Execution.Succeeded)},
+			map[string]interface{}{"source": "execution", "target": "dropped", "value": int64(w.Execution.Reverted)},
+		)
+		if reached == "consensus_finalized" {
+			links = append(links, map[string]interface{}{"source": "state_update", "target": "finality", "value": int64(w.Execution.Succeeded)})
+		}
+	}
+
+	waterfall := map[string]interface{}{"nodes": nodes, "links": links}
+	sanitizeWaterfallFlows(waterfall)
+	return SankeyFlow{Nodes: sankeyNodes(waterfall["nodes"]), Links: sankeyLinks(waterfall["links"])}
+}
+
+// handleBlockWaterfall serves the waterfall of one block (:number is a
+// block number or "latest")
+func handleBlockWaterfall(c *gin.Context) {
+	client := GetMonadClient()
+	if client == nil {
+		c.JSON(http.StatusServiceUnavailable, APIError{Error: "Monad client not initialized"})
+		return
+	}
+
+	var number int64
+	if param := c.Param("number"); param == "latest" {
+		head, err := client.GetBlockNumberByTag("latest")
+		if err != nil {
+			c.JSON(http.StatusBadGateway, APIError{Error: err.Error()})
+			return
+		}
+		number = head
+	} else if n, err := strconv.ParseInt(param, 10, 64); err == nil && n >= 0 {
+		number = n
+	} else {
+		c.JSON(http.StatusBadRequest, APIError{Error: "Block number must be a non-negative integer or \"latest\""})
+		return
+	}
+
+	block, err := client.GetBlockByNumber(number)
+	if errors.Is(err, errEmptyResult) {
+		c.JSON(http.StatusNotFound, APIError{Error: "Block not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusBadGateway, APIError{Error: err.Error()})
+		return
+	}
+
+	// Receipts of recent blocks are already summarized; older ones are
+	// fetched, and may not exist yet for a block that is still executing
+	stats, ok := GetExecStatsTracker().ForBlock(number)
+	if !ok {
+		receipts, err := client.GetBlockReceipts(number)
+		if err != nil && !errors.Is(err, errEmptyResult) {
+			c.JSON(http.StatusBadGateway, APIError{Error: err.Error()})
+			return
+		}
+		if err == nil {
+			stats = ComputeBlockExecStats(number, receipts)
+		}
+	}
+	c.JSON(http.StatusOK, buildBlockWaterfall(block, stats))
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Waterfall links carry stable IDs ("source->target") so a frontend can
+// match them across payloads, and a delta against the previous interval so
+// it can animate flow changes. An interval is one collector sample (or one
+// block for block estimates); every payload within it is compared with the
+// same previous interval, so unchanged stream messages stay deduplicated.
+// The previous interval is kept per protocol version, since each version's
+// consumers poll at their own pace and should see the change since the
+// interval they were last served.
+
+// Waterfall protocol versions with their own previous interval
+const (
+	waterfallVersionStream = "stream" // WebSocket (Firedancer and native) and SSE
+	waterfallVersionV1     = "v1"     // /api/v1/waterfall/v2
+	waterfallVersionV2     = "v2"     // /api/v2/waterfall
+)
+
+// waterfallLinkID returns the stable ID of the link from source to target
+func waterfallLinkID(source, target string) string {
+	return source + "->" + target
+}
+
+// RemovedWaterfallLink is a link of the previous interval that has no flow now
+type RemovedWaterfallLink struct {
+	ID       string  `json:"id"`
+	Source   string  `json:"source"`
+	Target   string  `json:"target"`
+	Previous float64 `json:"previous"`
+}
+
+// waterfallDiffState is one protocol version's last two intervals
+type waterfallDiffState struct {
+	interval string
+	current  map[string]waterfallLinkValue
+	previous map[string]waterfallLinkValue // Nil until the second interval
+}
+
+// waterfallLinkValue is a link's flow within an interval
+type waterfallLinkValue struct {
+	source, target string
+	value          float64
+}
+
+// WaterfallDiffer remembers the previous waterfall interval per protocol version
+type WaterfallDiffer struct {
+	mu       sync.Mutex
+	versions map[string]*waterfallDiffState
+}
+
+// Global waterfall differ
+var waterfallDiffer = &WaterfallDiffer{versions: make(map[string]*waterfallDiffState)}
+
+// waterfallIntervalKey identifies the interval a waterfall was generated in
+func waterfallIntervalKey(metadata map[string]interface{}) string {
+	source, _ := metadata["source"].(string)
+	switch {
+	case metadata["last_updated"] != nil:
+		return fmt.Sprintf("%s@%v", source, metadata["last_updated"])
+	case metadata["block_height"] != nil:
+		return fmt.Sprintf("%s#%v", source, metadata["block_height"])
+	}
+	return source
+}
+
+// Apply adds each link's delta against the previous interval served to
+// version, and lists the links that disappeared since
+func (d *WaterfallDiffer) Apply(version string, waterfall map[string]interface{}) {
+	links, _ := waterfall["links"].([]map[string]interface{})
+	metadata, _ := waterfall["metadata"].(map[string]interface{})
+	interval := waterfallIntervalKey(metadata)
+
+	values := make(map[string]waterfallLinkValue, len(links))
+	for _, link := range links {
+		source, _ := link["source"].(string)
+		target, _ := link["target"].(string)
+		link["id"] = waterfallLinkID(source, target)
+		values[link["id"].(string)] = waterfallLinkValue{source: source, target: target, value: numberValue(link["value"])}
+	}
+
+	d.mu.Lock()
+	state, ok := d.versions[version]
+	if !ok {
+		state = &waterfallDiffState{interval: interval}
+		d.versions[version] = state
+	} else if state.interval != interval {
+		state.previous, state.interval = state.current, interval
+	}
+	state.current = values
+	previous := state.previous
+	d.mu.Unlock()
+
+	if metadata != nil {
+		metadata["deltas"] = previous != nil
+	}
+	if previous == nil {
+		return
+	}
+
+	for _, link := range links {
+		id, _ := link["id"].(string)
+		link["delta"] = numberValue(link["value"]) - previous[id].value
+	}
+	removed := make([]RemovedWaterfallLink, 0)
+	for id, link := range previous {
+		if _, ok := values[id]; !ok {
+			removed = append(removed, RemovedWaterfallLink{ID: id, Source: link.source, Target: link.target, Previous: link.value})
+		}
+	}
+	sort.Slice(removed, func(i, j int) bool { return removed[i].ID < removed[j].ID })
+	if len(removed) > 0 {
+		waterfall["removed_links"] = removed
+	}
+}
+
+// GenerateMonadWaterfallFor generates the lifecycle waterfall with link
+// deltas against the previous interval served to version
+func GenerateMonadWaterfallFor(version string) map[string]interface{} {
+	waterfall := GenerateMonadWaterfall()
+	waterfallDiffer.Apply(version, waterfall)
+	return waterfall
+}
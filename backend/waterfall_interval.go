@@ -0,0 +1,186 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// The waterfall turns collector rates into counts per interval. The
+// interval is the measured spacing of the collector's last two samples
+// when there is one, so the counts match the counter deltas actually
+// observed even when the scrape cadence changes or adaptive mode slows it.
+// The nominal interval (WATERFALL_INTERVAL, default the Prometheus cadence)
+// covers the first scrape and sources without samples, and paces interval
+// sampling; it can be changed at runtime through the admin API.
+
+// Bounds for the nominal interval
+const (
+	minWaterfallInterval = time.Second
+	maxWaterfallInterval = time.Minute // The pending transaction meter keeps one minute
+)
+
+// Interval sources
+const (
+	intervalMeasured = "measured" // Spacing of the collector's last two samples
+	intervalNominal  = "nominal"  // No measurement yet
+	intervalBlock    = "block"    // One block (block estimation)
+)
+
+// waterfallNominal is the nominal interval in nanoseconds, zero until
+// InitializeWaterfallInterval runs
+var waterfallNominal atomic.Int64
+
+// waterfallIntervalChanged wakes the interval sampler after a change
+var waterfallIntervalChanged = make(chan struct{}, 1)
+
+// InitializeWaterfallInterval reads the nominal interval from
+// WATERFALL_INTERVAL, defaulting to the Prometheus scrape cadence
+func InitializeWaterfallInterval() {
+	nominal := prometheusCadence.Base
+	if value := os.Getenv("WATERFALL_INTERVAL"); value != "" {
+		if parsed, err := time.ParseDuration(value); err == nil && validWaterfallInterval(parsed) == nil {
+			nominal = parsed
+		} else {
+			log.Printf("Invalid WATERFALL_INTERVAL %q, using %s", value, nominal)
+		}
+	}
+	waterfallNominal.Store(int64(nominal))
+	log.Printf("Waterfall nominal interval: %s", nominal)
+}
+
+// validWaterfallInterval checks a nominal interval against the bounds
+func validWaterfallInterval(d time.Duration) error {
+	if d < minWaterfallInterval || d > maxWaterfallInterval {
+		return fmt.Errorf("interval must be between %s and %s", minWaterfallInterval, maxWaterfallInterval)
+	}
+	return nil
+}
+
+// waterfallNominalInterval returns the nominal interval
+func waterfallNominalInterval() time.Duration {
+	if nominal := waterfallNominal.Load(); nominal > 0 {
+		return time.Duration(nominal)
+	}
+	return prometheusCadence.Base
+}
+
+// SetWaterfallNominalInterval changes the nominal interval at runtime
+func SetWaterfallNominalInterval(d time.Duration) error {
+	if err := validWaterfallInterval(d); err != nil {
+		return err
+	}
+	if previous := time.Duration(waterfallNominal.Swap(int64(d))); previous != d {
+		log.Printf("Waterfall nominal interval changed: %s -> %s", previous, d)
+	}
+	select {
+	case waterfallIntervalChanged <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// WaterfallIntervalInfo is the interval a waterfall's counts cover
+type WaterfallIntervalInfo struct {
+	Seconds        float64 `json:"seconds"`
+	NominalSeconds float64 `json:"nominal_seconds"`
+	Source         string  `json:"source"` // measured, nominal or block
+}
+
+// waterfallIntervalFor picks the rate-to-count interval for a collector
+// whose last two samples were window apart (zero before the second sample)
+func waterfallIntervalFor(window time.Duration) WaterfallIntervalInfo {
+	nominal := waterfallNominalInterval().Seconds()
+	if window > 0 {
+		return WaterfallIntervalInfo{Seconds: window.Seconds(), NominalSeconds: nominal, Source: intervalMeasured}
+	}
+	return WaterfallIntervalInfo{Seconds: nominal, NominalSeconds: nominal, Source: intervalNominal}
+}
+
+// blockWaterfallInterval is the interval of a waterfall estimated from one block
+func blockWaterfallInterval() WaterfallIntervalInfo {
+	return WaterfallIntervalInfo{
+		Seconds:        GetChainParams().BlockTime,
+		NominalSeconds: waterfallNominalInterval().Seconds(),
+		Source:         intervalBlock,
+	}
+}
+
+// Count converts a per-second rate into a count over the interval
+func (i WaterfallIntervalInfo) Count(rate float64) int64 {
+	return int64(rate * i.Seconds)
+}
+
+// Duration returns the interval rounded to whole seconds
+func (i WaterfallIntervalInfo) Duration() time.Duration {
+	return time.Duration(math.Round(i.Seconds)) * time.Second
+}
+
+// annotate records the interval in a waterfall's metadata
+func (i WaterfallIntervalInfo) annotate(metadata map[string]interface{}) {
+	metadata["interval_seconds"] = i.Seconds
+	metadata["nominal_interval_seconds"] = i.NominalSeconds
+	metadata["interval_source"] = i.Source
+}
+
+// WaterfallCadenceResponse is the body of /api/v1/waterfall/cadence
+type WaterfallCadenceResponse struct {
+	NominalSeconds float64                `json:"nominal_seconds"`
+	MinSeconds     float64                `json:"min_seconds"`
+	MaxSeconds     float64                `json:"max_seconds"`
+	Prometheus     *WaterfallIntervalInfo `json:"prometheus,omitempty"` // Interval the Prometheus waterfall uses
+	IPC            *WaterfallIntervalInfo `json:"ipc,omitempty"`        // Interval between IPC polls
+}
+
+// waterfallCadence reports the nominal interval and what each collector measured
+func waterfallCadence() WaterfallCadenceResponse {
+	response := WaterfallCadenceResponse{
+		NominalSeconds: waterfallNominalInterval().Seconds(),
+		MinSeconds:     minWaterfallInterval.Seconds(),
+		MaxSeconds:     maxWaterfallInterval.Seconds(),
+	}
+	if collector := GetPrometheusCollector(); collector != nil {
+		info := waterfallIntervalFor(collector.GetMetrics().RateWindow)
+		response.Prometheus = &info
+	}
+	if collector := GetIPCCollector(); collector != nil {
+		info := waterfallIntervalFor(collector.GetMetrics().PollInterval)
+		response.IPC = &info
+	}
+	return response
+}
+
+// handleWaterfallCadence reports the nominal and measured waterfall intervals
+func handleWaterfallCadence(c *gin.Context) {
+	c.JSON(http.StatusOK, waterfallCadence())
+}
+
+// WaterfallCadenceRequest is the body of PUT /api/v1/admin/waterfall/cadence
+type WaterfallCadenceRequest struct {
+	Interval string `json:"interval"` // Go duration, e.g. "10s"
+}
+
+// handleSetWaterfallCadence changes the nominal waterfall interval (admin)
+func handleSetWaterfallCadence(c *gin.Context) {
+	var req WaterfallCadenceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, APIError{Error: "Invalid request body"})
+		return
+	}
+	interval, err := time.ParseDuration(req.Interval)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, APIError{Error: fmt.Sprintf("Invalid interval %q", req.Interval)})
+		return
+	}
+	if err := SetWaterfallNominalInterval(interval); err != nil {
+		c.JSON(http.StatusBadRequest, APIError{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, waterfallCadence())
+}
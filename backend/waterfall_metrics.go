@@ -66,97 +66,100 @@ func NewWaterfallStageMetrics() *WaterfallStageMetrics {
 	}
 }
 
-// Snapshot returns current values as a map
-func (w *WaterfallStageMetrics) Snapshot() map[string]interface{} {
-	return map[string]interface{}{
-		"net": map[string]interface{}{
-			"rpc_received": w.NetRPCReceived.Load(),
-			"p2p_received": w.NetP2PReceived.Load(),
-			"dropped":      w.NetDropped.Load(),
-		},
-		"verify": map[string]interface{}{
-			"verified":       w.VerifyVerified.Load(),
-			"sig_failed":     w.VerifySigFailed.Load(),
-			"nonce_failed":   w.VerifyNonceFailed.Load(),
-			"balance_failed": w.VerifyBalanceFailed.Load(),
-		},
-		"pool": map[string]interface{}{
-			"queued":      w.PoolQueued.Load(),
-			"promoted":    w.PoolPromoted.Load(),
-			"fee_dropped": w.PoolFeeDropped.Load(),
-			"pool_full":   w.PoolFull.Load(),
-		},
-		"pack": map[string]interface{}{
-			"selected":        w.PackSelected.Load(),
-			"backend_lookups": w.PackBackendLookups.Load(),
-			"excluded":        w.PackExcluded.Load(),
-		},
-		"exec": map[string]interface{}{
-			"parallel_success":     w.ExecParallelSuccess.Load(),
-			"sequential_fallback":  w.ExecSequentialFallback.Load(),
-			"failed":               w.ExecFailed.Load(),
-			"state_reads":          w.ExecStateReads.Load(),
-			"state_writes":         w.ExecStateWrites.Load(),
-		},
-		"state": map[string]interface{}{
-			"accounts_updated": w.StateAccountsUpdated.Load(),
-			"storage_updated":  w.StateStorageUpdated.Load(),
-			"logs_emitted":     w.StateLogsEmitted.Load(),
-		},
-		"block": map[string]interface{}{
-			"proposed":  w.BlockProposed.Load(),
-			"qc_formed": w.BlockQCFormed.Load(),
-			"finalized": w.BlockFinalized.Load(),
-			"rejected":  w.BlockRejected.Load(),
-		},
-		"timing": map[string]interface{}{
-			"verify_latency_ns":    w.VerifyLatencyNs.Load(),
-			"exec_latency_ns":      w.ExecLatencyNs.Load(),
-			"block_exec_latency_ns": w.BlockExecLatencyNs.Load(),
-			"finalize_latency_ns":  w.FinalizeLatencyNs.Load(),
-		},
+// counters lists every counter in snapshot order
+func (w *WaterfallStageMetrics) counters() []waterfallCounter {
+	return []waterfallCounter{
+		{"net", "rpc_received", &w.NetRPCReceived, false},
+		{"net", "p2p_received", &w.NetP2PReceived, false},
+		{"net", "dropped", &w.NetDropped, false},
+
+		{"verify", "verified", &w.VerifyVerified, false},
+		{"verify", "sig_failed", &w.VerifySigFailed, false},
+		{"verify", "nonce_failed", &w.VerifyNonceFailed, false},
+		{"verify", "balance_failed", &w.VerifyBalanceFailed, false},
+
+		{"pool", "queued", &w.PoolQueued, false},
+		{"pool", "promoted", &w.PoolPromoted, false},
+		{"pool", "fee_dropped", &w.PoolFeeDropped, false},
+		{"pool", "pool_full", &w.PoolFull, false},
+
+		{"pack", "selected", &w.PackSelected, false},
+		{"pack", "backend_lookups", &w.PackBackendLookups, false},
+		{"pack", "excluded", &w.PackExcluded, false},
+
+		{"exec", "parallel_success", &w.ExecParallelSuccess, false},
+		{"exec", "sequential_fallback", &w.ExecSequentialFallback, false},
+		{"exec", "failed", &w.ExecFailed, false},
+		{"exec", "state_reads", &w.ExecStateReads, false},
+		{"exec", "state_writes", &w.ExecStateWrites, false},
+
+		{"state", "accounts_updated", &w.StateAccountsUpdated, false},
+		{"state", "storage_updated", &w.StateStorageUpdated, false},
+		{"state", "logs_emitted", &w.StateLogsEmitted, false},
+
+		{"block", "proposed", &w.BlockProposed, false},
+		{"block", "qc_formed", &w.BlockQCFormed, false},
+		{"block", "finalized", &w.BlockFinalized, false},
+		{"block", "rejected", &w.BlockRejected, false},
+
+		{"timing", "verify_latency_ns", &w.VerifyLatencyNs, true},
+		{"timing", "exec_latency_ns", &w.ExecLatencyNs, true},
+		{"timing", "block_exec_latency_ns", &w.BlockExecLatencyNs, true},
+		{"timing", "finalize_latency_ns", &w.FinalizeLatencyNs, true},
 	}
 }
 
+// Snapshot returns current values as a map.
+// Holding the read lock keeps a snapshot from interleaving with a reset.
+func (w *WaterfallStageMetrics) Snapshot() map[string]interface{} {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return buildWaterfallSnapshot(w.counters(), false)
+}
+
 // Reset resets all counters (for periodic sampling)
 func (w *WaterfallStageMetrics) Reset() {
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
-	w.NetRPCReceived.Store(0)
-	w.NetP2PReceived.Store(0)
-	w.NetDropped.Store(0)
-
-	w.VerifyVerified.Store(0)
-	w.VerifySigFailed.Store(0)
-	w.VerifyNonceFailed.Store(0)
-	w.VerifyBalanceFailed.Store(0)
-
-	w.PoolQueued.Store(0)
-	w.PoolPromoted.Store(0)
-	w.PoolFeeDropped.Store(0)
-	w.PoolFull.Store(0)
-
-	w.PackSelected.Store(0)
-	w.PackBackendLookups.Store(0)
-	w.PackExcluded.Store(0)
-
-	w.ExecParallelSuccess.Store(0)
-	w.ExecSequentialFallback.Store(0)
-	w.ExecFailed.Store(0)
-	w.ExecStateReads.Store(0)
-	w.ExecStateWrites.Store(0)
+	for _, c := range w.counters() {
+		if !c.gauge {
+			c.counter.Store(0)
+		}
+	}
+	w.lastReset = time.Now()
+}
 
-	w.StateAccountsUpdated.Store(0)
-	w.StateStorageUpdated.Store(0)
-	w.StateLogsEmitted.Store(0)
+// SnapshotAndReset atomically swaps every counter to zero and returns the
+// pre-reset values along with the interval length
+func (w *WaterfallStageMetrics) SnapshotAndReset() map[string]interface{} {
+	w.mu.Lock()
+	defer w.mu.Unlock()
 
-	w.BlockProposed.Store(0)
-	w.BlockQCFormed.Store(0)
-	w.BlockFinalized.Store(0)
-	w.BlockRejected.Store(0)
+	snapshot := buildWaterfallSnapshot(w.counters(), true)
+	now := time.Now()
+	snapshot["interval_seconds"] = now.Sub(w.lastReset).Seconds()
+	w.lastReset = now
+	return snapshot
+}
 
-	w.lastReset = time.Now()
+// buildWaterfallSnapshot groups counter values into a nested map. With swap,
+// non-gauge counters are atomically exchanged for zero while being read.
+func buildWaterfallSnapshot(counters []waterfallCounter, swap bool) map[string]interface{} {
+	snapshot := make(map[string]interface{})
+	for _, c := range counters {
+		group, ok := snapshot[c.group].(map[string]interface{})
+		if !ok {
+			group = make(map[string]interface{})
+			snapshot[c.group] = group
+		}
+		if swap && !c.gauge {
+			group[c.key] = c.counter.Swap(0)
+		} else {
+			group[c.key] = c.counter.Load()
+		}
+	}
+	return snapshot
 }
 
 // GetElapsedSeconds returns seconds since last reset
@@ -186,36 +189,30 @@ func GetWaterfallMetrics() *WaterfallStageMetrics {
 // GenerateWaterfallFromSubscriber generates waterfall metrics from real-time block data
 // Now with real Prometheus/IPC metrics when available
 func GenerateWaterfallFromSubscriber() map[string]interface{} {
-	// Priority 1: Try Prometheus metrics (most comprehensive)
-	promCollector := GetPrometheusCollector()
-	if promCollector != nil && promCollector.IsHealthy() {
-		promMetrics := promCollector.GetMetrics()
-		// Check if we have ACTIVE txpool metrics in Prometheus
-		// Only use Prometheus if there's actual activity (rate > 0)
-		if promMetrics.TPS60s > 0 && (promMetrics.InsertOwnedTxsRate > 0 || promMetrics.InsertForwardedTxsRate > 0) {
-			return generateWaterfallFromPrometheus(promMetrics)
-		}
-		// If TPS > 0 but insert rates are 0, fall through to estimation
-		// This happens when counters aren't updating but blocks are being produced
+	source, provenance := GetSourceResolver().Resolve(MetricWaterfall)
+
+	var waterfall map[string]interface{}
+	switch {
+	case source == nil:
+		waterfall = generateMockWaterfall()
+	case source.Name() == "prometheus_metrics":
+		waterfall = generateWaterfallFromPrometheus(GetPrometheusCollector().GetMetrics())
+	case source.Name() == "real_ipc_metrics":
+		waterfall = generateWaterfallFromRealMetrics(GetIPCCollector().GetMetrics())
+	case source.Name() == "block_estimation":
+		waterfall = generateWaterfallFromBlock(GetSubscriber().GetLatestBlock())
+	default:
+		waterfall = generateMockWaterfall()
 	}
 
-	// Priority 2: Try IPC metrics
-	ipcCollector := GetIPCCollector()
-	if ipcCollector != nil && ipcCollector.IsHealthy() {
-		return generateWaterfallFromRealMetrics(ipcCollector.GetMetrics())
-	}
-
-	// Priority 3: Fallback to block-based estimation
-	if monadSubscriber == nil || !monadSubscriber.IsConnected() {
-		return generateMockWaterfall()
-	}
-
-	// Get latest block
-	block := monadSubscriber.GetLatestBlock()
-	if block == nil {
-		return generateMockWaterfall()
+	if metadata, ok := waterfall["metadata"].(map[string]interface{}); ok {
+		metadata["provenance"] = provenance
 	}
+	return waterfall
+}
 
+// generateWaterfallFromBlock estimates the legacy waterfall from the latest block
+func generateWaterfallFromBlock(block *BlockHeader) map[string]interface{} {
 	// Calculate realistic waterfall based on actual transaction data
 	txCount := int64(block.Transactions)
 
@@ -285,10 +282,13 @@ func GenerateWaterfallFromSubscriber() map[string]interface{} {
 			"block_finalized":    finalized,
 		},
 		"metadata": map[string]interface{}{
+			"source":             "block_estimation",
 			"block_height":       block.Number,
 			"block_hash":         block.Hash,
 			"block_txs":          block.Transactions,
 			"timestamp":          block.Timestamp,
+			"interval_seconds":   blockWaterfallInterval().Seconds,
+			"interval_source":    intervalBlock,
 		},
 	}
 }
@@ -296,35 +296,35 @@ func GenerateWaterfallFromSubscriber() map[string]interface{} {
 // generateWaterfallFromPrometheus generates waterfall from Prometheus metrics
 func generateWaterfallFromPrometheus(metrics *PrometheusMetrics) map[string]interface{} {
 	// Use RATE values (not cumulative totals!) for waterfall visualization
-	// Multiply by 5 seconds (collection interval) to get counts per interval
-	interval := 5.0
+	// Multiply by the time between the last two scrapes to get counts per interval
+	interval := waterfallIntervalFor(metrics.RateWindow)
 
-	insertOwnedCount := int64(metrics.InsertOwnedTxsRate * interval)
-	insertForwardedCount := int64(metrics.InsertForwardedTxsRate * interval)
-	dropSigCount := int64(metrics.DropInvalidSignatureRate * interval)
-	dropNonceCount := int64(metrics.DropNonceTooLowRate * interval)
-	dropBalanceCount := int64(metrics.DropInsufficientBalanceRate * interval)
-	dropFeeCount := int64(metrics.DropFeeTooLowRate * interval)
-	dropPoolFullCount := int64(metrics.DropPoolFullRate * interval)
+	insertOwnedCount := interval.Count(metrics.InsertOwnedTxsRate)
+	insertForwardedCount := interval.Count(metrics.InsertForwardedTxsRate)
+	dropSigCount := interval.Count(metrics.DropInvalidSignatureRate)
+	dropNonceCount := interval.Count(metrics.DropNonceTooLowRate)
+	dropBalanceCount := interval.Count(metrics.DropInsufficientBalanceRate)
+	dropFeeCount := interval.Count(metrics.DropFeeTooLowRate)
+	dropPoolFullCount := interval.Count(metrics.DropPoolFullRate)
 
 	// Calculate successful txs (TPS * interval)
-	successfulTxs := int64(metrics.TPS60s * interval)
+	successfulTxs := interval.Count(metrics.TPS60s)
 
 	return map[string]interface{}{
 		"in": map[string]interface{}{
-			"rpc":    insertOwnedCount,    // ✅ Real: RPC transactions (per 5s)
-			"p2p":    insertForwardedCount, // ✅ Real: P2P transactions (per 5s)
+			"rpc":    insertOwnedCount,    // ✅ Real: RPC transactions (per interval)
+			"p2p":    insertForwardedCount, // ✅ Real: P2P transactions (per interval)
 			"gossip": insertForwardedCount,
 		},
 		"out": map[string]interface{}{
 			// Verification stage - Real counters from Prometheus!
-			"verify_failed":      dropSigCount,     // ✅ Real (per 5s)
-			"nonce_failed":       dropNonceCount,   // ✅ Real (per 5s)
-			"balance_failed":     dropBalanceCount, // ✅ Real (per 5s)
+			"verify_failed":      dropSigCount,     // ✅ Real (per interval)
+			"nonce_failed":       dropNonceCount,   // ✅ Real (per interval)
+			"balance_failed":     dropBalanceCount, // ✅ Real (per interval)
 
 			// Pool stage - Real counters from Prometheus!
-			"pool_fee_dropped":   dropFeeCount,     // ✅ Real (per 5s)
-			"pool_full":          dropPoolFullCount, // ✅ Real (per 5s)
+			"pool_fee_dropped":   dropFeeCount,     // ✅ Real (per interval)
+			"pool_full":          dropPoolFullCount, // ✅ Real (per interval)
 
 			// Execution stage - calculated from successful txs
 			"exec_parallel":      int64(float64(successfulTxs) * 0.85),  // 85% parallel (estimate)
@@ -336,9 +336,9 @@ func generateWaterfallFromPrometheus(metrics *PrometheusMetrics) map[string]inte
 			"state_writes":       successfulTxs,      // ~1 write per tx
 			"logs_emitted":       successfulTxs / 3,  // ~33% emit logs
 
-			// Block stage (blocks per 5s interval)
-			"block_proposed":     int64(interval / 0.4),  // ~12 blocks per 5s (0.4s block time)
-			"block_finalized":    int64(interval / 0.4),
+			// Block stage (blocks per interval)
+			"block_proposed":     int64(interval.Seconds / GetChainParams().BlockTime),
+			"block_finalized":    int64(interval.Seconds / GetChainParams().BlockTime),
 		},
 		"metadata": map[string]interface{}{
 			"source":       "prometheus_metrics",
@@ -346,7 +346,11 @@ func generateWaterfallFromPrometheus(metrics *PrometheusMetrics) map[string]inte
 			"pending_txs":  int64(metrics.PendingTxs),
 			"tracked_txs":  int64(metrics.TrackedTxs),
 			"tps":          metrics.TPS60s,
-			"interval_seconds": interval,
+			"interval_seconds":         interval.Seconds,
+			"nominal_interval_seconds": interval.NominalSeconds,
+			"interval_source":          interval.Source,
+			"discontinuous":            metrics.Discontinuous,
+			"reset_counters":           metrics.ResetCounters,
 		},
 	}
 }
@@ -389,6 +393,8 @@ func generateWaterfallFromRealMetrics(metrics *MonadRealMetrics) map[string]inte
 			"last_updated": metrics.LastUpdated.Unix(),
 			"pending_txs":  metrics.PendingTxs,
 			"tracked_txs":  metrics.TrackedTxs,
+			"discontinuous":  metrics.Discontinuous,
+			"reset_counters": metrics.ResetCounters,
 		},
 	}
 }
@@ -417,7 +423,9 @@ func generateMockWaterfall() map[string]interface{} {
 			"block_finalized":    int64(1),
 		},
 		"metadata": map[string]interface{}{
-			"source": "mock_data",
+			"source":           "mock_data",
+			"interval_seconds": waterfallNominalInterval().Seconds(),
+			"interval_source":  intervalNominal,
 		},
 	}
 }
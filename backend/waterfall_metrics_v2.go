@@ -70,106 +70,95 @@ func NewMonadWaterfallMetrics() *MonadWaterfallMetrics {
 	}
 }
 
-// Snapshot returns current values as a structured map
-func (m *MonadWaterfallMetrics) Snapshot() map[string]interface{} {
-	return map[string]interface{}{
-		"submission": map[string]interface{}{
-			"rpc_received":     m.SubmissionRPCReceived.Load(),
-			"p2p_received":     m.SubmissionP2PReceived.Load(),
-			"invalid_sig":      m.SubmissionInvalidSig.Load(),
-			"invalid_format":   m.SubmissionInvalidFormat.Load(),
-		},
-		"mempool": map[string]interface{}{
-			"received":            m.MempoolReceived.Load(),
-			"nonce_invalid":       m.MempoolNonceInvalid.Load(),
-			"gas_too_high":        m.MempoolGasTooHigh.Load(),
-			"propagation_failed":  m.MempoolPropagationFailed.Load(),
-			"to_block_building":   m.MempoolToBlockBuilding.Load(),
-		},
-		"block_building": map[string]interface{}{
-			"received":              m.BlockBuildingReceived.Load(),
-			"insufficient_balance":  m.BlockBuildingInsufficientBalance.Load(),
-			"nonce_gap":             m.BlockBuildingNonceGap.Load(),
-			"block_full":            m.BlockBuildingBlockFull.Load(),
-			"to_consensus":          m.BlockBuildingToConsensus.Load(),
-		},
-		"consensus": map[string]interface{}{
-			"proposed":       m.ConsensusProposed.Load(),
-			"voted":          m.ConsensusVoted.Load(),
-			"finalized":      m.ConsensusFinalized.Load(),
-			"rejected":       m.ConsensusRejected.Load(),
-			"to_execution":   m.ConsensusToExecution.Load(),
-		},
-		"execution": map[string]interface{}{
-			"parallel_success":  m.ExecutionParallelSuccess.Load(),
-			"parallel_retry":    m.ExecutionParallelRetry.Load(),
-			"reverted":          m.ExecutionReverted.Load(),
-			"to_state_update":   m.ExecutionToStateUpdate.Load(),
-		},
-		"state_update": map[string]interface{}{
-			"accounts_updated": m.StateAccountsUpdated.Load(),
-			"storage_writes":   m.StateStorageWrites.Load(),
-			"logs_emitted":     m.StateLogsEmitted.Load(),
-			"to_finality":      m.StateToFinality.Load(),
-		},
-		"finality": map[string]interface{}{
-			"queryable":          m.FinalityQueryable.Load(),
-			"receipts_generated": m.FinalityReceiptsGenerated.Load(),
-		},
-		"timing": map[string]interface{}{
-			"mempool_propagation_latency_ns": m.MempoolPropagationLatencyNs.Load(),
-			"consensus_latency_ns":           m.ConsensusLatencyNs.Load(),
-			"execution_latency_ns":           m.ExecutionLatencyNs.Load(),
-			"finality_latency_ns":            m.FinalityLatencyNs.Load(),
-		},
+// waterfallCounter names one counter within a snapshot group
+type waterfallCounter struct {
+	group   string
+	key     string
+	counter *atomic.Int64
+	gauge   bool // Gauges (timings) are reported but never reset
+}
+
+// counters lists every counter in snapshot order
+func (m *MonadWaterfallMetrics) counters() []waterfallCounter {
+	return []waterfallCounter{
+		{"submission", "rpc_received", &m.SubmissionRPCReceived, false},
+		{"submission", "p2p_received", &m.SubmissionP2PReceived, false},
+		{"submission", "invalid_sig", &m.SubmissionInvalidSig, false},
+		{"submission", "invalid_format", &m.SubmissionInvalidFormat, false},
+
+		{"mempool", "received", &m.MempoolReceived, false},
+		{"mempool", "nonce_invalid", &m.MempoolNonceInvalid, false},
+		{"mempool", "gas_too_high", &m.MempoolGasTooHigh, false},
+		{"mempool", "propagation_failed", &m.MempoolPropagationFailed, false},
+		{"mempool", "to_block_building", &m.MempoolToBlockBuilding, false},
+
+		{"block_building", "received", &m.BlockBuildingReceived, false},
+		{"block_building", "insufficient_balance", &m.BlockBuildingInsufficientBalance, false},
+		{"block_building", "nonce_gap", &m.BlockBuildingNonceGap, false},
+		{"block_building", "block_full", &m.BlockBuildingBlockFull, false},
+		{"block_building", "to_consensus", &m.BlockBuildingToConsensus, false},
+
+		{"consensus", "proposed", &m.ConsensusProposed, false},
+		{"consensus", "voted", &m.ConsensusVoted, false},
+		{"consensus", "finalized", &m.ConsensusFinalized, false},
+		{"consensus", "rejected", &m.ConsensusRejected, false},
+		{"consensus", "to_execution", &m.ConsensusToExecution, false},
+
+		{"execution", "parallel_success", &m.ExecutionParallelSuccess, false},
+		{"execution", "parallel_retry", &m.ExecutionParallelRetry, false},
+		{"execution", "reverted", &m.ExecutionReverted, false},
+		{"execution", "to_state_update", &m.ExecutionToStateUpdate, false},
+
+		{"state_update", "accounts_updated", &m.StateAccountsUpdated, false},
+		{"state_update", "storage_writes", &m.StateStorageWrites, false},
+		{"state_update", "logs_emitted", &m.StateLogsEmitted, false},
+		{"state_update", "to_finality", &m.StateToFinality, false},
+
+		{"finality", "queryable", &m.FinalityQueryable, false},
+		{"finality", "receipts_generated", &m.FinalityReceiptsGenerated, false},
+
+		{"timing", "mempool_propagation_latency_ns", &m.MempoolPropagationLatencyNs, true},
+		{"timing", "consensus_latency_ns", &m.ConsensusLatencyNs, true},
+		{"timing", "execution_latency_ns", &m.ExecutionLatencyNs, true},
+		{"timing", "finality_latency_ns", &m.FinalityLatencyNs, true},
 	}
 }
 
+// Snapshot returns current values as a structured map.
+// Holding the read lock keeps a snapshot from interleaving with a reset.
+func (m *MonadWaterfallMetrics) Snapshot() map[string]interface{} {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return buildWaterfallSnapshot(m.counters(), false)
+}
+
 // Reset resets all counters
 func (m *MonadWaterfallMetrics) Reset() {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	// Reset all atomic counters
-	m.SubmissionRPCReceived.Store(0)
-	m.SubmissionP2PReceived.Store(0)
-	m.SubmissionInvalidSig.Store(0)
-	m.SubmissionInvalidFormat.Store(0)
-
-	m.MempoolReceived.Store(0)
-	m.MempoolNonceInvalid.Store(0)
-	m.MempoolGasTooHigh.Store(0)
-	m.MempoolPropagationFailed.Store(0)
-	m.MempoolToBlockBuilding.Store(0)
-
-	m.BlockBuildingReceived.Store(0)
-	m.BlockBuildingInsufficientBalance.Store(0)
-	m.BlockBuildingNonceGap.Store(0)
-	m.BlockBuildingBlockFull.Store(0)
-	m.BlockBuildingToConsensus.Store(0)
-
-	m.ConsensusProposed.Store(0)
-	m.ConsensusVoted.Store(0)
-	m.ConsensusFinalized.Store(0)
-	m.ConsensusRejected.Store(0)
-	m.ConsensusToExecution.Store(0)
-
-	m.ExecutionParallelSuccess.Store(0)
-	m.ExecutionParallelRetry.Store(0)
-	m.ExecutionReverted.Store(0)
-	m.ExecutionToStateUpdate.Store(0)
-
-	m.StateAccountsUpdated.Store(0)
-	m.StateStorageWrites.Store(0)
-	m.StateLogsEmitted.Store(0)
-	m.StateToFinality.Store(0)
-
-	m.FinalityQueryable.Store(0)
-	m.FinalityReceiptsGenerated.Store(0)
-
+	for _, c := range m.counters() {
+		if !c.gauge {
+			c.counter.Store(0)
+		}
+	}
 	m.lastReset = time.Now()
 }
 
+// SnapshotAndReset atomically swaps every counter to zero and returns the
+// pre-reset values, so no increments are lost or double-counted between
+// sampling intervals. The interval length is reported as "interval_seconds".
+func (m *MonadWaterfallMetrics) SnapshotAndReset() map[string]interface{} {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	snapshot := buildWaterfallSnapshot(m.counters(), true)
+	now := time.Now()
+	snapshot["interval_seconds"] = now.Sub(m.lastReset).Seconds()
+	m.lastReset = now
+	return snapshot
+}
+
 // GetElapsedSeconds returns seconds since last reset
 func (m *MonadWaterfallMetrics) GetElapsedSeconds() float64 {
 	m.mu.RLock()
@@ -197,52 +186,82 @@ func GetMonadWaterfallMetrics() *MonadWaterfallMetrics {
 // GenerateMonadWaterfall generates waterfall data matching Monad's transaction lifecycle
 // Priority: Prometheus > IPC > Block Estimation > Mock
 func GenerateMonadWaterfall() map[string]interface{} {
-	// Priority 1: Try Prometheus metrics (most comprehensive)
-	promCollector := GetPrometheusCollector()
-	if promCollector != nil && promCollector.IsHealthy() {
-		promMetrics := promCollector.GetMetrics()
-		// Check if we have ACTIVE txpool metrics
-		if promMetrics.TPS60s > 0 && (promMetrics.InsertOwnedTxsRate > 0 || promMetrics.InsertForwardedTxsRate > 0) {
-			return generateMonadWaterfallFromPrometheus(promMetrics)
+	waterfall := generateMonadWaterfallFromSources()
+	sanitizeWaterfallFlows(waterfall)
+
+	// Fee flow segment (burned vs. validator) from the latest block's receipts
+	if ft := GetFeeTracker(); ft != nil {
+		if feeFlow := ft.FeeFlow(); feeFlow != nil {
+			waterfall["fee_flow"] = feeFlow
 		}
 	}
 
-	// Priority 2: Try IPC metrics
-	ipcCollector := GetIPCCollector()
-	if ipcCollector != nil && ipcCollector.IsHealthy() {
-		return generateMonadWaterfallFromIPC(ipcCollector.GetMetrics())
+	// Real latency distributions from Prometheus histograms/summaries
+	if promCollector := GetPrometheusCollector(); promCollector != nil && promCollector.IsHealthy() {
+		if timing := promCollector.GetMetrics().TimingDistributions(); len(timing) > 0 {
+			waterfall["timing"] = timing
+		}
 	}
 
-	// Priority 3: Fallback to block-based estimation
-	if monadSubscriber != nil && monadSubscriber.IsConnected() {
-		block := monadSubscriber.GetLatestBlock()
-		if block != nil {
-			return generateMonadWaterfallFromBlock(block)
-		}
+	// Leader-slot awareness for the local validator
+	if lt := GetLeaderTracker(); lt != nil {
+		waterfall["leader"] = lt.LeaderMetadata()
 	}
 
-	// Priority 4: Mock data for testing
-	return generateMonadMockWaterfall()
+	return waterfall
+}
+
+// generateMonadWaterfallFromSources picks the best available data source
+// through the source resolver and records its provenance in the metadata
+func generateMonadWaterfallFromSources() map[string]interface{} {
+	source, provenance := GetSourceResolver().Resolve(MetricWaterfall)
+
+	var waterfall map[string]interface{}
+	switch {
+	case source == nil:
+		waterfall = generateMonadMockWaterfall()
+	case source.Name() == "prometheus_metrics":
+		waterfall = generateMonadWaterfallFromPrometheus(GetPrometheusCollector().GetMetrics())
+	case source.Name() == "real_ipc_metrics":
+		waterfall = generateMonadWaterfallFromIPC(GetIPCCollector().GetMetrics())
+	case source.Name() == "block_estimation":
+		waterfall = generateMonadWaterfallFromBlock(GetSubscriber().GetLatestBlock())
+	default:
+		waterfall = generateMonadMockWaterfall()
+	}
+
+	if metadata, ok := waterfall["metadata"].(map[string]interface{}); ok {
+		metadata["provenance"] = provenance
+	}
+	return waterfall
 }
 
 // generateMonadWaterfallFromPrometheus generates Monad-aligned waterfall from Prometheus metrics
 func generateMonadWaterfallFromPrometheus(metrics *PrometheusMetrics) map[string]interface{} {
-	// Collection interval for rate-to-count conversion
-	interval := 5.0
+	// Rates cover the time between the last two scrapes
+	interval := waterfallIntervalFor(metrics.RateWindow)
 
 	// Stage 1: Submission
-	rpcReceived := int64(metrics.InsertOwnedTxsRate * interval)
-	p2pReceived := int64(metrics.InsertForwardedTxsRate * interval)
-	invalidSig := int64(metrics.DropInvalidSignatureRate * interval)
+	rpcReceived := interval.Count(metrics.InsertOwnedTxsRate)
+	p2pReceived := interval.Count(metrics.InsertForwardedTxsRate)
+	invalidSig := interval.Count(metrics.DropInvalidSignatureRate)
+
+	// Measured ingress from the pending transaction subscription replaces
+	// the counter rates; the counters still decide the RPC/P2P split
+	submissionSource := "txpool_counters"
+	if measured, ok := GetPendingTxMeter().Measured(interval.Duration()); ok {
+		rpcReceived, p2pReceived = splitSubmission(measured, rpcReceived, p2pReceived)
+		submissionSource = "pending_subscription"
+	}
 
 	// Stage 2: Mempool
 	toMempool := rpcReceived + p2pReceived - invalidSig
-	nonceInvalid := int64(metrics.DropNonceTooLowRate * interval)
+	nonceInvalid := interval.Count(metrics.DropNonceTooLowRate)
 
 	// Stage 3: Block Building
-	insufficientBalance := int64(metrics.DropInsufficientBalanceRate * interval)
-	blockFull := int64(metrics.DropPoolFullRate * interval)
-	feeDropped := int64(metrics.DropFeeTooLowRate * interval)
+	insufficientBalance := interval.Count(metrics.DropInsufficientBalanceRate)
+	blockFull := interval.Count(metrics.DropPoolFullRate)
+	feeDropped := interval.Count(metrics.DropFeeTooLowRate)
 
 	// Stage 4: Consensus - get from ConsensusTracker
 	consensusTracker := GetConsensusTracker()
@@ -270,122 +289,66 @@ func generateMonadWaterfallFromPrometheus(metrics *PrometheusMetrics) map[string
 	toStateUpdate := toExecution
 	toFinality := toStateUpdate
 
-	// Build links array for Sankey diagram
-	links := []map[string]interface{}{}
-
-	// Submission → Mempool
-	if rpcReceived > 0 {
-		links = append(links, map[string]interface{}{
-			"source": "submission_rpc",
-			"target": "mempool",
-			"value":  rpcReceived,
-		})
-	}
-	if p2pReceived > 0 {
-		links = append(links, map[string]interface{}{
-			"source": "submission_p2p",
-			"target": "mempool",
-			"value":  p2pReceived,
-		})
-	}
+	// Build links array for Sankey diagram; non-positive flows are
+	// clamped and dropped by sanitizeWaterfallFlows
+	links := []map[string]interface{}{
+		// Submission → Mempool
+		{"source": "submission_rpc", "target": "mempool", "value": rpcReceived},
+		{"source": "submission_p2p", "target": "mempool", "value": p2pReceived},
 
-	// Mempool → Block Building / Dropped
-	if toBlockBuilding > 0 {
-		links = append(links, map[string]interface{}{
-			"source": "mempool",
-			"target": "block_building",
-			"value":  toBlockBuilding,
-		})
-	}
-	if invalidSig+nonceInvalid > 0 {
-		links = append(links, map[string]interface{}{
-			"source": "mempool",
-			"target": "dropped",
-			"value":  invalidSig + nonceInvalid,
-		})
-	}
+		// Mempool → Block Building / Dropped
+		{"source": "mempool", "target": "block_building", "value": toBlockBuilding},
+		{"source": "mempool", "target": "dropped", "value": invalidSig + nonceInvalid},
 
-	// Block Building → Consensus / Dropped
-	if toConsensus > 0 {
-		links = append(links, map[string]interface{}{
-			"source": "block_building",
-			"target": "consensus_proposed",
-			"value":  toConsensus,
-		})
-	}
-	if insufficientBalance+blockFull+feeDropped > 0 {
-		links = append(links, map[string]interface{}{
-			"source": "block_building",
-			"target": "dropped",
-			"value":  insufficientBalance + blockFull + feeDropped,
-		})
-	}
+		// Block Building → Consensus / Dropped
+		{"source": "block_building", "target": "consensus_proposed", "value": toConsensus},
+		{"source": "block_building", "target": "dropped", "value": insufficientBalance + blockFull + feeDropped},
 
-	// Consensus: Proposed → Voted → Finalized
-	if toConsensus > 0 {
-		links = append(links, map[string]interface{}{
-			"source": "consensus_proposed",
-			"target": "consensus_voted",
-			"value":  toConsensus,
-		})
-		links = append(links, map[string]interface{}{
-			"source": "consensus_voted",
-			"target": "consensus_finalized",
-			"value":  toConsensus,
-		})
-		links = append(links, map[string]interface{}{
-			"source": "consensus_finalized",
-			"target": "execution",
-			"value":  toExecution,
-		})
-	}
+		// Consensus: Proposed → Voted → Finalized
+		{"source": "consensus_proposed", "target": "consensus_voted", "value": toConsensus},
+		{"source": "consensus_voted", "target": "consensus_finalized", "value": toConsensus},
+		{"source": "consensus_finalized", "target": "execution", "value": toExecution},
 
-	// Execution → State Update
-	if toStateUpdate > 0 {
-		links = append(links, map[string]interface{}{
-			"source": "execution",
-			"target": "state_update",
-			"value":  toStateUpdate,
-		})
-	}
+		// Execution → State Update
+		{"source": "execution", "target": "state_update", "value": toStateUpdate},
 
-	// State Update → Finality
-	if toFinality > 0 {
-		links = append(links, map[string]interface{}{
-			"source": "state_update",
-			"target": "finality",
-			"value":  toFinality,
-		})
+		// State Update → Finality
+		{"source": "state_update", "target": "finality", "value": toFinality},
 	}
 
 	// Get latest block for block height
 	var blockHeight int64
 	var blockHash string
-	if monadSubscriber != nil && monadSubscriber.IsConnected() {
-		if block := monadSubscriber.GetLatestBlock(); block != nil {
+	if subscriber := GetSubscriber(); subscriber != nil && subscriber.IsConnected() {
+		if block := subscriber.GetLatestBlock(); block != nil {
 			blockHeight = block.Number
 			blockHash = block.Hash
 		}
 	}
 
+	metadata := map[string]interface{}{
+		"source":            "prometheus_metrics",
+		"last_updated":      metrics.LastUpdated.Unix(),
+		"tps":               metrics.TPS60s,
+		"pending_txs":       int64(metrics.PendingTxs),
+		"tracked_txs":       int64(metrics.TrackedTxs),
+		"discontinuous":     metrics.Discontinuous,
+		"reset_counters":    metrics.ResetCounters,
+		"consensus_state":   consensusState,
+		// Add fields for MonadMetrics component
+		"rpc_submit":        rpcReceived,
+		"p2p_gossip":        p2pReceived,
+		"submission_source": submissionSource,
+		"blocks_committed":  blockHeight,
+		"block_height":      blockHeight,
+		"block_hash":        blockHash,
+	}
+	interval.annotate(metadata)
+
 	return map[string]interface{}{
-		"nodes": nodes,
-		"links": links,
-		"metadata": map[string]interface{}{
-			"source":            "prometheus_metrics",
-			"last_updated":      metrics.LastUpdated.Unix(),
-			"tps":               metrics.TPS60s,
-			"pending_txs":       int64(metrics.PendingTxs),
-			"tracked_txs":       int64(metrics.TrackedTxs),
-			"interval_seconds":  interval,
-			"consensus_state":   consensusState,
-			// Add fields for MonadMetrics component
-			"rpc_submit":        rpcReceived,
-			"p2p_gossip":        p2pReceived,
-			"blocks_committed":  blockHeight,
-			"block_height":      blockHeight,
-			"block_hash":        blockHash,
-		},
+		"nodes":    nodes,
+		"links":    links,
+		"metadata": metadata,
 		"drops": map[string]interface{}{
 			"invalid_signature":     invalidSig,
 			"nonce_invalid":         nonceInvalid,
@@ -420,6 +383,14 @@ func generateMonadWaterfallFromBlock(block *BlockHeader) map[string]interface{}
 	toBlockBuilding := toMempool - nonceInvalid
 	toConsensus := toBlockBuilding - insufficientBalance
 
+	// Receipts give the real execution outcome once the block is processed
+	executed, reverted := toConsensus, int64(0)
+	execStats, hasReceipts := GetExecStatsTracker().ForBlock(block.Number)
+	if hasReceipts {
+		executed = int64(execStats.Succeeded)
+		reverted = int64(execStats.Reverted)
+	}
+
 	nodes := []map[string]interface{}{
 		{"id": "submission_rpc", "label": "RPC", "color": "#4CAF50"},
 		{"id": "submission_p2p", "label": "P2P", "color": "#2196F3"},
@@ -443,24 +414,33 @@ func generateMonadWaterfallFromBlock(block *BlockHeader) map[string]interface{}
 		{"source": "block_building", "target": "dropped", "value": insufficientBalance},
 		{"source": "consensus_proposed", "target": "consensus_voted", "value": toConsensus},
 		{"source": "consensus_voted", "target": "consensus_finalized", "value": toConsensus},
-		{"source": "consensus_finalized", "target": "execution", "value": toConsensus},
-		{"source": "execution", "target": "state_update", "value": toConsensus},
-		{"source": "state_update", "target": "finality", "value": toConsensus},
+		{"source": "consensus_finalized", "target": "execution", "value": executed + reverted},
+		{"source": "execution", "target": "state_update", "value": executed},
+		{"source": "state_update", "target": "finality", "value": executed},
+	}
+	if reverted > 0 {
+		links = append(links, map[string]interface{}{"source": "execution", "target": "dropped", "value": reverted})
 	}
 
 	consensusTracker := GetConsensusTracker()
 
+	metadata := map[string]interface{}{
+		"source":         "block_estimation",
+		"block_height":   block.Number,
+		"block_hash":     block.Hash,
+		"block_txs":      block.Transactions,
+		"timestamp":      block.Timestamp,
+		"consensus_state": consensusTracker.GetConsensusState(),
+	}
+	if hasReceipts {
+		metadata["execution_stats"] = execStats
+	}
+	blockWaterfallInterval().annotate(metadata)
+
 	return map[string]interface{}{
-		"nodes": nodes,
-		"links": links,
-		"metadata": map[string]interface{}{
-			"source":         "block_estimation",
-			"block_height":   block.Number,
-			"block_hash":     block.Hash,
-			"block_txs":      block.Transactions,
-			"timestamp":      block.Timestamp,
-			"consensus_state": consensusTracker.GetConsensusState(),
-		},
+		"nodes":    nodes,
+		"links":    links,
+		"metadata": metadata,
 	}
 }
 
@@ -495,19 +475,22 @@ func generateMonadMockWaterfall() map[string]interface{} {
 		{"source": "state_update", "target": "finality", "value": 925},
 	}
 
-	return map[string]interface{}{
-		"nodes": nodes,
-		"links": links,
-		"metadata": map[string]interface{}{
-			"source": "mock_data",
-			"consensus_state": map[string]interface{}{
-				"current_block":    100,
-				"finalized_block":  98,
-				"blocks_behind":    2,
-				"proposed_blocks":  1,
-				"voted_blocks":     1,
-				"finalized_blocks": 1,
-			},
+	metadata := map[string]interface{}{
+		"source": "mock_data",
+		"consensus_state": map[string]interface{}{
+			"current_block":    100,
+			"finalized_block":  98,
+			"blocks_behind":    2,
+			"proposed_blocks":  1,
+			"voted_blocks":     1,
+			"finalized_blocks": 1,
 		},
 	}
+	waterfallIntervalFor(0).annotate(metadata)
+
+	return map[string]interface{}{
+		"nodes":    nodes,
+		"links":    links,
+		"metadata": metadata,
+	}
 }
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WaterfallInterval is one sampled interval of the event-driven waterfall counters
+type WaterfallInterval struct {
+	SampledAt time.Time              `json:"sampled_at"`
+	Lifecycle map[string]interface{} `json:"lifecycle"` // MonadWaterfallMetrics
+	Stages    map[string]interface{} `json:"stages"`    // WaterfallStageMetrics
+}
+
+var (
+	lastWaterfallInterval   *WaterfallInterval
+	lastWaterfallIntervalMu sync.RWMutex
+)
+
+// sampleWaterfallInterval snapshots and resets both waterfall counter sets,
+// keeping the result as the latest completed interval
+func sampleWaterfallInterval() *WaterfallInterval {
+	interval := &WaterfallInterval{
+		SampledAt: time.Now(),
+		Lifecycle: GetMonadWaterfallMetrics().SnapshotAndReset(),
+		Stages:    GetWaterfallMetrics().SnapshotAndReset(),
+	}
+
+	lastWaterfallIntervalMu.Lock()
+	lastWaterfallInterval = interval
+	lastWaterfallIntervalMu.Unlock()
+
+	if exporter := GetAnalyticsExporter(); exporter != nil {
+		exporter.ObserveWaterfallInterval(interval)
+	}
+
+	return interval
+}
+
+// GetLastWaterfallInterval returns the latest completed interval, or nil
+func GetLastWaterfallInterval() *WaterfallInterval {
+	lastWaterfallIntervalMu.RLock()
+	defer lastWaterfallIntervalMu.RUnlock()
+	return lastWaterfallInterval
+}
+
+// StartWaterfallSampling closes waterfall counter intervals at the nominal
+// waterfall interval, following runtime changes to it
+func StartWaterfallSampling() {
+	go func() {
+		log.Printf("Waterfall interval sampling started (every %s)", waterfallNominalInterval())
+		for {
+			timer := time.NewTimer(waterfallNominalInterval())
+			select {
+			case <-timer.C:
+				sampleWaterfallInterval()
+			case <-waterfallIntervalChanged:
+				// Close the running interval at the old cadence so the next
+				// one starts fresh at the new one
+				timer.Stop()
+				sampleWaterfallInterval()
+			case <-shutdownContext().Done():
+				timer.Stop()
+				return
+			}
+		}
+	}()
+}
+
+// handleWaterfallSnapshotReset closes the current interval on demand and
+// returns the pre-reset counters (admin)
+func handleWaterfallSnapshotReset(c *gin.Context) {
+	c.JSON(http.StatusOK, sampleWaterfallInterval())
+}
+
+// handleWaterfallInterval returns the latest completed sampling interval
+func handleWaterfallInterval(c *gin.Context) {
+	interval := GetLastWaterfallInterval()
+	if interval == nil {
+		c.JSON(http.StatusNotFound, APIError{Error: "No interval sampled yet"})
+		return
+	}
+	c.JSON(http.StatusOK, interval)
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Waterfall flows are derived from rates and estimates that are sampled at
+// slightly different moments, so a computed flow can go negative or a
+// stage can pass on more than it received. sanitizeWaterfallFlows repairs
+// the Sankey links before they are served: negative flows are clamped to
+// zero, a stage's outgoing flows are scaled down to its inflow, and links
+// left empty are dropped. Every change is listed in the metadata.
+
+// Adjustment reasons
+const (
+	flowNegative      = "negative"       // Raw flow below zero, clamped
+	flowExceedsInflow = "exceeds_inflow" // Scaled down with the stage's other outflows
+)
+
+// FlowAdjustment is one link changed by sanitization
+type FlowAdjustment struct {
+	Source   string  `json:"source"`
+	Target   string  `json:"target"`
+	Raw      float64 `json:"raw"`
+	Adjusted float64 `json:"adjusted"`
+	Reason   string  `json:"reason,omitempty"` // negative or exceeds_inflow
+}
+
+// WaterfallNodeBalance is a stage's inflow and outflow before and after sanitization
+type WaterfallNodeBalance struct {
+	ID        string  `json:"id"`
+	RawIn     float64 `json:"raw_in"`
+	RawOut    float64 `json:"raw_out"`
+	In        float64 `json:"in"`
+	Out       float64 `json:"out"`
+	Remaining float64 `json:"remaining"` // In minus out, still in the stage; zero for sources and sinks
+}
+
+// WaterfallFlowReport is the body of /api/v1/waterfall/debug
+type WaterfallFlowReport struct {
+	GeneratedAt time.Time              `json:"generated_at"`
+	Source      string                 `json:"source"`
+	Links       []FlowAdjustment       `json:"links"` // Every link; no reason when unchanged
+	Nodes       []WaterfallNodeBalance `json:"nodes"`
+	Adjusted    int                    `json:"adjusted"`
+}
+
+// flowLink is a link being sanitized
+type flowLink struct {
+	source, target string
+	raw, value     float64
+	reason         string
+}
+
+// sanitizeWaterfallFlows repairs the waterfall's links in place, annotates
+// the metadata with the adjustments and returns raw and adjusted flows
+func sanitizeWaterfallFlows(waterfall map[string]interface{}) WaterfallFlowReport {
+	raw, _ := waterfall["links"].([]map[string]interface{})
+	links := make([]*flowLink, 0, len(raw))
+	for _, link := range raw {
+		source, _ := link["source"].(string)
+		target, _ := link["target"].(string)
+		value := numberValue(link["value"])
+		l := &flowLink{source: source, target: target, raw: value, value: value}
+		if value < 0 {
+			l.value, l.reason = 0, flowNegative
+		}
+		links = append(links, l)
+	}
+
+	// Visit stages upstream first, so a stage's inflow is final before its
+	// outflows are checked against it
+	for _, node := range flowOrder(links) {
+		var in, out float64
+		incoming := false
+		for _, l := range links {
+			if l.target == node {
+				in += l.value
+				incoming = true
+			}
+			if l.source == node {
+				out += l.value
+			}
+		}
+		if !incoming || out <= in {
+			continue
+		}
+		scaleFlows(links, node, math.Floor(in), out)
+	}
+
+	report := WaterfallFlowReport{GeneratedAt: time.Now().UTC(), Links: make([]FlowAdjustment, 0, len(links))}
+	sanitized := make([]map[string]interface{}, 0, len(links))
+	adjustments := make([]FlowAdjustment, 0)
+	balances := make(map[string]*WaterfallNodeBalance)
+	var order []string
+	sides := make(map[string]int) // Bit 1: has inflow, bit 2: has outflow
+	balance := func(id string) *WaterfallNodeBalance {
+		if b, ok := balances[id]; ok {
+			return b
+		}
+		balances[id] = &WaterfallNodeBalance{ID: id}
+		order = append(order, id)
+		return balances[id]
+	}
+	for _, l := range links {
+		entry := FlowAdjustment{Source: l.source, Target: l.target, Raw: l.raw, Adjusted: l.value, Reason: l.reason}
+		report.Links = append(report.Links, entry)
+		if l.reason != "" && l.value != l.raw {
+			adjustments = append(adjustments, entry)
+		}
+		if l.value > 0 {
+			sanitized = append(sanitized, map[string]interface{}{
+				"id":     waterfallLinkID(l.source, l.target),
+				"source": l.source,
+				"target": l.target,
+				"value":  int64(l.value),
+			})
+		}
+		src, dst := balance(l.source), balance(l.target)
+		sides[l.source] |= 2
+		sides[l.target] |= 1
+		src.RawOut += l.raw
+		src.Out += l.value
+		dst.RawIn += l.raw
+		dst.In += l.value
+	}
+	for _, id := range order {
+		b := balances[id]
+		if sides[id] == 3 {
+			b.Remaining = b.In - b.Out
+		}
+		report.Nodes = append(report.Nodes, *b)
+	}
+	report.Adjusted = len(adjustments)
+
+	waterfall["links"] = sanitized
+	if metadata, ok := waterfall["metadata"].(map[string]interface{}); ok {
+		report.Source, _ = metadata["source"].(string)
+		metadata["flows_adjusted"] = len(adjustments)
+		if len(adjustments) > 0 {
+			metadata["flow_adjustments"] = adjustments
+		}
+	}
+	return report
+}
+
+// scaleFlows scales node's outgoing flows from out down to a whole total
+// of in, handing the units lost to rounding to the largest remainders
+func scaleFlows(links []*flowLink, node string, in, out float64) {
+	var outgoing []*flowLink
+	remainders := make(map[*flowLink]float64)
+	left := in
+	for _, l := range links {
+		if l.source != node || l.value <= 0 {
+			continue
+		}
+		scaled := l.value * in / out
+		l.value = math.Floor(scaled)
+		if l.reason == "" {
+			l.reason = flowExceedsInflow
+		}
+		remainders[l] = scaled - l.value
+		left -= l.value
+		outgoing = append(outgoing, l)
+	}
+	sort.SliceStable(outgoing, func(i, j int) bool { return remainders[outgoing[i]] > remainders[outgoing[j]] })
+	for i := 0; left >= 1 && i < len(outgoing); i++ {
+		outgoing[i].value++
+		left--
+	}
+}
+
+// flowOrder returns the link graph's nodes upstream first (Kahn's
+// algorithm); nodes on a cycle are left out
+func flowOrder(links []*flowLink) []string {
+	indegree := make(map[string]int)
+	var nodes []string
+	seen := make(map[string]bool)
+	for _, l := range links {
+		for _, id := range []string{l.source, l.target} {
+			if !seen[id] {
+				seen[id] = true
+				nodes = append(nodes, id)
+			}
+		}
+		indegree[l.target]++
+	}
+
+	var order, ready []string
+	for _, id := range nodes {
+		if indegree[id] == 0 {
+			ready = append(ready, id)
+		}
+	}
+	for len(ready) > 0 {
+		id := ready[0]
+		ready = ready[1:]
+		order = append(order, id)
+		for _, l := range links {
+			if l.source == id {
+				if indegree[l.target]--; indegree[l.target] == 0 {
+					ready = append(ready, l.target)
+				}
+			}
+		}
+	}
+	return order
+}
+
+// handleWaterfallFlowDebug generates the lifecycle waterfall and reports
+// every link's raw and sanitized flow and each stage's balance
+func handleWaterfallFlowDebug(c *gin.Context) {
+	c.JSON(http.StatusOK, sanitizeWaterfallFlows(generateMonadWaterfallFromSources()))
+}
@@ -0,0 +1,625 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Webhook event types
+const (
+	webhookBlockFinalized = "block.finalized"
+	webhookEpochRollover  = "epoch.rollover"
+	webhookAlertFired     = "alert.fired"    // A component became degraded or down
+	webhookAlertResolved  = "alert.resolved" // A component recovered
+	webhookTest           = "webhook.test"   // Sent by POST /api/v1/webhooks/:id/test only
+)
+
+// webhookEvents are the events a webhook can subscribe to
+var webhookEvents = []string{webhookBlockFinalized, webhookEpochRollover, webhookAlertFired, webhookAlertResolved}
+
+// Delivery states
+const (
+	deliveryPending   = "pending"
+	deliveryRetrying  = "retrying"
+	deliveryDelivered = "delivered"
+	deliveryFailed    = "failed"
+)
+
+// Deliveries kept per webhook for /api/v1/webhooks/:id
+const webhookDeliveryHistory = 50
+
+// Webhook is a registered receiver and its event filters
+type Webhook struct {
+	ID          string    `json:"id"`
+	URL         string    `json:"url"`
+	Events      []string  `json:"events"`
+	Components  []string  `json:"components,omitempty"`   // Alert events only for these components
+	EveryBlocks uint64    `json:"every_blocks,omitempty"` // block.finalized only for multiples of N
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// WebhookStats counts one webhook's deliveries since startup
+type WebhookStats struct {
+	Delivered int64 `json:"delivered"`
+	Failed    int64 `json:"failed"`
+	Retries   int64 `json:"retries"`
+	Dropped   int64 `json:"dropped"` // Queue full
+}
+
+// WebhookDelivery is the status of one event sent to one webhook
+type WebhookDelivery struct {
+	ID            string     `json:"id"`
+	Event         string     `json:"event"`
+	Status        string     `json:"status"` // pending, retrying, delivered, failed
+	Attempts      int        `json:"attempts"`
+	ResponseCode  int        `json:"response_code,omitempty"`
+	Error         string     `json:"error,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	LastAttemptAt *time.Time `json:"last_attempt_at,omitempty"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"`
+}
+
+// WebhookPayload is the signed JSON body POSTed to receivers
+type WebhookPayload struct {
+	ID        string      `json:"id"` // Delivery ID, stable across retries
+	Event     string      `json:"event"`
+	Chain     string      `json:"chain"`
+	Timestamp time.Time   `json:"timestamp"`
+	Data      interface{} `json:"data"`
+}
+
+// FinalizedBlockEvent is the data of block.finalized
+type FinalizedBlockEvent struct {
+	Number      uint64    `json:"number"`
+	Hash        string    `json:"hash"`
+	TxCount     int       `json:"tx_count"`
+	FinalizedAt time.Time `json:"finalized_at"`
+}
+
+// EpochRolloverEvent is the data of epoch.rollover
+type EpochRolloverEvent struct {
+	Epoch         int64 `json:"epoch"`
+	PreviousEpoch int64 `json:"previous_epoch"`
+	Block         int64 `json:"block"` // First block seen in the new epoch
+}
+
+// AlertEvent is the data of alert.fired and alert.resolved
+type AlertEvent struct {
+	Component string    `json:"component"`
+	State     string    `json:"state"`
+	Previous  string    `json:"previous"`
+	Reason    string    `json:"reason,omitempty"`
+	At        time.Time `json:"at"`
+}
+
+// webhookEntry is a registered webhook with its secret and delivery state
+type webhookEntry struct {
+	Webhook
+	Secret string `json:"secret"`
+
+	stats      WebhookStats
+	deliveries []*WebhookDelivery // Oldest first
+}
+
+// webhookJob is one delivery attempt waiting for a worker
+type webhookJob struct {
+	entry    *webhookEntry
+	delivery *WebhookDelivery
+	body     []byte
+}
+
+// WebhookDispatcher stores webhook registrations in WEBHOOKS_PATH and POSTs
+// HMAC-signed events to them from a worker pool, retrying failed deliveries
+// with exponential backoff
+type WebhookDispatcher struct {
+	path        string
+	client      *http.Client
+	maxAttempts int
+	retryBase   time.Duration
+	jobs        chan *webhookJob
+
+	mu    sync.Mutex
+	hooks map[string]*webhookEntry
+}
+
+// Global webhook dispatcher instance
+var (
+	webhookDispatcher   *WebhookDispatcher
+	webhookDispatcherMu sync.RWMutex
+)
+
+// InitializeWebhooks loads registered webhooks from WEBHOOKS_PATH (default
+// webhooks.json) and starts WEBHOOK_WORKERS delivery workers. Failed
+// deliveries are attempted up to WEBHOOK_MAX_ATTEMPTS times.
+func InitializeWebhooks() *WebhookDispatcher {
+	path := os.Getenv("WEBHOOKS_PATH")
+	if path == "" {
+		path = "webhooks.json"
+	}
+	workers, queue, attempts := int64(4), int64(256), int64(5)
+	if n, ok := positiveIntEnv("WEBHOOK_WORKERS"); ok {
+		workers = n
+	}
+	if n, ok := positiveIntEnv("WEBHOOK_QUEUE"); ok {
+		queue = n
+	}
+	if n, ok := positiveIntEnv("WEBHOOK_MAX_ATTEMPTS"); ok {
+		attempts = n
+	}
+
+	d := &WebhookDispatcher{
+		path:        path,
+		client:      &http.Client{Timeout: 10 * time.Second},
+		maxAttempts: int(attempts),
+		retryBase:   time.Second,
+		jobs:        make(chan *webhookJob, queue),
+		hooks:       make(map[string]*webhookEntry),
+	}
+	if err := d.load(); err != nil {
+		log.Printf("Webhooks %s not loaded: %v", path, err)
+	}
+	for i := int64(0); i < workers; i++ {
+		go d.worker()
+	}
+
+	webhookDispatcherMu.Lock()
+	webhookDispatcher = d
+	webhookDispatcherMu.Unlock()
+
+	log.Printf("✅ Webhooks initialized (%d registered, %d workers)", len(d.hooks), workers)
+	return d
+}
+
+// GetWebhooks returns the global webhook dispatcher
+func GetWebhooks() *WebhookDispatcher {
+	webhookDispatcherMu.RLock()
+	defer webhookDispatcherMu.RUnlock()
+	return webhookDispatcher
+}
+
+// publishWebhookEvent sends an event to every matching webhook, if enabled
+func publishWebhookEvent(event string, data interface{}) {
+	if d := GetWebhooks(); d != nil {
+		d.Publish(event, data)
+	}
+}
+
+// load reads registrations saved by a previous run
+func (d *WebhookDispatcher) load() error {
+	raw, err := os.ReadFile(d.path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	var entries []*webhookEntry
+	if err := json.Unmarshal(raw, &entries); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		d.hooks[entry.ID] = entry
+	}
+	return nil
+}
+
+// saveLocked writes every registration to disk; caller holds d.mu
+func (d *WebhookDispatcher) saveLocked() error {
+	entries := make([]*webhookEntry, 0, len(d.hooks))
+	for _, entry := range d.hooks {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].CreatedAt.Before(entries[j].CreatedAt) })
+
+	raw, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := d.path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, d.path)
+}
+
+// containsString reports whether list contains s
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// randomID returns n random bytes, hex-encoded
+func randomID(n int) string {
+	buf := make([]byte, n)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// Register validates and stores a webhook, returning it with its signing secret
+func (d *WebhookDispatcher) Register(hook Webhook) (*webhookEntry, error) {
+	parsed, err := url.Parse(hook.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, fmt.Errorf("url must be an absolute http(s) URL")
+	}
+	if len(hook.Events) == 0 {
+		hook.Events = append([]string(nil), webhookEvents...)
+	}
+	for _, event := range hook.Events {
+		if !containsString(webhookEvents, event) {
+			return nil, fmt.Errorf("unknown event %q", event)
+		}
+	}
+
+	hook.ID = randomID(8)
+	hook.CreatedAt = time.Now().UTC()
+	entry := &webhookEntry{Webhook: hook, Secret: randomID(32)}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.hooks[entry.ID] = entry
+	if err := d.saveLocked(); err != nil {
+		log.Printf("Failed to save webhooks: %v", err)
+	}
+	log.Printf("Webhook %s registered for %v → %s", entry.ID, entry.Events, entry.URL)
+	return entry, nil
+}
+
+// Remove deletes a webhook, reporting whether it existed. Queued
+// deliveries to it are discarded.
+func (d *WebhookDispatcher) Remove(id string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, ok := d.hooks[id]; !ok {
+		return false
+	}
+	delete(d.hooks, id)
+	if err := d.saveLocked(); err != nil {
+		log.Printf("Failed to save webhooks: %v", err)
+	}
+	return true
+}
+
+// matches reports whether a webhook wants an event
+func (e *webhookEntry) matches(event string, data interface{}) bool {
+	if !containsString(e.Events, event) {
+		return false
+	}
+	switch v := data.(type) {
+	case FinalizedBlockEvent:
+		return e.EveryBlocks == 0 || v.Number%e.EveryBlocks == 0
+	case AlertEvent:
+		return len(e.Components) == 0 || containsString(e.Components, v.Component)
+	}
+	return true
+}
+
+// Publish queues an event for every matching webhook. It never blocks:
+// when the queue is full the delivery is recorded as failed.
+func (d *WebhookDispatcher) Publish(event string, data interface{}) {
+	d.mu.Lock()
+	var targets []*webhookEntry
+	for _, entry := range d.hooks {
+		if entry.matches(event, data) {
+			targets = append(targets, entry)
+		}
+	}
+	d.mu.Unlock()
+
+	for _, entry := range targets {
+		d.enqueue(entry, event, data)
+	}
+}
+
+// enqueue creates a delivery of event to one webhook and queues its first attempt
+func (d *WebhookDispatcher) enqueue(entry *webhookEntry, event string, data interface{}) *WebhookDelivery {
+	delivery := &WebhookDelivery{
+		ID:        randomID(8),
+		Event:     event,
+		Status:    deliveryPending,
+		CreatedAt: time.Now().UTC(),
+	}
+	body, _ := json.Marshal(WebhookPayload{
+		ID:        delivery.ID,
+		Event:     event,
+		Chain:     primaryChainName(),
+		Timestamp: delivery.CreatedAt,
+		Data:      data,
+	})
+
+	d.mu.Lock()
+	entry.deliveries = append(entry.deliveries, delivery)
+	if len(entry.deliveries) > webhookDeliveryHistory {
+		entry.deliveries = entry.deliveries[len(entry.deliveries)-webhookDeliveryHistory:]
+	}
+	d.mu.Unlock()
+
+	d.submit(&webhookJob{entry: entry, delivery: delivery, body: body})
+	return delivery
+}
+
+// submit hands a job to the workers without blocking
+func (d *WebhookDispatcher) submit(job *webhookJob) {
+	select {
+	case d.jobs <- job:
+	default:
+		d.mu.Lock()
+		job.delivery.Status = deliveryFailed
+		job.delivery.Error = "delivery queue full"
+		job.delivery.NextAttemptAt = nil
+		job.entry.stats.Dropped++
+		d.mu.Unlock()
+	}
+}
+
+func (d *WebhookDispatcher) worker() {
+	for job := range d.jobs {
+		d.attempt(job)
+	}
+}
+
+// attempt POSTs a job once and schedules a retry if it failed
+func (d *WebhookDispatcher) attempt(job *webhookJob) {
+	d.mu.Lock()
+	_, registered := d.hooks[job.entry.ID]
+	d.mu.Unlock()
+	if !registered {
+		return
+	}
+
+	code, err := d.post(job)
+	now := time.Now().UTC()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delivery := job.delivery
+	delivery.Attempts++
+	delivery.LastAttemptAt = &now
+	delivery.NextAttemptAt = nil
+	delivery.ResponseCode = code
+	delivery.Error = ""
+	if err == nil {
+		delivery.Status = deliveryDelivered
+		job.entry.stats.Delivered++
+		return
+	}
+	delivery.Error = err.Error()
+
+	// A 4xx other than timeout/rate limiting will fail the same way again
+	permanent := code >= 400 && code < 500 && code != http.StatusRequestTimeout && code != http.StatusTooManyRequests
+	if permanent || delivery.Attempts >= d.maxAttempts {
+		delivery.Status = deliveryFailed
+		job.entry.stats.Failed++
+		log.Printf("Webhook %s delivery %s (%s) failed after %d attempts: %v",
+			job.entry.ID, delivery.ID, delivery.Event, delivery.Attempts, err)
+		return
+	}
+
+	backoff := d.retryBase << uint(delivery.Attempts-1)
+	if backoff > 5*time.Minute {
+		backoff = 5 * time.Minute
+	}
+	next := now.Add(backoff)
+	delivery.Status = deliveryRetrying
+	delivery.NextAttemptAt = &next
+	job.entry.stats.Retries++
+	time.AfterFunc(backoff, func() { d.submit(job) })
+}
+
+// post sends one signed request. The signature is the hex HMAC-SHA256 of
+// "<timestamp>.<body>" keyed with the webhook's secret, so receivers can
+// reject replays by checking X-Webhook-Timestamp.
+func (d *WebhookDispatcher) post(job *webhookJob) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, job.entry.URL, bytes.NewReader(job.body))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(job.entry.Secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(job.body)
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "monad-dashboard-webhooks")
+	req.Header.Set("X-Webhook-Event", job.delivery.Event)
+	req.Header.Set("X-Webhook-Delivery", job.delivery.ID)
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("receiver returned %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// WebhookStatus is a webhook with its delivery counters and, for
+// /api/v1/webhooks/:id, its recent deliveries (newest first)
+type WebhookStatus struct {
+	Webhook
+	Stats      WebhookStats      `json:"stats"`
+	Deliveries []WebhookDelivery `json:"deliveries,omitempty"`
+}
+
+// status snapshots a webhook; caller holds d.mu
+func (e *webhookEntry) status(withDeliveries bool) WebhookStatus {
+	status := WebhookStatus{Webhook: e.Webhook, Stats: e.stats}
+	if withDeliveries {
+		status.Deliveries = make([]WebhookDelivery, 0, len(e.deliveries))
+		for i := len(e.deliveries) - 1; i >= 0; i-- {
+			status.Deliveries = append(status.Deliveries, *e.deliveries[i])
+		}
+	}
+	return status
+}
+
+// List returns every webhook, oldest first
+func (d *WebhookDispatcher) List() []WebhookStatus {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	list := make([]WebhookStatus, 0, len(d.hooks))
+	for _, entry := range d.hooks {
+		list = append(list, entry.status(false))
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.Before(list[j].CreatedAt) })
+	return list
+}
+
+// Get returns one webhook with its recent deliveries
+func (d *WebhookDispatcher) Get(id string) (WebhookStatus, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	entry, ok := d.hooks[id]
+	if !ok {
+		return WebhookStatus{}, false
+	}
+	return entry.status(true), true
+}
+
+// Test queues a webhook.test delivery regardless of the webhook's filters
+func (d *WebhookDispatcher) Test(id string) (WebhookDelivery, bool) {
+	d.mu.Lock()
+	entry, ok := d.hooks[id]
+	d.mu.Unlock()
+	if !ok {
+		return WebhookDelivery{}, false
+	}
+	delivery := d.enqueue(entry, webhookTest, map[string]string{"message": "Test delivery from the Monad dashboard"})
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return *delivery, true
+}
+
+// WebhooksResponse is the body of /api/v1/webhooks
+type WebhooksResponse struct {
+	Webhooks []WebhookStatus `json:"webhooks"`
+	Events   []string        `json:"events"` // Events a webhook can subscribe to
+}
+
+// WebhookCreateRequest is the body of POST /api/v1/webhooks
+type WebhookCreateRequest struct {
+	URL         string   `json:"url"`
+	Events      []string `json:"events,omitempty"`       // Default every event
+	Components  []string `json:"components,omitempty"`   // Filter alert events by component
+	EveryBlocks uint64   `json:"every_blocks,omitempty"` // Send every Nth finalized block
+}
+
+// WebhookCreatedResponse is a new webhook with the secret its payloads are
+// signed with. The secret is not shown again.
+type WebhookCreatedResponse struct {
+	WebhookStatus
+	Secret string `json:"secret"`
+}
+
+// WebhookRemoveResponse echoes the removed webhook ID
+type WebhookRemoveResponse struct {
+	Removed string `json:"removed"`
+}
+
+// handleWebhooksList returns every registered webhook
+func handleWebhooksList(c *gin.Context) {
+	d := GetWebhooks()
+	if d == nil {
+		c.JSON(http.StatusServiceUnavailable, APIError{Error: "Webhooks not enabled"})
+		return
+	}
+	c.JSON(http.StatusOK, WebhooksResponse{Webhooks: d.List(), Events: webhookEvents})
+}
+
+// handleWebhookCreate registers a webhook
+func handleWebhookCreate(c *gin.Context) {
+	d := GetWebhooks()
+	if d == nil {
+		c.JSON(http.StatusServiceUnavailable, APIError{Error: "Webhooks not enabled"})
+		return
+	}
+
+	var req WebhookCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, APIError{Error: "Expected {\"url\": \"https://...\", \"events\": [...]}"})
+		return
+	}
+	entry, err := d.Register(Webhook{
+		URL:         req.URL,
+		Events:      req.Events,
+		Components:  req.Components,
+		EveryBlocks: req.EveryBlocks,
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, APIError{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, WebhookCreatedResponse{
+		WebhookStatus: WebhookStatus{Webhook: entry.Webhook},
+		Secret:        entry.Secret,
+	})
+}
+
+// handleWebhookGet returns one webhook with its recent deliveries
+func handleWebhookGet(c *gin.Context) {
+	d := GetWebhooks()
+	if d == nil {
+		c.JSON(http.StatusServiceUnavailable, APIError{Error: "Webhooks not enabled"})
+		return
+	}
+	status, ok := d.Get(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, APIError{Error: "Webhook not found"})
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+// handleWebhookRemove deletes a webhook
+func handleWebhookRemove(c *gin.Context) {
+	d := GetWebhooks()
+	if d == nil {
+		c.JSON(http.StatusServiceUnavailable, APIError{Error: "Webhooks not enabled"})
+		return
+	}
+	if !d.Remove(c.Param("id")) {
+		c.JSON(http.StatusNotFound, APIError{Error: "Webhook not found"})
+		return
+	}
+	c.JSON(http.StatusOK, WebhookRemoveResponse{Removed: c.Param("id")})
+}
+
+// handleWebhookTest sends a webhook.test event to a webhook
+func handleWebhookTest(c *gin.Context) {
+	d := GetWebhooks()
+	if d == nil {
+		c.JSON(http.StatusServiceUnavailable, APIError{Error: "Webhooks not enabled"})
+		return
+	}
+	delivery, ok := d.Test(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, APIError{Error: "Webhook not found"})
+		return
+	}
+	c.JSON(http.StatusAccepted, delivery)
+}
@@ -0,0 +1,379 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// Errors returned when validating session tokens
+var (
+	errTokenMissing   = errors.New("session token required")
+	errTokenMalformed = errors.New("malformed session token")
+	errTokenSignature = errors.New("invalid session token signature")
+	errTokenExpired   = errors.New("session token expired")
+)
+
+// How long a client may take to send its auth message after connecting
+const wsAuthTimeout = 10 * time.Second
+
+// SessionClaims is the signed payload of a session token
+type SessionClaims struct {
+	Subject   string `json:"sub"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// Expiry returns the claims' expiry time
+func (s SessionClaims) Expiry() time.Time {
+	return time.Unix(s.ExpiresAt, 0)
+}
+
+// WSAuth issues and validates HMAC-signed session tokens for the realtime
+// stream. Auth is enabled only when WS_AUTH_SECRET is set.
+type WSAuth struct {
+	secret    []byte
+	issuerKey string        // Key required to mint tokens over HTTP (WS_AUTH_ISSUER_KEY)
+	ttl       time.Duration // Token lifetime (WS_AUTH_TTL)
+
+	mu       sync.Mutex
+	sessions map[*websocket.Conn]*wsSession
+}
+
+// wsSession tracks the current expiry of an authenticated connection
+type wsSession struct {
+	claims SessionClaims // Replaced on renewal; guarded by WSAuth.mu
+	renew  chan time.Time // New expiry after an in-band renewal
+}
+
+// Global WebSocket auth instance (nil when auth is disabled)
+var wsAuth *WSAuth
+
+// InitializeWSAuth enables session tokens if WS_AUTH_SECRET is set
+func InitializeWSAuth() {
+	secret := os.Getenv("WS_AUTH_SECRET")
+	if secret == "" {
+		log.Printf("WebSocket auth disabled (set WS_AUTH_SECRET to require session tokens)")
+		return
+	}
+
+	ttl := time.Hour
+	if value := os.Getenv("WS_AUTH_TTL"); value != "" {
+		if parsed, err := time.ParseDuration(value); err == nil && parsed > 0 {
+			ttl = parsed
+		} else {
+			log.Printf("Invalid WS_AUTH_TTL %q, using %s", value, ttl)
+		}
+	}
+
+	wsAuth = &WSAuth{
+		secret:    []byte(secret),
+		issuerKey: os.Getenv("WS_AUTH_ISSUER_KEY"),
+		ttl:       ttl,
+		sessions:  make(map[*websocket.Conn]*wsSession),
+	}
+	log.Printf("✅ WebSocket session tokens required (ttl %s)", ttl)
+}
+
+// GetWSAuth returns the global auth instance, or nil when auth is disabled
+func GetWSAuth() *WSAuth {
+	return wsAuth
+}
+
+// sign returns the base64url HMAC-SHA256 of payload
+func (a *WSAuth) sign(payload string) string {
+	mac := hmac.New(sha256.New, a.secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Issue mints a token for subject valid for the configured TTL
+func (a *WSAuth) Issue(subject string) (string, SessionClaims) {
+	now := time.Now()
+	claims := SessionClaims{
+		Subject:   subject,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(a.ttl).Unix(),
+	}
+
+	body, _ := json.Marshal(claims)
+	payload := base64.RawURLEncoding.EncodeToString(body)
+	return payload + "." + a.sign(payload), claims
+}
+
+// Validate checks a token's signature and expiry
+func (a *WSAuth) Validate(token string) (SessionClaims, error) {
+	var claims SessionClaims
+	if token == "" {
+		return claims, errTokenMissing
+	}
+
+	payload, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return claims, errTokenMalformed
+	}
+	if subtle.ConstantTimeCompare([]byte(signature), []byte(a.sign(payload))) != 1 {
+		return claims, errTokenSignature
+	}
+
+	body, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return claims, errTokenMalformed
+	}
+	if err := json.Unmarshal(body, &claims); err != nil {
+		return claims, errTokenMalformed
+	}
+	if time.Now().After(claims.Expiry()) {
+		return claims, errTokenExpired
+	}
+	return claims, nil
+}
+
+// Renew exchanges a valid token for a fresh one with the same subject
+func (a *WSAuth) Renew(token string) (string, SessionClaims, error) {
+	claims, err := a.Validate(token)
+	if err != nil {
+		return "", SessionClaims{}, err
+	}
+	renewed, renewedClaims := a.Issue(claims.Subject)
+	return renewed, renewedClaims, nil
+}
+
+// Authenticate validates the token from the ?token= query parameter, or
+// else waits for a first message {"topic":"auth","key":"login","params":{"token":...}}
+func (a *WSAuth) Authenticate(c *gin.Context, conn *websocket.Conn) (SessionClaims, error) {
+	token := c.Query("token")
+	if token == "" {
+		conn.SetReadDeadline(time.Now().Add(wsAuthTimeout))
+		_, message, err := conn.ReadMessage()
+		conn.SetReadDeadline(time.Time{})
+		if err != nil {
+			return SessionClaims{}, errTokenMissing
+		}
+
+		var login struct {
+			Topic  string `json:"topic"`
+			Key    string `json:"key"`
+			Params struct {
+				Token string `json:"token"`
+			} `json:"params"`
+		}
+		if err := json.Unmarshal(message, &login); err != nil || login.Topic != "auth" || login.Key != "login" {
+			return SessionClaims{}, errTokenMissing
+		}
+		token = login.Params.Token
+	}
+
+	claims, err := a.Validate(token)
+	if err != nil {
+		return claims, err
+	}
+
+	a.mu.Lock()
+	a.sessions[conn] = &wsSession{claims: claims, renew: make(chan time.Time, 1)}
+	a.mu.Unlock()
+	return claims, nil
+}
+
+// Release forgets a closed connection's session
+func (a *WSAuth) Release(conn *websocket.Conn) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.sessions, conn)
+}
+
+// session returns the session of an authenticated connection
+func (a *WSAuth) session(conn *websocket.Conn) *wsSession {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.sessions[conn]
+}
+
+// claims returns a session's current claims
+func (a *WSAuth) claims(session *wsSession) SessionClaims {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return session.claims
+}
+
+// EnforceExpiry closes conn when its session expires. Clients get an
+// "expiring" notice a minute ahead so they can renew in-band.
+func (a *WSAuth) EnforceExpiry(conn *websocket.Conn, done <-chan struct{}) {
+	session := a.session(conn)
+	if session == nil {
+		return
+	}
+
+	expiry := a.claims(session).Expiry()
+	for {
+		warnIn := time.Until(expiry) - time.Minute
+		if warnIn < 0 {
+			warnIn = 0
+		}
+		warn := time.NewTimer(warnIn)
+		expire := time.NewTimer(time.Until(expiry))
+
+		select {
+		case <-done:
+			warn.Stop()
+			expire.Stop()
+			return
+		case expiry = <-session.renew:
+			warn.Stop()
+			expire.Stop()
+			continue
+		case <-warn.C:
+			expire.Stop()
+			safeWriteJSON(conn, FiredancerMessage{
+				Topic: "auth",
+				Key:   "expiring",
+				Value: map[string]interface{}{"expires_at": expiry.Unix()},
+			})
+			// Wait for renewal or expiry
+			select {
+			case <-done:
+				return
+			case expiry = <-session.renew:
+				continue
+			case <-time.After(time.Until(expiry)):
+			}
+		case <-expire.C:
+			warn.Stop()
+		}
+
+		log.Printf("WebSocket session for %q expired", a.claims(session).Subject)
+		safeWriteJSON(conn, FiredancerMessage{Topic: "auth", Key: "expired"})
+		conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.ClosePolicyViolation, errTokenExpired.Error()),
+			time.Now().Add(time.Second))
+		conn.Close()
+		return
+	}
+}
+
+// handleAuthClientMessage handles {"topic":"auth","key":"renew","params":{"token":...}}
+func handleAuthClientMessage(conn *websocket.Conn, key string, params map[string]interface{}) {
+	a := GetWSAuth()
+	if a == nil || key != "renew" {
+		return
+	}
+
+	session := a.session(conn)
+	if session == nil {
+		return
+	}
+
+	token, _ := params["token"].(string)
+	renewed, claims, err := a.Renew(token)
+	if err != nil || claims.Subject != a.claims(session).Subject {
+		safeWriteJSON(conn, FiredancerMessage{
+			Topic: "auth",
+			Key:   "renew_failed",
+			Value: map[string]interface{}{"error": "invalid or expired token"},
+		})
+		return
+	}
+
+	a.mu.Lock()
+	session.claims = claims
+	a.mu.Unlock()
+	select {
+	case session.renew <- claims.Expiry():
+	default:
+	}
+
+	safeWriteJSON(conn, FiredancerMessage{
+		Topic: "auth",
+		Key:   "renewed",
+		Value: map[string]interface{}{"token": renewed, "expires_at": claims.ExpiresAt},
+	})
+}
+
+// WSTokenRequest is the optional body of POST /api/v1/ws/token
+type WSTokenRequest struct {
+	Subject string `json:"subject,omitempty"` // Default "dashboard"
+}
+
+// WSTokenRenewRequest is the body of POST /api/v1/ws/token/renew
+type WSTokenRenewRequest struct {
+	Token string `json:"token"`
+}
+
+// WSTokenResponse is an issued or renewed session token
+type WSTokenResponse struct {
+	Token     string `json:"token"`
+	Subject   string `json:"subject"`
+	ExpiresAt int64  `json:"expires_at"` // Unix seconds
+}
+
+// handleIssueToken mints a session token. Requires WS_AUTH_ISSUER_KEY as a
+// bearer token, so a hosting proxy can hand tokens to logged-in users.
+func handleIssueToken(c *gin.Context) {
+	a := GetWSAuth()
+	if a == nil {
+		c.JSON(http.StatusNotFound, APIError{Error: "WebSocket auth disabled"})
+		return
+	}
+	if a.issuerKey == "" {
+		c.JSON(http.StatusForbidden, APIError{Error: "Token issuance disabled (WS_AUTH_ISSUER_KEY not set)"})
+		return
+	}
+
+	provided := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(provided), []byte(a.issuerKey)) != 1 {
+		c.JSON(http.StatusUnauthorized, APIError{Error: "Invalid issuer key"})
+		return
+	}
+
+	var req WSTokenRequest
+	c.ShouldBindJSON(&req)
+	if req.Subject == "" {
+		req.Subject = "dashboard"
+	}
+
+	token, claims := a.Issue(req.Subject)
+	c.JSON(http.StatusOK, WSTokenResponse{
+		Token:     token,
+		Subject:   claims.Subject,
+		ExpiresAt: claims.ExpiresAt,
+	})
+}
+
+// handleRenewToken exchanges a valid, unexpired token for a fresh one
+func handleRenewToken(c *gin.Context) {
+	a := GetWSAuth()
+	if a == nil {
+		c.JSON(http.StatusNotFound, APIError{Error: "WebSocket auth disabled"})
+		return
+	}
+
+	var req WSTokenRenewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, APIError{Error: "Expected {\"token\": \"...\"}"})
+		return
+	}
+
+	token, claims, err := a.Renew(req.Token)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, APIError{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, WSTokenResponse{
+		Token:     token,
+		Subject:   claims.Subject,
+		ExpiresAt: claims.ExpiresAt,
+	})
+}
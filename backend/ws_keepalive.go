@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// WebSocket keepalive timing. Clients must answer pings (browsers do so
+// automatically); a connection silent for wsPongWait is considered dead.
+const (
+	wsWriteWait  = 10 * time.Second
+	wsPongWait   = 60 * time.Second
+	wsPingPeriod = 25 * time.Second // Must be less than wsPongWait
+
+	// Suggested client reconnect delay sent in close frames
+	wsReconnectAfter = 3 * time.Second
+)
+
+// Number of connections closed because they stopped responding or writes failed
+var wsReapedClients atomic.Uint64
+
+// wsCloseMessage builds a close frame whose reason is a JSON reconnect hint,
+// e.g. {"reason":"pong timeout","reconnect":true,"retry_after_ms":3000}
+func wsCloseMessage(code int, reason string, reconnect bool) []byte {
+	hint := map[string]interface{}{
+		"reason":    reason,
+		"reconnect": reconnect,
+	}
+	if reconnect {
+		hint["retry_after_ms"] = wsReconnectAfter.Milliseconds()
+	}
+	text, _ := json.Marshal(hint)
+	// Control frame payloads are limited to 125 bytes (2 for the code)
+	if len(text) > 123 {
+		text = text[:123]
+	}
+	return websocket.FormatCloseMessage(code, string(text))
+}
+
+// startWSKeepalive arms the read deadline, extends it on every pong and
+// pings the client until done is closed
+func startWSKeepalive(conn *websocket.Conn, done <-chan struct{}) {
+	conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+
+	go func() {
+		ticker := time.NewTicker(wsPingPeriod)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				// WriteControl is safe to call concurrently with WriteJSON
+				if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteWait)); err != nil {
+					reapWSClient(conn, "ping failed")
+					return
+				}
+			}
+		}
+	}()
+}
+
+// reapWSClient drops a dead or stuck connection from the registry and closes
+// it with a reconnect hint; its read loop then exits and cleans up the rest
+func reapWSClient(conn *websocket.Conn, reason string) {
+	if !unregisterWSClient(conn) {
+		return // Already reaped or disconnected
+	}
+	wsReapedClients.Add(1)
+	log.Printf("Reaping WebSocket client %s: %s", conn.RemoteAddr(), reason)
+
+	conn.WriteControl(websocket.CloseMessage,
+		wsCloseMessage(websocket.CloseGoingAway, reason, true),
+		time.Now().Add(wsWriteWait))
+	conn.Close()
+}
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WebSocket upgrades are not covered by the browser's same-origin policy,
+// so a page on any site could open the stream with the visitor's cookies or
+// network position. WS_ALLOWED_ORIGINS lists the origins allowed to upgrade
+// (comma-separated, e.g. "https://dash.example.org,https://*.example.org");
+// unset keeps the stream open to every origin. Requests without an Origin
+// header are not from a browser and are always allowed; a page is always
+// allowed on its own host. WS_UPGRADE_TOKENS=true additionally requires a
+// one-time ?upgrade_token= minted by POST /api/v1/ws/upgrade-token: a
+// cross-site page can send that POST but cannot read the token, since the
+// API sends no CORS headers.
+
+const (
+	// maxUpgradeTokens bounds the unused upgrade tokens kept; the oldest is
+	// dropped to make room for a new one
+	maxUpgradeTokens = 10000
+
+	// Tokens minted per client address: one a second, bursts of 10
+	upgradeTokenMintRate  = 1
+	upgradeTokenMintBurst = 10
+)
+
+// Upgrade rejection reasons
+const (
+	upgradeRejectOrigin  = "origin"
+	upgradeRejectMissing = "token_missing"
+	upgradeRejectInvalid = "token_invalid" // Unknown, used, expired or minted for another origin
+)
+
+// upgradeToken is an unused upgrade token
+type upgradeToken struct {
+	origin    string // Origin that minted it, empty for non-browser clients
+	expiresAt time.Time
+}
+
+// WSUpgradeGuard checks stream upgrades against the origin allowlist and
+// the upgrade tokens
+type WSUpgradeGuard struct {
+	origins       []string // Exact origins, "*." host wildcards or "*"
+	requireTokens bool
+	tokenTTL      time.Duration
+
+	mints *rateLimiter
+
+	mu     sync.Mutex
+	tokens map[string]upgradeToken
+	order  []string // Tokens in minting order, including redeemed ones
+
+	minted      atomic.Int64
+	evicted     atomic.Int64 // Dropped unused and unexpired to make room
+	rateLimited atomic.Int64
+	accepted    atomic.Int64
+	rejected    sync.Map // reason -> *atomic.Int64
+}
+
+// Global upgrade guard
+var (
+	wsUpgradeGuard     *WSUpgradeGuard
+	wsUpgradeGuardOnce sync.Once
+)
+
+// GetWSUpgradeGuard returns the global upgrade guard configured from
+// WS_ALLOWED_ORIGINS, WS_UPGRADE_TOKENS and WS_UPGRADE_TOKEN_TTL
+func GetWSUpgradeGuard() *WSUpgradeGuard {
+	wsUpgradeGuardOnce.Do(func() {
+		g := &WSUpgradeGuard{
+			requireTokens: os.Getenv("WS_UPGRADE_TOKENS") == "true",
+			tokenTTL:      30 * time.Second,
+			mints:         newRateLimiter(upgradeTokenMintRate, upgradeTokenMintBurst),
+			tokens:        make(map[string]upgradeToken),
+		}
+		for _, origin := range strings.Split(os.Getenv("WS_ALLOWED_ORIGINS"), ",") {
+			origin = strings.ToLower(strings.TrimRight(strings.TrimSpace(origin), "/"))
+			if origin != "" {
+				g.origins = append(g.origins, origin)
+			}
+		}
+		if value := os.Getenv("WS_UPGRADE_TOKEN_TTL"); value != "" {
+			if parsed, err := time.ParseDuration(value); err == nil && parsed > 0 {
+				g.tokenTTL = parsed
+			} else {
+				log.Printf("Invalid WS_UPGRADE_TOKEN_TTL %q, using %s", value, g.tokenTTL)
+			}
+		}
+		if len(g.origins) > 0 {
+			log.Printf("WebSocket upgrades allowed from %s", strings.Join(g.origins, ", "))
+		}
+		if g.requireTokens {
+			log.Printf("WebSocket upgrades require a one-time upgrade token (ttl %s)", g.tokenTTL)
+		}
+		wsUpgradeGuard = g
+	})
+	return wsUpgradeGuard
+}
+
+// originAllowed reports whether r's Origin may upgrade
+func (g *WSUpgradeGuard) originAllowed(r *http.Request) bool {
+	origin := strings.ToLower(r.Header.Get("Origin"))
+	if origin == "" || len(g.origins) == 0 {
+		return true
+	}
+	parsed, err := url.Parse(origin)
+	if err != nil || parsed.Host == "" {
+		return false
+	}
+	if strings.EqualFold(parsed.Host, r.Host) {
+		return true
+	}
+	for _, allowed := range g.origins {
+		switch {
+		case allowed == "*" || allowed == origin:
+			return true
+		case strings.Contains(allowed, "://*."):
+			// https://*.example.org matches subdomains on that scheme
+			scheme, suffix, _ := strings.Cut(allowed, "://*")
+			if parsed.Scheme == scheme && strings.HasSuffix(parsed.Hostname(), suffix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Mint issues a one-time upgrade token for origin
+func (g *WSUpgradeGuard) Mint(origin string, now time.Time) (string, time.Time) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		log.Printf("Failed to generate upgrade token: %v", err)
+		return "", time.Time{}
+	}
+	token := hex.EncodeToString(b)
+	expiresAt := now.Add(g.tokenTTL)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	// Tokens share one TTL, so the oldest expire first: drop redeemed and
+	// expired ones from the front, and the oldest live ones while full
+	for len(g.order) > 0 {
+		oldest := g.order[0]
+		entry, ok := g.tokens[oldest]
+		if ok && !now.After(entry.expiresAt) && len(g.tokens) < maxUpgradeTokens {
+			break
+		}
+		if ok {
+			if !now.After(entry.expiresAt) {
+				g.evicted.Add(1)
+			}
+			delete(g.tokens, oldest)
+		}
+		g.order = g.order[1:]
+	}
+	g.tokens[token] = upgradeToken{origin: strings.ToLower(origin), expiresAt: expiresAt}
+	g.order = append(g.order, token)
+	g.minted.Add(1)
+	return token, expiresAt
+}
+
+// redeem consumes a token, checking its expiry and origin
+func (g *WSUpgradeGuard) redeem(token, origin string, now time.Time) bool {
+	g.mu.Lock()
+	entry, ok := g.tokens[token]
+	delete(g.tokens, token)
+	g.mu.Unlock()
+	return ok && !now.After(entry.expiresAt) && entry.origin == strings.ToLower(origin)
+}
+
+// reject counts a refused upgrade
+func (g *WSUpgradeGuard) reject(reason string) {
+	counter, _ := g.rejected.LoadOrStore(reason, new(atomic.Int64))
+	counter.(*atomic.Int64).Add(1)
+}
+
+// authorizeWSUpgrade checks a stream upgrade request before it is
+// upgraded, answering 403 and returning false when it is refused
+func authorizeWSUpgrade(c *gin.Context) bool {
+	g := GetWSUpgradeGuard()
+	origin := c.GetHeader("Origin")
+	reason := ""
+	switch {
+	case !g.originAllowed(c.Request):
+		reason = upgradeRejectOrigin
+	case !g.requireTokens:
+	case c.Query("upgrade_token") == "":
+		reason = upgradeRejectMissing
+	case !g.redeem(c.Query("upgrade_token"), origin, time.Now()):
+		reason = upgradeRejectInvalid
+	}
+	if reason != "" {
+		g.reject(reason)
+		log.Printf("WebSocket upgrade from %s refused (%s, origin %q)", c.Request.RemoteAddr, reason, origin)
+		c.AbortWithStatusJSON(http.StatusForbidden, APIError{Error: "WebSocket upgrade refused: " + strings.ReplaceAll(reason, "_", " ")})
+		return false
+	}
+	g.accepted.Add(1)
+	return true
+}
+
+// WSUpgradeTokenResponse is the body of POST /api/v1/ws/upgrade-token
+type WSUpgradeTokenResponse struct {
+	Token     string `json:"token"`
+	ExpiresAt int64  `json:"expires_at"` // Unix seconds
+}
+
+// handleMintUpgradeToken issues a one-time upgrade token to an allowed origin
+func handleMintUpgradeToken(c *gin.Context) {
+	g := GetWSUpgradeGuard()
+	if !g.requireTokens {
+		c.JSON(http.StatusNotFound, APIError{Error: "Upgrade tokens disabled (WS_UPGRADE_TOKENS not set)"})
+		return
+	}
+	if !g.originAllowed(c.Request) {
+		g.reject(upgradeRejectOrigin)
+		c.JSON(http.StatusForbidden, APIError{Error: "Origin not allowed"})
+		return
+	}
+	now := time.Now()
+	if !g.mints.allow(clientKey(c), now) {
+		g.rateLimited.Add(1)
+		c.JSON(http.StatusTooManyRequests, APIError{Error: "Too many upgrade tokens requested, slow down"})
+		return
+	}
+	token, expiresAt := g.Mint(c.GetHeader("Origin"), now)
+	if token == "" {
+		c.JSON(http.StatusInternalServerError, APIError{Error: "Failed to generate upgrade token"})
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, WSUpgradeTokenResponse{Token: token, ExpiresAt: expiresAt.Unix()})
+}
+
+// WSUpgradeStats reports upgrade checks for /api/v1/ws-stats
+type WSUpgradeStats struct {
+	AllowedOrigins []string         `json:"allowed_origins"` // Empty allows every origin
+	RequireTokens  bool             `json:"require_tokens"`
+	Minted         int64            `json:"minted"`
+	Evicted        int64            `json:"evicted"`      // Unused tokens dropped for newer ones
+	RateLimited    int64            `json:"rate_limited"` // Mint requests over the per-client limit
+	Accepted       int64            `json:"accepted"`
+	Rejected       map[string]int64 `json:"rejected"` // By reason: origin, token_missing, token_invalid
+	PendingTokens  int              `json:"pending_tokens"`
+}
+
+// Stats returns the guard's configuration and counters
+func (g *WSUpgradeGuard) Stats() WSUpgradeStats {
+	stats := WSUpgradeStats{
+		AllowedOrigins: append([]string{}, g.origins...),
+		RequireTokens:  g.requireTokens,
+		Minted:         g.minted.Load(),
+		Evicted:        g.evicted.Load(),
+		RateLimited:    g.rateLimited.Load(),
+		Accepted:       g.accepted.Load(),
+		Rejected:       map[string]int64{upgradeRejectOrigin: 0, upgradeRejectMissing: 0, upgradeRejectInvalid: 0},
+	}
+	g.rejected.Range(func(reason, counter interface{}) bool {
+		stats.Rejected[reason.(string)] = counter.(*atomic.Int64).Load()
+		return true
+	})
+	g.mu.Lock()
+	stats.PendingTokens = len(g.tokens)
+	g.mu.Unlock()
+	return stats
+}
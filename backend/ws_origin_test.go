@@ -0,0 +1,34 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// TestUpgradeTokenEviction makes room for new tokens by dropping the oldest
+// instead of refusing to mint once the guard is full
+func TestUpgradeTokenEviction(t *testing.T) {
+	g := &WSUpgradeGuard{tokenTTL: time.Minute, tokens: make(map[string]upgradeToken)}
+	now := time.Unix(1700000000, 0)
+
+	first, _ := g.Mint("", now)
+	var last string
+	for i := 1; i <= maxUpgradeTokens; i++ {
+		last, _ = g.Mint("", now)
+	}
+	if len(g.tokens) != maxUpgradeTokens || g.evicted.Load() != 1 {
+		t.Fatalf("%d tokens kept, %d evicted", len(g.tokens), g.evicted.Load())
+	}
+	if g.redeem(first, "", now) {
+		t.Fatal("evicted token redeemed")
+	}
+	if !g.redeem(last, "", now) || g.redeem(last, "", now) {
+		t.Fatal("newest token not redeemable exactly once")
+	}
+
+	// Expired tokens are dropped without counting as evicted
+	g.Mint("", now.Add(2*time.Minute))
+	if len(g.tokens) != 1 || g.evicted.Load() != 1 || len(g.order) != 1 {
+		t.Fatalf("%d tokens, %d queued, %d evicted after expiry", len(g.tokens), len(g.order), g.evicted.Load())
+	}
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"monad-dashboard/hexutil"
+)
+
+// WebSocket queries are id-correlated request/response frames on the stream:
+//
+//	-> {"topic": "query", "key": "rpc_block", "id": 7, "params": {"number": 12345}}
+//	<- {"topic": "query", "key": "rpc_block", "id": 7, "value": {...}}
+//	<- {"topic": "query", "key": "error", "id": 7, "value": {"query": "rpc_block", "error": "..."}}
+//
+// Every GraphQL root field is available as a query, plus the ones below.
+var wsQueryResolvers = map[string]func(args map[string]interface{}) (interface{}, error){
+	"rpc_block":    resolveWSRPCBlock,
+	"history":      resolveWSHistory,
+	"tx_lifecycle": resolveWSTxLifecycle,
+	"graphql":      resolveWSGraphQL,
+}
+
+// wsQuerySlots bounds queries running concurrently across all connections
+var wsQuerySlots = make(chan struct{}, 32)
+
+// handleQueryClientMessage runs a query and replies with the same id
+func handleQueryClientMessage(conn *websocket.Conn, key string, id *int, params map[string]interface{}) {
+	if id == nil {
+		safeWriteJSON(conn, FiredancerMessage{
+			Topic: "query",
+			Key:   "error",
+			Value: map[string]interface{}{"query": key, "error": "query requires an id"},
+		})
+		return
+	}
+
+	resolver, ok := wsQueryResolvers[key]
+	if !ok {
+		resolver, ok = dashboardSchema.Query[key]
+	}
+	if !ok {
+		replyWSQueryError(conn, key, id, fmt.Errorf("unknown query %q", key))
+		return
+	}
+
+	// Run off the read loop; queries may call the node RPC
+	go func() {
+		select {
+		case wsQuerySlots <- struct{}{}:
+			defer func() { <-wsQuerySlots }()
+		case <-time.After(5 * time.Second):
+			replyWSQueryError(conn, key, id, fmt.Errorf("server busy"))
+			return
+		}
+
+		if params == nil {
+			params = map[string]interface{}{}
+		}
+		result, err := resolver(params)
+		if err != nil {
+			replyWSQueryError(conn, key, id, err)
+			return
+		}
+		safeWriteJSON(conn, FiredancerMessage{Topic: "query", Key: key, ID: id, Value: result})
+	}()
+}
+
+// replyWSQueryError sends a query error frame
+func replyWSQueryError(conn *websocket.Conn, key string, id *int, err error) {
+	safeWriteJSON(conn, FiredancerMessage{
+		Topic: "query",
+		Key:   "error",
+		ID:    id,
+		Value: map[string]interface{}{"query": key, "error": err.Error()},
+	})
+}
+
+// resolveWSRPCBlock fetches block details from the node (through the block
+// cache) by "number" or "hash", with receipt-based execution stats when known
+func resolveWSRPCBlock(args map[string]interface{}) (interface{}, error) {
+	hash, err := stringArg(args, "hash", "")
+	if err != nil {
+		return nil, err
+	}
+	number, err := intArg(args, "number", -1)
+	if err != nil {
+		return nil, err
+	}
+
+	var block *RPCBlock
+	switch {
+	case hash != "":
+		block, err = GetMonadClient().GetBlockByHash(hash)
+	case number >= 0:
+		block, err = GetMonadClient().GetBlockByNumber(int64(number))
+	default:
+		return nil, fmt.Errorf("argument \"number\" or \"hash\" is required")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string]interface{}{"block": block}
+	if stats, ok := GetExecStatsTracker().ForBlock(hexutil.Int64OrZero(block.Number)); ok {
+		result["execution_stats"] = stats
+	}
+	return result, nil
+}
+
+// resolveWSHistory returns points of a recorded series ("series", "seconds", "max_points")
+func resolveWSHistory(args map[string]interface{}) (interface{}, error) {
+	store := GetHistoryStore()
+	if store == nil {
+		return nil, fmt.Errorf("history store not initialized")
+	}
+
+	series, err := stringArg(args, "series", "")
+	if err != nil {
+		return nil, err
+	}
+	if series == "" {
+		return store.SeriesNames(), nil
+	}
+	seconds, err := intArg(args, "seconds", 300)
+	if err != nil {
+		return nil, err
+	}
+	maxPoints, err := intArg(args, "max_points", 500)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	return store.Query(series, now.Add(-time.Duration(seconds)*time.Second), now, maxPoints), nil
+}
+
+// resolveWSTxLifecycle returns the lifecycle record of a transaction ("hash")
+func resolveWSTxLifecycle(args map[string]interface{}) (interface{}, error) {
+	hash, err := stringArg(args, "hash", "")
+	if err != nil {
+		return nil, err
+	}
+	record, ok := GetTxLifecycleCorrelator().Get(hash)
+	if !ok {
+		return nil, fmt.Errorf("transaction not tracked")
+	}
+	return map[string]interface{}{
+		"lifecycle": record,
+		"durations": record.Durations(),
+	}, nil
+}
+
+// resolveWSGraphQL runs a GraphQL query ("query", "variables") over the socket
+func resolveWSGraphQL(args map[string]interface{}) (interface{}, error) {
+	query, err := stringArg(args, "query", "")
+	if err != nil {
+		return nil, err
+	}
+	if query == "" {
+		return nil, fmt.Errorf("argument \"query\" is required")
+	}
+	variables, _ := args["variables"].(map[string]interface{})
+	return dashboardSchema.Execute(query, variables), nil
+}
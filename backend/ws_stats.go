@@ -0,0 +1,178 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Per-topic WebSocket statistics: every frame written to a client is
+// counted against its topic/key, and every encode is timed, so operators
+// can see which messages dominate bandwidth when tuning cadences.
+
+// wsStatsWindow is the number of one-second buckets rates are averaged over
+const wsStatsWindow = 10
+
+// wsStatsBucket counts the frames written during one second
+type wsStatsBucket struct {
+	second   int64 // Unix seconds
+	messages int64
+	bytes    int64
+}
+
+// wsTopicCounters accumulates one topic/key's frames and encodes
+type wsTopicCounters struct {
+	topic      string
+	key        string
+	messages   int64
+	bytes      int64
+	encodes    int64
+	encodeTime time.Duration
+	buckets    [wsStatsWindow]wsStatsBucket
+}
+
+var wsStats = struct {
+	sync.Mutex
+	topics map[string]*wsTopicCounters // "topic/key"
+	since  time.Time
+}{topics: make(map[string]*wsTopicCounters), since: time.Now()}
+
+// wsStatsKey returns the topic and key statistics are kept under
+func wsStatsKey(msg interface{}) (topic, key string) {
+	topic, key, _, _, ok := messageParts(unwrapMessage(msg))
+	if !ok {
+		return "unknown", ""
+	}
+	return topic, key
+}
+
+// wsTopic returns the counters for a topic/key; caller holds wsStats
+func wsTopic(topic, key string) *wsTopicCounters {
+	slot := topic + "/" + key
+	counters, ok := wsStats.topics[slot]
+	if !ok {
+		counters = &wsTopicCounters{topic: topic, key: key}
+		wsStats.topics[slot] = counters
+	}
+	return counters
+}
+
+// recordWSWrite counts a frame of size bytes written to one client
+func recordWSWrite(msg interface{}, size int) {
+	topic, key := wsStatsKey(msg)
+	second := time.Now().Unix()
+
+	wsStats.Lock()
+	defer wsStats.Unlock()
+	counters := wsTopic(topic, key)
+	counters.messages++
+	counters.bytes += int64(size)
+
+	bucket := &counters.buckets[second%wsStatsWindow]
+	if bucket.second != second {
+		*bucket = wsStatsBucket{second: second}
+	}
+	bucket.messages++
+	bucket.bytes += int64(size)
+}
+
+// recordWSEncode times one encode of msg; shared messages are encoded once
+// per protocol adapter however many clients receive them
+func recordWSEncode(msg interface{}, took time.Duration) {
+	topic, key := wsStatsKey(msg)
+
+	wsStats.Lock()
+	defer wsStats.Unlock()
+	counters := wsTopic(topic, key)
+	counters.encodes++
+	counters.encodeTime += took
+}
+
+// WSTopicStats is one topic/key's outbound traffic
+type WSTopicStats struct {
+	Topic          string  `json:"topic"`
+	Key            string  `json:"key"`
+	MessagesPerSec float64 `json:"messages_per_sec"` // Frames written to clients, averaged over the window
+	BytesPerSec    float64 `json:"bytes_per_sec"`
+	AvgEncodeMs    float64 `json:"avg_encode_ms"`
+	Messages       int64   `json:"messages"` // Since startup
+	Bytes          int64   `json:"bytes"`
+	Encodes        int64   `json:"encodes"`
+}
+
+// WSStatsResponse is the body of /api/v1/ws-stats
+type WSStatsResponse struct {
+	WindowSeconds  int            `json:"window_seconds"`
+	Clients        int            `json:"clients"`
+	MessagesPerSec float64        `json:"messages_per_sec"`
+	BytesPerSec    float64        `json:"bytes_per_sec"`
+	Topics         []WSTopicStats `json:"topics"` // Most bytes per second first
+	Resume         ResumeStats    `json:"resume"`
+	Upgrade        WSUpgradeStats `json:"upgrade"` // Origin allowlist and upgrade token checks
+}
+
+// wsStatsSnapshot reports per-topic rates over the last complete window
+func wsStatsSnapshot(now time.Time) WSStatsResponse {
+	current := now.Unix()
+
+	wsStats.Lock()
+	// Average over the window's complete seconds, or the seconds since
+	// startup when shorter
+	window := int64(wsStatsWindow - 1)
+	if elapsed := current - wsStats.since.Unix(); elapsed < window {
+		window = elapsed
+	}
+	if window < 1 {
+		window = 1
+	}
+
+	response := WSStatsResponse{WindowSeconds: int(window), Topics: make([]WSTopicStats, 0, len(wsStats.topics))}
+	for _, counters := range wsStats.topics {
+		var messages, bytes int64
+		for _, bucket := range counters.buckets {
+			if bucket.second < current && bucket.second >= current-window {
+				messages += bucket.messages
+				bytes += bucket.bytes
+			}
+		}
+		stats := WSTopicStats{
+			Topic:          counters.topic,
+			Key:            counters.key,
+			MessagesPerSec: float64(messages) / float64(window),
+			BytesPerSec:    float64(bytes) / float64(window),
+			Messages:       counters.messages,
+			Bytes:          counters.bytes,
+			Encodes:        counters.encodes,
+		}
+		if counters.encodes > 0 {
+			stats.AvgEncodeMs = float64(counters.encodeTime.Microseconds()) / 1000 / float64(counters.encodes)
+		}
+		response.MessagesPerSec += stats.MessagesPerSec
+		response.BytesPerSec += stats.BytesPerSec
+		response.Topics = append(response.Topics, stats)
+	}
+	wsStats.Unlock()
+
+	sort.Slice(response.Topics, func(i, j int) bool {
+		a, b := response.Topics[i], response.Topics[j]
+		if a.BytesPerSec != b.BytesPerSec {
+			return a.BytesPerSec > b.BytesPerSec
+		}
+		return a.Bytes > b.Bytes
+	})
+
+	wsClientsMu.RLock()
+	response.Clients = len(wsClients)
+	wsClientsMu.RUnlock()
+	response.Resume = GetResumeStore().Stats()
+	response.Upgrade = GetWSUpgradeGuard().Stats()
+	return response
+}
+
+// handleWSStats reports outbound WebSocket traffic per topic/key
+func handleWSStats(c *gin.Context) {
+	c.JSON(http.StatusOK, wsStatsSnapshot(time.Now()))
+}